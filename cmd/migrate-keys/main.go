@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	"github.com/dhanavadh/fastfill-backend/internal/config"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+)
+
+// renameFlags collects repeated -rename old=new flags.
+type renameFlags map[string]string
+
+func (r renameFlags) String() string {
+	pairs := make([]string, 0, len(r))
+	for oldKey, newKey := range r {
+		pairs = append(pairs, oldKey+"="+newKey)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (r renameFlags) Set(value string) error {
+	oldKey, newKey, ok := strings.Cut(value, "=")
+	if !ok || oldKey == "" || newKey == "" {
+		return fmt.Errorf("expected old=new, got %q", value)
+	}
+	r[oldKey] = newKey
+	return nil
+}
+
+func main() {
+	renames := renameFlags{}
+	templateID := flag.String("template", "", "ID of the template whose DataKeys are renamed")
+	dryRun := flag.Bool("dry-run", false, "Show what would be updated without making changes")
+	flag.Var(renames, "rename", "DataKey rename as old=new (repeatable)")
+	flag.Parse()
+
+	if *templateID == "" || len(renames) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: migrate-keys -template <id> -rename old=new [-rename old=new ...] [-dry-run]")
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	// Initialize database
+	if err := internal.InitDB(cfg); err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer internal.CloseDB()
+
+	if *dryRun {
+		log.Println("Running in DRY RUN mode - no changes will be made")
+	}
+
+	templateService := services.NewTemplateService()
+	migration, err := templateService.MigrateDataKeys(*templateID, renames, *dryRun)
+	if err != nil {
+		log.Fatal("Failed to migrate data keys:", err)
+	}
+
+	for oldKey, newKey := range migration.Renames {
+		log.Printf("  %s -> %s", oldKey, newKey)
+	}
+	log.Printf("Fields: %d, submissions: %d, integrations: %d, duplicate policy: %t",
+		migration.FieldsUpdated, migration.SubmissionsUpdated, migration.IntegrationsUpdated, migration.DuplicateKeys)
+	if !*dryRun {
+		log.Printf("Migration completed successfully! Template is now at version %d", migration.Version)
+	}
+}
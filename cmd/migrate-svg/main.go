@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	"github.com/dhanavadh/fastfill-backend/internal/config"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+	"github.com/dhanavadh/fastfill-backend/internal/storage"
+)
+
+func main() {
+	templateID := flag.String("template", "", "Only migrate this template")
+	dryRun := flag.Bool("dry-run", false, "Show what would be migrated without making changes")
+	flag.Parse()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	// Initialize database
+	if err := internal.InitDB(cfg); err != nil {
+		log.Fatal("Failed to initialize database:", err)
+	}
+	defer internal.CloseDB()
+
+	var storageClient storage.StorageClient
+	if cfg.Storage.Backend == "s3" {
+		storageClient, err = storage.NewS3Client(storage.S3Options{
+			Endpoint:        cfg.Storage.S3.Endpoint,
+			BucketName:      cfg.Storage.S3.BucketName,
+			Region:          cfg.Storage.S3.Region,
+			AccessKeyID:     cfg.Storage.S3.AccessKeyID,
+			SecretAccessKey: cfg.Storage.S3.SecretAccessKey,
+			UseSSL:          cfg.Storage.S3.UseSSL,
+		})
+		if err != nil {
+			log.Fatal("Failed to initialize S3 client:", err)
+		}
+	} else {
+		gcsClient, err := storage.NewGCSClient(cfg.GCS.BucketName, cfg.GCS.CredentialsPath)
+		if err != nil {
+			log.Fatal("Failed to initialize GCS client:", err)
+		}
+		gcsClient.KMSKeyName = cfg.GCS.KMSKeyName
+		storageClient = gcsClient
+	}
+	defer storageClient.Close()
+
+	if *dryRun {
+		log.Println("Running in DRY RUN mode - no changes will be made")
+	}
+
+	uploadService := services.NewUploadService(storageClient)
+	migrations, err := uploadService.MigrateLegacyBackgrounds(context.Background(), *templateID, *dryRun)
+	if err != nil {
+		log.Fatal("Failed to migrate SVG backgrounds:", err)
+	}
+
+	failed := 0
+	for _, migration := range migrations {
+		if migration.Error != "" {
+			failed++
+			log.Printf("  %s: FAILED %s (%s)", migration.TemplateID, migration.Error, migration.Background)
+			continue
+		}
+		log.Printf("  %s: %s (%d bytes) -> page 0", migration.TemplateID, migration.Source, migration.Size)
+	}
+	log.Printf("Templates: %d, failed: %d", len(migrations), failed)
+	if failed == 0 && !*dryRun {
+		log.Println("Migration completed successfully! Set SVG_FILES_ONLY=true to stop reading svgBackground.")
+	}
+}
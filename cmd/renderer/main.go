@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/config"
+	"github.com/dhanavadh/fastfill-backend/internal/renderer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The renderer runs headless Chrome on behalf of the API server (set
+// RENDERER_URL on the API to use it), so Chrome crashes and memory spikes
+// stay out of the API process and rendering can be scaled separately.
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load configuration:", err)
+	}
+
+	if cfg.Renderer.Token == "" {
+		log.Println("Warning: RENDERER_TOKEN is not set; the renderer accepts unauthenticated requests")
+	}
+
+	chrome := renderer.NewChrome(cfg.Renderer.Concurrency)
+	chrome.StartHealthChecks(context.Background(), time.Minute)
+	monitor := renderer.NewMonitor(chrome, cfg.Renderer.ChromeVersion)
+	monitor.Start(context.Background())
+
+	r := gin.Default()
+	renderer.NewServer(chrome, monitor, cfg.Renderer.Token, cfg.Renderer.Concurrency, cfg.Renderer.Timeout).Register(r)
+
+	log.Printf("Renderer starting on :%s (concurrency %d)", cfg.Renderer.Port, cfg.Renderer.Concurrency)
+	r.Run(":" + cfg.Renderer.Port)
+}
@@ -0,0 +1,11 @@
+package main
+
+import "github.com/dhanavadh/fastfill-backend/internal/handlers"
+
+// registerGenerationHooks is where a deployment plugs its own behavior into
+// PDF generation without changing the handlers: implement
+// handlers.PreRenderHook or handlers.PostRenderHook and register it here,
+// e.g. pdfHandler.AddPreRenderHook(brandingHook{}). Hooks run in the order
+// they are registered, for every generated PDF including background renders.
+func registerGenerationHooks(pdfHandler *handlers.PDFHandler) {
+}
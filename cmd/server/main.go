@@ -1,14 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os/exec"
+	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/dhanavadh/fastfill-backend/internal"
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
 	"github.com/dhanavadh/fastfill-backend/internal/config"
 	"github.com/dhanavadh/fastfill-backend/internal/handlers"
+	"github.com/dhanavadh/fastfill-backend/internal/middleware"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/renderer"
 	"github.com/dhanavadh/fastfill-backend/internal/services"
 	"github.com/dhanavadh/fastfill-backend/internal/storage"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -24,69 +34,504 @@ func main() {
 		log.Fatal("Failed to initialize database:", err)
 	}
 	defer internal.CloseDB()
+	internal.StartDBStatsLogger(context.Background(), cfg.Database.StatsInterval)
 
+	if cfg.PDF.ThaiDictionaryPath != "" {
+		if err := utils.LoadThaiDictionary(cfg.PDF.ThaiDictionaryPath); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+
+	// gcsClient stays nil with the S3 backend; it is kept for the
+	// GCS-only credential rotation.
+	var storageClient storage.StorageClient
 	var gcsClient *storage.GCSClient
-	if cfg.GCS.BucketName != "" {
+	switch cfg.Storage.Backend {
+	case "s3":
+		if cfg.Storage.S3.Endpoint == "" || cfg.Storage.S3.BucketName == "" {
+			log.Fatal("S3 endpoint and bucket name are required")
+		}
+		s3Client, err := storage.NewS3Client(storage.S3Options{
+			Endpoint:        cfg.Storage.S3.Endpoint,
+			BucketName:      cfg.Storage.S3.BucketName,
+			Region:          cfg.Storage.S3.Region,
+			AccessKeyID:     cfg.Storage.S3.AccessKeyID,
+			SecretAccessKey: cfg.Storage.S3.SecretAccessKey,
+			UseSSL:          cfg.Storage.S3.UseSSL,
+		})
+		if err != nil {
+			log.Fatal("Failed to initialize S3 client:", err)
+		}
+		storageClient = s3Client
+		log.Println("S3 client initialized successfully")
+		if cfg.GCS.KMSKeyName != "" {
+			log.Println("Warning: GCS_KMS_KEY_NAME is ignored with the S3 storage backend")
+		}
+	case "gcs":
+		if cfg.GCS.BucketName == "" {
+			log.Fatal("GCS bucket name is required")
+		}
 		gcsClient, err = storage.NewGCSClient(cfg.GCS.BucketName, cfg.GCS.CredentialsPath)
 		if err != nil {
 			log.Fatal("Failed to initialize GCS client:", err)
 		}
+		storageClient = gcsClient
 		log.Println("GCS client initialized successfully")
-	} else {
-		log.Fatal("GCS bucket name is required")
+		if cfg.GCS.KMSKeyName != "" {
+			if !storage.ValidKMSKeyName(cfg.GCS.KMSKeyName) {
+				log.Fatal("GCS_KMS_KEY_NAME must be projects/{project}/locations/{location}/keyRings/{ring}/cryptoKeys/{key}")
+			}
+			gcsClient.KMSKeyName = cfg.GCS.KMSKeyName
+			log.Printf("Encrypting new objects with KMS key %s", cfg.GCS.KMSKeyName)
+		}
+	default:
+		log.Fatalf("STORAGE_BACKEND must be gcs or s3, not %q", cfg.Storage.Backend)
 	}
 
 	templateService := services.NewTemplateService()
 	formService := services.NewFormService()
-	uploadService := services.NewUploadService(gcsClient)
+	uploadService := services.NewUploadService(storageClient)
+	uploadService.SignedURLTTL = cfg.GCS.SignedURLTTL
+	uploadService.MaxPDFSize = cfg.PDF.UploadMaxSize
+	if cfg.PDF.PageConverterBinary != "" {
+		if binary, err := exec.LookPath(cfg.PDF.PageConverterBinary); err != nil {
+			log.Printf("PDF template backgrounds disabled: %s not found", cfg.PDF.PageConverterBinary)
+		} else {
+			uploadService.PageConverter = services.NewPDFToCairoConverter(binary, cfg.PDF.PageDPI)
+		}
+	}
+	calibrationService := services.NewCalibrationService()
+	syncService := services.NewSyncService(storageClient, templateService)
+	integrationService := services.NewIntegrationService()
+	optionListService := services.NewOptionListService()
+	usageService := services.NewUsageService(cfg.GoogleVision.MonthlyCap, cfg.GoogleVision.CapAction, cfg.GoogleVision.CostPer1000)
+	sessionService := auth.NewSessionService(cfg.Auth.JWTSecret, cfg.Auth.TokenTTL)
+	ssoService := services.NewSSOService()
+	tokenService := auth.NewTokenService()
+	auditService := services.NewAuditService()
+	signatureService := services.NewSignatureService(storageClient)
+	stampService := services.NewStampService(storageClient)
+	loginThrottle := auth.NewLoginThrottle(cfg.Auth.MaxAttempts, cfg.Auth.LockoutBase, cfg.Auth.LockoutMaximum)
+	loginThrottle.OnLockout = func(key string, failures int, lockout time.Duration) {
+		log.Printf("Warning: authentication locked out for %s after %d failures (%s)", key, failures, lockout)
+		auditService.Record(&gormmodels.AuditLog{
+			Action:   "auth.lockout",
+			Resource: key,
+		})
+	}
+
+	domainService := services.NewDomainService(cfg.Server.BaseURL)
+	cdnService := services.NewCDNService(cfg.CDN.AssetHost, cfg.CDN.PurgeURL, cfg.CDN.PurgeToken)
+	uploadService.OnPageChanged = func(templateID string, pageIndex int) {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		if err := cdnService.PurgePage(ctx, templateID, pageIndex); err != nil {
+			log.Printf("Warning: failed to purge page %d of template %s from CDN: %v", pageIndex, templateID, err)
+		}
+	}
 
-	templateHandler := handlers.NewTemplateHandler(templateService, cfg)
-	formHandler := handlers.NewFormHandler(formService, templateService)
-	uploadHandler := handlers.NewUploadHandler(uploadService, templateService, cfg)
-	pdfHandler := handlers.NewPDFHandler(templateService, formService, uploadHandler)
-	legacyHandler := handlers.NewLegacyHandler(templateService)
+	templateHandler := handlers.NewTemplateHandler(templateService, formService, optionListService, cdnService, stampService, domainService, uploadService, cfg)
+	validationWebhookService := services.NewValidationWebhookService(cfg.Submissions.ValidationWebhookSecret)
+	fieldAccessService := services.NewFieldAccessService()
+	formHandler := handlers.NewFormHandler(formService, templateService, optionListService, signatureService, stampService, validationWebhookService, fieldAccessService)
+	previewService := services.NewPreviewService(storageClient, uploadService)
+	tempUploadService := services.NewTempUploadService(storageClient)
+	tempUploadService.TTL = cfg.GCS.TempUploadTTL
+	tempUploadService.MaxSize = cfg.GCS.TempUploadMaxSize
+	tempUploadService.StartSweeper(context.Background())
+	uploadHandler := handlers.NewUploadHandler(uploadService, templateService, previewService, tempUploadService, cfg)
+	diagnosticsService := services.NewDiagnosticsService(storageClient, cfg.Diagnostics.Prefix, cfg.Diagnostics.Retention, cfg.Diagnostics.Enabled)
+	diagnosticsService.StartPurger(context.Background(), time.Hour)
+	debugRecordingService := services.NewDebugRecordingService(cfg.Diagnostics.RecordingMaxWindow, cfg.Diagnostics.RecordingRetention)
+	debugRecordingService.StartPurger(context.Background(), time.Hour)
+	eventService := services.NewEventService()
+	expiryService := services.NewExpiryService()
+	expiryService.StartScheduler(context.Background(), cfg.Submissions.ExpiryInterval)
+	pdfOptimizer := services.NewPDFOptimizer(cfg.PDF.OptimizerBinary, cfg.PDF.OptimizeDPI)
+	var pdfRenderer renderer.Renderer
+	if cfg.Renderer.URL != "" {
+		pdfRenderer = renderer.NewClient(cfg.Renderer.URL, cfg.Renderer.Token)
+		log.Printf("Rendering PDFs with renderer at %s", cfg.Renderer.URL)
+	} else {
+		chrome := renderer.NewChrome(cfg.Renderer.PoolSize)
+		chrome.StartHealthChecks(context.Background(), time.Minute)
+		pdfRenderer = chrome
+	}
+	rendererMonitor := renderer.NewMonitor(pdfRenderer, cfg.Renderer.ChromeVersion)
+	rendererMonitor.Start(context.Background())
+	pdfHandler := handlers.NewPDFHandler(templateService, formService, uploadHandler, diagnosticsService, pdfOptimizer, pdfRenderer, signatureService, stampService, eventService)
+	pdfHandler.SVGFilesOnly = cfg.Server.SVGFilesOnly
+	pdfHandler.Deterministic = cfg.Renderer.Deterministic
+	pdfHandler.BulkWorkers = cfg.PDF.BulkWorkers
+	pdfHandler.Limits = handlers.GenerationLimits{
+		MaxPages:        cfg.PDF.MaxPages,
+		MaxHTMLBytes:    cfg.PDF.MaxHTMLSize,
+		MaxDataURIBytes: cfg.PDF.MaxDataURISize,
+	}
+	registerGenerationHooks(pdfHandler)
+	documentService := services.NewDocumentService(storageClient)
+	if cfg.PDF.TextExtractorBinary != "" {
+		if binary, err := exec.LookPath(cfg.PDF.TextExtractorBinary); err != nil {
+			log.Printf("Document text search disabled: %s not found", cfg.PDF.TextExtractorBinary)
+		} else {
+			documentService.TextExtractor = services.NewPDFToTextExtractor(binary)
+		}
+	}
+	regenerationService := services.NewRegenerationService(documentService, formService, pdfHandler.RenderSubmission)
+	generationCallbackService := services.NewGenerationCallbackService(documentService, formService, pdfHandler.RenderSubmission, cfg.PDF.CallbackSecret)
+	generationCallbackService.MaxAttempts = cfg.PDF.CallbackAttempts
+	generationCallbackService.Backoff = cfg.PDF.CallbackBackoff
+	generationCallbackService.StartSweeper(context.Background())
+	snapshotService := services.NewSnapshotService(templateService, pdfHandler.RenderSnapshot)
+	if cfg.Renderer.VerifySnapshots {
+		snapshotService.StartVerification(context.Background())
+	}
+	snapshotHandler := handlers.NewSnapshotHandler(snapshotService)
+	previewSessionService := services.NewPreviewSessionService(templateService, pdfHandler.ResolvePreviewTemplate, pdfHandler.RenderPreviewPage, cfg.Editor.PreviewSessionTTL, cfg.Editor.PreviewDebounce)
+	previewSessionHandler := handlers.NewPreviewSessionHandler(previewSessionService, templateService, signatureService, stampService)
+	documentSearchHandler := handlers.NewDocumentSearchHandler(documentService)
+	regenerationHandler := handlers.NewRegenerationHandler(regenerationService, documentService, formService, templateService)
+	generationCallbackHandler := handlers.NewGenerationCallbackHandler(generationCallbackService, formService, templateService)
+	legacyHandler := handlers.NewLegacyHandler(templateService, uploadService)
+	calibrationHandler := handlers.NewCalibrationHandler(calibrationService, templateService)
+	syncHandler := handlers.NewSyncHandler(syncService, cfg)
+	integrationHandler := handlers.NewIntegrationHandler(integrationService, formService, templateService, optionListService, signatureService, stampService, validationWebhookService)
+	fillLinkHandler := handlers.NewFillLinkHandler(services.NewFillLinkService(), formService, templateService, optionListService, signatureService, stampService, validationWebhookService)
+	assignmentHandler := handlers.NewAssignmentHandler(services.NewAssignmentService(), formService, templateService)
+	securityReviewHandler := handlers.NewSecurityReviewHandler(services.NewSecurityReviewService(uploadService), templateService)
+	importHandler := handlers.NewImportHandler(services.NewImportService(), formService, optionListService, signatureService, stampService, validationWebhookService)
+	ssoHandler := handlers.NewSSOHandler(ssoService, sessionService, auditService, loginThrottle, cfg)
+	tokenHandler := handlers.NewTokenHandler(tokenService, auditService)
+	signatureHandler := handlers.NewSignatureHandler(signatureService, auditService)
+	stampHandler := handlers.NewStampHandler(stampService)
+	shareHandler := handlers.NewShareHandler(services.NewShareService(syncService), auditService)
+	legalHoldHandler := handlers.NewLegalHoldHandler(services.NewLegalHoldService(), formService, documentService, templateService, auditService)
+	domainHandler := handlers.NewDomainHandler(domainService, auditService)
+	debugRecordingHandler := handlers.NewDebugRecordingHandler(debugRecordingService, auditService, cfg.Diagnostics.SupportAPIKey)
+	eventHandler := handlers.NewEventHandler(eventService)
+	ocrService := services.NewOCRService(cfg.GoogleVision.APIKey, cfg.GoogleVision.Endpoint, cfg.GoogleVision.FallbackBinary)
+	ocrHandler := handlers.NewOCRHandler(ocrService, usageService, cfg.GoogleVision.MaxUploadSize)
+	suggestionHandler := handlers.NewSuggestionHandler(services.NewSuggestionService(cfg.Submissions.SuggestionExcludedKeys), templateService)
+	optionListHandler := handlers.NewOptionListHandler(optionListService)
+	usageHandler := handlers.NewUsageHandler(usageService)
+	publicPreviewHandler := handlers.NewPublicPreviewHandler(templateService, previewService, domainService)
+	editLockHandler := handlers.NewEditLockHandler(services.NewEditLockService(cfg.Editor.LockTTL), templateService)
+	numberingService := services.NewNumberingService()
+	numberingService.TTL = cfg.Submissions.NumberReservationTTL
+	numberingHandler := handlers.NewNumberingHandler(numberingService, templateService)
+	graphQLHandler := handlers.NewGraphQLHandler(templateHandler, templateService, formService, fieldAccessService)
+
+	// Settings that can change on reload; see applyReload.
+	origins := middleware.NewOrigins(cfg.Server.AllowOrigins)
+	previewLimiter := services.NewRateLimiter(cfg.Server.PublicPreviewLimit, time.Minute)
+	pageImageLimiter := services.NewRateLimiter(cfg.Server.PublicPreviewLimit*20, time.Minute)
+	var graphQLEnabled, metricsEnabled, ocrEnabled, authRequired atomic.Bool
+	authRequired.Store(cfg.Auth.Required)
+	graphQLEnabled.Store(cfg.Server.GraphQL)
+	metricsEnabled.Store(cfg.Server.Metrics)
+	ocrEnabled.Store(cfg.GoogleVision.APIKey != "")
+	if cfg.GoogleVision.APIKey == "" {
+		log.Printf("OCR disabled: GOOGLE_VISION_API_KEY is not set")
+	}
+
+	watcher := config.NewWatcher(cfg)
+	watcher.OnReload(func(previous, current *config.Config) []string {
+		var changes []string
+		if !slices.Equal(previous.Server.AllowOrigins, current.Server.AllowOrigins) {
+			origins.Set(current.Server.AllowOrigins)
+			changes = append(changes, "FRONTEND_URL_1, FRONTEND_URL_2")
+		}
+		if previous.Server.PublicPreviewLimit != current.Server.PublicPreviewLimit {
+			previewLimiter.SetLimit(current.Server.PublicPreviewLimit)
+			pageImageLimiter.SetLimit(current.Server.PublicPreviewLimit * 20)
+			changes = append(changes, "PUBLIC_PREVIEW_RATE_LIMIT")
+		}
+		if previous.Server.GraphQL != current.Server.GraphQL {
+			graphQLEnabled.Store(current.Server.GraphQL)
+			changes = append(changes, "GRAPHQL_ENABLED")
+		}
+		if previous.Server.Metrics != current.Server.Metrics {
+			metricsEnabled.Store(current.Server.Metrics)
+			changes = append(changes, "METRICS_ENABLED")
+		}
+		if previous.Auth.Required != current.Auth.Required {
+			authRequired.Store(current.Auth.Required)
+			changes = append(changes, "AUTH_REQUIRED")
+		}
+		if previous.Server.AdminAPIKey != current.Server.AdminAPIKey {
+			changes = append(changes, "ADMIN_API_KEY")
+		}
+		if previous.GoogleVision.APIKey != current.GoogleVision.APIKey {
+			ocrService.SetAPIKey(current.GoogleVision.APIKey)
+			ocrEnabled.Store(current.GoogleVision.APIKey != "")
+			changes = append(changes, "GOOGLE_VISION_API_KEY")
+		}
+		if gcsClient != nil {
+			if rotated, err := gcsClient.RotateCredentials(current.GCS.CredentialsPath); err != nil {
+				log.Printf("Warning: failed to rotate GCS credentials, keeping the current ones: %v", err)
+			} else if rotated {
+				changes = append(changes, "GCS_CREDENTIALS_PATH")
+			}
+		}
+		return changes
+	})
+	watcher.Start(context.Background(), cfg.Server.ConfigWatchInterval)
+	adminHandler := handlers.NewAdminHandler(watcher)
 
 	r := gin.Default()
 
 	corsConfig := cors.DefaultConfig()
-	corsConfig.AllowOrigins = cfg.Server.AllowOrigins
+	corsConfig.AllowOriginFunc = origins.Allow
 	corsConfig.AllowCredentials = true
+	corsConfig.ExposeHeaders = []string{"X-Total-Count"}
 	r.Use(cors.New(corsConfig))
+	r.Use(middleware.LocalizeErrors())
+
+	readTemplates := auth.RequireAuthorizedScope(auth.ScopeTemplatesRead, &authRequired)
+	writeTemplates := auth.RequireAuthorizedScope(auth.ScopeTemplatesWrite, &authRequired)
+	readForms := auth.RequireAuthorizedScope(auth.ScopeFormsRead, &authRequired)
+	writeForms := auth.RequireAuthorizedScope(auth.ScopeFormsWrite, &authRequired)
+	generatePDF := auth.RequireAuthorizedScope(auth.ScopePDFGenerate, &authRequired)
+	viewTemplate := middleware.RequireTemplateAccess(templateService, services.AccessView)
+	useTemplate := middleware.RequireTemplateAccess(templateService, services.AccessUse)
+	ownTemplate := middleware.RequireTemplateAccess(templateService, services.AccessOwner)
+	workspaceAdmin := auth.RequireRole(gormmodels.RoleAdmin)
+	recordDebug := middleware.RecordDebug(debugRecordingService)
+
+	// Requests get REQUEST_TIMEOUT unless they render, move files or call
+	// out to other services, such as validation webhooks on submit;
+	// streaming and all-template routes are unbounded.
+	timeouts := middleware.NewTimeouts(cfg.Server.RequestTimeout)
+	timeouts.Set(cfg.Server.GenerationTimeout,
+		"/api/generate-pdf", "/api/forms/:id/generate-pdf", "/api/templates/:id/font-check",
+		"/api/preview/session", "/api/preview/session/:id",
+		"/api/templates/:id/calibration/:pageIndex/sheet", "/api/templates/:id/test-print",
+		"/api/templates/:id/annotated-svg/:page",
+		"/api/templates/:id/calibration/:pageIndex/scan-compare",
+		"/api/templates/:id/snapshots", "/api/templates/:id/snapshots/verify",
+		"/api/templates/full", "/api/templates/from-form-svg", "/api/templates/:id/copy", "/api/templates/:id/clone",
+		"/api/templates/:id/security-review", "/api/templates/:id/migrate-keys",
+		"/api/upload/svg/:templateId", "/api/upload/svgs/:templateId", "/api/upload/pdf/:templateId", "/api/upload/svg/:templateId/attach",
+		"/api/uploads", "/api/files/svg/:templateId/page/:pageIndex", "/api/files/svg/:templateId",
+		"/api/svg/:templateId/:filename", "/api/templates/:id/forms/import",
+		"/api/stamps", "/api/me/signatures",
+		"/api/forms/submit", "/api/forms/:id", "/api/integrations/inbound/:token", "/public/fill/:token/submit",
+		"/api/sync/push", "/api/sync/pull", "/api/sync/templates/:id", "/api/sync/import",
+		"/public/templates/:id/preview", "/public/templates/:id/pages/:page")
+	timeouts.Set(cfg.Server.OCRTimeout, "/api/ocr/thai-id")
+	timeouts.Set(0,
+		"/api/templates/:id/forms/export.ndjson", "/api/templates/:id/forms/import.ndjson",
+		"/api/snapshots/verify", "/api/templates/:id/generate-pdfs")
+	requestTimeout := middleware.Timeout(timeouts)
 
 	api := r.Group("/api")
+	api.Use(requestTimeout, auth.Identify(sessionService, tokenService, loginThrottle))
 	{
-		api.GET("/templates", templateHandler.GetAll)
-		api.GET("/templates/:id", templateHandler.GetByID)
-		api.PUT("/templates/:id", templateHandler.Update)
-		api.DELETE("/templates/:id", templateHandler.Delete)
-		api.POST("/templates", templateHandler.Create)
-
-		api.POST("/upload/svg/:templateId", uploadHandler.UploadSVG)
-		api.DELETE("/upload/svg/:templateId/:svgFileId", uploadHandler.DeleteSVGFile)
-		api.GET("/templates/:id/svg", uploadHandler.GetSVG)
+		api.GET("/templates", readTemplates, templateHandler.GetAll)
+		api.GET("/templates/:id", readTemplates, viewTemplate, templateHandler.GetByID)
+		api.PUT("/templates/:id", writeTemplates, ownTemplate, templateHandler.Update)
+		api.DELETE("/templates/:id", writeTemplates, ownTemplate, templateHandler.Delete)
+		api.POST("/templates", writeTemplates, templateHandler.Create)
+		api.POST("/templates/full", writeTemplates, templateHandler.CreateFull)
+		api.POST("/templates/:id/archive", writeTemplates, ownTemplate, templateHandler.Archive)
+		api.POST("/templates/:id/unarchive", writeTemplates, ownTemplate, templateHandler.Unarchive)
+		api.POST("/templates/:id/impact", readTemplates, viewTemplate, templateHandler.AnalyzeImpact)
+		api.GET("/templates/:id/security-review", auth.RequireUser(), workspaceAdmin, readTemplates, viewTemplate, securityReviewHandler.Review)
+		api.POST("/templates/:id/migrate-keys", writeForms, ownTemplate, templateHandler.MigrateKeys)
+		api.GET("/templates/:id/field-graph", readTemplates, viewTemplate, templateHandler.GetFieldGraph)
+		api.GET("/templates/:id/data-schema", readTemplates, viewTemplate, templateHandler.GetDataSchema)
+		api.GET("/templates/:id/layout", readTemplates, viewTemplate, templateHandler.GetLayout)
+		api.PUT("/templates/:id/layout", writeTemplates, ownTemplate, templateHandler.PutLayout)
+		api.GET("/templates/:id/grid", readTemplates, viewTemplate, templateHandler.GetGrid)
+		api.PUT("/templates/:id/grid", writeTemplates, ownTemplate, templateHandler.PutGrid)
+		api.POST("/templates/:id/grid/snap", writeTemplates, ownTemplate, templateHandler.SnapToGrid)
+		api.POST("/templates/:id/fields/bulk-update", writeTemplates, ownTemplate, templateHandler.BulkUpdateFields)
+		api.GET("/templates/:id/lock", readTemplates, viewTemplate, editLockHandler.Get)
+		api.POST("/templates/:id/lock", writeTemplates, ownTemplate, editLockHandler.Acquire)
+		api.POST("/templates/:id/lock/heartbeat", writeTemplates, ownTemplate, editLockHandler.Heartbeat)
+		api.DELETE("/templates/:id/lock", writeTemplates, ownTemplate, editLockHandler.Release)
+		api.GET("/templates/:id/includes", readTemplates, viewTemplate, templateHandler.GetIncludes)
+		api.POST("/templates/:id/includes", writeTemplates, ownTemplate, templateHandler.AddInclude)
+		api.DELETE("/templates/:id/includes/:includeId", writeTemplates, ownTemplate, templateHandler.DeleteInclude)
+		api.GET("/templates/:id/shares", auth.RequireUser(), workspaceAdmin, ownTemplate, shareHandler.GetByTemplateID)
+		api.POST("/templates/:id/shares", auth.RequireUser(), workspaceAdmin, ownTemplate, shareHandler.Invite)
+		api.DELETE("/templates/:id/shares/:shareId", auth.RequireUser(), workspaceAdmin, ownTemplate, shareHandler.Revoke)
+		api.POST("/templates/:id/copy", auth.RequireUser(), writeTemplates, viewTemplate, shareHandler.Copy)
+		api.POST("/templates/:id/clone", writeTemplates, ownTemplate, shareHandler.Clone)
+
+		api.GET("/templates/:id/calibration", readTemplates, viewTemplate, calibrationHandler.GetByTemplateID)
+		api.PUT("/templates/:id/calibration/:pageIndex", writeTemplates, ownTemplate, calibrationHandler.Calibrate)
+		api.DELETE("/templates/:id/calibration/:pageIndex", writeTemplates, ownTemplate, calibrationHandler.Delete)
+		api.GET("/templates/:id/calibration/:pageIndex/sheet", readTemplates, viewTemplate, pdfHandler.CalibrationSheet)
+		api.GET("/templates/:id/test-print", readTemplates, viewTemplate, pdfHandler.TestPrint)
+		api.GET("/templates/:id/annotated-svg/:page", readTemplates, viewTemplate, pdfHandler.AnnotatedSVG)
+		api.POST("/templates/:id/calibration/:pageIndex/scan-compare", readTemplates, viewTemplate, pdfHandler.CompareScan)
+		api.POST("/templates/:id/calibration/:pageIndex/positions", writeTemplates, ownTemplate, calibrationHandler.ImportPositions)
+
+		api.POST("/upload/svg/:templateId", writeTemplates, ownTemplate, uploadHandler.UploadSVG)
+		api.POST("/upload/svgs/:templateId", writeTemplates, ownTemplate, uploadHandler.UploadSVGs)
+		api.POST("/upload/pdf/:templateId", writeTemplates, ownTemplate, uploadHandler.UploadPDF)
+		api.DELETE("/upload/svg/:templateId/:svgFileId", writeTemplates, ownTemplate, uploadHandler.DeleteSVGFile)
+		api.POST("/upload/svg/:templateId/attach", auth.RequireUser(), writeTemplates, ownTemplate, uploadHandler.AttachSVG)
+		api.POST("/uploads", auth.RequireUser(), uploadHandler.UploadTemp)
+		api.GET("/uploads", auth.RequireUser(), uploadHandler.ListTemp)
+		api.GET("/uploads/:id", auth.RequireUser(), uploadHandler.GetTemp)
+		api.DELETE("/uploads/:id", auth.RequireUser(), uploadHandler.DeleteTemp)
+		api.GET("/templates/:id/svg", viewTemplate, uploadHandler.GetSVG)
+		api.GET("/templates/:id/asset-urls", viewTemplate, uploadHandler.AssetURLs)
 		api.GET("/files/svg/:templateId/page/:pageIndex", uploadHandler.ServeSVGByPage)
 		api.GET("/files/svg/:templateId", uploadHandler.ServeSVG)
 		
 		// Legacy SVG route for PDF generation
 		api.GET("/svg/:templateId/:filename", uploadHandler.ServeLegacySVG)
 
-		api.POST("/forms/submit", formHandler.Submit)
-		api.GET("/forms/:id", formHandler.GetByID)
-		api.PUT("/forms/:id", formHandler.Update)
-		api.DELETE("/forms/:id", formHandler.Delete)
-		api.GET("/templates/:id/forms", formHandler.GetByTemplateID)
+		api.POST("/forms/submit", writeForms, formHandler.Submit)
+		api.GET("/forms/:id", readForms, formHandler.GetByID)
+		api.PUT("/forms/:id", writeForms, formHandler.Update)
+		api.DELETE("/forms/:id", writeForms, formHandler.Delete)
+		api.GET("/forms/:id/revisions", readForms, formHandler.Revisions)
+		api.GET("/forms/:id/revisions/diff", readForms, formHandler.DiffRevisions)
+		api.GET("/forms/:id/export", readForms, formHandler.Export)
+		api.GET("/forms/:id/assignment", auth.RequireUser(), readForms, assignmentHandler.Get)
+		api.PUT("/forms/:id/assignment", auth.RequireUser(), writeForms, assignmentHandler.Assign)
+		api.DELETE("/forms/:id/assignment", auth.RequireUser(), writeForms, assignmentHandler.Unassign)
+		api.POST("/forms/:id/assignment/complete", auth.RequireUser(), writeForms, assignmentHandler.Complete)
+		api.GET("/my-queue", auth.RequireUser(), readForms, assignmentHandler.MyQueue)
+		api.GET("/assignments/workload", auth.RequireUser(), readForms, assignmentHandler.Workload)
+		api.POST("/assignments/reassign", auth.RequireUser(), workspaceAdmin, writeForms, assignmentHandler.Reassign)
+		api.GET("/templates/:id/forms", readForms, ownTemplate, formHandler.GetByTemplateID)
+		api.GET("/templates/:id/forms/export.ndjson", readForms, ownTemplate, formHandler.ExportNDJSON)
+		api.POST("/templates/:id/forms/import.ndjson", writeForms, ownTemplate, formHandler.ImportNDJSON)
+		api.GET("/templates/:id/suggestions", readForms, useTemplate, suggestionHandler.GetSuggestions)
+
+		api.GET("/option-lists", readTemplates, optionListHandler.GetAll)
+		api.GET("/option-lists/:id", readTemplates, optionListHandler.GetByID)
+		api.POST("/option-lists", writeTemplates, optionListHandler.Create)
+		api.PUT("/option-lists/:id", writeTemplates, optionListHandler.Update)
+		api.DELETE("/option-lists/:id", writeTemplates, optionListHandler.Delete)
+
+		stamps := api.Group("/stamps", auth.RequireUser())
+		stamps.GET("", readTemplates, stampHandler.GetAll)
+		stamps.POST("", writeTemplates, stampHandler.Create)
+		stamps.GET("/:id/image", readTemplates, stampHandler.Image)
+		stamps.DELETE("/:id", writeTemplates, stampHandler.Delete)
+
+		api.POST("/templates/:id/integrations", writeTemplates, ownTemplate, integrationHandler.Create)
+		api.GET("/templates/:id/integrations", readTemplates, ownTemplate, integrationHandler.GetByTemplateID)
+		api.DELETE("/integrations/:id", writeTemplates, integrationHandler.Delete)
+		api.POST("/integrations/inbound/:token", integrationHandler.Inbound)
 
-		api.POST("/generate-pdf", pdfHandler.GeneratePDF)
-		api.POST("/forms/:id/generate-pdf", pdfHandler.GeneratePDFFromSubmission)
+		api.GET("/templates/:id/fill-links", readTemplates, ownTemplate, fillLinkHandler.GetByTemplateID)
+		api.POST("/templates/:id/fill-links", writeTemplates, ownTemplate, fillLinkHandler.Create)
+		api.DELETE("/templates/:id/fill-links/:linkId", writeTemplates, ownTemplate, fillLinkHandler.Delete)
+
+		api.GET("/templates/:id/import-profiles", readTemplates, ownTemplate, importHandler.GetProfiles)
+		api.POST("/templates/:id/import-profiles", writeTemplates, ownTemplate, importHandler.CreateProfile)
+		api.GET("/templates/:id/import-profiles/:profileId", readTemplates, ownTemplate, importHandler.GetProfile)
+		api.PUT("/templates/:id/import-profiles/:profileId", writeTemplates, ownTemplate, importHandler.UpdateProfile)
+		api.DELETE("/templates/:id/import-profiles/:profileId", writeTemplates, ownTemplate, importHandler.DeleteProfile)
+		api.POST("/templates/:id/forms/import", writeForms, useTemplate, importHandler.Import)
+
+		api.POST("/generate-pdf", generatePDF, recordDebug, pdfHandler.GeneratePDF)
+		api.POST("/preview/session", generatePDF, previewSessionHandler.Create)
+		api.POST("/preview/session/:id", generatePDF, previewSessionHandler.Update)
+		api.DELETE("/preview/session/:id", generatePDF, previewSessionHandler.Close)
+		api.POST("/templates/:id/font-check", readTemplates, useTemplate, pdfHandler.CheckFonts)
+		api.POST("/templates/:id/number/reserve", generatePDF, useTemplate, numberingHandler.Reserve)
+		api.POST("/templates/:id/number/:reservationId/confirm", generatePDF, useTemplate, numberingHandler.Confirm)
+		api.POST("/forms/:id/generate-pdf", generatePDF, recordDebug, pdfHandler.GeneratePDFFromSubmission)
+		api.POST("/templates/:id/generate-pdfs", generatePDF, readForms, useTemplate, pdfHandler.GenerateBulk)
+		api.POST("/forms/:id/generate-pdf/async", generatePDF, generationCallbackHandler.GenerateAsync)
+		api.GET("/generation-jobs/:id", readForms, generationCallbackHandler.GetJob)
+		api.GET("/forms/:id/document", readForms, regenerationHandler.GetDocument)
+		api.PUT("/forms/:id/legal-hold", auth.RequireUser(), writeForms, legalHoldHandler.HoldSubmission)
+		api.DELETE("/forms/:id/legal-hold", auth.RequireUser(), writeForms, legalHoldHandler.ReleaseSubmission)
+		api.GET("/documents/search", auth.RequireUser(), readForms, documentSearchHandler.Search)
+		api.PUT("/documents/:id/legal-hold", auth.RequireUser(), writeForms, legalHoldHandler.HoldDocument)
+		api.DELETE("/documents/:id/legal-hold", auth.RequireUser(), writeForms, legalHoldHandler.ReleaseDocument)
+		api.POST("/templates/:id/regenerate-documents", generatePDF, ownTemplate, regenerationHandler.Regenerate)
+		api.GET("/regeneration-jobs/:id", readForms, regenerationHandler.GetJob)
+		api.GET("/templates/:id/snapshots", readTemplates, viewTemplate, snapshotHandler.GetByTemplateID)
+		api.POST("/templates/:id/snapshots", writeTemplates, ownTemplate, snapshotHandler.Record)
+		api.POST("/templates/:id/snapshots/verify", writeTemplates, ownTemplate, snapshotHandler.Verify)
+		api.POST("/snapshots/verify", writeTemplates, snapshotHandler.VerifyAll)
+
+		api.POST("/sync/push", syncHandler.Push)
+		api.POST("/sync/pull", syncHandler.Pull)
+		api.GET("/sync/templates/:id", syncHandler.Export)
+		api.POST("/sync/import", syncHandler.Import)
+
+		api.POST("/workspaces", adminHandler.RequireKey(), ssoHandler.CreateWorkspace)
+		api.GET("/workspaces/:id/sso", auth.RequireUser(), workspaceAdmin, ssoHandler.GetConfig)
+		api.PUT("/workspaces/:id/sso", auth.RequireUser(), workspaceAdmin, ssoHandler.SaveConfig)
+		api.PUT("/workspaces/:id/vision-quota", auth.RequireUser(), workspaceAdmin, usageHandler.SetVisionQuota)
+		api.GET("/stats/usage", auth.RequireUser(), workspaceAdmin, usageHandler.GetUsage)
+		api.GET("/events", auth.RequireUser(), readForms, eventHandler.List)
+		api.GET("/auth/sso/:workspace/login", ssoHandler.Login)
+		api.GET("/auth/sso/:workspace/callback", ssoHandler.Callback)
+
+		me := api.Group("/me", auth.RequireUser())
+		me.GET("/tokens", tokenHandler.List)
+		me.POST("/tokens", tokenHandler.Create)
+		me.POST("/tokens/:id/rotate", tokenHandler.Rotate)
+		me.DELETE("/tokens/:id", tokenHandler.Revoke)
+		me.GET("/audit-logs", tokenHandler.AuditLogs)
+		me.GET("/signatures", signatureHandler.List)
+		me.POST("/signatures", signatureHandler.Create)
+		me.GET("/signatures/:id/image", signatureHandler.Image)
+		me.DELETE("/signatures/:id", signatureHandler.Invalidate)
+		me.GET("/template-shares", shareHandler.Incoming)
+		me.POST("/template-shares/:id/accept", workspaceAdmin, shareHandler.Accept)
+
+		workspace := api.Group("/workspace", auth.RequireUser())
+		workspace.GET("/domain", domainHandler.Get)
+		workspace.PUT("/domain", workspaceAdmin, domainHandler.Set)
+		workspace.POST("/domain/verify", workspaceAdmin, domainHandler.Verify)
+		workspace.DELETE("/domain", workspaceAdmin, domainHandler.Remove)
+		workspace.GET("/debug-recording", debugRecordingHandler.Get)
+		workspace.PUT("/debug-recording", workspaceAdmin, debugRecordingHandler.Enable)
+		workspace.DELETE("/debug-recording", workspaceAdmin, debugRecordingHandler.Disable)
+
+		api.GET("/support/workspaces/:id/recordings", debugRecordingHandler.List)
+		api.GET("/support/recordings/:id", debugRecordingHandler.GetRecording)
+
+		// OCR is only served with a Vision API key configured.
+		api.POST("/ocr/thai-id", middleware.RequireFeature(&ocrEnabled), writeForms, recordDebug, ocrHandler.ThaiID)
+
+		graphQL := middleware.RequireFeature(&graphQLEnabled)
+		// GraphQL checks the scope of each root field itself.
+		graphQLAuth := auth.RequireAuthenticated(&authRequired)
+		api.POST("/graphql", graphQL, graphQLAuth, graphQLHandler.Query)
+		api.GET("/graphql", graphQL, graphQLAuth, graphQLHandler.Query)
+		api.GET("/graphql/schema", graphQL, graphQLHandler.Schema)
+
+		api.POST("/admin/reload-config", adminHandler.ReloadConfig)
 
 		api.GET("/form-templates", legacyHandler.GetFormTemplates)
-		api.POST("/templates/from-form-svg", legacyHandler.CreateTemplateFromFormSVG)
+		api.POST("/templates/from-form-svg", writeTemplates, legacyHandler.CreateTemplateFromFormSVG)
 
 		api.GET("/health", func(c *gin.Context) {
-			c.JSON(200, gin.H{"status": "ok"})
+			status := "ok"
+			caps := rendererMonitor.Capabilities()
+			if caps != nil && !caps.Healthy {
+				status = "degraded"
+			}
+			c.JSON(200, gin.H{"status": status, "renderer": caps})
 		})
 	}
 
+	// Public previews are unauthenticated; thumbnails get a larger quota
+	// since one preview page loads an image per template page.
+	public := r.Group("/public", requestTimeout)
+	{
+		public.GET("/templates/:id/preview", middleware.RateLimit(previewLimiter), publicPreviewHandler.Preview)
+		public.GET("/templates/:id/pages/:page", middleware.RateLimit(pageImageLimiter), publicPreviewHandler.PageImage)
+		public.GET("/fill/:token", fillLinkHandler.Get)
+		public.POST("/fill/:token/submit", fillLinkHandler.Submit)
+	}
+
+	r.GET("/metrics", middleware.RequireFeature(&metricsEnabled), handlers.NewMetricsHandler(cfg.Database.DBName).Metrics)
+
 	r.Static("/static", "./static")
 
 	r.Use(func(c *gin.Context) {
@@ -0,0 +1,96 @@
+// Package auth identifies callers from session JWTs and personal access
+// tokens, limits tokens to their scopes and throttles failed attempts.
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const tokenIssuer = "fastfill"
+
+// SessionClaims are the claims carried by locally issued session tokens.
+type SessionClaims struct {
+	jwt.Claims
+	WorkspaceID string `json:"wid"`
+	Email       string `json:"email,omitempty"`
+	Role        string `json:"role"`
+}
+
+// SessionService issues and verifies the session JWTs handed out at sign-in.
+type SessionService struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewSessionService(secret string, ttl time.Duration) *SessionService {
+	return &SessionService{
+		secret: []byte(secret),
+		ttl:    ttl,
+	}
+}
+
+func (s *SessionService) Enabled() bool {
+	return len(s.secret) > 0
+}
+
+// IssueToken signs a session token for the given user.
+func (s *SessionService) IssueToken(user *gormmodels.User) (string, time.Time, error) {
+	if !s.Enabled() {
+		return "", time.Time{}, fmt.Errorf("JWT secret is not configured")
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: s.secret}, (&jose.SignerOptions{}).WithType("JWT"))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create signer: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	claims := SessionClaims{
+		Claims: jwt.Claims{
+			Issuer:   tokenIssuer,
+			Subject:  user.ID,
+			IssuedAt: jwt.NewNumericDate(now),
+			Expiry:   jwt.NewNumericDate(expiresAt),
+		},
+		WorkspaceID: user.WorkspaceID,
+		Email:       user.Email,
+		Role:        user.Role,
+	}
+
+	token, err := jwt.Signed(signer).Claims(claims).Serialize()
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
+	}
+
+	return token, expiresAt, nil
+}
+
+// ParseToken verifies a session token and returns its claims.
+func (s *SessionService) ParseToken(raw string) (*SessionClaims, error) {
+	if !s.Enabled() {
+		return nil, fmt.Errorf("JWT secret is not configured")
+	}
+
+	token, err := jwt.ParseSigned(raw, []jose.SignatureAlgorithm{jose.HS256})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token: %w", err)
+	}
+
+	var claims SessionClaims
+	if err := token.Claims(s.secret, &claims); err != nil {
+		return nil, fmt.Errorf("invalid token signature: %w", err)
+	}
+
+	if err := claims.Validate(jwt.Expected{Issuer: tokenIssuer, Time: time.Now()}); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	return &claims, nil
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// LoginThrottle tracks failed authentication attempts per key (client IP or
+// account) and locks a key out with exponential backoff once it exceeds the
+// allowed number of failures.
+type LoginThrottle struct {
+	maxAttempts int
+	baseLockout time.Duration
+	maxLockout  time.Duration
+	window      time.Duration
+
+	// OnLockout, if set, is called whenever a key becomes locked.
+	OnLockout func(key string, failures int, lockout time.Duration)
+
+	mu      sync.Mutex
+	entries map[string]*attemptState
+}
+
+type attemptState struct {
+	failures    int
+	lastFailure time.Time
+	lockedUntil time.Time
+}
+
+func NewLoginThrottle(maxAttempts int, baseLockout, maxLockout time.Duration) *LoginThrottle {
+	return &LoginThrottle{
+		maxAttempts: maxAttempts,
+		baseLockout: baseLockout,
+		maxLockout:  maxLockout,
+		window:      maxLockout * 2,
+		entries:     make(map[string]*attemptState),
+	}
+}
+
+// Locked reports whether any of the keys is currently locked out and, if so,
+// how long the caller should wait before retrying.
+func (t *LoginThrottle) Locked(keys ...string) (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	var wait time.Duration
+	for _, key := range keys {
+		if state, ok := t.entries[key]; ok && now.Before(state.lockedUntil) {
+			if remaining := state.lockedUntil.Sub(now); remaining > wait {
+				wait = remaining
+			}
+		}
+	}
+	return wait, wait > 0
+}
+
+// Fail records a failed attempt for each key.
+func (t *LoginThrottle) Fail(keys ...string) {
+	now := time.Now()
+
+	t.mu.Lock()
+	var locked []attemptLockout
+	for _, key := range keys {
+		state, ok := t.entries[key]
+		if !ok || now.Sub(state.lastFailure) > t.window {
+			state = &attemptState{}
+			t.entries[key] = state
+		}
+		state.failures++
+		state.lastFailure = now
+
+		if over := state.failures - t.maxAttempts; over >= 0 {
+			lockout := t.baseLockout << uint(min(over, 20))
+			if lockout > t.maxLockout || lockout <= 0 {
+				lockout = t.maxLockout
+			}
+			state.lockedUntil = now.Add(lockout)
+			locked = append(locked, attemptLockout{key, state.failures, lockout})
+		}
+	}
+	t.pruneLocked(now)
+	t.mu.Unlock()
+
+	if t.OnLockout != nil {
+		for _, l := range locked {
+			t.OnLockout(l.key, l.failures, l.lockout)
+		}
+	}
+}
+
+// Succeed clears the failure history for each key.
+func (t *LoginThrottle) Succeed(keys ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, key := range keys {
+		delete(t.entries, key)
+	}
+}
+
+type attemptLockout struct {
+	key      string
+	failures int
+	lockout  time.Duration
+}
+
+// pruneLocked drops entries whose last failure is outside the window. The caller must hold t.mu.
+func (t *LoginThrottle) pruneLocked(now time.Time) {
+	if len(t.entries) < 1024 {
+		return
+	}
+	for key, state := range t.entries {
+		if now.Sub(state.lastFailure) > t.window && now.After(state.lockedUntil) {
+			delete(t.entries, key)
+		}
+	}
+}
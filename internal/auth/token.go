@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TokenPrefix marks personal access tokens so they can be told apart from session JWTs.
+const TokenPrefix = "ffpat_"
+
+const (
+	ScopeTemplatesRead  = "templates:read"
+	ScopeTemplatesWrite = "templates:write"
+	ScopeFormsRead      = "forms:read"
+	ScopeFormsWrite     = "forms:write"
+	ScopePDFGenerate    = "pdf:generate"
+)
+
+// KnownScopes lists every scope a personal access token may be granted.
+var KnownScopes = []string{
+	ScopeTemplatesRead,
+	ScopeTemplatesWrite,
+	ScopeFormsRead,
+	ScopeFormsWrite,
+	ScopePDFGenerate,
+}
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired token")
+	ErrUnknownScope = errors.New("unknown scope")
+)
+
+type TokenService struct{}
+
+func NewTokenService() *TokenService {
+	return &TokenService{}
+}
+
+// Create mints a new token and returns its plaintext value.
+func (s *TokenService) Create(token *gormmodels.PersonalAccessToken) (string, error) {
+	for _, scope := range token.Scopes {
+		if !isKnownScope(scope) {
+			return "", fmt.Errorf("%w %q", ErrUnknownScope, scope)
+		}
+	}
+
+	plaintext, err := newTokenSecret()
+	if err != nil {
+		return "", err
+	}
+
+	token.ID = uuid.New().String()
+	token.Prefix = plaintext[:len(TokenPrefix)+6]
+	token.TokenHash = hashToken(plaintext)
+
+	if err := internal.DB.Create(token).Error; err != nil {
+		return "", fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+func (s *TokenService) GetByUserID(userID string) ([]gormmodels.PersonalAccessToken, error) {
+	var tokens []gormmodels.PersonalAccessToken
+
+	err := internal.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tokens: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (s *TokenService) GetForUser(userID, tokenID string) (*gormmodels.PersonalAccessToken, error) {
+	var token gormmodels.PersonalAccessToken
+
+	err := internal.DB.Where("id = ? AND user_id = ?", tokenID, userID).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to fetch token: %w", err)
+	}
+
+	return &token, nil
+}
+
+// Rotate replaces the token secret, keeping its name, scopes and expiry.
+func (s *TokenService) Rotate(token *gormmodels.PersonalAccessToken) (string, error) {
+	plaintext, err := newTokenSecret()
+	if err != nil {
+		return "", err
+	}
+
+	token.Prefix = plaintext[:len(TokenPrefix)+6]
+	token.TokenHash = hashToken(plaintext)
+
+	err = internal.DB.Model(token).Updates(map[string]interface{}{
+		"prefix":     token.Prefix,
+		"token_hash": token.TokenHash,
+	}).Error
+	if err != nil {
+		return "", fmt.Errorf("failed to rotate token: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+func (s *TokenService) Revoke(token *gormmodels.PersonalAccessToken) error {
+	now := time.Now()
+	token.RevokedAt = &now
+
+	if err := internal.DB.Model(token).Update("revoked_at", now).Error; err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// Authenticate resolves a plaintext token to its record, rejecting revoked or expired tokens.
+func (s *TokenService) Authenticate(plaintext string) (*gormmodels.PersonalAccessToken, error) {
+	var token gormmodels.PersonalAccessToken
+
+	err := internal.DB.Where("token_hash = ?", hashToken(plaintext)).First(&token).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrInvalidToken
+		}
+		return nil, fmt.Errorf("failed to fetch token: %w", err)
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil || (token.ExpiresAt != nil && now.After(*token.ExpiresAt)) {
+		return nil, ErrInvalidToken
+	}
+
+	internal.DB.Model(&token).UpdateColumn("last_used_at", now)
+	return &token, nil
+}
+
+func IsPersonalAccessToken(raw string) bool {
+	return strings.HasPrefix(raw, TokenPrefix)
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range KnownScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+func newTokenSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token secret: %w", err)
+	}
+	return TokenPrefix + hex.EncodeToString(buf), nil
+}
+
+func hashToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
@@ -3,14 +3,25 @@ package config
 import (
 	"fmt"
 	"os"
-
-	"github.com/joho/godotenv"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	GCS      GCSConfig
+	Database     DatabaseConfig
+	Server       ServerConfig
+	Storage      StorageConfig
+	GCS          GCSConfig
+	Sync         SyncConfig
+	Auth         AuthConfig
+	Diagnostics  DiagnosticsConfig
+	PDF          PDFConfig
+	GoogleVision GoogleVisionConfig
+	Submissions  SubmissionsConfig
+	Editor       EditorConfig
+	CDN          CDNConfig
+	Renderer     RendererConfig
 }
 
 type DatabaseConfig struct {
@@ -19,6 +30,20 @@ type DatabaseConfig struct {
 	User     string
 	Password string
 	DBName   string
+
+	// Connection pool limits. Zero leaves the database/sql default.
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// SlowQueryThreshold is the duration above which queries are logged.
+	SlowQueryThreshold time.Duration
+	// StatsInterval is how often pool statistics are logged (0 disables).
+	StatsInterval time.Duration
+
+	// ReplicaDSNs are read replicas for listing, search and stats queries.
+	ReplicaDSNs []string
 }
 
 type ServerConfig struct {
@@ -26,19 +51,218 @@ type ServerConfig struct {
 	Environment  string
 	AllowOrigins []string
 	BaseURL      string
+	GraphQL      bool
+	// PublicPreviewLimit is the number of public preview pages a client
+	// may load per minute (0 disables the limit).
+	PublicPreviewLimit int
+	// Metrics serves Prometheus metrics at /metrics.
+	Metrics bool
+	// SVGFilesOnly reads template pages from their page files alone and
+	// ignores legacy svgBackground values; run cmd/migrate-svg first.
+	SVGFilesOnly bool
+	// AdminAPIKey guards the admin endpoints; empty disables them.
+	AdminAPIKey string
+	// ConfigWatchInterval is how often .env and the GCS credentials file
+	// are checked for changes (0 disables watching).
+	ConfigWatchInterval time.Duration
+	// RequestTimeout is the time budget of API requests. Rendering, upload,
+	// import and sync routes get GenerationTimeout and OCR gets OCRTimeout
+	// instead; streaming routes have no budget.
+	RequestTimeout    time.Duration
+	GenerationTimeout time.Duration
+	OCRTimeout        time.Duration
+}
+
+// StorageConfig selects the object store. Backend is "gcs" (the default),
+// configured by GCSConfig, or "s3" for Amazon S3 and S3-compatible services
+// such as MinIO. Signed URL, asset proxy and temporary upload settings in
+// GCSConfig apply to either backend.
+type StorageConfig struct {
+	Backend string
+	S3      S3Config
+}
+
+type S3Config struct {
+	// Endpoint is the host[:port] of the S3 API, optionally with an
+	// http:// or https:// scheme that overrides UseSSL.
+	Endpoint        string
+	BucketName      string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
 }
 
 type GCSConfig struct {
 	BucketName      string
 	ProjectID       string
 	CredentialsPath string
+	// SignedURLTTL is how long signed URLs of page backgrounds stay valid.
+	SignedURLTTL time.Duration
+	// ProxyAssets serves page backgrounds through the API instead of
+	// redirecting to signed URLs, so asset URLs never expire.
+	ProxyAssets bool
+	// TempUploadTTL is how long files uploaded before their template exists
+	// are kept unless attached; TempUploadMaxSize bounds each file.
+	TempUploadTTL     time.Duration
+	TempUploadMaxSize int64
+	// KMSKeyName is the customer-managed Cloud KMS key
+	// (projects/.../locations/.../keyRings/.../cryptoKeys/...) that encrypts
+	// new objects; empty uses the bucket's default encryption.
+	KMSKeyName string
+}
+
+type SyncConfig struct {
+	APIKey string
+}
+
+// AuthConfig holds session tokens and login throttling. Required rejects
+// anonymous requests to routes that change templates or submissions or
+// generate PDFs.
+type AuthConfig struct {
+	Required       bool
+	JWTSecret      string
+	TokenTTL       time.Duration
+	MaxAttempts    int
+	LockoutBase    time.Duration
+	LockoutMaximum time.Duration
+}
+
+// DiagnosticsConfig holds render diagnostics and debug recording. Support
+// reads recordings with SupportAPIKey; empty disables the support endpoints.
+type DiagnosticsConfig struct {
+	Enabled            bool
+	Prefix             string
+	Retention          time.Duration
+	SupportAPIKey      string
+	RecordingMaxWindow time.Duration
+	RecordingRetention time.Duration
+}
+
+// PDFConfig holds the PDF post-processing tools. TextExtractorBinary reads
+// the text of stored documents for search; empty disables indexing.
+// PageConverterBinary turns uploaded PDFs into page backgrounds at PageDPI;
+// empty disables PDF uploads, which are bounded by UploadMaxSize.
+// CallbackSecret signs the deliveries of async generation callbacks, which
+// are tried CallbackAttempts times, waiting CallbackBackoff (doubling) in
+// between.
+// BulkWorkers is the number of PDFs a bulk generation renders at once.
+// MaxPages, MaxHTMLSize and MaxDataURISize bound every generation; zero
+// disables a limit.
+type PDFConfig struct {
+	OptimizerBinary     string
+	OptimizeDPI         int
+	ThaiDictionaryPath  string
+	TextExtractorBinary string
+	PageConverterBinary string
+	PageDPI             int
+	UploadMaxSize       int64
+	CallbackSecret      string
+	CallbackAttempts    int
+	CallbackBackoff     time.Duration
+	BulkWorkers         int
+	MaxPages            int
+	MaxHTMLSize         int
+	MaxDataURISize      int
+}
+
+// GoogleVisionConfig holds the OCR provider settings and usage limits. OCR is
+// disabled without an APIKey. MonthlyCap is the default per-workspace limit
+// (0 disables it); CapAction is "reject" or "fallback", which reads images
+// with the FallbackBinary (Tesseract) once the cap is reached.
+type GoogleVisionConfig struct {
+	APIKey         string
+	Endpoint       string
+	FallbackBinary string
+	MaxUploadSize  int64
+	MonthlyCap     int
+	CapAction      string
+	CostPer1000    float64
+}
+
+// SubmissionsConfig controls background submission maintenance. Expiry
+// rules are set per template; ExpiryInterval is how often they run (0 disables).
+// SuggestionExcludedKeys are DataKey fragments never offered as autocomplete
+// suggestions.
+type SubmissionsConfig struct {
+	ExpiryInterval         time.Duration
+	SuggestionExcludedKeys []string
+	// ValidationWebhookSecret signs the calls made to template validation webhooks.
+	ValidationWebhookSecret string
+	// NumberReservationTTL is how long a reserved document number is held
+	// before it can be handed out again.
+	NumberReservationTTL time.Duration
+}
+
+// EditorConfig controls template editing sessions. LockTTL is how long an
+// edit lock lives without a heartbeat. Live preview sessions expire
+// PreviewSessionTTL after their last update, and updates wait
+// PreviewDebounce for a later one before rendering.
+type EditorConfig struct {
+	LockTTL           time.Duration
+	PreviewSessionTTL time.Duration
+	PreviewDebounce   time.Duration
+}
+
+// CDNConfig points template asset URLs at a CDN in front of the asset proxy.
+// PurgeURL and PurgeToken are used to evict replaced pages from its cache.
+type CDNConfig struct {
+	AssetHost  string
+	PurgeURL   string
+	PurgeToken string
+}
+
+// RendererConfig selects where PDFs are rendered. With URL empty the API runs
+// Chrome in-process; otherwise it calls the renderer process at URL. Port,
+// Concurrency and Timeout configure cmd/renderer itself. VerifySnapshots
+// re-renders template snapshots at startup to catch output changes after an
+// upgrade. ChromeVersion pins the Chrome version (or version prefix) the
+// startup probe expects. Deterministic renders every PDF in deterministic
+// mode unless the request opts out. PoolSize is how many Chrome browsers the
+// API keeps running when it renders in-process; cmd/renderer keeps
+// Concurrency browsers.
+type RendererConfig struct {
+	URL             string
+	Token           string
+	Port            string
+	Concurrency     int
+	Timeout         time.Duration
+	VerifySnapshots bool
+	ChromeVersion   string
+	Deterministic   bool
+	PoolSize        int
 }
 
 func Load() (*Config, error) {
-	if err := godotenv.Load(); err != nil {
+	processEnv = make(map[string]bool)
+	for _, entry := range os.Environ() {
+		key, _, _ := strings.Cut(entry, "=")
+		processEnv[key] = true
+	}
+
+	if err := loadEnvFile(); err != nil {
 		fmt.Printf("Failed to load .env file: %v, using system environment variables\n", err)
 	}
 
+	config := build()
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate rejects configurations the server cannot run safely with.
+func (c *Config) Validate() error {
+	// Sessions are the only way to sign in and to create access tokens, so
+	// requiring auth without JWT_SECRET would lock every caller out.
+	if c.Auth.Required && c.Auth.JWTSecret == "" {
+		return fmt.Errorf("AUTH_REQUIRED is on but JWT_SECRET is empty; set JWT_SECRET, or AUTH_REQUIRED=false for local development")
+	}
+	return nil
+}
+
+// build reads the configuration from the environment.
+func build() *Config {
 	config := &Config{
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -46,24 +270,130 @@ func Load() (*Config, error) {
 			User:     getEnv("DB_USER", "root"),
 			Password: getEnv("DB_PASSWORD", ""),
 			DBName:   getEnv("DB_NAME", "fastfill_db"),
+
+			MaxOpenConns:    getInt("DB_MAX_OPEN_CONNS", 25),
+			MaxIdleConns:    getInt("DB_MAX_IDLE_CONNS", 10),
+			ConnMaxLifetime: getDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+			ConnMaxIdleTime: getDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+			SlowQueryThreshold: getDuration("DB_SLOW_QUERY_THRESHOLD", 200*time.Millisecond),
+			StatsInterval:      getDuration("DB_STATS_INTERVAL", time.Minute),
+
+			ReplicaDSNs: getList("DB_REPLICA_DSNS"),
 		},
 		Server: ServerConfig{
-			Port:        getEnv("PORT", getEnv("SERVER_PORT", "8080")),
-			Environment: getEnv("ENVIRONMENT", "development"),
-			BaseURL:     getEnv("API_BASE_URL", ""),
+			Port:                getEnv("PORT", getEnv("SERVER_PORT", "8080")),
+			Environment:         getEnv("ENVIRONMENT", "development"),
+			BaseURL:             getEnv("API_BASE_URL", ""),
+			GraphQL:             getEnv("GRAPHQL_ENABLED", "false") == "true",
+			PublicPreviewLimit:  getInt("PUBLIC_PREVIEW_RATE_LIMIT", 30),
+			Metrics:             getEnv("METRICS_ENABLED", "true") == "true",
+			SVGFilesOnly:        getEnv("SVG_FILES_ONLY", "false") == "true",
+			AdminAPIKey:         getEnv("ADMIN_API_KEY", ""),
+			ConfigWatchInterval: getDuration("CONFIG_WATCH_INTERVAL", 30*time.Second),
+			RequestTimeout:      getDuration("REQUEST_TIMEOUT", 5*time.Second),
+			GenerationTimeout:   getDuration("GENERATION_TIMEOUT", 60*time.Second),
+			OCRTimeout:          getDuration("OCR_TIMEOUT", 20*time.Second),
 			AllowOrigins: []string{
 				getEnv("FRONTEND_URL_1", "http://localhost:3000"),
 				getEnv("FRONTEND_URL_2", "http://localhost:3001"),
 			},
 		},
+		Storage: StorageConfig{
+			Backend: getEnv("STORAGE_BACKEND", "gcs"),
+			S3: S3Config{
+				Endpoint:        getEnv("S3_ENDPOINT", ""),
+				BucketName:      getEnv("S3_BUCKET_NAME", ""),
+				Region:          getEnv("S3_REGION", ""),
+				AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+				SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+				UseSSL:          getEnv("S3_USE_SSL", "true") == "true",
+			},
+		},
 		GCS: GCSConfig{
-			BucketName:      getEnv("GCS_BUCKET_NAME", ""),
-			ProjectID:       getEnv("GOOGLE_CLOUD_PROJECT", ""),
-			CredentialsPath: getEnv("GCS_CREDENTIALS_PATH", ""),
+			BucketName:        getEnv("GCS_BUCKET_NAME", ""),
+			ProjectID:         getEnv("GOOGLE_CLOUD_PROJECT", ""),
+			CredentialsPath:   getEnv("GCS_CREDENTIALS_PATH", ""),
+			SignedURLTTL:      getDuration("GCS_SIGNED_URL_TTL", time.Hour),
+			ProxyAssets:       getEnv("GCS_PROXY_ASSETS", "false") == "true",
+			TempUploadTTL:     getDuration("TEMP_UPLOAD_TTL", 24*time.Hour),
+			TempUploadMaxSize: int64(getInt("TEMP_UPLOAD_MAX_SIZE", 20<<20)),
+			KMSKeyName:        getEnv("GCS_KMS_KEY_NAME", ""),
+		},
+		Sync: SyncConfig{
+			APIKey: getEnv("SYNC_API_KEY", ""),
+		},
+		Auth: AuthConfig{
+			Required:       getEnv("AUTH_REQUIRED", "true") == "true",
+			JWTSecret:      getEnv("JWT_SECRET", ""),
+			TokenTTL:       getDuration("JWT_TTL", 12*time.Hour),
+			MaxAttempts:    getInt("AUTH_MAX_ATTEMPTS", 5),
+			LockoutBase:    getDuration("AUTH_LOCKOUT_BASE", 30*time.Second),
+			LockoutMaximum: getDuration("AUTH_LOCKOUT_MAX", time.Hour),
+		},
+		Diagnostics: DiagnosticsConfig{
+			Enabled:            getEnv("RENDER_DIAGNOSTICS", "true") == "true",
+			Prefix:             getEnv("RENDER_DIAGNOSTICS_PREFIX", "diagnostics/"),
+			Retention:          getDuration("RENDER_DIAGNOSTICS_TTL", 7*24*time.Hour),
+			SupportAPIKey:      getEnv("SUPPORT_API_KEY", ""),
+			RecordingMaxWindow: getDuration("DEBUG_RECORDING_MAX_WINDOW", 24*time.Hour),
+			RecordingRetention: getDuration("DEBUG_RECORDING_TTL", 7*24*time.Hour),
+		},
+		PDF: PDFConfig{
+			OptimizerBinary:     getEnv("PDF_OPTIMIZER_BIN", "gs"),
+			OptimizeDPI:         getInt("PDF_OPTIMIZE_DPI", 150),
+			ThaiDictionaryPath:  getEnv("THAI_DICTIONARY_PATH", ""),
+			TextExtractorBinary: getEnv("PDF_TEXT_EXTRACTOR_BIN", "pdftotext"),
+			PageConverterBinary: getEnv("PDF_PAGE_CONVERTER_BIN", "pdftocairo"),
+			PageDPI:             getInt("PDF_PAGE_DPI", 150),
+			UploadMaxSize:       int64(getInt("PDF_UPLOAD_MAX_SIZE", 20<<20)),
+			CallbackSecret:      getEnv("GENERATION_CALLBACK_SECRET", ""),
+			CallbackAttempts:    getInt("GENERATION_CALLBACK_ATTEMPTS", 5),
+			CallbackBackoff:     getDuration("GENERATION_CALLBACK_BACKOFF", 30*time.Second),
+			BulkWorkers:         getInt("BULK_PDF_WORKERS", 4),
+			MaxPages:            getInt("GENERATION_MAX_PAGES", 100),
+			MaxHTMLSize:         getInt("GENERATION_MAX_HTML_SIZE", 50<<20),
+			MaxDataURISize:      getInt("GENERATION_MAX_DATA_URI_SIZE", 10<<20),
+		},
+		GoogleVision: GoogleVisionConfig{
+			APIKey:         getEnv("GOOGLE_VISION_API_KEY", ""),
+			Endpoint:       getEnv("GOOGLE_VISION_ENDPOINT", ""),
+			FallbackBinary: getEnv("OCR_FALLBACK_BIN", ""),
+			MaxUploadSize:  int64(getInt("OCR_MAX_UPLOAD_SIZE", 5<<20)),
+			MonthlyCap:     getInt("VISION_MONTHLY_CAP", 0),
+			CapAction:      getEnv("VISION_CAP_ACTION", "reject"),
+			CostPer1000:    getFloat("VISION_COST_PER_1000", 1.5),
+		},
+		Submissions: SubmissionsConfig{
+			ExpiryInterval:          getDuration("SUBMISSION_EXPIRY_INTERVAL", time.Hour),
+			SuggestionExcludedKeys:  suggestionExcludedKeys(),
+			ValidationWebhookSecret: getEnv("VALIDATION_WEBHOOK_SECRET", ""),
+			NumberReservationTTL:    getDuration("NUMBER_RESERVATION_TTL", 24*time.Hour),
+		},
+		CDN: CDNConfig{
+			AssetHost:  getEnv("CDN_ASSET_HOST", ""),
+			PurgeURL:   getEnv("CDN_PURGE_URL", ""),
+			PurgeToken: getEnv("CDN_PURGE_TOKEN", ""),
+		},
+		Renderer: RendererConfig{
+			URL:             getEnv("RENDERER_URL", ""),
+			Token:           getEnv("RENDERER_TOKEN", ""),
+			Port:            getEnv("RENDERER_PORT", "8090"),
+			Concurrency:     getInt("RENDERER_CONCURRENCY", 4),
+			Timeout:         getDuration("RENDERER_TIMEOUT", 30*time.Second),
+			VerifySnapshots: getEnv("VERIFY_SNAPSHOTS_ON_START", "false") == "true",
+			ChromeVersion:   getEnv("RENDERER_CHROME_VERSION", ""),
+			Deterministic:   getEnv("RENDERER_DETERMINISTIC", "false") == "true",
+			PoolSize:        getInt("RENDERER_POOL_SIZE", 2),
+		},
+		Editor: EditorConfig{
+			LockTTL:           getDuration("TEMPLATE_LOCK_TTL", 2*time.Minute),
+			PreviewSessionTTL: getDuration("PREVIEW_SESSION_TTL", 10*time.Minute),
+			PreviewDebounce:   getDuration("PREVIEW_DEBOUNCE", 250*time.Millisecond),
 		},
 	}
 
-	return config, nil
+	return config
 }
 
 func getEnv(key, defaultValue string) string {
@@ -73,6 +403,53 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if i, err := strconv.Atoi(value); err == nil {
+			return i
+		}
+	}
+	return defaultValue
+}
+
+func getFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
+// suggestionExcludedKeys reads SUGGESTION_EXCLUDED_KEYS, defaulting to keys
+// that commonly hold personal identifiers or contact details.
+func suggestionExcludedKeys() []string {
+	if keys := getList("SUGGESTION_EXCLUDED_KEYS"); len(keys) > 0 {
+		return keys
+	}
+	return []string{"password", "passport", "citizen", "national", "idcard", "id_card", "tax", "bank", "account", "card", "phone", "mobile", "email", "birth"}
+}
+
+// getList splits a comma-separated variable, dropping empty entries.
+func getList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func (d *DatabaseConfig) DSN() string {
 	// Check if we're using Cloud SQL Unix socket (path starts with /)
 	if len(d.Host) > 0 && d.Host[0] == '/' {
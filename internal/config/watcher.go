@@ -0,0 +1,156 @@
+package config
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// envFile is the dotenv file the configuration is loaded, and reloaded, from.
+const envFile = ".env"
+
+var (
+	// processEnv records the variables set before envFile was first read.
+	// They take precedence over envFile at startup and on every reload.
+	processEnv map[string]bool
+	// envFileKeys are the variables last set from envFile, so that keys
+	// removed from the file are unset again on reload.
+	envFileKeys = make(map[string]bool)
+)
+
+// loadEnvFile sets the variables of envFile that the process environment
+// does not already set.
+func loadEnvFile() error {
+	values, err := godotenv.Read(envFile)
+	if err != nil {
+		return err
+	}
+
+	for key := range envFileKeys {
+		if _, ok := values[key]; !ok {
+			os.Unsetenv(key)
+		}
+	}
+	envFileKeys = make(map[string]bool, len(values))
+	for key, value := range values {
+		if processEnv[key] {
+			continue
+		}
+		os.Setenv(key, value)
+		envFileKeys[key] = true
+	}
+	return nil
+}
+
+// ReloadFunc applies a reloaded configuration and describes what it changed.
+type ReloadFunc func(previous, current *Config) []string
+
+// Watcher reloads the configuration when .env or one of the watched files
+// changes, or when asked to. Components that support changing settings at
+// runtime register a ReloadFunc; everything else keeps the values it was
+// started with.
+type Watcher struct {
+	mu       sync.Mutex
+	current  *Config
+	appliers []ReloadFunc
+	modTimes map[string]time.Time
+}
+
+func NewWatcher(cfg *Config) *Watcher {
+	w := &Watcher{current: cfg, modTimes: make(map[string]time.Time)}
+	w.changedFiles(cfg)
+	return w
+}
+
+// OnReload registers fn to be called with the previous and the reloaded
+// configuration on every reload.
+func (w *Watcher) OnReload(fn ReloadFunc) {
+	w.mu.Lock()
+	w.appliers = append(w.appliers, fn)
+	w.mu.Unlock()
+}
+
+// Current returns the configuration as of the last reload.
+func (w *Watcher) Current() *Config {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.current
+}
+
+// Reload re-reads .env and the environment, applies the result and returns
+// the changes the registered components reported.
+func (w *Watcher) Reload() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := loadEnvFile(); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	current := build()
+	w.changedFiles(current)
+	if err := current.Validate(); err != nil {
+		return nil, err
+	}
+
+	changes := []string{}
+	for _, apply := range w.appliers {
+		changes = append(changes, apply(w.current, current)...)
+	}
+	w.current = current
+	return changes, nil
+}
+
+// Start checks .env and the GCS credentials file every interval and reloads
+// when either changed, until ctx is cancelled.
+func (w *Watcher) Start(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.mu.Lock()
+				changed := w.changedFiles(w.current)
+				w.mu.Unlock()
+				if !changed {
+					continue
+				}
+				if changes, err := w.Reload(); err != nil {
+					log.Printf("Warning: failed to reload configuration: %v", err)
+				} else {
+					log.Printf("Configuration reloaded: %d change(s) %v", len(changes), changes)
+				}
+			}
+		}
+	}()
+}
+
+// changedFiles records the modification times of the watched files and
+// reports whether any differ from the last check. The caller must hold w.mu.
+func (w *Watcher) changedFiles(cfg *Config) bool {
+	changed := false
+	for _, path := range []string{envFile, cfg.GCS.CredentialsPath} {
+		if path == "" {
+			continue
+		}
+		var modTime time.Time
+		if info, err := os.Stat(path); err == nil {
+			modTime = info.ModTime()
+		}
+		if previous, ok := w.modTimes[path]; ok && !previous.Equal(modTime) {
+			changed = true
+		}
+		w.modTimes[path] = modTime
+	}
+	return changed
+}
@@ -1,23 +1,41 @@
 package internal
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"github.com/dhanavadh/fastfill-backend/internal/config"
 	"github.com/dhanavadh/fastfill-backend/internal/models/gorm"
 
 	"gorm.io/driver/mysql"
 	gormdb "gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/plugin/dbresolver"
 )
 
 var DB *gormdb.DB
 
+// replicaResolver names the dbresolver configuration for read replicas.
+// Queries only use it when they ask for it through ReadDB.
+const replicaResolver = "read-replicas"
+
+var replicasEnabled bool
+
 func InitDB(cfg *config.Config) error {
 	var err error
 	dsn := cfg.Database.DSN()
 	log.Printf("Connecting to database with DSN: %s", dsn)
-	DB, err = gormdb.Open(mysql.Open(dsn), &gormdb.Config{})
+	DB, err = gormdb.Open(mysql.Open(dsn), &gormdb.Config{
+		Logger: logger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), logger.Config{
+			SlowThreshold: cfg.Database.SlowQueryThreshold,
+			LogLevel:      logger.Warn,
+			Colorful:      true,
+		}),
+	})
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
@@ -27,6 +45,12 @@ func InitDB(cfg *config.Config) error {
 		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
+	configurePool(sqlDB, cfg.Database)
+
+	if err := registerReplicas(cfg.Database); err != nil {
+		return fmt.Errorf("failed to configure read replicas: %w", err)
+	}
+
 	if err := sqlDB.Ping(); err != nil {
 		return fmt.Errorf("failed to ping database: %w", err)
 	}
@@ -40,13 +64,164 @@ func InitDB(cfg *config.Config) error {
 	return nil
 }
 
+func configurePool(sqlDB *sql.DB, cfg config.DatabaseConfig) {
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+}
+
+// registerReplicas routes ReadDB queries to the configured replicas. Other
+// queries, and everything inside transactions, stay on the primary.
+func registerReplicas(cfg config.DatabaseConfig) error {
+	if len(cfg.ReplicaDSNs) == 0 {
+		return nil
+	}
+
+	replicas := make([]gormdb.Dialector, len(cfg.ReplicaDSNs))
+	for i, dsn := range cfg.ReplicaDSNs {
+		replicas[i] = mysql.Open(dsn)
+	}
+
+	resolver := dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}, replicaResolver)
+	if cfg.MaxOpenConns > 0 {
+		resolver.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		resolver.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		resolver.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		resolver.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+
+	if err := DB.Use(resolver); err != nil {
+		return err
+	}
+
+	replicasEnabled = true
+	log.Printf("Read replicas enabled: %d", len(replicas))
+	return nil
+}
+
+// ReadDB returns a handle for read-heavy queries that tolerate replication
+// lag, such as listings, search and statistics. It reads from a replica when
+// one is configured and from the primary otherwise.
+func ReadDB() *gormdb.DB {
+	if !replicasEnabled {
+		return DB
+	}
+	return DB.Clauses(dbresolver.Use(replicaResolver), dbresolver.Read)
+}
+
+// DBStats returns the connection pool statistics of the database.
+func DBStats() (sql.DBStats, error) {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return sql.DBStats{}, err
+	}
+	return sqlDB.Stats(), nil
+}
+
+// StartDBStatsLogger logs connection pool usage every interval until ctx is
+// canceled, warning when requests had to wait for a free connection.
+func StartDBStatsLogger(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last sql.DBStats
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				stats, err := DBStats()
+				if err != nil {
+					log.Printf("Warning: failed to read database pool stats: %v", err)
+					continue
+				}
+
+				waits := stats.WaitCount - last.WaitCount
+				if waits > 0 {
+					log.Printf("Warning: database pool exhausted: %d waits (%s) in the last %s, %d/%d connections in use",
+						waits, stats.WaitDuration-last.WaitDuration, interval, stats.InUse, stats.MaxOpenConnections)
+				} else {
+					log.Printf("Database pool: open=%d inUse=%d idle=%d max=%d", stats.OpenConnections, stats.InUse, stats.Idle, stats.MaxOpenConnections)
+				}
+				last = stats
+			}
+		}
+	}()
+}
+
 func autoMigrate() error {
-	return DB.AutoMigrate(
+	// Template counters are maintained incrementally; fill them in once for
+	// the submissions and documents that predate them.
+	recount := DB.Migrator().HasTable(&gorm.Template{}) && !DB.Migrator().HasColumn(&gorm.Template{}, "SubmissionCount")
+
+	err := DB.AutoMigrate(
 		&gorm.Template{},
 		&gorm.Field{},
 		&gorm.SVGFile{},
 		&gorm.FormSubmission{},
+		&gorm.PageCalibration{},
+		&gorm.TemplateInclude{},
+		&gorm.OptionList{},
+		&gorm.InboundIntegration{},
+		&gorm.Workspace{},
+		&gorm.User{},
+		&gorm.SSOConfig{},
+		&gorm.PersonalAccessToken{},
+		&gorm.AuditLog{},
+		&gorm.GeneratedDocument{},
+		&gorm.DocumentText{},
+		&gorm.RegenerationJob{},
+		&gorm.GenerationJob{},
+		&gorm.UsageCounter{},
+		&gorm.TemplateLock{},
+		&gorm.Signature{},
+		&gorm.Stamp{},
+		&gorm.TemplateSnapshot{},
+		&gorm.TemplateShare{},
+		&gorm.WorkspaceDomain{},
+		&gorm.Event{},
+		&gorm.DebugRecordingWindow{},
+		&gorm.DebugRecording{},
+		&gorm.SubmissionRevision{},
+		&gorm.FillLink{},
+		&gorm.ImportProfile{},
+		&gorm.Assignment{},
+		&gorm.TempUpload{},
+		&gorm.DocumentNumberCounter{},
+		&gorm.NumberReservation{},
 	)
+	if err != nil {
+		return err
+	}
+
+	if recount {
+		log.Printf("Counting submissions and documents per template")
+		return gorm.RecountTemplateStats(DB)
+	}
+	return nil
 }
 
 func CloseDB() {
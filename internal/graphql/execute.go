@@ -0,0 +1,487 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Request is a GraphQL request as sent over HTTP.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response carries the result of a request. Data is omitted when the request
+// could not be executed at all.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Execute parses and runs a query against the schema. Field errors are
+// reported in the response and leave the field null; request errors (syntax,
+// unknown operation, invalid variables) return a response without data.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return requestError(err)
+	}
+
+	op, err := doc.operation(req.OperationName)
+	if err != nil {
+		return requestError(err)
+	}
+	if op.Type != "query" {
+		return requestError(fmt.Errorf("%s operations are not supported", op.Type))
+	}
+
+	variables, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return requestError(err)
+	}
+
+	e := &executor{ctx: ctx, schema: s, doc: doc, variables: variables}
+	data := e.selectionSet(s.types[s.Query], nil, op.Selections, nil, 0)
+	if e.fatal != nil {
+		return requestError(e.fatal)
+	}
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func requestError(err error) *Response {
+	return &Response{Errors: []*Error{{Message: err.Error()}}}
+}
+
+func (d *Document) operation(name string) (*Operation, error) {
+	if name == "" {
+		if len(d.Operations) > 1 {
+			return nil, fmt.Errorf("operationName is required for documents with several operations")
+		}
+		return d.Operations[0], nil
+	}
+	for _, op := range d.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+type executor struct {
+	ctx       context.Context
+	schema    *Schema
+	doc       *Document
+	variables map[string]interface{}
+	errors    []*Error
+	// fatal aborts execution for document errors such as unknown fragments.
+	fatal error
+}
+
+func (e *executor) fieldError(path []interface{}, format string, args ...interface{}) {
+	e.errors = append(e.errors, &Error{
+		Message: fmt.Sprintf(format, args...),
+		Path:    append([]interface{}(nil), path...),
+	})
+}
+
+// fieldGroup is the set of fields in a selection sharing one response key.
+type fieldGroup struct {
+	key    string
+	fields []*Field
+}
+
+func (e *executor) selectionSet(obj *Object, source interface{}, selections []Selection, path []interface{}, depth int) *orderedMap {
+	if depth > e.schema.MaxDepth {
+		e.fieldError(path, "query is nested more than %d levels deep", e.schema.MaxDepth)
+		return nil
+	}
+
+	var groups []*fieldGroup
+	e.collectFields(obj, selections, &groups, map[string]bool{})
+
+	result := &orderedMap{values: make(map[string]interface{}, len(groups))}
+	for _, group := range groups {
+		if e.fatal != nil {
+			return nil
+		}
+		fieldPath := append(path, group.key)
+		result.set(group.key, e.field(obj, source, group.fields, fieldPath, depth))
+	}
+	return result
+}
+
+func (e *executor) collectFields(obj *Object, selections []Selection, groups *[]*fieldGroup, visited map[string]bool) {
+	for _, selection := range selections {
+		switch sel := selection.(type) {
+		case *Field:
+			if !e.included(sel.Directives) {
+				continue
+			}
+			key := sel.ResponseKey()
+			found := false
+			for _, group := range *groups {
+				if group.key == key {
+					group.fields = append(group.fields, sel)
+					found = true
+					break
+				}
+			}
+			if !found {
+				*groups = append(*groups, &fieldGroup{key: key, fields: []*Field{sel}})
+			}
+		case *FragmentSpread:
+			if !e.included(sel.Directives) || visited[sel.Name] {
+				continue
+			}
+			fragment, ok := e.doc.Fragments[sel.Name]
+			if !ok {
+				e.fatal = fmt.Errorf("unknown fragment %q", sel.Name)
+				return
+			}
+			visited[sel.Name] = true
+			if fragment.TypeCondition == obj.Name {
+				e.collectFields(obj, fragment.Selections, groups, visited)
+			}
+		case *InlineFragment:
+			if !e.included(sel.Directives) {
+				continue
+			}
+			if sel.TypeCondition == "" || sel.TypeCondition == obj.Name {
+				e.collectFields(obj, sel.Selections, groups, visited)
+			}
+		}
+	}
+}
+
+// included evaluates @skip and @include.
+func (e *executor) included(directives []*Directive) bool {
+	for _, d := range directives {
+		if d.Name != "skip" && d.Name != "include" {
+			continue
+		}
+		condition := false
+		for _, arg := range d.Arguments {
+			if arg.Name == "if" {
+				condition, _ = e.value(arg.Value).(bool)
+			}
+		}
+		if (d.Name == "skip") == condition {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *executor) field(obj *Object, source interface{}, fields []*Field, path []interface{}, depth int) interface{} {
+	f := fields[0]
+	if f.Name == "__typename" {
+		return obj.Name
+	}
+
+	def := obj.field(f.Name)
+	if def == nil {
+		e.fieldError(path, "cannot query field %q on type %q", f.Name, obj.Name)
+		return nil
+	}
+
+	args, err := e.arguments(def, f.Arguments)
+	if err != nil {
+		e.fieldError(path, "%v", err)
+		return nil
+	}
+
+	var value interface{}
+	if def.Resolve != nil {
+		value, err = def.Resolve(e.ctx, source, args)
+	} else {
+		value, err = defaultResolve(source, def.Name)
+	}
+	if err != nil {
+		e.fieldError(path, "%v", err)
+		return nil
+	}
+
+	var selections []Selection
+	for _, field := range fields {
+		selections = append(selections, field.Selections...)
+	}
+	return e.complete(def.Type, value, selections, path, depth+1)
+}
+
+func (e *executor) complete(typ string, value interface{}, selections []Selection, path []interface{}, depth int) interface{} {
+	nonNull := strings.HasSuffix(typ, "!")
+	typ = strings.TrimSuffix(typ, "!")
+
+	if isNil(value) {
+		if nonNull {
+			e.fieldError(path, "non-null field resolved to null")
+		}
+		return nil
+	}
+
+	if strings.HasPrefix(typ, "[") {
+		list := reflect.ValueOf(value)
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			e.fieldError(path, "expected a list, got %T", value)
+			return nil
+		}
+		items := make([]interface{}, list.Len())
+		for i := range items {
+			items[i] = e.complete(typ[1:len(typ)-1], list.Index(i).Interface(), selections, append(path, i), depth)
+		}
+		return items
+	}
+
+	if obj, ok := e.schema.types[typ]; ok {
+		if len(selections) == 0 {
+			e.fieldError(path, "field of type %s must have a selection of subfields", typ)
+			return nil
+		}
+		return e.selectionSet(obj, value, selections, path, depth)
+	}
+
+	if len(selections) > 0 {
+		e.fieldError(path, "field of scalar type %s cannot have a selection", typ)
+		return nil
+	}
+	return value
+}
+
+func (e *executor) arguments(def *FieldDef, provided []*Argument) (map[string]interface{}, error) {
+	args := make(map[string]interface{}, len(def.Args))
+	for _, arg := range provided {
+		found := false
+		for _, a := range def.Args {
+			if a.Name == arg.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown argument %q on field %q", arg.Name, def.Name)
+		}
+	}
+
+	for _, a := range def.Args {
+		var value interface{}
+		present := false
+		for _, arg := range provided {
+			if arg.Name == a.Name {
+				value, present = e.value(arg.Value), true
+			}
+		}
+		if !present && a.Default != nil {
+			value = a.Default
+		}
+
+		coerced, err := coerceInput(value, a.Type)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %v", a.Name, err)
+		}
+		if coerced != nil {
+			args[a.Name] = coerced
+		}
+	}
+	return args, nil
+}
+
+// value resolves variables and enums in a literal to plain Go values.
+func (e *executor) value(v Value) interface{} {
+	switch val := v.(type) {
+	case Variable:
+		return e.variables[string(val)]
+	case Enum:
+		return string(val)
+	case []Value:
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			list[i] = e.value(item)
+		}
+		return list
+	case map[string]Value:
+		object := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			object[k] = e.value(item)
+		}
+		return object
+	}
+	return v
+}
+
+func coerceVariables(op *Operation, provided map[string]interface{}) (map[string]interface{}, error) {
+	variables := make(map[string]interface{}, len(op.Variables))
+	for _, def := range op.Variables {
+		value, ok := provided[def.Name]
+		if !ok && def.Default != nil {
+			value = (&executor{}).value(def.Default)
+		}
+		coerced, err := coerceInput(value, def.Type)
+		if err != nil {
+			return nil, fmt.Errorf("variable $%s: %v", def.Name, err)
+		}
+		variables[def.Name] = coerced
+	}
+	return variables, nil
+}
+
+// coerceInput checks an input value against a type, converting JSON numbers
+// to Int where needed. Unknown named types are passed through.
+func coerceInput(value interface{}, typ string) (interface{}, error) {
+	nonNull := strings.HasSuffix(typ, "!")
+	typ = strings.TrimSuffix(typ, "!")
+
+	if value == nil {
+		if nonNull {
+			return nil, fmt.Errorf("expected a non-null %s", typ)
+		}
+		return nil, nil
+	}
+
+	if strings.HasPrefix(typ, "[") {
+		inner := typ[1 : len(typ)-1]
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		list := make([]interface{}, len(items))
+		for i, item := range items {
+			coerced, err := coerceInput(item, inner)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = coerced
+		}
+		return list, nil
+	}
+
+	switch typ {
+	case "Int":
+		switch n := value.(type) {
+		case int:
+			return n, nil
+		case float64:
+			if n == math.Trunc(n) && math.Abs(n) <= math.MaxInt32 {
+				return int(n), nil
+			}
+		}
+		return nil, fmt.Errorf("expected Int, got %v", value)
+	case "Float":
+		switch n := value.(type) {
+		case int:
+			return float64(n), nil
+		case float64:
+			return n, nil
+		}
+		return nil, fmt.Errorf("expected Float, got %v", value)
+	case "String":
+		if str, ok := value.(string); ok {
+			return str, nil
+		}
+		return nil, fmt.Errorf("expected String, got %v", value)
+	case "ID":
+		switch id := value.(type) {
+		case string:
+			return id, nil
+		case int:
+			return fmt.Sprint(id), nil
+		case float64:
+			if id == math.Trunc(id) {
+				return fmt.Sprint(int64(id)), nil
+			}
+		}
+		return nil, fmt.Errorf("expected ID, got %v", value)
+	case "Boolean":
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected Boolean, got %v", value)
+	}
+	return value, nil
+}
+
+// defaultResolve reads name from a map or the JSON-tagged field of a struct.
+func defaultResolve(source interface{}, name string) (interface{}, error) {
+	if m, ok := source.(map[string]interface{}); ok {
+		return m[name], nil
+	}
+
+	v := reflect.ValueOf(source)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot resolve %q on %T", name, source)
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if tag == name || tag == "" && strings.EqualFold(t.Field(i).Name, name) {
+			return v.Field(i).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot resolve %q on %T", name, source)
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// orderedMap is a JSON object that keeps the order of the selection set.
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) set(key string, value interface{}) {
+	if _, exists := m.values[key]; !exists {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,107 @@
+// Package graphql implements the subset of GraphQL needed to serve read-only
+// queries over a fixed schema: operations, variables, aliases, fragments and
+// the @skip/@include directives. Mutations, subscriptions and introspection
+// are not supported; Schema.SDL describes the schema instead.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ResolveFunc produces the value of a field from its parent value and
+// coerced arguments.
+type ResolveFunc func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error)
+
+// Schema is a set of object types with a root query type. Field types use
+// GraphQL notation ("String!", "[Field!]!"); names that are not object types
+// are leaf scalars whose resolved values are serialized as JSON.
+type Schema struct {
+	Query    string
+	Scalars  []string
+	MaxDepth int
+
+	types map[string]*Object
+	order []string
+}
+
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*FieldDef
+}
+
+type FieldDef struct {
+	Name        string
+	Type        string
+	Description string
+	Args        []*ArgDef
+	// Resolve may be nil to read the same-named key or JSON-tagged struct
+	// field from the parent value.
+	Resolve ResolveFunc
+}
+
+type ArgDef struct {
+	Name    string
+	Type    string
+	Default interface{}
+}
+
+// NewSchema builds a schema whose root query type is query.
+func NewSchema(query string, types ...*Object) *Schema {
+	s := &Schema{Query: query, MaxDepth: 12, types: make(map[string]*Object)}
+	for _, t := range types {
+		s.types[t.Name] = t
+		s.order = append(s.order, t.Name)
+	}
+	return s
+}
+
+func (o *Object) field(name string) *FieldDef {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// SDL renders the schema in GraphQL schema definition language.
+func (s *Schema) SDL() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "schema {\n  query: %s\n}\n", s.Query)
+	for _, scalar := range s.Scalars {
+		fmt.Fprintf(&b, "\nscalar %s\n", scalar)
+	}
+
+	for _, name := range s.order {
+		t := s.types[name]
+		b.WriteString("\n")
+		if t.Description != "" {
+			fmt.Fprintf(&b, "%q\n", t.Description)
+		}
+		fmt.Fprintf(&b, "type %s {\n", t.Name)
+		for _, f := range t.Fields {
+			if f.Description != "" {
+				fmt.Fprintf(&b, "  %q\n", f.Description)
+			}
+			b.WriteString("  " + f.Name)
+			if len(f.Args) > 0 {
+				args := make([]string, len(f.Args))
+				for i, a := range f.Args {
+					args[i] = a.Name + ": " + a.Type
+					if str, ok := a.Default.(string); ok {
+						args[i] += fmt.Sprintf(" = %q", str)
+					} else if a.Default != nil {
+						args[i] += fmt.Sprintf(" = %v", a.Default)
+					}
+				}
+				b.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			b.WriteString(": " + f.Type + "\n")
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
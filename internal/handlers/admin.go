@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	watcher *config.Watcher
+}
+
+func NewAdminHandler(watcher *config.Watcher) *AdminHandler {
+	return &AdminHandler{watcher: watcher}
+}
+
+// authorize checks the admin key of the current configuration, so a
+// rotated key takes effect on reload; admin endpoints are disabled when no
+// key is configured.
+func (h *AdminHandler) authorize(c *gin.Context) bool {
+	key := h.watcher.Current().Server.AdminAPIKey
+	if key == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin access is not enabled"})
+		return false
+	}
+
+	provided := c.GetHeader("X-API-Key")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return false
+	}
+
+	return true
+}
+
+// RequireKey guards routes outside the admin handler, such as creating
+// workspaces, with the admin key.
+func (h *AdminHandler) RequireKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.authorize(c) {
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ReloadConfig re-reads .env and the environment and applies the settings
+// that can change at runtime. The response lists what changed.
+func (h *AdminHandler) ReloadConfig(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	changes, err := h.watcher.Reload()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reload configuration"})
+		log.Printf("Warning: failed to reload configuration: %v", err)
+		return
+	}
+
+	log.Printf("Configuration reloaded by admin request from %s: %v", c.ClientIP(), changes)
+	c.JSON(http.StatusOK, gin.H{"reloaded": true, "changes": changes})
+}
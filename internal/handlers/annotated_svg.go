@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnnotatedSVG serves one page (0-based, optionally ending in ".svg") as an
+// SVG with its background and, over it, an outline of every field where it
+// prints labelled with its DataKey. Checkbox groups outline each box with its
+// value. It lets reviewers check a template in any browser without the
+// editor; calibrations and safe margins apply as in generated PDFs.
+func (h *PDFHandler) AnnotatedSVG(c *gin.Context) {
+	pageIndex, err := strconv.Atoi(strings.TrimSuffix(c.Param("page"), ".svg"))
+	if err != nil || pageIndex < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index"})
+		return
+	}
+
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	resolved, err := h.resolveTemplate(template)
+	if err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+
+	if pageIndex >= renderedPageCount(resolved) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Page not found"})
+		return
+	}
+
+	background, err := h.annotatedBackground(resolved, pageIndex)
+	if err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+
+	var fields []gormmodels.Field
+	for _, field := range resolved.Fields {
+		// Legacy single-page templates draw every field on their one page.
+		if field.PageIndex == pageIndex || len(resolved.SVGFiles) == 0 {
+			fields = append(fields, field)
+		}
+	}
+	applyCalibrations(fields, resolved.Calibrations)
+	applySafeMargins(fields, resolved.PrintOptions)
+
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "image/svg+xml", []byte(annotatedPageSVG(background, fields)))
+}
+
+// annotatedBackground loads the background of a page as a data URI, or ""
+// when the page has none.
+func (h *PDFHandler) annotatedBackground(tmpl *gormmodels.Template, pageIndex int) (string, error) {
+	if len(tmpl.SVGFiles) == 0 {
+		if h.SVGFilesOnly || tmpl.SVGBackground == "" {
+			return "", nil
+		}
+		background, err := h.convertToDataURI(tmpl.SVGBackground)
+		var genErr *GenerationError
+		if err != nil && !errors.As(err, &genErr) {
+			return "", storageError("STORAGE_SVG_FETCH", "Failed to load template background", "Check that the template's SVG file still exists in storage.", err)
+		}
+		return background, err
+	}
+
+	for _, svgFile := range tmpl.SVGFiles {
+		if svgFile.PageIndex == pageIndex {
+			return h.pageBackgroundURI(tmpl, &svgFile)
+		}
+	}
+	return "", nil
+}
+
+// annotatedPageSVG draws the field outlines over the background, which is
+// scaled to cover the page as in generated PDFs.
+func annotatedPageSVG(background string, fields []gormmodels.Field) string {
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		pageWidthPx, pageHeightPx, pageWidthPx, pageHeightPx)
+	svg.WriteString(`<style>.field { fill: rgba(224, 32, 32, 0.08); stroke: #e02020; stroke-width: 0.8; stroke-dasharray: 3 2; } .label { font: 8px sans-serif; fill: #c01010; }</style>`)
+	if background != "" {
+		fmt.Fprintf(&svg, `<image href="%s" x="0" y="0" width="%d" height="%d" preserveAspectRatio="xMidYMid slice"/>`,
+			html.EscapeString(background), pageWidthPx, pageHeightPx)
+	}
+
+	svg.WriteString(`<g id="fields">`)
+	for _, field := range fields {
+		if len(field.Boxes) > 0 {
+			for _, box := range field.Boxes {
+				annotateRect(&svg, box.Top, box.Left, box.Width, box.Height, field.DataKey+"="+box.Value)
+			}
+			continue
+		}
+		annotateRect(&svg, field.PositionTop, field.PositionLeft, field.PositionWidth, field.PositionHeight, field.DataKey)
+	}
+	svg.WriteString(`</g></svg>`)
+	return svg.String()
+}
+
+// annotateRect outlines a rectangle and writes its label just above it, or
+// inside it at the top of the page.
+func annotateRect(svg *strings.Builder, top, left, width, height int, label string) {
+	fmt.Fprintf(svg, `<rect class="field" x="%d" y="%d" width="%d" height="%d"><title>%s</title></rect>`,
+		left, top, width, height, html.EscapeString(label))
+	labelY := top - 2
+	if labelY < 8 {
+		labelY = top + 8
+	}
+	fmt.Fprintf(svg, `<text class="label" x="%d" y="%d">%s</text>`, left, labelY, html.EscapeString(label))
+}
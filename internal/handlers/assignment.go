@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AssignmentHandler assigns submissions to staff users and serves their work
+// queues.
+type AssignmentHandler struct {
+	assignmentService *services.AssignmentService
+	formService       *services.FormService
+	templateService   *services.TemplateService
+}
+
+func NewAssignmentHandler(assignmentService *services.AssignmentService, formService *services.FormService, templateService *services.TemplateService) *AssignmentHandler {
+	return &AssignmentHandler{
+		assignmentService: assignmentService,
+		formService:       formService,
+		templateService:   templateService,
+	}
+}
+
+type AssignRequest struct {
+	AssigneeID string     `json:"assigneeId" binding:"required"`
+	DueAt      *time.Time `json:"dueAt"`
+	Note       string     `json:"note" binding:"max=1024"`
+}
+
+type ReassignRequest struct {
+	FromUserID string `json:"fromUserId" binding:"required"`
+	ToUserID   string `json:"toUserId" binding:"required"`
+}
+
+// Assign puts a submission in a staff user's queue, replacing any existing
+// assignment.
+func (h *AssignmentHandler) Assign(c *gin.Context) {
+	var req AssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if h.checkSubmissionAccess(c) {
+		return
+	}
+
+	assignment, err := h.assignmentService.Assign(services.AssignRequest{
+		SubmissionID: c.Param("id"),
+		AssigneeID:   req.AssigneeID,
+		WorkspaceID:  c.GetString(auth.ContextWorkspaceID),
+		DueAt:        req.DueAt,
+		Note:         req.Note,
+		AssignedBy:   c.GetString(auth.ContextUserID),
+	})
+	if err != nil {
+		writeServiceError(c, "Failed to assign form submission", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+func (h *AssignmentHandler) Get(c *gin.Context) {
+	assignment, err := h.assignmentService.Get(c.Param("id"), c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch assignment", err)
+		return
+	}
+
+	if assignment == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+// Complete marks an assignment done. Only the assignee or a workspace admin
+// can complete it.
+func (h *AssignmentHandler) Complete(c *gin.Context) {
+	workspaceID := c.GetString(auth.ContextWorkspaceID)
+	assignment, err := h.assignmentService.Get(c.Param("id"), workspaceID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch assignment", err)
+		return
+	}
+
+	if assignment == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+
+	if assignment.AssigneeID != c.GetString(auth.ContextUserID) && c.GetString(auth.ContextRole) != gormmodels.RoleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the assignee can complete this assignment"})
+		return
+	}
+
+	assignment, err = h.assignmentService.Complete(c.Param("id"), workspaceID)
+	if err != nil {
+		writeServiceError(c, "Failed to complete assignment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, assignment)
+}
+
+func (h *AssignmentHandler) Unassign(c *gin.Context) {
+	if h.checkSubmissionAccess(c) {
+		return
+	}
+
+	if err := h.assignmentService.Unassign(c.Param("id"), c.GetString(auth.ContextWorkspaceID)); err != nil {
+		writeServiceError(c, "Failed to delete assignment", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Assignment deleted successfully"})
+}
+
+// MyQueue lists the current user's assignments, soonest due first. The
+// status query parameter picks open (the default) or done assignments, and
+// overdue=true keeps only open assignments past their due date.
+func (h *AssignmentHandler) MyQueue(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != gormmodels.AssignmentOpen && status != gormmodels.AssignmentDone {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status", "details": "status must be open or done"})
+		return
+	}
+	overdue, _ := strconv.ParseBool(c.Query("overdue"))
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	items, total, err := h.assignmentService.Queue(services.QueueFilter{
+		AssigneeID: c.GetString(auth.ContextUserID),
+		Status:     status,
+		Overdue:    overdue,
+		Limit:      limit,
+		Offset:     max(offset, 0),
+	})
+	if err != nil {
+		writeServiceError(c, "Failed to fetch work queue", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"items": items,
+		"total": total,
+	})
+}
+
+// Workload counts the assignments of every user in the workspace.
+func (h *AssignmentHandler) Workload(c *gin.Context) {
+	workloads, err := h.assignmentService.Workloads(c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch workload", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, workloads)
+}
+
+// Reassign moves all open assignments of one user to another.
+func (h *AssignmentHandler) Reassign(c *gin.Context) {
+	var req ReassignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	moved, err := h.assignmentService.Reassign(req.FromUserID, req.ToUserID, c.GetString(auth.ContextWorkspaceID), c.GetString(auth.ContextUserID))
+	if err != nil {
+		writeServiceError(c, "Failed to reassign assignments", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reassigned": moved})
+}
+
+// checkSubmissionAccess refuses the request unless the submission exists and
+// the workspace can use its template. It writes the response and returns
+// true when refused.
+func (h *AssignmentHandler) checkSubmissionAccess(c *gin.Context) bool {
+	_, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessUse)
+	return refused
+}
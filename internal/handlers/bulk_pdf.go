@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBulkPDFs bounds the submissions one bulk generation renders.
+const maxBulkPDFs = 200
+
+// defaultBulkWorkers is the number of PDFs rendered at once in a bulk
+// generation when BulkWorkers is unset.
+const defaultBulkWorkers = 4
+
+type BulkGenerateRequest struct {
+	// SubmissionIDs picks the submissions to render; without it every
+	// submission of the template with Status (any status if empty) is.
+	SubmissionIDs []string `json:"submissionIds" binding:"max=200"`
+	Status        string   `json:"status"`
+}
+
+// bulkResult is one rendered submission of a bulk generation.
+type bulkResult struct {
+	submission gormmodels.FormSubmission
+	pdf        []byte
+	err        error
+}
+
+// bulkManifestEntry describes one submission in the manifest.json of a bulk
+// generation archive.
+type bulkManifestEntry struct {
+	SubmissionID string `json:"submissionId"`
+	File         string `json:"file,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// GenerateBulk renders the PDFs of many submissions of a template and streams
+// them back as a ZIP archive, one "<submissionId>.pdf" per submission,
+// followed by manifest.json listing every submission with its file or the
+// error that kept it out. PDFs are rendered BulkWorkers at a time and written
+// as they finish, so the archive is not in request order.
+func (h *PDFHandler) GenerateBulk(c *gin.Context) {
+	var req BulkGenerateRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+	}
+
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	submissions, ok := h.bulkSubmissions(c, template.ID, req)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-pdfs.zip"`, template.ID))
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	manifest := make([]bulkManifestEntry, 0, len(submissions))
+	for result := range h.renderBulk(c.Request.Context(), submissions) {
+		entry := bulkManifestEntry{SubmissionID: result.submission.ID}
+		if result.err != nil {
+			entry.Error = result.err.Error()
+			manifest = append(manifest, entry)
+			continue
+		}
+
+		entry.File = result.submission.ID + ".pdf"
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: entry.File, Method: zip.Deflate, Modified: time.Now()})
+		if err == nil {
+			_, err = w.Write(result.pdf)
+		}
+		if err != nil {
+			// The client went away; the remaining renders stop with the request.
+			c.Error(err)
+			return
+		}
+		c.Writer.Flush()
+		manifest = append(manifest, entry)
+		h.eventService.Record(gormmodels.EventPDFGenerated, result.submission.ID, result.submission.TemplateID, nil)
+	}
+
+	if w, err := archive.Create("manifest.json"); err == nil {
+		json.NewEncoder(w).Encode(manifest)
+	}
+	if err := archive.Close(); err != nil {
+		c.Error(err)
+	}
+}
+
+// bulkSubmissions loads the submissions a bulk generation renders, answering
+// the request itself when they cannot be rendered.
+func (h *PDFHandler) bulkSubmissions(c *gin.Context, templateID string, req BulkGenerateRequest) ([]gormmodels.FormSubmission, bool) {
+	workspaceID := c.GetString(auth.ContextWorkspaceID)
+	if len(req.SubmissionIDs) == 0 {
+		submissions, total, err := h.formService.Find(services.SubmissionFilter{
+			TemplateID: templateID,
+			Status:     req.Status,
+			Limit:      maxBulkPDFs,
+			VisibleTo:  &workspaceID,
+		})
+		if err != nil {
+			writeServiceError(c, "Failed to fetch form submissions", err)
+			return nil, false
+		}
+		if total > maxBulkPDFs {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Too many submissions", "details": fmt.Sprintf("%d submissions match; at most %d can be generated at once, pick them with submissionIds", total, maxBulkPDFs)})
+			return nil, false
+		}
+		if len(submissions) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "No submissions to generate"})
+			return nil, false
+		}
+		return submissions, true
+	}
+
+	submissions, err := h.formService.FindByIDs(templateID, req.SubmissionIDs, workspaceID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch form submissions", err)
+		return nil, false
+	}
+	found := make(map[string]bool, len(submissions))
+	for _, submission := range submissions {
+		found[submission.ID] = true
+	}
+	var missing []string
+	for _, id := range req.SubmissionIDs {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown submissions", "details": "not submissions of this template: " + strings.Join(missing, ", ")})
+		return nil, false
+	}
+	return submissions, true
+}
+
+// renderBulk renders the submissions with a pool of BulkWorkers workers. The
+// channel is closed once every submission is rendered or ctx is done.
+func (h *PDFHandler) renderBulk(ctx context.Context, submissions []gormmodels.FormSubmission) <-chan bulkResult {
+	workers := h.BulkWorkers
+	if workers <= 0 {
+		workers = defaultBulkWorkers
+	}
+
+	jobs := make(chan gormmodels.FormSubmission)
+	results := make(chan bulkResult)
+	var wg sync.WaitGroup
+	for i := 0; i < min(workers, len(submissions)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for submission := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				_, pdf, err := h.RenderSubmission(ctx, &submission)
+				select {
+				case results <- bulkResult{submission: submission, pdf: pdf, err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for _, submission := range submissions {
+			select {
+			case jobs <- submission:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
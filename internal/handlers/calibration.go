@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalibrationHandler struct {
+	calibrationService *services.CalibrationService
+	templateService    *services.TemplateService
+}
+
+func NewCalibrationHandler(calibrationService *services.CalibrationService, templateService *services.TemplateService) *CalibrationHandler {
+	return &CalibrationHandler{
+		calibrationService: calibrationService,
+		templateService:    templateService,
+	}
+}
+
+type PointRequest struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type ReferenceMarkRequest struct {
+	SVG  PointRequest `json:"svg"`
+	Page PointRequest `json:"page"`
+}
+
+type CalibrateRequest struct {
+	References []ReferenceMarkRequest `json:"references" binding:"required,len=2"`
+}
+
+func (h *CalibrationHandler) Calibrate(c *gin.Context) {
+	templateID := c.Param("id")
+
+	pageIndex, err := strconv.Atoi(c.Param("pageIndex"))
+	if err != nil || pageIndex < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index"})
+		return
+	}
+
+	var req CalibrateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.GetByID(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	a := toReferencePoint(req.References[0])
+	b := toReferencePoint(req.References[1])
+
+	if _, _, _, _, err := services.ComputeCalibration(a, b); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reference marks", "details": err.Error()})
+		return
+	}
+
+	calibration, err := h.calibrationService.Calibrate(templateID, pageIndex, a, b)
+	if err != nil {
+		writeServiceError(c, "Failed to save calibration", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, calibration)
+}
+
+func (h *CalibrationHandler) GetByTemplateID(c *gin.Context) {
+	templateID := c.Param("id")
+
+	calibrations, err := h.calibrationService.GetByTemplateID(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch calibrations", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, calibrations)
+}
+
+func (h *CalibrationHandler) Delete(c *gin.Context) {
+	templateID := c.Param("id")
+
+	pageIndex, err := strconv.Atoi(c.Param("pageIndex"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index"})
+		return
+	}
+
+	if err := h.calibrationService.Delete(templateID, pageIndex); err != nil {
+		writeServiceError(c, "Failed to delete calibration", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calibration deleted successfully"})
+}
+
+type SheetPositionsRequest struct {
+	Marks  []services.SheetMark `json:"marks" binding:"required,min=1,dive"`
+	DryRun bool                 `json:"dryRun"`
+}
+
+// ImportPositions moves fields to the boxes marked on a printed calibration
+// sheet of the page. With dryRun the proposed layout entries are returned
+// without saving them.
+func (h *CalibrationHandler) ImportPositions(c *gin.Context) {
+	pageIndex, err := strconv.Atoi(c.Param("pageIndex"))
+	if err != nil || pageIndex < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index"})
+		return
+	}
+
+	var req SheetPositionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	for i, mark := range req.Marks {
+		if mark.DataKey == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid marks", "details": fmt.Sprintf("marks[%d]: dataKey is required", i)})
+			return
+		}
+		if mark.Occurrence < 0 || mark.Top < 0 || mark.Left < 0 || mark.Width <= 0 || mark.Height <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid marks", "details": fmt.Sprintf("marks[%d]: position must not be negative and the box must have a size", i)})
+			return
+		}
+		if mark.Unit != "" && mark.Unit != "px" && mark.Unit != "mm" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid marks", "details": fmt.Sprintf("marks[%d]: unit must be px or mm", i)})
+			return
+		}
+	}
+
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	var calibration *gormmodels.PageCalibration
+	for i := range template.Calibrations {
+		if template.Calibrations[i].PageIndex == pageIndex {
+			calibration = &template.Calibrations[i]
+		}
+	}
+
+	layout := h.templateService.ExportLayout(template)
+	unmatched, err := services.ApplySheetMarks(layout, pageIndex, calibration, req.Marks)
+	if err != nil {
+		writeServiceError(c, "Failed to convert marks", err)
+		return
+	}
+
+	if req.DryRun {
+		marked := make(map[string]bool, len(req.Marks))
+		for _, mark := range req.Marks {
+			marked[fmt.Sprintf("%s#%d", mark.DataKey, mark.Occurrence)] = true
+		}
+		fields := []services.FieldLayout{}
+		for _, entry := range layout.Fields {
+			if marked[fmt.Sprintf("%s#%d", entry.DataKey, entry.Occurrence)] {
+				fields = append(fields, entry)
+			}
+		}
+		c.JSON(http.StatusOK, gin.H{"fields": fields, "unmatched": unmatched})
+		return
+	}
+
+	if _, err := h.templateService.ImportLayout(template, layout); err != nil {
+		writeServiceError(c, "Failed to import positions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"updated": len(req.Marks) - len(unmatched), "unmatched": unmatched})
+}
+
+func toReferencePoint(r ReferenceMarkRequest) services.ReferencePoint {
+	return services.ReferencePoint{
+		SVGX:  r.SVG.X,
+		SVGY:  r.SVG.Y,
+		PageX: r.Page.X,
+		PageY: r.Page.Y,
+	}
+}
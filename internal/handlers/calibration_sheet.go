@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// sheetGridPx is the spacing of the calibration sheet's fine grid;
+	// every sheetLabelPx a darker line is drawn and labelled.
+	sheetGridPx  = 10
+	sheetLabelPx = 50
+)
+
+// CalibrationSheet prints a page of the template with a pixel grid and rulers
+// over its background, so positions can be measured on paper and posted back
+// to the positions endpoint. The current fields are outlined where they print
+// unless fields=false.
+func (h *PDFHandler) CalibrationSheet(c *gin.Context) {
+	pageIndex, err := strconv.Atoi(c.Param("pageIndex"))
+	if err != nil || pageIndex < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index"})
+		return
+	}
+
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	resolved, err := h.resolveTemplate(template)
+	if err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+
+	if pageIndex >= renderedPageCount(resolved) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Page not found"})
+		return
+	}
+
+	var background string
+	for i := range resolved.SVGFiles {
+		if resolved.SVGFiles[i].PageIndex != pageIndex {
+			continue
+		}
+		content, err := h.pageSVGContent(resolved, &resolved.SVGFiles[i])
+		if err != nil {
+			writeGenerationError(c, storageError("STORAGE_MISSING_PAGE", fmt.Sprintf("Failed to load background for page %d", pageIndex+1),
+				"The page's SVG file could not be read from storage; re-upload it for this page.", err))
+			return
+		}
+		background = "data:image/svg+xml;base64," + base64.StdEncoding.EncodeToString(content)
+		break
+	}
+
+	// Outlines come from the template's own layout, so their labels carry
+	// the occurrence numbers the positions endpoint matches on.
+	var outlines []sheetOutline
+	if c.Query("fields") != "false" {
+		outlines = h.sheetOutlines(template, pageIndex)
+	}
+
+	pdfBytes, err := h.htmlToPDF(c.Request.Context(), calibrationSheetHTML(resolved, pageIndex, background, outlines), a4Paper, h.Deterministic)
+	if err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+	pdfBytes = h.normalizePDF(c, pdfBytes, h.Deterministic)
+
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-page-%d-calibration.pdf"`, template.ID, pageIndex+1))
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// calibrationSheetHTML lays an SVG grid over the page background. Grid
+// coordinates are page pixels, the same units the positions endpoint takes.
+func calibrationSheetHTML(tmpl *gormmodels.Template, pageIndex int, background string, outlines []sheetOutline) string {
+	var overlay strings.Builder
+
+	for x := sheetGridPx; x < pageWidthPx; x += sheetGridPx {
+		overlay.WriteString(sheetLine(x, 0, x, pageHeightPx, x%sheetLabelPx == 0))
+		if x%sheetLabelPx == 0 {
+			fmt.Fprintf(&overlay, `<text x="%d" y="8" class="label" text-anchor="middle">%d</text>`, x, x)
+			fmt.Fprintf(&overlay, `<text x="%d" y="%d" class="label" text-anchor="middle">%d</text>`, x, pageHeightPx-2, x)
+		}
+	}
+	for y := sheetGridPx; y < pageHeightPx; y += sheetGridPx {
+		overlay.WriteString(sheetLine(0, y, pageWidthPx, y, y%sheetLabelPx == 0))
+		if y%sheetLabelPx == 0 {
+			fmt.Fprintf(&overlay, `<text x="2" y="%d" class="label">%d</text>`, y+3, y)
+			fmt.Fprintf(&overlay, `<text x="%d" y="%d" class="label" text-anchor="end">%d</text>`, pageWidthPx-2, y+3, y)
+		}
+	}
+
+	for _, outline := range outlines {
+		field := outline.field
+		fmt.Fprintf(&overlay, `<rect x="%d" y="%d" width="%d" height="%d" class="field"/>`,
+			field.PositionLeft, field.PositionTop, field.PositionWidth, field.PositionHeight)
+		fmt.Fprintf(&overlay, `<text x="%d" y="%d" class="field-label">%s</text>`,
+			field.PositionLeft+1, field.PositionTop+7, html.EscapeString(outline.label))
+	}
+
+	backgroundStyle := ""
+	if background != "" {
+		backgroundStyle = fmt.Sprintf("background-image: url('%s');", background)
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<style>
+@page { margin: 0; size: A4; }
+body { margin: 0; padding: 0; }
+.page { position: relative; width: %dpx; height: %dpx; background-size: cover; background-repeat: no-repeat; background-position: center; }
+svg { position: absolute; top: 0; left: 0; }
+line { stroke: #00a0e0; stroke-width: 0.25; stroke-opacity: 0.5; }
+line.major { stroke-width: 0.6; stroke-opacity: 0.8; }
+.label { font: 6px sans-serif; fill: #0070a0; }
+.field { fill: #ff4040; fill-opacity: 0.08; stroke: #e02020; stroke-width: 0.6; }
+.field-label { font: 6px sans-serif; fill: #c01010; }
+.title { font: bold 7px sans-serif; fill: #0070a0; }
+</style>
+</head>
+<body>
+<div class="page" style="%s">
+<svg width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">
+%s<text x="14" y="18" class="title">%s - page %d - grid %dpx</text>
+</svg>
+</div>
+</body>
+</html>`, pageWidthPx, pageHeightPx, backgroundStyle, pageWidthPx, pageHeightPx, pageWidthPx, pageHeightPx,
+		overlay.String(), html.EscapeString(tmpl.DisplayName), pageIndex+1, sheetGridPx)
+}
+
+func sheetLine(x1, y1, x2, y2 int, major bool) string {
+	class := ""
+	if major {
+		class = ` class="major"`
+	}
+	return fmt.Sprintf(`<line x1="%d" y1="%d" x2="%d" y2="%d"%s/>`, x1, y1, x2, y2, class)
+}
+
+// sheetOutline is a field outlined on a calibration sheet at its printed position.
+type sheetOutline struct {
+	field gormmodels.Field
+	label string
+}
+
+func (h *PDFHandler) sheetOutlines(template *gormmodels.Template, pageIndex int) []sheetOutline {
+	var outlines []sheetOutline
+	for _, entry := range h.templateService.ExportLayout(template).Fields {
+		if entry.PageIndex != pageIndex {
+			continue
+		}
+
+		fields := []gormmodels.Field{{
+			PageIndex:      entry.PageIndex,
+			PositionTop:    int(entry.Position.Top),
+			PositionLeft:   int(entry.Position.Left),
+			PositionWidth:  int(entry.Position.Width),
+			PositionHeight: int(entry.Position.Height),
+		}}
+		applyCalibrations(fields, template.Calibrations)
+
+		label := entry.DataKey
+		if entry.Occurrence > 0 {
+			label = fmt.Sprintf("%s #%d", entry.DataKey, entry.Occurrence)
+		}
+		outlines = append(outlines, sheetOutline{field: fields[0], label: label})
+	}
+	return outlines
+}
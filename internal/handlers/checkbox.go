@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// defaultCheckMark is drawn in ticked boxes when a field sets no checkMark.
+const defaultCheckMark = "✓"
+
+func toCheckboxBoxes(boxes []CheckboxBoxRequest) []gormmodels.CheckboxBox {
+	if len(boxes) == 0 {
+		return nil
+	}
+	result := make([]gormmodels.CheckboxBox, len(boxes))
+	for i, box := range boxes {
+		result[i] = gormmodels.CheckboxBox{
+			Value:  strings.TrimSpace(box.Value),
+			Top:    int(box.Position.Top),
+			Left:   int(box.Position.Left),
+			Width:  int(box.Position.Width),
+			Height: int(box.Position.Height),
+		}
+	}
+	return result
+}
+
+func toCheckboxBoxResponses(boxes []gormmodels.CheckboxBox) []CheckboxBoxResponse {
+	if len(boxes) == 0 {
+		return nil
+	}
+	result := make([]CheckboxBoxResponse, len(boxes))
+	for i, box := range boxes {
+		result[i] = CheckboxBoxResponse{
+			Value: box.Value,
+			Position: PositionResponse{
+				Top:    float64(box.Top),
+				Left:   float64(box.Left),
+				Width:  float64(box.Width),
+				Height: float64(box.Height),
+			},
+		}
+	}
+	return result
+}
+
+func checkMark(field gormmodels.Field) string {
+	if field.CheckMark != "" {
+		return field.CheckMark
+	}
+	return defaultCheckMark
+}
+
+// checkboxMarksHTML draws the field's check mark in every box the value
+// ticks.
+func checkboxMarksHTML(field gormmodels.Field, value interface{}) string {
+	var b strings.Builder
+	for _, box := range field.CheckedBoxes(value) {
+		b.WriteString(checkboxMarkHTML(field, box))
+	}
+	return b.String()
+}
+
+// checkboxMarkHTML draws the field's check mark centered in a box. The mark is
+// sized to the box.
+func checkboxMarkHTML(field gormmodels.Field, box gormmodels.CheckboxBox) string {
+	size := box.Height
+	if box.Width < size {
+		size = box.Width
+	}
+	return fmt.Sprintf(`
+        <div class="%s checkbox-mark" style="
+            top: %dpx;
+            left: %dpx;
+            width: %dpx;
+            height: %dpx;
+            padding: 0;
+            align-items: center;
+            justify-content: center;
+            font-size: %dpx;
+            line-height: 1;
+            font-family: %s;
+        ">%s</div>`, fieldClass(field), box.Top, box.Left, box.Width, box.Height, size*4/5, fontStack(field.FontFamily), html.EscapeString(checkMark(field)))
+}
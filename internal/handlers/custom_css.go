@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+)
+
+// customCSSScope is the body class template CSS is scoped to.
+const customCSSScope = "template-custom"
+
+var cssClassNamePattern = regexp.MustCompile(`^-?[_a-zA-Z][_a-zA-Z0-9-]*$`)
+
+// sanitizeTemplateStyles validates the template's custom CSS and field class
+// names, storing the CSS without comments.
+func sanitizeTemplateStyles(template *gormmodels.Template) error {
+	css, err := utils.SanitizeTemplateCSS(template.CustomCSS)
+	if err != nil {
+		return err
+	}
+	template.CustomCSS = css
+
+	for _, field := range template.Fields {
+		for _, class := range strings.Fields(field.ClassName) {
+			if !cssClassNamePattern.MatchString(class) {
+				return fmt.Errorf("field %s: invalid class name %q", field.DataKey, class)
+			}
+		}
+	}
+	return nil
+}
+
+// applyCustomCSS injects the template's CSS, scoped to the document body,
+// after the built-in styles so it can override them.
+func applyCustomCSS(htmlContent string, css string) (string, error) {
+	if css == "" {
+		return htmlContent, nil
+	}
+
+	scoped, err := utils.ScopeCSS(css, "."+customCSSScope)
+	if err != nil {
+		return "", templateError("TEMPLATE_CSS", "Template custom CSS is invalid",
+			"Fix or clear the template's customCss.", err)
+	}
+
+	htmlContent = strings.Replace(htmlContent, "</head>", "<style>\n"+scoped+"</style>\n</head>", 1)
+	return strings.Replace(htmlContent, "<body>", `<body class="`+customCSSScope+`">`, 1), nil
+}
+
+// fieldClass returns the class attribute of a field's element, keeping only
+// valid custom class names.
+func fieldClass(field gormmodels.Field) string {
+	classes := []string{"field"}
+	if field.Flow {
+		classes = append(classes, "flow")
+	}
+	for _, class := range strings.Fields(field.ClassName) {
+		if cssClassNamePattern.MatchString(class) {
+			classes = append(classes, class)
+		}
+	}
+	return strings.Join(classes, " ")
+}
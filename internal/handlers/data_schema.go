@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDataSchema returns a JSON Schema of the formData a template expects,
+// with nested DataKeys expanded into objects and arrays. Included templates
+// are resolved first, matching what generation renders.
+func (h *TemplateHandler) GetDataSchema(c *gin.Context) {
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	resolved, err := h.templateService.ResolveIncludes(template)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to resolve included templates", "details": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", "application/schema+json")
+	c.JSON(http.StatusOK, services.BuildDataSchema(resolved, h.fieldOptionLists(resolved.Fields)))
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DebugRecordingHandler struct {
+	recordingService *services.DebugRecordingService
+	auditService     *services.AuditService
+	supportKey       string
+}
+
+func NewDebugRecordingHandler(recordingService *services.DebugRecordingService, auditService *services.AuditService, supportKey string) *DebugRecordingHandler {
+	return &DebugRecordingHandler{
+		recordingService: recordingService,
+		auditService:     auditService,
+		supportKey:       supportKey,
+	}
+}
+
+type EnableDebugRecordingRequest struct {
+	Minutes int    `json:"minutes" binding:"required,min=1"`
+	Reason  string `json:"reason" binding:"max=500"`
+}
+
+func (h *DebugRecordingHandler) Get(c *gin.Context) {
+	window, err := h.recordingService.Window(c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch debug recording window", err)
+		return
+	}
+
+	if window == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "window": window})
+}
+
+// Enable opts the workspace in to debug recording for the given number of
+// minutes; generation and OCR requests are recorded until it expires.
+func (h *DebugRecordingHandler) Enable(c *gin.Context) {
+	var req EnableDebugRecordingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	workspaceID := c.GetString(auth.ContextWorkspaceID)
+	window, err := h.recordingService.Enable(workspaceID, c.GetString(auth.ContextUserID), req.Reason, time.Duration(req.Minutes)*time.Minute)
+	if err != nil {
+		writeServiceError(c, "Failed to enable debug recording", err)
+		return
+	}
+
+	h.audit(c, "workspace.debug_recording.enable", workspaceID)
+
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "window": window})
+}
+
+func (h *DebugRecordingHandler) Disable(c *gin.Context) {
+	workspaceID := c.GetString(auth.ContextWorkspaceID)
+	if err := h.recordingService.Disable(workspaceID); err != nil {
+		writeServiceError(c, "Failed to disable debug recording", err)
+		return
+	}
+
+	h.audit(c, "workspace.debug_recording.disable", workspaceID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Debug recording disabled"})
+}
+
+// List returns a workspace's recordings without their bodies, for support.
+func (h *DebugRecordingHandler) List(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	recordings, err := h.recordingService.List(c.Param("id"), limit)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch debug recordings", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, recordings)
+}
+
+// GetRecording returns one recording with its sanitized bodies, for support.
+func (h *DebugRecordingHandler) GetRecording(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	recording, err := h.recordingService.Get(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch debug recording", err)
+		return
+	}
+
+	if recording == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Debug recording not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, recording)
+}
+
+// authorize checks the support key; the support endpoints are disabled when
+// no key is configured.
+func (h *DebugRecordingHandler) authorize(c *gin.Context) bool {
+	if h.supportKey == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Support access is not enabled"})
+		return false
+	}
+
+	provided := c.GetHeader("X-API-Key")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(h.supportKey)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return false
+	}
+
+	return true
+}
+
+func (h *DebugRecordingHandler) audit(c *gin.Context, action, resource string) {
+	h.auditService.Record(&gormmodels.AuditLog{
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		UserID:      c.GetString(auth.ContextUserID),
+		TokenID:     c.GetString(auth.ContextTokenID),
+		Action:      action,
+		Resource:    resource,
+		IPAddress:   c.ClientIP(),
+	})
+}
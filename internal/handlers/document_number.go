@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NumberingHandler struct {
+	numberingService *services.NumberingService
+	templateService  *services.TemplateService
+}
+
+func NewNumberingHandler(numberingService *services.NumberingService, templateService *services.TemplateService) *NumberingHandler {
+	return &NumberingHandler{
+		numberingService: numberingService,
+		templateService:  templateService,
+	}
+}
+
+type ConfirmNumberRequest struct {
+	SubmissionID string `json:"submissionId" binding:"max=36"`
+}
+
+// Reserve holds the template's next document number, to be confirmed once
+// the document is generated.
+func (h *NumberingHandler) Reserve(c *gin.Context) {
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	reservation, err := h.numberingService.Reserve(template.ID, c.GetString(auth.ContextUserID))
+	if err != nil {
+		writeServiceError(c, "Failed to reserve document number", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, reservation)
+}
+
+// Confirm marks a reserved number as used. It may be called again with the
+// same body, for example when a generation is retried.
+func (h *NumberingHandler) Confirm(c *gin.Context) {
+	reservationID, err := strconv.ParseUint(c.Param("reservationId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
+		return
+	}
+
+	var req ConfirmNumberRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+	}
+
+	reservation, err := h.numberingService.Confirm(c.Param("id"), uint(reservationID), req.SubmissionID)
+	if err != nil {
+		writeServiceError(c, "Failed to confirm document number", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, reservation)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DocumentSearchHandler struct {
+	documentService *services.DocumentService
+}
+
+func NewDocumentSearchHandler(documentService *services.DocumentService) *DocumentSearchHandler {
+	return &DocumentSearchHandler{documentService: documentService}
+}
+
+// Search finds the caller's generated documents by the text of their PDF.
+// ?q= is the phrase to find; ?templateId= and ?limit= narrow the results.
+func (h *DocumentSearchHandler) Search(c *gin.Context) {
+	search := services.DocumentSearch{
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		Query:       c.Query("q"),
+		TemplateID:  c.Query("templateId"),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		search.Limit = value
+	}
+
+	matches, err := h.documentService.Search(search)
+	if err != nil {
+		writeServiceError(c, "Failed to search documents", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"documents": matches})
+}
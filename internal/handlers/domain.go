@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DomainHandler struct {
+	domainService *services.DomainService
+	auditService  *services.AuditService
+}
+
+func NewDomainHandler(domainService *services.DomainService, auditService *services.AuditService) *DomainHandler {
+	return &DomainHandler{
+		domainService: domainService,
+		auditService:  auditService,
+	}
+}
+
+type SetDomainRequest struct {
+	Domain string `json:"domain" binding:"required"`
+}
+
+// DomainResponse is a workspace domain with the DNS record that verifies it.
+type DomainResponse struct {
+	gormmodels.WorkspaceDomain
+	Verified bool               `json:"verified"`
+	Record   VerificationRecord `json:"verificationRecord"`
+}
+
+type VerificationRecord struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (h *DomainHandler) Get(c *gin.Context) {
+	domain, err := h.domainService.Get(c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch workspace domain", err)
+		return
+	}
+
+	if domain == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace has no custom domain"})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toDomainResponse(domain))
+}
+
+// Set claims a custom domain for the workspace. The response carries the TXT
+// record to publish before calling Verify.
+func (h *DomainHandler) Set(c *gin.Context) {
+	var req SetDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	domain, err := h.domainService.Set(c.GetString(auth.ContextWorkspaceID), req.Domain, c.GetString(auth.ContextUserID))
+	if err != nil {
+		writeServiceError(c, "Failed to set workspace domain", err)
+		return
+	}
+
+	h.audit(c, "workspace.domain.set", domain.Domain)
+
+	c.JSON(http.StatusOK, h.toDomainResponse(domain))
+}
+
+// Verify checks the domain's TXT record; public links use the domain from
+// the moment it succeeds.
+func (h *DomainHandler) Verify(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 10*time.Second)
+	defer cancel()
+
+	domain, err := h.domainService.Verify(ctx, c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "Failed to verify workspace domain", err)
+		return
+	}
+
+	if domain == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace has no custom domain"})
+		return
+	}
+
+	h.audit(c, "workspace.domain.verify", domain.Domain)
+
+	c.JSON(http.StatusOK, h.toDomainResponse(domain))
+}
+
+func (h *DomainHandler) Remove(c *gin.Context) {
+	if err := h.domainService.Remove(c.GetString(auth.ContextWorkspaceID)); err != nil {
+		writeServiceError(c, "Failed to remove workspace domain", err)
+		return
+	}
+
+	h.audit(c, "workspace.domain.remove", c.GetString(auth.ContextWorkspaceID))
+
+	c.JSON(http.StatusOK, gin.H{"message": "Workspace domain removed successfully"})
+}
+
+func (h *DomainHandler) toDomainResponse(domain *gormmodels.WorkspaceDomain) DomainResponse {
+	name, value := h.domainService.VerificationRecord(domain)
+	return DomainResponse{
+		WorkspaceDomain: *domain,
+		Verified:        domain.Verified(),
+		Record:          VerificationRecord{Type: "TXT", Name: name, Value: value},
+	}
+}
+
+func (h *DomainHandler) audit(c *gin.Context, action, resource string) {
+	h.auditService.Record(&gormmodels.AuditLog{
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		UserID:      c.GetString(auth.ContextUserID),
+		TokenID:     c.GetString(auth.ContextTokenID),
+		Action:      action,
+		Resource:    resource,
+		IPAddress:   c.ClientIP(),
+	})
+}
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EditLockHandler struct {
+	editLockService *services.EditLockService
+	templateService *services.TemplateService
+}
+
+func NewEditLockHandler(editLockService *services.EditLockService, templateService *services.TemplateService) *EditLockHandler {
+	return &EditLockHandler{
+		editLockService: editLockService,
+		templateService: templateService,
+	}
+}
+
+type EditLockRequest struct {
+	SessionID string `json:"sessionId" binding:"required,max=64"`
+	Name      string `json:"name" binding:"max=255"`
+	Force     bool   `json:"force"`
+}
+
+// lockStatus describes a template's lock from the point of view of the
+// calling session. The session ID itself is never echoed back.
+func (h *EditLockHandler) lockStatus(lock *gormmodels.TemplateLock, sessionID string) gin.H {
+	if lock == nil {
+		return gin.H{"locked": false, "ttlSeconds": int(h.editLockService.TTL().Seconds())}
+	}
+	return gin.H{
+		"locked":     true,
+		"heldByYou":  sessionID != "" && lock.SessionID == sessionID,
+		"lock":       lock,
+		"ttlSeconds": int(h.editLockService.TTL().Seconds()),
+	}
+}
+
+func (h *EditLockHandler) templateExists(c *gin.Context) bool {
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return false
+	}
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return false
+	}
+	return true
+}
+
+// Get reports who, if anyone, is editing the template.
+func (h *EditLockHandler) Get(c *gin.Context) {
+	lock, err := h.editLockService.Get(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template lock", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.lockStatus(lock, c.Query("sessionId")))
+}
+
+// Acquire takes or renews the editing lock. A lock held by another session
+// is reported with 409 so the editor can warn before changes are made.
+func (h *EditLockHandler) Acquire(c *gin.Context) {
+	var req EditLockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if !h.templateExists(c) {
+		return
+	}
+
+	holder := services.LockHolder{
+		SessionID: req.SessionID,
+		UserID:    c.GetString(auth.ContextUserID),
+		Name:      req.Name,
+	}
+
+	lock, err := h.editLockService.Acquire(c.Param("id"), holder, req.Force)
+	if errors.Is(err, services.ErrLockHeld) {
+		status := h.lockStatus(lock, req.SessionID)
+		status["error"] = "Template is being edited by someone else"
+		c.JSON(http.StatusConflict, status)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to acquire template lock", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.lockStatus(lock, req.SessionID))
+}
+
+// Heartbeat extends the caller's lock. If the lock lapsed or was taken over
+// the response is 409 with the current holder, if any.
+func (h *EditLockHandler) Heartbeat(c *gin.Context) {
+	var req EditLockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	lock, err := h.editLockService.Heartbeat(c.Param("id"), req.SessionID)
+	if errors.Is(err, services.ErrLockNotHeld) {
+		status := h.lockStatus(lock, req.SessionID)
+		status["error"] = "Template lock is no longer held by this session"
+		c.JSON(http.StatusConflict, status)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to renew template lock", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.lockStatus(lock, req.SessionID))
+}
+
+func (h *EditLockHandler) Release(c *gin.Context) {
+	sessionID := c.Query("sessionId")
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sessionId is required"})
+		return
+	}
+
+	if err := h.editLockService.Release(c.Param("id"), sessionID); err != nil {
+		writeServiceError(c, "Failed to release template lock", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template lock released"})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFormatEFiling is the export format of a template's e-filing payload.
+const exportFormatEFiling = "efiling"
+
+// Export downloads a submission in an agency format. format=efiling builds
+// the XML payload described by the template's e-filing export.
+func (h *FormHandler) Export(c *gin.Context) {
+	format := c.Query("format")
+	if format != exportFormatEFiling {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported export format", "details": "format must be efiling"})
+		return
+	}
+
+	submission, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessUse)
+	if refused {
+		return
+	}
+
+	template, err := h.templateService.GetByID(submission.TemplateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil || template.EFiling.RootElement == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template has no e-filing export"})
+		return
+	}
+
+	if err := maskRestricted(c, h.fieldAccess, submission); err != nil {
+		writeServiceError(c, "Failed to fetch form submission", err)
+		return
+	}
+
+	payload, err := services.BuildEFiling(template.EFiling, submission.FormData)
+	if err != nil {
+		var missing *services.EFilingMissing
+		if errors.As(err, &missing) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":   "Submission is missing required e-filing values",
+				"missing": missing.Elements,
+			})
+			return
+		}
+		writeServiceError(c, "Failed to build e-filing payload", err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-efiling.xml"`, submission.ID))
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", payload)
+}
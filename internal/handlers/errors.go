@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serviceErrorStatus maps a service error to the HTTP status it is reported with.
+func serviceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrValidation), errors.Is(err, utils.ErrInvalidCSS), errors.Is(err, auth.ErrUnknownScope):
+		return http.StatusBadRequest
+	case errors.Is(err, auth.ErrInvalidToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrVisionQuotaExceeded):
+		return http.StatusTooManyRequests
+	case errors.Is(err, services.ErrOCRFailed):
+		return http.StatusBadGateway
+	case errors.Is(err, services.ErrStorageUnavailable):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// writeServiceError responds to a failed service call with the status for the
+// error's category. Client errors carry the error text as details; storage
+// and internal failures only carry the message.
+func writeServiceError(c *gin.Context, message string, err error) {
+	status := serviceErrorStatus(err)
+	if status >= http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": message})
+		return
+	}
+	c.JSON(status, gin.H{"error": message, "details": err.Error()})
+}
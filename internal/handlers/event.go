@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EventHandler struct {
+	eventService *services.EventService
+}
+
+func NewEventHandler(eventService *services.EventService) *EventHandler {
+	return &EventHandler{eventService: eventService}
+}
+
+// List returns the caller's workspace events after ?since=, the cursor
+// returned by the previous call. Pass the returned cursor back to continue;
+// it stays put when there is nothing new.
+func (h *EventHandler) List(c *gin.Context) {
+	query := services.EventQuery{WorkspaceID: c.GetString(auth.ContextWorkspaceID)}
+
+	if since := c.Query("since"); since != "" {
+		cursor, err := strconv.ParseUint(since, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid since cursor", "details": err.Error()})
+			return
+		}
+		query.Since = cursor
+	}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+		query.Limit = value
+	}
+	if types := c.Query("types"); types != "" {
+		query.Types = strings.Split(types, ",")
+	}
+
+	events, err := h.eventService.List(query)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch events", err)
+		return
+	}
+
+	cursor := query.Since
+	if len(events) > 0 {
+		cursor = events[len(events)-1].Sequence
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": events,
+		"cursor": cursor,
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maskRestricted masks the values of fields the request's role may not view.
+// Every response and export that carries submission data passes its
+// submissions through here first.
+func maskRestricted(c *gin.Context, fieldAccess *services.FieldAccessService, submissions ...*gormmodels.FormSubmission) error {
+	role := c.GetString(auth.ContextRole)
+	keys := make(map[string]map[string]bool)
+	for _, submission := range submissions {
+		restricted, ok := keys[submission.TemplateID]
+		if !ok {
+			var err error
+			restricted, err = fieldAccess.RestrictedKeys(submission.TemplateID, role)
+			if err != nil {
+				return err
+			}
+			keys[submission.TemplateID] = restricted
+		}
+		services.MaskSubmission(submission, restricted)
+	}
+	return nil
+}
+
+// maskRestrictedList is maskRestricted over a slice of submissions.
+func maskRestrictedList(c *gin.Context, fieldAccess *services.FieldAccessService, submissions []gormmodels.FormSubmission) error {
+	pointers := make([]*gormmodels.FormSubmission, len(submissions))
+	for i := range submissions {
+		pointers[i] = &submissions[i]
+	}
+	return maskRestricted(c, fieldAccess, pointers...)
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetFieldGraph describes field relationships and server-side validation
+// rules so form builders can evaluate them the same way the server does.
+// Included templates are resolved first, matching what generation renders.
+func (h *TemplateHandler) GetFieldGraph(c *gin.Context) {
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	resolved, err := h.templateService.ResolveIncludes(template)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Failed to resolve included templates", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, services.BuildFieldGraph(resolved, h.fieldOptionLists(resolved.Fields)))
+}
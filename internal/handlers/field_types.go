@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/skip2/go-qrcode"
+)
+
+// FieldRenderer draws the fields of one type onto a page.
+type FieldRenderer interface {
+	// Validate checks the settings of a field when its template is saved.
+	Validate(field gormmodels.Field) error
+	// Text returns the characters the field draws in its font, for the font
+	// coverage check.
+	Text(field gormmodels.Field, value interface{}, htmlValue string) string
+	// Render returns the positioned HTML of the field. value is its form
+	// data value and htmlValue its prepared HTML, such as an embedded
+	// signature, or "" when it has none.
+	Render(field gormmodels.Field, value interface{}, htmlValue string) (string, error)
+}
+
+// fieldRenderers maps every field type to its renderer. A new field type is
+// added by registering its renderer here; templates with a type that is not
+// registered are rejected when saved and fail generation.
+var fieldRenderers = map[string]FieldRenderer{
+	services.FieldTypeText:          textField{},
+	services.FieldTypeNumber:        textField{},
+	services.FieldTypeDate:          textField{},
+	services.FieldTypeSignature:     textField{},
+	services.FieldTypeStamp:         textField{},
+	services.FieldTypeCheckbox:      checkboxField{},
+	services.FieldTypeCheckboxGroup: checkboxGroupField{},
+	services.FieldTypeImage:         imageField{},
+	services.FieldTypeQRCode:        qrCodeField{},
+	services.FieldTypeTable:         tableField{},
+}
+
+// fieldRendererFor returns the renderer of a field type. Fields stored
+// without a type are text.
+func fieldRendererFor(fieldType string) (FieldRenderer, bool) {
+	if fieldType == "" {
+		fieldType = services.FieldTypeText
+	}
+	renderer, ok := fieldRenderers[fieldType]
+	return renderer, ok
+}
+
+// fieldTypeNames lists the registered field types.
+func fieldTypeNames() []string {
+	names := make([]string, 0, len(fieldRenderers))
+	for name := range fieldRenderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkFieldTypes rejects fields of unknown types and checks the settings of
+// the others.
+func checkFieldTypes(fields []gormmodels.Field) error {
+	for _, f := range fields {
+		renderer, ok := fieldRendererFor(f.Type)
+		if !ok {
+			return fmt.Errorf("field %s has unknown type %q; supported types are %s", f.DataKey, f.Type, strings.Join(fieldTypeNames(), ", "))
+		}
+		if err := renderer.Validate(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderFields draws the fields of a page with the renderer of their type.
+// A field of an unknown type fails the generation rather than being drawn as
+// text.
+func renderFields(fields []gormmodels.Field, data map[string]interface{}, htmlData map[string]interface{}) (string, error) {
+	var b strings.Builder
+	for _, field := range fields {
+		renderer, ok := fieldRendererFor(field.Type)
+		if !ok {
+			return "", templateError("TEMPLATE_FIELD_TYPE", fmt.Sprintf("Field %s has unknown type %q", field.DataKey, field.Type),
+				"Change the field to one of the supported types: "+strings.Join(fieldTypeNames(), ", ")+".", nil)
+		}
+		fieldHTML, err := renderer.Render(field, dataValue(data, field.DataKey), htmlValue(htmlData, field.DataKey))
+		if err != nil {
+			return "", dataError("DATA_FIELD_VALUE", fmt.Sprintf("Invalid value for %s field %s", field.Type, field.DataKey), "", err)
+		}
+		b.WriteString(fieldHTML)
+	}
+	return b.String(), nil
+}
+
+// htmlValue returns the prepared HTML of a DataKey, or "" when it has none.
+func htmlValue(htmlData map[string]interface{}, dataKey string) string {
+	value, ok := htmlData[dataKey]
+	if !ok || value == nil || value == "" {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+// fieldBox positions content in the field's rectangle with its formatting.
+func fieldBox(field gormmodels.Field, content string) string {
+	return fmt.Sprintf(`
+        <div class="%s" style="
+            top: %dpx;
+            left: %dpx;
+            width: %dpx;
+            height: %dpx;
+            %s
+        ">
+            <div class="field-text" style="%s">%s</div>
+        </div>`, fieldClass(field), field.PositionTop, field.PositionLeft, field.PositionWidth, field.PositionHeight, fieldFormatStyle(field), fieldTextStyle(field), content)
+}
+
+// textField writes its value as escaped text, or its prepared HTML when it
+// has some. Signatures and stamps are text fields whose HTML is the embedded
+// image.
+type textField struct{}
+
+func (textField) Validate(gormmodels.Field) error { return nil }
+
+func (textField) Text(_ gormmodels.Field, value interface{}, htmlValue string) string {
+	if htmlValue != "" {
+		return htmlTagPattern.ReplaceAllString(htmlValue, " ")
+	}
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func (textField) Render(field gormmodels.Field, value interface{}, htmlValue string) (string, error) {
+	content := htmlValue
+	if content == "" && value != nil {
+		content = html.EscapeString(fmt.Sprint(value))
+	}
+	return fieldBox(field, content), nil
+}
+
+// checkboxField draws its check mark in its own rectangle when its value is
+// true, "true", "yes", "on" or a non-zero number.
+type checkboxField struct{}
+
+func (checkboxField) Validate(gormmodels.Field) error { return nil }
+
+func (checkboxField) Text(field gormmodels.Field, value interface{}, _ string) string {
+	if !isChecked(value) {
+		return ""
+	}
+	return checkMark(field)
+}
+
+func (checkboxField) Render(field gormmodels.Field, value interface{}, _ string) (string, error) {
+	if !isChecked(value) {
+		return "", nil
+	}
+	return checkboxMarkHTML(field, gormmodels.CheckboxBox{
+		Top:    field.PositionTop,
+		Left:   field.PositionLeft,
+		Width:  field.PositionWidth,
+		Height: field.PositionHeight,
+	}), nil
+}
+
+func isChecked(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case int:
+		return v != 0
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "on", "1":
+			return true
+		}
+	}
+	return false
+}
+
+// checkboxGroupField ticks the printed boxes its value selects instead of
+// writing the value.
+type checkboxGroupField struct{}
+
+// Validate requires boxes with distinct values and a visible size.
+func (checkboxGroupField) Validate(f gormmodels.Field) error {
+	if len(f.Boxes) == 0 {
+		return fmt.Errorf("field %s has no boxes", f.DataKey)
+	}
+
+	seen := make(map[string]bool, len(f.Boxes))
+	for _, box := range f.Boxes {
+		if box.Value == "" {
+			return fmt.Errorf("field %s has a box without a value", f.DataKey)
+		}
+		if seen[box.Value] {
+			return fmt.Errorf("field %s has more than one box for value %q", f.DataKey, box.Value)
+		}
+		if box.Width <= 0 || box.Height <= 0 {
+			return fmt.Errorf("box %q of field %s has no size", box.Value, f.DataKey)
+		}
+		seen[box.Value] = true
+	}
+	return nil
+}
+
+func (checkboxGroupField) Text(field gormmodels.Field, _ interface{}, _ string) string {
+	return checkMark(field)
+}
+
+func (checkboxGroupField) Render(field gormmodels.Field, value interface{}, _ string) (string, error) {
+	return checkboxMarksHTML(field, value), nil
+}
+
+// imageField draws an image data URI scaled to fit its rectangle.
+type imageField struct{}
+
+func (imageField) Validate(gormmodels.Field) error { return nil }
+
+func (imageField) Text(gormmodels.Field, interface{}, string) string { return "" }
+
+func (imageField) Render(field gormmodels.Field, value interface{}, _ string) (string, error) {
+	uri, _ := value.(string)
+	uri = strings.TrimSpace(uri)
+	if value == nil || uri == "" {
+		return "", nil
+	}
+	if !services.IsImageDataURI(uri) {
+		return "", fmt.Errorf("value must be a base64 data URI of a PNG, JPEG, GIF or WebP image")
+	}
+	return fieldBox(field, fmt.Sprintf(`<img src="%s" style="display: block; width: 100%%; height: %dpx; object-fit: contain;">`,
+		uri, field.PositionHeight)), nil
+}
+
+// qrCodeMinSize is the smallest QR code image generated, in pixels, so codes
+// in small fields stay scannable when printed.
+const qrCodeMinSize = 128
+
+// qrCodeField encodes its value as a QR code filling the shorter side of its
+// rectangle.
+type qrCodeField struct{}
+
+func (qrCodeField) Validate(f gormmodels.Field) error {
+	if f.PositionWidth <= 0 || f.PositionHeight <= 0 {
+		return fmt.Errorf("QR code field %s has no size", f.DataKey)
+	}
+	return nil
+}
+
+func (qrCodeField) Text(gormmodels.Field, interface{}, string) string { return "" }
+
+func (qrCodeField) Render(field gormmodels.Field, value interface{}, _ string) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	content := strings.TrimSpace(fmt.Sprint(value))
+	if content == "" {
+		return "", nil
+	}
+
+	size := min(field.PositionWidth, field.PositionHeight)
+	png, err := qrcode.Encode(content, qrcode.Medium, max(size*2, qrCodeMinSize))
+	if err != nil {
+		return "", err
+	}
+	return fieldBox(field, fmt.Sprintf(`<img src="data:image/png;base64,%s" style="display: block; width: %dpx; height: %dpx; image-rendering: pixelated;">`,
+		base64.StdEncoding.EncodeToString(png), size, size)), nil
+}
+
+// tableField lays out an array of rows, each an array of cells, as a table
+// filling its rectangle. A row that is a single value is one cell.
+type tableField struct{}
+
+func (tableField) Validate(gormmodels.Field) error { return nil }
+
+func (tableField) Text(_ gormmodels.Field, value interface{}, _ string) string {
+	rows, err := tableRows(value)
+	if err != nil {
+		return ""
+	}
+	var cells []string
+	for _, row := range rows {
+		cells = append(cells, row...)
+	}
+	return strings.Join(cells, " ")
+}
+
+func (tableField) Render(field gormmodels.Field, value interface{}, _ string) (string, error) {
+	rows, err := tableRows(value)
+	if err != nil || len(rows) == 0 {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(`<table style="width: 100%; border-collapse: collapse; font: inherit;">`)
+	for _, row := range rows {
+		b.WriteString("<tr>")
+		for _, cell := range row {
+			fmt.Fprintf(&b, `<td style="padding: 0 2px; vertical-align: top;">%s</td>`, html.EscapeString(cell))
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</table>")
+	return fieldBox(field, b.String()), nil
+}
+
+// tableRows reads the cells of a table value.
+func tableRows(value interface{}) ([][]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value must be an array of rows")
+	}
+
+	rows := make([][]string, len(list))
+	for i, item := range list {
+		switch row := item.(type) {
+		case []interface{}:
+			for _, cell := range row {
+				rows[i] = append(rows[i], cellText(cell))
+			}
+		case map[string]interface{}:
+			return nil, fmt.Errorf("row %d must be an array of cells", i+1)
+		default:
+			rows[i] = []string{cellText(row)}
+		}
+	}
+	return rows, nil
+}
+
+func cellText(cell interface{}) string {
+	if cell == nil {
+		return ""
+	}
+	return fmt.Sprint(cell)
+}
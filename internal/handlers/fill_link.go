@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FillLinkHandler manages fill links and takes the submissions made through
+// them. Public endpoints are authorized by the link token alone.
+type FillLinkHandler struct {
+	fillLinkService *services.FillLinkService
+	templateService *services.TemplateService
+	pipeline        *submissionPipeline
+}
+
+func NewFillLinkHandler(fillLinkService *services.FillLinkService, formService *services.FormService, templateService *services.TemplateService, optionListService *services.OptionListService, signatureService *services.SignatureService, stampService *services.StampService, validationService *services.ValidationWebhookService) *FillLinkHandler {
+	return &FillLinkHandler{
+		fillLinkService: fillLinkService,
+		templateService: templateService,
+		pipeline:        newSubmissionPipeline(formService, optionListService, signatureService, stampService, validationService),
+	}
+}
+
+type CreateFillLinkRequest struct {
+	Name         string                 `json:"name"`
+	LockedValues map[string]interface{} `json:"lockedValues"`
+	Status       string                 `json:"status"`
+	ExpiresAt    *time.Time             `json:"expiresAt"`
+}
+
+type PublicSubmitRequest struct {
+	FormData map[string]interface{} `json:"formData" binding:"required"`
+	Locale   string                 `json:"locale,omitempty"`
+}
+
+// publicFillField is what a fill link exposes about a field.
+type publicFillField struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`
+	DataKey   string `json:"dataKey"`
+	Required  bool   `json:"required"`
+	PageIndex int    `json:"pageIndex"`
+	Locked    bool   `json:"locked"`
+}
+
+func (h *FillLinkHandler) Create(c *gin.Context) {
+	templateID := c.Param("id")
+
+	var req CreateFillLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if req.Status == "" {
+		req.Status = "submitted"
+	}
+
+	link := &gormmodels.FillLink{
+		TemplateID:   templateID,
+		Name:         req.Name,
+		LockedValues: req.LockedValues,
+		Status:       req.Status,
+		ExpiresAt:    req.ExpiresAt,
+		CreatedBy:    c.GetString(auth.ContextUserID),
+	}
+
+	if err := h.fillLinkService.Create(link); err != nil {
+		writeServiceError(c, "Failed to create fill link", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"fillLink": link,
+		"url":      "/public/fill/" + link.Token,
+	})
+}
+
+func (h *FillLinkHandler) GetByTemplateID(c *gin.Context) {
+	links, err := h.fillLinkService.GetByTemplateID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch fill links", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+func (h *FillLinkHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("linkId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid fill link ID"})
+		return
+	}
+
+	if err := h.fillLinkService.Delete(c.Param("id"), uint(id)); err != nil {
+		writeServiceError(c, "Failed to delete fill link", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fill link deleted successfully"})
+}
+
+// Get describes the template behind a fill link: its fields, which of them
+// are locked, and their locked values.
+func (h *FillLinkHandler) Get(c *gin.Context) {
+	link, err := h.fillLinkService.GetByToken(c.Param("token"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch fill link", err)
+		return
+	}
+
+	if link == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fill link not found"})
+		return
+	}
+
+	template, err := h.templateService.GetByID(link.TemplateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil || template.ArchivedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fill link not found"})
+		return
+	}
+
+	fields := make([]publicFillField, 0, len(template.Fields))
+	for _, field := range template.Fields {
+		_, locked := link.LockedValues[field.DataKey]
+		fields = append(fields, publicFillField{
+			Name:      field.Name,
+			Type:      field.Type,
+			DataKey:   field.DataKey,
+			Required:  field.Required,
+			PageIndex: field.PageIndex,
+			Locked:    locked,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templateId":   template.ID,
+		"displayName":  template.DisplayName,
+		"description":  template.Description,
+		"fields":       fields,
+		"lockedValues": link.LockedValues,
+		"expiresAt":    link.ExpiresAt,
+	})
+}
+
+// Submit creates a submission through a fill link. Locked values are written
+// over the submitted data, and a submission that tries to change one is
+// rejected with 422, so the submitter can only complete the other fields.
+func (h *FillLinkHandler) Submit(c *gin.Context) {
+	link, err := h.fillLinkService.GetByToken(c.Param("token"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch fill link", err)
+		return
+	}
+
+	if link == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Fill link not found"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxInboundPayloadSize)
+	var req PublicSubmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	changed, err := h.fillLinkService.ApplyLockedValues(link, req.FormData)
+	if err != nil {
+		writeServiceError(c, "Failed to apply locked values", err)
+		return
+	}
+	if len(changed) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":        "Locked fields cannot be changed",
+			"lockedFields": changed,
+		})
+		return
+	}
+
+	// Fill link submitters have no user or workspace, so they cannot use
+	// stored signatures or choose stamps.
+	submission := h.pipeline.newSubmission(c, link.TemplateID, ChannelFillLink, submissionCreator{})
+	submission.Metadata.FillLinkID = link.ID
+	submission.FormData = req.FormData
+	submission.Status = link.Status
+	h.pipeline.submit(c, submission, inputLocale(c, req.Locale))
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// Continuation layout defaults: 20 mm margins on A4 and at most 5
+// continuation pages per field.
+const (
+	defaultContinuationMargin = 76
+	defaultContinuationPages  = 5
+)
+
+// continuationLayout fills in the defaults of a template's continuation layout.
+func continuationLayout(layout gormmodels.ContinuationLayout) gormmodels.ContinuationLayout {
+	if layout.Width <= 0 || layout.Height <= 0 {
+		layout.Top, layout.Left = defaultContinuationMargin, defaultContinuationMargin
+		layout.Width = pageWidthPx - 2*defaultContinuationMargin
+		layout.Height = pageHeightPx - 2*defaultContinuationMargin
+	}
+	if layout.MaxPages <= 0 {
+		layout.MaxPages = defaultContinuationPages
+	}
+	return layout
+}
+
+// flowScript moves the content of flowing fields that overflows their box
+// onto continuation pages, a word or element at a time, once the document
+// has laid out. Each continuation page is a blank copy of the field's page
+// inserted after it, holding a copy of the field with the same styles in the
+// layout's box. Content left after the last allowed page is clipped.
+const flowScript = `<style>
+        .flow-header { position: absolute; font-size: 12px; color: #4b5563; }
+    </style>
+    <script>
+    (function () {
+        var layout = %s;
+        function fits(box) { return box.scrollHeight <= box.clientHeight + 1; }
+        // fill moves content from the start of src to the end of dst while box fits.
+        function fill(dst, src, box) {
+            while (src.firstChild) {
+                var node = src.firstChild;
+                dst.appendChild(node);
+                if (fits(box)) { continue; }
+                src.insertBefore(node, src.firstChild);
+                if (node.nodeType === 3) {
+                    var words = node.data.split(/(\s+)/), lo = 0, hi = words.length;
+                    var part = document.createTextNode("");
+                    dst.appendChild(part);
+                    while (lo < hi) {
+                        var mid = Math.ceil((lo + hi) / 2);
+                        part.data = words.slice(0, mid).join("");
+                        if (fits(box)) { lo = mid; } else { hi = mid - 1; }
+                    }
+                    part.data = words.slice(0, lo).join("");
+                    node.data = words.slice(lo).join("");
+                    if (!part.data) { dst.removeChild(part); }
+                } else if (node.nodeType === 1 && node.firstChild) {
+                    var clone = node.cloneNode(false);
+                    dst.appendChild(clone);
+                    fill(clone, node, box);
+                    if (!clone.firstChild) { dst.removeChild(clone); }
+                }
+                return;
+            }
+        }
+        var fields = document.querySelectorAll(".field.flow");
+        for (var i = 0; i < fields.length; i++) {
+            var field = fields[i], text = field.querySelector(".field-text");
+            if (!text || fits(field)) { continue; }
+            var rest = document.createElement("div");
+            while (text.firstChild) { rest.appendChild(text.firstChild); }
+            fill(text, rest, field);
+            var anchor = field.closest(".page, .document-container");
+            for (var n = 1; rest.firstChild && n <= layout.maxPages; n++) {
+                var page = anchor.cloneNode(false);
+                page.style.backgroundImage = "none";
+                var top = layout.top, header = null;
+                if (layout.header) {
+                    header = document.createElement("div");
+                    header.className = "flow-header";
+                    header.style.top = layout.top + "px";
+                    header.style.left = layout.left + "px";
+                    header.style.width = layout.width + "px";
+                    header.textContent = layout.header.replace("{page}", n);
+                    page.appendChild(header);
+                }
+                anchor.parentNode.insertBefore(page, anchor.nextSibling);
+                if (header) { top += header.offsetHeight + 8; }
+                var box = field.cloneNode(false), boxText = text.cloneNode(false);
+                box.style.top = top + "px";
+                box.style.left = layout.left + "px";
+                box.style.width = layout.width + "px";
+                box.style.height = (layout.height - (top - layout.top)) + "px";
+                box.appendChild(boxText);
+                page.appendChild(box);
+                fill(boxText, rest, box);
+                // Content too large for an empty box is placed anyway and clipped.
+                if (!boxText.firstChild) { boxText.appendChild(rest.firstChild); }
+                anchor = page;
+            }
+        }
+    })();
+    </script>
+</body>`
+
+// applyFlowLayout adds the continuation script to documents with flowing
+// fields. Previews render single pages without it, so flowing fields are
+// clipped there as before.
+func applyFlowLayout(htmlContent string, tmpl gormmodels.Template) string {
+	flows := false
+	for _, field := range tmpl.Fields {
+		flows = flows || field.Flow
+	}
+	if !flows {
+		return htmlContent
+	}
+
+	layout := continuationLayout(tmpl.Continuation)
+	config, err := json.Marshal(map[string]interface{}{
+		"top":      layout.Top,
+		"left":     layout.Left,
+		"width":    layout.Width,
+		"height":   layout.Height,
+		"header":   layout.Header,
+		"maxPages": layout.MaxPages,
+	})
+	if err != nil {
+		return htmlContent
+	}
+	return strings.Replace(htmlContent, "</body>", strings.Replace(flowScript, "%s", string(config), 1), 1)
+}
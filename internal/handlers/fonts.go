@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Font fallback modes for PDF generation.
+const (
+	FontFallbackSubstitute = "substitute"
+	FontFallbackWarn       = "warn"
+	FontFallbackStrict     = "strict"
+)
+
+const defaultFontFamily = "Times New Roman"
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// FontWarning reports characters in a field value that its font cannot render.
+type FontWarning struct {
+	DataKey    string `json:"dataKey"`
+	FontFamily string `json:"fontFamily"`
+	Script     string `json:"script"`
+	Characters string `json:"characters"`
+	Fallback   string `json:"fallback,omitempty"`
+	Applied    bool   `json:"applied"`
+}
+
+// fontCheck carries the fallback mode into HTML generation and collects the
+// warnings it produces. A nil fontCheck substitutes fallbacks silently.
+type fontCheck struct {
+	Mode     string
+	Warnings []FontWarning
+}
+
+// applyFontFallbacks scans field values for characters the field's font cannot
+// render. Depending on the mode it appends fallback families to the field's
+// font stack, only records warnings, or fails generation. The template's
+// scriptFonts come before the server-wide fallback of each script, so such
+// characters render in the font the template chose rather than whichever
+// font happens to cover them.
+func applyFontFallbacks(fields []gormmodels.Field, scriptFonts map[string]string, data map[string]interface{}, htmlData map[string]interface{}, check *fontCheck) error {
+	mode := FontFallbackSubstitute
+	if check != nil && check.Mode != "" {
+		mode = check.Mode
+	}
+
+	var warnings []FontWarning
+	for i := range fields {
+		renderer, ok := fieldRendererFor(fields[i].Type)
+		if !ok {
+			continue
+		}
+		text := renderer.Text(fields[i], dataValue(data, fields[i].DataKey), htmlValue(htmlData, fields[i].DataKey))
+		if text == "" {
+			continue
+		}
+
+		families := splitFontFamilies(fields[i].FontFamily)
+		missing := utils.UncoveredRunes(families, text)
+		if len(missing) == 0 {
+			continue
+		}
+
+		scripts := make([]string, 0, len(missing))
+		for script := range missing {
+			scripts = append(scripts, string(script))
+		}
+		sort.Strings(scripts)
+
+		for _, name := range scripts {
+			fallback := utils.FallbackFont(utils.Script(name))
+			stack := []string{fallback}
+			if configured := scriptFonts[name]; configured != "" {
+				stack = append(splitFontFamilies(configured), fallback)
+				fallback = configured
+			}
+			applied := mode == FontFallbackSubstitute && fallback != ""
+			if applied {
+				for _, family := range stack {
+					if family != "" && !slices.Contains(families, family) {
+						families = append(families, family)
+					}
+				}
+			}
+
+			chars := missing[utils.Script(name)]
+			if len(chars) > 10 {
+				chars = chars[:10]
+			}
+			warnings = append(warnings, FontWarning{
+				DataKey:    fields[i].DataKey,
+				FontFamily: fields[i].FontFamily,
+				Script:     name,
+				Characters: string(chars),
+				Fallback:   fallback,
+				Applied:    applied,
+			})
+		}
+
+		if mode == FontFallbackSubstitute {
+			fields[i].FontFamily = strings.Join(families, ", ")
+		}
+	}
+
+	if check != nil {
+		check.Warnings = append(check.Warnings, warnings...)
+	}
+
+	if mode == FontFallbackStrict && len(warnings) > 0 {
+		return dataError("DATA_MISSING_GLYPHS", "Field values contain characters the selected fonts cannot render",
+			"Choose a font that covers these characters or generate with fontFallback=substitute.",
+			fmt.Errorf("%d field(s) have unsupported characters, first: %s (%s)", len(warnings), warnings[0].DataKey, warnings[0].Script))
+	}
+	return nil
+}
+
+func splitFontFamilies(fontFamily string) []string {
+	var families []string
+	for _, family := range strings.Split(fontFamily, ",") {
+		family = strings.Trim(strings.TrimSpace(family), `'"`)
+		if family != "" {
+			families = append(families, family)
+		}
+	}
+	if len(families) == 0 {
+		families = []string{defaultFontFamily}
+	}
+	return families
+}
+
+// fontStack renders a comma-separated family list as a quoted CSS font stack.
+func fontStack(fontFamily string) template.CSS {
+	families := splitFontFamilies(fontFamily)
+	quoted := make([]string, len(families))
+	for i, family := range families {
+		quoted[i] = "'" + strings.NewReplacer(`'`, "", `"`, "", `\`, "", "<", "", ">", "", ";", "").Replace(family) + "'"
+	}
+	return template.CSS(strings.Join(quoted, ", ") + ", serif")
+}
+
+type FontCheckRequest struct {
+	Data           map[string]interface{} `json:"data"`
+	HtmlData       map[string]interface{} `json:"htmlData,omitempty"`
+	FormattingData map[string]interface{} `json:"formattingData,omitempty"`
+}
+
+// CheckFonts reports, without generating a PDF, which field values contain
+// characters their fonts cannot render and which fallback would be used.
+func (h *PDFHandler) CheckFonts(c *gin.Context) {
+	var req FontCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	template, err = h.resolveTemplate(template)
+	if err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+
+	fields := make([]gormmodels.Field, len(template.Fields))
+	copy(fields, template.Fields)
+	for i := range fields {
+		if formatting, ok := req.FormattingData[fields[i].DataKey].(map[string]interface{}); ok {
+			if fontFamily, ok := formatting["fontFamily"].(string); ok && fontFamily != "" {
+				fields[i].FontFamily = fontFamily
+			}
+		}
+	}
+
+	check := &fontCheck{Mode: FontFallbackWarn}
+	applyFontFallbacks(fields, template.ScriptFonts, req.Data, req.HtmlData, check)
+
+	warnings := check.Warnings
+	if warnings == nil {
+		warnings = []FontWarning{}
+	}
+	c.JSON(http.StatusOK, gin.H{"warnings": warnings})
+}
@@ -2,37 +2,52 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/middleware"
 	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
 	"github.com/dhanavadh/fastfill-backend/internal/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 type FormHandler struct {
-	formService     *services.FormService
-	templateService *services.TemplateService
+	formService       *services.FormService
+	templateService   *services.TemplateService
+	optionListService *services.OptionListService
+	signatureService  *services.SignatureService
+	stampService      *services.StampService
+	fieldAccess       *services.FieldAccessService
+	pipeline          *submissionPipeline
 }
 
-func NewFormHandler(formService *services.FormService, templateService *services.TemplateService) *FormHandler {
+func NewFormHandler(formService *services.FormService, templateService *services.TemplateService, optionListService *services.OptionListService, signatureService *services.SignatureService, stampService *services.StampService, validationService *services.ValidationWebhookService, fieldAccess *services.FieldAccessService) *FormHandler {
 	return &FormHandler{
-		formService:     formService,
-		templateService: templateService,
+		formService:       formService,
+		templateService:   templateService,
+		optionListService: optionListService,
+		signatureService:  signatureService,
+		stampService:      stampService,
+		fieldAccess:       fieldAccess,
+		pipeline:          newSubmissionPipeline(formService, optionListService, signatureService, stampService, validationService),
 	}
 }
 
 type SubmitFormRequest struct {
-	TemplateID      string                 `json:"templateId" binding:"required"`
-	FormData        map[string]interface{} `json:"formData" binding:"required"`
-	FormattingData  map[string]interface{} `json:"formattingData,omitempty"`
-	HtmlData        map[string]interface{} `json:"htmlData,omitempty"`
-	Status          string                 `json:"status"`
+	TemplateID     string                 `json:"templateId" binding:"required"`
+	FormData       map[string]interface{} `json:"formData" binding:"required"`
+	FormattingData map[string]interface{} `json:"formattingData,omitempty"`
+	HtmlData       map[string]interface{} `json:"htmlData,omitempty"`
+	Status         string                 `json:"status"`
+	Locale         string                 `json:"locale,omitempty"`
 }
 
 type UpdateFormRequest struct {
 	FormData map[string]interface{} `json:"formData"`
 	Status   string                 `json:"status"`
+	Locale   string                 `json:"locale,omitempty"`
 }
 
 func (h *FormHandler) Submit(c *gin.Context) {
@@ -46,38 +61,26 @@ func (h *FormHandler) Submit(c *gin.Context) {
 		req.Status = "draft"
 	}
 
-	submission := &gormmodels.FormSubmission{
-		ID:             uuid.New().String(),
-		TemplateID:     req.TemplateID,
-		FormData:       req.FormData,
-		FormattingData: req.FormattingData,
-		HtmlData:       req.HtmlData,
-		Status:         req.Status,
-	}
-
-	if err := h.formService.Create(submission); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save form submission"})
+	if checkTemplateAccess(c, h.templateService, req.TemplateID, services.AccessUse) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"id":      submission.ID,
-		"message": "Form submitted successfully",
-		"status":  submission.Status,
-	})
+	submission := h.pipeline.newSubmission(c, req.TemplateID, ChannelAPI, requestCreator(c))
+	submission.FormData = req.FormData
+	submission.FormattingData = req.FormattingData
+	submission.HtmlData = req.HtmlData
+	submission.Status = req.Status
+	h.pipeline.submit(c, submission, inputLocale(c, req.Locale))
 }
 
 func (h *FormHandler) GetByID(c *gin.Context) {
-	submissionID := c.Param("id")
-
-	submission, err := h.formService.GetByID(submissionID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch form submission"})
+	submission, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessUse)
+	if refused {
 		return
 	}
 
-	if submission == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form submission not found"})
+	if err := maskRestricted(c, h.fieldAccess, submission); err != nil {
+		writeServiceError(c, "Failed to fetch form submission", err)
 		return
 	}
 
@@ -85,43 +88,87 @@ func (h *FormHandler) GetByID(c *gin.Context) {
 }
 
 func (h *FormHandler) Update(c *gin.Context) {
-	submissionID := c.Param("id")
-
 	var req UpdateFormRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
 
-	submission, err := h.formService.GetByID(submissionID)
+	submission, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessUse)
+	if refused {
+		return
+	}
+
+	if submission.Status == services.SubmissionStatusExpired {
+		c.JSON(http.StatusConflict, gin.H{"error": "Form submission has expired"})
+		return
+	}
+
+	restricted, err := h.fieldAccess.RestrictedKeys(submission.TemplateID, c.GetString(auth.ContextRole))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch form submission"})
+		writeServiceError(c, "Failed to fetch form submission", err)
 		return
 	}
+	req.FormData = services.KeepRestricted(req.FormData, submission.FormData, restricted)
 
-	if submission == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form submission not found"})
+	status := submission.Status
+	if req.Status != "" {
+		status = req.Status
+	}
+	if checkFormData(c, h.formService, submission.TemplateID, req.FormData, inputLocale(c, req.Locale), status) {
+		return
+	}
+
+	violations, err := h.optionListService.Validate(submission.TemplateID, req.FormData)
+	if err != nil {
+		writeServiceError(c, "Failed to validate form submission", err)
+		return
+	}
+	if writeOptionViolations(c, violations) {
 		return
 	}
 
+	if checkSignatures(c, h.signatureService, submission.TemplateID, req.FormData, c.GetString(auth.ContextUserID)) {
+		return
+	}
+
+	if checkStamps(c, h.stampService, submission.TemplateID, req.FormData, c.GetString(auth.ContextWorkspaceID)) {
+		return
+	}
+
+	rawData, err := h.formService.NormalizeFormData(submission.TemplateID, req.FormData, inputLocale(c, req.Locale))
+	if err != nil {
+		writeServiceError(c, "Failed to normalize form data", err)
+		return
+	}
+	if rawData == nil {
+		// An empty map makes Updates clear input kept from the previous data.
+		rawData = map[string]interface{}{}
+	}
+
 	submission.FormData = req.FormData
+	submission.RawData = rawData
 	if req.Status != "" {
 		submission.Status = req.Status
 	}
 
-	if err := h.formService.Update(submission); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update form submission"})
+	if err := h.formService.Update(submission, c.GetString(auth.ContextUserID)); err != nil {
+		writeServiceError(c, "Failed to update form submission", err)
 		return
 	}
 
+	services.MaskSubmission(submission, restricted)
 	c.JSON(http.StatusOK, submission)
 }
 
 func (h *FormHandler) Delete(c *gin.Context) {
-	submissionID := c.Param("id")
+	submission, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessOwner)
+	if refused {
+		return
+	}
 
-	if err := h.formService.Delete(submissionID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete form submission"})
+	if err := h.formService.Delete(submission.ID); err != nil {
+		writeServiceError(c, "Failed to delete form submission", err)
 		return
 	}
 
@@ -131,11 +178,132 @@ func (h *FormHandler) Delete(c *gin.Context) {
 func (h *FormHandler) GetByTemplateID(c *gin.Context) {
 	templateID := c.Param("id")
 
-	submissions, err := h.formService.GetByTemplateID(templateID)
+	var dataKeys []string
+	if fields := c.Query("fields"); fields != "" {
+		for _, key := range strings.Split(fields, ",") {
+			dataKeys = append(dataKeys, strings.TrimSpace(key))
+		}
+	}
+
+	submissions, err := h.formService.GetByTemplateID(templateID, dataKeys)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch form submissions"})
+		writeServiceError(c, "Failed to fetch form submissions", err)
+		return
+	}
+
+	// Provenance is only exposed on the detail endpoint
+	for i := range submissions {
+		submissions[i].Metadata = nil
+	}
+
+	if err := maskRestrictedList(c, h.fieldAccess, submissions); err != nil {
+		writeServiceError(c, "Failed to fetch form submissions", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, submissions)
 }
+
+// Revisions lists the revisions of a submission, with the data paths each
+// one changed.
+func (h *FormHandler) Revisions(c *gin.Context) {
+	submission, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessUse)
+	if refused {
+		return
+	}
+
+	revisions, err := h.formService.Revisions(submission.ID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch submission revisions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"submissionId": submission.ID, "revisions": revisions})
+}
+
+// DiffRevisions shows which form data values changed between ?from= and
+// ?to=, and when and by whom. By default it compares the latest revision
+// with the one before.
+func (h *FormHandler) DiffRevisions(c *gin.Context) {
+	from, err := strconv.Atoi(c.DefaultQuery("from", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from revision"})
+		return
+	}
+	to, err := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to revision"})
+		return
+	}
+
+	submission, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessUse)
+	if refused {
+		return
+	}
+
+	diff, err := h.formService.DiffRevisions(submission.ID, from, to)
+	if err != nil {
+		writeServiceError(c, "Failed to compare submission revisions", err)
+		return
+	}
+
+	restricted, err := h.fieldAccess.RestrictedKeys(submission.TemplateID, c.GetString(auth.ContextRole))
+	if err != nil {
+		writeServiceError(c, "Failed to compare submission revisions", err)
+		return
+	}
+	services.MaskChanges(diff.Changes, restricted)
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// fetchSubmission loads the submission named by the "id" parameter; see
+// fetchSubmissionByID.
+func fetchSubmission(c *gin.Context, formService *services.FormService, templateService *services.TemplateService, access services.TemplateAccess) (*gormmodels.FormSubmission, bool) {
+	return fetchSubmissionByID(c, formService, templateService, c.Param("id"), access)
+}
+
+// fetchSubmissionByID loads a submission, refusing the request unless it
+// exists, the workspace may see it and it has at least the given access to
+// its template. It writes the response and returns true when refused.
+func fetchSubmissionByID(c *gin.Context, formService *services.FormService, templateService *services.TemplateService, id string, access services.TemplateAccess) (*gormmodels.FormSubmission, bool) {
+	submission, err := formService.GetByID(id)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch form submission", err)
+		return nil, true
+	}
+
+	if submission == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Form submission not found"})
+		return nil, true
+	}
+
+	granted, err := templateService.Access(submission.TemplateID, c.GetString(auth.ContextWorkspaceID))
+	if err == services.ErrTemplateNotFound {
+		return submission, false
+	}
+	if err != nil {
+		writeServiceError(c, "Failed to check template access", err)
+		return nil, true
+	}
+	if !submissionVisible(c, submission, granted) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Form submission not found"})
+		return nil, true
+	}
+	if granted < access {
+		middleware.AbortTemplateAccess(c, granted)
+		return nil, true
+	}
+	return submission, false
+}
+
+// submissionVisible reports whether the workspace may see the submission:
+// the template's owner sees every submission, workspaces it is shared with
+// only their own.
+func submissionVisible(c *gin.Context, submission *gormmodels.FormSubmission, granted services.TemplateAccess) bool {
+	if granted >= services.AccessOwner {
+		return true
+	}
+	workspaceID := c.GetString(auth.ContextWorkspaceID)
+	return workspaceID != "" && submission.WorkspaceID == workspaceID
+}
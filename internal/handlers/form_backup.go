@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxRestoreLine bounds one submission in an NDJSON restore.
+const maxRestoreLine = 10 << 20
+
+// maxRestoreIssues bounds the skipped and failed lines listed in a restore
+// response; the counts cover every line.
+const maxRestoreIssues = 100
+
+// restoreLineResult is a line of an NDJSON restore that was not imported.
+type restoreLineResult struct {
+	Line         int    `json:"line"`
+	SubmissionID string `json:"submissionId,omitempty"`
+	Error        string `json:"error"`
+}
+
+// ExportNDJSON streams every submission of a template as newline-delimited
+// JSON, one submission per line with its status, metadata and timestamps.
+// Roles that cannot view some of the template's fields are refused: a masked
+// export would restore its placeholders as data.
+func (h *FormHandler) ExportNDJSON(c *gin.Context) {
+	templateID := c.Param("id")
+
+	restricted, err := h.fieldAccess.RestrictedKeys(templateID, c.GetString(auth.ContextRole))
+	if err != nil {
+		writeServiceError(c, "Failed to export form submissions", err)
+		return
+	}
+	if len(restricted) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Export requires access to every field", "fields": slices.Sorted(maps.Keys(restricted))})
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-forms.ndjson", templateID))
+	c.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(c.Writer)
+	err = h.formService.Export(templateID, func(batch []gormmodels.FormSubmission) error {
+		for i := range batch {
+			if err := encoder.Encode(&batch[i]); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err == nil {
+		return
+	}
+	if !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		writeServiceError(c, "Failed to export form submissions", err)
+		return
+	}
+
+	// The status is already sent; cut the stream short so clients do not
+	// take a partial export for a complete one.
+	log.Printf("Export of submissions of template %s failed: %v", templateID, err)
+	c.Abort()
+	if hijacker, ok := c.Writer.(http.Hijacker); ok {
+		if conn, _, err := hijacker.Hijack(); err == nil {
+			conn.Close()
+		}
+	}
+}
+
+// ImportNDJSON restores submissions from an NDJSON export into a template.
+// Each line is stored as it was exported, keeping its ID, status, form data
+// and timestamps; lines without an ID get a new one. Who submitted it, its
+// provenance and legal hold are not taken from the file: the restoring user
+// becomes the creator and no hold is restored. Lines whose ID already exists
+// are skipped, so a restore can be repeated, and invalid lines are reported
+// while the rest are restored.
+func (h *FormHandler) ImportNDJSON(c *gin.Context) {
+	templateID := c.Param("id")
+
+	template, err := h.templateService.GetByID(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	scanner := bufio.NewScanner(c.Request.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxRestoreLine)
+
+	var issues []restoreLineResult
+	report := func(result restoreLineResult) {
+		if len(issues) < maxRestoreIssues {
+			issues = append(issues, result)
+		}
+	}
+
+	imported, skipped, failed, line := 0, 0, 0, 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var submission gormmodels.FormSubmission
+		if err := json.Unmarshal(scanner.Bytes(), &submission); err != nil {
+			failed++
+			report(restoreLineResult{Line: line, Error: "invalid JSON: " + err.Error()})
+			continue
+		}
+		if submission.ID == "" {
+			submission.ID = uuid.New().String()
+		} else if _, err := uuid.Parse(submission.ID); err != nil {
+			failed++
+			report(restoreLineResult{Line: line, SubmissionID: submission.ID, Error: "id is not a UUID"})
+			continue
+		}
+		submission.TemplateID = templateID
+		submission.WorkspaceID = c.GetString(auth.ContextWorkspaceID)
+		submission.CreatedBy = c.GetString(auth.ContextUserID)
+		submission.Metadata = submissionMetadata(c, ChannelRestore)
+		submission.LegalHoldAt = nil
+		submission.LegalHoldReason = ""
+		submission.LegalHoldBy = ""
+		if submission.Status == "" {
+			submission.Status = "draft"
+		}
+
+		err := h.formService.Restore(&submission)
+		switch {
+		case errors.Is(err, services.ErrSubmissionExists):
+			skipped++
+			report(restoreLineResult{Line: line, SubmissionID: submission.ID, Error: err.Error()})
+		case err != nil:
+			failed++
+			report(restoreLineResult{Line: line, SubmissionID: submission.ID, Error: err.Error()})
+		default:
+			imported++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":    "Failed to read file",
+			"details":  fmt.Sprintf("line %d: %v", line+1, err),
+			"imported": imported,
+			"skipped":  skipped,
+			"failed":   failed,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"imported": imported,
+		"skipped":  skipped,
+		"failed":   failed,
+		"lines":    issues,
+	})
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkFormData responds with 422 and one error per field when form data
+// does not fit the template's fields. Required fields are enforced unless
+// the submission is a draft. It reports whether a response was written.
+func checkFormData(c *gin.Context, formService *services.FormService, templateID string, data map[string]interface{}, locale services.InputLocale, status string) bool {
+	fieldErrors, err := formService.ValidateFormData(templateID, data, locale, status != services.SubmissionStatusDraft)
+	if err != nil {
+		writeServiceError(c, "Failed to validate form submission", err)
+		return true
+	}
+	if len(fieldErrors) == 0 {
+		return false
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":  "Form data does not match the template fields",
+		"errors": fieldErrors,
+	})
+	return true
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// Font sizes, in points, a field or a formattingData override may use.
+const (
+	defaultFontSize = 12
+	minFontSize     = 4
+	maxFontSize     = 96
+)
+
+// applyFormatting overrides the text formatting of fields with the entries
+// of formattingData keyed by DataKey. Missing, empty or out-of-range values
+// keep the field's stored formatting.
+func applyFormatting(fields []gormmodels.Field, formattingData map[string]interface{}) {
+	if len(formattingData) == 0 {
+		return
+	}
+
+	for i := range fields {
+		formatting, ok := formattingData[fields[i].DataKey].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if fontWeight, ok := formatting["fontWeight"].(string); ok && fontWeight != "" {
+			fields[i].FontWeight = fontWeight
+		}
+		if fontStyle, ok := formatting["fontStyle"].(string); ok && fontStyle != "" {
+			fields[i].FontStyle = fontStyle
+		}
+		if textDecoration, ok := formatting["textDecoration"].(string); ok && textDecoration != "" {
+			fields[i].TextDecoration = textDecoration
+		}
+		if textColor, ok := formatting["textColor"].(string); ok && textColor != "" {
+			fields[i].TextColor = textColor
+		}
+		if fontFamily, ok := formatting["fontFamily"].(string); ok && fontFamily != "" {
+			fields[i].FontFamily = fontFamily
+		}
+		if fontSize, ok := formatting["fontSize"].(float64); ok && fontSize >= minFontSize && fontSize <= maxFontSize {
+			fields[i].FontSize = int(fontSize)
+		}
+	}
+}
+
+// fieldFormatStyle is the inline CSS for a field's text formatting, shared by
+// the single-page and multi-page layouts.
+func fieldFormatStyle(field gormmodels.Field) template.CSS {
+	fontSize := field.FontSize
+	if fontSize <= 0 {
+		fontSize = defaultFontSize
+	}
+
+	return template.CSS(fmt.Sprintf("font-size: %dpt; font-weight: %s; font-style: %s; text-decoration: %s; color: %s; font-family: %s;",
+		fontSize,
+		cssValue(field.FontWeight, "normal"),
+		cssValue(field.FontStyle, "normal"),
+		cssValue(field.TextDecoration, "none"),
+		cssValue(field.TextColor, "#000000"),
+		fontStack(field.FontFamily)))
+}
+
+// cssValue returns value, or fallback when it is empty, with characters that
+// could end the declaration removed.
+func cssValue(value, fallback string) string {
+	value = strings.NewReplacer(";", "", "{", "", "}", "", "<", "", ">", "", `"`, "", "'", "", `\`, "").Replace(strings.TrimSpace(value))
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GenerationCallbackHandler struct {
+	callbackService *services.GenerationCallbackService
+	formService     *services.FormService
+	templateService *services.TemplateService
+}
+
+func NewGenerationCallbackHandler(callbackService *services.GenerationCallbackService, formService *services.FormService, templateService *services.TemplateService) *GenerationCallbackHandler {
+	return &GenerationCallbackHandler{
+		callbackService: callbackService,
+		formService:     formService,
+		templateService: templateService,
+	}
+}
+
+type GeneratePDFAsyncRequest struct {
+	CallbackURL string `json:"callbackUrl" binding:"required"`
+	Delivery    string `json:"delivery,omitempty"`
+}
+
+// GenerateAsync queues a submission's PDF for generation and delivery to a
+// callback URL.
+func (h *GenerationCallbackHandler) GenerateAsync(c *gin.Context) {
+	var req GeneratePDFAsyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	submission, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessUse)
+	if refused {
+		return
+	}
+
+	job, err := h.callbackService.Enqueue(submission, req.CallbackURL, req.Delivery)
+	if err != nil {
+		writeServiceError(c, "Failed to queue PDF generation", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *GenerationCallbackHandler) GetJob(c *gin.Context) {
+	job, err := h.callbackService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch generation job", err)
+		return
+	}
+
+	if job == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Generation job not found"})
+		return
+	}
+
+	if _, refused := fetchSubmissionByID(c, h.formService, h.templateService, job.SubmissionID, services.AccessUse); refused {
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/renderer"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Error categories reported to clients when document generation fails.
+const (
+	CategoryTemplate = "template"
+	CategoryData     = "data"
+	CategoryRenderer = "renderer"
+	CategoryStorage  = "storage"
+	CategoryHook     = "hook"
+	CategoryLimit    = "limit"
+)
+
+// GenerationError describes a PDF generation failure in a form the client
+// can act on: a stable code, the failing stage, and a hint for fixing it.
+type GenerationError struct {
+	Status   int
+	Category string
+	Code     string
+	Message  string
+	Hint     string
+	Err      error
+
+	// Artifacts references captured debugging material, if any.
+	Artifacts *services.RenderArtifacts
+}
+
+func (e *GenerationError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *GenerationError) Unwrap() error {
+	return e.Err
+}
+
+func templateError(code, message, hint string, err error) *GenerationError {
+	return &GenerationError{Status: http.StatusUnprocessableEntity, Category: CategoryTemplate, Code: code, Message: message, Hint: hint, Err: err}
+}
+
+func dataError(code, message, hint string, err error) *GenerationError {
+	return &GenerationError{Status: http.StatusBadRequest, Category: CategoryData, Code: code, Message: message, Hint: hint, Err: err}
+}
+
+func storageError(code, message, hint string, err error) *GenerationError {
+	return &GenerationError{Status: http.StatusBadGateway, Category: CategoryStorage, Code: code, Message: message, Hint: hint, Err: err}
+}
+
+func rendererError(err error) *GenerationError {
+	if errors.Is(err, renderer.ErrUnavailable) {
+		return &GenerationError{
+			Status:   http.StatusServiceUnavailable,
+			Category: CategoryRenderer,
+			Code:     "RENDERER_UNAVAILABLE",
+			Message:  "PDF renderer is unavailable",
+			Hint:     "The renderer service is down or busy; retry shortly.",
+			Err:      err,
+		}
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &GenerationError{
+			Status:   http.StatusGatewayTimeout,
+			Category: CategoryRenderer,
+			Code:     "RENDERER_TIMEOUT",
+			Message:  "PDF renderer timed out",
+			Hint:     "The document may be too large or contain slow-loading assets; reduce page count or image sizes and retry.",
+			Err:      err,
+		}
+	}
+	return &GenerationError{
+		Status:   http.StatusInternalServerError,
+		Category: CategoryRenderer,
+		Code:     "RENDERER_FAILED",
+		Message:  "PDF renderer failed",
+		Hint:     "Headless Chrome could not print the document; check that Chrome is installed and retry.",
+		Err:      err,
+	}
+}
+
+// writeGenerationError sends a structured error response for a failed generation.
+func writeGenerationError(c *gin.Context, err error) {
+	log.Printf("PDF generation failed: %v", err)
+
+	var genErr *GenerationError
+	if !errors.As(err, &genErr) {
+		genErr = &GenerationError{
+			Status:   http.StatusInternalServerError,
+			Category: CategoryRenderer,
+			Code:     "GENERATION_FAILED",
+			Message:  "Failed to generate PDF",
+			Err:      err,
+		}
+	}
+
+	body := gin.H{
+		"error":    genErr.Message,
+		"code":     genErr.Code,
+		"category": genErr.Category,
+	}
+	if genErr.Hint != "" {
+		body["hint"] = genErr.Hint
+	}
+	if genErr.Err != nil {
+		body["details"] = genErr.Err.Error()
+	}
+	if genErr.Artifacts != nil {
+		log.Printf("PDF generation diagnostics: %s", genErr.Artifacts.ID)
+		body["diagnostics"] = genErr.Artifacts
+	}
+
+	c.JSON(genErr.Status, body)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Generation is a document being generated, as seen by generation hooks.
+// Pre-render hooks may change the template and values; they work on copies,
+// so stored templates and submissions are not affected.
+type Generation struct {
+	Template       *gormmodels.Template
+	Data           map[string]interface{}
+	FormattingData map[string]interface{}
+	HtmlData       map[string]interface{}
+
+	// SubmissionID is empty for documents generated from request data.
+	SubmissionID string
+	// UserID, WorkspaceID and Role identify the caller; they are empty for
+	// anonymous requests and background renders.
+	UserID      string
+	WorkspaceID string
+	Role        string
+}
+
+// PreRenderHook runs before a document's HTML is built, for example to add
+// branding through the template's custom CSS or to drop pages some roles may
+// not see. Returning an error stops the generation; a *GenerationError is
+// reported as is, so hooks choose the status and code.
+type PreRenderHook interface {
+	BeforeRender(ctx context.Context, g *Generation) error
+}
+
+// PostRenderHook runs on the printed PDF, before it is optimized, given its
+// metadata and normalized, and returns the PDF to continue with.
+type PostRenderHook interface {
+	AfterRender(ctx context.Context, g *Generation, pdf []byte) ([]byte, error)
+}
+
+// AddPreRenderHook registers a hook run before every PDF is rendered, after
+// the hooks registered before it. Hooks are registered at startup.
+func (h *PDFHandler) AddPreRenderHook(hook PreRenderHook) {
+	h.preRenderHooks = append(h.preRenderHooks, hook)
+}
+
+// AddPostRenderHook registers a hook run on every printed PDF, after the
+// hooks registered before it. Hooks are registered at startup.
+func (h *PDFHandler) AddPostRenderHook(hook PostRenderHook) {
+	h.postRenderHooks = append(h.postRenderHooks, hook)
+}
+
+// newGeneration describes a generation for hooks. c is nil for background
+// renders.
+func newGeneration(c *gin.Context, template gormmodels.Template, submissionID string, data, formattingData, htmlData map[string]interface{}) *Generation {
+	g := &Generation{
+		Template:       &template,
+		Data:           data,
+		FormattingData: formattingData,
+		HtmlData:       htmlData,
+		SubmissionID:   submissionID,
+	}
+	if c != nil {
+		g.UserID = c.GetString(auth.ContextUserID)
+		g.WorkspaceID = c.GetString(auth.ContextWorkspaceID)
+		g.Role = c.GetString(auth.ContextRole)
+	}
+	return g
+}
+
+// beforeRender runs the pre-render hooks in order.
+func (h *PDFHandler) beforeRender(ctx context.Context, g *Generation) error {
+	if len(h.preRenderHooks) == 0 {
+		return nil
+	}
+
+	// Hooks get their own copy of the fields and values to change.
+	template := *g.Template
+	template.Fields = append([]gormmodels.Field(nil), template.Fields...)
+	template.SVGFiles = append([]gormmodels.SVGFile(nil), template.SVGFiles...)
+	g.Template = &template
+	g.Data = copyValues(g.Data)
+	g.FormattingData = copyValues(g.FormattingData)
+	g.HtmlData = copyValues(g.HtmlData)
+
+	for _, hook := range h.preRenderHooks {
+		if err := hook.BeforeRender(ctx, g); err != nil {
+			return hookError(err)
+		}
+	}
+	return nil
+}
+
+// afterRender runs the post-render hooks in order.
+func (h *PDFHandler) afterRender(ctx context.Context, g *Generation, pdfBytes []byte) ([]byte, error) {
+	for _, hook := range h.postRenderHooks {
+		var err error
+		if pdfBytes, err = hook.AfterRender(ctx, g, pdfBytes); err != nil {
+			return nil, hookError(err)
+		}
+	}
+	return pdfBytes, nil
+}
+
+func copyValues(values map[string]interface{}) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	copied := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		copied[key] = value
+	}
+	return copied
+}
+
+// hookError reports a hook failure, keeping a *GenerationError the hook
+// returned.
+func hookError(err error) error {
+	var genErr *GenerationError
+	if errors.As(err, &genErr) {
+		return genErr
+	}
+	return &GenerationError{
+		Status:   http.StatusInternalServerError,
+		Category: CategoryHook,
+		Code:     "GENERATION_HOOK",
+		Message:  "A generation hook failed",
+		Err:      err,
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GenerationLimits bound the documents a generation may render, so jobs
+// too large to print in time are rejected before they reach Chrome. A zero
+// limit is not enforced.
+type GenerationLimits struct {
+	// MaxPages bounds the pages of the rendered template.
+	MaxPages int
+	// MaxHTMLBytes bounds the HTML sent to the renderer, backgrounds and
+	// embedded images included.
+	MaxHTMLBytes int
+	// MaxDataURIBytes bounds each data URI in the submitted values, such as
+	// an image field's value.
+	MaxDataURIBytes int
+}
+
+func limitError(code, message, hint string) *GenerationError {
+	return &GenerationError{Status: http.StatusRequestEntityTooLarge, Category: CategoryLimit, Code: code, Message: message, Hint: hint}
+}
+
+// checkPages rejects templates with more pages than MaxPages.
+func (l GenerationLimits) checkPages(pages int) error {
+	if l.MaxPages > 0 && pages > l.MaxPages {
+		return limitError("LIMIT_PAGES", fmt.Sprintf("Document has %d pages, more than the limit of %d", pages, l.MaxPages),
+			"Split the template into smaller templates, or generate fewer pages at once.")
+	}
+	return nil
+}
+
+// checkHTML rejects documents whose HTML is larger than MaxHTMLBytes.
+func (l GenerationLimits) checkHTML(htmlContent string) error {
+	if l.MaxHTMLBytes > 0 && len(htmlContent) > l.MaxHTMLBytes {
+		return limitError("LIMIT_HTML_SIZE", fmt.Sprintf("Document is %d KB, more than the limit of %d KB", len(htmlContent)>>10, l.MaxHTMLBytes>>10),
+			"Reduce the page count or the size of page backgrounds and embedded images.")
+	}
+	return nil
+}
+
+// checkDataURIs rejects values holding a data URI larger than
+// MaxDataURIBytes, naming the first such value.
+func (l GenerationLimits) checkDataURIs(values map[string]interface{}) error {
+	if l.MaxDataURIBytes <= 0 {
+		return nil
+	}
+	if path, size, ok := oversizedDataURI(values, "", l.MaxDataURIBytes); ok {
+		return limitError("LIMIT_DATA_URI_SIZE", fmt.Sprintf("Value of %s is a %d KB data URI, more than the limit of %d KB", path, size>>10, l.MaxDataURIBytes>>10),
+			"Scale the image down or compress it before submitting it.")
+	}
+	return nil
+}
+
+// oversizedDataURI finds a data URI longer than limit in a value, returning
+// its data path and size.
+func oversizedDataURI(value interface{}, path string, limit int) (string, int, bool) {
+	switch v := value.(type) {
+	case string:
+		if len(v) > limit && strings.HasPrefix(v, "data:") {
+			return path, len(v), true
+		}
+	case map[string]interface{}:
+		for key, item := range v {
+			itemPath := key
+			if path != "" {
+				itemPath = path + "." + key
+			}
+			if p, size, ok := oversizedDataURI(item, itemPath, limit); ok {
+				return p, size, true
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if p, size, ok := oversizedDataURI(item, fmt.Sprintf("%s[%d]", path, i), limit); ok {
+				return p, size, true
+			}
+		}
+	}
+	return "", 0, false
+}
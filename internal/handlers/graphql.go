@@ -0,0 +1,380 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/graphql"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxGraphQLPageSize caps the limit argument of list fields.
+const maxGraphQLPageSize = 100
+
+// GraphQLHandler serves read-only GraphQL queries over templates and
+// submissions. Templates are resolved through the REST template response, so
+// both APIs return the same shapes and URLs.
+type GraphQLHandler struct {
+	templateHandler *TemplateHandler
+	templateService *services.TemplateService
+	formService     *services.FormService
+	fieldAccess     *services.FieldAccessService
+	schema          *graphql.Schema
+}
+
+func NewGraphQLHandler(templateHandler *TemplateHandler, templateService *services.TemplateService, formService *services.FormService, fieldAccess *services.FieldAccessService) *GraphQLHandler {
+	h := &GraphQLHandler{
+		templateHandler: templateHandler,
+		templateService: templateService,
+		formService:     formService,
+		fieldAccess:     fieldAccess,
+	}
+	h.schema = h.buildSchema()
+	return h
+}
+
+// Query executes a GraphQL request given as a JSON body (POST) or as query
+// parameters (GET).
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req graphql.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	resp := h.schema.Execute(c, req)
+	if resp.Data == nil {
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// Schema returns the schema in SDL, in place of introspection.
+func (h *GraphQLHandler) Schema(c *gin.Context) {
+	c.String(http.StatusOK, h.schema.SDL())
+}
+
+func (h *GraphQLHandler) buildSchema() *graphql.Schema {
+	pageArgs := []*graphql.ArgDef{
+		{Name: "limit", Type: "Int", Default: 20},
+		{Name: "offset", Type: "Int", Default: 0},
+	}
+
+	schema := graphql.NewSchema("Query",
+		&graphql.Object{
+			Name: "Query",
+			Fields: []*graphql.FieldDef{
+				{
+					Name:    "template",
+					Type:    "Template",
+					Args:    []*graphql.ArgDef{{Name: "id", Type: "ID!"}},
+					Resolve: h.resolveTemplate,
+				},
+				{
+					Name: "templates",
+					Type: "TemplateConnection!",
+					Args: append([]*graphql.ArgDef{
+						{Name: "category", Type: "String"},
+						{Name: "search", Type: "String"},
+					}, pageArgs...),
+					Resolve: h.resolveTemplates,
+				},
+				{
+					Name:    "submission",
+					Type:    "Submission",
+					Args:    []*graphql.ArgDef{{Name: "id", Type: "ID!"}},
+					Resolve: h.resolveSubmission,
+				},
+				{
+					Name: "submissions",
+					Type: "SubmissionConnection!",
+					Args: append([]*graphql.ArgDef{
+						{Name: "templateId", Type: "ID"},
+						{Name: "status", Type: "String"},
+						{Name: "fields", Type: "[String!]"},
+					}, pageArgs...),
+					Resolve: h.resolveSubmissions,
+				},
+			},
+		},
+		&graphql.Object{
+			Name: "Template",
+			Fields: []*graphql.FieldDef{
+				{Name: "id", Type: "ID!"},
+				{Name: "displayName", Type: "String!"},
+				{Name: "description", Type: "String"},
+				{Name: "category", Type: "String"},
+				{Name: "previewImage", Type: "String"},
+				{Name: "svgBackground", Type: "String"},
+				{Name: "dataInterface", Type: "String"},
+				{Name: "locale", Type: "String"},
+				{
+					Name:    "fields",
+					Type:    "[Field!]!",
+					Args:    []*graphql.ArgDef{{Name: "pageIndex", Type: "Int"}},
+					Resolve: resolveTemplateFields,
+				},
+				{Name: "svgFiles", Type: "[SVGFile!]!"},
+				{
+					Name: "submissions",
+					Type: "SubmissionConnection!",
+					Args: append([]*graphql.ArgDef{{Name: "status", Type: "String"}, {Name: "fields", Type: "[String!]"}}, pageArgs...),
+					Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+						args["templateId"] = source.(TemplateResponse).ID
+						return h.resolveSubmissions(ctx, nil, args)
+					},
+				},
+			},
+		},
+		&graphql.Object{
+			Name: "Field",
+			Fields: []*graphql.FieldDef{
+				{Name: "name", Type: "String!"},
+				{Name: "type", Type: "String!"},
+				{Name: "required", Type: "Boolean!"},
+				{Name: "dataKey", Type: "String!"},
+				{Name: "isAddressComponent", Type: "Boolean!"},
+				{Name: "pageIndex", Type: "Int!"},
+				{Name: "options", Type: "[String!]"},
+				{Name: "optionValues", Type: "[String!]"},
+				{Name: "optionListId", Type: "String"},
+				{Name: "rotation", Type: "Int"},
+				{Name: "vertical", Type: "Boolean"},
+				{Name: "className", Type: "String"},
+				{Name: "position", Type: "Position"},
+				{Name: "translations", Type: "JSON"},
+			},
+		},
+		&graphql.Object{
+			Name: "Position",
+			Fields: []*graphql.FieldDef{
+				{Name: "top", Type: "Float!"},
+				{Name: "left", Type: "Float!"},
+				{Name: "width", Type: "Float!"},
+				{Name: "height", Type: "Float!"},
+			},
+		},
+		&graphql.Object{
+			Name: "SVGFile",
+			Fields: []*graphql.FieldDef{
+				{
+					Name: "id",
+					Type: "ID!",
+					Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+						return fmt.Sprint(source.(SVGFileResponse).ID), nil
+					},
+				},
+				{Name: "filename", Type: "String!"},
+				{Name: "originalName", Type: "String"},
+				{Name: "pageIndex", Type: "Int!"},
+				{Name: "fileUrl", Type: "String!"},
+			},
+		},
+		&graphql.Object{
+			Name: "Submission",
+			Fields: []*graphql.FieldDef{
+				{Name: "id", Type: "ID!"},
+				{Name: "templateId", Type: "ID!"},
+				{Name: "status", Type: "String!"},
+				{Name: "formData", Type: "JSON"},
+				{Name: "formattingData", Type: "JSON"},
+				{Name: "htmlData", Type: "JSON"},
+				{Name: "metadata", Type: "JSON", Description: "Provenance; only returned by submission(id)."},
+				{Name: "duplicateOf", Type: "ID"},
+				{Name: "expiredAt", Type: "Time"},
+				{Name: "createdAt", Type: "Time!"},
+				{Name: "updatedAt", Type: "Time!"},
+				{
+					Name: "template",
+					Type: "Template",
+					Resolve: func(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+						return h.resolveTemplate(ctx, nil, map[string]interface{}{"id": source.(gormmodels.FormSubmission).TemplateID})
+					},
+				},
+			},
+		},
+		connectionObject("TemplateConnection", "Template"),
+		connectionObject("SubmissionConnection", "Submission"),
+	)
+	schema.Scalars = []string{"JSON", "Time"}
+	return schema
+}
+
+func connectionObject(name, node string) *graphql.Object {
+	return &graphql.Object{
+		Name: name,
+		Fields: []*graphql.FieldDef{
+			{Name: "totalCount", Type: "Int!"},
+			{Name: "hasMore", Type: "Boolean!"},
+			{Name: "nodes", Type: "[" + node + "!]!"},
+		},
+	}
+}
+
+func (h *GraphQLHandler) resolveTemplate(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	c, err := requireGraphQLScope(ctx, auth.ScopeTemplatesRead)
+	if err != nil {
+		return nil, err
+	}
+
+	access, err := h.templateService.Access(args["id"].(string), c.GetString(auth.ContextWorkspaceID))
+	if err == services.ErrTemplateNotFound || (err == nil && access == services.AccessNone) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch template")
+	}
+
+	template, err := h.templateService.GetByID(args["id"].(string))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch template")
+	}
+	if template == nil {
+		return nil, nil
+	}
+	return h.templateHandler.toTemplateResponse(*template, c), nil
+}
+
+func (h *GraphQLHandler) resolveTemplates(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	c, err := requireGraphQLScope(ctx, auth.ScopeTemplatesRead)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceID := c.GetString(auth.ContextWorkspaceID)
+	archived := false
+	filter := services.TemplateFilter{Limit: pageLimit(args), Offset: pageOffset(args), Preload: []string{"Fields", "SVGFiles"}, VisibleTo: &workspaceID, Archived: &archived}
+	filter.Category, _ = args["category"].(string)
+	filter.Search, _ = args["search"].(string)
+
+	templates, total, err := h.templateService.Find(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch templates")
+	}
+
+	nodes := make([]TemplateResponse, len(templates))
+	for i, t := range templates {
+		nodes[i] = h.templateHandler.toTemplateResponse(t, c)
+	}
+	return connection(nodes, len(nodes), total, filter.Offset), nil
+}
+
+func (h *GraphQLHandler) resolveSubmission(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	c, err := requireGraphQLScope(ctx, auth.ScopeFormsRead)
+	if err != nil {
+		return nil, err
+	}
+
+	submission, err := h.formService.GetByID(args["id"].(string))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch form submission")
+	}
+	if submission == nil {
+		return nil, nil
+	}
+	access, err := h.templateService.Access(submission.TemplateID, c.GetString(auth.ContextWorkspaceID))
+	if err != nil && err != services.ErrTemplateNotFound {
+		return nil, fmt.Errorf("failed to fetch form submission")
+	}
+	if err == nil && !submissionVisible(c, submission, access) {
+		return nil, nil
+	}
+	if err := maskRestricted(c, h.fieldAccess, submission); err != nil {
+		return nil, fmt.Errorf("failed to fetch form submission")
+	}
+	return *submission, nil
+}
+
+func (h *GraphQLHandler) resolveSubmissions(ctx context.Context, _ interface{}, args map[string]interface{}) (interface{}, error) {
+	c, err := requireGraphQLScope(ctx, auth.ScopeFormsRead)
+	if err != nil {
+		return nil, err
+	}
+
+	workspaceID := c.GetString(auth.ContextWorkspaceID)
+	filter := services.SubmissionFilter{Limit: pageLimit(args), Offset: pageOffset(args), VisibleTo: &workspaceID}
+	filter.TemplateID, _ = args["templateId"].(string)
+	filter.Status, _ = args["status"].(string)
+	if fields, ok := args["fields"].([]interface{}); ok {
+		for _, field := range fields {
+			if key, ok := field.(string); ok {
+				filter.Fields = append(filter.Fields, key)
+			}
+		}
+	}
+
+	submissions, total, err := h.formService.Find(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch form submissions")
+	}
+
+	// Provenance is only exposed for a single submission, as in the REST API
+	for i := range submissions {
+		submissions[i].Metadata = nil
+	}
+	if err := maskRestrictedList(c, h.fieldAccess, submissions); err != nil {
+		return nil, fmt.Errorf("failed to fetch form submissions")
+	}
+	return connection(submissions, len(submissions), total, filter.Offset), nil
+}
+
+func resolveTemplateFields(ctx context.Context, source interface{}, args map[string]interface{}) (interface{}, error) {
+	fields := source.(TemplateResponse).Fields
+	pageIndex, ok := args["pageIndex"].(int)
+	if !ok {
+		return fields, nil
+	}
+
+	var page []FieldResponse
+	for _, f := range fields {
+		if f.PageIndex == pageIndex {
+			page = append(page, f)
+		}
+	}
+	return page, nil
+}
+
+func connection(nodes interface{}, count int, total int64, offset int) map[string]interface{} {
+	return map[string]interface{}{
+		"totalCount": int(total),
+		"hasMore":    int64(offset+count) < total,
+		"nodes":      nodes,
+	}
+}
+
+func pageLimit(args map[string]interface{}) int {
+	limit, _ := args["limit"].(int)
+	if limit <= 0 || limit > maxGraphQLPageSize {
+		return maxGraphQLPageSize
+	}
+	return limit
+}
+
+func pageOffset(args map[string]interface{}) int {
+	offset, _ := args["offset"].(int)
+	if offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+// requireGraphQLScope checks a root field's scope against the request's token.
+func requireGraphQLScope(ctx context.Context, scope string) (*gin.Context, error) {
+	c, ok := ctx.(*gin.Context)
+	if !ok {
+		return nil, fmt.Errorf("missing request context")
+	}
+	if !auth.HasScope(c, scope) {
+		return nil, fmt.Errorf("token is missing required scope %s", scope)
+	}
+	return c, nil
+}
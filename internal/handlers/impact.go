@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImpactRequest struct {
+	Fields []FieldRequest `json:"fields"`
+}
+
+type KeyMigrationRequest struct {
+	Renames map[string]string `json:"renames" binding:"required,min=1"`
+	DryRun  bool              `json:"dryRun"`
+}
+
+type AffectedKey struct {
+	DataKey         string `json:"dataKey"`
+	Submissions     int    `json:"submissions"`
+	SuggestedTarget string `json:"suggestedTarget,omitempty"`
+}
+
+// AnalyzeImpact compares a proposed field list against the stored template and
+// reports how many existing submissions would be orphaned by removed DataKeys.
+func (h *TemplateHandler) AnalyzeImpact(c *gin.Context) {
+	templateID := c.Param("id")
+
+	var req ImpactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON", "details": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.GetByID(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	proposed := h.toGormFields(req.Fields)
+	removed, added := diffDataKeys(template.Fields, proposed)
+
+	counts, err := h.formService.CountByDataKeys(templateID, removed)
+	if err != nil {
+		writeServiceError(c, "Failed to analyze submissions", err)
+		return
+	}
+
+	suggestions := suggestRenames(template.Fields, proposed, removed, added)
+
+	affected := make([]AffectedKey, 0, len(removed))
+	total := 0
+	for _, key := range removed {
+		affected = append(affected, AffectedKey{
+			DataKey:         key,
+			Submissions:     counts[key],
+			SuggestedTarget: suggestions[key],
+		})
+		total += counts[key]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"removedKeys":          affected,
+		"addedKeys":            added,
+		"affectedSubmissions":  total,
+		"suggestedMigration":   suggestions,
+		"requiresConfirmation": total > 0,
+	})
+}
+
+// MigrateKeys renames DataKeys across a template's fields and existing
+// submissions. With dryRun it only reports what would change.
+func (h *TemplateHandler) MigrateKeys(c *gin.Context) {
+	templateID := c.Param("id")
+
+	var req KeyMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON", "details": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.GetByID(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	migration, err := h.templateService.MigrateDataKeys(templateID, req.Renames, req.DryRun)
+	if err != nil {
+		writeServiceError(c, "Failed to migrate data keys", err)
+		return
+	}
+
+	message := "Data keys migrated successfully"
+	if migration.DryRun {
+		message = "Dry run: no changes were made"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            message,
+		"submissionsUpdated": migration.SubmissionsUpdated,
+		"renames":            migration.Renames,
+		"migration":          migration,
+	})
+}
+
+func diffDataKeys(current, proposed []gormmodels.Field) (removed, added []string) {
+	currentKeys := make(map[string]bool)
+	for _, f := range current {
+		currentKeys[f.DataKey] = true
+	}
+	proposedKeys := make(map[string]bool)
+	for _, f := range proposed {
+		proposedKeys[f.DataKey] = true
+	}
+
+	for key := range currentKeys {
+		if !proposedKeys[key] {
+			removed = append(removed, key)
+		}
+	}
+	for key := range proposedKeys {
+		if !currentKeys[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+	return removed, added
+}
+
+// suggestRenames pairs removed keys with added keys whose field has the same
+// name, or failing that the same page and position, as the removed one.
+func suggestRenames(current, proposed []gormmodels.Field, removed, added []string) map[string]string {
+	byKey := func(fields []gormmodels.Field, key string) *gormmodels.Field {
+		for i := range fields {
+			if fields[i].DataKey == key {
+				return &fields[i]
+			}
+		}
+		return nil
+	}
+
+	suggestions := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, oldKey := range removed {
+		oldField := byKey(current, oldKey)
+		for _, newKey := range added {
+			if taken[newKey] {
+				continue
+			}
+			newField := byKey(proposed, newKey)
+			sameName := oldField.Name != "" && oldField.Name == newField.Name
+			samePlace := oldField.PageIndex == newField.PageIndex &&
+				oldField.PositionTop == newField.PositionTop && oldField.PositionLeft == newField.PositionLeft
+			if sameName || samePlace {
+				suggestions[oldKey] = newKey
+				taken[newKey] = true
+				break
+			}
+		}
+	}
+	return suggestions
+}
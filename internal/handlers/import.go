@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportUploadSize bounds the CSV files accepted for import.
+const maxImportUploadSize = 10 << 20
+
+// ImportHandler manages the column mapping profiles of templates and imports
+// CSV files as submissions.
+type ImportHandler struct {
+	importService *services.ImportService
+	formService   *services.FormService
+	pipeline      *submissionPipeline
+}
+
+func NewImportHandler(importService *services.ImportService, formService *services.FormService, optionListService *services.OptionListService, signatureService *services.SignatureService, stampService *services.StampService, validationService *services.ValidationWebhookService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+		formService:   formService,
+		pipeline:      newSubmissionPipeline(formService, optionListService, signatureService, stampService, validationService),
+	}
+}
+
+type ImportProfileRequest struct {
+	Name    string                     `json:"name" binding:"required"`
+	Columns []gormmodels.ColumnMapping `json:"columns" binding:"required"`
+	Locale  string                     `json:"locale"`
+	Status  string                     `json:"status"`
+}
+
+// importRowResult is the outcome of importing one CSV record.
+type importRowResult struct {
+	Line         int      `json:"line"`
+	SubmissionID string   `json:"submissionId,omitempty"`
+	DuplicateOf  string   `json:"duplicateOf,omitempty"`
+	Errors       []string `json:"errors,omitempty"`
+}
+
+func (h *ImportHandler) CreateProfile(c *gin.Context) {
+	var req ImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if req.Status == "" {
+		req.Status = "submitted"
+	}
+
+	profile := &gormmodels.ImportProfile{
+		TemplateID: c.Param("id"),
+		Name:       req.Name,
+		Columns:    req.Columns,
+		Locale:     req.Locale,
+		Status:     req.Status,
+		CreatedBy:  c.GetString(auth.ContextUserID),
+	}
+
+	if err := h.importService.CreateProfile(profile); err != nil {
+		writeServiceError(c, "Failed to create import profile", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, profile)
+}
+
+func (h *ImportHandler) GetProfiles(c *gin.Context) {
+	profiles, err := h.importService.GetProfiles(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch import profiles", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profiles)
+}
+
+func (h *ImportHandler) GetProfile(c *gin.Context) {
+	profile, err := h.importService.GetProfile(c.Param("id"), c.Param("profileId"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch import profile", err)
+		return
+	}
+
+	if profile == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import profile not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ImportHandler) UpdateProfile(c *gin.Context) {
+	profile, err := h.importService.GetProfile(c.Param("id"), c.Param("profileId"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch import profile", err)
+		return
+	}
+
+	if profile == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Import profile not found"})
+		return
+	}
+
+	var req ImportProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if req.Status == "" {
+		req.Status = profile.Status
+	}
+
+	profile.Name = req.Name
+	profile.Columns = req.Columns
+	profile.Locale = req.Locale
+	profile.Status = req.Status
+
+	if err := h.importService.UpdateProfile(profile); err != nil {
+		writeServiceError(c, "Failed to update import profile", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *ImportHandler) DeleteProfile(c *gin.Context) {
+	if err := h.importService.DeleteProfile(c.Param("id"), c.Param("profileId")); err != nil {
+		writeServiceError(c, "Failed to delete import profile", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Import profile deleted successfully"})
+}
+
+// Import creates a submission from every record of a CSV file, sent as the
+// multipart "file". Columns are mapped by the saved profile named by
+// "profileId", or by an inline JSON "mapping" of the same shape as a
+// profile's columns. Each record goes through the same checks as a
+// submission made through the API; records that fail them are reported and
+// skipped while the rest are imported. With dryRun=true nothing is saved and
+// the validation webhook is not called.
+func (h *ImportHandler) Import(c *gin.Context) {
+	templateID := c.Param("id")
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportUploadSize+64<<10)
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	profile := &gormmodels.ImportProfile{Status: "submitted"}
+	if profileID := c.PostForm("profileId"); profileID != "" {
+		profile, err = h.importService.GetProfile(templateID, profileID)
+		if err != nil {
+			writeServiceError(c, "Failed to fetch import profile", err)
+			return
+		}
+		if profile == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Import profile not found"})
+			return
+		}
+	} else if mapping := c.PostForm("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &profile.Columns); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mapping", "details": err.Error()})
+			return
+		}
+		if err := h.importService.CheckColumns(templateID, profile.Columns); err != nil {
+			writeServiceError(c, "Invalid mapping", err)
+			return
+		}
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A profileId or a mapping is required"})
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(c.PostForm("dryRun"))
+	status := c.DefaultPostForm("status", profile.Status)
+	locale := c.PostForm("locale")
+	if locale == "" {
+		locale = profile.Locale
+	}
+
+	content, err := io.ReadAll(io.LimitReader(file, maxImportUploadSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file", "details": err.Error()})
+		return
+	}
+	if len(content) > maxImportUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large", "details": fmt.Sprintf("the limit is %d MB", maxImportUploadSize>>20)})
+		return
+	}
+
+	rows, err := h.importService.ReadCSV(bytes.NewReader(content), profile.Columns, inputLocale(c, locale))
+	if err != nil {
+		writeServiceError(c, "Invalid file", err)
+		return
+	}
+
+	results := make([]importRowResult, 0, len(rows))
+	imported, failed := 0, 0
+	for _, row := range rows {
+		result := importRowResult{Line: row.Line, Errors: row.Errors}
+		if len(result.Errors) == 0 {
+			submission := h.pipeline.newSubmission(c, templateID, ChannelImport, requestCreator(c))
+			submission.FormData = row.FormData
+			submission.Status = status
+			result.Errors, err = h.importRow(c, submission, inputLocale(c, locale), dryRun)
+			if err != nil {
+				result.Errors = []string{err.Error()}
+			} else if len(result.Errors) == 0 {
+				result.DuplicateOf = submission.DuplicateOf
+				if !dryRun {
+					result.SubmissionID = submission.ID
+				}
+			}
+		}
+
+		if len(result.Errors) > 0 {
+			failed++
+		} else {
+			imported++
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dryRun":   dryRun,
+		"imported": imported,
+		"failed":   failed,
+		"rows":     results,
+	})
+}
+
+// importRow runs one imported submission through the submission pipeline and
+// saves it unless dryRun is set. Rejections are returned as row errors and
+// failures as the error; either way the import moves on to the next row.
+func (h *ImportHandler) importRow(c *gin.Context, submission *gormmodels.FormSubmission, locale services.InputLocale, dryRun bool) ([]string, error) {
+	rejection, err := h.pipeline.check(c, submission, locale, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	if rejection != nil {
+		return rejection.messages, nil
+	}
+
+	if dryRun {
+		return nil, nil
+	}
+	if err := h.formService.Create(submission); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
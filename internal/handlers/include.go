@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CreateIncludeRequest struct {
+	IncludedTemplateID string `json:"includedTemplateId" binding:"required"`
+	PageOffset         int    `json:"pageOffset" binding:"min=0"`
+	Position           int    `json:"position"`
+}
+
+func (h *TemplateHandler) GetIncludes(c *gin.Context) {
+	templateID := c.Param("id")
+
+	includes, err := h.templateService.GetIncludes(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template includes", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, includes)
+}
+
+// AddInclude places another template's pages into this template. The include
+// is rejected if it would create a cycle or overlap existing pages.
+func (h *TemplateHandler) AddInclude(c *gin.Context) {
+	templateID := c.Param("id")
+
+	var req CreateIncludeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON", "details": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.GetByID(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	include := &gormmodels.TemplateInclude{
+		IncludedTemplateID: req.IncludedTemplateID,
+		PageOffset:         req.PageOffset,
+		Position:           req.Position,
+	}
+
+	if err := h.templateService.AddInclude(template, include); err != nil {
+		if errors.Is(err, services.ErrInvalidInclude) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid include", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template include"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, include)
+}
+
+func (h *TemplateHandler) DeleteInclude(c *gin.Context) {
+	templateID := c.Param("id")
+
+	includeID, err := strconv.ParseUint(c.Param("includeId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid include ID"})
+		return
+	}
+
+	if err := h.templateService.DeleteInclude(templateID, uint(includeID)); err != nil {
+		writeServiceError(c, "Failed to delete template include", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template include deleted successfully"})
+}
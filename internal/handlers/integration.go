@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxInboundPayloadSize = 1 << 20
+
+type IntegrationHandler struct {
+	integrationService *services.IntegrationService
+	templateService    *services.TemplateService
+	pipeline           *submissionPipeline
+}
+
+func NewIntegrationHandler(integrationService *services.IntegrationService, formService *services.FormService, templateService *services.TemplateService, optionListService *services.OptionListService, signatureService *services.SignatureService, stampService *services.StampService, validationService *services.ValidationWebhookService) *IntegrationHandler {
+	return &IntegrationHandler{
+		integrationService: integrationService,
+		templateService:    templateService,
+		pipeline:           newSubmissionPipeline(formService, optionListService, signatureService, stampService, validationService),
+	}
+}
+
+type CreateIntegrationRequest struct {
+	Name         string            `json:"name" binding:"required"`
+	FieldMapping map[string]string `json:"fieldMapping"`
+	Status       string            `json:"status"`
+}
+
+func (h *IntegrationHandler) Create(c *gin.Context) {
+	templateID := c.Param("id")
+
+	var req CreateIntegrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.GetByID(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	if req.Status == "" {
+		req.Status = "submitted"
+	}
+
+	integration := &gormmodels.InboundIntegration{
+		TemplateID:   templateID,
+		Name:         req.Name,
+		FieldMapping: req.FieldMapping,
+		Status:       req.Status,
+	}
+
+	secret, err := h.integrationService.Create(integration)
+	if err != nil {
+		writeServiceError(c, "Failed to create integration", err)
+		return
+	}
+
+	// The secret is only returned once, at creation time.
+	c.JSON(http.StatusCreated, gin.H{
+		"integration": integration,
+		"secret":      secret,
+		"url":         "/api/integrations/inbound/" + integration.Token,
+	})
+}
+
+func (h *IntegrationHandler) GetByTemplateID(c *gin.Context) {
+	integrations, err := h.integrationService.GetByTemplateID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch integrations", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, integrations)
+}
+
+func (h *IntegrationHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid integration ID"})
+		return
+	}
+
+	integration, err := h.integrationService.GetByID(uint(id))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch integration", err)
+		return
+	}
+
+	if integration == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Integration not found"})
+		return
+	}
+
+	if checkTemplateAccess(c, h.templateService, integration.TemplateID, services.AccessOwner) {
+		return
+	}
+
+	if err := h.integrationService.Delete(integration.ID); err != nil {
+		writeServiceError(c, "Failed to delete integration", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Integration deleted successfully"})
+}
+
+// Inbound creates a submission from a signed third-party payload.
+func (h *IntegrationHandler) Inbound(c *gin.Context) {
+	integration, err := h.integrationService.GetByToken(c.Param("token"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch integration", err)
+		return
+	}
+
+	if integration == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Integration not found"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxInboundPayloadSize+1))
+	if err != nil || len(body) > maxInboundPayloadSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if !h.integrationService.VerifySignature(integration, body, c.GetHeader("X-FastFill-Signature")) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid signature"})
+		return
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON", "details": err.Error()})
+		return
+	}
+
+	// Inbound payloads have no user or workspace, so they cannot use stored
+	// signatures or choose stamps.
+	submission := h.pipeline.newSubmission(c, integration.TemplateID, ChannelIntegration, submissionCreator{})
+	submission.Metadata.IntegrationID = integration.ID
+	submission.FormData = h.integrationService.MapPayload(integration, payload)
+	submission.Status = integration.Status
+	h.pipeline.submit(c, submission, inputLocale(c, ""))
+}
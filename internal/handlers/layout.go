@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetLayout exports the field geometry and formatting of a template as a
+// standalone JSON document. ?download=true serves it as an attachment.
+func (h *TemplateHandler) GetLayout(c *gin.Context) {
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	if c.Query("download") == "true" {
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.layout.json"`, template.ID))
+	}
+	c.JSON(http.StatusOK, h.templateService.ExportLayout(template))
+}
+
+// PutLayout applies a layout document to the fields of a template, matching
+// fields by DataKey. Fields are never added or removed.
+func (h *TemplateHandler) PutLayout(c *gin.Context) {
+	var layout services.TemplateLayout
+	if err := c.ShouldBindJSON(&layout); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON", "details": err.Error()})
+		return
+	}
+
+	if err := validateLayout(&layout); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid layout", "details": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	result, err := h.templateService.ImportLayout(template, &layout)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidLayout) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid layout", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import layout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func validateLayout(layout *services.TemplateLayout) error {
+	for i, f := range layout.Fields {
+		if f.DataKey == "" {
+			return fmt.Errorf("fields[%d]: dataKey is required", i)
+		}
+		if f.PageIndex < 0 || f.Occurrence < 0 {
+			return fmt.Errorf("fields[%d]: pageIndex and occurrence must not be negative", i)
+		}
+		if f.Position.Width < 0 || f.Position.Height < 0 {
+			return fmt.Errorf("fields[%d]: width and height must not be negative", i)
+		}
+		if f.FontSize != 0 && (f.FontSize < minFontSize || f.FontSize > maxFontSize) {
+			return fmt.Errorf("fields[%d]: fontSize must be between %d and %d", i, minFontSize, maxFontSize)
+		}
+		switch f.Rotation {
+		case 0, 90, 180, 270:
+		default:
+			return fmt.Errorf("fields[%d]: rotation must be 0, 90, 180 or 270", i)
+		}
+		for _, class := range strings.Fields(f.ClassName) {
+			if !cssClassNamePattern.MatchString(class) {
+				return fmt.Errorf("fields[%d]: invalid class name %q", i, class)
+			}
+		}
+	}
+	return nil
+}
+
+// BulkUpdateFields sets the same formatting or size on a selection of fields
+// in one go, without resending the whole template.
+func (h *TemplateHandler) BulkUpdateFields(c *gin.Context) {
+	var update services.FieldBulkUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON", "details": err.Error()})
+		return
+	}
+
+	if update.FontFamily != nil && strings.TrimSpace(*update.FontFamily) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid update", "details": "fontFamily must not be empty"})
+		return
+	}
+
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	result, err := h.templateService.BulkUpdateFields(template, &update)
+	if err != nil {
+		writeServiceError(c, "Failed to update fields", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetGrid returns the editor grid settings of a template.
+func (h *TemplateHandler) GetGrid(c *gin.Context) {
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, template.Grid)
+}
+
+// PutGrid replaces the editor grid settings of a template.
+func (h *TemplateHandler) PutGrid(c *gin.Context) {
+	var grid gormmodels.GridSettings
+	if err := c.ShouldBindJSON(&grid); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON", "details": err.Error()})
+		return
+	}
+
+	if err := h.templateService.SetGrid(c.Param("id"), grid); err != nil {
+		writeServiceError(c, "Failed to save grid", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, grid)
+}
+
+// SnapToGrid moves every field of a template onto its grid and guides.
+// ?pageIndex= limits snapping to one page; ?dryRun=true only reports the
+// positions fields would get.
+func (h *TemplateHandler) SnapToGrid(c *gin.Context) {
+	var pageIndex *int
+	if value := c.Query("pageIndex"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index"})
+			return
+		}
+		pageIndex = &page
+	}
+
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	result, err := h.templateService.SnapToGrid(template, pageIndex, c.Query("dryRun") == "true")
+	if err != nil {
+		writeServiceError(c, "Failed to snap fields to grid", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
@@ -17,11 +18,13 @@ import (
 
 type LegacyHandler struct {
 	templateService *services.TemplateService
+	uploadService   *services.UploadService
 }
 
-func NewLegacyHandler(templateService *services.TemplateService) *LegacyHandler {
+func NewLegacyHandler(templateService *services.TemplateService, uploadService *services.UploadService) *LegacyHandler {
 	return &LegacyHandler{
 		templateService: templateService,
+		uploadService:   uploadService,
 	}
 }
 
@@ -116,7 +119,7 @@ func (h *LegacyHandler) CreateTemplateFromFormSVG(c *gin.Context) {
 	}
 
 	if err := h.templateService.Create(template); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+		writeServiceError(c, "Failed to create template", err)
 		return
 	}
 
@@ -128,6 +131,16 @@ func (h *LegacyHandler) CreateTemplateFromFormSVG(c *gin.Context) {
 		fmt.Printf("Warning: Failed to update template SVG background: %v\n", err)
 	}
 
+	// Copy the static file into the template's page files right away, so the
+	// template does not depend on the legacy background.
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+	if _, err := h.uploadService.MigrateLegacyBackground(ctx, template.ID); err != nil {
+		fmt.Printf("Warning: Failed to copy SVG background into page files: %v\n", err)
+	} else {
+		template.SVGBackground = template.ID
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"id":      template.ID,
 		"message": "Template created successfully",
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LegalHoldHandler struct {
+	legalHoldService *services.LegalHoldService
+	formService      *services.FormService
+	documentService  *services.DocumentService
+	templateService  *services.TemplateService
+	auditService     *services.AuditService
+}
+
+func NewLegalHoldHandler(legalHoldService *services.LegalHoldService, formService *services.FormService, documentService *services.DocumentService, templateService *services.TemplateService, auditService *services.AuditService) *LegalHoldHandler {
+	return &LegalHoldHandler{
+		legalHoldService: legalHoldService,
+		formService:      formService,
+		documentService:  documentService,
+		templateService:  templateService,
+		auditService:     auditService,
+	}
+}
+
+type LegalHoldRequest struct {
+	Reason string `json:"reason" binding:"required,max=512"`
+}
+
+// HoldSubmission keeps a submission and its documents from deletion and
+// retention purges until the hold is released. Holds are placed and released
+// by the workspace that owns the template, as they decide what may be deleted.
+func (h *LegalHoldHandler) HoldSubmission(c *gin.Context) {
+	if _, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessOwner); refused {
+		return
+	}
+	h.hold(c, "submission", h.legalHoldService.HoldSubmission)
+}
+
+func (h *LegalHoldHandler) ReleaseSubmission(c *gin.Context) {
+	if _, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessOwner); refused {
+		return
+	}
+	h.release(c, "submission", h.legalHoldService.ReleaseSubmission)
+}
+
+func (h *LegalHoldHandler) HoldDocument(c *gin.Context) {
+	if h.checkDocumentAccess(c) {
+		return
+	}
+	h.hold(c, "document", h.legalHoldService.HoldDocument)
+}
+
+func (h *LegalHoldHandler) ReleaseDocument(c *gin.Context) {
+	if h.checkDocumentAccess(c) {
+		return
+	}
+	h.release(c, "document", h.legalHoldService.ReleaseDocument)
+}
+
+// checkDocumentAccess refuses the request unless the document exists and the
+// workspace owns its template. It writes the response and returns true when
+// refused.
+func (h *LegalHoldHandler) checkDocumentAccess(c *gin.Context) bool {
+	document, err := h.documentService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch document", err)
+		return true
+	}
+
+	if document == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return true
+	}
+
+	return checkTemplateAccess(c, h.templateService, document.TemplateID, services.AccessOwner)
+}
+
+func (h *LegalHoldHandler) hold(c *gin.Context, kind string, hold func(id, reason, actor string) error) {
+	var req LegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	id := c.Param("id")
+	if err := hold(id, req.Reason, c.GetString(auth.ContextUserID)); err != nil {
+		writeServiceError(c, "Failed to place legal hold", err)
+		return
+	}
+
+	h.audit(c, "legal_hold.apply", kind+":"+id)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Legal hold placed successfully"})
+}
+
+func (h *LegalHoldHandler) release(c *gin.Context, kind string, release func(id string) error) {
+	id := c.Param("id")
+	if err := release(id); err != nil {
+		writeServiceError(c, "Failed to release legal hold", err)
+		return
+	}
+
+	h.audit(c, "legal_hold.release", kind+":"+id)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Legal hold released successfully"})
+}
+
+func (h *LegalHoldHandler) audit(c *gin.Context, action, resource string) {
+	h.auditService.Record(&gormmodels.AuditLog{
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		UserID:      c.GetString(auth.ContextUserID),
+		TokenID:     c.GetString(auth.ContextTokenID),
+		Action:      action,
+		Resource:    resource,
+		IPAddress:   c.ClientIP(),
+	})
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"sort"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// templateLocale picks the translation locale for a template from the
+// ?locale= query parameter or the Accept-Language header. An empty result
+// means the fields' own labels should be used.
+func templateLocale(c *gin.Context, t gormmodels.Template) string {
+	available := make(map[string]bool)
+	for _, f := range t.Fields {
+		for locale := range f.Translations {
+			available[locale] = true
+		}
+	}
+	if len(available) == 0 {
+		return ""
+	}
+
+	var preferred []language.Tag
+	if locale := c.Query("locale"); locale != "" {
+		tag, err := language.Parse(locale)
+		if err != nil {
+			return ""
+		}
+		preferred = []language.Tag{tag}
+	} else {
+		tags, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+		if err != nil || len(tags) == 0 {
+			return ""
+		}
+		preferred = tags
+	}
+
+	locales := make([]string, 0, len(available))
+	for locale := range available {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	supported := make([]language.Tag, len(locales))
+	for i, locale := range locales {
+		supported[i] = language.Make(locale)
+	}
+
+	_, index, confidence := language.NewMatcher(supported).Match(preferred...)
+	if confidence < language.High {
+		return ""
+	}
+	return locales[index]
+}
+
+// translateField returns the field's label and options for a locale. When
+// options are translated, the stored option values are returned separately so
+// submissions keep using the canonical values.
+func translateField(f gormmodels.Field, options []string, locale string) (name string, labels []string, values []string) {
+	name = f.Name
+	labels = options
+
+	translation, ok := f.Translations[locale]
+	if locale == "" || !ok {
+		return name, labels, nil
+	}
+
+	if translation.Name != "" {
+		name = translation.Name
+	}
+	if len(translation.Options) > 0 && len(translation.Options) == len(options) {
+		labels = translation.Options
+		values = options
+	}
+	return name, labels, values
+}
+
+// inputLocale picks the locale used to read numbers and dates in submitted
+// form data: an explicit locale from the request body, then the ?locale=
+// query parameter, Content-Language and Accept-Language.
+func inputLocale(c *gin.Context, explicit string) services.InputLocale {
+	return services.MatchInputLocale(explicit, c.Query("locale"), c.GetHeader("Content-Language"), c.GetHeader("Accept-Language"))
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsHandler serves database pool metrics in the Prometheus text format,
+// using the metric names of the client library's DBStats collector.
+type MetricsHandler struct {
+	dbName string
+}
+
+func NewMetricsHandler(dbName string) *MetricsHandler {
+	return &MetricsHandler{dbName: dbName}
+}
+
+type metric struct {
+	name  string
+	kind  string
+	help  string
+	value float64
+}
+
+func (h *MetricsHandler) Metrics(c *gin.Context) {
+	stats, err := internal.DBStats()
+	if err != nil {
+		c.String(http.StatusServiceUnavailable, "database unavailable")
+		return
+	}
+
+	metrics := []metric{
+		{"go_sql_max_open_connections", "gauge", "Maximum number of open connections to the database.", float64(stats.MaxOpenConnections)},
+		{"go_sql_open_connections", "gauge", "The number of established connections both in use and idle.", float64(stats.OpenConnections)},
+		{"go_sql_in_use_connections", "gauge", "The number of connections currently in use.", float64(stats.InUse)},
+		{"go_sql_idle_connections", "gauge", "The number of idle connections.", float64(stats.Idle)},
+		{"go_sql_wait_count_total", "counter", "The total number of connections waited for.", float64(stats.WaitCount)},
+		{"go_sql_wait_duration_seconds_total", "counter", "The total time blocked waiting for a new connection.", stats.WaitDuration.Seconds()},
+		{"go_sql_max_idle_closed_total", "counter", "The total number of connections closed due to SetMaxIdleConns.", float64(stats.MaxIdleClosed)},
+		{"go_sql_max_idle_time_closed_total", "counter", "The total number of connections closed due to SetConnMaxIdleTime.", float64(stats.MaxIdleTimeClosed)},
+		{"go_sql_max_lifetime_closed_total", "counter", "The total number of connections closed due to SetConnMaxLifetime.", float64(stats.MaxLifetimeClosed)},
+	}
+
+	label := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(h.dbName)
+
+	var b strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n%s{db_name=\"%s\"} %g\n", m.name, m.help, m.name, m.kind, m.name, label, m.value)
+	}
+
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+}
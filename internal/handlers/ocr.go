@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OCRHandler struct {
+	ocrService    *services.OCRService
+	usageService  *services.UsageService
+	maxUploadSize int64
+}
+
+func NewOCRHandler(ocrService *services.OCRService, usageService *services.UsageService, maxUploadSize int64) *OCRHandler {
+	return &OCRHandler{
+		ocrService:    ocrService,
+		usageService:  usageService,
+		maxUploadSize: maxUploadSize,
+	}
+}
+
+// ThaiID reads the front of a Thai national ID card uploaded as the multipart
+// "image" and returns its fields. Every call counts towards the workspace's
+// monthly Vision quota. ?text=true also returns the raw OCR text.
+func (h *OCRHandler) ThaiID(c *gin.Context) {
+	// Leave room for the multipart envelope around the image.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxUploadSize+64<<10)
+
+	file, _, err := c.Request.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No image uploaded", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	image, err := io.ReadAll(io.LimitReader(file, h.maxUploadSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read image", "details": err.Error()})
+		return
+	}
+	if int64(len(image)) > h.maxUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image is too large", "details": fmt.Sprintf("the limit is %d KB", h.maxUploadSize>>10)})
+		return
+	}
+	if _, err := services.OCRMimeType(image); err != nil {
+		writeServiceError(c, "Invalid image", err)
+		return
+	}
+
+	route, err := h.usageService.AuthorizeVision(c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "OCR is not available", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 45*time.Second)
+	defer cancel()
+
+	text, err := h.ocrService.Text(ctx, image, route)
+	if err != nil {
+		writeServiceError(c, "Failed to read ID card", err)
+		return
+	}
+
+	response := gin.H{
+		"card":     services.ParseThaiIDCard(text),
+		"provider": route,
+	}
+	if c.Query("text") == "true" {
+		response["text"] = text
+	}
+	c.JSON(http.StatusOK, response)
+}
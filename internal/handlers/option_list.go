@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OptionListHandler struct {
+	optionListService *services.OptionListService
+}
+
+func NewOptionListHandler(optionListService *services.OptionListService) *OptionListHandler {
+	return &OptionListHandler{
+		optionListService: optionListService,
+	}
+}
+
+type OptionListRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Options     []string `json:"options" binding:"required,min=1"`
+}
+
+func (h *OptionListHandler) GetAll(c *gin.Context) {
+	lists, err := h.optionListService.GetAll(c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch option lists", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+func (h *OptionListHandler) GetByID(c *gin.Context) {
+	list, err := h.optionListService.GetByID(c.Param("id"), c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch option list", err)
+		return
+	}
+
+	if list == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Option list not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+func (h *OptionListHandler) Create(c *gin.Context) {
+	var req OptionListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	list := &gormmodels.OptionList{
+		ID:          uuid.New().String(),
+		Name:        req.Name,
+		Description: req.Description,
+		Options:     cleanOptions(req.Options),
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+	}
+
+	if err := h.optionListService.Create(list); err != nil {
+		writeServiceError(c, "Failed to create option list", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, list)
+}
+
+func (h *OptionListHandler) Update(c *gin.Context) {
+	var req OptionListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	list, err := h.optionListService.GetByID(c.Param("id"), c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch option list", err)
+		return
+	}
+
+	if list == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Option list not found"})
+		return
+	}
+
+	list.Name = req.Name
+	list.Description = req.Description
+	list.Options = cleanOptions(req.Options)
+	list.UpdatedAt = time.Now()
+
+	if err := h.optionListService.Update(list); err != nil {
+		writeServiceError(c, "Failed to update option list", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// Delete refuses to remove a list that fields still reference.
+func (h *OptionListHandler) Delete(c *gin.Context) {
+	listID := c.Param("id")
+	workspaceID := c.GetString(auth.ContextWorkspaceID)
+
+	list, err := h.optionListService.GetByID(listID, workspaceID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch option list", err)
+		return
+	}
+
+	if list == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Option list not found"})
+		return
+	}
+
+	fields, err := h.optionListService.CountFields(listID)
+	if err != nil {
+		writeServiceError(c, "Failed to delete option list", err)
+		return
+	}
+
+	if fields > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Option list is used by template fields", "fields": fields})
+		return
+	}
+
+	if err := h.optionListService.Delete(listID, workspaceID); err != nil {
+		writeServiceError(c, "Failed to delete option list", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Option list deleted successfully"})
+}
+
+// cleanOptions trims options and drops empty and duplicate entries.
+func cleanOptions(options []string) []string {
+	seen := make(map[string]bool, len(options))
+	cleaned := make([]string, 0, len(options))
+	for _, option := range options {
+		option = strings.TrimSpace(option)
+		if option == "" || seen[option] {
+			continue
+		}
+		seen[option] = true
+		cleaned = append(cleaned, option)
+	}
+	return cleaned
+}
+
+// writeOptionViolations responds with 422 when submitted values fall outside
+// their fields' option lists. It reports whether a response was written.
+func writeOptionViolations(c *gin.Context, violations []services.OptionViolation) bool {
+	if len(violations) == 0 {
+		return false
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":      "Submitted values are not in their option lists",
+		"violations": violations,
+	})
+	return true
+}
@@ -3,19 +3,24 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
 	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/renderer"
 	"github.com/dhanavadh/fastfill-backend/internal/services"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
 
-	"github.com/chromedp/cdproto/page"
-	"github.com/chromedp/chromedp"
 	"github.com/gin-gonic/gin"
 )
 
@@ -43,6 +48,13 @@ func getString(m map[string]interface{}, key string, defaultValue string) string
 	return defaultValue
 }
 
+// dataValue returns the form data value for a DataKey, following nested
+// paths such as "applicant.address.province" or "children[2].name".
+func dataValue(data map[string]interface{}, dataKey string) interface{} {
+	value, _ := utils.LookupDataPath(data, dataKey)
+	return value
+}
+
 func getInt(m map[string]interface{}, key string, defaultValue int) int {
 	if val, ok := m[key]; ok {
 		if f, ok := val.(float64); ok {
@@ -64,17 +76,66 @@ func getBool(m map[string]interface{}, key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// applyCalibrations shifts field positions by the stored per-page calibration
+// so the printed PDF matches what the editor previewed.
+func applyCalibrations(fields []gormmodels.Field, calibrations []gormmodels.PageCalibration) {
+	if len(calibrations) == 0 {
+		return
+	}
+
+	byPage := make(map[int]gormmodels.PageCalibration, len(calibrations))
+	for _, cal := range calibrations {
+		byPage[cal.PageIndex] = cal
+	}
+
+	for i := range fields {
+		if cal, ok := byPage[fields[i].PageIndex]; ok {
+			cal.Apply(&fields[i])
+		}
+	}
+}
+
 type PDFHandler struct {
-	templateService *services.TemplateService
-	formService     *services.FormService
-	uploadHandler   *UploadHandler
+	templateService    *services.TemplateService
+	formService        *services.FormService
+	uploadHandler      *UploadHandler
+	diagnosticsService *services.DiagnosticsService
+	optimizer          *services.PDFOptimizer
+	renderer           renderer.Renderer
+	signatureService   *services.SignatureService
+	stampService       *services.StampService
+	eventService       *services.EventService
+
+	preRenderHooks  []PreRenderHook
+	postRenderHooks []PostRenderHook
+
+	// SVGFilesOnly renders page backgrounds from SVGFiles alone, ignoring the
+	// legacy svgBackground of templates without page files.
+	SVGFilesOnly bool
+
+	// Deterministic renders PDFs in deterministic mode unless a request
+	// says otherwise; see renderPDF.
+	Deterministic bool
+
+	// BulkWorkers is the number of PDFs a bulk generation renders at once;
+	// zero means defaultBulkWorkers.
+	BulkWorkers int
+
+	// Limits bound the size of every generation.
+	Limits GenerationLimits
 }
 
-func NewPDFHandler(templateService *services.TemplateService, formService *services.FormService, uploadHandler *UploadHandler) *PDFHandler {
+func NewPDFHandler(templateService *services.TemplateService, formService *services.FormService, uploadHandler *UploadHandler, diagnosticsService *services.DiagnosticsService, optimizer *services.PDFOptimizer, pdfRenderer renderer.Renderer, signatureService *services.SignatureService, stampService *services.StampService, eventService *services.EventService) *PDFHandler {
 	return &PDFHandler{
-		templateService: templateService,
-		formService:     formService,
-		uploadHandler:   uploadHandler,
+		templateService:    templateService,
+		formService:        formService,
+		uploadHandler:      uploadHandler,
+		diagnosticsService: diagnosticsService,
+		optimizer:          optimizer,
+		renderer:           pdfRenderer,
+		signatureService:   signatureService,
+		stampService:       stampService,
+		eventService:       eventService,
 	}
 }
 
@@ -84,6 +145,13 @@ type GeneratePDFRequest struct {
 	FormattingData  map[string]interface{} `json:"formattingData,omitempty"`
 	HtmlData        map[string]interface{} `json:"htmlData,omitempty"`
 	CustomFields    []interface{}          `json:"customFields,omitempty"`
+	Optimize        *bool                  `json:"optimize,omitempty"`
+	OptimizeDPI     int                    `json:"optimizeDpi,omitempty"`
+	FontFallback    string                 `json:"fontFallback,omitempty" binding:"omitempty,oneof=substitute warn strict"`
+	// SuppressBackground overrides the template's setting for this request.
+	SuppressBackground *bool               `json:"suppressBackground,omitempty"`
+	// Deterministic overrides RENDERER_DETERMINISTIC for this request.
+	Deterministic   *bool                  `json:"deterministic,omitempty"`
 }
 
 func (h *PDFHandler) GeneratePDF(c *gin.Context) {
@@ -99,7 +167,7 @@ func (h *PDFHandler) GeneratePDF(c *gin.Context) {
 
 	template, err := h.templateService.GetByID(req.TemplateID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch template"})
+		writeServiceError(c, "Failed to fetch template", err)
 		return
 	}
 
@@ -108,12 +176,33 @@ func (h *PDFHandler) GeneratePDF(c *gin.Context) {
 		return
 	}
 
+	if checkTemplateAccess(c, h.templateService, req.TemplateID, services.AccessUse) {
+		return
+	}
+
+	if checkSignatures(c, h.signatureService, req.TemplateID, req.Data, c.GetString(auth.ContextUserID)) {
+		return
+	}
+
+	if checkStamps(c, h.stampService, req.TemplateID, req.Data, c.GetString(auth.ContextWorkspaceID)) {
+		return
+	}
+
+	template, err = h.resolveTemplate(template)
+	if err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+
 	log.Printf("About to generate HTML with data: %+v", req.Data)
 	log.Printf("About to generate HTML with htmlData: %+v", req.HtmlData)
 	log.Printf("Custom fields received: %+v", req.CustomFields)
 	
 	// Add custom fields to template
 	extendedTemplate := *template
+	if req.SuppressBackground != nil {
+		extendedTemplate.SuppressBackground = *req.SuppressBackground
+	}
 	if req.CustomFields != nil && len(req.CustomFields) > 0 {
 		for _, customFieldData := range req.CustomFields {
 			if fieldMap, ok := customFieldData.(map[string]interface{}); ok {
@@ -128,6 +217,8 @@ func (h *PDFHandler) GeneratePDF(c *gin.Context) {
 					PositionLeft:   getInt(fieldMap, "position.left", 0),
 					PositionWidth:  getInt(fieldMap, "position.width", 150),
 					PositionHeight: getInt(fieldMap, "position.height", 25),
+					Rotation:       getInt(fieldMap, "rotation", 0),
+					Vertical:       getBool(fieldMap, "vertical", false),
 				}
 				
 				// Handle formatting from fieldMap or from global formattingData
@@ -168,86 +259,311 @@ func (h *PDFHandler) GeneratePDF(c *gin.Context) {
 		}
 	}
 	
-	htmlContent, err := h.generateHTML(c, extendedTemplate, req.Data, req.FormattingData, req.HtmlData)
+	generation := newGeneration(c, extendedTemplate, "", req.Data, req.FormattingData, req.HtmlData)
+	if err := h.beforeRender(c.Request.Context(), generation); err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+
+	fonts := &fontCheck{Mode: req.FontFallback}
+	htmlContent, err := h.generateHTML(c, *generation.Template, generation.Data, generation.FormattingData, generation.HtmlData, fonts)
 	if err != nil {
-		log.Printf("Failed to generate HTML: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate HTML"})
+		writeGenerationError(c, err)
 		return
 	}
+	c.Header("X-Font-Warnings", strconv.Itoa(len(fonts.Warnings)))
 	
 	log.Printf("Generated HTML content length: %d", len(htmlContent))
 	log.Printf("HTML content preview: %s", htmlContent[:min(1000, len(htmlContent))])
 
-	pdfBytes, err := h.htmlToPDF(htmlContent)
+	deterministic := h.deterministic(req.Deterministic)
+	pdfBytes, err := h.htmlToPDF(c.Request.Context(), htmlContent, printPaper(template.PrintOptions), deterministic)
+	if err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+
+	pdfBytes, err = h.afterRender(c.Request.Context(), generation, pdfBytes)
 	if err != nil {
-		log.Printf("Failed to generate PDF: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		writeGenerationError(c, err)
 		return
 	}
 
+	pdfBytes = h.optimizePDF(c, template, pdfBytes, req.Optimize, req.OptimizeDPI)
+	pdfBytes = h.embedMetadata(template, "", pdfBytes)
+	pdfBytes = h.normalizePDF(c, pdfBytes, deterministic)
+	h.eventService.Record(gormmodels.EventPDFGenerated, req.TemplateID, req.TemplateID, nil)
+
 	c.Header("Content-Type", "application/pdf")
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", req.TemplateID))
 	c.Data(http.StatusOK, "application/pdf", pdfBytes)
 }
 
 func (h *PDFHandler) GeneratePDFFromSubmission(c *gin.Context) {
-	submissionID := c.Param("id")
+	submission, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessUse)
+	if refused {
+		return
+	}
 
-	submission, err := h.formService.GetByID(submissionID)
+	template, err := h.templateService.GetByID(submission.TemplateID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch form submission"})
+		writeServiceError(c, "Failed to fetch template", err)
 		return
 	}
 
-	if submission == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Form submission not found"})
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
 		return
 	}
 
-	template, err := h.templateService.GetByID(submission.TemplateID)
+	template, err = h.resolveTemplate(template)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch template"})
+		writeGenerationError(c, err)
 		return
 	}
 
-	if template == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+	if value := c.Query("suppressBackground"); value != "" {
+		template.SuppressBackground = value == "true"
+	}
+
+	generation := newGeneration(c, *template, submission.ID, submission.DisplayData(), submission.FormattingData, submission.HtmlData)
+	if err := h.beforeRender(c.Request.Context(), generation); err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+
+	fonts := &fontCheck{Mode: c.Query("fontFallback")}
+	htmlContent, err := h.generateHTML(c, *generation.Template, generation.Data, generation.FormattingData, generation.HtmlData, fonts)
+	if err != nil {
+		writeGenerationError(c, err)
 		return
 	}
+	c.Header("X-Font-Warnings", strconv.Itoa(len(fonts.Warnings)))
 
-	htmlContent, err := h.generateHTML(c, *template, submission.FormData, submission.FormattingData, submission.HtmlData)
+	var deterministic *bool
+	if value := c.Query("deterministic"); value != "" {
+		enabled := value == "true"
+		deterministic = &enabled
+	}
+	isDeterministic := h.deterministic(deterministic)
+	pdfBytes, err := h.htmlToPDF(c.Request.Context(), htmlContent, printPaper(template.PrintOptions), isDeterministic)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate HTML"})
+		writeGenerationError(c, err)
 		return
 	}
 
-	pdfBytes, err := h.htmlToPDF(htmlContent)
+	pdfBytes, err = h.afterRender(c.Request.Context(), generation, pdfBytes)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate PDF"})
+		writeGenerationError(c, err)
 		return
 	}
 
-	filename := fmt.Sprintf("%s_%s.pdf", template.DisplayName, submissionID[:8])
+	var optimize *bool
+	if value := c.Query("optimize"); value != "" {
+		enabled := value == "true"
+		optimize = &enabled
+	}
+	dpi, _ := strconv.Atoi(c.Query("dpi"))
+	pdfBytes = h.optimizePDF(c, template, pdfBytes, optimize, dpi)
+	pdfBytes = h.embedMetadata(template, submission.ID, pdfBytes)
+	pdfBytes = h.normalizePDF(c, pdfBytes, isDeterministic)
+	h.eventService.Record(gormmodels.EventPDFGenerated, submission.ID, submission.TemplateID, nil)
+
+	filename := fmt.Sprintf("%s_%s.pdf", template.DisplayName, submission.ID[:8])
 	c.Header("Content-Type", "application/pdf")
 	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
 	c.Data(http.StatusOK, "application/pdf", pdfBytes)
 }
 
-func (h *PDFHandler) generateHTML(c *gin.Context, tmplData gormmodels.Template, data map[string]interface{}, formattingData map[string]interface{}, htmlData map[string]interface{}) (string, error) {
+// RenderSubmission renders a stored submission against the current template.
+// It is used for background regeneration, outside of a request.
+func (h *PDFHandler) RenderSubmission(ctx context.Context, submission *gormmodels.FormSubmission) (string, []byte, error) {
+	template, err := h.templateService.GetByID(submission.TemplateID)
+	if err != nil {
+		return "", nil, err
+	}
+	if template == nil {
+		return "", nil, fmt.Errorf("template %s not found", submission.TemplateID)
+	}
+
+	template, err = h.resolveTemplate(template)
+	if err != nil {
+		return "", nil, err
+	}
+
+	generation := newGeneration(nil, *template, submission.ID, submission.DisplayData(), submission.FormattingData, submission.HtmlData)
+	if err := h.beforeRender(ctx, generation); err != nil {
+		return "", nil, err
+	}
+
+	htmlContent, err := h.generateHTML(nil, *generation.Template, generation.Data, generation.FormattingData, generation.HtmlData, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate HTML: %w", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+
+	pdfBytes, err := h.htmlToPDF(ctx, htmlContent, printPaper(template.PrintOptions), h.Deterministic)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if pdfBytes, err = h.afterRender(ctx, generation, pdfBytes); err != nil {
+		return "", nil, err
+	}
+
+	pdfBytes = h.optimizePDF(nil, template, pdfBytes, nil, 0)
+	pdfBytes = h.embedMetadata(template, submission.ID, pdfBytes)
+	return htmlContent, h.normalizePDF(nil, pdfBytes, h.Deterministic), nil
+}
+
+// RenderSnapshot renders sample data against a template for snapshot
+// verification, returning the HTML, a screenshot of the stacked pages and the
+// page count.
+func (h *PDFHandler) RenderSnapshot(ctx context.Context, template *gormmodels.Template, data, formattingData, htmlData map[string]interface{}) (string, []byte, int, error) {
+	template, err := h.resolveTemplate(template)
+	if err != nil {
+		return "", nil, 0, err
+	}
+
+	htmlContent, err := h.generateHTML(nil, *template, data, formattingData, htmlData, nil)
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("failed to generate HTML: %w", err)
+	}
+
+	screenshot, err := h.renderer.Screenshot(ctx, htmlContent)
+	if err != nil {
+		return "", nil, 0, rendererError(err)
+	}
+
+	return htmlContent, screenshot, renderedPageCount(template), nil
+}
+
+// ResolvePreviewTemplate resolves a template's includes for a live preview
+// session, which renders it many times.
+func (h *PDFHandler) ResolvePreviewTemplate(template *gormmodels.Template) (*gormmodels.Template, error) {
+	return h.resolveTemplate(template)
+}
+
+// RenderPreviewPage renders one page of a resolved template as a PNG for a
+// live preview. Backgrounds are read from storage once per session and kept
+// in backgrounds; print layout is left out, as the preview shows the page
+// itself rather than the printed sheet.
+func (h *PDFHandler) RenderPreviewPage(ctx context.Context, template *gormmodels.Template, pageIndex int, values services.PreviewValues, backgrounds map[int]string, width int) ([]byte, error) {
+	data, htmlData, err := h.prepareData(*template, values.Data, values.HTMLData)
+	if err != nil {
+		return nil, err
+	}
+
+	legacy := len(template.SVGFiles) == 0 && !h.SVGFilesOnly
+	fields := template.Fields
+	if !legacy {
+		fields = nil
+		for _, field := range template.Fields {
+			if field.PageIndex == pageIndex {
+				fields = append(fields, field)
+			}
+		}
+	}
+
+	background, ok := backgrounds[pageIndex]
+	if !ok {
+		if legacy {
+			background, err = h.convertToDataURI(template.SVGBackground)
+			var genErr *GenerationError
+			if errors.As(err, &genErr) {
+				return nil, err
+			}
+			if err != nil {
+				return nil, storageError("STORAGE_SVG_FETCH", "Failed to load template background", "Check that the template's SVG file still exists in storage.", err)
+			}
+		}
+		for _, svgFile := range template.SVGFiles {
+			if svgFile.PageIndex == pageIndex {
+				if background, err = h.pageBackgroundURI(template, &svgFile); err != nil {
+					return nil, err
+				}
+			}
+		}
+		backgrounds[pageIndex] = background
+	}
+
+	pageHTML, err := h.buildPage(*template, fields, background, data, values.FormattingData, htmlData, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	htmlContent, err := applyCustomCSS(multiPageDocument([]string{pageHTML}), template.CustomCSS)
+	if err != nil {
+		return nil, err
+	}
+
+	image, err := h.renderer.PagePNG(ctx, htmlContent, width)
+	if err != nil {
+		return nil, rendererError(err)
+	}
+	return image, nil
+}
+
+// renderedPageCount is the number of pages generateHTML stacks for a template.
+func renderedPageCount(template *gormmodels.Template) int {
+	if len(template.SVGFiles) == 0 {
+		return 1
+	}
+
+	maxPage := 0
+	for _, field := range template.Fields {
+		maxPage = max(maxPage, field.PageIndex)
+	}
+	for _, svgFile := range template.SVGFiles {
+		maxPage = max(maxPage, svgFile.PageIndex)
+	}
+	return maxPage + 1
+}
+
+func (h *PDFHandler) generateHTML(c *gin.Context, tmplData gormmodels.Template, data map[string]interface{}, formattingData map[string]interface{}, htmlData map[string]interface{}, fonts *fontCheck) (string, error) {
 	log.Printf("Generating HTML for template %s", tmplData.ID)
 	log.Printf("Template has %d fields and %d SVG files", len(tmplData.Fields), len(tmplData.SVGFiles))
 	log.Printf("Data keys: %v", getKeys(data))
+
+	if err := h.Limits.checkPages(renderedPageCount(&tmplData)); err != nil {
+		return "", err
+	}
+	if err := h.Limits.checkDataURIs(data); err != nil {
+		return "", err
+	}
+	if err := h.Limits.checkDataURIs(htmlData); err != nil {
+		return "", err
+	}
+
+	data, htmlData, err := h.prepareData(tmplData, data, htmlData)
+	if err != nil {
+		return "", err
+	}
 	
 	// Check if this is a multi-page template
-	if len(tmplData.SVGFiles) > 0 {
-		return h.generateMultiPageHTML(tmplData, data, formattingData, htmlData)
+	if len(tmplData.SVGFiles) > 0 || h.SVGFilesOnly {
+		htmlContent, err := h.generateMultiPageHTML(tmplData, data, formattingData, htmlData, fonts)
+		if err != nil {
+			return "", err
+		}
+		return applyCustomCSS(applyFlowLayout(applyPrintLayout(htmlContent, tmplData.PrintOptions), tmplData), tmplData.CustomCSS)
 	}
 	
 	// Fallback to legacy single-page generation
 	log.Printf("Using legacy single-page generation with SVG background: %s", tmplData.SVGBackground)
-	svgDataURI, err := h.convertToDataURI(tmplData.SVGBackground)
-	if err != nil {
-		return "", fmt.Errorf("failed to convert SVG to data URI: %w", err)
+	var svgDataURI string
+	if !tmplData.SuppressBackground {
+		svgDataURI, err = h.convertToDataURI(tmplData.SVGBackground)
+		if err != nil {
+			var genErr *GenerationError
+			if errors.As(err, &genErr) {
+				return "", err
+			}
+			return "", storageError("STORAGE_SVG_FETCH", "Failed to load template background", "Check that the template's SVG file still exists in storage.", err)
+		}
 	}
 	log.Printf("SVG data URI length: %d", len(svgDataURI))
 	htmlTemplate := `
@@ -272,7 +588,7 @@ func (h *PDFHandler) generateHTML(c *gin.Context, tmplData gormmodels.Template,
             position: relative;
             width: 794px;
             height: 1123px;
-            background-image: url('{{.SVGBackground}}');
+            {{if .SVGBackground}}background-image: url('{{.SVGBackground}}');{{end}}
             background-size: cover;
             background-repeat: no-repeat;
             background-position: center;
@@ -297,84 +613,48 @@ func (h *PDFHandler) generateHTML(c *gin.Context, tmplData gormmodels.Template,
 </head>
 <body>
     <div class="document-container">
-        {{range .Fields}}
-        <div class="field" style="
-            top: {{.PositionTop}}px;
-            left: {{.PositionLeft}}px;
-            width: {{.PositionWidth}}px;
-            height: {{.PositionHeight}}px;
-            font-size: {{if .FontSize}}{{.FontSize}}pt{{else}}12pt{{end}};
-            font-weight: {{if .FontWeight}}{{.FontWeight}}{{else}}normal{{end}};
-            font-style: {{if .FontStyle}}{{.FontStyle}}{{else}}normal{{end}};
-            text-decoration: {{if .TextDecoration}}{{.TextDecoration}}{{else}}none{{end}};
-            color: {{if .TextColor}}{{.TextColor}}{{else}}#000000{{end}};
-            font-family: {{if .FontFamily}}'{{.FontFamily}}', serif{{else}}'Times New Roman', serif{{end}};
-        ">
-            <div class="field-text">{{if index $.HtmlData .DataKey}}{{index $.HtmlData .DataKey}}{{else}}{{index $.Data .DataKey}}{{end}}</div>
-        </div>
-        {{end}}
+        {{.FieldsHTML}}
     </div>
 </body>
 </html>`
 
 	tmpl, err := template.New("document").Parse(htmlTemplate)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse template: %w", err)
+		return "", templateError("TEMPLATE_RENDER", "Failed to build document layout", "", err)
 	}
 
 	// Apply formatting overrides to fields
 	fieldsWithFormatting := make([]gormmodels.Field, len(tmplData.Fields))
 	copy(fieldsWithFormatting, tmplData.Fields)
+	applyCalibrations(fieldsWithFormatting, tmplData.Calibrations)
+	applySafeMargins(fieldsWithFormatting, tmplData.PrintOptions)
 	
 	log.Printf("Template has %d fields before formatting", len(fieldsWithFormatting))
 	for i, field := range fieldsWithFormatting {
 		log.Printf("Field %d: DataKey=%s, Position=(%d,%d,%d,%d)", i, field.DataKey, field.PositionTop, field.PositionLeft, field.PositionWidth, field.PositionHeight)
 	}
 	
-	if formattingData != nil {
-		for i, field := range fieldsWithFormatting {
-			if fieldFormatting, exists := formattingData[field.DataKey]; exists {
-				if formatting, ok := fieldFormatting.(map[string]interface{}); ok {
-					if fontWeight, ok := formatting["fontWeight"].(string); ok && fontWeight != "" {
-						fieldsWithFormatting[i].FontWeight = fontWeight
-					}
-					if fontStyle, ok := formatting["fontStyle"].(string); ok && fontStyle != "" {
-						fieldsWithFormatting[i].FontStyle = fontStyle
-					}
-					if textDecoration, ok := formatting["textDecoration"].(string); ok && textDecoration != "" {
-						fieldsWithFormatting[i].TextDecoration = textDecoration
-					}
-					if textColor, ok := formatting["textColor"].(string); ok && textColor != "" {
-						fieldsWithFormatting[i].TextColor = textColor
-					}
-					if fontFamily, ok := formatting["fontFamily"].(string); ok && fontFamily != "" {
-						fieldsWithFormatting[i].FontFamily = fontFamily
-					}
-				}
-			}
-		}
+	applyFormatting(fieldsWithFormatting, formattingData)
+
+	if err := applyFontFallbacks(fieldsWithFormatting, tmplData.ScriptFonts, data, htmlData, fonts); err != nil {
+		return "", err
 	}
 
-	// Convert HTML data to template.HTML type to prevent escaping
-	processedHtmlData := make(map[string]template.HTML)
-	if htmlData != nil {
-		for key, value := range htmlData {
-			if str, ok := value.(string); ok {
-				processedHtmlData[key] = template.HTML(str)
-			}
-		}
+	fieldsHTML, err := renderFields(fieldsWithFormatting, data, htmlData)
+	if err != nil {
+		return "", err
 	}
 
 	templateData := struct {
 		SVGBackground template.URL
 		Fields        []gormmodels.Field
 		Data          map[string]interface{}
-		HtmlData      map[string]template.HTML
+		FieldsHTML    template.HTML
 	}{
 		SVGBackground: template.URL(svgDataURI),
 		Fields:        fieldsWithFormatting,
 		Data:          data,
-		HtmlData:      processedHtmlData,
+		FieldsHTML:    template.HTML(fieldsHTML),
 	}
 	
 	log.Printf("Template data prepared with %d fields and %d data entries", len(templateData.Fields), len(templateData.Data))
@@ -385,7 +665,7 @@ func (h *PDFHandler) generateHTML(c *gin.Context, tmplData gormmodels.Template,
 	var buf bytes.Buffer
 	err = tmpl.Execute(&buf, templateData)
 	if err != nil {
-		return "", fmt.Errorf("failed to execute template: %w", err)
+		return "", templateError("TEMPLATE_RENDER", "Failed to build document layout", "", err)
 	}
 
 	htmlContent := buf.String()
@@ -404,10 +684,35 @@ func (h *PDFHandler) generateHTML(c *gin.Context, tmplData gormmodels.Template,
 		log.Printf("Warning: No field divs found in generated HTML")
 	}
 	
-	return htmlContent, nil
+	return applyCustomCSS(applyFlowLayout(applyPrintLayout(htmlContent, tmplData.PrintOptions), tmplData), tmplData.CustomCSS)
+}
+
+// prepareData normalizes the values of a document before its fields are laid
+// out: HTML values are sanitized, Thai text segmented when the template asks
+// for it, and signatures and stamps embedded as images.
+func (h *PDFHandler) prepareData(tmplData gormmodels.Template, data map[string]interface{}, htmlData map[string]interface{}) (map[string]interface{}, map[string]interface{}, error) {
+	htmlData, err := normalizeHTMLData(htmlData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if tmplData.ThaiWordBreak {
+		data, htmlData = segmentThaiData(data, htmlData)
+	}
+
+	htmlData, err = h.embedSignatures(tmplData.Fields, data, htmlData)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	htmlData, err = h.embedStamps(tmplData.Fields, data, htmlData)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, htmlData, nil
 }
 
-func (h *PDFHandler) generateMultiPageHTML(tmplData gormmodels.Template, data map[string]interface{}, formattingData map[string]interface{}, htmlData map[string]interface{}) (string, error) {
+func (h *PDFHandler) generateMultiPageHTML(tmplData gormmodels.Template, data map[string]interface{}, formattingData map[string]interface{}, htmlData map[string]interface{}, fonts *fontCheck) (string, error) {
 	log.Printf("Generating multi-page HTML for template %s", tmplData.ID)
 	
 	// Group fields by page index
@@ -447,74 +752,64 @@ func (h *PDFHandler) generateMultiPageHTML(tmplData gormmodels.Template, data ma
 		}
 		
 		var svgDataURI string
-		if hasSVG {
-			// Get SVG content using the page-specific identifier
-			pageIdentifier := fmt.Sprintf("page_%d", pageIndex)
-			content, err := h.uploadHandler.uploadService.GetSVGContent(tmplData.ID, pageIdentifier)
-			if err != nil {
-				log.Printf("Warning: Failed to get SVG content for page %d: %v", pageIndex, err)
-				svgDataURI = ""
-			} else {
-				// Convert to data URI
-				encoded := base64.StdEncoding.EncodeToString(content)
-				svgDataURI = fmt.Sprintf("data:image/svg+xml;base64,%s", encoded)
-				log.Printf("Generated data URI for page %d, length: %d", pageIndex, len(svgDataURI))
-			}
-		}
-		
-		// Apply formatting overrides to fields for this page
-		fieldsWithFormatting := make([]gormmodels.Field, len(fields))
-		copy(fieldsWithFormatting, fields)
-		
-		if formattingData != nil {
-			for i, field := range fieldsWithFormatting {
-				if fieldFormatting, exists := formattingData[field.DataKey]; exists {
-					if formatting, ok := fieldFormatting.(map[string]interface{}); ok {
-						if fontWeight, ok := formatting["fontWeight"].(string); ok && fontWeight != "" {
-							fieldsWithFormatting[i].FontWeight = fontWeight
-						}
-						if fontStyle, ok := formatting["fontStyle"].(string); ok && fontStyle != "" {
-							fieldsWithFormatting[i].FontStyle = fontStyle
-						}
-						if textDecoration, ok := formatting["textDecoration"].(string); ok && textDecoration != "" {
-							fieldsWithFormatting[i].TextDecoration = textDecoration
-						}
-						if textColor, ok := formatting["textColor"].(string); ok && textColor != "" {
-							fieldsWithFormatting[i].TextColor = textColor
-						}
-						if fontFamily, ok := formatting["fontFamily"].(string); ok && fontFamily != "" {
-							fieldsWithFormatting[i].FontFamily = fontFamily
-						}
-					}
-				}
-			}
-		}
-		
-		// Merge HTML data into regular data for this page
-		mergedData := make(map[string]interface{})
-		for k, v := range data {
-			mergedData[k] = v
-		}
-		// Prioritize HTML data over plain text data
-		if htmlData != nil {
-			for k, v := range htmlData {
-				if v != "" {
-					mergedData[k] = v
-				}
+		if hasSVG && !tmplData.SuppressBackground {
+			svgFile := svgFilesByPage[pageIndex]
+			var err error
+			if svgDataURI, err = h.pageBackgroundURI(&tmplData, &svgFile); err != nil {
+				return "", err
 			}
 		}
 		
 		// Generate HTML for this page
-		pageHTML := h.generatePageHTML(svgDataURI, fieldsWithFormatting, mergedData)
+		pageHTML, err := h.buildPage(tmplData, fields, svgDataURI, data, formattingData, htmlData, fonts)
+		if err != nil {
+			return "", err
+		}
 		htmlPages = append(htmlPages, pageHTML)
 	}
 	
 	if len(htmlPages) == 0 {
-		return "", fmt.Errorf("no pages with SVG files or fields found")
+		return "", templateError("TEMPLATE_NO_PAGES", "Template has no pages to render",
+			"Upload an SVG background or add fields before generating a PDF.", nil)
+	}
+	
+	fullHTML := multiPageDocument(htmlPages)
+	log.Printf("Generated multi-page HTML with %d pages, total length: %d characters", len(htmlPages), len(fullHTML))
+	return fullHTML, nil
+}
+
+// pageBackgroundURI loads the background of one page as a data URI.
+func (h *PDFHandler) pageBackgroundURI(tmplData *gormmodels.Template, svgFile *gormmodels.SVGFile) (string, error) {
+	content, err := h.pageSVGContent(tmplData, svgFile)
+	if err != nil {
+		return "", storageError("STORAGE_MISSING_PAGE", fmt.Sprintf("Failed to load background for page %d", svgFile.PageIndex+1),
+			"The page's SVG file could not be read from storage; re-upload it for this page.", err)
+	}
+	svgDataURI := fmt.Sprintf("data:image/svg+xml;base64,%s", base64.StdEncoding.EncodeToString(content))
+	log.Printf("Generated data URI for page %d, length: %d", svgFile.PageIndex, len(svgDataURI))
+	return svgDataURI, nil
+}
+
+// buildPage lays out the fields of one page over its background.
+func (h *PDFHandler) buildPage(tmplData gormmodels.Template, fields []gormmodels.Field, svgDataURI string, data map[string]interface{}, formattingData map[string]interface{}, htmlData map[string]interface{}, fonts *fontCheck) (string, error) {
+	// Apply formatting overrides to fields for this page
+	fieldsWithFormatting := make([]gormmodels.Field, len(fields))
+	copy(fieldsWithFormatting, fields)
+	applyCalibrations(fieldsWithFormatting, tmplData.Calibrations)
+	applySafeMargins(fieldsWithFormatting, tmplData.PrintOptions)
+	
+	applyFormatting(fieldsWithFormatting, formattingData)
+	
+	if err := applyFontFallbacks(fieldsWithFormatting, tmplData.ScriptFonts, data, htmlData, fonts); err != nil {
+		return "", err
 	}
 	
-	// Combine all pages into single HTML document
-	fullHTML := fmt.Sprintf(`<!DOCTYPE html>
+	return h.generatePageHTML(svgDataURI, fieldsWithFormatting, data, htmlData)
+}
+
+// multiPageDocument combines page HTML into a single document.
+func multiPageDocument(htmlPages []string) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
 <html>
 <head>
     <meta charset="UTF-8">
@@ -565,30 +860,22 @@ func (h *PDFHandler) generateMultiPageHTML(tmplData gormmodels.Template, data ma
 %s
 </body>
 </html>`, strings.Join(htmlPages, "\n"))
-	
-	log.Printf("Generated multi-page HTML with %d pages, total length: %d characters", len(htmlPages), len(fullHTML))
-	return fullHTML, nil
 }
 
-func (h *PDFHandler) generatePageHTML(svgDataURI string, fields []gormmodels.Field, data map[string]interface{}) string {
-	var fieldsHTML strings.Builder
-	
-	for _, field := range fields {
-		value, exists := data[field.DataKey]
-		if !exists {
-			value = ""
-		}
-		
-		fieldsHTML.WriteString(fmt.Sprintf(`
-        <div class="field" style="
-            top: %dpx;
-            left: %dpx;
-            width: %dpx;
-            height: %dpx;
-            font-size: 12pt;
-        ">
-            <div class="field-text">%v</div>
-        </div>`, field.PositionTop, field.PositionLeft, field.PositionWidth, field.PositionHeight, value))
+// pageSVGContent reads the background of one page of a resolved template.
+func (h *PDFHandler) pageSVGContent(tmplData *gormmodels.Template, svgFile *gormmodels.SVGFile) ([]byte, error) {
+	if svgFile.TemplateID != tmplData.ID {
+		// Page comes from an included template, so its stored page index differs
+		return h.uploadHandler.uploadService.GetSVGFileContent(svgFile)
+	}
+	// Get SVG content using the page-specific identifier
+	return h.uploadHandler.uploadService.GetSVGContent(tmplData.ID, fmt.Sprintf("page_%d", svgFile.PageIndex))
+}
+
+func (h *PDFHandler) generatePageHTML(svgDataURI string, fields []gormmodels.Field, data map[string]interface{}, htmlData map[string]interface{}) (string, error) {
+	fieldsHTML, err := renderFields(fields, data, htmlData)
+	if err != nil {
+		return "", err
 	}
 	
 	backgroundStyle := ""
@@ -598,53 +885,181 @@ func (h *PDFHandler) generatePageHTML(svgDataURI string, fields []gormmodels.Fie
 	
 	return fmt.Sprintf(`    <div class="page" style="%s">
 %s
-    </div>`, backgroundStyle, fieldsHTML.String())
+    </div>`, backgroundStyle, fieldsHTML), nil
 }
 
-func (h *PDFHandler) htmlToPDF(htmlContent string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+// htmlToPDF prints HTML to a PDF. Printing stops when ctx is done, such as
+// when the request's timeout budget runs out, and after 30 seconds at most.
+func (h *PDFHandler) htmlToPDF(ctx context.Context, htmlContent string, paper paperSize, deterministic bool) ([]byte, error) {
+	if err := h.Limits.checkHTML(htmlContent); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	opts := append(chromedp.DefaultExecAllocatorOptions[:],
-		chromedp.Flag("headless", true),
-		chromedp.Flag("disable-gpu", true),
-		chromedp.Flag("no-sandbox", true),
-		chromedp.Flag("disable-dev-shm-usage", true),
-	)
+	if deterministic {
+		htmlContent = strings.Replace(htmlContent, "</head>", deterministicStyle+"</head>", 1)
+	}
+
+	pdfBytes, err := h.renderer.PrintPDF(ctx, htmlContent, renderer.PDFOptions{
+		PaperWidth:    paper.Width,
+		PaperHeight:   paper.Height,
+		Deterministic: deterministic,
+	})
+	if err != nil {
+		genErr := rendererError(err)
+		if !errors.Is(err, renderer.ErrUnavailable) {
+			genErr.Artifacts = h.captureArtifacts(htmlContent)
+		}
+		return nil, genErr
+	}
+
+	return pdfBytes, nil
+}
+
+// deterministicStyle stops animations, transitions and the text caret, so a
+// deterministic print does not depend on when Chrome paints.
+const deterministicStyle = "<style>*, *::before, *::after { animation: none !important; transition: none !important; caret-color: transparent !important; }</style>\n"
+
+// deterministic reports whether a render is deterministic: the request's
+// choice if it made one, otherwise the server default. Deterministic renders
+// of the same input are byte-identical, so their hash can be compared.
+func (h *PDFHandler) deterministic(override *bool) bool {
+	if override != nil {
+		return *override
+	}
+	return h.Deterministic
+}
+
+// normalizePDF strips the dates and random IDs of a deterministic render,
+// after optimization and metadata have added theirs, and reports the
+// resulting SHA-256 in X-PDF-SHA256.
+func (h *PDFHandler) normalizePDF(c *gin.Context, pdfBytes []byte, deterministic bool) []byte {
+	if !deterministic {
+		return pdfBytes
+	}
+
+	pdfBytes = utils.NormalizePDF(pdfBytes)
+	if c != nil {
+		sum := sha256.Sum256(pdfBytes)
+		c.Header("X-PDF-SHA256", hex.EncodeToString(sum[:]))
+	}
+	return pdfBytes
+}
+
+// optimizePDF runs the optional compression pass. The template setting is used
+// unless the request overrides it. Optimization failures fall back to the
+// unoptimized PDF rather than failing the generation.
+func (h *PDFHandler) optimizePDF(c *gin.Context, tmpl *gormmodels.Template, pdfBytes []byte, override *bool, dpi int) []byte {
+	enabled := tmpl.OptimizePDF
+	if override != nil {
+		enabled = *override
+	}
+	if !enabled || h.optimizer == nil {
+		return pdfBytes
+	}
+	if dpi <= 0 {
+		dpi = tmpl.OptimizeDPI
+	}
 
-	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	chromeCtx, cancel := chromedp.NewContext(allocCtx)
+	optimized, result, err := h.optimizer.Optimize(ctx, pdfBytes, services.OptimizeOptions{DPI: dpi})
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return pdfBytes
+	}
+
+	log.Printf("PDF optimization for template %s: %d -> %d bytes", tmpl.ID, result.OriginalSize, result.OptimizedSize)
+	if c != nil {
+		c.Header("X-PDF-Original-Size", strconv.Itoa(result.OriginalSize))
+		c.Header("X-PDF-Optimized-Size", strconv.Itoa(result.OptimizedSize))
+	}
+	return optimized
+}
+
+// resolveTemplate merges included templates into the template being rendered.
+func (h *PDFHandler) resolveTemplate(tmpl *gormmodels.Template) (*gormmodels.Template, error) {
+	resolved, err := h.templateService.ResolveIncludes(tmpl)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidInclude) {
+			return nil, templateError("TEMPLATE_INCLUDE", "Failed to resolve included templates",
+				"Check that included templates still exist and that their pages do not overlap.", err)
+		}
+		return nil, storageError("STORAGE_TEMPLATE_INCLUDE", "Failed to load included templates", "", err)
+	}
+	return resolved, nil
+}
+
+// embedMetadata writes the template's document properties and FastFill
+// identifiers into the PDF. It runs after optimization, which would otherwise
+// drop the XMP packet. Failures leave the PDF unchanged.
+func (h *PDFHandler) embedMetadata(tmpl *gormmodels.Template, submissionID string, pdfBytes []byte) []byte {
+	settings := tmpl.PDFMetadata
+	meta := utils.PDFMetadata{
+		Title:    settings.Title,
+		Author:   settings.Author,
+		Subject:  settings.Subject,
+		Keywords: settings.Keywords,
+		Creator:  "FastFill",
+		Custom:   make(map[string]string, len(settings.Custom)+3),
+	}
+	if meta.Title == "" {
+		meta.Title = tmpl.DisplayName
+	}
+	if meta.Subject == "" {
+		meta.Subject = tmpl.Description
+	}
+	if meta.Keywords == "" {
+		meta.Keywords = tmpl.Category
+	}
+	for key, value := range settings.Custom {
+		meta.Custom[key] = value
+	}
+	meta.Custom["templateId"] = tmpl.ID
+	meta.Custom["templateVersion"] = tmpl.UpdatedAt.UTC().Format(time.RFC3339)
+	if submissionID != "" {
+		meta.Custom["submissionId"] = submissionID
+	}
+
+	embedded, err := utils.EmbedPDFMetadata(pdfBytes, meta)
+	if err != nil {
+		log.Printf("Warning: failed to embed PDF metadata for template %s: %v", tmpl.ID, err)
+		return pdfBytes
+	}
+	return embedded
+}
+
+// captureArtifacts stores the HTML that failed to render, plus a best-effort
+// screenshot, so template authors can inspect the failure later.
+func (h *PDFHandler) captureArtifacts(htmlContent string) *services.RenderArtifacts {
+	if h.diagnosticsService == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	var pdfBytes []byte
+	return h.diagnosticsService.Capture(ctx, htmlContent, h.screenshotHTML(htmlContent))
+}
 
-	err := chromedp.Run(chromeCtx,
-		chromedp.Navigate("data:text/html,"+htmlContent),
-		chromedp.WaitReady("body"),
-		chromedp.ActionFunc(func(ctx context.Context) error {
-			var err error
-			pdfBytes, _, err = page.PrintToPDF().
-				WithPrintBackground(true).
-				WithPaperWidth(8.27).
-				WithPaperHeight(11.69).
-				WithMarginTop(0).
-				WithMarginBottom(0).
-				WithMarginLeft(0).
-				WithMarginRight(0).
-				Do(ctx)
-			return err
-		}),
-	)
+func (h *PDFHandler) screenshotHTML(htmlContent string) []byte {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
 
+	screenshot, err := h.renderer.Screenshot(ctx, htmlContent)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate PDF: %w", err)
+		log.Printf("Warning: failed to capture render screenshot: %v", err)
+		return nil
 	}
 
-	return pdfBytes, nil
+	return screenshot
 }
 
+// convertToDataURI reads a legacy svgBackground value as a data URI. The
+// formats it accepts are listed at UploadService.LegacyBackground.
 func (h *PDFHandler) convertToDataURI(url string) (string, error) {
 	log.Printf("Converting URL to data URI: %s", url)
 	if url == "" {
@@ -658,45 +1073,14 @@ func (h *PDFHandler) convertToDataURI(url string) (string, error) {
 		return url, nil
 	}
 
-	var templateID string
-	var svgID string
-
-	// Handle different URL formats
-	if strings.Contains(url, "/api/files/svg/") {
-		parts := strings.Split(strings.TrimPrefix(url, "/"), "/")
-		if len(parts) >= 6 && parts[0] == "api" && parts[1] == "files" && parts[2] == "svg" && parts[4] == "page" {
-			// New format: "/api/files/svg/{templateId}/page/{pageIndex}"
-			templateID = parts[3]
-			pageIndex := parts[5]
-			svgID = fmt.Sprintf("page_%s", pageIndex) // Use page index as identifier
-		} else if len(parts) >= 4 && parts[0] == "api" && parts[1] == "files" && parts[2] == "svg" {
-			// Legacy format: "/api/files/svg/{templateId}"
-			templateID = parts[3]
-			svgID = "" // Will use most recent SVG for this template
-		} else {
-			return "", fmt.Errorf("invalid SVG URL format: %s", url)
-		}
-	} else if strings.Contains(url, "templates/") {
-		// Legacy format: "templates/templateId/timestamp.svg" (may or may not have leading slash)
-		urlPath := strings.TrimPrefix(url, "/")
-		parts := strings.Split(urlPath, "/")
-		if len(parts) >= 3 && parts[0] == "templates" {
-			templateID = parts[1]
-			filename := parts[2]
-			svgID = strings.TrimSuffix(filename, ".svg")
-		} else {
-			return "", fmt.Errorf("invalid SVG URL format: %s", url)
-		}
-	} else {
-		return "", fmt.Errorf("unsupported SVG URL format: %s", url)
+	content, err := h.uploadHandler.uploadService.LegacyBackground(url)
+	if errors.Is(err, services.ErrValidation) {
+		return "", templateError("TEMPLATE_SVG_URL", "Invalid SVG background URL",
+			"Re-upload the template background, or run cmd/migrate-svg to move it into the template's page files.", err)
 	}
-
-	log.Printf("Parsed templateID: %s, svgID: %s", templateID, svgID)
-	
-	// Use the upload handler to get SVG content
-	content, err := h.uploadHandler.GetSVGContent(templateID, svgID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get SVG content: %w", err)
+		return "", storageError("STORAGE_SVG_FETCH", "Failed to load template background",
+			"Check that the template's SVG file still exists in storage.", err)
 	}
 
 	log.Printf("Retrieved SVG content length: %d bytes", len(content))
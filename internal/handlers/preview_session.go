@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PreviewSessionHandler struct {
+	sessionService   *services.PreviewSessionService
+	templateService  *services.TemplateService
+	signatureService *services.SignatureService
+	stampService     *services.StampService
+}
+
+func NewPreviewSessionHandler(sessionService *services.PreviewSessionService, templateService *services.TemplateService, signatureService *services.SignatureService, stampService *services.StampService) *PreviewSessionHandler {
+	return &PreviewSessionHandler{
+		sessionService:   sessionService,
+		templateService:  templateService,
+		signatureService: signatureService,
+		stampService:     stampService,
+	}
+}
+
+type CreatePreviewSessionRequest struct {
+	TemplateID string `json:"templateId" binding:"required"`
+	services.PreviewValues
+	// Width is the width of the page images in pixels (default 800, max 1600).
+	Width int `json:"width" binding:"min=0"`
+}
+
+// Create starts a live preview session and returns PNGs of every page with
+// the initial values. Images are base64 in JSON.
+func (h *PreviewSessionHandler) Create(c *gin.Context) {
+	var req CreatePreviewSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if checkTemplateAccess(c, h.templateService, req.TemplateID, services.AccessUse) {
+		return
+	}
+	if h.checkReferences(c, req.TemplateID, req.Data) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	session, pages, err := h.sessionService.Create(ctx, c.GetString(auth.ContextWorkspaceID), req.TemplateID, req.PreviewValues, req.Width)
+	if err != nil {
+		writePreviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"sessionId": session.ID,
+		"revision":  0,
+		"expiresAt": session.ExpiresAt(),
+		"pages":     pages,
+	})
+}
+
+// Update applies the changed keys of data, formattingData and htmlData (null
+// removes a key) and returns PNGs of the pages they appear on. Updates sent
+// in quick succession are debounced: earlier ones answer superseded and the
+// last renders every page changed since the previous render.
+func (h *PreviewSessionHandler) Update(c *gin.Context) {
+	session := h.sessionService.Get(c.Param("id"), c.GetString(auth.ContextWorkspaceID))
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview session not found or expired"})
+		return
+	}
+
+	var changes services.PreviewValues
+	if err := c.ShouldBindJSON(&changes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if h.checkReferences(c, session.TemplateID, changes.Data) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	update, err := h.sessionService.Update(ctx, session, changes)
+	if err != nil {
+		writePreviewError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, update)
+}
+
+func (h *PreviewSessionHandler) Close(c *gin.Context) {
+	session := h.sessionService.Get(c.Param("id"), c.GetString(auth.ContextWorkspaceID))
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Preview session not found or expired"})
+		return
+	}
+
+	h.sessionService.Close(session.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Preview session closed"})
+}
+
+// checkReferences rejects signatures and stamps the caller may not print, as
+// PDF generation does.
+func (h *PreviewSessionHandler) checkReferences(c *gin.Context, templateID string, data map[string]interface{}) bool {
+	if len(data) == 0 {
+		return false
+	}
+	if checkSignatures(c, h.signatureService, templateID, data, c.GetString(auth.ContextUserID)) {
+		return true
+	}
+	return checkStamps(c, h.stampService, templateID, data, c.GetString(auth.ContextWorkspaceID))
+}
+
+func writePreviewError(c *gin.Context, err error) {
+	var genErr *GenerationError
+	if errors.As(err, &genErr) {
+		writeGenerationError(c, err)
+		return
+	}
+	writeServiceError(c, "Failed to render preview", err)
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+const (
+	// Trim box of a rendered page at 96 DPI (A4).
+	pageWidthPx  = 794
+	pageHeightPx = 1123
+
+	pxPerMM = 96 / 25.4
+
+	cropMarkLengthMM = 6
+	cropMarkGapMM    = 2
+)
+
+// paperSize is the PrintToPDF sheet size in inches.
+type paperSize struct {
+	Width  float64
+	Height float64
+}
+
+var a4Paper = paperSize{Width: 8.27, Height: 11.69}
+
+// printOffsetPx is the space added around each page's trim box for bleed and crop marks.
+func printOffsetPx(opts gormmodels.PrintSettings) float64 {
+	offset := opts.BleedMM
+	if opts.CropMarks {
+		offset += cropMarkGapMM + cropMarkLengthMM
+	}
+	return offset * pxPerMM
+}
+
+// printPaper returns the sheet size for a template, enlarged by bleed and crop marks.
+func printPaper(opts gormmodels.PrintSettings) paperSize {
+	offset := printOffsetPx(opts)
+	if offset == 0 {
+		return a4Paper
+	}
+	return paperSize{
+		Width:  (pageWidthPx + 2*offset) / 96,
+		Height: (pageHeightPx + 2*offset) / 96,
+	}
+}
+
+// applySafeMargins moves and shrinks fields so they stay inside the safe area
+// and are not lost when the sheet is trimmed.
+func applySafeMargins(fields []gormmodels.Field, opts gormmodels.PrintSettings) {
+	if opts.SafeMarginMM <= 0 {
+		return
+	}
+
+	margin := int(opts.SafeMarginMM*pxPerMM + 0.5)
+	maxWidth := pageWidthPx - 2*margin
+	maxHeight := pageHeightPx - 2*margin
+
+	for i := range fields {
+		f := &fields[i]
+		if f.PositionWidth > maxWidth {
+			f.PositionWidth = maxWidth
+		}
+		if f.PositionHeight > maxHeight {
+			f.PositionHeight = maxHeight
+		}
+		if f.PositionLeft < margin {
+			f.PositionLeft = margin
+		}
+		if f.PositionTop < margin {
+			f.PositionTop = margin
+		}
+		if f.PositionLeft+f.PositionWidth > pageWidthPx-margin {
+			f.PositionLeft = pageWidthPx - margin - f.PositionWidth
+		}
+		if f.PositionTop+f.PositionHeight > pageHeightPx-margin {
+			f.PositionTop = pageHeightPx - margin - f.PositionHeight
+		}
+	}
+}
+
+// applyPrintLayout adds bleed and crop marks around every page of the
+// generated document. Pages keep their trim size so field positions are
+// unchanged; the extra space is drawn as a transparent border.
+func applyPrintLayout(htmlContent string, opts gormmodels.PrintSettings) string {
+	offset := printOffsetPx(opts)
+	if offset == 0 {
+		return htmlContent
+	}
+
+	paper := printPaper(opts)
+
+	var css strings.Builder
+	css.WriteString("<style>\n")
+	fmt.Fprintf(&css, "        @page { margin: 0; size: %.3fin %.3fin; }\n", paper.Width, paper.Height)
+	fmt.Fprintf(&css, "        .page, .document-container { border: %.2fpx solid transparent; box-sizing: content-box; }\n", offset)
+
+	if opts.CropMarks {
+		bleed := opts.BleedMM * pxPerMM
+		length := cropMarkLengthMM * pxPerMM
+		far := offset + bleed + cropMarkGapMM*pxPerMM
+
+		type mark struct{ x, y, w, h float64 }
+		marks := []mark{
+			// Horizontal marks level with the top and bottom trim edges.
+			{0, offset, length, 1},
+			{far + pageWidthPx, offset, length, 1},
+			{0, offset + pageHeightPx, length, 1},
+			{far + pageWidthPx, offset + pageHeightPx, length, 1},
+			// Vertical marks level with the left and right trim edges.
+			{offset, 0, 1, length},
+			{offset + pageWidthPx, 0, 1, length},
+			{offset, far + pageHeightPx, 1, length},
+			{offset + pageWidthPx, far + pageHeightPx, 1, length},
+		}
+
+		images := make([]string, len(marks))
+		sizes := make([]string, len(marks))
+		positions := make([]string, len(marks))
+		for i, m := range marks {
+			images[i] = "linear-gradient(#000, #000)"
+			sizes[i] = fmt.Sprintf("%.2fpx %.2fpx", m.w, m.h)
+			positions[i] = fmt.Sprintf("%.2fpx %.2fpx", m.x, m.y)
+		}
+
+		fmt.Fprintf(&css, "        .page::after, .document-container::after { content: ''; position: absolute; top: %.2fpx; left: %.2fpx; width: %.2fpx; height: %.2fpx; pointer-events: none; background-repeat: no-repeat; background-image: %s; background-size: %s; background-position: %s; }\n",
+			-offset, -offset, pageWidthPx+2*offset, pageHeightPx+2*offset,
+			strings.Join(images, ", "), strings.Join(sizes, ", "), strings.Join(positions, ", "))
+	}
+	css.WriteString("    </style>\n")
+
+	return strings.Replace(htmlContent, "</head>", css.String()+"</head>", 1)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Submission channels recorded in SubmissionMetadata.
+const (
+	ChannelAPI         = "api"
+	ChannelIntegration = "integration"
+	ChannelFillLink    = "fill_link"
+	ChannelImport      = "import"
+	ChannelRestore     = "restore"
+)
+
+const maxUserAgentLength = 512
+
+// Headers carrying coarse client geolocation, in order of preference.
+var (
+	countryHeaders = []string{"CF-IPCountry", "X-Appengine-Country", "CloudFront-Viewer-Country", "X-Client-Geo-Country"}
+	regionHeaders  = []string{"X-Appengine-Region", "CloudFront-Viewer-Country-Region", "X-Client-Geo-Region"}
+)
+
+// submissionMetadata captures the provenance of a submission from the request:
+// the authenticated identity, share-link token, user agent, IP and coarse location.
+func submissionMetadata(c *gin.Context, channel string) *gormmodels.SubmissionMetadata {
+	userAgent := c.Request.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+
+	return &gormmodels.SubmissionMetadata{
+		Channel:     channel,
+		UserID:      c.GetString(auth.ContextUserID),
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		TokenID:     c.GetString(auth.ContextTokenID),
+		ShareToken:  c.GetHeader("X-Share-Token"),
+		UserAgent:   userAgent,
+		IPAddress:   c.ClientIP(),
+		Country:     firstHeader(c, countryHeaders),
+		Region:      firstHeader(c, regionHeaders),
+	}
+}
+
+func firstHeader(c *gin.Context, names []string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(c.GetHeader(name)); value != "" && value != "XX" {
+			return value
+		}
+	}
+	return ""
+}
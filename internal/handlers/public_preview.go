@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// publicThumbnailWidth is the pixel width of page thumbnails on public previews.
+const publicThumbnailWidth = 600
+
+// PublicPreviewHandler serves unauthenticated template previews for sharing a
+// template with someone before they fill it. Only templates opted in with
+// PublicPreview are served, and only their display name, description, page
+// backgrounds and field outlines are exposed.
+type PublicPreviewHandler struct {
+	templateService *services.TemplateService
+	previewService  *services.PreviewService
+	domainService   *services.DomainService
+}
+
+func NewPublicPreviewHandler(templateService *services.TemplateService, previewService *services.PreviewService, domainService *services.DomainService) *PublicPreviewHandler {
+	return &PublicPreviewHandler{
+		templateService: templateService,
+		previewService:  previewService,
+		domainService:   domainService,
+	}
+}
+
+type previewPage struct {
+	Number   int
+	ImageURL string
+	Fields   []previewField
+}
+
+// previewField is a field outline positioned in percent of the page box.
+type previewField struct {
+	Label    string
+	Required bool
+	Left     string
+	Top      string
+	Width    string
+	Height   string
+}
+
+var publicPreviewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <meta name="robots" content="noindex">
+    <title>{{.Title}}</title>
+    <style>
+        body { margin: 0; padding: 24px; font-family: system-ui, sans-serif; background: #f3f4f6; color: #111827; }
+        header { max-width: 960px; margin: 0 auto 24px; }
+        h1 { margin: 0 0 8px; font-size: 24px; }
+        .description { margin: 0; color: #4b5563; white-space: pre-line; }
+        .meta { margin-top: 8px; font-size: 14px; color: #6b7280; }
+        .pages { display: flex; flex-wrap: wrap; gap: 24px; justify-content: center; }
+        figure { margin: 0; width: 300px; }
+        .page { position: relative; width: 300px; aspect-ratio: 794 / 1123; background: #fff; box-shadow: 0 1px 4px rgba(0, 0, 0, 0.15); overflow: hidden; }
+        .page img { position: absolute; inset: 0; width: 100%; height: 100%; }
+        .field { position: absolute; box-sizing: border-box; border: 1px solid #2563eb; background: rgba(37, 99, 235, 0.12); }
+        .field.required { border-color: #dc2626; background: rgba(220, 38, 38, 0.1); }
+        figcaption { margin-top: 8px; text-align: center; font-size: 13px; color: #6b7280; }
+    </style>
+</head>
+<body>
+    <header>
+        <h1>{{.Title}}</h1>
+        {{if .Description}}<p class="description">{{.Description}}</p>{{end}}
+        <div class="meta">{{len .Pages}} page(s) · {{.FieldCount}} field(s)</div>
+    </header>
+    <main class="pages">
+        {{range .Pages}}<figure>
+            <div class="page">
+                {{if .ImageURL}}<img src="{{.ImageURL}}" alt="Page {{.Number}}" loading="lazy">{{end}}
+                {{range .Fields}}<div class="field{{if .Required}} required{{end}}" style="left: {{.Left}}; top: {{.Top}}; width: {{.Width}}; height: {{.Height}};" title="{{.Label}}"></div>
+                {{end}}
+            </div>
+            <figcaption>Page {{.Number}}</figcaption>
+        </figure>
+        {{end}}
+    </main>
+</body>
+</html>`))
+
+// Preview renders the HTML preview page of a template.
+func (h *PublicPreviewHandler) Preview(c *gin.Context) {
+	tmpl, err := h.publicTemplate(c, c.Param("id"))
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to load template")
+		return
+	}
+	if tmpl == nil {
+		c.String(http.StatusNotFound, "Template not found")
+		return
+	}
+
+	var buf bytes.Buffer
+	err = publicPreviewTemplate.Execute(&buf, gin.H{
+		"Title":       tmpl.DisplayName,
+		"Description": tmpl.Description,
+		"FieldCount":  len(tmpl.Fields),
+		"Pages":       previewPages(tmpl),
+	})
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render preview")
+		return
+	}
+
+	c.Header("Content-Security-Policy", "default-src 'none'; img-src 'self'; style-src 'unsafe-inline'")
+	c.Header("X-Robots-Tag", "noindex")
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// PageImage serves a page thumbnail for the preview, as "<pageIndex>.png".
+func (h *PublicPreviewHandler) PageImage(c *gin.Context) {
+	pageIndex, err := strconv.Atoi(strings.TrimSuffix(c.Param("page"), ".png"))
+	if err != nil || pageIndex < 0 {
+		c.String(http.StatusBadRequest, "Invalid page index")
+		return
+	}
+
+	tmpl, err := h.publicTemplate(c, c.Param("id"))
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render page preview")
+		return
+	}
+	if tmpl == nil {
+		c.String(http.StatusNotFound, "Page not found")
+		return
+	}
+
+	preview, err := h.previewService.PagePNG(c.Request.Context(), tmpl.ID, pageIndex, publicThumbnailWidth, c.GetHeader("If-None-Match"))
+	if err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render page preview")
+		return
+	}
+	if preview == nil {
+		c.String(http.StatusNotFound, "Page not found")
+		return
+	}
+
+	c.Header("ETag", preview.ETag)
+	c.Header("Cache-Control", "public, max-age=86400")
+	if preview.Data == nil {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", preview.Data)
+}
+
+// publicTemplate loads the template if it may be previewed on the request
+// host, and returns nil otherwise: when it does not exist, is not opted into
+// public previews or is archived.
+func (h *PublicPreviewHandler) publicTemplate(c *gin.Context, templateID string) (*gormmodels.Template, error) {
+	tmpl, err := h.templateService.GetByID(templateID)
+	if err != nil || tmpl == nil {
+		return nil, err
+	}
+	if !tmpl.PublicPreview || tmpl.ArchivedAt != nil {
+		return nil, nil
+	}
+
+	served, err := h.servedOnHost(c, tmpl.ID)
+	if err != nil || !served {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// servedOnHost reports whether the template may be previewed on the request
+// host. A workspace's custom domain serves only that workspace's templates;
+// any other host serves every public template.
+func (h *PublicPreviewHandler) servedOnHost(c *gin.Context, templateID string) (bool, error) {
+	workspaceID, err := h.domainService.WorkspaceForHost(c.Request.Host)
+	if err != nil || workspaceID == "" {
+		return err == nil, err
+	}
+
+	granted, err := h.templateService.Access(templateID, workspaceID)
+	if err == services.ErrTemplateNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return granted == services.AccessOwner, nil
+}
+
+// previewPages lists every page with a background or fields, in page order.
+func previewPages(tmpl *gormmodels.Template) []previewPage {
+	pages := make(map[int]*previewPage)
+	page := func(index int) *previewPage {
+		if p, ok := pages[index]; ok {
+			return p
+		}
+		p := &previewPage{Number: index + 1}
+		pages[index] = p
+		return p
+	}
+
+	for _, svgFile := range tmpl.SVGFiles {
+		page(svgFile.PageIndex).ImageURL = fmt.Sprintf("/public/templates/%s/pages/%d.png", tmpl.ID, svgFile.PageIndex)
+	}
+	for _, field := range tmpl.Fields {
+		p := page(field.PageIndex)
+		p.Fields = append(p.Fields, previewField{
+			Label:    field.Name,
+			Required: field.Required,
+			Left:     percentOf(field.PositionLeft, pageWidthPx),
+			Top:      percentOf(field.PositionTop, pageHeightPx),
+			Width:    percentOf(field.PositionWidth, pageWidthPx),
+			Height:   percentOf(field.PositionHeight, pageHeightPx),
+		})
+	}
+
+	indexes := make([]int, 0, len(pages))
+	for index := range pages {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	result := make([]previewPage, len(indexes))
+	for i, index := range indexes {
+		result[i] = *pages[index]
+	}
+	return result
+}
+
+func percentOf(value, total int) string {
+	return strconv.FormatFloat(float64(value)*100/float64(total), 'f', 2, 64) + "%"
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RegenerationHandler struct {
+	regenerationService *services.RegenerationService
+	documentService     *services.DocumentService
+	formService         *services.FormService
+	templateService     *services.TemplateService
+}
+
+func NewRegenerationHandler(regenerationService *services.RegenerationService, documentService *services.DocumentService, formService *services.FormService, templateService *services.TemplateService) *RegenerationHandler {
+	return &RegenerationHandler{
+		regenerationService: regenerationService,
+		documentService:     documentService,
+		formService:         formService,
+		templateService:     templateService,
+	}
+}
+
+type RegenerateDocumentsRequest struct {
+	SubmissionIDs []string `json:"submissionIds"`
+}
+
+// Regenerate queues re-rendering of stored documents against the latest template.
+func (h *RegenerationHandler) Regenerate(c *gin.Context) {
+	templateID := c.Param("id")
+
+	var req RegenerateDocumentsRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+			return
+		}
+	}
+
+	template, err := h.templateService.GetByID(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	job, err := h.regenerationService.Enqueue(templateID, req.SubmissionIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to queue regeneration", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *RegenerationHandler) GetJob(c *gin.Context) {
+	job, err := h.regenerationService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch regeneration job", err)
+		return
+	}
+
+	if job == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Regeneration job not found"})
+		return
+	}
+
+	if checkTemplateAccess(c, h.templateService, job.TemplateID, services.AccessOwner) {
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// GetDocument redirects to the latest stored PDF for a submission.
+func (h *RegenerationHandler) GetDocument(c *gin.Context) {
+	submission, refused := fetchSubmission(c, h.formService, h.templateService, services.AccessUse)
+	if refused {
+		return
+	}
+
+	document, err := h.documentService.GetLatest(submission.ID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch document", err)
+		return
+	}
+
+	if document == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No stored document for this submission"})
+		return
+	}
+
+	signedURL, err := h.documentService.GetSignedURL(document)
+	if err != nil {
+		writeServiceError(c, "Failed to get file", err)
+		return
+	}
+
+	c.Redirect(http.StatusTemporaryRedirect, signedURL)
+}
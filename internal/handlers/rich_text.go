@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+)
+
+// normalizeHTMLData returns a copy of htmlData with every value reduced to the
+// supported rich text subset. Non-string values are rejected.
+func normalizeHTMLData(htmlData map[string]interface{}) (map[string]interface{}, error) {
+	if htmlData == nil {
+		return nil, nil
+	}
+
+	normalized := make(map[string]interface{}, len(htmlData))
+	for key, value := range htmlData {
+		if value == nil {
+			continue
+		}
+		str, ok := value.(string)
+		if !ok {
+			return nil, dataError("DATA_INVALID_HTML", "Invalid htmlData value",
+				"Every htmlData entry must be a string of HTML markup keyed by the field's dataKey.",
+				fmt.Errorf("htmlData[%q] is %T", key, value))
+		}
+		normalized[key] = utils.SanitizeRichText(str)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// fieldTextStyle returns the inline style for a field's text element. Rotated
+// text is sized to the box's rotated extent and anchored at the box centre, so
+// after the transform it covers the box exactly instead of spilling out of it.
+func fieldTextStyle(field gormmodels.Field) template.CSS {
+	switch field.Rotation {
+	case 90, 270:
+		return template.CSS(fmt.Sprintf(
+			"position: absolute; left: 50%%; top: 50%%; width: %dpx; height: %dpx; transform: translate(-50%%, -50%%) rotate(%ddeg); transform-origin: center;",
+			field.PositionHeight, field.PositionWidth, field.Rotation))
+	case 180:
+		return template.CSS(fmt.Sprintf(
+			"position: absolute; left: 50%%; top: 50%%; width: %dpx; height: %dpx; transform: translate(-50%%, -50%%) rotate(180deg); transform-origin: center;",
+			field.PositionWidth, field.PositionHeight))
+	}
+
+	if field.Vertical {
+		return "writing-mode: vertical-rl; text-orientation: upright; width: auto; height: 100%;"
+	}
+	return ""
+}
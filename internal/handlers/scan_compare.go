@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxScanUploadSize bounds the scans accepted for comparison.
+const maxScanUploadSize = 20 << 20
+
+// CompareScan overlays the field boxes of a template page on a scanned,
+// filled-in example of the paper form, sent as the multipart "scan", and
+// measures how the ink of each field lines up with its box. A blank
+// rendering of the page is subtracted so printed form content is not
+// counted. ?format=png returns only the overlay image.
+func (h *PDFHandler) CompareScan(c *gin.Context) {
+	pageIndex, err := strconv.Atoi(c.Param("pageIndex"))
+	if err != nil || pageIndex < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxScanUploadSize+64<<10)
+	file, _, err := c.Request.FormFile("scan")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No scan uploaded", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, maxScanUploadSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read scan", "details": err.Error()})
+		return
+	}
+	if len(content) > maxScanUploadSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Scan is too large", "details": fmt.Sprintf("the limit is %d MB", maxScanUploadSize>>20)})
+		return
+	}
+
+	scan, err := services.DecodeScan(content)
+	if err != nil {
+		writeServiceError(c, "Invalid scan", err)
+		return
+	}
+
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	resolved, err := h.resolveTemplate(template)
+	if err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+
+	if pageIndex >= renderedPageCount(resolved) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Page not found"})
+		return
+	}
+
+	// Boxes are where fields print, after calibration, as on the calibration sheet.
+	var boxes []services.ScanBox
+	for _, outline := range h.sheetOutlines(template, pageIndex) {
+		boxes = append(boxes, services.ScanBox{
+			Label:  outline.label,
+			Top:    outline.field.PositionTop,
+			Left:   outline.field.PositionLeft,
+			Width:  outline.field.PositionWidth,
+			Height: outline.field.PositionHeight,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	// Without a blank page every dark pixel counts as ink; the response says so.
+	var blank image.Image
+	if png, err := h.RenderPreviewPage(ctx, resolved, pageIndex, services.PreviewValues{}, map[int]string{}, pageWidthPx); err != nil {
+		log.Printf("Warning: failed to render blank page %d of template %s for scan comparison: %v", pageIndex, template.ID, err)
+	} else if blank, err = services.DecodeScan(png); err != nil {
+		log.Printf("Warning: failed to decode blank page %d of template %s: %v", pageIndex, template.ID, err)
+		blank = nil
+	}
+
+	comparison, err := services.CompareScan(scan, blank, boxes, pageWidthPx, pageHeightPx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compare scan"})
+		return
+	}
+
+	if c.Query("format") == "png" {
+		c.Data(http.StatusOK, "image/png", comparison.Overlay)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"templateId": template.ID,
+		"pageIndex":  pageIndex,
+		"comparison": comparison,
+	})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SecurityReviewHandler struct {
+	reviewService   *services.SecurityReviewService
+	templateService *services.TemplateService
+}
+
+func NewSecurityReviewHandler(reviewService *services.SecurityReviewService, templateService *services.TemplateService) *SecurityReviewHandler {
+	return &SecurityReviewHandler{
+		reviewService:   reviewService,
+		templateService: templateService,
+	}
+}
+
+// Review scans a template's page SVGs, custom CSS and submitted htmlData for
+// scripts and external resources. The report's passed flag is the gate an
+// approval step checks before a template is published.
+func (h *SecurityReviewHandler) Review(c *gin.Context) {
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	report, err := h.reviewService.Review(template)
+	if err != nil {
+		writeServiceError(c, "Failed to review template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/middleware"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShareHandler struct {
+	shareService *services.ShareService
+	auditService *services.AuditService
+}
+
+func NewShareHandler(shareService *services.ShareService, auditService *services.AuditService) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+		auditService: auditService,
+	}
+}
+
+type InviteShareRequest struct {
+	WorkspaceID string `json:"workspaceId"`
+	Email       string `json:"email"`
+	Access      string `json:"access" binding:"required,oneof=read copy"`
+}
+
+func (h *ShareHandler) GetByTemplateID(c *gin.Context) {
+	shares, err := h.shareService.GetByTemplateID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template shares", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+// Invite shares the template with another workspace, by workspace ID or by
+// the email address of a user who accepts it for their workspace.
+func (h *ShareHandler) Invite(c *gin.Context) {
+	var req InviteShareRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	share := &gormmodels.TemplateShare{
+		TemplateID:       c.Param("id"),
+		OwnerWorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		WorkspaceID:      req.WorkspaceID,
+		InviteEmail:      req.Email,
+		Access:           req.Access,
+		InvitedBy:        c.GetString(auth.ContextUserID),
+	}
+
+	if err := h.shareService.Invite(share); err != nil {
+		writeServiceError(c, "Failed to share template", err)
+		return
+	}
+
+	h.audit(c, "template.share", share.ID)
+
+	c.JSON(http.StatusCreated, share)
+}
+
+// Revoke withdraws a share; the other workspace loses access immediately.
+// Copies it already took are its own and remain.
+func (h *ShareHandler) Revoke(c *gin.Context) {
+	share, err := h.shareService.GetForOwner(c.GetString(auth.ContextWorkspaceID), c.Param("id"), c.Param("shareId"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template share", err)
+		return
+	}
+
+	if share == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template share not found"})
+		return
+	}
+
+	if err := h.shareService.Revoke(share); err != nil {
+		writeServiceError(c, "Failed to revoke template share", err)
+		return
+	}
+
+	h.audit(c, "template.share.revoke", share.ID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template share revoked successfully"})
+}
+
+// Incoming lists the shares offered to the caller's workspace, including
+// pending email invites addressed to the caller.
+func (h *ShareHandler) Incoming(c *gin.Context) {
+	shares, err := h.shareService.Incoming(c.GetString(auth.ContextWorkspaceID), c.GetString(auth.ContextUserID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template shares", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shares": shares})
+}
+
+func (h *ShareHandler) Accept(c *gin.Context) {
+	share, err := h.shareService.Accept(c.Param("id"), c.GetString(auth.ContextWorkspaceID), c.GetString(auth.ContextUserID))
+	if err != nil {
+		writeServiceError(c, "Failed to accept template share", err)
+		return
+	}
+
+	if share == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template share not found"})
+		return
+	}
+
+	h.audit(c, "template.share.accept", share.ID)
+
+	c.JSON(http.StatusOK, share)
+}
+
+// Copy takes a copy of the template into the caller's workspace. The copy can
+// be edited freely and does not follow later changes to the original.
+func (h *ShareHandler) Copy(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	template, err := h.shareService.Copy(ctx, c.Param("id"), c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "Failed to copy template", err)
+		return
+	}
+
+	h.audit(c, "template.copy", c.Param("id")+" -> "+template.ID)
+
+	c.JSON(http.StatusCreated, template)
+}
+
+func (h *ShareHandler) audit(c *gin.Context, action, resource string) {
+	h.auditService.Record(&gormmodels.AuditLog{
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		UserID:      c.GetString(auth.ContextUserID),
+		TokenID:     c.GetString(auth.ContextTokenID),
+		Action:      action,
+		Resource:    resource,
+		IPAddress:   c.ClientIP(),
+	})
+}
+
+// checkTemplateAccess responds when the caller's workspace may not act on
+// the template with at least access, and returns true.
+func checkTemplateAccess(c *gin.Context, templateService *services.TemplateService, templateID string, access services.TemplateAccess) bool {
+	granted, err := templateService.Access(templateID, c.GetString(auth.ContextWorkspaceID))
+	if err == services.ErrTemplateNotFound {
+		return false
+	}
+	if err != nil {
+		writeServiceError(c, "Failed to check template access", err)
+		return true
+	}
+	if granted < access {
+		middleware.AbortTemplateAccess(c, granted)
+		return true
+	}
+	return false
+}
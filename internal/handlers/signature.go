@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSignatureConsent is recorded when a client does not send the
+// consent text it showed to the user.
+const defaultSignatureConsent = "I agree that this signature may be placed on documents I fill in."
+
+type SignatureHandler struct {
+	signatureService *services.SignatureService
+	auditService     *services.AuditService
+}
+
+func NewSignatureHandler(signatureService *services.SignatureService, auditService *services.AuditService) *SignatureHandler {
+	return &SignatureHandler{
+		signatureService: signatureService,
+		auditService:     auditService,
+	}
+}
+
+func (h *SignatureHandler) List(c *gin.Context) {
+	signatures, err := h.signatureService.GetByUserID(c.GetString(auth.ContextUserID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch signatures", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"signatures": signatures})
+}
+
+// Create stores a signature drawn by the user. The multipart form carries the
+// image as "file" and must set "consent" to true.
+func (h *SignatureHandler) Create(c *gin.Context) {
+	if consent, _ := strconv.ParseBool(c.PostForm("consent")); !consent {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Consent is required to store a signature"})
+		return
+	}
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, services.MaxSignatureSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file", "details": err.Error()})
+		return
+	}
+
+	consentText := c.PostForm("consentText")
+	if consentText == "" {
+		consentText = defaultSignatureConsent
+	}
+
+	signature := &gormmodels.Signature{
+		UserID:      c.GetString(auth.ContextUserID),
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		Name:        c.PostForm("name"),
+		ConsentText: consentText,
+		ConsentedAt: time.Now(),
+		ConsentIP:   c.ClientIP(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := h.signatureService.Create(ctx, signature, content); err != nil {
+		writeServiceError(c, "Failed to store signature", err)
+		return
+	}
+
+	h.audit(c, "signature.create", signature.ID)
+
+	c.JSON(http.StatusCreated, signature)
+}
+
+// Image serves the signature image. SVGs are served with a policy that keeps
+// them from running script when opened directly.
+func (h *SignatureHandler) Image(c *gin.Context) {
+	signature, ok := h.loadSignature(c)
+	if !ok {
+		return
+	}
+
+	if !signature.Valid() {
+		c.JSON(http.StatusGone, gin.H{"error": "Signature has been invalidated"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	content, err := h.signatureService.Content(ctx, signature)
+	if err != nil {
+		writeServiceError(c, "Failed to read signature", err)
+		return
+	}
+
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, signature.MimeType, content)
+}
+
+// Invalidate withdraws the signature. Submissions that reference it keep the
+// ID but no longer render the image.
+func (h *SignatureHandler) Invalidate(c *gin.Context) {
+	signature, ok := h.loadSignature(c)
+	if !ok {
+		return
+	}
+
+	if signature.Valid() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := h.signatureService.Invalidate(ctx, signature); err != nil {
+			writeServiceError(c, "Failed to invalidate signature", err)
+			return
+		}
+		h.audit(c, "signature.invalidate", signature.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Signature invalidated successfully"})
+}
+
+func (h *SignatureHandler) loadSignature(c *gin.Context) (*gormmodels.Signature, bool) {
+	signature, err := h.signatureService.GetForUser(c.GetString(auth.ContextUserID), c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch signature", err)
+		return nil, false
+	}
+
+	if signature == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Signature not found"})
+		return nil, false
+	}
+
+	return signature, true
+}
+
+func (h *SignatureHandler) audit(c *gin.Context, action, resource string) {
+	h.auditService.Record(&gormmodels.AuditLog{
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		UserID:      c.GetString(auth.ContextUserID),
+		TokenID:     c.GetString(auth.ContextTokenID),
+		Action:      action,
+		Resource:    resource,
+		IPAddress:   c.ClientIP(),
+	})
+}
+
+// checkSignatures responds with 422 when signature fields refer to signatures
+// the submitting user cannot use.
+func checkSignatures(c *gin.Context, signatureService *services.SignatureService, templateID string, data map[string]interface{}, userID string) bool {
+	violations, err := signatureService.CheckReferences(templateID, data, userID)
+	if err != nil {
+		writeServiceError(c, "Failed to validate signatures", err)
+		return true
+	}
+	if len(violations) == 0 {
+		return false
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":      "Signature fields refer to unusable signatures",
+		"violations": violations,
+	})
+	return true
+}
+
+// embeddedImageHTML embeds an image scaled to fit its field. Opacity 0
+// leaves the image opaque.
+func embeddedImageHTML(class, mimeType string, content []byte, opacity float64) string {
+	style := "max-width:100%;max-height:100%;object-fit:contain"
+	if opacity > 0 && opacity < 1 {
+		style += fmt.Sprintf(";opacity:%g", opacity)
+	}
+	return fmt.Sprintf(`<img class="%s" src="data:%s;base64,%s" alt="" style="%s">`,
+		class, html.EscapeString(mimeType), base64.StdEncoding.EncodeToString(content), style)
+}
+
+// embedSignatures returns htmlData with the image of every signature field
+// whose value refers to a valid signature. Fields referring to missing or
+// invalidated signatures render empty rather than showing the ID.
+func (h *PDFHandler) embedSignatures(fields []gormmodels.Field, data map[string]interface{}, htmlData map[string]interface{}) (map[string]interface{}, error) {
+	var signed []gormmodels.Field
+	for _, field := range fields {
+		if field.Type == services.FieldTypeSignature && dataValue(data, field.DataKey) != nil {
+			signed = append(signed, field)
+		}
+	}
+	if len(signed) == 0 {
+		return htmlData, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	images, err := h.signatureService.Images(ctx, signed, data)
+	if err != nil {
+		return nil, err
+	}
+
+	embedded := make(map[string]interface{}, len(htmlData)+len(signed))
+	for key, value := range htmlData {
+		embedded[key] = value
+	}
+	for _, field := range signed {
+		if image, ok := images[field.DataKey]; ok {
+			embedded[field.DataKey] = embeddedImageHTML("signature", image.MimeType, image.Content, 0)
+		} else {
+			embedded[field.DataKey] = `<span class="signature"></span>`
+		}
+	}
+	return embedded, nil
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SnapshotHandler struct {
+	snapshotService *services.SnapshotService
+}
+
+func NewSnapshotHandler(snapshotService *services.SnapshotService) *SnapshotHandler {
+	return &SnapshotHandler{
+		snapshotService: snapshotService,
+	}
+}
+
+type RecordSnapshotRequest struct {
+	SampleData     map[string]interface{} `json:"sampleData" binding:"required"`
+	FormattingData map[string]interface{} `json:"formattingData,omitempty"`
+	HtmlData       map[string]interface{} `json:"htmlData,omitempty"`
+}
+
+func (h *SnapshotHandler) GetByTemplateID(c *gin.Context) {
+	snapshots, err := h.snapshotService.GetByTemplateID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch snapshots", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"snapshots": snapshots})
+}
+
+// Record renders the sample data and stores it as the snapshot of the
+// template's current version.
+func (h *SnapshotHandler) Record(c *gin.Context) {
+	var req RecordSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	snapshot := &gormmodels.TemplateSnapshot{
+		SampleData:     req.SampleData,
+		FormattingData: req.FormattingData,
+		HtmlData:       req.HtmlData,
+		CreatedBy:      c.GetString(auth.ContextUserID),
+	}
+
+	if err := h.snapshotService.Record(c.Request.Context(), c.Param("id"), snapshot); err != nil {
+		var genErr *GenerationError
+		if errors.As(err, &genErr) {
+			writeGenerationError(c, err)
+			return
+		}
+		writeServiceError(c, "Failed to record snapshot", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, snapshot)
+}
+
+// Verify re-renders the template's snapshot and reports changed pages.
+func (h *SnapshotHandler) Verify(c *gin.Context) {
+	result, err := h.snapshotService.Verify(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to verify snapshot", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// VerifyAll verifies every template with a snapshot of its current version.
+func (h *SnapshotHandler) VerifyAll(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Minute)
+	defer cancel()
+
+	results, err := h.snapshotService.VerifyAll(ctx)
+	if err != nil {
+		writeServiceError(c, "Failed to verify snapshots", err)
+		return
+	}
+
+	changed := 0
+	for _, result := range results {
+		if result.Result != gormmodels.SnapshotMatch {
+			changed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"verified": len(results), "changed": changed, "results": results})
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/config"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ssoStateCookie    = "fastfill_sso_state"
+	ssoReturnCookie   = "fastfill_sso_return"
+	ssoCookieLifetime = 10 * 60
+)
+
+type SSOHandler struct {
+	ssoService     *services.SSOService
+	sessionService *auth.SessionService
+	auditService   *services.AuditService
+	throttle       *auth.LoginThrottle
+	config         *config.Config
+}
+
+func NewSSOHandler(ssoService *services.SSOService, sessionService *auth.SessionService, auditService *services.AuditService, throttle *auth.LoginThrottle, cfg *config.Config) *SSOHandler {
+	return &SSOHandler{
+		ssoService:     ssoService,
+		sessionService: sessionService,
+		auditService:   auditService,
+		throttle:       throttle,
+		config:         cfg,
+	}
+}
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name" binding:"required"`
+	Slug string `json:"slug" binding:"required"`
+	// SSO configures single sign-on with the workspace, so its first admins
+	// can sign in; later changes are made by them.
+	SSO *SSOConfigRequest `json:"sso"`
+}
+
+type SSOConfigRequest struct {
+	Protocol     string            `json:"protocol"`
+	Issuer       string            `json:"issuer" binding:"required,url"`
+	ClientID     string            `json:"clientId" binding:"required"`
+	ClientSecret string            `json:"clientSecret"`
+	Scopes       string            `json:"scopes"`
+	RoleClaim    string            `json:"roleClaim"`
+	RoleMapping  map[string]string `json:"roleMapping"`
+	DefaultRole  string            `json:"defaultRole"`
+	Enabled      *bool             `json:"enabled"`
+}
+
+func (h *SSOHandler) CreateWorkspace(c *gin.Context) {
+	var req CreateWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	var ssoConfig *gormmodels.SSOConfig
+	if req.SSO != nil {
+		var ok bool
+		if ssoConfig, ok = newSSOConfig(c, *req.SSO); !ok {
+			return
+		}
+	}
+
+	workspace := &gormmodels.Workspace{
+		Name: req.Name,
+		Slug: strings.ToLower(req.Slug),
+	}
+
+	if err := h.ssoService.CreateWorkspace(workspace, ssoConfig); err != nil {
+		writeServiceError(c, "Failed to create workspace", err)
+		return
+	}
+
+	if ssoConfig == nil {
+		c.JSON(http.StatusCreated, workspace)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"workspace": workspace, "sso": ssoConfig})
+}
+
+func (h *SSOHandler) GetConfig(c *gin.Context) {
+	workspace, ok := h.loadWorkspace(c)
+	if !ok {
+		return
+	}
+
+	ssoConfig, err := h.ssoService.GetConfig(workspace.ID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch SSO config", err)
+		return
+	}
+
+	if ssoConfig == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SSO is not configured for this workspace"})
+		return
+	}
+
+	c.JSON(http.StatusOK, ssoConfig)
+}
+
+func (h *SSOHandler) SaveConfig(c *gin.Context) {
+	workspace, ok := h.loadWorkspace(c)
+	if !ok {
+		return
+	}
+
+	var req SSOConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	ssoConfig, ok := newSSOConfig(c, req)
+	if !ok {
+		return
+	}
+	ssoConfig.WorkspaceID = workspace.ID
+
+	if err := h.ssoService.SaveConfig(ssoConfig); err != nil {
+		writeServiceError(c, "Failed to save SSO config", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ssoConfig)
+}
+
+// newSSOConfig builds an SSO configuration from a request, filling in the
+// defaults, and writes the error response when it is invalid.
+func newSSOConfig(c *gin.Context, req SSOConfigRequest) (*gormmodels.SSOConfig, bool) {
+	if req.Protocol == "" {
+		req.Protocol = "oidc"
+	}
+	if req.Protocol != "oidc" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only the oidc protocol is currently supported"})
+		return nil, false
+	}
+	if req.Scopes == "" {
+		req.Scopes = "openid email profile"
+	}
+	if req.DefaultRole == "" {
+		req.DefaultRole = gormmodels.RoleMember
+	}
+
+	ssoConfig := &gormmodels.SSOConfig{
+		Protocol:     req.Protocol,
+		Issuer:       req.Issuer,
+		ClientID:     req.ClientID,
+		ClientSecret: req.ClientSecret,
+		Scopes:       req.Scopes,
+		RoleClaim:    req.RoleClaim,
+		RoleMapping:  req.RoleMapping,
+		DefaultRole:  req.DefaultRole,
+		Enabled:      req.Enabled == nil || *req.Enabled,
+	}
+
+	return ssoConfig, true
+}
+
+// Login redirects the browser to the workspace's identity provider.
+func (h *SSOHandler) Login(c *gin.Context) {
+	login, ok := h.resolveLogin(c)
+	if !ok {
+		return
+	}
+
+	returnTo := c.Query("returnTo")
+	if returnTo != "" && !h.isAllowedReturn(returnTo) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "returnTo must point at an allowed frontend origin"})
+		return
+	}
+
+	stateBytes := make([]byte, 16)
+	if _, err := rand.Read(stateBytes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start login"})
+		return
+	}
+	state := hex.EncodeToString(stateBytes)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+	defer cancel()
+
+	authURL, err := h.ssoService.AuthCodeURL(ctx, login, h.callbackURL(c), state)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to contact identity provider", "details": err.Error()})
+		return
+	}
+
+	secure := c.Request.TLS != nil || h.config.Server.Environment == "production"
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoStateCookie, state, ssoCookieLifetime, "/api/auth/sso", "", secure, true)
+	c.SetCookie(ssoReturnCookie, returnTo, ssoCookieLifetime, "/api/auth/sso", "", secure, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback completes the login and issues a local session token.
+func (h *SSOHandler) Callback(c *gin.Context) {
+	login, ok := h.resolveLogin(c)
+	if !ok {
+		return
+	}
+
+	ipKey := "ip:" + c.ClientIP()
+	accountKey := "sso:" + login.Workspace.ID + ":" + c.ClientIP()
+	if wait, locked := h.throttle.Locked(ipKey, accountKey); locked {
+		auth.AbortThrottled(c, wait)
+		return
+	}
+
+	if errParam := c.Query("error"); errParam != "" {
+		h.recordFailure(c, login, ipKey, accountKey, errParam)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Identity provider rejected the login", "details": errParam})
+		return
+	}
+
+	state, err := c.Cookie(ssoStateCookie)
+	if err != nil || state == "" || state != c.Query("state") {
+		h.recordFailure(c, login, ipKey, accountKey, "invalid state")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid login state"})
+		return
+	}
+	returnTo, _ := c.Cookie(ssoReturnCookie)
+	c.SetCookie(ssoStateCookie, "", -1, "/api/auth/sso", "", false, true)
+	c.SetCookie(ssoReturnCookie, "", -1, "/api/auth/sso", "", false, true)
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	user, err := h.ssoService.CompleteLogin(ctx, login, h.callbackURL(c), c.Query("code"))
+	if err != nil {
+		h.recordFailure(c, login, ipKey, accountKey, err.Error())
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Failed to complete login", "details": err.Error()})
+		return
+	}
+
+	h.throttle.Succeed(accountKey)
+	h.auditService.Record(&gormmodels.AuditLog{
+		WorkspaceID: login.Workspace.ID,
+		UserID:      user.ID,
+		Action:      "auth.login",
+		Resource:    "sso",
+		IPAddress:   c.ClientIP(),
+	})
+
+	token, expiresAt, err := h.sessionService.IssueToken(user)
+	if err != nil {
+		writeServiceError(c, "Failed to issue session token", err)
+		return
+	}
+
+	if returnTo != "" && h.isAllowedReturn(returnTo) {
+		fragment := url.Values{"token": {token}, "expiresAt": {expiresAt.Format(time.RFC3339)}}
+		c.Redirect(http.StatusFound, returnTo+"#"+fragment.Encode())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":     token,
+		"expiresAt": expiresAt,
+		"user":      user,
+	})
+}
+
+func (h *SSOHandler) recordFailure(c *gin.Context, login *services.SSOLogin, ipKey, accountKey, reason string) {
+	h.throttle.Fail(ipKey, accountKey)
+	h.auditService.Record(&gormmodels.AuditLog{
+		WorkspaceID: login.Workspace.ID,
+		Action:      "auth.failure",
+		Resource:    truncate(reason, 255),
+		IPAddress:   c.ClientIP(),
+	})
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// loadWorkspace loads the workspace named by the :id route parameter, by ID
+// or slug. Callers may only manage their own workspace; others are reported
+// as missing.
+func (h *SSOHandler) loadWorkspace(c *gin.Context) (*gormmodels.Workspace, bool) {
+	workspace, err := h.ssoService.GetWorkspace(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch workspace", err)
+		return nil, false
+	}
+
+	if workspace == nil || workspace.ID != c.GetString(auth.ContextWorkspaceID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return nil, false
+	}
+
+	return workspace, true
+}
+
+func (h *SSOHandler) resolveLogin(c *gin.Context) (*services.SSOLogin, bool) {
+	if !h.sessionService.Enabled() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Authentication is not configured"})
+		return nil, false
+	}
+
+	login, err := h.ssoService.ResolveLogin(c.Param("workspace"))
+	if err != nil {
+		writeServiceError(c, "Failed to load SSO configuration", err)
+		return nil, false
+	}
+
+	if login == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SSO is not enabled for this workspace"})
+		return nil, false
+	}
+
+	return login, true
+}
+
+func (h *SSOHandler) callbackURL(c *gin.Context) string {
+	return fmt.Sprintf("%s/api/auth/sso/%s/callback", h.getBaseURL(c), url.PathEscape(c.Param("workspace")))
+}
+
+func (h *SSOHandler) isAllowedReturn(returnTo string) bool {
+	for _, origin := range h.config.Server.AllowOrigins {
+		if origin != "" && (returnTo == origin || strings.HasPrefix(returnTo, origin+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *SSOHandler) getBaseURL(c *gin.Context) string {
+	if h.config.Server.BaseURL != "" {
+		return h.config.Server.BaseURL
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || c.Request.Header.Get("X-Forwarded-Proto") == "https" || h.config.Server.Environment == "production" {
+		scheme = "https"
+	}
+
+	return fmt.Sprintf("%s://%s", scheme, c.Request.Host)
+}
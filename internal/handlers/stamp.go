@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StampHandler struct {
+	stampService *services.StampService
+}
+
+func NewStampHandler(stampService *services.StampService) *StampHandler {
+	return &StampHandler{
+		stampService: stampService,
+	}
+}
+
+func (h *StampHandler) GetAll(c *gin.Context) {
+	stamps, err := h.stampService.GetByWorkspaceID(c.GetString(auth.ContextWorkspaceID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch stamps", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stamps": stamps})
+}
+
+// Create uploads a stamp for the workspace. The multipart form carries the
+// image as "file" and the stamp's "name".
+func (h *StampHandler) Create(c *gin.Context) {
+	name := strings.TrimSpace(c.PostForm("name"))
+	if name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Stamp name is required"})
+		return
+	}
+
+	file, _, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(io.LimitReader(file, services.MaxStampSize+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file", "details": err.Error()})
+		return
+	}
+
+	stamp := &gormmodels.Stamp{
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		Name:        name,
+		CreatedBy:   c.GetString(auth.ContextUserID),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := h.stampService.Create(ctx, stamp, content); err != nil {
+		writeServiceError(c, "Failed to store stamp", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, stamp)
+}
+
+// Image serves the stamp image with the same restrictions as signature images.
+func (h *StampHandler) Image(c *gin.Context) {
+	stamp, ok := h.loadStamp(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	content, err := h.stampService.Content(ctx, stamp)
+	if err != nil {
+		writeServiceError(c, "Failed to read stamp", err)
+		return
+	}
+
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, stamp.MimeType, content)
+}
+
+// Delete refuses to remove a stamp that template fields place at a fixed
+// position.
+func (h *StampHandler) Delete(c *gin.Context) {
+	stamp, ok := h.loadStamp(c)
+	if !ok {
+		return
+	}
+
+	fields, err := h.stampService.CountFields(stamp.ID)
+	if err != nil {
+		writeServiceError(c, "Failed to delete stamp", err)
+		return
+	}
+
+	if fields > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Stamp is used by template fields", "fields": fields})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := h.stampService.Delete(ctx, stamp); err != nil {
+		writeServiceError(c, "Failed to delete stamp", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Stamp deleted successfully"})
+}
+
+func (h *StampHandler) loadStamp(c *gin.Context) (*gormmodels.Stamp, bool) {
+	stamp, err := h.stampService.GetForWorkspace(c.GetString(auth.ContextWorkspaceID), c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch stamp", err)
+		return nil, false
+	}
+
+	if stamp == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Stamp not found"})
+		return nil, false
+	}
+
+	return stamp, true
+}
+
+// checkStamps responds with 422 when stamp fields choose stamps outside the
+// submitting workspace.
+func checkStamps(c *gin.Context, stampService *services.StampService, templateID string, data map[string]interface{}, workspaceID string) bool {
+	violations, err := stampService.CheckReferences(templateID, data, workspaceID)
+	if err != nil {
+		writeServiceError(c, "Failed to validate stamps", err)
+		return true
+	}
+	if len(violations) == 0 {
+		return false
+	}
+
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":      "Stamp fields refer to unknown stamps",
+		"violations": violations,
+	})
+	return true
+}
+
+// embedStamps returns htmlData with the stamp image of every stamp field,
+// drawn with the field's opacity. Fields whose stamp no longer exists render
+// empty.
+func (h *PDFHandler) embedStamps(fields []gormmodels.Field, data map[string]interface{}, htmlData map[string]interface{}) (map[string]interface{}, error) {
+	var stamped []gormmodels.Field
+	for _, field := range fields {
+		if field.Type == services.FieldTypeStamp && (field.StampID != "" || dataValue(data, field.DataKey) != nil) {
+			stamped = append(stamped, field)
+		}
+	}
+	if len(stamped) == 0 {
+		return htmlData, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	images, err := h.stampService.Images(ctx, stamped, data)
+	if err != nil {
+		return nil, err
+	}
+
+	embedded := make(map[string]interface{}, len(htmlData)+len(stamped))
+	for key, value := range htmlData {
+		embedded[key] = value
+	}
+	for _, field := range stamped {
+		if image, ok := images[field.DataKey]; ok {
+			embedded[field.DataKey] = embeddedImageHTML("stamp", image.MimeType, image.Content, field.Opacity)
+		} else {
+			embedded[field.DataKey] = `<span class="stamp"></span>`
+		}
+	}
+	return embedded, nil
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// submissionCreator is who a new submission is made for: the user whose
+// stored signatures it may use and the workspace whose stamps it may use and
+// that may see it. Fill link and inbound submissions have neither.
+type submissionCreator struct {
+	UserID      string
+	WorkspaceID string
+}
+
+// requestCreator is the signed-in caller of the request, if any.
+func requestCreator(c *gin.Context) submissionCreator {
+	return submissionCreator{
+		UserID:      c.GetString(auth.ContextUserID),
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+	}
+}
+
+// submissionPipeline is the path every new submission takes, whatever the
+// channel it came through: the field, option list, signature and stamp
+// checks, normalization, duplicate detection and the validation webhook,
+// then saving it.
+type submissionPipeline struct {
+	formService       *services.FormService
+	optionListService *services.OptionListService
+	signatureService  *services.SignatureService
+	stampService      *services.StampService
+	validationService *services.ValidationWebhookService
+}
+
+func newSubmissionPipeline(formService *services.FormService, optionListService *services.OptionListService, signatureService *services.SignatureService, stampService *services.StampService, validationService *services.ValidationWebhookService) *submissionPipeline {
+	return &submissionPipeline{
+		formService:       formService,
+		optionListService: optionListService,
+		signatureService:  signatureService,
+		stampService:      stampService,
+		validationService: validationService,
+	}
+}
+
+// submissionRejection is why the pipeline refused a submission, both as the
+// response for channels that take one submission per request and as
+// messages for import rows.
+type submissionRejection struct {
+	status   int
+	body     gin.H
+	messages []string
+}
+
+// reject records a failed check. The response is that of the first check
+// that failed; the messages are those of every check.
+func (r *submissionRejection) reject(status int, body gin.H, messages []string) {
+	if r.body == nil {
+		r.status, r.body = status, body
+	}
+	r.messages = append(r.messages, messages...)
+}
+
+// newSubmission starts a submission to templateID made through channel by
+// creator, recording its provenance from the request.
+func (p *submissionPipeline) newSubmission(c *gin.Context, templateID, channel string, creator submissionCreator) *gormmodels.FormSubmission {
+	return &gormmodels.FormSubmission{
+		ID:          uuid.New().String(),
+		TemplateID:  templateID,
+		CreatedBy:   creator.UserID,
+		WorkspaceID: creator.WorkspaceID,
+		Metadata:    submissionMetadata(c, channel),
+	}
+}
+
+// check runs a new submission through the checks, filling in its normalized
+// HTML and raw data, its duplicate reference and the webhook's verdict.
+// With dryRun the validation webhook is not called.
+func (p *submissionPipeline) check(c *gin.Context, submission *gormmodels.FormSubmission, locale services.InputLocale, dryRun bool) (*submissionRejection, error) {
+	rejection := &submissionRejection{}
+
+	fieldErrors, err := p.formService.ValidateFormData(submission.TemplateID, submission.FormData, locale, submission.Status != services.SubmissionStatusDraft)
+	if err != nil {
+		return nil, err
+	}
+	if len(fieldErrors) > 0 {
+		messages := make([]string, len(fieldErrors))
+		for i, e := range fieldErrors {
+			messages[i] = fmt.Sprintf("%s: %s", e.Field, e.Message)
+		}
+		rejection.reject(http.StatusUnprocessableEntity, gin.H{
+			"error":  "Form data does not match the template fields",
+			"errors": fieldErrors,
+		}, messages)
+	}
+
+	optionViolations, err := p.optionListService.Validate(submission.TemplateID, submission.FormData)
+	if err != nil {
+		return nil, err
+	}
+	if len(optionViolations) > 0 {
+		messages := make([]string, len(optionViolations))
+		for i, v := range optionViolations {
+			messages[i] = fmt.Sprintf("%s: %v is not an option of list %s", v.DataKey, v.Value, v.OptionListID)
+		}
+		rejection.reject(http.StatusUnprocessableEntity, gin.H{
+			"error":      "Submitted values are not in their option lists",
+			"violations": optionViolations,
+		}, messages)
+	}
+
+	signatureViolations, err := p.signatureService.CheckReferences(submission.TemplateID, submission.FormData, submission.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if len(signatureViolations) > 0 {
+		messages := make([]string, len(signatureViolations))
+		for i, v := range signatureViolations {
+			messages[i] = fmt.Sprintf("%s: signature %s %s", v.DataKey, v.SignatureID, v.Reason)
+		}
+		rejection.reject(http.StatusUnprocessableEntity, gin.H{
+			"error":      "Signature fields refer to unusable signatures",
+			"violations": signatureViolations,
+		}, messages)
+	}
+
+	stampViolations, err := p.stampService.CheckReferences(submission.TemplateID, submission.FormData, submission.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if len(stampViolations) > 0 {
+		messages := make([]string, len(stampViolations))
+		for i, v := range stampViolations {
+			messages[i] = fmt.Sprintf("%s: unknown stamp %s", v.DataKey, v.StampID)
+		}
+		rejection.reject(http.StatusUnprocessableEntity, gin.H{
+			"error":      "Stamp fields refer to unknown stamps",
+			"violations": stampViolations,
+		}, messages)
+	}
+	if rejection.body != nil {
+		return rejection, nil
+	}
+
+	if submission.HtmlData, err = normalizeHTMLData(submission.HtmlData); err != nil {
+		rejection.reject(http.StatusBadRequest, gin.H{"error": "Invalid htmlData", "details": err.Error()}, []string{"htmlData: " + err.Error()})
+		return rejection, nil
+	}
+
+	if submission.RawData, err = p.formService.NormalizeFormData(submission.TemplateID, submission.FormData, locale); err != nil {
+		return nil, err
+	}
+
+	match, err := p.formService.FindDuplicate(submission)
+	if err != nil {
+		return nil, err
+	}
+	if match != nil {
+		if match.Action == gormmodels.DuplicateBlock {
+			rejection.reject(http.StatusConflict, gin.H{
+				"error":       "Duplicate submission",
+				"duplicateOf": match.Submission.ID,
+				"submittedAt": match.Submission.CreatedAt,
+			}, []string{fmt.Sprintf("duplicate of submission %s", match.Submission.ID)})
+			return rejection, nil
+		}
+		submission.DuplicateOf = match.Submission.ID
+	}
+
+	if dryRun {
+		return nil, nil
+	}
+
+	if err := p.validationService.Validate(c.Request.Context(), submission); err != nil {
+		var webhookRejection *services.ValidationRejection
+		if !errors.As(err, &webhookRejection) {
+			return nil, err
+		}
+		var messages []string
+		for _, issue := range webhookRejection.Errors {
+			if issue.Field != "" {
+				messages = append(messages, fmt.Sprintf("%s: %s", issue.Field, issue.Message))
+			} else {
+				messages = append(messages, issue.Message)
+			}
+		}
+		if len(messages) == 0 {
+			messages = append(messages, "rejected by validation webhook")
+		}
+		rejection.reject(http.StatusUnprocessableEntity, gin.H{
+			"error":            "Submission rejected by validation webhook",
+			"validationErrors": webhookRejection.Errors,
+		}, messages)
+		return rejection, nil
+	}
+	return nil, nil
+}
+
+// submit checks and saves a submission made by a single request, and
+// answers with the rejection, the failure or the created submission.
+func (p *submissionPipeline) submit(c *gin.Context, submission *gormmodels.FormSubmission, locale services.InputLocale) {
+	rejection, err := p.check(c, submission, locale, false)
+	if err != nil {
+		writeServiceError(c, "Failed to validate form submission", err)
+		return
+	}
+	if rejection != nil {
+		c.JSON(rejection.status, rejection.body)
+		return
+	}
+
+	if err := p.formService.Create(submission); err != nil {
+		writeServiceError(c, "Failed to save form submission", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, submissionCreated(submission))
+}
+
+// submissionCreated is the response body for a newly created submission.
+func submissionCreated(submission *gormmodels.FormSubmission) gin.H {
+	body := gin.H{
+		"id":      submission.ID,
+		"message": "Form submitted successfully",
+		"status":  submission.Status,
+	}
+	if submission.DuplicateOf != "" {
+		body["duplicateOf"] = submission.DuplicateOf
+	}
+	return body
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SuggestionHandler struct {
+	suggestionService *services.SuggestionService
+	templateService   *services.TemplateService
+}
+
+func NewSuggestionHandler(suggestionService *services.SuggestionService, templateService *services.TemplateService) *SuggestionHandler {
+	return &SuggestionHandler{
+		suggestionService: suggestionService,
+		templateService:   templateService,
+	}
+}
+
+// GetSuggestions returns the values most often submitted for ?dataKey=,
+// filtered by ?q=, for autocompleting the field while filling the form.
+func (h *SuggestionHandler) GetSuggestions(c *gin.Context) {
+	dataKey := c.Query("dataKey")
+	if dataKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dataKey is required"})
+		return
+	}
+
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+			return
+		}
+	}
+
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	found := false
+	for i := range template.Fields {
+		if template.Fields[i].DataKey != dataKey {
+			continue
+		}
+		found = true
+		// A DataKey shared by several fields is suggested only if every one allows it.
+		if !h.suggestionService.Allowed(&template.Fields[i]) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Suggestions are not offered for this field", "details": dataKey})
+			return
+		}
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Field not found", "details": dataKey})
+		return
+	}
+
+	suggestions, err := h.suggestionService.Suggest(template.ID, c.GetString(auth.ContextWorkspaceID), dataKey, c.Query("q"), limit)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch suggestions", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"dataKey": dataKey, "suggestions": suggestions})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/config"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SyncHandler struct {
+	syncService *services.SyncService
+	config      *config.Config
+}
+
+func NewSyncHandler(syncService *services.SyncService, cfg *config.Config) *SyncHandler {
+	return &SyncHandler{
+		syncService: syncService,
+		config:      cfg,
+	}
+}
+
+type SyncRequest struct {
+	RemoteURL  string `json:"remoteUrl" binding:"required,url"`
+	APIKey     string `json:"apiKey" binding:"required"`
+	TemplateID string `json:"templateId" binding:"required"`
+	Force      bool   `json:"force"`
+}
+
+// authorize checks the shared sync key; sync is disabled when no key is configured.
+func (h *SyncHandler) authorize(c *gin.Context) bool {
+	key := h.config.Sync.APIKey
+	if key == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Template sync is not enabled"})
+		return false
+	}
+
+	provided := c.GetHeader("X-API-Key")
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		return false
+	}
+
+	return true
+}
+
+func (h *SyncHandler) Push(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	var req SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	bundle, err := h.syncService.Push(ctx, req.RemoteURL, req.APIKey, req.TemplateID, req.Force)
+	if err != nil {
+		h.writeSyncError(c, "Failed to push template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Template pushed successfully",
+		"templateId": req.TemplateID,
+		"hash":       bundle.Hash,
+	})
+}
+
+func (h *SyncHandler) Pull(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	var req SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
+	defer cancel()
+
+	bundle, err := h.syncService.Pull(ctx, req.RemoteURL, req.APIKey, req.TemplateID, req.Force)
+	if err != nil {
+		h.writeSyncError(c, "Failed to pull template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Template pulled successfully",
+		"templateId": req.TemplateID,
+		"hash":       bundle.Hash,
+	})
+}
+
+// Export serves a template bundle to a remote instance pulling from us.
+func (h *SyncHandler) Export(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Minute)
+	defer cancel()
+
+	bundle, err := h.syncService.Export(ctx, c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to export template", err)
+		return
+	}
+
+	if bundle == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+// Import accepts a template bundle from a remote instance pushing to us.
+func (h *SyncHandler) Import(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	var bundle services.TemplateBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bundle", "details": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), time.Minute)
+	defer cancel()
+
+	if err := h.syncService.Import(ctx, &bundle, c.Query("force") == "true"); err != nil {
+		h.writeSyncError(c, "Failed to import template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template imported successfully", "hash": bundle.Hash})
+}
+
+func (h *SyncHandler) writeSyncError(c *gin.Context, message string, err error) {
+	if errors.Is(err, services.ErrSyncConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": message, "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusBadGateway, gin.H{"error": message, "details": err.Error()})
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AttachUploadRequest struct {
+	UploadID  string `json:"uploadId" binding:"required"`
+	PageIndex int    `json:"pageIndex" binding:"min=0"`
+}
+
+// UploadTemp puts the multipart "file" in the caller's hold area, for files
+// chosen before the template they belong to has been created. It answers
+// with the upload's ID and when it expires unless attached.
+func (h *UploadHandler) UploadTemp(c *gin.Context) {
+	limit := h.tempUploadService.Limit()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+64<<10)
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded", "details": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	upload, err := h.tempUploadService.Upload(ctx, c.GetString(auth.ContextUserID), c.GetString(auth.ContextWorkspaceID),
+		header.Filename, header.Header.Get("Content-Type"), file)
+	if err != nil {
+		if errors.Is(err, services.ErrTempUploadTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large", "details": fmt.Sprintf("the limit is %d bytes", limit)})
+			return
+		}
+		writeServiceError(c, "Failed to upload file", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, upload)
+}
+
+// ListTemp lists the caller's unexpired uploads.
+func (h *UploadHandler) ListTemp(c *gin.Context) {
+	uploads, err := h.tempUploadService.List(c.GetString(auth.ContextUserID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch uploads", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, uploads)
+}
+
+func (h *UploadHandler) GetTemp(c *gin.Context) {
+	upload, err := h.tempUploadService.Get(c.Param("id"), c.GetString(auth.ContextUserID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch upload", err)
+		return
+	}
+
+	if upload == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, upload)
+}
+
+func (h *UploadHandler) DeleteTemp(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := h.tempUploadService.Delete(ctx, c.Param("id"), c.GetString(auth.ContextUserID)); err != nil {
+		writeServiceError(c, "Failed to delete upload", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Upload deleted successfully"})
+}
+
+// AttachSVG makes one of the caller's temporary uploads the background of a
+// template page, as if it had been uploaded to the page directly.
+func (h *UploadHandler) AttachSVG(c *gin.Context) {
+	templateID := c.Param("templateId")
+
+	var req AttachUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	upload, err := h.tempUploadService.Get(req.UploadID, c.GetString(auth.ContextUserID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch upload", err)
+		return
+	}
+
+	if upload == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	}
+
+	template, err := h.templateService.GetByID(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	svgFile, err := h.uploadService.AttachPage(ctx, templateID, req.PageIndex, upload)
+	if err != nil {
+		writeServiceError(c, "Failed to attach upload", err)
+		return
+	}
+
+	// Keep the legacy SVG background in step with page 0, as UploadSVG does.
+	if svgFile.PageIndex == 0 && template.SVGBackground != templateID {
+		template.SVGBackground = templateID
+		if err := h.templateService.Update(template); err != nil {
+			fmt.Printf("Warning: Failed to update template SVG background: %v\n", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Upload attached successfully",
+		"filename":     svgFile.Filename,
+		"originalName": svgFile.OriginalName,
+		"size":         svgFile.FileSize,
+		"pageIndex":    svgFile.PageIndex,
+		"url":          fmt.Sprintf("%s/api/files/svg/%s/page/%d", h.getBaseURL(c), templateID, svgFile.PageIndex),
+		"gcsPath":      svgFile.GCSPath,
+	})
+}
@@ -1,53 +1,113 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/config"
 	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
 	"github.com/dhanavadh/fastfill-backend/internal/services"
-	"github.com/dhanavadh/fastfill-backend/internal/config"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 )
 
 type TemplateHandler struct {
-	templateService *services.TemplateService
-	config          *config.Config
+	templateService   *services.TemplateService
+	formService       *services.FormService
+	optionListService *services.OptionListService
+	cdnService        *services.CDNService
+	stampService      *services.StampService
+	domainService     *services.DomainService
+	uploadService     *services.UploadService
+	config            *config.Config
 }
 
-func NewTemplateHandler(templateService *services.TemplateService, cfg *config.Config) *TemplateHandler {
+func NewTemplateHandler(templateService *services.TemplateService, formService *services.FormService, optionListService *services.OptionListService, cdnService *services.CDNService, stampService *services.StampService, domainService *services.DomainService, uploadService *services.UploadService, cfg *config.Config) *TemplateHandler {
 	return &TemplateHandler{
-		templateService: templateService,
-		config:          cfg,
+		templateService:   templateService,
+		formService:       formService,
+		optionListService: optionListService,
+		cdnService:        cdnService,
+		stampService:      stampService,
+		domainService:     domainService,
+		uploadService:     uploadService,
+		config:            cfg,
 	}
 }
 
 type TemplateResponse struct {
-	ID            string             `json:"id"`
-	DisplayName   string             `json:"displayName"`
-	Description   string             `json:"description"`
-	Category      string             `json:"category"`
-	PreviewImage  string             `json:"previewImage"`
-	SVGBackground string             `json:"svgBackground"`
-	DataInterface string             `json:"dataInterface"`
-	Fields        []FieldResponse    `json:"fields"`
-	SVGFiles      []SVGFileResponse  `json:"svgFiles,omitempty"`
+	ID                 string                         `json:"id"`
+	DisplayName        string                         `json:"displayName"`
+	Description        string                         `json:"description"`
+	Category           string                         `json:"category"`
+	PreviewImage       string                         `json:"previewImage"`
+	SVGBackground      string                         `json:"svgBackground"`
+	DataInterface      string                         `json:"dataInterface"`
+	Locale             string                         `json:"locale,omitempty"`
+	OptimizePDF        bool                           `json:"optimizePdf"`
+	OptimizeDPI        int                            `json:"optimizeDpi,omitempty"`
+	PDFMetadata        gormmodels.PDFMetadataSettings `json:"pdfMetadata"`
+	PrintOptions       gormmodels.PrintSettings       `json:"printOptions"`
+	ThaiWordBreak      bool                           `json:"thaiWordBreak"`
+	SuppressBackground bool                           `json:"suppressBackground"`
+	ScriptFonts        map[string]string              `json:"scriptFonts,omitempty"`
+	CustomCSS          string                         `json:"customCss,omitempty"`
+	DuplicatePolicy    gormmodels.DuplicatePolicy     `json:"duplicatePolicy"`
+	ExpiryPolicy       gormmodels.ExpiryPolicy        `json:"expiryPolicy"`
+	ValidationWebhook  gormmodels.ValidationWebhook   `json:"validationWebhook"`
+	Grid               gormmodels.GridSettings        `json:"grid"`
+	Continuation       gormmodels.ContinuationLayout  `json:"continuation"`
+	EFiling            gormmodels.EFilingExport       `json:"efiling"`
+	Version            int                            `json:"version"`
+	WorkspaceID        string                         `json:"workspaceId,omitempty"`
+	ArchivedAt         *time.Time                     `json:"archivedAt,omitempty"`
+	PublicPreview      bool                           `json:"publicPreview"`
+	PublicURL          string                         `json:"publicUrl"`
+	Fields             []FieldResponse                `json:"fields"`
+	SVGFiles           []SVGFileResponse              `json:"svgFiles,omitempty"`
 }
 
 type FieldResponse struct {
-	Name               string            `json:"name"`
-	Type               string            `json:"type"`
-	Required           bool              `json:"required"`
-	DataKey            string            `json:"dataKey"`
-	IsAddressComponent bool              `json:"isAddressComponent"`
-	PageIndex          int               `json:"pageIndex"`
-	Options            []string          `json:"options,omitempty"`
-	Position           *PositionResponse `json:"position,omitempty"`
+	Name               string                                 `json:"name"`
+	Type               string                                 `json:"type"`
+	Required           bool                                   `json:"required"`
+	DataKey            string                                 `json:"dataKey"`
+	IsAddressComponent bool                                   `json:"isAddressComponent"`
+	PageIndex          int                                    `json:"pageIndex"`
+	Options            []string                               `json:"options,omitempty"`
+	OptionValues       []string                               `json:"optionValues,omitempty"`
+	OptionListID       string                                 `json:"optionListId,omitempty"`
+	Rotation           int                                    `json:"rotation,omitempty"`
+	Vertical           bool                                   `json:"vertical,omitempty"`
+	ClassName          string                                 `json:"className,omitempty"`
+	Boxes              []CheckboxBoxResponse                  `json:"boxes,omitempty"`
+	CheckMark          string                                 `json:"checkMark,omitempty"`
+	StampID            string                                 `json:"stampId,omitempty"`
+	Opacity            float64                                `json:"opacity,omitempty"`
+	Sensitive          bool                                   `json:"sensitive,omitempty"`
+	ViewRoles          []string                               `json:"viewRoles,omitempty"`
+	Flow               bool                                   `json:"flow,omitempty"`
+	FontSize           int                                    `json:"fontSize,omitempty"`
+	Position           *PositionResponse                      `json:"position,omitempty"`
+	Translations       map[string]gormmodels.FieldTranslation `json:"translations,omitempty"`
+}
+
+type CheckboxBoxResponse struct {
+	Value    string           `json:"value"`
+	Position PositionResponse `json:"position"`
 }
 
 type SVGFileResponse struct {
@@ -66,24 +126,98 @@ type PositionResponse struct {
 }
 
 type CreateTemplateRequest struct {
-	DisplayName   string         `json:"displayName" binding:"required"`
-	Description   string         `json:"description"`
-	Category      string         `json:"category"`
-	PreviewImage  string         `json:"previewImage"`
-	SVGBackground string         `json:"svgBackground"`
-	DataInterface string         `json:"dataInterface"`
-	Fields        []FieldRequest `json:"fields"`
+	DisplayName        string                         `json:"displayName" binding:"required"`
+	Description        string                         `json:"description"`
+	Category           string                         `json:"category"`
+	PreviewImage       string                         `json:"previewImage"`
+	SVGBackground      string                         `json:"svgBackground"`
+	DataInterface      string                         `json:"dataInterface"`
+	OptimizePDF        bool                           `json:"optimizePdf"`
+	OptimizeDPI        int                            `json:"optimizeDpi" binding:"min=0,max=1200"`
+	PDFMetadata        gormmodels.PDFMetadataSettings `json:"pdfMetadata"`
+	PrintOptions       PrintOptionsRequest            `json:"printOptions"`
+	ThaiWordBreak      bool                           `json:"thaiWordBreak"`
+	SuppressBackground bool                           `json:"suppressBackground"`
+	ScriptFonts        map[string]string              `json:"scriptFonts,omitempty"`
+	CustomCSS          string                         `json:"customCss"`
+	DuplicatePolicy    DuplicatePolicyRequest         `json:"duplicatePolicy"`
+	ExpiryPolicy       ExpiryPolicyRequest            `json:"expiryPolicy"`
+	ValidationWebhook  ValidationWebhookRequest       `json:"validationWebhook"`
+	Continuation       ContinuationLayoutRequest      `json:"continuation"`
+	EFiling            EFilingExportRequest           `json:"efiling"`
+	Version            int                            `json:"version,omitempty"`
+	PublicPreview      bool                           `json:"publicPreview"`
+	Fields             []FieldRequest                 `json:"fields"`
+}
+
+type PrintOptionsRequest struct {
+	BleedMM      float64 `json:"bleedMm" binding:"min=0,max=10"`
+	SafeMarginMM float64 `json:"safeMarginMm" binding:"min=0,max=30"`
+	CropMarks    bool    `json:"cropMarks"`
+}
+
+type DuplicatePolicyRequest struct {
+	Keys       []string `json:"keys"`
+	WindowDays int      `json:"windowDays" binding:"min=0,max=3650"`
+	Action     string   `json:"action" binding:"omitempty,oneof=flag block"`
+}
+
+type ExpiryPolicyRequest struct {
+	DraftTTLDays int    `json:"draftTtlDays" binding:"min=0,max=3650"`
+	WarnDays     int    `json:"warnDays" binding:"min=0,max=365"`
+	PurgeData    bool   `json:"purgeData"`
+	NotifyURL    string `json:"notifyUrl" binding:"omitempty,url"`
+}
+
+type ValidationWebhookRequest struct {
+	URL         string `json:"url" binding:"omitempty,url"`
+	TimeoutMS   int    `json:"timeoutMs" binding:"min=0,max=10000"`
+	FailureMode string `json:"failureMode" binding:"omitempty,oneof=open closed"`
+}
+
+type ContinuationLayoutRequest struct {
+	Top      int    `json:"top" binding:"min=0,max=1123"`
+	Left     int    `json:"left" binding:"min=0,max=794"`
+	Width    int    `json:"width" binding:"min=0,max=794"`
+	Height   int    `json:"height" binding:"min=0,max=1123"`
+	Header   string `json:"header" binding:"max=200"`
+	MaxPages int    `json:"maxPages" binding:"min=0,max=50"`
+}
+
+type EFilingExportRequest struct {
+	RootElement string                      `json:"rootElement" binding:"max=100"`
+	Namespace   string                      `json:"namespace" binding:"max=500"`
+	Schema      string                      `json:"schema"`
+	Elements    []gormmodels.EFilingElement `json:"elements" binding:"max=500"`
 }
 
 type FieldRequest struct {
-	Name               string           `json:"name" binding:"required"`
-	Type               string           `json:"type" binding:"required"`
-	Required           bool             `json:"required"`
-	DataKey            string           `json:"dataKey" binding:"required"`
-	IsAddressComponent bool             `json:"isAddressComponent"`
-	PageIndex          int              `json:"pageIndex"`
-	Options            []string         `json:"options,omitempty"`
-	Position           *PositionRequest `json:"position"`
+	Name               string                                 `json:"name" binding:"required"`
+	Type               string                                 `json:"type" binding:"required"`
+	Required           bool                                   `json:"required"`
+	DataKey            string                                 `json:"dataKey" binding:"required"`
+	IsAddressComponent bool                                   `json:"isAddressComponent"`
+	PageIndex          int                                    `json:"pageIndex"`
+	Options            []string                               `json:"options,omitempty"`
+	OptionListID       string                                 `json:"optionListId,omitempty"`
+	Rotation           int                                    `json:"rotation" binding:"oneof=0 90 180 270"`
+	Vertical           bool                                   `json:"vertical"`
+	ClassName          string                                 `json:"className,omitempty"`
+	Boxes              []CheckboxBoxRequest                   `json:"boxes,omitempty" binding:"dive"`
+	CheckMark          string                                 `json:"checkMark,omitempty" binding:"max=4"`
+	StampID            string                                 `json:"stampId,omitempty"`
+	Opacity            float64                                `json:"opacity,omitempty" binding:"min=0,max=1"`
+	Sensitive          bool                                   `json:"sensitive,omitempty"`
+	ViewRoles          []string                               `json:"viewRoles,omitempty" binding:"omitempty,max=16,dive,required,max=32"`
+	Flow               bool                                   `json:"flow,omitempty"`
+	FontSize           int                                    `json:"fontSize,omitempty" binding:"omitempty,min=4,max=96"`
+	Position           *PositionRequest                       `json:"position"`
+	Translations       map[string]gormmodels.FieldTranslation `json:"translations,omitempty"`
+}
+
+type CheckboxBoxRequest struct {
+	Value    string          `json:"value" binding:"required"`
+	Position PositionRequest `json:"position"`
 }
 
 type PositionRequest struct {
@@ -93,10 +227,125 @@ type PositionRequest struct {
 	Height float64 `json:"height"`
 }
 
+// Template list page sizes.
+const (
+	defaultTemplatePageSize = 50
+	maxTemplatePageSize     = 200
+)
+
+// templateIncludes maps the ?include values of the template list to the
+// associations they preload.
+var templateIncludes = map[string]string{
+	"fields":   "Fields",
+	"svgFiles": "SVGFiles",
+}
+
+// GetAll lists templates a page at a time, newest first unless ?sort= says
+// otherwise. Summaries are returned unless ?include=fields,svgFiles asks for
+// full templates. The total number of matches is sent in X-Total-Count.
+// Archived templates are left out unless ?archived=true (only archived) or
+// ?archived=all. Pages are picked with ?page= and ?pageSize=, which return
+// the templates in an envelope with the totals, or with ?limit= and
+// ?offset=, which return a bare array.
 func (h *TemplateHandler) GetAll(c *gin.Context) {
-	templates, err := h.templateService.GetAll()
+	workspaceID := c.GetString(auth.ContextWorkspaceID)
+	archived := false
+	filter := services.TemplateFilter{
+		Category:  c.Query("category"),
+		Search:    c.Query("q"),
+		Sort:      c.Query("sort"),
+		Limit:     defaultTemplatePageSize,
+		VisibleTo: &workspaceID,
+		Archived:  &archived,
+	}
+
+	if filter.Sort != "" && !slices.Contains(services.TemplateSorts(), filter.Sort) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sort", "details": "sort must be one of " + strings.Join(services.TemplateSorts(), ", ")})
+		return
+	}
+
+	switch c.Query("archived") {
+	case "", "false":
+	case "true":
+		archived = true
+	case "all":
+		filter.Archived = nil
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid archived", "details": "archived must be true, false or all"})
+		return
+	}
+
+	paged := c.Query("page") != "" || c.Query("pageSize") != ""
+	if paged && (c.Query("limit") != "" || c.Query("offset") != "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pagination", "details": "use page and pageSize or limit and offset, not both"})
+		return
+	}
+	page := 1
+	if paged {
+		if pageSize := c.Query("pageSize"); pageSize != "" {
+			n, err := strconv.Atoi(pageSize)
+			if err != nil || n < 1 || n > maxTemplatePageSize {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pageSize", "details": fmt.Sprintf("pageSize must be between 1 and %d", maxTemplatePageSize)})
+				return
+			}
+			filter.Limit = n
+		}
+		if p := c.Query("page"); p != "" {
+			n, err := strconv.Atoi(p)
+			if err != nil || n < 1 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page", "details": "page must be 1 or more"})
+				return
+			}
+			page = n
+		}
+		filter.Offset = (page - 1) * filter.Limit
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxTemplatePageSize {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit", "details": fmt.Sprintf("limit must be between 1 and %d", maxTemplatePageSize)})
+			return
+		}
+		filter.Limit = n
+	}
+	if offset := c.Query("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+			return
+		}
+		filter.Offset = n
+	}
+	if include := c.Query("include"); include != "" {
+		for _, name := range strings.Split(include, ",") {
+			association, ok := templateIncludes[strings.TrimSpace(name)]
+			if !ok {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid include", "details": fmt.Sprintf("unknown include %q", name)})
+				return
+			}
+			filter.Preload = append(filter.Preload, association)
+		}
+	}
+
+	if len(filter.Preload) == 0 {
+		summaries, total, err := h.templateService.FindSummaries(filter)
+		if err != nil {
+			writeServiceError(c, "Failed to fetch templates", err)
+			return
+		}
+		c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+		if paged {
+			c.JSON(http.StatusOK, templatePage(summaries, total, page, filter.Limit))
+			return
+		}
+		c.JSON(http.StatusOK, summaries)
+		return
+	}
+
+	templates, total, err := h.templateService.Find(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch templates"})
+		writeServiceError(c, "Failed to fetch templates", err)
 		return
 	}
 
@@ -105,15 +354,31 @@ func (h *TemplateHandler) GetAll(c *gin.Context) {
 		response[i] = h.toTemplateResponse(t, c)
 	}
 
+	c.Header("X-Total-Count", strconv.FormatInt(total, 10))
+	if paged {
+		c.JSON(http.StatusOK, templatePage(response, total, page, filter.Limit))
+		return
+	}
 	c.JSON(http.StatusOK, response)
 }
 
+// templatePage is the envelope of a template list page.
+func templatePage(items interface{}, total int64, page, pageSize int) gin.H {
+	return gin.H{
+		"items":      items,
+		"total":      total,
+		"page":       page,
+		"pageSize":   pageSize,
+		"totalPages": (total + int64(pageSize) - 1) / int64(pageSize),
+	}
+}
+
 func (h *TemplateHandler) GetByID(c *gin.Context) {
 	templateID := c.Param("id")
 
 	template, err := h.templateService.GetByID(templateID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch template"})
+		writeServiceError(c, "Failed to fetch template", err)
 		return
 	}
 
@@ -122,6 +387,7 @@ func (h *TemplateHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	setVersionETag(c, template.Version)
 	c.JSON(http.StatusOK, h.toTemplateResponse(*template, c))
 }
 
@@ -132,29 +398,162 @@ func (h *TemplateHandler) Create(c *gin.Context) {
 		return
 	}
 
-	template := &gormmodels.Template{
-		ID:            uuid.New().String(),
-		DisplayName:   req.DisplayName,
-		Description:   req.Description,
-		Category:      req.Category,
-		PreviewImage:  req.PreviewImage,
-		SVGBackground: req.SVGBackground,
-		DataInterface: req.DataInterface,
-		Fields:        h.toGormFields(req.Fields),
+	template, ok := h.newTemplate(c, req)
+	if !ok {
+		return
 	}
 
-	if template.DataInterface == "" {
-		template.DataInterface = template.DisplayName + "FormData"
+	if err := h.templateService.Create(template); err != nil {
+		writeServiceError(c, "Failed to create template", err)
+		return
 	}
 
-	if err := h.templateService.Create(template); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+	c.JSON(http.StatusCreated, h.toTemplateResponse(*template, c))
+}
+
+// CreateFull creates a template together with its page backgrounds from one
+// multipart request: "template" holds the JSON body Create takes, "svgs" the
+// page files and "pageIndexes" their pages. Without pageIndexes the files
+// become pages 0, 1, ... in order. Nothing is created unless everything is.
+func (h *TemplateHandler) CreateFull(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form", "details": err.Error()})
+		return
+	}
+
+	definition := form.Value["template"]
+	if len(definition) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Exactly one template definition is required"})
+		return
+	}
+
+	var req CreateTemplateRequest
+	if err := json.Unmarshal([]byte(definition[0]), &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON", "details": err.Error()})
+		return
+	}
+	if err := binding.Validator.ValidateStruct(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON", "details": err.Error()})
+		return
+	}
+
+	files := form.File["svgs"]
+	indexes := form.Value["pageIndexes"]
+	if len(indexes) > 0 && len(indexes) != len(files) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Each file needs a page index", "details": fmt.Sprintf("%d files, %d pageIndexes", len(files), len(indexes))})
+		return
+	}
+
+	pages := make([]services.PageUpload, len(files))
+	for i, header := range files {
+		pageIndex := i
+		if len(indexes) > 0 {
+			if pageIndex, err = strconv.Atoi(indexes[i]); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index", "details": indexes[i]})
+				return
+			}
+		}
+		pages[i] = services.PageUpload{Header: header, PageIndex: pageIndex}
+	}
+
+	template, ok := h.newTemplate(c, req)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	if err := h.uploadService.CreateTemplate(ctx, template, pages); err != nil {
+		writeServiceError(c, "Failed to create template", err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, h.toTemplateResponse(*template, c))
 }
 
+// newTemplate builds a new template from a create request and validates it,
+// writing the error response and returning false when it is invalid.
+func (h *TemplateHandler) newTemplate(c *gin.Context, req CreateTemplateRequest) (*gormmodels.Template, bool) {
+	template := &gormmodels.Template{
+		ID:                 uuid.New().String(),
+		WorkspaceID:        c.GetString(auth.ContextWorkspaceID),
+		DisplayName:        req.DisplayName,
+		Description:        req.Description,
+		Category:           req.Category,
+		PreviewImage:       req.PreviewImage,
+		SVGBackground:      req.SVGBackground,
+		DataInterface:      req.DataInterface,
+		OptimizePDF:        req.OptimizePDF,
+		OptimizeDPI:        req.OptimizeDPI,
+		PDFMetadata:        req.PDFMetadata,
+		PrintOptions:       gormmodels.PrintSettings(req.PrintOptions),
+		ThaiWordBreak:      req.ThaiWordBreak,
+		SuppressBackground: req.SuppressBackground,
+		ScriptFonts:        req.ScriptFonts,
+		CustomCSS:          req.CustomCSS,
+		DuplicatePolicy:    gormmodels.DuplicatePolicy(req.DuplicatePolicy),
+		ExpiryPolicy:       gormmodels.ExpiryPolicy(req.ExpiryPolicy),
+		ValidationWebhook:  gormmodels.ValidationWebhook(req.ValidationWebhook),
+		Continuation:       gormmodels.ContinuationLayout(req.Continuation),
+		EFiling:            gormmodels.EFilingExport(req.EFiling),
+		PublicPreview:      req.PublicPreview,
+		Fields:             h.toGormFields(req.Fields),
+	}
+
+	if err := h.checkOptionLists(template.Fields, c.GetString(auth.ContextWorkspaceID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid option list", "details": err.Error()})
+		return nil, false
+	}
+
+	if err := checkDataKeys(template.Fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dataKey", "details": err.Error()})
+		return nil, false
+	}
+
+	if err := checkFieldTypes(template.Fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid field", "details": err.Error()})
+		return nil, false
+	}
+
+	if err := h.stampService.CheckFixed(template.Fields, c.GetString(auth.ContextWorkspaceID)); err != nil {
+		writeServiceError(c, "Invalid stamp", err)
+		return nil, false
+	}
+
+	if err := sanitizeTemplateStyles(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid custom CSS", "details": err.Error()})
+		return nil, false
+	}
+
+	if err := checkDuplicatePolicy(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duplicate policy", "details": err.Error()})
+		return nil, false
+	}
+
+	if err := checkValidationWebhookPolicy(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid validation webhook", "details": err.Error()})
+		return nil, false
+	}
+
+	if err := checkEFilingExport(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid e-filing export", "details": err.Error()})
+		return nil, false
+	}
+
+	if err := checkScriptFonts(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid script fonts", "details": err.Error()})
+		return nil, false
+	}
+
+	if template.DataInterface == "" {
+		template.DataInterface = template.DisplayName + "FormData"
+	}
+
+	return template, true
+}
+
 func (h *TemplateHandler) Update(c *gin.Context) {
 	templateID := c.Param("id")
 
@@ -165,58 +564,160 @@ func (h *TemplateHandler) Update(c *gin.Context) {
 	}
 
 	template := &gormmodels.Template{
-		ID:            templateID,
-		DisplayName:   req.DisplayName,
-		Description:   req.Description,
-		Category:      req.Category,
-		PreviewImage:  req.PreviewImage,
-		SVGBackground: req.SVGBackground,
-		DataInterface: req.DataInterface,
-		Fields:        h.toGormFields(req.Fields),
-		UpdatedAt:     time.Now(),
+		ID:                 templateID,
+		DisplayName:        req.DisplayName,
+		Description:        req.Description,
+		Category:           req.Category,
+		PreviewImage:       req.PreviewImage,
+		SVGBackground:      req.SVGBackground,
+		DataInterface:      req.DataInterface,
+		OptimizePDF:        req.OptimizePDF,
+		OptimizeDPI:        req.OptimizeDPI,
+		PDFMetadata:        req.PDFMetadata,
+		PrintOptions:       gormmodels.PrintSettings(req.PrintOptions),
+		ThaiWordBreak:      req.ThaiWordBreak,
+		SuppressBackground: req.SuppressBackground,
+		ScriptFonts:        req.ScriptFonts,
+		CustomCSS:          req.CustomCSS,
+		DuplicatePolicy:    gormmodels.DuplicatePolicy(req.DuplicatePolicy),
+		ExpiryPolicy:       gormmodels.ExpiryPolicy(req.ExpiryPolicy),
+		ValidationWebhook:  gormmodels.ValidationWebhook(req.ValidationWebhook),
+		Continuation:       gormmodels.ContinuationLayout(req.Continuation),
+		EFiling:            gormmodels.EFilingExport(req.EFiling),
+		PublicPreview:      req.PublicPreview,
+		Fields:             h.toGormFields(req.Fields),
+		UpdatedAt:          time.Now(),
+	}
+
+	if err := h.checkOptionLists(template.Fields, c.GetString(auth.ContextWorkspaceID)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid option list", "details": err.Error()})
+		return
+	}
+
+	if err := checkDataKeys(template.Fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dataKey", "details": err.Error()})
+		return
+	}
+
+	if err := checkFieldTypes(template.Fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid field", "details": err.Error()})
+		return
+	}
+
+	if err := h.stampService.CheckFixed(template.Fields, c.GetString(auth.ContextWorkspaceID)); err != nil {
+		writeServiceError(c, "Invalid stamp", err)
+		return
+	}
+
+	if err := sanitizeTemplateStyles(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid custom CSS", "details": err.Error()})
+		return
+	}
+
+	if err := checkDuplicatePolicy(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duplicate policy", "details": err.Error()})
+		return
+	}
+
+	if err := checkValidationWebhookPolicy(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid validation webhook", "details": err.Error()})
+		return
+	}
+
+	if err := checkEFilingExport(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid e-filing export", "details": err.Error()})
+		return
+	}
+
+	if err := checkScriptFonts(template); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid script fonts", "details": err.Error()})
+		return
+	}
+
+	expectedVersion, err := expectedTemplateVersion(c, req.Version)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid If-Match header", "details": err.Error()})
+		return
 	}
 
 	existing, err := h.templateService.GetByID(templateID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		writeServiceError(c, "Database error", err)
 		return
 	}
 
 	if existing == nil {
 		if err := h.templateService.Create(template); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create template"})
+			writeServiceError(c, "Failed to create template", err)
 			return
 		}
 	} else {
+		template.Version = expectedVersion
 		if err := h.templateService.Update(template); err != nil {
+			if errors.Is(err, services.ErrVersionConflict) {
+				h.writeVersionConflict(c, templateID)
+				return
+			}
 			fmt.Printf("Template update error: %v\n", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template", "details": err.Error()})
+			writeServiceError(c, "Failed to update template", err)
 			return
 		}
 	}
 
+	setVersionETag(c, template.Version)
 	c.JSON(http.StatusOK, h.toTemplateResponse(*template, c))
 }
 
 func (h *TemplateHandler) Delete(c *gin.Context) {
 	templateID := c.Param("id")
 
+	includers, err := h.templateService.CountIncluders(templateID)
+	if err != nil {
+		writeServiceError(c, "Failed to delete template", err)
+		return
+	}
+
+	if includers > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Template is included by other templates", "includedBy": includers})
+		return
+	}
+
 	if err := h.templateService.Delete(templateID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete template"})
+		writeServiceError(c, "Failed to delete template", err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
 }
 
+// Archive retires a template: it leaves default listings and rejects new
+// submissions, while existing submissions and documents keep working.
+func (h *TemplateHandler) Archive(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+func (h *TemplateHandler) Unarchive(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *TemplateHandler) setArchived(c *gin.Context, archived bool) {
+	template, err := h.templateService.SetArchived(c.Param("id"), archived)
+	if err != nil {
+		writeServiceError(c, "Failed to archive template", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.toTemplateResponse(*template, c))
+}
+
 func (h *TemplateHandler) getBaseURL(c *gin.Context) string {
 	// Priority: 1. API_BASE_URL config, 2. Request host, 3. localhost fallback
 	if h.config.Server.BaseURL != "" {
 		return h.config.Server.BaseURL
 	}
-	
+
 	scheme := "http"
-	
+
 	// Check for HTTPS in multiple ways (for load balancers/proxies)
 	if c.Request.TLS != nil {
 		scheme = "https"
@@ -228,41 +729,71 @@ func (h *TemplateHandler) getBaseURL(c *gin.Context) string {
 		// Force HTTPS in production
 		scheme = "https"
 	}
-	
+
 	host := c.Request.Host
 	if host == "" {
 		// Use production domain as fallback instead of localhost
 		host = "api.dooform.com"
 	}
-	
+
 	return fmt.Sprintf("%s://%s", scheme, host)
 }
 
+// publicBaseURL is the base of links handed to people outside the workspace:
+// the workspace's verified custom domain, or the API's own base URL.
+func (h *TemplateHandler) publicBaseURL(c *gin.Context, workspaceID string) string {
+	if baseURL := h.domainService.PublicBaseURL(workspaceID); baseURL != "" {
+		return baseURL
+	}
+	return h.getBaseURL(c)
+}
+
 func (h *TemplateHandler) toTemplateResponse(t gormmodels.Template, c *gin.Context) TemplateResponse {
+	locale := templateLocale(c, t)
+	optionLists := h.fieldOptionLists(t.Fields)
+
 	fields := make([]FieldResponse, len(t.Fields))
 	for i, f := range t.Fields {
 		var options []string
-		if f.Options != "" {
+		if list, ok := optionLists[f.OptionListID]; ok {
+			options = list.Options
+		} else if f.Options != "" {
 			if err := json.Unmarshal([]byte(f.Options), &options); err != nil {
 				// If unmarshal fails, treat as empty options
 				options = nil
 			}
 		}
-		
+
+		name, options, optionValues := translateField(f, options, locale)
+
 		fields[i] = FieldResponse{
-			Name:               f.Name,
+			Name:               name,
 			Type:               f.Type,
 			Required:           f.Required,
 			DataKey:            f.DataKey,
 			IsAddressComponent: f.IsAddressComponent,
 			PageIndex:          f.PageIndex,
 			Options:            options,
+			OptionValues:       optionValues,
+			OptionListID:       f.OptionListID,
+			Rotation:           f.Rotation,
+			Vertical:           f.Vertical,
+			ClassName:          f.ClassName,
+			Boxes:              toCheckboxBoxResponses(f.Boxes),
+			CheckMark:          f.CheckMark,
+			StampID:            f.StampID,
+			Opacity:            f.Opacity,
+			Sensitive:          f.Sensitive,
+			ViewRoles:          f.ViewRoles,
+			Flow:               f.Flow,
+			FontSize:           f.FontSize,
 			Position: &PositionResponse{
 				Top:    float64(f.PositionTop),
 				Left:   float64(f.PositionLeft),
 				Width:  float64(f.PositionWidth),
 				Height: float64(f.PositionHeight),
 			},
+			Translations: f.Translations,
 		}
 	}
 
@@ -270,7 +801,10 @@ func (h *TemplateHandler) toTemplateResponse(t gormmodels.Template, c *gin.Conte
 	baseURL := h.getBaseURL(c)
 	for i, svf := range t.SVGFiles {
 		fileURL := fmt.Sprintf("%s/api/files/svg/%s/page/%d", baseURL, t.ID, svf.PageIndex)
-		
+		if h.cdnService.Enabled() {
+			fileURL = h.cdnService.PageURL(t.ID, svf.PageIndex)
+		}
+
 		svgFiles[i] = SVGFileResponse{
 			ID:           svf.ID,
 			Filename:     svf.Filename,
@@ -282,7 +816,17 @@ func (h *TemplateHandler) toTemplateResponse(t gormmodels.Template, c *gin.Conte
 
 	// Generate SVGBackground URL dynamically
 	svgBackground := ""
-	if t.SVGBackground != "" {
+	if h.config.Server.SVGFilesOnly {
+		// Page 0's file stands in for the legacy background
+		for _, svf := range t.SVGFiles {
+			if svf.PageIndex == 0 {
+				svgBackground = fmt.Sprintf("%s/api/files/svg/%s/page/0", h.getBaseURL(c), t.ID)
+				if h.cdnService.Enabled() {
+					svgBackground = h.cdnService.PageURL(t.ID, 0)
+				}
+			}
+		}
+	} else if t.SVGBackground != "" {
 		// If it's already a full URL (http/https), use as is
 		if strings.HasPrefix(t.SVGBackground, "http://") || strings.HasPrefix(t.SVGBackground, "https://") {
 			svgBackground = t.SVGBackground
@@ -290,19 +834,48 @@ func (h *TemplateHandler) toTemplateResponse(t gormmodels.Template, c *gin.Conte
 			// If it's a relative path or just template ID, construct the URL
 			baseURL := h.getBaseURL(c)
 			svgBackground = fmt.Sprintf("%s/api/files/svg/%s", baseURL, t.ID)
+			if h.cdnService.Enabled() {
+				svgBackground = h.cdnService.PageURL(t.ID, 0)
+			}
 		}
 	}
 
+	// Only templates opted into public previews get a link.
+	publicURL := ""
+	if t.PublicPreview {
+		publicURL = fmt.Sprintf("%s/public/templates/%s/preview", h.publicBaseURL(c, t.WorkspaceID), t.ID)
+	}
+
 	return TemplateResponse{
-		ID:            t.ID,
-		DisplayName:   t.DisplayName,
-		Description:   t.Description,
-		Category:      t.Category,
-		PreviewImage:  t.PreviewImage,
-		SVGBackground: svgBackground,
-		DataInterface: t.DataInterface,
-		Fields:        fields,
-		SVGFiles:      svgFiles,
+		ID:                 t.ID,
+		WorkspaceID:        t.WorkspaceID,
+		ArchivedAt:         t.ArchivedAt,
+		PublicPreview:      t.PublicPreview,
+		PublicURL:          publicURL,
+		DisplayName:        t.DisplayName,
+		Description:        t.Description,
+		Category:           t.Category,
+		PreviewImage:       t.PreviewImage,
+		SVGBackground:      svgBackground,
+		DataInterface:      t.DataInterface,
+		Locale:             locale,
+		OptimizePDF:        t.OptimizePDF,
+		OptimizeDPI:        t.OptimizeDPI,
+		PDFMetadata:        t.PDFMetadata,
+		PrintOptions:       t.PrintOptions,
+		ThaiWordBreak:      t.ThaiWordBreak,
+		SuppressBackground: t.SuppressBackground,
+		ScriptFonts:        t.ScriptFonts,
+		CustomCSS:          t.CustomCSS,
+		DuplicatePolicy:    t.DuplicatePolicy,
+		ExpiryPolicy:       t.ExpiryPolicy,
+		ValidationWebhook:  t.ValidationWebhook,
+		Grid:               t.Grid,
+		Continuation:       t.Continuation,
+		EFiling:            t.EFiling,
+		Version:            t.Version,
+		Fields:             fields,
+		SVGFiles:           svgFiles,
 	}
 }
 
@@ -310,12 +883,14 @@ func (h *TemplateHandler) toGormFields(fields []FieldRequest) []gormmodels.Field
 	gormFields := make([]gormmodels.Field, len(fields))
 	for i, f := range fields {
 		var optionsJSON string
+		var keptOptions []int
 		if len(f.Options) > 0 {
 			// Filter out empty options
 			validOptions := make([]string, 0)
-			for _, opt := range f.Options {
+			for j, opt := range f.Options {
 				if strings.TrimSpace(opt) != "" {
 					validOptions = append(validOptions, strings.TrimSpace(opt))
+					keptOptions = append(keptOptions, j)
 				}
 			}
 			if len(validOptions) > 0 {
@@ -324,7 +899,7 @@ func (h *TemplateHandler) toGormFields(fields []FieldRequest) []gormmodels.Field
 				}
 			}
 		}
-		
+
 		gormFields[i] = gormmodels.Field{
 			Name:               f.Name,
 			Type:               f.Type,
@@ -333,6 +908,19 @@ func (h *TemplateHandler) toGormFields(fields []FieldRequest) []gormmodels.Field
 			IsAddressComponent: f.IsAddressComponent,
 			PageIndex:          f.PageIndex,
 			Options:            optionsJSON,
+			OptionListID:       f.OptionListID,
+			Rotation:           f.Rotation,
+			Vertical:           f.Vertical,
+			ClassName:          f.ClassName,
+			Boxes:              toCheckboxBoxes(f.Boxes),
+			CheckMark:          f.CheckMark,
+			StampID:            strings.TrimSpace(f.StampID),
+			Opacity:            f.Opacity,
+			Sensitive:          f.Sensitive,
+			ViewRoles:          viewRoles(f.ViewRoles),
+			Flow:               f.Flow,
+			FontSize:           f.FontSize,
+			Translations:       alignTranslations(f.Translations, keptOptions),
 		}
 
 		if f.Position != nil {
@@ -344,3 +932,175 @@ func (h *TemplateHandler) toGormFields(fields []FieldRequest) []gormmodels.Field
 	}
 	return gormFields
 }
+
+// viewRoles trims the roles a field is restricted to, dropping blanks and
+// repeats. A field left with none is visible to everyone.
+func viewRoles(roles []string) []string {
+	var kept []string
+	for _, role := range roles {
+		role = strings.TrimSpace(role)
+		if role != "" && !slices.Contains(kept, role) {
+			kept = append(kept, role)
+		}
+	}
+	return kept
+}
+
+// alignTranslations drops translated options whose base option was filtered
+// out as empty, keeping translations index-aligned with the stored options.
+func alignTranslations(translations map[string]gormmodels.FieldTranslation, keptOptions []int) map[string]gormmodels.FieldTranslation {
+	if len(translations) == 0 {
+		return nil
+	}
+
+	aligned := make(map[string]gormmodels.FieldTranslation, len(translations))
+	for locale, translation := range translations {
+		if len(translation.Options) > 0 {
+			options := make([]string, 0, len(keptOptions))
+			for _, j := range keptOptions {
+				if j < len(translation.Options) {
+					options = append(options, strings.TrimSpace(translation.Options[j]))
+				}
+			}
+			translation.Options = options
+		}
+		aligned[locale] = translation
+	}
+	return aligned
+}
+
+// fieldOptionLists loads the option lists referenced by fields. Lists that
+// cannot be loaded fall back to the field's stored options.
+func (h *TemplateHandler) fieldOptionLists(fields []gormmodels.Field) map[string]gormmodels.OptionList {
+	var ids []string
+	for _, f := range fields {
+		if f.OptionListID != "" {
+			ids = append(ids, f.OptionListID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	lists, err := h.optionListService.GetByIDs(ids)
+	if err != nil {
+		log.Printf("Warning: failed to load option lists: %v", err)
+		return nil
+	}
+	return lists
+}
+
+// checkOptionLists verifies that every referenced option list exists and
+// may be used by the workspace.
+func (h *TemplateHandler) checkOptionLists(fields []gormmodels.Field, workspaceID string) error {
+	var ids []string
+	for _, f := range fields {
+		if f.OptionListID != "" {
+			ids = append(ids, f.OptionListID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	lists, err := h.optionListService.GetVisibleByIDs(ids, workspaceID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, ok := lists[id]; !ok {
+			return fmt.Errorf("option list %s not found", id)
+		}
+	}
+	return nil
+}
+
+// checkDataKeys verifies that nested DataKeys such as "children[2].name" are
+// well-formed paths.
+func checkDataKeys(fields []gormmodels.Field) error {
+	for _, f := range fields {
+		if !utils.IsDataPath(f.DataKey) {
+			continue
+		}
+		if _, err := utils.ParseDataPath(f.DataKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkValidationWebhookPolicy requires an http(s) webhook URL and fills in
+// the default failure mode. Settings without a URL are cleared.
+func checkValidationWebhookPolicy(template *gormmodels.Template) error {
+	webhook := &template.ValidationWebhook
+	if webhook.URL == "" {
+		*webhook = gormmodels.ValidationWebhook{}
+		return nil
+	}
+
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("url must be an http or https URL")
+	}
+
+	if webhook.FailureMode == "" {
+		webhook.FailureMode = gormmodels.WebhookFailOpen
+	}
+	return nil
+}
+
+// checkEFilingExport verifies that the e-filing mapping only refers to
+// template fields and writes well-formed XML.
+func checkEFilingExport(template *gormmodels.Template) error {
+	dataKeys := make(map[string]bool, len(template.Fields))
+	for _, f := range template.Fields {
+		dataKeys[f.DataKey] = true
+	}
+	return services.CheckEFilingExport(template.EFiling, dataKeys)
+}
+
+// checkScriptFonts requires known script names and non-empty font stacks,
+// trimming the stacks. Empty settings are cleared.
+func checkScriptFonts(template *gormmodels.Template) error {
+	if len(template.ScriptFonts) == 0 {
+		template.ScriptFonts = nil
+		return nil
+	}
+
+	for script, stack := range template.ScriptFonts {
+		if !utils.IsScript(script) {
+			return fmt.Errorf("unknown script %q", script)
+		}
+		stack = strings.TrimSpace(stack)
+		if stack == "" || len(stack) > 200 {
+			return fmt.Errorf("font stack for %s must be 1 to 200 characters", script)
+		}
+		template.ScriptFonts[script] = stack
+	}
+	return nil
+}
+
+// checkDuplicatePolicy verifies that duplicate keys are template fields and
+// defaults the action to flagging.
+func checkDuplicatePolicy(template *gormmodels.Template) error {
+	policy := &template.DuplicatePolicy
+	if len(policy.Keys) == 0 {
+		*policy = gormmodels.DuplicatePolicy{}
+		return nil
+	}
+
+	dataKeys := make(map[string]bool, len(template.Fields))
+	for _, f := range template.Fields {
+		dataKeys[f.DataKey] = true
+	}
+	for _, key := range policy.Keys {
+		if !dataKeys[key] {
+			return fmt.Errorf("duplicate key %s is not a field of the template", key)
+		}
+	}
+
+	if policy.Action == "" {
+		policy.Action = gormmodels.DuplicateFlag
+	}
+	return nil
+}
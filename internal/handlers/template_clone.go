@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Clone copies the template onto revised page backgrounds. The multipart form
+// carries the new backgrounds as "svgs" and, in the same order, the
+// "pageIndexes" they replace (0, 1, ... in order without them), plus an
+// optional "displayName" for the clone. Fields on replaced pages are rescaled
+// to the new backgrounds' size; the response reports the scale of each page.
+func (h *ShareHandler) Clone(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form", "details": err.Error()})
+		return
+	}
+
+	files := form.File["svgs"]
+	indexes := form.Value["pageIndexes"]
+	if len(indexes) > 0 && len(indexes) != len(files) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Each file needs a page index", "details": fmt.Sprintf("%d files, %d pageIndexes", len(files), len(indexes))})
+		return
+	}
+
+	pages := make([]services.PageUpload, len(files))
+	for i, header := range files {
+		pageIndex := i
+		if len(indexes) > 0 {
+			if pageIndex, err = strconv.Atoi(indexes[i]); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index", "details": indexes[i]})
+				return
+			}
+		}
+		pages[i] = services.PageUpload{Header: header, PageIndex: pageIndex}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	result, err := h.shareService.CloneWithBackgrounds(ctx, c.Param("id"), c.GetString(auth.ContextWorkspaceID), c.PostForm("displayName"), pages)
+	if err != nil {
+		writeServiceError(c, "Failed to clone template", err)
+		return
+	}
+
+	h.audit(c, "template.clone", c.Param("id")+" -> "+result.Template.ID)
+
+	c.JSON(http.StatusCreated, result)
+}
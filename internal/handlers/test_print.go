@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testPrintScaleMM is the length of the scale bar printed on test pages, long
+// enough to show a printer that scales the page to fit.
+const testPrintScaleMM = 100
+
+// TestPrint prints every page of the template without its background, with
+// each field box outlined where it prints and filled with its DataKey in the
+// field's font, and millimetre rulers along the edges. Printed on the real
+// pre-printed form, it shows whether fields line up before any submission is
+// printed. Calibrations, safe margins and bleed apply as in generated PDFs.
+func (h *PDFHandler) TestPrint(c *gin.Context) {
+	template, err := h.templateService.GetByID(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch template", err)
+		return
+	}
+
+	if template == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	resolved, err := h.resolveTemplate(template)
+	if err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+
+	fields := make([]gormmodels.Field, len(resolved.Fields))
+	copy(fields, resolved.Fields)
+	applyCalibrations(fields, resolved.Calibrations)
+	applySafeMargins(fields, resolved.PrintOptions)
+
+	htmlContent := applyPrintLayout(testPrintHTML(resolved, fields), resolved.PrintOptions)
+	pdfBytes, err := h.htmlToPDF(c.Request.Context(), htmlContent, printPaper(resolved.PrintOptions), h.Deterministic)
+	if err != nil {
+		writeGenerationError(c, err)
+		return
+	}
+	pdfBytes = h.normalizePDF(c, pdfBytes, h.Deterministic)
+
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-test-print.pdf"`, template.ID))
+	c.Data(http.StatusOK, "application/pdf", pdfBytes)
+}
+
+// testPrintHTML lays out one blank page per template page with the field
+// outlines and rulers.
+func testPrintHTML(tmpl *gormmodels.Template, fields []gormmodels.Field) string {
+	pageCount := renderedPageCount(tmpl)
+
+	var pages strings.Builder
+	for pageIndex := 0; pageIndex < pageCount; pageIndex++ {
+		pages.WriteString(`<div class="page">`)
+		pages.WriteString(testPrintRulers(tmpl, pageIndex, pageCount))
+		for _, field := range fields {
+			if field.PageIndex != pageIndex {
+				continue
+			}
+			if len(field.Boxes) > 0 {
+				for _, box := range field.Boxes {
+					fmt.Fprintf(&pages, `<div class="box" style="top: %dpx; left: %dpx; width: %dpx; height: %dpx;"></div>`,
+						box.Top, box.Left, box.Width, box.Height)
+					fmt.Fprintf(&pages, `<div class="caption" style="top: %dpx; left: %dpx;">%s=%s</div>`,
+						box.Top+box.Height+1, box.Left, html.EscapeString(field.DataKey), html.EscapeString(box.Value))
+				}
+				continue
+			}
+			fmt.Fprintf(&pages, `<div class="caption" style="top: %dpx; left: %dpx;">%s</div>`,
+				max(field.PositionTop-9, 0), field.PositionLeft, html.EscapeString(fontSummary(field)))
+			fmt.Fprintf(&pages, `<div class="field" style="top: %dpx; left: %dpx; width: %dpx; height: %dpx; %s"><div class="field-text" style="%s">%s</div></div>`,
+				field.PositionTop, field.PositionLeft, field.PositionWidth, field.PositionHeight,
+				fieldFormatStyle(field), fieldTextStyle(field), html.EscapeString(field.DataKey))
+		}
+		pages.WriteString(`</div>`)
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="UTF-8">
+<style>
+@page { margin: 0; size: A4; }
+body { margin: 0; padding: 0; }
+.page { position: relative; width: %dpx; height: %dpx; page-break-after: always; overflow: hidden; }
+.page:last-child { page-break-after: auto; }
+svg { position: absolute; top: 0; left: 0; }
+line { stroke: #0070a0; stroke-width: 0.5; }
+.label { font: 6px sans-serif; fill: #0070a0; }
+.title { font: bold 7px sans-serif; fill: #0070a0; }
+.field { position: absolute; display: flex; align-items: flex-start; word-wrap: break-word; word-break: break-word; white-space: pre-wrap; overflow: hidden; padding-top: 2px; outline: 0.6px dashed #e02020; }
+.field-text { width: 100%%; text-align: left; }
+.box { position: absolute; outline: 0.6px dashed #e02020; }
+.caption { position: absolute; font: 6px sans-serif; color: #c01010; white-space: nowrap; }
+</style>
+</head>
+<body>
+%s</body>
+</html>`, pageWidthPx, pageHeightPx, pages.String())
+}
+
+// testPrintRulers draws millimetre rulers along the four edges of a page,
+// labelled every centimetre, and a scale bar to measure the printer's scaling.
+func testPrintRulers(tmpl *gormmodels.Template, pageIndex, pageCount int) string {
+	var svg strings.Builder
+	fmt.Fprintf(&svg, `<svg width="%d" height="%d" viewBox="0 0 %d %d" xmlns="http://www.w3.org/2000/svg">`,
+		pageWidthPx, pageHeightPx, pageWidthPx, pageHeightPx)
+
+	tick := func(mm int) float64 {
+		switch {
+		case mm%10 == 0:
+			return 8
+		case mm%5 == 0:
+			return 5
+		}
+		return 3
+	}
+	for mm := 1; float64(mm)*pxPerMM < pageWidthPx; mm++ {
+		x, length := float64(mm)*pxPerMM, tick(mm)
+		fmt.Fprintf(&svg, `<line x1="%.2f" y1="0" x2="%.2f" y2="%.0f"/>`, x, x, length)
+		fmt.Fprintf(&svg, `<line x1="%.2f" y1="%d" x2="%.2f" y2="%.0f"/>`, x, pageHeightPx, x, pageHeightPx-length)
+		if mm%10 == 0 {
+			fmt.Fprintf(&svg, `<text x="%.2f" y="15" class="label" text-anchor="middle">%d</text>`, x, mm)
+			fmt.Fprintf(&svg, `<text x="%.2f" y="%d" class="label" text-anchor="middle">%d</text>`, x, pageHeightPx-10, mm)
+		}
+	}
+	for mm := 1; float64(mm)*pxPerMM < pageHeightPx; mm++ {
+		y, length := float64(mm)*pxPerMM, tick(mm)
+		fmt.Fprintf(&svg, `<line x1="0" y1="%.2f" x2="%.0f" y2="%.2f"/>`, y, length, y)
+		fmt.Fprintf(&svg, `<line x1="%d" y1="%.2f" x2="%.0f" y2="%.2f"/>`, pageWidthPx, y, pageWidthPx-length, y)
+		if mm%10 == 0 {
+			fmt.Fprintf(&svg, `<text x="10" y="%.2f" class="label">%d</text>`, y+2, mm)
+			fmt.Fprintf(&svg, `<text x="%d" y="%.2f" class="label" text-anchor="end">%d</text>`, pageWidthPx-10, y+2, mm)
+		}
+	}
+
+	// The scale bar sits centred near the bottom edge, away from most fields.
+	length := testPrintScaleMM * pxPerMM
+	x0, y := (pageWidthPx-length)/2, float64(pageHeightPx-40)
+	fmt.Fprintf(&svg, `<line x1="%.2f" y1="%.0f" x2="%.2f" y2="%.0f"/>`, x0, y, x0+length, y)
+	for mm := 0; mm <= testPrintScaleMM; mm += 10 {
+		x := x0 + float64(mm)*pxPerMM
+		fmt.Fprintf(&svg, `<line x1="%.2f" y1="%.0f" x2="%.2f" y2="%.0f"/>`, x, y-4, x, y)
+	}
+	fmt.Fprintf(&svg, `<text x="%.2f" y="%.0f" class="label" text-anchor="middle">%d mm at 100%% scale</text>`, x0+length/2, y+9, testPrintScaleMM)
+
+	fmt.Fprintf(&svg, `<text x="24" y="30" class="title">%s - test print - page %d of %d</text>`,
+		html.EscapeString(tmpl.DisplayName), pageIndex+1, pageCount)
+	svg.WriteString(`</svg>`)
+	return svg.String()
+}
+
+// fontSummary describes the font settings of a field for its caption.
+func fontSummary(field gormmodels.Field) string {
+	fontSize := field.FontSize
+	if fontSize <= 0 {
+		fontSize = defaultFontSize
+	}
+
+	parts := []string{field.DataKey, cssValue(field.FontFamily, "Times New Roman"), fmt.Sprintf("%dpt", fontSize)}
+	if weight := cssValue(field.FontWeight, "normal"); weight != "normal" {
+		parts = append(parts, weight)
+	}
+	if style := cssValue(field.FontStyle, "normal"); style != "normal" {
+		parts = append(parts, style)
+	}
+	if field.Rotation != 0 {
+		parts = append(parts, fmt.Sprintf("%d°", field.Rotation))
+	}
+	if field.Vertical {
+		parts = append(parts, "vertical")
+	}
+	return strings.Join(parts, " · ")
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+)
+
+// segmentThaiData returns copies of the field values with zero-width spaces
+// between Thai words. HTML values are segmented outside of tags only.
+func segmentThaiData(data map[string]interface{}, htmlData map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	segmentedData, _ := segmentThaiValue(data).(map[string]interface{})
+
+	var segmentedHTML map[string]interface{}
+	if htmlData != nil {
+		segmentedHTML = make(map[string]interface{}, len(htmlData))
+		for key, value := range htmlData {
+			if str, ok := value.(string); ok {
+				value = segmentThaiHTML(str)
+			}
+			segmentedHTML[key] = value
+		}
+	}
+
+	return segmentedData, segmentedHTML
+}
+
+// segmentThaiValue segments the strings of a value, descending into nested
+// objects and arrays.
+func segmentThaiValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return utils.SegmentThai(v)
+	case map[string]interface{}:
+		segmented := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			segmented[key] = segmentThaiValue(item)
+		}
+		return segmented
+	case []interface{}:
+		segmented := make([]interface{}, len(v))
+		for i, item := range v {
+			segmented[i] = segmentThaiValue(item)
+		}
+		return segmented
+	}
+	return value
+}
+
+func segmentThaiHTML(html string) string {
+	var out strings.Builder
+	for html != "" {
+		tagStart := strings.IndexByte(html, '<')
+		if tagStart < 0 {
+			out.WriteString(utils.SegmentThai(html))
+			break
+		}
+		out.WriteString(utils.SegmentThai(html[:tagStart]))
+
+		tagEnd := strings.IndexByte(html[tagStart:], '>')
+		if tagEnd < 0 {
+			out.WriteString(html[tagStart:])
+			break
+		}
+		out.WriteString(html[tagStart : tagStart+tagEnd+1])
+		html = html[tagStart+tagEnd+1:]
+	}
+	return out.String()
+}
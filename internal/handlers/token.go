@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TokenHandler struct {
+	tokenService *auth.TokenService
+	auditService *services.AuditService
+}
+
+func NewTokenHandler(tokenService *auth.TokenService, auditService *services.AuditService) *TokenHandler {
+	return &TokenHandler{
+		tokenService: tokenService,
+		auditService: auditService,
+	}
+}
+
+type CreateTokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expiresInDays" binding:"min=0,max=365"`
+}
+
+func (h *TokenHandler) List(c *gin.Context) {
+	tokens, err := h.tokenService.GetByUserID(c.GetString(auth.ContextUserID))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch tokens", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens, "availableScopes": auth.KnownScopes})
+}
+
+func (h *TokenHandler) Create(c *gin.Context) {
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if missing := ungrantedScopes(c, req.Scopes); len(missing) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Token cannot grant scopes it does not have", "details": strings.Join(missing, ", ")})
+		return
+	}
+
+	token := &gormmodels.PersonalAccessToken{
+		UserID:      c.GetString(auth.ContextUserID),
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		Name:        req.Name,
+		Scopes:      req.Scopes,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	plaintext, err := h.tokenService.Create(token)
+	if err != nil {
+		writeServiceError(c, "Failed to create token", err)
+		return
+	}
+
+	h.audit(c, "token.create", token.ID)
+
+	c.JSON(http.StatusCreated, gin.H{"token": token, "secret": plaintext})
+}
+
+func (h *TokenHandler) Rotate(c *gin.Context) {
+	token, ok := h.loadToken(c)
+	if !ok {
+		return
+	}
+
+	if token.RevokedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Token has been revoked"})
+		return
+	}
+
+	// Rotating hands out the token's secret, so a token may not rotate one
+	// that can do more than itself.
+	if missing := ungrantedScopes(c, token.Scopes); len(missing) > 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Token cannot grant scopes it does not have", "details": strings.Join(missing, ", ")})
+		return
+	}
+
+	plaintext, err := h.tokenService.Rotate(token)
+	if err != nil {
+		writeServiceError(c, "Failed to rotate token", err)
+		return
+	}
+
+	h.audit(c, "token.rotate", token.ID)
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "secret": plaintext})
+}
+
+func (h *TokenHandler) Revoke(c *gin.Context) {
+	token, ok := h.loadToken(c)
+	if !ok {
+		return
+	}
+
+	if token.RevokedAt == nil {
+		if err := h.tokenService.Revoke(token); err != nil {
+			writeServiceError(c, "Failed to revoke token", err)
+			return
+		}
+		h.audit(c, "token.revoke", token.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked successfully"})
+}
+
+func (h *TokenHandler) AuditLogs(c *gin.Context) {
+	logs, err := h.auditService.GetByUserID(c.GetString(auth.ContextUserID), 100)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch audit logs", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, logs)
+}
+
+func (h *TokenHandler) loadToken(c *gin.Context) (*gormmodels.PersonalAccessToken, bool) {
+	token, err := h.tokenService.GetForUser(c.GetString(auth.ContextUserID), c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to fetch token", err)
+		return nil, false
+	}
+
+	if token == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Token not found"})
+		return nil, false
+	}
+
+	return token, true
+}
+
+// ungrantedScopes lists the scopes the request may not act with. A personal
+// access token can only hand out scopes it holds itself; sessions may grant
+// any scope.
+func ungrantedScopes(c *gin.Context, scopes []string) []string {
+	var missing []string
+	for _, scope := range scopes {
+		if !auth.HasScope(c, scope) {
+			missing = append(missing, scope)
+		}
+	}
+	return missing
+}
+
+func (h *TokenHandler) audit(c *gin.Context, action, resource string) {
+	h.auditService.Record(&gormmodels.AuditLog{
+		WorkspaceID: c.GetString(auth.ContextWorkspaceID),
+		UserID:      c.GetString(auth.ContextUserID),
+		TokenID:     c.GetString(auth.ContextTokenID),
+		Action:      action,
+		Resource:    resource,
+		IPAddress:   c.ClientIP(),
+	})
+}
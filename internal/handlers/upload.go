@@ -1,13 +1,16 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
 	"github.com/dhanavadh/fastfill-backend/internal/services"
 	"github.com/dhanavadh/fastfill-backend/internal/config"
 
@@ -15,16 +18,20 @@ import (
 )
 
 type UploadHandler struct {
-	uploadService   *services.UploadService
-	templateService *services.TemplateService
-	config          *config.Config
+	uploadService     *services.UploadService
+	templateService   *services.TemplateService
+	previewService    *services.PreviewService
+	tempUploadService *services.TempUploadService
+	config            *config.Config
 }
 
-func NewUploadHandler(uploadService *services.UploadService, templateService *services.TemplateService, cfg *config.Config) *UploadHandler {
+func NewUploadHandler(uploadService *services.UploadService, templateService *services.TemplateService, previewService *services.PreviewService, tempUploadService *services.TempUploadService, cfg *config.Config) *UploadHandler {
 	return &UploadHandler{
-		uploadService:   uploadService,
-		templateService: templateService,
-		config:          cfg,
+		uploadService:     uploadService,
+		templateService:   templateService,
+		previewService:    previewService,
+		tempUploadService: tempUploadService,
+		config:            cfg,
 	}
 }
 
@@ -86,7 +93,7 @@ func (h *UploadHandler) UploadSVG(c *gin.Context) {
 
 	svgFile, err := h.uploadService.UploadSVGWithPage(ctx, templateID, file, header, pageIndex)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload file"})
+		writeServiceError(c, "Failed to upload file", err)
 		return
 	}
 
@@ -98,7 +105,7 @@ func (h *UploadHandler) UploadSVG(c *gin.Context) {
 	if pageIndex == 0 {
 		template, err := h.templateService.GetByID(templateID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update template"})
+			writeServiceError(c, "Failed to update template", err)
 			return
 		}
 
@@ -122,16 +129,189 @@ func (h *UploadHandler) UploadSVG(c *gin.Context) {
 	})
 }
 
+// UploadSVGs uploads several pages in one request. The multipart form carries
+// the files as "svgs" and, in the same order, their "pageIndexes". Either
+// every page is replaced or none is.
+func (h *UploadHandler) UploadSVGs(c *gin.Context) {
+	templateID := c.Param("templateId")
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form", "details": err.Error()})
+		return
+	}
+
+	files := form.File["svgs"]
+	indexes := form.Value["pageIndexes"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No files uploaded"})
+		return
+	}
+	if len(indexes) != len(files) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Each file needs a page index", "details": fmt.Sprintf("%d files, %d pageIndexes", len(files), len(indexes))})
+		return
+	}
+
+	pages := make([]services.PageUpload, len(files))
+	for i, header := range files {
+		pageIndex, err := strconv.Atoi(indexes[i])
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index", "details": indexes[i]})
+			return
+		}
+		pages[i] = services.PageUpload{Header: header, PageIndex: pageIndex}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	svgFiles, err := h.uploadService.UploadPages(ctx, templateID, pages)
+	if err != nil {
+		writeServiceError(c, "Failed to upload files", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Files uploaded successfully",
+		"files":   h.uploadedPages(c, templateID, svgFiles),
+	})
+}
+
+// UploadPDF turns every page of a PDF, uploaded as "pdf", into a page
+// background, so templates can be built on forms that only exist as PDFs.
+// The first page becomes "pageIndex" (default 0) and the others follow it;
+// the pages' current backgrounds are replaced all-or-nothing.
+func (h *UploadHandler) UploadPDF(c *gin.Context) {
+	templateID := c.Param("templateId")
+
+	if h.uploadService.PageConverter == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PDF backgrounds are not configured"})
+		return
+	}
+
+	limit := h.uploadService.PDFLimit()
+	// Leave room for the multipart envelope around the PDF.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+64<<10)
+
+	file, header, err := c.Request.FormFile("pdf")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	pdf, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file", "details": err.Error()})
+		return
+	}
+	if int64(len(pdf)) > limit {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File is too large", "details": fmt.Sprintf("the limit is %d KB", limit>>10)})
+		return
+	}
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File must be a PDF"})
+		return
+	}
+
+	pageIndex := 0
+	if pageIndexStr := c.PostForm("pageIndex"); pageIndexStr != "" {
+		if pageIndex, err = strconv.Atoi(pageIndexStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index", "details": pageIndexStr})
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	svgFiles, err := h.uploadService.UploadPDF(ctx, templateID, header.Filename, pdf, pageIndex)
+	if err != nil {
+		writeServiceError(c, "Failed to convert PDF", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "PDF converted successfully",
+		"files":   h.uploadedPages(c, templateID, svgFiles),
+	})
+}
+
+// uploadedPages describes newly uploaded pages, keeping the legacy SVG
+// background in step with page 0 as UploadSVG does.
+func (h *UploadHandler) uploadedPages(c *gin.Context, templateID string, svgFiles []gormmodels.SVGFile) []gin.H {
+	baseURL := h.getBaseURL(c)
+	uploaded := make([]gin.H, len(svgFiles))
+	for i, svgFile := range svgFiles {
+		uploaded[i] = gin.H{
+			"filename":     svgFile.Filename,
+			"originalName": svgFile.OriginalName,
+			"size":         svgFile.FileSize,
+			"pageIndex":    svgFile.PageIndex,
+			"url":          fmt.Sprintf("%s/api/files/svg/%s/page/%d", baseURL, templateID, svgFile.PageIndex),
+			"gcsPath":      svgFile.GCSPath,
+		}
+
+		if svgFile.PageIndex == 0 {
+			template, err := h.templateService.GetByID(templateID)
+			if err == nil && template != nil && template.SVGBackground != templateID {
+				template.SVGBackground = templateID
+				if err := h.templateService.Update(template); err != nil {
+					fmt.Printf("Warning: Failed to update template SVG background: %v\n", err)
+				}
+			}
+		}
+	}
+	return uploaded
+}
+
 func (h *UploadHandler) GetSVG(c *gin.Context) {
 	templateID := c.Param("id")
 
+	expiresAt := time.Now().Add(h.uploadService.URLTTL())
 	signedURL, err := h.uploadService.GetSVGFileURL(templateID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SVG file"})
+		writeServiceError(c, "Failed to fetch SVG file", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"url": signedURL, "expiresAt": expiresAt})
+}
+
+// refreshMargin is how long before expiry clients are told to refresh signed
+// URLs, at most a fifth of their lifetime.
+const refreshMargin = 5 * time.Minute
+
+// AssetURLs signs fresh URLs for every page background of a template, for
+// editors that load pages straight from storage and must replace the URLs
+// before they expire. refreshAt says when to call again.
+func (h *UploadHandler) AssetURLs(c *gin.Context) {
+	urls, err := h.uploadService.SignedPageURLs(c.Param("id"))
+	if err != nil {
+		writeServiceError(c, "Failed to sign page URLs", err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"url": signedURL})
+	ttl := h.uploadService.URLTTL()
+	expiresAt := time.Now().Add(ttl)
+	for _, url := range urls {
+		if url.ExpiresAt.Before(expiresAt) {
+			expiresAt = url.ExpiresAt
+		}
+	}
+
+	margin := refreshMargin
+	if ttl/5 < margin {
+		margin = ttl / 5
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"templateId": c.Param("id"),
+		"urls":       urls,
+		"expiresAt":  expiresAt,
+		"refreshAt":  expiresAt.Add(-margin),
+	})
 }
 
 func (h *UploadHandler) ServeSVG(c *gin.Context) {
@@ -143,15 +323,46 @@ func (h *UploadHandler) ServeSVG(c *gin.Context) {
 		return
 	}
 
+	if h.config.GCS.ProxyAssets {
+		h.proxySVG(c, svgFile)
+		return
+	}
+
 	signedURL, err := h.uploadService.GetSVGFileURL(templateID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get file"})
+		writeServiceError(c, "Failed to get file", err)
 		return
 	}
 
+	redirectToSignedURL(c, signedURL)
+}
+
+// redirectToSignedURL redirects to a signed storage URL. The redirect is
+// not cached, so clients come back for a fresh signature instead of reusing
+// an expired one.
+func redirectToSignedURL(c *gin.Context, signedURL string) {
+	c.Header("Cache-Control", "no-store")
 	c.Redirect(http.StatusTemporaryRedirect, signedURL)
 }
 
+// proxySVG serves a page background through the API, for deployments whose
+// asset URLs must not expire.
+func (h *UploadHandler) proxySVG(c *gin.Context, svgFile *gormmodels.SVGFile) {
+	content, err := h.uploadService.GetSVGFileContent(svgFile)
+	if err != nil {
+		writeServiceError(c, "Failed to get file", err)
+		return
+	}
+
+	if h.config.CDN.AssetHost != "" {
+		// The CDN is purged when a page is replaced, so it can keep pages longer than browsers.
+		c.Header("Cache-Control", "public, max-age=3600, s-maxage=604800")
+	} else {
+		c.Header("Cache-Control", "public, max-age=3600")
+	}
+	c.Data(http.StatusOK, "image/svg+xml", content)
+}
+
 func (h *UploadHandler) GetSVGContent(templateID, svgID string) ([]byte, error) {
 	return h.uploadService.GetSVGContent(templateID, svgID)
 }
@@ -170,7 +381,7 @@ func (h *UploadHandler) DeleteSVGFile(c *gin.Context) {
 
 	err = h.uploadService.DeleteSVGFileByID(ctx, uint(id))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete SVG file"})
+		writeServiceError(c, "Failed to delete SVG file", err)
 		return
 	}
 
@@ -180,6 +391,12 @@ func (h *UploadHandler) DeleteSVGFile(c *gin.Context) {
 func (h *UploadHandler) ServeSVGByPage(c *gin.Context) {
 	templateID := c.Param("templateId")
 	pageIndexStr := c.Param("pageIndex")
+
+	// gin cannot route "/page/:index.png" separately, so PNG previews share this route
+	if strings.HasSuffix(pageIndexStr, ".png") {
+		h.servePagePNG(c, templateID, strings.TrimSuffix(pageIndexStr, ".png"))
+		return
+	}
 	
 	pageIndex, err := strconv.Atoi(pageIndexStr)
 	if err != nil {
@@ -187,13 +404,54 @@ func (h *UploadHandler) ServeSVGByPage(c *gin.Context) {
 		return
 	}
 
+	if h.config.GCS.ProxyAssets {
+		svgFile, err := h.uploadService.GetSVGFileByPage(templateID, pageIndex)
+		if err != nil || svgFile == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "SVG file not found for this page"})
+			return
+		}
+		h.proxySVG(c, svgFile)
+		return
+	}
+
 	signedURL, err := h.uploadService.GetSVGFileURLByPage(templateID, pageIndex)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "SVG file not found for this page"})
 		return
 	}
 
-	c.Redirect(http.StatusTemporaryRedirect, signedURL)
+	redirectToSignedURL(c, signedURL)
+}
+
+// servePagePNG serves a page background rasterized at ?width= pixels.
+func (h *UploadHandler) servePagePNG(c *gin.Context, templateID, pageIndexStr string) {
+	pageIndex, err := strconv.Atoi(pageIndexStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page index"})
+		return
+	}
+
+	width, _ := strconv.Atoi(c.Query("width"))
+
+	preview, err := h.previewService.PagePNG(c.Request.Context(), templateID, pageIndex, width, c.GetHeader("If-None-Match"))
+	if err != nil {
+		writeServiceError(c, "Failed to render page preview", err)
+		return
+	}
+
+	if preview == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SVG file not found for this page"})
+		return
+	}
+
+	c.Header("ETag", preview.ETag)
+	c.Header("Cache-Control", "public, max-age=86400")
+	if preview.Data == nil {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", preview.Data)
 }
 
 func (h *UploadHandler) ServeLegacySVG(c *gin.Context) {
@@ -212,6 +470,11 @@ func (h *UploadHandler) ServeLegacySVG(c *gin.Context) {
 	
 	// Serve the SVG content directly
 	c.Header("Content-Type", "image/svg+xml")
-	c.Header("Cache-Control", "public, max-age=3600")
+	if h.config.CDN.AssetHost != "" {
+		// The CDN is purged when a page is replaced, so it can keep pages longer than browsers.
+		c.Header("Cache-Control", "public, max-age=3600, s-maxage=604800")
+	} else {
+		c.Header("Cache-Control", "public, max-age=3600")
+	}
 	c.Data(http.StatusOK, "image/svg+xml", content)
 }
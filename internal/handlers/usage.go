@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UsageHandler struct {
+	usageService *services.UsageService
+}
+
+func NewUsageHandler(usageService *services.UsageService) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+	}
+}
+
+type VisionQuotaRequest struct {
+	MonthlyCap int `json:"monthlyCap" binding:"min=0"`
+}
+
+// GetUsage reports metered usage for the caller's workspace and a month,
+// by default the current one (YYYY-MM). A workspaceId naming another
+// workspace is reported as missing.
+func (h *UsageHandler) GetUsage(c *gin.Context) {
+	workspaceID := c.GetString(auth.ContextWorkspaceID)
+	if requested := c.Query("workspaceId"); requested != "" && requested != workspaceID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+
+	period := c.DefaultQuery("period", services.UsagePeriod(time.Now()))
+	if _, err := time.Parse("2006-01", period); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period, expected YYYY-MM"})
+		return
+	}
+
+	report, err := h.usageService.Report(workspaceID, period)
+	if err != nil {
+		writeServiceError(c, "Failed to fetch usage", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// SetVisionQuota overrides the monthly OCR cap of the caller's workspace.
+func (h *UsageHandler) SetVisionQuota(c *gin.Context) {
+	if c.Param("id") != c.GetString(auth.ContextWorkspaceID) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Workspace not found"})
+		return
+	}
+
+	var req VisionQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	if err := h.usageService.SetVisionCap(c.Param("id"), req.MonthlyCap); err != nil {
+		writeServiceError(c, "Failed to update vision quota", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"workspaceId": c.Param("id"), "visionMonthlyCap": req.MonthlyCap})
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// expectedTemplateVersion returns the template version an update is based on,
+// from the If-Match header or the body's version. Zero means the client sent
+// no precondition.
+func expectedTemplateVersion(c *gin.Context, bodyVersion int) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return bodyVersion, nil
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(header, "W/"), `"`))
+	if err != nil || version <= 0 {
+		return 0, fmt.Errorf("expected a template version, got %s", header)
+	}
+	return version, nil
+}
+
+func setVersionETag(c *gin.Context, version int) {
+	c.Header("ETag", fmt.Sprintf(`"%d"`, version))
+}
+
+// writeVersionConflict answers a failed precondition with the template's
+// current state so the client can merge and retry.
+func (h *TemplateHandler) writeVersionConflict(c *gin.Context, templateID string) {
+	current, err := h.templateService.GetByID(templateID)
+	if err != nil || current == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Template was modified by another editor"})
+		return
+	}
+
+	setVersionETag(c, current.Version)
+	c.JSON(http.StatusConflict, gin.H{
+		"error":   "Template was modified by another editor",
+		"current": h.toTemplateResponse(*current, c),
+	})
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/auth"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RecordDebug records the request and response of workspaces with an open
+// debug recording window. Bodies are sanitized before they are stored;
+// uploads are described by their form fields and file sizes only.
+func RecordDebug(recordingService *services.DebugRecordingService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		workspaceID := c.GetString(auth.ContextWorkspaceID)
+		if !recordingService.Active(workspaceID) {
+			c.Next()
+			return
+		}
+
+		started := time.Now()
+		requestType := c.GetHeader("Content-Type")
+		multipart := strings.HasPrefix(requestType, "multipart/")
+
+		request := &capture{}
+		if !multipart && c.Request.Body != nil {
+			c.Request.Body = teeBody{Reader: io.TeeReader(c.Request.Body, request), Closer: c.Request.Body}
+		}
+		response := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = response
+
+		c.Next()
+
+		sensitiveKeys := recordingService.SensitiveKeys(requestTemplateID(requestType, request))
+		recording := &gormmodels.DebugRecording{
+			WorkspaceID:  workspaceID,
+			UserID:       c.GetString(auth.ContextUserID),
+			Method:       c.Request.Method,
+			Path:         c.Request.URL.RequestURI(),
+			Status:       response.Status(),
+			DurationMS:   time.Since(started).Milliseconds(),
+			RequestType:  requestType,
+			ResponseType: response.Header().Get("Content-Type"),
+			ResponseBody: services.SanitizeRecordedBody(response.Header().Get("Content-Type"), response.body.data, response.body.size, sensitiveKeys),
+		}
+		if multipart {
+			recording.RequestBody = describeMultipart(c)
+		} else {
+			recording.RequestBody = services.SanitizeRecordedBody(requestType, request.data, request.size, sensitiveKeys)
+		}
+		recordingService.Record(recording)
+	}
+}
+
+// capture keeps the first MaxRecordedBody bytes written to it and counts the rest.
+type capture struct {
+	data []byte
+	size int64
+}
+
+func (b *capture) Write(p []byte) (int, error) {
+	if room := services.MaxRecordedBody - len(b.data); room > 0 {
+		b.data = append(b.data, p[:min(room, len(p))]...)
+	}
+	b.size += int64(len(p))
+	return len(p), nil
+}
+
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
+
+type capturingWriter struct {
+	gin.ResponseWriter
+	body capture
+}
+
+func (w *capturingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.body.Write(p[:n])
+	return n, err
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	w.body.Write([]byte(s[:n]))
+	return n, err
+}
+
+// requestTemplateID returns the templateId of a JSON request body, whose
+// sensitive fields are then redacted.
+func requestTemplateID(contentType string, request *capture) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "application/json" || int64(len(request.data)) != request.size {
+		return ""
+	}
+	var body struct {
+		TemplateID string `json:"templateId"`
+	}
+	json.Unmarshal(request.data, &body)
+	return body.TemplateID
+}
+
+// describeMultipart lists the form values and uploaded files of a multipart
+// request the handler parsed, without their contents.
+func describeMultipart(c *gin.Context) string {
+	form := c.Request.MultipartForm
+	if form == nil {
+		return fmt.Sprintf("[%s, %d bytes, not parsed]", c.GetHeader("Content-Type"), c.Request.ContentLength)
+	}
+	var parts []string
+	for name, values := range form.Value {
+		parts = append(parts, fmt.Sprintf("%s: %d value(s)", name, len(values)))
+	}
+	for name, files := range form.File {
+		for _, file := range files {
+			parts = append(parts, fmt.Sprintf("%s: file (%s, %d bytes)", name, file.Header.Get("Content-Type"), file.Size))
+		}
+	}
+	return "[multipart: " + strings.Join(parts, "; ") + "]"
+}
@@ -0,0 +1,572 @@
+package middleware
+
+// errorCodes gives the error messages handlers write a stable code, keyed by
+// their English text. Clients handle errors by code, which stays the same
+// when the message is reworded or translated.
+var errorCodes = map[string]string{
+	"A profileId or a mapping is required":                           "PROFILE_OR_MAPPING_REQUIRED",
+	"Admin access is not enabled":                                    "ADMIN_ACCESS_IS_NOT_ENABLED",
+	"Assignment not found":                                           "ASSIGNMENT_NOT_FOUND",
+	"Authentication is not configured":                               "AUTHENTICATION_IS_NOT_CONFIGURED",
+	"Authentication required":                                        "AUTHENTICATION_REQUIRED",
+	"Consent is required to store a signature":                       "CONSENT_IS_REQUIRED_TO_STORE_A_SIGNATURE",
+	"Database error":                                                 "DATABASE_ERROR",
+	"dataKey is required":                                            "DATA_KEY_REQUIRED",
+	"Debug recording not found":                                      "DEBUG_RECORDING_NOT_FOUND",
+	"Document not found":                                             "DOCUMENT_NOT_FOUND",
+	"Duplicate submission":                                           "DUPLICATE_SUBMISSION",
+	"Each file needs a page index":                                   "EACH_FILE_NEEDS_A_PAGE_INDEX",
+	"Exactly one template definition is required":                    "EXACTLY_ONE_TEMPLATE_DEFINITION_IS_REQUIRED",
+	"Export requires access to every field":                          "EXPORT_REQUIRES_ACCESS_TO_EVERY_FIELD",
+	"Failed to accept template share":                                "FAILED_TO_ACCEPT_TEMPLATE_SHARE",
+	"Failed to acquire template lock":                                "FAILED_TO_ACQUIRE_TEMPLATE_LOCK",
+	"Failed to analyze submissions":                                  "FAILED_TO_ANALYZE_SUBMISSIONS",
+	"Failed to apply locked values":                                  "FAILED_TO_APPLY_LOCKED_VALUES",
+	"Failed to archive template":                                     "FAILED_TO_ARCHIVE_TEMPLATE",
+	"Failed to assign form submission":                               "FAILED_TO_ASSIGN_FORM_SUBMISSION",
+	"Failed to attach upload":                                        "FAILED_TO_ATTACH_UPLOAD",
+	"Failed to build e-filing payload":                               "FAILED_TO_BUILD_E_FILING_PAYLOAD",
+	"Failed to check for duplicate submissions":                      "FAILED_TO_CHECK_FOR_DUPLICATE_SUBMISSIONS",
+	"Failed to check template access":                                "FAILED_TO_CHECK_TEMPLATE_ACCESS",
+	"Failed to clone template":                                       "FAILED_TO_CLONE_TEMPLATE",
+	"Failed to compare scan":                                         "FAILED_TO_COMPARE_SCAN",
+	"Failed to compare submission revisions":                         "FAILED_TO_COMPARE_SUBMISSION_REVISIONS",
+	"Failed to complete assignment":                                  "FAILED_TO_COMPLETE_ASSIGNMENT",
+	"Failed to complete login":                                       "FAILED_TO_COMPLETE_LOGIN",
+	"Failed to confirm document number":                              "FAILED_TO_CONFIRM_DOCUMENT_NUMBER",
+	"Failed to contact identity provider":                            "FAILED_TO_CONTACT_IDENTITY_PROVIDER",
+	"Failed to convert marks":                                        "FAILED_TO_CONVERT_MARKS",
+	"Failed to convert PDF":                                          "FAILED_TO_CONVERT_PDF",
+	"Failed to copy template":                                        "FAILED_TO_COPY_TEMPLATE",
+	"Failed to create fill link":                                     "FAILED_TO_CREATE_FILL_LINK",
+	"Failed to create import profile":                                "FAILED_TO_CREATE_IMPORT_PROFILE",
+	"Failed to create integration":                                   "FAILED_TO_CREATE_INTEGRATION",
+	"Failed to create option list":                                   "FAILED_TO_CREATE_OPTION_LIST",
+	"Failed to create template":                                      "FAILED_TO_CREATE_TEMPLATE",
+	"Failed to create template include":                              "FAILED_TO_CREATE_TEMPLATE_INCLUDE",
+	"Failed to create token":                                         "FAILED_TO_CREATE_TOKEN",
+	"Failed to create workspace":                                     "FAILED_TO_CREATE_WORKSPACE",
+	"Failed to delete assignment":                                    "FAILED_TO_DELETE_ASSIGNMENT",
+	"Failed to delete calibration":                                   "FAILED_TO_DELETE_CALIBRATION",
+	"Failed to delete fill link":                                     "FAILED_TO_DELETE_FILL_LINK",
+	"Failed to delete form submission":                               "FAILED_TO_DELETE_FORM_SUBMISSION",
+	"Failed to delete import profile":                                "FAILED_TO_DELETE_IMPORT_PROFILE",
+	"Failed to delete integration":                                   "FAILED_TO_DELETE_INTEGRATION",
+	"Failed to delete option list":                                   "FAILED_TO_DELETE_OPTION_LIST",
+	"Failed to delete stamp":                                         "FAILED_TO_DELETE_STAMP",
+	"Failed to delete SVG file":                                      "FAILED_TO_DELETE_SVG_FILE",
+	"Failed to delete template":                                      "FAILED_TO_DELETE_TEMPLATE",
+	"Failed to delete template include":                              "FAILED_TO_DELETE_TEMPLATE_INCLUDE",
+	"Failed to delete upload":                                        "FAILED_TO_DELETE_UPLOAD",
+	"Failed to disable debug recording":                              "FAILED_TO_DISABLE_DEBUG_RECORDING",
+	"Failed to enable debug recording":                               "FAILED_TO_ENABLE_DEBUG_RECORDING",
+	"Failed to export form submissions":                              "FAILED_TO_EXPORT_FORM_SUBMISSIONS",
+	"Failed to export template":                                      "FAILED_TO_EXPORT_TEMPLATE",
+	"Failed to fetch assignment":                                     "FAILED_TO_FETCH_ASSIGNMENT",
+	"Failed to fetch audit logs":                                     "FAILED_TO_FETCH_AUDIT_LOGS",
+	"Failed to fetch calibrations":                                   "FAILED_TO_FETCH_CALIBRATIONS",
+	"Failed to fetch debug recording":                                "FAILED_TO_FETCH_DEBUG_RECORDING",
+	"Failed to fetch debug recording window":                         "FAILED_TO_FETCH_DEBUG_RECORDING_WINDOW",
+	"Failed to fetch debug recordings":                               "FAILED_TO_FETCH_DEBUG_RECORDINGS",
+	"Failed to fetch document":                                       "FAILED_TO_FETCH_DOCUMENT",
+	"Failed to fetch events":                                         "FAILED_TO_FETCH_EVENTS",
+	"Failed to fetch fill link":                                      "FAILED_TO_FETCH_FILL_LINK",
+	"Failed to fetch fill links":                                     "FAILED_TO_FETCH_FILL_LINKS",
+	"Failed to fetch form submission":                                "FAILED_TO_FETCH_FORM_SUBMISSION",
+	"Failed to fetch form submissions":                               "FAILED_TO_FETCH_FORM_SUBMISSIONS",
+	"Failed to fetch generation job":                                 "FAILED_TO_FETCH_GENERATION_JOB",
+	"Failed to fetch import profile":                                 "FAILED_TO_FETCH_IMPORT_PROFILE",
+	"Failed to fetch import profiles":                                "FAILED_TO_FETCH_IMPORT_PROFILES",
+	"Failed to fetch integration":                                    "FAILED_TO_FETCH_INTEGRATION",
+	"Failed to fetch integrations":                                   "FAILED_TO_FETCH_INTEGRATIONS",
+	"Failed to fetch option list":                                    "FAILED_TO_FETCH_OPTION_LIST",
+	"Failed to fetch option lists":                                   "FAILED_TO_FETCH_OPTION_LISTS",
+	"Failed to fetch regeneration job":                               "FAILED_TO_FETCH_REGENERATION_JOB",
+	"Failed to fetch signature":                                      "FAILED_TO_FETCH_SIGNATURE",
+	"Failed to fetch signatures":                                     "FAILED_TO_FETCH_SIGNATURES",
+	"Failed to fetch snapshots":                                      "FAILED_TO_FETCH_SNAPSHOTS",
+	"Failed to fetch SSO config":                                     "FAILED_TO_FETCH_SSO_CONFIG",
+	"Failed to fetch stamp":                                          "FAILED_TO_FETCH_STAMP",
+	"Failed to fetch stamps":                                         "FAILED_TO_FETCH_STAMPS",
+	"Failed to fetch submission revisions":                           "FAILED_TO_FETCH_SUBMISSION_REVISIONS",
+	"Failed to fetch suggestions":                                    "FAILED_TO_FETCH_SUGGESTIONS",
+	"Failed to fetch SVG file":                                       "FAILED_TO_FETCH_SVG_FILE",
+	"Failed to fetch template":                                       "FAILED_TO_FETCH_TEMPLATE",
+	"Failed to fetch template includes":                              "FAILED_TO_FETCH_TEMPLATE_INCLUDES",
+	"Failed to fetch template lock":                                  "FAILED_TO_FETCH_TEMPLATE_LOCK",
+	"Failed to fetch template share":                                 "FAILED_TO_FETCH_TEMPLATE_SHARE",
+	"Failed to fetch template shares":                                "FAILED_TO_FETCH_TEMPLATE_SHARES",
+	"Failed to fetch templates":                                      "FAILED_TO_FETCH_TEMPLATES",
+	"Failed to fetch token":                                          "FAILED_TO_FETCH_TOKEN",
+	"Failed to fetch tokens":                                         "FAILED_TO_FETCH_TOKENS",
+	"Failed to fetch upload":                                         "FAILED_TO_FETCH_UPLOAD",
+	"Failed to fetch uploads":                                        "FAILED_TO_FETCH_UPLOADS",
+	"Failed to fetch usage":                                          "FAILED_TO_FETCH_USAGE",
+	"Failed to fetch work queue":                                     "FAILED_TO_FETCH_WORK_QUEUE",
+	"Failed to fetch workload":                                       "FAILED_TO_FETCH_WORKLOAD",
+	"Failed to fetch workspace":                                      "FAILED_TO_FETCH_WORKSPACE",
+	"Failed to fetch workspace domain":                               "FAILED_TO_FETCH_WORKSPACE_DOMAIN",
+	"Failed to get file":                                             "FAILED_TO_GET_FILE",
+	"Failed to import layout":                                        "FAILED_TO_IMPORT_LAYOUT",
+	"Failed to import positions":                                     "FAILED_TO_IMPORT_POSITIONS",
+	"Failed to invalidate signature":                                 "FAILED_TO_INVALIDATE_SIGNATURE",
+	"Failed to issue session token":                                  "FAILED_TO_ISSUE_SESSION_TOKEN",
+	"Failed to load SSO configuration":                               "FAILED_TO_LOAD_SSO_CONFIGURATION",
+	"Failed to migrate data keys":                                    "FAILED_TO_MIGRATE_DATA_KEYS",
+	"Failed to normalize form data":                                  "FAILED_TO_NORMALIZE_FORM_DATA",
+	"Failed to place legal hold":                                     "FAILED_TO_PLACE_LEGAL_HOLD",
+	"Failed to queue PDF generation":                                 "FAILED_TO_QUEUE_PDF_GENERATION",
+	"Failed to queue regeneration":                                   "FAILED_TO_QUEUE_REGENERATION",
+	"Failed to read file":                                            "FAILED_TO_READ_FILE",
+	"Failed to read form templates":                                  "FAILED_TO_READ_FORM_TEMPLATES",
+	"Failed to read ID card":                                         "FAILED_TO_READ_ID_CARD",
+	"Failed to read image":                                           "FAILED_TO_READ_IMAGE",
+	"Failed to read scan":                                            "FAILED_TO_READ_SCAN",
+	"Failed to read signature":                                       "FAILED_TO_READ_SIGNATURE",
+	"Failed to read stamp":                                           "FAILED_TO_READ_STAMP",
+	"Failed to reassign assignments":                                 "FAILED_TO_REASSIGN_ASSIGNMENTS",
+	"Failed to record snapshot":                                      "FAILED_TO_RECORD_SNAPSHOT",
+	"Failed to release legal hold":                                   "FAILED_TO_RELEASE_LEGAL_HOLD",
+	"Failed to release template lock":                                "FAILED_TO_RELEASE_TEMPLATE_LOCK",
+	"Failed to reload configuration":                                 "FAILED_TO_RELOAD_CONFIGURATION",
+	"Failed to remove workspace domain":                              "FAILED_TO_REMOVE_WORKSPACE_DOMAIN",
+	"Failed to render page preview":                                  "FAILED_TO_RENDER_PAGE_PREVIEW",
+	"Failed to render preview":                                       "FAILED_TO_RENDER_PREVIEW",
+	"Failed to renew template lock":                                  "FAILED_TO_RENEW_TEMPLATE_LOCK",
+	"Failed to reserve document number":                              "FAILED_TO_RESERVE_DOCUMENT_NUMBER",
+	"Failed to resolve included templates":                           "FAILED_TO_RESOLVE_INCLUDED_TEMPLATES",
+	"Failed to review template":                                      "FAILED_TO_REVIEW_TEMPLATE",
+	"Failed to revoke template share":                                "FAILED_TO_REVOKE_TEMPLATE_SHARE",
+	"Failed to revoke token":                                         "FAILED_TO_REVOKE_TOKEN",
+	"Failed to rotate token":                                         "FAILED_TO_ROTATE_TOKEN",
+	"Failed to save calibration":                                     "FAILED_TO_SAVE_CALIBRATION",
+	"Failed to save form submission":                                 "FAILED_TO_SAVE_FORM_SUBMISSION",
+	"Failed to save grid":                                            "FAILED_TO_SAVE_GRID",
+	"Failed to save SSO config":                                      "FAILED_TO_SAVE_SSO_CONFIG",
+	"Failed to search documents":                                     "FAILED_TO_SEARCH_DOCUMENTS",
+	"Failed to set workspace domain":                                 "FAILED_TO_SET_WORKSPACE_DOMAIN",
+	"Failed to share template":                                       "FAILED_TO_SHARE_TEMPLATE",
+	"Failed to sign page URLs":                                       "FAILED_TO_SIGN_PAGE_URLS",
+	"Failed to snap fields to grid":                                  "FAILED_TO_SNAP_FIELDS_TO_GRID",
+	"Failed to start login":                                          "FAILED_TO_START_LOGIN",
+	"Failed to store signature":                                      "FAILED_TO_STORE_SIGNATURE",
+	"Failed to store stamp":                                          "FAILED_TO_STORE_STAMP",
+	"Failed to update fields":                                        "FAILED_TO_UPDATE_FIELDS",
+	"Failed to update form submission":                               "FAILED_TO_UPDATE_FORM_SUBMISSION",
+	"Failed to update import profile":                                "FAILED_TO_UPDATE_IMPORT_PROFILE",
+	"Failed to update option list":                                   "FAILED_TO_UPDATE_OPTION_LIST",
+	"Failed to update template":                                      "FAILED_TO_UPDATE_TEMPLATE",
+	"Failed to update vision quota":                                  "FAILED_TO_UPDATE_VISION_QUOTA",
+	"Failed to upload file":                                          "FAILED_TO_UPLOAD_FILE",
+	"Failed to upload files":                                         "FAILED_TO_UPLOAD_FILES",
+	"Failed to validate form submission":                             "FAILED_TO_VALIDATE_FORM_SUBMISSION",
+	"Failed to validate signatures":                                  "FAILED_TO_VALIDATE_SIGNATURES",
+	"Failed to validate stamps":                                      "FAILED_TO_VALIDATE_STAMPS",
+	"Failed to verify snapshot":                                      "FAILED_TO_VERIFY_SNAPSHOT",
+	"Failed to verify snapshots":                                     "FAILED_TO_VERIFY_SNAPSHOTS",
+	"Failed to verify workspace domain":                              "FAILED_TO_VERIFY_WORKSPACE_DOMAIN",
+	"Field not found":                                                "FIELD_NOT_FOUND",
+	"File is too large":                                              "FILE_IS_TOO_LARGE",
+	"File must be a PDF":                                             "FILE_MUST_BE_A_PDF",
+	"File must be an SVG":                                            "FILE_MUST_BE_AN_SVG",
+	"Fill link not found":                                            "FILL_LINK_NOT_FOUND",
+	"Form data does not match the template fields":                   "FORM_DATA_INVALID",
+	"Form submission has expired":                                    "FORM_SUBMISSION_HAS_EXPIRED",
+	"Form submission not found":                                      "FORM_SUBMISSION_NOT_FOUND",
+	"Generation job not found":                                       "GENERATION_JOB_NOT_FOUND",
+	"Identity provider rejected the login":                           "IDENTITY_PROVIDER_REJECTED_THE_LOGIN",
+	"Image is too large":                                             "IMAGE_IS_TOO_LARGE",
+	"Import profile not found":                                       "IMPORT_PROFILE_NOT_FOUND",
+	"Integration not found":                                          "INTEGRATION_NOT_FOUND",
+	"Invalid API key":                                                "INVALID_API_KEY",
+	"Invalid archived":                                               "INVALID_ARCHIVED",
+	"Invalid bundle":                                                 "INVALID_BUNDLE",
+	"Invalid custom CSS":                                             "INVALID_CUSTOM_CSS",
+	"Invalid dataKey":                                                "INVALID_DATA_KEY",
+	"Invalid duplicate policy":                                       "INVALID_DUPLICATE_POLICY",
+	"Invalid e-filing export":                                        "INVALID_E_FILING_EXPORT",
+	"Invalid field":                                                  "INVALID_FIELD",
+	"Invalid file":                                                   "INVALID_FILE",
+	"Invalid fill link ID":                                           "INVALID_FILL_LINK_ID",
+	"Invalid from revision":                                          "INVALID_FROM_REVISION",
+	"Invalid htmlData":                                               "INVALID_HTML_DATA",
+	"Invalid If-Match header":                                        "INVALID_IF_MATCH",
+	"Invalid image":                                                  "INVALID_IMAGE",
+	"Invalid include":                                                "INVALID_INCLUDE",
+	"Invalid include ID":                                             "INVALID_INCLUDE_ID",
+	"Invalid integration ID":                                         "INVALID_INTEGRATION_ID",
+	"Invalid JSON":                                                   "INVALID_JSON",
+	"Invalid layout":                                                 "INVALID_LAYOUT",
+	"Invalid limit":                                                  "INVALID_LIMIT",
+	"Invalid login state":                                            "INVALID_LOGIN_STATE",
+	"Invalid mapping":                                                "INVALID_MAPPING",
+	"Invalid marks":                                                  "INVALID_MARKS",
+	"Invalid multipart form":                                         "INVALID_MULTIPART_FORM",
+	"Invalid offset":                                                 "INVALID_OFFSET",
+	"Invalid option list":                                            "INVALID_OPTION_LIST",
+	"Invalid or expired session":                                     "INVALID_OR_EXPIRED_SESSION",
+	"Invalid or expired token":                                       "INVALID_OR_EXPIRED_TOKEN",
+	"Invalid page":                                                   "INVALID_PAGE",
+	"Invalid page index":                                             "INVALID_PAGE_INDEX",
+	"Invalid pageSize":                                               "INVALID_PAGE_SIZE",
+	"Invalid pagination":                                             "INVALID_PAGINATION",
+	"Invalid period, expected YYYY-MM":                               "INVALID_PERIOD",
+	"Invalid reference marks":                                        "INVALID_REFERENCE_MARKS",
+	"Invalid request body":                                           "INVALID_REQUEST_BODY",
+	"Invalid request data":                                           "INVALID_REQUEST_DATA",
+	"Invalid reservation ID":                                         "INVALID_RESERVATION_ID",
+	"Invalid scan":                                                   "INVALID_SCAN",
+	"Invalid script fonts":                                           "INVALID_SCRIPT_FONTS",
+	"Invalid signature":                                              "INVALID_SIGNATURE",
+	"Invalid sort":                                                   "INVALID_SORT",
+	"Invalid since cursor":                                           "INVALID_SINCE_CURSOR",
+	"Invalid stamp":                                                  "INVALID_STAMP",
+	"Invalid status":                                                 "INVALID_STATUS",
+	"Invalid SVG file ID":                                            "INVALID_SVG_FILE_ID",
+	"Invalid to revision":                                            "INVALID_TO_REVISION",
+	"Invalid update":                                                 "INVALID_UPDATE",
+	"Invalid validation webhook":                                     "INVALID_VALIDATION_WEBHOOK",
+	"Locked fields cannot be changed":                                "LOCKED_FIELDS_CANNOT_BE_CHANGED",
+	"No file uploaded":                                               "NO_FILE_UPLOADED",
+	"No files uploaded":                                              "NO_FILES_UPLOADED",
+	"No image uploaded":                                              "NO_IMAGE_UPLOADED",
+	"No scan uploaded":                                               "NO_SCAN_UPLOADED",
+	"No stored document for this submission":                         "NO_STORED_DOCUMENT_FOR_THIS_SUBMISSION",
+	"No submissions to generate":                                     "NO_SUBMISSIONS_TO_GENERATE",
+	"Not found":                                                      "NOT_FOUND",
+	"OCR is not available":                                           "OCR_IS_NOT_AVAILABLE",
+	"Only the assignee can complete this assignment":                 "ONLY_THE_ASSIGNEE_CAN_COMPLETE_THIS_ASSIGNMENT",
+	"Only the oidc protocol is currently supported":                  "ONLY_THE_OIDC_PROTOCOL_IS_CURRENTLY_SUPPORTED",
+	"Option list is used by template fields":                         "OPTION_LIST_IS_USED_BY_TEMPLATE_FIELDS",
+	"Option list not found":                                          "OPTION_LIST_NOT_FOUND",
+	"PDF backgrounds are not configured":                             "PDF_BACKGROUNDS_ARE_NOT_CONFIGURED",
+	"Page not found":                                                 "PAGE_NOT_FOUND",
+	"Preview session not found or expired":                           "PREVIEW_SESSION_NOT_FOUND_OR_EXPIRED",
+	"Regeneration job not found":                                     "REGENERATION_JOB_NOT_FOUND",
+	"Request timed out":                                              "REQUEST_TIMEOUT",
+	"returnTo must point at an allowed frontend origin":              "INVALID_RETURN_TO",
+	"Scan is too large":                                              "SCAN_IS_TOO_LARGE",
+	"Selected SVG file does not exist":                               "SELECTED_SVG_FILE_DOES_NOT_EXIST",
+	"sessionId is required":                                          "SESSION_ID_REQUIRED",
+	"Signature fields refer to unusable signatures":                  "SIGNATURE_FIELDS_REFER_TO_UNUSABLE_SIGNATURES",
+	"Signature has been invalidated":                                 "SIGNATURE_HAS_BEEN_INVALIDATED",
+	"Signature not found":                                            "SIGNATURE_NOT_FOUND",
+	"SSO is not configured for this workspace":                       "SSO_IS_NOT_CONFIGURED_FOR_THIS_WORKSPACE",
+	"SSO is not enabled for this workspace":                          "SSO_IS_NOT_ENABLED_FOR_THIS_WORKSPACE",
+	"Stamp fields refer to unknown stamps":                           "STAMP_FIELDS_REFER_TO_UNKNOWN_STAMPS",
+	"Stamp is used by template fields":                               "STAMP_IS_USED_BY_TEMPLATE_FIELDS",
+	"Stamp name is required":                                         "STAMP_NAME_IS_REQUIRED",
+	"Stamp not found":                                                "STAMP_NOT_FOUND",
+	"Submission is missing required e-filing values":                 "SUBMISSION_IS_MISSING_REQUIRED_E_FILING_VALUES",
+	"Submission rejected by validation webhook":                      "SUBMISSION_REJECTED_BY_VALIDATION_WEBHOOK",
+	"Submitted values are not in their option lists":                 "SUBMITTED_VALUES_ARE_NOT_IN_THEIR_OPTION_LISTS",
+	"Suggestions are not offered for this field":                     "SUGGESTIONS_ARE_NOT_OFFERED_FOR_THIS_FIELD",
+	"Support access is not enabled":                                  "SUPPORT_ACCESS_IS_NOT_ENABLED",
+	"SVG file not found":                                             "SVG_FILE_NOT_FOUND",
+	"SVG file not found for this page":                               "SVG_FILE_NOT_FOUND_FOR_THIS_PAGE",
+	"Template has no e-filing export":                                "TEMPLATE_HAS_NO_E_FILING_EXPORT",
+	"Template is included by other templates":                        "TEMPLATE_IS_INCLUDED_BY_OTHER_TEMPLATES",
+	"Template is shared with your workspace without this permission": "TEMPLATE_IS_SHARED_WITH_YOUR_WORKSPACE_WITHOUT_THIS_PERMISSION",
+	"Template not found":                                             "TEMPLATE_NOT_FOUND",
+	"Template share not found":                                       "TEMPLATE_SHARE_NOT_FOUND",
+	"Template sync is not enabled":                                   "TEMPLATE_SYNC_IS_NOT_ENABLED",
+	"Template was modified by another editor":                        "TEMPLATE_WAS_MODIFIED_BY_ANOTHER_EDITOR",
+	"Token cannot grant scopes it does not have":                     "TOKEN_CANNOT_GRANT_SCOPES_IT_DOES_NOT_HAVE",
+	"Token has been revoked":                                         "TOKEN_HAS_BEEN_REVOKED",
+	"Token is missing required scope":                                "TOKEN_IS_MISSING_REQUIRED_SCOPE",
+	"Token not found":                                                "TOKEN_NOT_FOUND",
+	"Too many failed authentication attempts":                        "TOO_MANY_FAILED_AUTHENTICATION_ATTEMPTS",
+	"Too many requests":                                              "TOO_MANY_REQUESTS",
+	"Too many submissions":                                           "TOO_MANY_SUBMISSIONS",
+	"Unknown submissions":                                            "UNKNOWN_SUBMISSIONS",
+	"Unsupported export format":                                      "UNSUPPORTED_EXPORT_FORMAT",
+	"Upload not found":                                               "UPLOAD_NOT_FOUND",
+	"Workspace has no custom domain":                                 "WORKSPACE_HAS_NO_CUSTOM_DOMAIN",
+	"Workspace not found":                                            "WORKSPACE_NOT_FOUND",
+}
+
+// errorCatalogs holds the translations of error messages by language and
+// code. English, the language handlers write, needs no catalog.
+var errorCatalogs = map[string]map[string]string{
+	"th": {
+		"ADMIN_ACCESS_IS_NOT_ENABLED":                                    "ยังไม่ได้เปิดใช้การเข้าถึงของผู้ดูแลระบบ",
+		"ASSIGNMENT_NOT_FOUND":                                           "ไม่พบงานที่มอบหมาย",
+		"AUTHENTICATION_IS_NOT_CONFIGURED":                               "ยังไม่ได้ตั้งค่าการยืนยันตัวตน",
+		"AUTHENTICATION_REQUIRED":                                        "ต้องเข้าสู่ระบบก่อน",
+		"CONSENT_IS_REQUIRED_TO_STORE_A_SIGNATURE":                       "ต้องได้รับความยินยอมก่อนจัดเก็บลายมือชื่อ",
+		"DATABASE_ERROR":                                                 "เกิดข้อผิดพลาดของฐานข้อมูล",
+		"DATA_KEY_REQUIRED":                                              "ต้องระบุ dataKey",
+		"DEBUG_RECORDING_NOT_FOUND":                                      "ไม่พบบันทึกการดีบัก",
+		"DOCUMENT_NOT_FOUND":                                             "ไม่พบเอกสาร",
+		"DUPLICATE_SUBMISSION":                                           "ข้อมูลที่ส่งซ้ำกับรายการที่มีอยู่แล้ว",
+		"EACH_FILE_NEEDS_A_PAGE_INDEX":                                   "ทุกไฟล์ต้องระบุหมายเลขหน้า",
+		"EXACTLY_ONE_TEMPLATE_DEFINITION_IS_REQUIRED":                    "ต้องมีคำจำกัดความเทมเพลตเพียงหนึ่งรายการ",
+		"EXPORT_REQUIRES_ACCESS_TO_EVERY_FIELD":                          "การส่งออกต้องมีสิทธิ์เข้าถึงทุกฟิลด์",
+		"FAILED_TO_ACCEPT_TEMPLATE_SHARE":                                "ไม่สามารถรับการแชร์เทมเพลตได้",
+		"FAILED_TO_ACQUIRE_TEMPLATE_LOCK":                                "ไม่สามารถล็อกเทมเพลตได้",
+		"FAILED_TO_ANALYZE_SUBMISSIONS":                                  "ไม่สามารถวิเคราะห์ข้อมูลที่ส่งได้",
+		"FAILED_TO_APPLY_LOCKED_VALUES":                                  "ไม่สามารถใช้ค่าที่ถูกล็อกได้",
+		"FAILED_TO_ARCHIVE_TEMPLATE":                                     "ไม่สามารถเก็บถาวรเทมเพลตได้",
+		"FAILED_TO_ASSIGN_FORM_SUBMISSION":                               "ไม่สามารถมอบหมายแบบฟอร์มที่ส่งได้",
+		"FAILED_TO_ATTACH_UPLOAD":                                        "ไม่สามารถแนบไฟล์ที่อัปโหลดได้",
+		"FAILED_TO_BUILD_E_FILING_PAYLOAD":                               "ไม่สามารถสร้างข้อมูลสำหรับยื่นแบบอิเล็กทรอนิกส์ได้",
+		"FAILED_TO_CHECK_FOR_DUPLICATE_SUBMISSIONS":                      "ไม่สามารถตรวจสอบข้อมูลที่ส่งซ้ำได้",
+		"FAILED_TO_CHECK_TEMPLATE_ACCESS":                                "ไม่สามารถตรวจสอบสิทธิ์การเข้าถึงเทมเพลตได้",
+		"FAILED_TO_CLONE_TEMPLATE":                                       "ไม่สามารถสร้างสำเนาเทมเพลตได้",
+		"FAILED_TO_COMPARE_SCAN":                                         "ไม่สามารถเปรียบเทียบภาพสแกนได้",
+		"FAILED_TO_COMPARE_SUBMISSION_REVISIONS":                         "ไม่สามารถเปรียบเทียบฉบับแก้ไขของข้อมูลที่ส่งได้",
+		"FAILED_TO_COMPLETE_ASSIGNMENT":                                  "ไม่สามารถปิดงานที่มอบหมายได้",
+		"FAILED_TO_COMPLETE_LOGIN":                                       "ไม่สามารถเข้าสู่ระบบให้เสร็จสมบูรณ์ได้",
+		"FAILED_TO_CONFIRM_DOCUMENT_NUMBER":                              "ไม่สามารถยืนยันเลขที่เอกสารได้",
+		"FAILED_TO_CONTACT_IDENTITY_PROVIDER":                            "ไม่สามารถติดต่อผู้ให้บริการยืนยันตัวตนได้",
+		"FAILED_TO_CONVERT_MARKS":                                        "ไม่สามารถแปลงเครื่องหมายได้",
+		"FAILED_TO_CONVERT_PDF":                                          "ไม่สามารถแปลงไฟล์ PDF ได้",
+		"FAILED_TO_COPY_TEMPLATE":                                        "ไม่สามารถคัดลอกเทมเพลตได้",
+		"FAILED_TO_CREATE_FILL_LINK":                                     "ไม่สามารถสร้างลิงก์กรอกแบบฟอร์มได้",
+		"FAILED_TO_CREATE_IMPORT_PROFILE":                                "ไม่สามารถสร้างโปรไฟล์การนำเข้าได้",
+		"FAILED_TO_CREATE_INTEGRATION":                                   "ไม่สามารถสร้างการเชื่อมต่อได้",
+		"FAILED_TO_CREATE_OPTION_LIST":                                   "ไม่สามารถสร้างรายการตัวเลือกได้",
+		"FAILED_TO_CREATE_TEMPLATE":                                      "ไม่สามารถสร้างเทมเพลตได้",
+		"FAILED_TO_CREATE_TEMPLATE_INCLUDE":                              "ไม่สามารถเพิ่มเทมเพลตที่รวมไว้ได้",
+		"FAILED_TO_CREATE_TOKEN":                                         "ไม่สามารถสร้างโทเค็นได้",
+		"FAILED_TO_CREATE_WORKSPACE":                                     "ไม่สามารถสร้างพื้นที่ทำงานได้",
+		"FAILED_TO_DELETE_ASSIGNMENT":                                    "ไม่สามารถลบงานที่มอบหมายได้",
+		"FAILED_TO_DELETE_CALIBRATION":                                   "ไม่สามารถลบค่าการปรับเทียบได้",
+		"FAILED_TO_DELETE_FILL_LINK":                                     "ไม่สามารถลบลิงก์กรอกแบบฟอร์มได้",
+		"FAILED_TO_DELETE_FORM_SUBMISSION":                               "ไม่สามารถลบแบบฟอร์มที่ส่งได้",
+		"FAILED_TO_DELETE_IMPORT_PROFILE":                                "ไม่สามารถลบโปรไฟล์การนำเข้าได้",
+		"FAILED_TO_DELETE_INTEGRATION":                                   "ไม่สามารถลบการเชื่อมต่อได้",
+		"FAILED_TO_DELETE_OPTION_LIST":                                   "ไม่สามารถลบรายการตัวเลือกได้",
+		"FAILED_TO_DELETE_STAMP":                                         "ไม่สามารถลบตราประทับได้",
+		"FAILED_TO_DELETE_SVG_FILE":                                      "ไม่สามารถลบไฟล์ SVG ได้",
+		"FAILED_TO_DELETE_TEMPLATE":                                      "ไม่สามารถลบเทมเพลตได้",
+		"FAILED_TO_DELETE_TEMPLATE_INCLUDE":                              "ไม่สามารถลบเทมเพลตที่รวมไว้ได้",
+		"FAILED_TO_DELETE_UPLOAD":                                        "ไม่สามารถลบไฟล์ที่อัปโหลดได้",
+		"FAILED_TO_DISABLE_DEBUG_RECORDING":                              "ไม่สามารถปิดการบันทึกดีบักได้",
+		"FAILED_TO_ENABLE_DEBUG_RECORDING":                               "ไม่สามารถเปิดการบันทึกดีบักได้",
+		"FAILED_TO_EXPORT_FORM_SUBMISSIONS":                              "ไม่สามารถส่งออกแบบฟอร์มที่ส่งได้",
+		"FAILED_TO_EXPORT_TEMPLATE":                                      "ไม่สามารถส่งออกเทมเพลตได้",
+		"FAILED_TO_FETCH_ASSIGNMENT":                                     "ไม่สามารถดึงงานที่มอบหมายได้",
+		"FAILED_TO_FETCH_AUDIT_LOGS":                                     "ไม่สามารถดึงบันทึกการตรวจสอบได้",
+		"FAILED_TO_FETCH_CALIBRATIONS":                                   "ไม่สามารถดึงค่าการปรับเทียบได้",
+		"FAILED_TO_FETCH_DEBUG_RECORDING":                                "ไม่สามารถดึงบันทึกดีบักได้",
+		"FAILED_TO_FETCH_DEBUG_RECORDINGS":                               "ไม่สามารถดึงบันทึกดีบักได้",
+		"FAILED_TO_FETCH_DEBUG_RECORDING_WINDOW":                         "ไม่สามารถดึงช่วงเวลาการบันทึกดีบักได้",
+		"FAILED_TO_FETCH_DOCUMENT":                                       "ไม่สามารถดึงเอกสารได้",
+		"FAILED_TO_FETCH_EVENTS":                                         "ไม่สามารถดึงเหตุการณ์ได้",
+		"FAILED_TO_FETCH_FILL_LINK":                                      "ไม่สามารถดึงลิงก์กรอกแบบฟอร์มได้",
+		"FAILED_TO_FETCH_FILL_LINKS":                                     "ไม่สามารถดึงลิงก์กรอกแบบฟอร์มได้",
+		"FAILED_TO_FETCH_FORM_SUBMISSION":                                "ไม่สามารถดึงแบบฟอร์มที่ส่งได้",
+		"FAILED_TO_FETCH_FORM_SUBMISSIONS":                               "ไม่สามารถดึงแบบฟอร์มที่ส่งได้",
+		"FAILED_TO_FETCH_GENERATION_JOB":                                 "ไม่สามารถดึงงานสร้างเอกสารได้",
+		"FAILED_TO_FETCH_IMPORT_PROFILE":                                 "ไม่สามารถดึงโปรไฟล์การนำเข้าได้",
+		"FAILED_TO_FETCH_IMPORT_PROFILES":                                "ไม่สามารถดึงโปรไฟล์การนำเข้าได้",
+		"FAILED_TO_FETCH_INTEGRATION":                                    "ไม่สามารถดึงการเชื่อมต่อได้",
+		"FAILED_TO_FETCH_INTEGRATIONS":                                   "ไม่สามารถดึงการเชื่อมต่อได้",
+		"FAILED_TO_FETCH_OPTION_LIST":                                    "ไม่สามารถดึงรายการตัวเลือกได้",
+		"FAILED_TO_FETCH_OPTION_LISTS":                                   "ไม่สามารถดึงรายการตัวเลือกได้",
+		"FAILED_TO_FETCH_REGENERATION_JOB":                               "ไม่สามารถดึงงานสร้างเอกสารใหม่ได้",
+		"FAILED_TO_FETCH_SIGNATURE":                                      "ไม่สามารถดึงลายมือชื่อได้",
+		"FAILED_TO_FETCH_SIGNATURES":                                     "ไม่สามารถดึงลายมือชื่อได้",
+		"FAILED_TO_FETCH_SNAPSHOTS":                                      "ไม่สามารถดึงสแนปช็อตได้",
+		"FAILED_TO_FETCH_SSO_CONFIG":                                     "ไม่สามารถดึงการตั้งค่า SSO ได้",
+		"FAILED_TO_FETCH_STAMP":                                          "ไม่สามารถดึงตราประทับได้",
+		"FAILED_TO_FETCH_STAMPS":                                         "ไม่สามารถดึงตราประทับได้",
+		"FAILED_TO_FETCH_SUBMISSION_REVISIONS":                           "ไม่สามารถดึงฉบับแก้ไขของข้อมูลที่ส่งได้",
+		"FAILED_TO_FETCH_SUGGESTIONS":                                    "ไม่สามารถดึงคำแนะนำได้",
+		"FAILED_TO_FETCH_SVG_FILE":                                       "ไม่สามารถดึงไฟล์ SVG ได้",
+		"FAILED_TO_FETCH_TEMPLATE":                                       "ไม่สามารถดึงเทมเพลตได้",
+		"FAILED_TO_FETCH_TEMPLATES":                                      "ไม่สามารถดึงเทมเพลตได้",
+		"FAILED_TO_FETCH_TEMPLATE_INCLUDES":                              "ไม่สามารถดึงเทมเพลตที่รวมไว้ได้",
+		"FAILED_TO_FETCH_TEMPLATE_LOCK":                                  "ไม่สามารถดึงสถานะการล็อกเทมเพลตได้",
+		"FAILED_TO_FETCH_TEMPLATE_SHARE":                                 "ไม่สามารถดึงการแชร์เทมเพลตได้",
+		"FAILED_TO_FETCH_TEMPLATE_SHARES":                                "ไม่สามารถดึงการแชร์เทมเพลตได้",
+		"FAILED_TO_FETCH_TOKEN":                                          "ไม่สามารถดึงโทเค็นได้",
+		"FAILED_TO_FETCH_TOKENS":                                         "ไม่สามารถดึงโทเค็นได้",
+		"FAILED_TO_FETCH_UPLOAD":                                         "ไม่สามารถดึงไฟล์ที่อัปโหลดได้",
+		"FAILED_TO_FETCH_UPLOADS":                                        "ไม่สามารถดึงไฟล์ที่อัปโหลดได้",
+		"FAILED_TO_FETCH_USAGE":                                          "ไม่สามารถดึงข้อมูลการใช้งานได้",
+		"FAILED_TO_FETCH_WORKLOAD":                                       "ไม่สามารถดึงภาระงานได้",
+		"FAILED_TO_FETCH_WORKSPACE":                                      "ไม่สามารถดึงพื้นที่ทำงานได้",
+		"FAILED_TO_FETCH_WORKSPACE_DOMAIN":                               "ไม่สามารถดึงโดเมนของพื้นที่ทำงานได้",
+		"FAILED_TO_FETCH_WORK_QUEUE":                                     "ไม่สามารถดึงคิวงานได้",
+		"FAILED_TO_GET_FILE":                                             "ไม่สามารถดึงไฟล์ได้",
+		"FAILED_TO_IMPORT_LAYOUT":                                        "ไม่สามารถนำเข้าเลย์เอาต์ได้",
+		"FAILED_TO_IMPORT_POSITIONS":                                     "ไม่สามารถนำเข้าตำแหน่งได้",
+		"FAILED_TO_INVALIDATE_SIGNATURE":                                 "ไม่สามารถยกเลิกลายมือชื่อได้",
+		"FAILED_TO_ISSUE_SESSION_TOKEN":                                  "ไม่สามารถออกโทเค็นเซสชันได้",
+		"FAILED_TO_LOAD_SSO_CONFIGURATION":                               "ไม่สามารถโหลดการตั้งค่า SSO ได้",
+		"FAILED_TO_MIGRATE_DATA_KEYS":                                    "ไม่สามารถย้าย dataKey ได้",
+		"FAILED_TO_NORMALIZE_FORM_DATA":                                  "ไม่สามารถปรับรูปแบบข้อมูลแบบฟอร์มได้",
+		"FAILED_TO_PLACE_LEGAL_HOLD":                                     "ไม่สามารถระงับการลบตามกฎหมายได้",
+		"FAILED_TO_QUEUE_PDF_GENERATION":                                 "ไม่สามารถเข้าคิวการสร้าง PDF ได้",
+		"FAILED_TO_QUEUE_REGENERATION":                                   "ไม่สามารถเข้าคิวการสร้างเอกสารใหม่ได้",
+		"FAILED_TO_READ_FILE":                                            "ไม่สามารถอ่านไฟล์ได้",
+		"FAILED_TO_READ_FORM_TEMPLATES":                                  "ไม่สามารถอ่านเทมเพลตแบบฟอร์มได้",
+		"FAILED_TO_READ_ID_CARD":                                         "ไม่สามารถอ่านบัตรประชาชนได้",
+		"FAILED_TO_READ_IMAGE":                                           "ไม่สามารถอ่านรูปภาพได้",
+		"FAILED_TO_READ_SCAN":                                            "ไม่สามารถอ่านภาพสแกนได้",
+		"FAILED_TO_READ_SIGNATURE":                                       "ไม่สามารถอ่านลายมือชื่อได้",
+		"FAILED_TO_READ_STAMP":                                           "ไม่สามารถอ่านตราประทับได้",
+		"FAILED_TO_REASSIGN_ASSIGNMENTS":                                 "ไม่สามารถมอบหมายงานใหม่ได้",
+		"FAILED_TO_RECORD_SNAPSHOT":                                      "ไม่สามารถบันทึกสแนปช็อตได้",
+		"FAILED_TO_RELEASE_LEGAL_HOLD":                                   "ไม่สามารถยกเลิกการระงับการลบตามกฎหมายได้",
+		"FAILED_TO_RELEASE_TEMPLATE_LOCK":                                "ไม่สามารถปลดล็อกเทมเพลตได้",
+		"FAILED_TO_RELOAD_CONFIGURATION":                                 "ไม่สามารถโหลดการตั้งค่าใหม่ได้",
+		"FAILED_TO_REMOVE_WORKSPACE_DOMAIN":                              "ไม่สามารถนำโดเมนของพื้นที่ทำงานออกได้",
+		"FAILED_TO_RENDER_PAGE_PREVIEW":                                  "ไม่สามารถแสดงตัวอย่างหน้าได้",
+		"FAILED_TO_RENDER_PREVIEW":                                       "ไม่สามารถแสดงตัวอย่างได้",
+		"FAILED_TO_RENEW_TEMPLATE_LOCK":                                  "ไม่สามารถต่ออายุการล็อกเทมเพลตได้",
+		"FAILED_TO_RESERVE_DOCUMENT_NUMBER":                              "ไม่สามารถจองเลขที่เอกสารได้",
+		"FAILED_TO_RESOLVE_INCLUDED_TEMPLATES":                           "ไม่สามารถรวมเทมเพลตที่อ้างถึงได้",
+		"FAILED_TO_REVIEW_TEMPLATE":                                      "ไม่สามารถตรวจสอบเทมเพลตได้",
+		"FAILED_TO_REVOKE_TEMPLATE_SHARE":                                "ไม่สามารถยกเลิกการแชร์เทมเพลตได้",
+		"FAILED_TO_REVOKE_TOKEN":                                         "ไม่สามารถเพิกถอนโทเค็นได้",
+		"FAILED_TO_ROTATE_TOKEN":                                         "ไม่สามารถหมุนเวียนโทเค็นได้",
+		"FAILED_TO_SAVE_CALIBRATION":                                     "ไม่สามารถบันทึกค่าการปรับเทียบได้",
+		"FAILED_TO_SAVE_FORM_SUBMISSION":                                 "ไม่สามารถบันทึกแบบฟอร์มที่ส่งได้",
+		"FAILED_TO_SAVE_GRID":                                            "ไม่สามารถบันทึกเส้นตารางได้",
+		"FAILED_TO_SAVE_SSO_CONFIG":                                      "ไม่สามารถบันทึกการตั้งค่า SSO ได้",
+		"FAILED_TO_SEARCH_DOCUMENTS":                                     "ไม่สามารถค้นหาเอกสารได้",
+		"FAILED_TO_SET_WORKSPACE_DOMAIN":                                 "ไม่สามารถตั้งโดเมนของพื้นที่ทำงานได้",
+		"FAILED_TO_SHARE_TEMPLATE":                                       "ไม่สามารถแชร์เทมเพลตได้",
+		"FAILED_TO_SIGN_PAGE_URLS":                                       "ไม่สามารถสร้าง URL ของหน้าได้",
+		"FAILED_TO_SNAP_FIELDS_TO_GRID":                                  "ไม่สามารถจัดฟิลด์ให้ตรงเส้นตารางได้",
+		"FAILED_TO_START_LOGIN":                                          "ไม่สามารถเริ่มการเข้าสู่ระบบได้",
+		"FAILED_TO_STORE_SIGNATURE":                                      "ไม่สามารถจัดเก็บลายมือชื่อได้",
+		"FAILED_TO_STORE_STAMP":                                          "ไม่สามารถจัดเก็บตราประทับได้",
+		"FAILED_TO_UPDATE_FIELDS":                                        "ไม่สามารถแก้ไขฟิลด์ได้",
+		"FAILED_TO_UPDATE_FORM_SUBMISSION":                               "ไม่สามารถแก้ไขแบบฟอร์มที่ส่งได้",
+		"FAILED_TO_UPDATE_IMPORT_PROFILE":                                "ไม่สามารถแก้ไขโปรไฟล์การนำเข้าได้",
+		"FAILED_TO_UPDATE_OPTION_LIST":                                   "ไม่สามารถแก้ไขรายการตัวเลือกได้",
+		"FAILED_TO_UPDATE_TEMPLATE":                                      "ไม่สามารถแก้ไขเทมเพลตได้",
+		"FAILED_TO_UPDATE_VISION_QUOTA":                                  "ไม่สามารถแก้ไขโควตา Vision ได้",
+		"FAILED_TO_UPLOAD_FILE":                                          "ไม่สามารถอัปโหลดไฟล์ได้",
+		"FAILED_TO_UPLOAD_FILES":                                         "ไม่สามารถอัปโหลดไฟล์ได้",
+		"FAILED_TO_VALIDATE_FORM_SUBMISSION":                             "ไม่สามารถตรวจสอบแบบฟอร์มที่ส่งได้",
+		"FAILED_TO_VALIDATE_SIGNATURES":                                  "ไม่สามารถตรวจสอบลายมือชื่อได้",
+		"FAILED_TO_VALIDATE_STAMPS":                                      "ไม่สามารถตรวจสอบตราประทับได้",
+		"FAILED_TO_VERIFY_SNAPSHOT":                                      "ไม่สามารถตรวจสอบสแนปช็อตได้",
+		"FAILED_TO_VERIFY_SNAPSHOTS":                                     "ไม่สามารถตรวจสอบสแนปช็อตได้",
+		"FAILED_TO_VERIFY_WORKSPACE_DOMAIN":                              "ไม่สามารถยืนยันโดเมนของพื้นที่ทำงานได้",
+		"FIELD_NOT_FOUND":                                                "ไม่พบฟิลด์",
+		"FILE_IS_TOO_LARGE":                                              "ไฟล์มีขนาดใหญ่เกินไป",
+		"FILE_MUST_BE_A_PDF":                                             "ไฟล์ต้องเป็น PDF",
+		"FILE_MUST_BE_AN_SVG":                                            "ไฟล์ต้องเป็น SVG",
+		"FILL_LINK_NOT_FOUND":                                            "ไม่พบลิงก์กรอกแบบฟอร์ม",
+		"FORM_DATA_INVALID":                                              "ข้อมูลแบบฟอร์มไม่ตรงกับฟิลด์ของเทมเพลต",
+		"FORM_SUBMISSION_HAS_EXPIRED":                                    "แบบฟอร์มที่ส่งหมดอายุแล้ว",
+		"FORM_SUBMISSION_NOT_FOUND":                                      "ไม่พบแบบฟอร์มที่ส่ง",
+		"GENERATION_JOB_NOT_FOUND":                                       "ไม่พบงานสร้างเอกสาร",
+		"IDENTITY_PROVIDER_REJECTED_THE_LOGIN":                           "ผู้ให้บริการยืนยันตัวตนปฏิเสธการเข้าสู่ระบบ",
+		"IMAGE_IS_TOO_LARGE":                                             "รูปภาพมีขนาดใหญ่เกินไป",
+		"IMPORT_PROFILE_NOT_FOUND":                                       "ไม่พบโปรไฟล์การนำเข้า",
+		"INTEGRATION_NOT_FOUND":                                          "ไม่พบการเชื่อมต่อ",
+		"INVALID_API_KEY":                                                "API key ไม่ถูกต้อง",
+		"INVALID_ARCHIVED":                                               "ค่า archived ไม่ถูกต้อง",
+		"INVALID_BUNDLE":                                                 "ชุดข้อมูลไม่ถูกต้อง",
+		"INVALID_CUSTOM_CSS":                                             "CSS ที่กำหนดเองไม่ถูกต้อง",
+		"INVALID_DATA_KEY":                                               "dataKey ไม่ถูกต้อง",
+		"INVALID_DUPLICATE_POLICY":                                       "นโยบายการตรวจข้อมูลซ้ำไม่ถูกต้อง",
+		"INVALID_E_FILING_EXPORT":                                        "การตั้งค่าส่งออกสำหรับยื่นแบบอิเล็กทรอนิกส์ไม่ถูกต้อง",
+		"INVALID_FIELD":                                                  "ฟิลด์ไม่ถูกต้อง",
+		"INVALID_FILE":                                                   "ไฟล์ไม่ถูกต้อง",
+		"INVALID_FILL_LINK_ID":                                           "รหัสลิงก์กรอกแบบฟอร์มไม่ถูกต้อง",
+		"INVALID_FROM_REVISION":                                          "ฉบับแก้ไขต้นทางไม่ถูกต้อง",
+		"INVALID_HTML_DATA":                                              "htmlData ไม่ถูกต้อง",
+		"INVALID_IF_MATCH":                                               "ส่วนหัว If-Match ไม่ถูกต้อง",
+		"INVALID_IMAGE":                                                  "รูปภาพไม่ถูกต้อง",
+		"INVALID_INCLUDE":                                                "เทมเพลตที่รวมไม่ถูกต้อง",
+		"INVALID_INCLUDE_ID":                                             "รหัสเทมเพลตที่รวมไม่ถูกต้อง",
+		"INVALID_INTEGRATION_ID":                                         "รหัสการเชื่อมต่อไม่ถูกต้อง",
+		"INVALID_JSON":                                                   "JSON ไม่ถูกต้อง",
+		"INVALID_LAYOUT":                                                 "เลย์เอาต์ไม่ถูกต้อง",
+		"INVALID_LIMIT":                                                  "ค่า limit ไม่ถูกต้อง",
+		"INVALID_LOGIN_STATE":                                            "สถานะการเข้าสู่ระบบไม่ถูกต้อง",
+		"INVALID_MAPPING":                                                "การจับคู่ข้อมูลไม่ถูกต้อง",
+		"INVALID_MARKS":                                                  "เครื่องหมายไม่ถูกต้อง",
+		"INVALID_MULTIPART_FORM":                                         "ฟอร์ม multipart ไม่ถูกต้อง",
+		"INVALID_OFFSET":                                                 "ค่า offset ไม่ถูกต้อง",
+		"INVALID_OPTION_LIST":                                            "รายการตัวเลือกไม่ถูกต้อง",
+		"INVALID_OR_EXPIRED_SESSION":                                     "เซสชันไม่ถูกต้องหรือหมดอายุแล้ว",
+		"INVALID_OR_EXPIRED_TOKEN":                                       "โทเค็นไม่ถูกต้องหรือหมดอายุแล้ว",
+		"INVALID_PAGE":                                                   "ค่า page ไม่ถูกต้อง",
+		"INVALID_PAGE_INDEX":                                             "หมายเลขหน้าไม่ถูกต้อง",
+		"INVALID_PAGE_SIZE":                                              "ค่า pageSize ไม่ถูกต้อง",
+		"INVALID_PAGINATION":                                             "การแบ่งหน้าไม่ถูกต้อง",
+		"INVALID_PERIOD":                                                 "ช่วงเวลาไม่ถูกต้อง ต้องอยู่ในรูปแบบ YYYY-MM",
+		"INVALID_REFERENCE_MARKS":                                        "เครื่องหมายอ้างอิงไม่ถูกต้อง",
+		"INVALID_REQUEST_BODY":                                           "เนื้อหาคำขอไม่ถูกต้อง",
+		"INVALID_REQUEST_DATA":                                           "ข้อมูลคำขอไม่ถูกต้อง",
+		"INVALID_RESERVATION_ID":                                         "รหัสการจองไม่ถูกต้อง",
+		"INVALID_RETURN_TO":                                              "returnTo ต้องชี้ไปยังต้นทางฟรอนต์เอนด์ที่อนุญาต",
+		"INVALID_SCAN":                                                   "ภาพสแกนไม่ถูกต้อง",
+		"INVALID_SCRIPT_FONTS":                                           "การตั้งค่าฟอนต์ตามชุดอักษรไม่ถูกต้อง",
+		"INVALID_SIGNATURE":                                              "ลายมือชื่อไม่ถูกต้อง",
+		"INVALID_SORT":                                                   "ค่า sort ไม่ถูกต้อง",
+		"INVALID_SINCE_CURSOR":                                           "ค่า since ไม่ถูกต้อง",
+		"INVALID_STAMP":                                                  "ตราประทับไม่ถูกต้อง",
+		"INVALID_STATUS":                                                 "สถานะไม่ถูกต้อง",
+		"INVALID_SVG_FILE_ID":                                            "รหัสไฟล์ SVG ไม่ถูกต้อง",
+		"INVALID_TO_REVISION":                                            "ฉบับแก้ไขปลายทางไม่ถูกต้อง",
+		"INVALID_UPDATE":                                                 "การแก้ไขไม่ถูกต้อง",
+		"INVALID_VALIDATION_WEBHOOK":                                     "เว็บฮุกตรวจสอบข้อมูลไม่ถูกต้อง",
+		"LOCKED_FIELDS_CANNOT_BE_CHANGED":                                "ไม่สามารถแก้ไขฟิลด์ที่ถูกล็อกได้",
+		"NOT_FOUND":                                                      "ไม่พบข้อมูล",
+		"NO_FILES_UPLOADED":                                              "ไม่มีไฟล์ที่อัปโหลด",
+		"NO_FILE_UPLOADED":                                               "ไม่มีไฟล์ที่อัปโหลด",
+		"NO_IMAGE_UPLOADED":                                              "ไม่มีรูปภาพที่อัปโหลด",
+		"NO_SCAN_UPLOADED":                                               "ไม่มีภาพสแกนที่อัปโหลด",
+		"NO_STORED_DOCUMENT_FOR_THIS_SUBMISSION":                         "ไม่มีเอกสารที่จัดเก็บไว้สำหรับข้อมูลที่ส่งนี้",
+		"NO_SUBMISSIONS_TO_GENERATE":                                     "ไม่มีรายการส่งแบบฟอร์มให้สร้าง PDF",
+		"OCR_IS_NOT_AVAILABLE":                                           "ไม่สามารถใช้งาน OCR ได้",
+		"ONLY_THE_ASSIGNEE_CAN_COMPLETE_THIS_ASSIGNMENT":                 "เฉพาะผู้ได้รับมอบหมายเท่านั้นที่ปิดงานนี้ได้",
+		"ONLY_THE_OIDC_PROTOCOL_IS_CURRENTLY_SUPPORTED":                  "ขณะนี้รองรับเฉพาะโปรโตคอล oidc",
+		"OPTION_LIST_IS_USED_BY_TEMPLATE_FIELDS":                         "รายการตัวเลือกนี้ถูกใช้โดยฟิลด์ในเทมเพลต",
+		"OPTION_LIST_NOT_FOUND":                                          "ไม่พบรายการตัวเลือก",
+		"PDF_BACKGROUNDS_ARE_NOT_CONFIGURED":                             "ยังไม่ได้ตั้งค่าการแปลง PDF เป็นพื้นหลัง",
+		"PAGE_NOT_FOUND":                                                 "ไม่พบหน้า",
+		"PREVIEW_SESSION_NOT_FOUND_OR_EXPIRED":                           "ไม่พบเซสชันแสดงตัวอย่างหรือหมดอายุแล้ว",
+		"PROFILE_OR_MAPPING_REQUIRED":                                    "ต้องระบุ profileId หรือ mapping",
+		"REGENERATION_JOB_NOT_FOUND":                                     "ไม่พบงานสร้างเอกสารใหม่",
+		"REQUEST_TIMEOUT":                                                "คำขอใช้เวลานานเกินกำหนด",
+		"SCAN_IS_TOO_LARGE":                                              "ภาพสแกนมีขนาดใหญ่เกินไป",
+		"SELECTED_SVG_FILE_DOES_NOT_EXIST":                               "ไม่มีไฟล์ SVG ที่เลือก",
+		"SESSION_ID_REQUIRED":                                            "ต้องระบุ sessionId",
+		"SIGNATURE_FIELDS_REFER_TO_UNUSABLE_SIGNATURES":                  "ฟิลด์ลายมือชื่ออ้างถึงลายมือชื่อที่ใช้ไม่ได้",
+		"SIGNATURE_HAS_BEEN_INVALIDATED":                                 "ลายมือชื่อถูกยกเลิกแล้ว",
+		"SIGNATURE_NOT_FOUND":                                            "ไม่พบลายมือชื่อ",
+		"SSO_IS_NOT_CONFIGURED_FOR_THIS_WORKSPACE":                       "พื้นที่ทำงานนี้ยังไม่ได้ตั้งค่า SSO",
+		"SSO_IS_NOT_ENABLED_FOR_THIS_WORKSPACE":                          "พื้นที่ทำงานนี้ยังไม่ได้เปิดใช้ SSO",
+		"STAMP_FIELDS_REFER_TO_UNKNOWN_STAMPS":                           "ฟิลด์ตราประทับอ้างถึงตราประทับที่ไม่มีอยู่",
+		"STAMP_IS_USED_BY_TEMPLATE_FIELDS":                               "ตราประทับนี้ถูกใช้โดยฟิลด์ในเทมเพลต",
+		"STAMP_NAME_IS_REQUIRED":                                         "ต้องระบุชื่อตราประทับ",
+		"STAMP_NOT_FOUND":                                                "ไม่พบตราประทับ",
+		"SUBMISSION_IS_MISSING_REQUIRED_E_FILING_VALUES":                 "ข้อมูลที่ส่งขาดค่าที่จำเป็นสำหรับยื่นแบบอิเล็กทรอนิกส์",
+		"SUBMISSION_REJECTED_BY_VALIDATION_WEBHOOK":                      "ข้อมูลที่ส่งถูกปฏิเสธโดยเว็บฮุกตรวจสอบข้อมูล",
+		"SUBMITTED_VALUES_ARE_NOT_IN_THEIR_OPTION_LISTS":                 "ค่าที่ส่งไม่อยู่ในรายการตัวเลือก",
+		"SUGGESTIONS_ARE_NOT_OFFERED_FOR_THIS_FIELD":                     "ฟิลด์นี้ไม่มีคำแนะนำ",
+		"SUPPORT_ACCESS_IS_NOT_ENABLED":                                  "ยังไม่ได้เปิดใช้การเข้าถึงของฝ่ายสนับสนุน",
+		"SVG_FILE_NOT_FOUND":                                             "ไม่พบไฟล์ SVG",
+		"SVG_FILE_NOT_FOUND_FOR_THIS_PAGE":                               "ไม่พบไฟล์ SVG ของหน้านี้",
+		"TEMPLATE_HAS_NO_E_FILING_EXPORT":                                "เทมเพลตนี้ไม่มีการตั้งค่าส่งออกสำหรับยื่นแบบอิเล็กทรอนิกส์",
+		"TEMPLATE_IS_INCLUDED_BY_OTHER_TEMPLATES":                        "เทมเพลตนี้ถูกรวมอยู่ในเทมเพลตอื่น",
+		"TEMPLATE_IS_SHARED_WITH_YOUR_WORKSPACE_WITHOUT_THIS_PERMISSION": "เทมเพลตนี้แชร์กับพื้นที่ทำงานของคุณโดยไม่มีสิทธิ์นี้",
+		"TEMPLATE_NOT_FOUND":                                             "ไม่พบเทมเพลต",
+		"TEMPLATE_SHARE_NOT_FOUND":                                       "ไม่พบการแชร์เทมเพลต",
+		"TEMPLATE_SYNC_IS_NOT_ENABLED":                                   "ยังไม่ได้เปิดใช้การซิงก์เทมเพลต",
+		"TEMPLATE_WAS_MODIFIED_BY_ANOTHER_EDITOR":                        "เทมเพลตถูกแก้ไขโดยผู้ใช้อื่น",
+		"TOKEN_CANNOT_GRANT_SCOPES_IT_DOES_NOT_HAVE":                     "โทเค็นไม่สามารถให้สิทธิ์ที่ตนเองไม่มีได้",
+		"TOKEN_HAS_BEEN_REVOKED":                                         "โทเค็นถูกเพิกถอนแล้ว",
+		"TOKEN_IS_MISSING_REQUIRED_SCOPE":                                "โทเค็นไม่มีสิทธิ์ที่จำเป็น",
+		"TOKEN_NOT_FOUND":                                                "ไม่พบโทเค็น",
+		"TOO_MANY_FAILED_AUTHENTICATION_ATTEMPTS":                        "ยืนยันตัวตนไม่สำเร็จหลายครั้งเกินไป",
+		"TOO_MANY_REQUESTS":                                              "มีคำขอมากเกินไป",
+		"TOO_MANY_SUBMISSIONS":                                           "มีรายการส่งแบบฟอร์มมากเกินไป",
+		"UNKNOWN_SUBMISSIONS":                                            "ไม่พบรายการส่งแบบฟอร์มบางรายการ",
+		"UNSUPPORTED_EXPORT_FORMAT":                                      "ไม่รองรับรูปแบบการส่งออกนี้",
+		"UPLOAD_NOT_FOUND":                                               "ไม่พบไฟล์ที่อัปโหลด",
+		"WORKSPACE_HAS_NO_CUSTOM_DOMAIN":                                 "พื้นที่ทำงานไม่มีโดเมนที่กำหนดเอง",
+		"WORKSPACE_NOT_FOUND":                                            "ไม่พบพื้นที่ทำงาน",
+	},
+}
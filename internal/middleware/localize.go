@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// errorLanguages are the languages error messages are written in, English
+// first as the default.
+var errorLanguages = []language.Tag{language.English, language.Thai}
+
+var errorLanguageMatcher = language.NewMatcher(errorLanguages)
+
+// errorLanguage picks the language of error messages from the Accept-Language
+// header: "th" for Thai, "" for English.
+func errorLanguage(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return ""
+	}
+	_, index, confidence := errorLanguageMatcher.Match(tags...)
+	if confidence < language.High || index == 0 {
+		return ""
+	}
+	base, _ := errorLanguages[index].Base()
+	return base.String()
+}
+
+// LocalizeErrors adds a stable "code" to JSON error responses whose message is
+// in the catalog, and translates the message into the language asked for
+// with Accept-Language. Responses that already carry a code keep it, and
+// "details" are left as they are. Successful responses are not buffered.
+func LocalizeErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writer := &localizingWriter{ResponseWriter: c.Writer, lang: errorLanguage(c.GetHeader("Accept-Language"))}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+		writer.commit()
+	}
+}
+
+// localizingWriter holds back JSON error bodies until the handler is done, so
+// their message can be rewritten.
+type localizingWriter struct {
+	gin.ResponseWriter
+
+	lang      string
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (w *localizingWriter) WriteHeader(status int) {
+	w.buffering = status >= 400 && !w.ResponseWriter.Written()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *localizingWriter) WriteHeaderNow() {
+	if !w.buffering {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *localizingWriter) Write(data []byte) (int, error) {
+	if w.buffering && w.body.Len() == 0 && !isJSON(w.Header().Get("Content-Type")) {
+		w.buffering = false
+	}
+	if !w.buffering {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *localizingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *localizingWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+func (w *localizingWriter) Size() int {
+	if w.body.Len() > 0 {
+		return w.body.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+// Flush sends a buffered error right away, as the request timeout does.
+func (w *localizingWriter) Flush() {
+	w.commit()
+	w.ResponseWriter.Flush()
+}
+
+// commit writes the buffered error body with its code and translation.
+func (w *localizingWriter) commit() {
+	if !w.buffering {
+		return
+	}
+	w.buffering = false
+	if w.body.Len() == 0 {
+		return
+	}
+
+	body := w.body.Bytes()
+	if localized, ok := localizeError(body, w.lang); ok {
+		body = localized
+		if w.lang != "" {
+			w.Header().Set("Content-Language", w.lang)
+		}
+	}
+	w.Header().Add("Vary", "Accept-Language")
+	w.ResponseWriter.Write(body)
+}
+
+// localizeError rewrites an error body whose message is in the catalog.
+func localizeError(body []byte, lang string) ([]byte, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, false
+	}
+	var message string
+	if err := json.Unmarshal(fields["error"], &message); err != nil {
+		return nil, false
+	}
+	code, ok := errorCodes[message]
+	if !ok {
+		return nil, false
+	}
+
+	if _, ok := fields["code"]; !ok {
+		fields["code"], _ = json.Marshal(code)
+	}
+	if translated, ok := errorCatalogs[lang][code]; ok {
+		fields["error"], _ = json.Marshal(translated)
+	}
+
+	localized, err := json.Marshal(fields)
+	if err != nil {
+		return nil, false
+	}
+	return localized, true
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/dhanavadh/fastfill-backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit rejects clients that exceed the limiter's quota, keyed by client IP.
+func RateLimit(limiter *services.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		wait, ok := limiter.Allow(c.ClientIP())
+		if ok {
+			c.Next()
+			return
+		}
+
+		seconds := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+			"error":      "Too many requests",
+			"retryAfter": seconds,
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Origins is the list of allowed CORS origins, replaceable while serving.
+type Origins struct {
+	list atomic.Pointer[[]string]
+}
+
+func NewOrigins(origins []string) *Origins {
+	o := &Origins{}
+	o.Set(origins)
+	return o
+}
+
+func (o *Origins) Set(origins []string) {
+	o.list.Store(&origins)
+}
+
+// Allow reports whether origin is in the list, ignoring case and a trailing slash.
+func (o *Origins) Allow(origin string) bool {
+	origin = strings.TrimSuffix(origin, "/")
+	for _, allowed := range *o.list.Load() {
+		if strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireFeature answers 404 while a feature is switched off, as if its
+// routes did not exist, so feature flags can change without a restart.
+func RequireFeature(enabled *atomic.Bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled.Load() {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+		c.Next()
+	}
+}
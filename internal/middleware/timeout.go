@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Timeouts holds the time budget of each route: a default, and overrides by
+// route pattern as registered with gin (c.FullPath()). A zero budget leaves a
+// route without a timeout, for streaming responses.
+type Timeouts struct {
+	Default time.Duration
+	routes  map[string]time.Duration
+}
+
+func NewTimeouts(budget time.Duration) *Timeouts {
+	return &Timeouts{Default: budget, routes: make(map[string]time.Duration)}
+}
+
+// Set gives routes a budget other than the default.
+func (t *Timeouts) Set(budget time.Duration, routes ...string) {
+	for _, route := range routes {
+		t.routes[route] = budget
+	}
+}
+
+// Budget returns the time budget of a route.
+func (t *Timeouts) Budget(route string) time.Duration {
+	if budget, ok := t.routes[route]; ok {
+		return budget
+	}
+	return t.Default
+}
+
+// Timeout enforces each route's budget. The request context is cancelled when
+// the budget runs out, so renders and queries using it stop, and the client
+// gets 504 with code REQUEST_TIMEOUT straight away. Handlers write into a
+// buffer that is sent once they finish in time and dropped otherwise; the
+// request ends once the handler has returned.
+func Timeout(timeouts *Timeouts) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		budget := timeouts.Budget(c.FullPath())
+		if budget <= 0 {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), budget)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		writer := c.Writer
+		buffered := &timeoutWriter{ResponseWriter: writer, header: make(http.Header), status: http.StatusOK}
+		c.Writer = buffered
+
+		done := make(chan struct{})
+		var panicked interface{}
+		go func() {
+			defer close(done)
+			defer func() { panicked = recover() }()
+			c.Next()
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+			if buffered.expire() {
+				writer.Header().Set("Content-Type", "application/json; charset=utf-8")
+				writer.WriteHeader(http.StatusGatewayTimeout)
+				fmt.Fprintf(writer, `{"error":"Request timed out","code":"REQUEST_TIMEOUT","timeout":%q}`, budget.String())
+				writer.Flush()
+			}
+			<-done
+		}
+
+		c.Writer = writer
+		if panicked != nil {
+			panic(panicked)
+		}
+		buffered.send()
+	}
+}
+
+// timeoutWriter buffers a response until the handler finishes or the budget
+// runs out, whichever comes first.
+type timeoutWriter struct {
+	gin.ResponseWriter
+
+	mu      sync.Mutex
+	header  http.Header
+	body    bytes.Buffer
+	status  int
+	written bool
+	expired bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		w.status = status
+	}
+}
+
+func (w *timeoutWriter) WriteHeaderNow() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.written = true
+}
+
+func (w *timeoutWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired {
+		return 0, http.ErrHandlerTimeout
+	}
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *timeoutWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *timeoutWriter) Status() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+func (w *timeoutWriter) Size() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *timeoutWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.written
+}
+
+// Flush is a no-op: the response is sent whole once the handler is done.
+func (w *timeoutWriter) Flush() {}
+
+// expire drops the buffered response, reporting whether it had not been
+// sent yet.
+func (w *timeoutWriter) expire() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired {
+		return false
+	}
+	w.expired = true
+	return true
+}
+
+// send writes the buffered response, unless the budget ran out first.
+func (w *timeoutWriter) send() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.expired {
+		return
+	}
+	w.expired = true
+
+	header := w.ResponseWriter.Header()
+	for key, values := range w.header {
+		header[key] = values
+	}
+	if !w.written && w.body.Len() == 0 {
+		if w.status != http.StatusOK {
+			w.ResponseWriter.WriteHeader(w.status)
+			w.ResponseWriter.WriteHeaderNow()
+		}
+		return
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.body.Bytes())
+}
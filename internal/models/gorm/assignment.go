@@ -0,0 +1,37 @@
+package gorm
+
+import (
+	"time"
+)
+
+// Assignment statuses.
+const (
+	AssignmentOpen = "open"
+	AssignmentDone = "done"
+)
+
+// Assignment puts a submission in a staff user's work queue. A submission has
+// at most one assignment; reassigning it moves it to another queue.
+type Assignment struct {
+	ID           uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	SubmissionID string     `gorm:"size:36;not null;uniqueIndex" json:"submissionId"`
+	TemplateID   string     `gorm:"size:36;not null;index" json:"templateId"`
+	WorkspaceID  string     `gorm:"size:36;not null;index" json:"workspaceId"`
+	AssigneeID   string     `gorm:"size:36;not null;index:idx_assignment_queue" json:"assigneeId"`
+	AssignedBy   string     `gorm:"size:36" json:"assignedBy,omitempty"`
+	Status       string     `gorm:"size:16;not null;default:open;index:idx_assignment_queue" json:"status"`
+	DueAt        *time.Time `json:"dueAt,omitempty"`
+	Note         string     `gorm:"size:1024" json:"note,omitempty"`
+	CompletedAt  *time.Time `json:"completedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+// Overdue reports whether the assignment is still open past its due date.
+func (a *Assignment) Overdue(now time.Time) bool {
+	return a.Status == AssignmentOpen && a.DueAt != nil && now.After(*a.DueAt)
+}
+
+func (Assignment) TableName() string {
+	return "assignments"
+}
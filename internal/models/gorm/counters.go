@@ -0,0 +1,37 @@
+package gorm
+
+import (
+	gormdb "gorm.io/gorm"
+)
+
+// AfterCreate counts the submission on its template, in the same transaction.
+func (s *FormSubmission) AfterCreate(tx *gormdb.DB) error {
+	return tx.Exec("UPDATE templates SET submission_count = submission_count + 1, "+
+		"last_submission_at = GREATEST(COALESCE(last_submission_at, ?), ?) WHERE id = ?",
+		s.CreatedAt, s.CreatedAt, s.TemplateID).Error
+}
+
+// AfterDelete uncounts a deleted submission. Deletes by condition, which
+// carry no TemplateID, are not counted and must recount instead.
+func (s *FormSubmission) AfterDelete(tx *gormdb.DB) error {
+	if s.TemplateID == "" || tx.Statement.RowsAffected == 0 {
+		return nil
+	}
+	return tx.Exec("UPDATE templates SET submission_count = GREATEST(submission_count - 1, 0), "+
+		"last_submission_at = (SELECT MAX(created_at) FROM form_submissions WHERE template_id = ?) WHERE id = ?",
+		s.TemplateID, s.TemplateID).Error
+}
+
+// AfterCreate counts the document on its template, in the same transaction.
+func (d *GeneratedDocument) AfterCreate(tx *gormdb.DB) error {
+	return tx.Exec("UPDATE templates SET document_count = document_count + 1 WHERE id = ?", d.TemplateID).Error
+}
+
+// RecountTemplateStats recomputes every template's counters from the
+// submission and document tables.
+func RecountTemplateStats(db *gormdb.DB) error {
+	return db.Exec("UPDATE templates SET " +
+		"submission_count = (SELECT COUNT(*) FROM form_submissions WHERE form_submissions.template_id = templates.id), " +
+		"last_submission_at = (SELECT MAX(created_at) FROM form_submissions WHERE form_submissions.template_id = templates.id), " +
+		"document_count = (SELECT COUNT(*) FROM generated_documents WHERE generated_documents.template_id = templates.id)").Error
+}
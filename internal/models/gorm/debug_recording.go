@@ -0,0 +1,40 @@
+package gorm
+
+import (
+	"time"
+)
+
+// DebugRecordingWindow is a workspace's opt-in to debug recording: until
+// ExpiresAt, generation and OCR requests of the workspace are recorded.
+type DebugRecordingWindow struct {
+	WorkspaceID string    `gorm:"primaryKey;size:36" json:"workspaceId"`
+	EnabledBy   string    `gorm:"size:36" json:"enabledBy"`
+	Reason      string    `gorm:"size:500" json:"reason,omitempty"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// DebugRecording is one sanitized request/response pair recorded while a
+// workspace's window was open. Binary bodies are summarized, not stored.
+type DebugRecording struct {
+	ID           string    `gorm:"primaryKey;size:36" json:"id"`
+	WorkspaceID  string    `gorm:"size:36;not null;index:idx_debug_recordings_workspace,priority:1" json:"workspaceId"`
+	UserID       string    `gorm:"size:36" json:"userId,omitempty"`
+	Method       string    `gorm:"size:10;not null" json:"method"`
+	Path         string    `gorm:"size:500;not null" json:"path"`
+	Status       int       `json:"status"`
+	DurationMS   int64     `json:"durationMs"`
+	RequestType  string    `gorm:"size:255" json:"requestType,omitempty"`
+	RequestBody  string    `gorm:"type:mediumtext" json:"requestBody,omitempty"`
+	ResponseType string    `gorm:"size:255" json:"responseType,omitempty"`
+	ResponseBody string    `gorm:"type:mediumtext" json:"responseBody,omitempty"`
+	CreatedAt    time.Time `gorm:"index:idx_debug_recordings_workspace,priority:2" json:"createdAt"`
+}
+
+func (DebugRecordingWindow) TableName() string {
+	return "debug_recording_windows"
+}
+
+func (DebugRecording) TableName() string {
+	return "debug_recordings"
+}
@@ -0,0 +1,94 @@
+package gorm
+
+import (
+	"time"
+)
+
+// GeneratedDocument is a rendered PDF stored for a submission.
+type GeneratedDocument struct {
+	ID           string `gorm:"primaryKey" json:"id"`
+	SubmissionID string `gorm:"not null;index" json:"submissionId"`
+	TemplateID   string `gorm:"not null;index" json:"templateId"`
+	GCSPath      string `gorm:"not null" json:"gcsPath"`
+	KMSKeyName   string `gorm:"size:512" json:"kmsKeyName,omitempty"`
+	FileSize     int64  `json:"fileSize"`
+	HTMLHash     string `gorm:"size:64" json:"htmlHash"`
+	// A document under legal hold must be kept until the hold is released.
+	LegalHoldAt     *time.Time `gorm:"index" json:"legalHoldAt,omitempty"`
+	LegalHoldReason string     `gorm:"size:512" json:"legalHoldReason,omitempty"`
+	LegalHoldBy     string     `gorm:"size:36" json:"legalHoldBy,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+
+	Submission FormSubmission `gorm:"foreignKey:SubmissionID" json:"-"`
+}
+
+// DocumentText is the text layer of a generated document, indexed for
+// full-text search. The ngram parser lets MySQL match Thai, which has no
+// spaces between words.
+type DocumentText struct {
+	DocumentID   string    `gorm:"primaryKey;size:191" json:"documentId"`
+	SubmissionID string    `gorm:"size:191;not null;index" json:"submissionId"`
+	TemplateID   string    `gorm:"size:191;not null;index" json:"templateId"`
+	Content      string    `gorm:"type:longtext;index:idx_document_texts_content,class:FULLTEXT,option:WITH PARSER ngram" json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// RegenerationJob tracks a bulk re-render of stored documents for a template.
+type RegenerationJob struct {
+	ID            string     `gorm:"primaryKey" json:"id"`
+	TemplateID    string     `gorm:"not null;index" json:"templateId"`
+	Status        string     `gorm:"default:queued" json:"status"`
+	SubmissionIDs []string   `gorm:"serializer:json" json:"submissionIds,omitempty"`
+	Total         int        `json:"total"`
+	Processed     int        `json:"processed"`
+	Changed       int        `json:"changed"`
+	Unchanged     int        `json:"unchanged"`
+	Failed        int        `json:"failed"`
+	Errors        []string   `gorm:"serializer:json" json:"errors,omitempty"`
+	StartedAt     *time.Time `json:"startedAt,omitempty"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// Delivery modes of a generation callback: the PDF itself as the body, or a
+// JSON body with a signed URL of the stored document.
+const (
+	CallbackDeliveryPDF = "pdf"
+	CallbackDeliveryURL = "url"
+)
+
+// GenerationJob renders a submission's PDF in the background and delivers it
+// to the caller's callback URL.
+type GenerationJob struct {
+	ID           string     `gorm:"primaryKey" json:"id"`
+	SubmissionID string     `gorm:"not null;index" json:"submissionId"`
+	TemplateID   string     `gorm:"not null;index" json:"templateId"`
+	Status       string     `gorm:"default:queued" json:"status"`
+	CallbackURL  string     `gorm:"size:2048;not null" json:"callbackUrl"`
+	Delivery     string     `gorm:"size:16;default:pdf" json:"delivery"`
+	DocumentID   string     `gorm:"size:36" json:"documentId,omitempty"`
+	Attempts     int        `json:"attempts"`
+	LastError    string     `gorm:"type:text" json:"lastError,omitempty"`
+	StartedAt    *time.Time `json:"startedAt,omitempty"`
+	DeliveredAt  *time.Time `json:"deliveredAt,omitempty"`
+	FinishedAt   *time.Time `json:"finishedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+func (GeneratedDocument) TableName() string {
+	return "generated_documents"
+}
+
+func (RegenerationJob) TableName() string {
+	return "regeneration_jobs"
+}
+
+func (GenerationJob) TableName() string {
+	return "generation_jobs"
+}
+
+func (DocumentText) TableName() string {
+	return "document_texts"
+}
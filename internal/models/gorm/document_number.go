@@ -0,0 +1,48 @@
+package gorm
+
+import (
+	"time"
+)
+
+// Statuses of a NumberReservation.
+const (
+	NumberReserved  = "reserved"
+	NumberConfirmed = "confirmed"
+)
+
+// DocumentNumberCounter holds the last document number handed out for a
+// template. Its row is locked while a number is reserved.
+type DocumentNumberCounter struct {
+	TemplateID string    `gorm:"primaryKey;size:36" json:"templateId"`
+	LastNumber int64     `gorm:"not null;default:0" json:"lastNumber"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+func (DocumentNumberCounter) TableName() string {
+	return "document_number_counters"
+}
+
+// NumberReservation holds a document number of a template until the document
+// is generated. A reservation that is not confirmed by ExpiresAt lapses, and
+// its number is handed out again by a later reservation.
+type NumberReservation struct {
+	ID           uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	TemplateID   string     `gorm:"size:36;not null;uniqueIndex:idx_number_reservations_number" json:"templateId"`
+	Number       int64      `gorm:"not null;uniqueIndex:idx_number_reservations_number" json:"number"`
+	Status       string     `gorm:"size:16;not null;default:reserved" json:"status"`
+	ReservedBy   string     `gorm:"size:36" json:"reservedBy,omitempty"`
+	SubmissionID string     `gorm:"size:36;index" json:"submissionId,omitempty"`
+	ExpiresAt    time.Time  `gorm:"not null;index" json:"expiresAt"`
+	ConfirmedAt  *time.Time `json:"confirmedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	UpdatedAt    time.Time  `json:"updatedAt"`
+}
+
+func (NumberReservation) TableName() string {
+	return "number_reservations"
+}
+
+// Expired reports whether the reservation lapsed unconfirmed.
+func (r *NumberReservation) Expired(now time.Time) bool {
+	return r.Status == NumberReserved && !now.Before(r.ExpiresAt)
+}
@@ -0,0 +1,28 @@
+package gorm
+
+import (
+	"time"
+)
+
+// WorkspaceDomain is the custom domain a workspace's public links are built
+// on. It is used only once VerifiedAt is set, which requires the
+// verification token to be published in a DNS TXT record on the domain.
+type WorkspaceDomain struct {
+	WorkspaceID string     `gorm:"primaryKey;size:36" json:"workspaceId"`
+	Domain      string     `gorm:"size:253;not null;index" json:"domain"`
+	Token       string     `gorm:"size:64;not null" json:"-"`
+	VerifiedAt  *time.Time `json:"verifiedAt,omitempty"`
+	CheckedAt   *time.Time `json:"checkedAt,omitempty"`
+	CreatedBy   string     `gorm:"size:36" json:"createdBy,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+// Verified reports whether public links use the domain.
+func (d *WorkspaceDomain) Verified() bool {
+	return d.VerifiedAt != nil
+}
+
+func (WorkspaceDomain) TableName() string {
+	return "workspace_domains"
+}
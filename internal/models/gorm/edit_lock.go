@@ -0,0 +1,26 @@
+package gorm
+
+import (
+	"time"
+)
+
+// TemplateLock is an advisory editing lock on a template. It expires unless
+// the holder renews it with heartbeats; expired rows are taken over in place.
+type TemplateLock struct {
+	TemplateID string    `gorm:"primaryKey;size:36" json:"templateId"`
+	SessionID  string    `gorm:"size:64;not null" json:"-"`
+	UserID     string    `gorm:"size:36" json:"userId,omitempty"`
+	HolderName string    `gorm:"size:255" json:"holderName,omitempty"`
+	AcquiredAt time.Time `gorm:"not null" json:"acquiredAt"`
+	RenewedAt  time.Time `gorm:"not null" json:"renewedAt"`
+	ExpiresAt  time.Time `gorm:"not null;index" json:"expiresAt"`
+}
+
+func (TemplateLock) TableName() string {
+	return "template_locks"
+}
+
+// Expired reports whether the lock has lapsed at the given time.
+func (l *TemplateLock) Expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
@@ -0,0 +1,31 @@
+package gorm
+
+import (
+	"time"
+)
+
+// Event types recorded in the outbox.
+const (
+	EventTemplateCreated = "template.created"
+	EventTemplateUpdated = "template.updated"
+	EventTemplateDeleted = "template.deleted"
+	EventFormSubmitted   = "form.submitted"
+	EventPDFGenerated    = "pdf.generated"
+)
+
+// Event is an entry in the events outbox. Sequence increases with every
+// event, so integrators can page through the events after the last one they
+// processed. Events belong to the workspace owning the template.
+type Event struct {
+	Sequence    uint64                 `gorm:"primaryKey;autoIncrement" json:"sequence"`
+	WorkspaceID string                 `gorm:"size:36;index" json:"workspaceId,omitempty"`
+	Type        string                 `gorm:"size:64;not null;index" json:"type"`
+	ResourceID  string                 `gorm:"size:36;not null" json:"resourceId"`
+	TemplateID  string                 `gorm:"size:36;index" json:"templateId,omitempty"`
+	Data        map[string]interface{} `gorm:"serializer:json" json:"data,omitempty"`
+	CreatedAt   time.Time              `gorm:"index" json:"createdAt"`
+}
+
+func (Event) TableName() string {
+	return "events"
+}
@@ -0,0 +1,32 @@
+package gorm
+
+import (
+	"time"
+)
+
+// FillLink lets anyone holding its token fill in a template without an
+// account. LockedValues are pre-filled by the template owner, keyed by
+// DataKey; the submitter sees them but cannot change them.
+type FillLink struct {
+	ID           uint                   `gorm:"primaryKey;autoIncrement" json:"id"`
+	TemplateID   string                 `gorm:"size:36;not null;index" json:"templateId"`
+	Name         string                 `json:"name"`
+	Token        string                 `gorm:"size:64;not null;uniqueIndex" json:"token"`
+	LockedValues map[string]interface{} `gorm:"serializer:json" json:"lockedValues"`
+	Status       string                 `gorm:"default:submitted" json:"status"`
+	ExpiresAt    *time.Time             `json:"expiresAt,omitempty"`
+	CreatedBy    string                 `gorm:"size:36" json:"createdBy,omitempty"`
+	CreatedAt    time.Time              `json:"createdAt"`
+	UpdatedAt    time.Time              `json:"updatedAt"`
+
+	Template Template `gorm:"foreignKey:TemplateID" json:"-"`
+}
+
+// Expired reports whether the link no longer takes submissions.
+func (l *FillLink) Expired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+func (FillLink) TableName() string {
+	return "fill_links"
+}
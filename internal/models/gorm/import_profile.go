@@ -0,0 +1,46 @@
+package gorm
+
+import (
+	"time"
+)
+
+// Column transforms applied to CSV values before they are stored.
+const (
+	TransformNone    = ""
+	TransformTrim    = "trim"
+	TransformUpper   = "upper"
+	TransformLower   = "lower"
+	TransformDate    = "date"
+	TransformNumber  = "number"
+	TransformBoolean = "boolean"
+)
+
+// ColumnMapping maps one CSV column, by header name, onto a field DataKey.
+// Format is the date layout of a date transform, written with YYYY, MM and
+// DD; without one, dates are read in the import locale.
+type ColumnMapping struct {
+	Column    string `json:"column"`
+	DataKey   string `json:"dataKey"`
+	Transform string `json:"transform,omitempty"`
+	Format    string `json:"format,omitempty"`
+}
+
+// ImportProfile is a named, reusable CSV column mapping for batch imports
+// into a template.
+type ImportProfile struct {
+	ID         string          `gorm:"primaryKey;size:36" json:"id"`
+	TemplateID string          `gorm:"size:36;not null;uniqueIndex:idx_import_profile_name" json:"templateId"`
+	Name       string          `gorm:"size:255;not null;uniqueIndex:idx_import_profile_name" json:"name"`
+	Columns    []ColumnMapping `gorm:"serializer:json" json:"columns"`
+	Locale     string          `gorm:"size:16" json:"locale,omitempty"`
+	Status     string          `gorm:"default:submitted" json:"status"`
+	CreatedBy  string          `gorm:"size:36" json:"createdBy,omitempty"`
+	CreatedAt  time.Time       `json:"createdAt"`
+	UpdatedAt  time.Time       `json:"updatedAt"`
+
+	Template Template `gorm:"foreignKey:TemplateID" json:"-"`
+}
+
+func (ImportProfile) TableName() string {
+	return "import_profiles"
+}
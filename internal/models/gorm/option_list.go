@@ -0,0 +1,32 @@
+package gorm
+
+import (
+	"time"
+)
+
+// OptionList is a managed set of select options shared by fields across
+// templates, such as company branches or provinces.
+type OptionList struct {
+	ID          string   `gorm:"primaryKey" json:"id"`
+	Name        string   `gorm:"not null" json:"name"`
+	Description string   `json:"description"`
+	Options     []string `gorm:"serializer:json" json:"options"`
+	// WorkspaceID owns the list; lists without one are shared by every caller.
+	WorkspaceID string    `gorm:"size:36;index;default:''" json:"workspaceId,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Contains reports whether value is one of the list's options.
+func (l *OptionList) Contains(value string) bool {
+	for _, option := range l.Options {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (OptionList) TableName() string {
+	return "option_lists"
+}
@@ -0,0 +1,42 @@
+package gorm
+
+import (
+	"time"
+)
+
+// Template share access levels.
+const (
+	// ShareRead lets the workspace view the template and fill it in.
+	ShareRead = "read"
+	// ShareCopy lets the workspace view the template and copy it into its
+	// own workspace, but not fill in the original.
+	ShareCopy = "copy"
+)
+
+// TemplateShare offers a template owned by one workspace to another. The
+// invite names either the workspace or the email address of a user who
+// accepts it for their workspace; the share grants access once accepted.
+type TemplateShare struct {
+	ID               string     `gorm:"primaryKey;size:36" json:"id"`
+	TemplateID       string     `gorm:"size:36;not null;index" json:"templateId"`
+	OwnerWorkspaceID string     `gorm:"size:36;not null;index" json:"ownerWorkspaceId"`
+	WorkspaceID      string     `gorm:"size:36;index" json:"workspaceId,omitempty"`
+	InviteEmail      string     `gorm:"size:255;index" json:"inviteEmail,omitempty"`
+	Access           string     `gorm:"size:16;not null" json:"access"`
+	InvitedBy        string     `gorm:"size:36" json:"invitedBy,omitempty"`
+	AcceptedBy       string     `gorm:"size:36" json:"acceptedBy,omitempty"`
+	AcceptedAt       *time.Time `json:"acceptedAt,omitempty"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+
+	Template Template `gorm:"foreignKey:TemplateID" json:"-"`
+}
+
+// Accepted reports whether the share grants access.
+func (s *TemplateShare) Accepted() bool {
+	return s.AcceptedAt != nil
+}
+
+func (TemplateShare) TableName() string {
+	return "template_shares"
+}
@@ -0,0 +1,36 @@
+package gorm
+
+import (
+	"time"
+)
+
+// Signature is a user's drawn signature, stored once and referenced by ID
+// from the formData of signature fields. The user consents to its reuse when
+// it is captured; invalidated signatures are kept for auditing but their
+// image is deleted and they are no longer rendered.
+type Signature struct {
+	ID            string     `gorm:"primaryKey;size:36" json:"id"`
+	UserID        string     `gorm:"size:36;not null;index" json:"userId"`
+	WorkspaceID   string     `gorm:"size:36;index" json:"workspaceId,omitempty"`
+	Name          string     `gorm:"size:255" json:"name,omitempty"`
+	MimeType      string     `gorm:"size:32;not null" json:"mimeType"`
+	FileSize      int64      `json:"fileSize"`
+	SHA256        string     `gorm:"size:64" json:"sha256"`
+	GCSPath       string     `gorm:"not null" json:"-"`
+	KMSKeyName    string     `gorm:"size:512" json:"kmsKeyName,omitempty"`
+	ConsentText   string     `gorm:"type:text" json:"consentText,omitempty"`
+	ConsentedAt   time.Time  `gorm:"not null" json:"consentedAt"`
+	ConsentIP     string     `gorm:"size:64" json:"consentIp,omitempty"`
+	InvalidatedAt *time.Time `json:"invalidatedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+func (Signature) TableName() string {
+	return "signatures"
+}
+
+// Valid reports whether the signature may still be used.
+func (s *Signature) Valid() bool {
+	return s.InvalidatedAt == nil
+}
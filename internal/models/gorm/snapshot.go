@@ -0,0 +1,37 @@
+package gorm
+
+import (
+	"time"
+)
+
+// Snapshot verification results.
+const (
+	SnapshotMatch   = "match"
+	SnapshotChanged = "changed"
+	SnapshotFailed  = "failed"
+)
+
+// TemplateSnapshot is the reference rendering of a template version: a
+// canonical sample data set and the hashes of the pages it rendered to.
+// Verification re-renders the sample and compares page by page.
+type TemplateSnapshot struct {
+	ID              string                 `gorm:"primaryKey;size:36" json:"id"`
+	TemplateID      string                 `gorm:"size:36;not null;uniqueIndex:idx_snapshot_version" json:"templateId"`
+	TemplateVersion int                    `gorm:"not null;uniqueIndex:idx_snapshot_version" json:"templateVersion"`
+	SampleData      map[string]interface{} `gorm:"serializer:json" json:"sampleData"`
+	FormattingData  map[string]interface{} `gorm:"serializer:json" json:"formattingData,omitempty"`
+	HtmlData        map[string]interface{} `gorm:"serializer:json" json:"htmlData,omitempty"`
+	HTMLHash        string                 `gorm:"size:64" json:"htmlHash"`
+	PageHashes      []string               `gorm:"serializer:json" json:"pageHashes"`
+	CreatedBy       string                 `gorm:"size:36" json:"createdBy,omitempty"`
+	LastResult      string                 `gorm:"size:16" json:"lastResult,omitempty"`
+	ChangedPages    []int                  `gorm:"serializer:json" json:"changedPages,omitempty"`
+	LastError       string                 `gorm:"type:text" json:"lastError,omitempty"`
+	VerifiedAt      *time.Time             `json:"verifiedAt,omitempty"`
+	CreatedAt       time.Time              `json:"createdAt"`
+	UpdatedAt       time.Time              `json:"updatedAt"`
+}
+
+func (TemplateSnapshot) TableName() string {
+	return "template_snapshots"
+}
@@ -0,0 +1,24 @@
+package gorm
+
+import (
+	"time"
+)
+
+// Stamp is a company stamp or seal uploaded once per workspace. Stamp fields
+// either name a stamp in StampID or take a stamp ID from formData.
+type Stamp struct {
+	ID          string    `gorm:"primaryKey;size:36" json:"id"`
+	WorkspaceID string    `gorm:"size:36;not null;index" json:"workspaceId"`
+	Name        string    `gorm:"size:255;not null" json:"name"`
+	MimeType    string    `gorm:"size:32;not null" json:"mimeType"`
+	FileSize    int64     `json:"fileSize"`
+	GCSPath     string    `gorm:"not null" json:"-"`
+	KMSKeyName  string    `gorm:"size:512" json:"kmsKeyName,omitempty"`
+	CreatedBy   string    `gorm:"size:36" json:"createdBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func (Stamp) TableName() string {
+	return "stamps"
+}
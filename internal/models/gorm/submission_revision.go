@@ -0,0 +1,23 @@
+package gorm
+
+import (
+	"time"
+)
+
+// SubmissionRevision is the data of a form submission as one create or
+// update left it. Revisions are numbered from 1 per submission.
+type SubmissionRevision struct {
+	ID           uint                   `gorm:"primaryKey;autoIncrement" json:"-"`
+	SubmissionID string                 `gorm:"size:36;not null;uniqueIndex:idx_submission_revision" json:"submissionId"`
+	Revision     int                    `gorm:"not null;uniqueIndex:idx_submission_revision" json:"revision"`
+	FormData     map[string]interface{} `gorm:"serializer:json" json:"formData"`
+	Status       string                 `gorm:"size:32" json:"status"`
+	// EditedBy is the user, or failing that the personal access token, that
+	// made the change; empty for anonymous and integration submissions.
+	EditedBy  string    `gorm:"size:36" json:"editedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (SubmissionRevision) TableName() string {
+	return "submission_revisions"
+}
@@ -0,0 +1,25 @@
+package gorm
+
+import (
+	"time"
+)
+
+// TempUpload is a file uploaded before the template it belongs to exists. It
+// stays in the hold area, visible only to the user who uploaded it, until it
+// is attached or it expires.
+type TempUpload struct {
+	ID          string    `gorm:"primaryKey;size:36" json:"id"`
+	UserID      string    `gorm:"size:36;not null;index" json:"-"`
+	WorkspaceID string    `gorm:"size:36;index" json:"-"`
+	Filename    string    `gorm:"not null" json:"filename"`
+	MimeType    string    `gorm:"size:100" json:"mimeType"`
+	Size        int64     `json:"size"`
+	GCSPath     string    `gorm:"not null" json:"-"`
+	KMSKeyName  string    `gorm:"size:512" json:"kmsKeyName,omitempty"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expiresAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (TempUpload) TableName() string {
+	return "temp_uploads"
+}
@@ -1,51 +1,282 @@
 package gorm
 
 import (
+	"fmt"
+	"math"
 	"time"
 )
 
 type Template struct {
-	ID            string    `gorm:"primaryKey" json:"id"`
-	DisplayName   string    `gorm:"not null" json:"displayName"`
-	Description   string    `json:"description"`
-	Category      string    `json:"category"`
-	PreviewImage  string    `json:"previewImage"`
-	SVGBackground string    `json:"svgBackground"`
-	DataInterface string    `json:"dataInterface"`
-	CreatedAt     time.Time `json:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
+	ID            string              `gorm:"primaryKey" json:"id"`
+	DisplayName   string              `gorm:"not null" json:"displayName"`
+	Description   string              `json:"description"`
+	Category      string              `json:"category"`
+	PreviewImage  string              `json:"previewImage"`
+	SVGBackground string              `json:"svgBackground"`
+	DataInterface string              `json:"dataInterface"`
+	SyncHash      string              `gorm:"size:64" json:"-"`
+	OptimizePDF   bool                `gorm:"default:false" json:"optimizePdf"`
+	OptimizeDPI   int                 `json:"optimizeDpi,omitempty"`
+	PDFMetadata   PDFMetadataSettings `gorm:"serializer:json" json:"pdfMetadata"`
+	PrintOptions  PrintSettings       `gorm:"serializer:json" json:"printOptions"`
+	ThaiWordBreak bool                `gorm:"default:false" json:"thaiWordBreak"`
+	// SuppressBackground prints only the field values, on blank pages, for
+	// filling in pre-printed paper forms.
+	SuppressBackground bool `gorm:"default:false" json:"suppressBackground"`
+	// ScriptFonts names, per script (thai, latin, cjk, ...), the font stack
+	// for characters a field's own font cannot render, ahead of the
+	// server-wide fallbacks.
+	ScriptFonts       map[string]string  `gorm:"serializer:json" json:"scriptFonts,omitempty"`
+	CustomCSS         string             `gorm:"type:text" json:"customCss"`
+	DuplicatePolicy   DuplicatePolicy    `gorm:"serializer:json" json:"duplicatePolicy"`
+	ExpiryPolicy      ExpiryPolicy       `gorm:"serializer:json" json:"expiryPolicy"`
+	ValidationWebhook ValidationWebhook  `gorm:"serializer:json" json:"validationWebhook"`
+	Grid              GridSettings       `gorm:"serializer:json" json:"grid"`
+	Continuation      ContinuationLayout `gorm:"serializer:json" json:"continuation"`
+	EFiling           EFilingExport      `gorm:"column:efiling;serializer:json" json:"efiling"`
+	Version           int                `gorm:"not null;default:1" json:"version"`
+	// WorkspaceID owns the template; templates without one are open to every caller.
+	WorkspaceID string `gorm:"size:36;index;default:''" json:"workspaceId,omitempty"`
+	// ArchivedAt retires a template: it is hidden from default listings and
+	// takes no new submissions, but its submissions and documents remain.
+	ArchivedAt *time.Time `gorm:"index" json:"archivedAt,omitempty"`
+	// PublicPreview opts the template into the unauthenticated preview page.
+	PublicPreview bool `gorm:"default:false" json:"publicPreview"`
+	// Dashboard counters, kept up to date by the FormSubmission and
+	// GeneratedDocument hooks. They are read-only here so saving a template
+	// never overwrites them with stale values.
+	SubmissionCount  int64      `gorm:"->;not null;default:0" json:"submissionCount"`
+	LastSubmissionAt *time.Time `gorm:"->" json:"lastSubmissionAt,omitempty"`
+	DocumentCount    int64      `gorm:"->;not null;default:0" json:"documentCount"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
 
-	Fields        []Field        `gorm:"foreignKey:TemplateID" json:"fields"`
-	SVGFiles      []SVGFile      `gorm:"foreignKey:TemplateID" json:"svgFiles,omitempty"`
-	Submissions   []FormSubmission `gorm:"foreignKey:TemplateID" json:"submissions,omitempty"`
+	Fields       []Field           `gorm:"foreignKey:TemplateID" json:"fields"`
+	SVGFiles     []SVGFile         `gorm:"foreignKey:TemplateID" json:"svgFiles,omitempty"`
+	Submissions  []FormSubmission  `gorm:"foreignKey:TemplateID" json:"submissions,omitempty"`
+	Calibrations []PageCalibration `gorm:"foreignKey:TemplateID" json:"calibrations,omitempty"`
+	Includes     []TemplateInclude `gorm:"foreignKey:TemplateID" json:"includes,omitempty"`
+}
+
+// PDFMetadataSettings controls the document properties embedded in generated PDFs.
+// Empty values fall back to the template's display name, description and category.
+type PDFMetadataSettings struct {
+	Title    string            `json:"title,omitempty"`
+	Author   string            `json:"author,omitempty"`
+	Subject  string            `json:"subject,omitempty"`
+	Keywords string            `json:"keywords,omitempty"`
+	Custom   map[string]string `json:"custom,omitempty"`
+}
+
+// Duplicate handling actions.
+const (
+	DuplicateFlag  = "flag"
+	DuplicateBlock = "block"
+)
+
+// DuplicatePolicy detects repeated filings of a template: submissions whose
+// Keys values match an earlier submission within WindowDays are flagged or
+// blocked. Detection is off when Keys is empty.
+type DuplicatePolicy struct {
+	Keys       []string `json:"keys,omitempty"`
+	WindowDays int      `json:"windowDays,omitempty"`
+	Action     string   `json:"action,omitempty"`
+}
+
+// ExpiryPolicy expires drafts that have not been updated for DraftTTLDays.
+// WarnDays before that, NotifyURL receives a list of drafts about to expire.
+// PurgeData clears the form data of expired drafts. Expiry is off when
+// DraftTTLDays is 0.
+type ExpiryPolicy struct {
+	DraftTTLDays int    `json:"draftTtlDays,omitempty"`
+	WarnDays     int    `json:"warnDays,omitempty"`
+	PurgeData    bool   `json:"purgeData,omitempty"`
+	NotifyURL    string `json:"notifyUrl,omitempty"`
+}
+
+// Validation webhook failure modes.
+const (
+	WebhookFailOpen   = "open"
+	WebhookFailClosed = "closed"
+)
+
+// ValidationWebhook has an external service check new submissions before
+// they are accepted. The service may reject a submission or annotate it.
+// FailureMode decides what happens when the service cannot be reached or
+// answers with an error: "open" accepts the submission unverified, "closed"
+// rejects it. Validation is off when URL is empty.
+type ValidationWebhook struct {
+	URL         string `json:"url,omitempty"`
+	TimeoutMS   int    `json:"timeoutMs,omitempty"`
+	FailureMode string `json:"failureMode,omitempty"`
+}
+
+// ContinuationLayout places the text of flowing fields that does not fit
+// their box on continuation pages: blank pages inserted after the field's
+// page, with the text in the box at Top, Left, Width x Height page pixels
+// under an optional Header. Zero values use 20 mm margins on A4 and at most
+// 5 pages per field.
+type ContinuationLayout struct {
+	Top      int    `json:"top,omitempty"`
+	Left     int    `json:"left,omitempty"`
+	Width    int    `json:"width,omitempty"`
+	Height   int    `json:"height,omitempty"`
+	Header   string `json:"header,omitempty"`
+	MaxPages int    `json:"maxPages,omitempty"`
+}
+
+// EFilingExport maps submissions onto the XML payload an agency accepts as
+// an electronic filing. The payload is a RootElement, in Namespace if set,
+// holding the mapped Elements. Schema keeps the agency's XSD with the
+// template for reference; payloads are not validated against it. Export is
+// off when RootElement is empty.
+type EFilingExport struct {
+	RootElement string           `json:"rootElement,omitempty"`
+	Namespace   string           `json:"namespace,omitempty"`
+	Schema      string           `json:"schema,omitempty"`
+	Elements    []EFilingElement `json:"elements,omitempty"`
+}
+
+// EFilingElement writes one value into an e-filing payload. Path is the
+// slash-separated element path below the root, e.g. "Taxpayer/TaxID"; a last
+// segment of "@name" sets an attribute of the element before it instead. The
+// value is the submission's DataKey value, or Value when DataKey is empty.
+// Format shapes dates with YYYY, MM and DD (BBBB for the Buddhist-era year)
+// and numbers with a pattern such as "0.00". Required elements must have a
+// value for the export to succeed; other empty elements are left out.
+type EFilingElement struct {
+	Path     string `json:"path"`
+	DataKey  string `json:"dataKey,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Format   string `json:"format,omitempty"`
+	Required bool   `json:"required,omitempty"`
+}
+
+// PrintSettings prepares a template for professional printing. Bleed and crop
+// marks enlarge the sheet around the A4 trim box; the safe margin keeps fields
+// away from the trim edge.
+type PrintSettings struct {
+	BleedMM      float64 `json:"bleedMm,omitempty"`
+	SafeMarginMM float64 `json:"safeMarginMm,omitempty"`
+	CropMarks    bool    `json:"cropMarks,omitempty"`
+}
+
+// Guide orientations.
+const (
+	GuideHorizontal = "horizontal"
+	GuideVertical   = "vertical"
+)
+
+// GridSettings are the editor grid of a template, shared by everyone who
+// edits it. Size is the grid spacing in page pixels; 0 means no grid. Guides
+// are extra snap lines, on one page or, with a nil PageIndex, on every page.
+type GridSettings struct {
+	Size   int         `json:"size,omitempty"`
+	Snap   bool        `json:"snap,omitempty"`
+	Guides []GridGuide `json:"guides,omitempty"`
+}
+
+// GridGuide is a horizontal guide at Position pixels from the top of the
+// page or a vertical one at Position pixels from the left.
+type GridGuide struct {
+	Orientation string `json:"orientation"`
+	Position    int    `json:"position"`
+	PageIndex   *int   `json:"pageIndex,omitempty"`
 }
 
 type Field struct {
-	ID                 uint      `gorm:"primaryKey;autoIncrement" json:"id"`
-	TemplateID         string    `gorm:"not null;index" json:"templateId"`
-	Name               string    `gorm:"not null" json:"name"`
-	Type               string    `gorm:"not null" json:"type"`
-	Required           bool      `json:"required"`
-	DataKey            string    `gorm:"not null" json:"dataKey"`
-	IsAddressComponent bool      `json:"isAddressComponent"`
-	FontSize           int       `gorm:"default:12" json:"fontSize"`
-	PageIndex          int       `gorm:"default:0" json:"pageIndex"`
-	Options            string    `gorm:"type:longtext" json:"options,omitempty"`
-	PositionTop        int       `json:"positionTop"`
-	PositionLeft       int       `json:"positionLeft"`
-	PositionWidth      int       `json:"positionWidth"`
-	PositionHeight     int       `json:"positionHeight"`
-	FontWeight         string    `gorm:"default:normal" json:"fontWeight,omitempty"`
-	FontStyle          string    `gorm:"default:normal" json:"fontStyle,omitempty"`
-	TextDecoration     string    `gorm:"default:none" json:"textDecoration,omitempty"`
-	TextColor          string    `gorm:"default:#000000" json:"textColor,omitempty"`
-	FontFamily         string    `gorm:"default:Times New Roman" json:"fontFamily,omitempty"`
-	CreatedAt          time.Time `json:"createdAt"`
-	UpdatedAt          time.Time `json:"updatedAt"`
+	ID                 uint          `gorm:"primaryKey;autoIncrement" json:"id"`
+	TemplateID         string        `gorm:"not null;index" json:"templateId"`
+	Name               string        `gorm:"not null" json:"name"`
+	Type               string        `gorm:"not null" json:"type"`
+	Required           bool          `json:"required"`
+	DataKey            string        `gorm:"not null" json:"dataKey"`
+	IsAddressComponent bool          `json:"isAddressComponent"`
+	FontSize           int           `gorm:"default:12" json:"fontSize"`
+	PageIndex          int           `gorm:"default:0" json:"pageIndex"`
+	Options            string        `gorm:"type:longtext" json:"options,omitempty"`
+	OptionListID       string        `gorm:"size:36;index" json:"optionListId,omitempty"`
+	PositionTop        int           `json:"positionTop"`
+	PositionLeft       int           `json:"positionLeft"`
+	PositionWidth      int           `json:"positionWidth"`
+	PositionHeight     int           `json:"positionHeight"`
+	FontWeight         string        `gorm:"default:normal" json:"fontWeight,omitempty"`
+	FontStyle          string        `gorm:"default:normal" json:"fontStyle,omitempty"`
+	TextDecoration     string        `gorm:"default:none" json:"textDecoration,omitempty"`
+	TextColor          string        `gorm:"default:#000000" json:"textColor,omitempty"`
+	FontFamily         string        `gorm:"default:Times New Roman" json:"fontFamily,omitempty"`
+	Rotation           int           `gorm:"default:0" json:"rotation,omitempty"`
+	Vertical           bool          `gorm:"default:false" json:"vertical,omitempty"`
+	ClassName          string        `json:"className,omitempty"`
+	Boxes              []CheckboxBox `gorm:"serializer:json" json:"boxes,omitempty"`
+	CheckMark          string        `gorm:"size:16" json:"checkMark,omitempty"`
+	StampID            string        `gorm:"size:36;index" json:"stampId,omitempty"`
+	Opacity            float64       `json:"opacity,omitempty"`
+	Sensitive          bool          `gorm:"default:false" json:"sensitive,omitempty"`
+	// ViewRoles restricts who sees the field's values in submission responses
+	// and exports to users with one of these roles; empty means everyone.
+	ViewRoles []string `gorm:"serializer:json" json:"viewRoles,omitempty"`
+	// Flow continues text that overflows the box on continuation pages
+	// instead of clipping it; see ContinuationLayout.
+	Flow         bool                        `gorm:"default:false" json:"flow,omitempty"`
+	Translations map[string]FieldTranslation `gorm:"serializer:json" json:"translations,omitempty"`
+	CreatedAt    time.Time                   `json:"createdAt"`
+	UpdatedAt    time.Time                   `json:"updatedAt"`
 
 	Template Template `gorm:"foreignKey:TemplateID" json:"-"`
 }
 
+// FieldTranslation holds a field's label and select options for one locale.
+// Options are display labels aligned by index with the field's stored options.
+type FieldTranslation struct {
+	Name    string   `json:"name,omitempty"`
+	Options []string `json:"options,omitempty"`
+}
+
+// CheckboxBox is one printed box of a checkbox group field, in the same page
+// coordinates as field positions. The box is ticked when the submitted value
+// equals Value.
+type CheckboxBox struct {
+	Value  string `json:"value"`
+	Top    int    `json:"top"`
+	Left   int    `json:"left"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// CheckedBoxes returns the boxes ticked by a submitted value: a single value,
+// or an array of values to tick several boxes. Values are compared as text.
+func (f *Field) CheckedBoxes(value interface{}) []CheckboxBox {
+	var values []interface{}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		values = v
+	case []string:
+		for _, s := range v {
+			values = append(values, s)
+		}
+	default:
+		values = []interface{}{v}
+	}
+
+	selected := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v != nil {
+			selected[fmt.Sprint(v)] = true
+		}
+	}
+
+	var checked []CheckboxBox
+	for _, box := range f.Boxes {
+		if selected[box.Value] {
+			checked = append(checked, box)
+		}
+	}
+	return checked
+}
+
 type Position struct {
 	Top    int `json:"top"`
 	Left   int `json:"left"`
@@ -78,6 +309,7 @@ type SVGFile struct {
 	FileSize     int64     `json:"fileSize"`
 	MimeType     string    `json:"mimeType"`
 	GCSPath      string    `json:"gcsPath,omitempty"`
+	KMSKeyName   string    `gorm:"size:512" json:"kmsKeyName,omitempty"`
 	PageIndex    int       `gorm:"default:0" json:"pageIndex"`
 	CreatedAt    time.Time `json:"createdAt"`
 
@@ -90,13 +322,79 @@ type FormSubmission struct {
 	FormData       map[string]interface{} `gorm:"serializer:json" json:"formData"`
 	FormattingData map[string]interface{} `gorm:"serializer:json" json:"formattingData,omitempty"`
 	HtmlData       map[string]interface{} `gorm:"serializer:json" json:"htmlData,omitempty"`
+	RawData        map[string]interface{} `gorm:"serializer:json" json:"rawData,omitempty"`
 	Status         string                 `gorm:"default:draft" json:"status"`
-	CreatedAt      time.Time             `json:"createdAt"`
-	UpdatedAt      time.Time             `json:"updatedAt"`
+	// CreatedBy is the signed-in user who submitted the form, empty for
+	// anonymous, fill link and integration submissions.
+	CreatedBy string `gorm:"size:36;index" json:"createdBy,omitempty"`
+	// WorkspaceID is the workspace that submitted the form. Besides the
+	// template's owner, only that workspace may see the submission.
+	WorkspaceID      string                `gorm:"size:36;index;default:''" json:"workspaceId,omitempty"`
+	Metadata         *SubmissionMetadata   `gorm:"serializer:json" json:"metadata,omitempty"`
+	DedupHash        string                `gorm:"size:64;index" json:"-"`
+	DuplicateOf      string                `gorm:"size:36;index" json:"duplicateOf,omitempty"`
+	Validation       *SubmissionValidation `gorm:"serializer:json" json:"validation,omitempty"`
+	ExpiryNotifiedAt *time.Time            `json:"expiryNotifiedAt,omitempty"`
+	ExpiredAt        *time.Time            `json:"expiredAt,omitempty"`
+	// A submission under legal hold cannot be deleted or purged until released.
+	LegalHoldAt     *time.Time `gorm:"index" json:"legalHoldAt,omitempty"`
+	LegalHoldReason string     `gorm:"size:512" json:"legalHoldReason,omitempty"`
+	LegalHoldBy     string     `gorm:"size:36" json:"legalHoldBy,omitempty"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
 
 	Template Template `gorm:"foreignKey:TemplateID" json:"-"`
 }
 
+// DisplayData returns the form data with normalized values replaced by the
+// user's original input, for rendering the document as it was filled in.
+func (s *FormSubmission) DisplayData() map[string]interface{} {
+	if len(s.RawData) == 0 {
+		return s.FormData
+	}
+	data := make(map[string]interface{}, len(s.FormData))
+	for key, value := range s.FormData {
+		data[key] = value
+	}
+	for key, value := range s.RawData {
+		data[key] = value
+	}
+	return data
+}
+
+// SubmissionMetadata records where a submission came from, for investigating
+// remotely filled documents. Geolocation is country/region level only and
+// comes from headers set by the load balancer or CDN.
+type SubmissionMetadata struct {
+	Channel       string `json:"channel"`
+	UserID        string `json:"userId,omitempty"`
+	WorkspaceID   string `json:"workspaceId,omitempty"`
+	TokenID       string `json:"tokenId,omitempty"`
+	IntegrationID uint   `json:"integrationId,omitempty"`
+	FillLinkID    uint   `json:"fillLinkId,omitempty"`
+	ShareToken    string `json:"shareToken,omitempty"`
+	UserAgent     string `json:"userAgent,omitempty"`
+	IPAddress     string `json:"ipAddress,omitempty"`
+	Country       string `json:"country,omitempty"`
+	Region        string `json:"region,omitempty"`
+}
+
+// Submission validation statuses.
+const (
+	ValidationPassed     = "passed"
+	ValidationUnverified = "unverified"
+)
+
+// SubmissionValidation records the template's validation webhook verdict on
+// a submission. Unverified submissions were accepted because the webhook
+// failed open; Error says why.
+type SubmissionValidation struct {
+	Status      string                 `json:"status"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+	Error       string                 `json:"error,omitempty"`
+	CheckedAt   time.Time              `json:"checkedAt"`
+}
+
 func (Template) TableName() string {
 	return "templates"
 }
@@ -111,4 +409,83 @@ func (SVGFile) TableName() string {
 
 func (FormSubmission) TableName() string {
 	return "form_submissions"
-}
\ No newline at end of file
+}
+
+// PageCalibration maps editor (SVG) coordinates onto printed page
+// coordinates for one page of a template.
+type PageCalibration struct {
+	ID         uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	TemplateID string    `gorm:"size:36;not null;uniqueIndex:idx_calibration_page" json:"templateId"`
+	PageIndex  int       `gorm:"not null;uniqueIndex:idx_calibration_page" json:"pageIndex"`
+	ScaleX     float64   `gorm:"default:1" json:"scaleX"`
+	ScaleY     float64   `gorm:"default:1" json:"scaleY"`
+	OffsetX    float64   `json:"offsetX"`
+	OffsetY    float64   `json:"offsetY"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	Template Template `gorm:"foreignKey:TemplateID" json:"-"`
+}
+
+// Apply transforms the field's position and checkbox boxes using the calibration.
+func (p *PageCalibration) Apply(f *Field) {
+	f.PositionLeft = int(math.Round(p.ScaleX*float64(f.PositionLeft) + p.OffsetX))
+	f.PositionTop = int(math.Round(p.ScaleY*float64(f.PositionTop) + p.OffsetY))
+	f.PositionWidth = int(math.Round(p.ScaleX * float64(f.PositionWidth)))
+	f.PositionHeight = int(math.Round(p.ScaleY * float64(f.PositionHeight)))
+
+	if len(f.Boxes) > 0 {
+		boxes := make([]CheckboxBox, len(f.Boxes))
+		for i, box := range f.Boxes {
+			boxes[i] = CheckboxBox{
+				Value:  box.Value,
+				Left:   int(math.Round(p.ScaleX*float64(box.Left) + p.OffsetX)),
+				Top:    int(math.Round(p.ScaleY*float64(box.Top) + p.OffsetY)),
+				Width:  int(math.Round(p.ScaleX * float64(box.Width))),
+				Height: int(math.Round(p.ScaleY * float64(box.Height))),
+			}
+		}
+		f.Boxes = boxes
+	}
+}
+
+func (PageCalibration) TableName() string {
+	return "page_calibrations"
+}
+
+// InboundIntegration lets an external system create submissions for a
+// template by POSTing signed JSON payloads.
+type InboundIntegration struct {
+	ID           uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	TemplateID   string            `gorm:"not null;index" json:"templateId"`
+	Name         string            `json:"name"`
+	Token        string            `gorm:"size:64;not null;uniqueIndex" json:"token"`
+	Secret       string            `gorm:"size:64;not null" json:"-"`
+	FieldMapping map[string]string `gorm:"serializer:json" json:"fieldMapping"`
+	Status       string            `gorm:"default:submitted" json:"status"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+
+	Template Template `gorm:"foreignKey:TemplateID" json:"-"`
+}
+
+func (InboundIntegration) TableName() string {
+	return "inbound_integrations"
+}
+
+// TemplateInclude places the pages of another template into this one, starting
+// at PageOffset, so shared sections such as annexes are maintained in one place.
+type TemplateInclude struct {
+	ID                 uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	TemplateID         string    `gorm:"not null;index" json:"templateId"`
+	IncludedTemplateID string    `gorm:"not null;index" json:"includedTemplateId"`
+	PageOffset         int       `gorm:"not null" json:"pageOffset"`
+	Position           int       `gorm:"default:0" json:"position"`
+	CreatedAt          time.Time `json:"createdAt"`
+
+	Template Template `gorm:"foreignKey:TemplateID" json:"-"`
+}
+
+func (TemplateInclude) TableName() string {
+	return "template_includes"
+}
@@ -0,0 +1,19 @@
+package gorm
+
+import (
+	"time"
+)
+
+// UsageCounter counts billable calls for a workspace in a calendar month.
+type UsageCounter struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"-"`
+	WorkspaceID string    `gorm:"size:36;not null;uniqueIndex:idx_usage_period" json:"workspaceId"`
+	Metric      string    `gorm:"size:64;not null;uniqueIndex:idx_usage_period" json:"metric"`
+	Period      string    `gorm:"size:7;not null;uniqueIndex:idx_usage_period" json:"period"`
+	Count       int64     `gorm:"not null;default:0" json:"count"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func (UsageCounter) TableName() string {
+	return "usage_counters"
+}
@@ -0,0 +1,106 @@
+package gorm
+
+import (
+	"time"
+)
+
+type Workspace struct {
+	ID               string    `gorm:"primaryKey" json:"id"`
+	Name             string    `gorm:"not null" json:"name"`
+	Slug             string    `gorm:"size:64;not null;uniqueIndex" json:"slug"`
+	VisionMonthlyCap int       `json:"visionMonthlyCap"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
+
+// Workspace roles.
+const (
+	RoleMember = "member"
+	RoleAdmin  = "admin"
+)
+
+type User struct {
+	ID          string     `gorm:"primaryKey" json:"id"`
+	WorkspaceID string     `gorm:"not null;index;uniqueIndex:idx_user_identity" json:"workspaceId"`
+	Email       string     `gorm:"index" json:"email"`
+	Name        string     `json:"name"`
+	Role        string     `gorm:"default:member" json:"role"`
+	Provider    string     `gorm:"size:32;uniqueIndex:idx_user_identity" json:"provider"`
+	Subject     string     `gorm:"size:255;uniqueIndex:idx_user_identity" json:"-"`
+	LastLoginAt *time.Time `json:"lastLoginAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+
+	Workspace Workspace `gorm:"foreignKey:WorkspaceID" json:"-"`
+}
+
+// SSOConfig holds the identity provider settings for a workspace.
+type SSOConfig struct {
+	ID           uint              `gorm:"primaryKey;autoIncrement" json:"id"`
+	WorkspaceID  string            `gorm:"size:36;not null;uniqueIndex" json:"workspaceId"`
+	Protocol     string            `gorm:"default:oidc" json:"protocol"`
+	Issuer       string            `gorm:"not null" json:"issuer"`
+	ClientID     string            `gorm:"not null" json:"clientId"`
+	ClientSecret string            `json:"-"`
+	Scopes       string            `gorm:"default:openid email profile" json:"scopes"`
+	RoleClaim    string            `json:"roleClaim,omitempty"`
+	RoleMapping  map[string]string `gorm:"serializer:json" json:"roleMapping,omitempty"`
+	DefaultRole  string            `gorm:"default:member" json:"defaultRole"`
+	Enabled      bool              `gorm:"default:true" json:"enabled"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+
+	Workspace Workspace `gorm:"foreignKey:WorkspaceID" json:"-"`
+}
+
+func (Workspace) TableName() string {
+	return "workspaces"
+}
+
+func (User) TableName() string {
+	return "users"
+}
+
+func (SSOConfig) TableName() string {
+	return "sso_configs"
+}
+
+// PersonalAccessToken is a user-minted API token. Only a hash of the
+// secret is stored; the plaintext is shown once at creation or rotation.
+type PersonalAccessToken struct {
+	ID          string     `gorm:"primaryKey" json:"id"`
+	UserID      string     `gorm:"not null;index" json:"userId"`
+	WorkspaceID string     `gorm:"not null;index" json:"workspaceId"`
+	Name        string     `gorm:"not null" json:"name"`
+	Prefix      string     `gorm:"size:16" json:"prefix"`
+	TokenHash   string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes      []string   `gorm:"serializer:json" json:"scopes"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt  *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// AuditLog records security-relevant actions together with the identity
+// (user and, when applicable, API token) that performed them.
+type AuditLog struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	WorkspaceID string    `gorm:"index" json:"workspaceId,omitempty"`
+	UserID      string    `gorm:"index" json:"userId,omitempty"`
+	TokenID     string    `gorm:"index" json:"tokenId,omitempty"`
+	Action      string    `gorm:"not null;index" json:"action"`
+	Resource    string    `json:"resource,omitempty"`
+	IPAddress   string    `json:"ipAddress,omitempty"`
+	CreatedAt   time.Time `gorm:"index" json:"createdAt"`
+}
+
+func (PersonalAccessToken) TableName() string {
+	return "personal_access_tokens"
+}
+
+func (AuditLog) TableName() string {
+	return "audit_logs"
+}
@@ -0,0 +1,157 @@
+package renderer
+
+import (
+	"context"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// deterministicFlags are the Chrome flags of a deterministic print: a fixed
+// JavaScript random seed and font and color rendering that does not depend on
+// the host.
+var deterministicFlags = []chromedp.ExecAllocatorOption{
+	chromedp.Flag("js-flags", "--random-seed=1157259157"),
+	chromedp.Flag("font-render-hinting", "none"),
+	chromedp.Flag("disable-font-subpixel-positioning", true),
+	chromedp.Flag("disable-lcd-text", true),
+	chromedp.Flag("force-color-profile", "srgb"),
+	chromedp.Flag("disable-partial-raster", true),
+	chromedp.Flag("disable-skia-runtime-opts", true),
+	chromedp.Flag("run-all-compositor-stages-before-draw", true),
+	chromedp.Flag("disable-threaded-animation", true),
+	chromedp.Flag("disable-checker-imaging", true),
+}
+
+// Chrome renders in-process with a pool of long-lived headless Chrome
+// browsers, one document per browser at a time. Deterministic prints need
+// their own browser flags and use a second pool of the same size.
+type Chrome struct {
+	pool              *browserPool
+	deterministicPool *browserPool
+}
+
+// NewChrome renders with up to poolSize browsers, started on first use.
+func NewChrome(poolSize int) *Chrome {
+	return &Chrome{
+		pool:              newBrowserPool(poolSize),
+		deterministicPool: newBrowserPool(poolSize, deterministicFlags...),
+	}
+}
+
+// StartHealthChecks pings idle browsers every interval and restarts the ones
+// that stopped answering, until ctx ends.
+func (r *Chrome) StartHealthChecks(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				r.pool.close()
+				r.deterministicPool.close()
+				return
+			case <-ticker.C:
+				r.pool.check()
+				r.deterministicPool.check()
+			}
+		}
+	}()
+}
+
+func (r *Chrome) PrintPDF(ctx context.Context, html string, opts PDFOptions) ([]byte, error) {
+	pool := r.pool
+	if opts.Deterministic {
+		pool = r.deterministicPool
+	}
+
+	var pdfBytes []byte
+	err := pool.run(ctx,
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			if !opts.Deterministic {
+				return nil
+			}
+			return emulation.SetEmulatedMedia().
+				WithFeatures([]*emulation.MediaFeature{{Name: "prefers-reduced-motion", Value: "reduce"}}).
+				Do(ctx)
+		}),
+		chromedp.Navigate("data:text/html,"+html),
+		chromedp.WaitReady("body"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			params := page.PrintToPDF().
+				WithPrintBackground(true).
+				WithPaperWidth(opts.PaperWidth).
+				WithPaperHeight(opts.PaperHeight).
+				WithMarginTop(0).
+				WithMarginBottom(0).
+				WithMarginLeft(0).
+				WithMarginRight(0)
+			if opts.Deterministic {
+				// Pinned rather than left to the Chrome version's default.
+				params = params.WithGenerateTaggedPDF(false)
+			}
+
+			var err error
+			pdfBytes, _, err = params.Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return pdfBytes, nil
+}
+
+func (r *Chrome) Screenshot(ctx context.Context, html string) ([]byte, error) {
+	var screenshot []byte
+	err := r.pool.run(ctx,
+		chromedp.Navigate("data:text/html,"+html),
+		chromedp.FullScreenshot(&screenshot, 80),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return screenshot, nil
+}
+
+func (r *Chrome) PagePNG(ctx context.Context, html string, width int) ([]byte, error) {
+	var image []byte
+	err := r.pool.run(ctx,
+		chromedp.EmulateViewport(PageWidth, PageHeight, chromedp.EmulateScale(float64(width)/PageWidth)),
+		chromedp.Navigate("data:text/html,"+html),
+		chromedp.WaitReady("body"),
+		chromedp.CaptureScreenshot(&image),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return image, nil
+}
+
+// chromeOptions are the options of a headless Chrome with flags added.
+func chromeOptions(flags ...chromedp.ExecAllocatorOption) []chromedp.ExecAllocatorOption {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+	)
+	return append(opts, flags...)
+}
+
+// newChromeContext starts a Chrome of its own, for probes that must see a
+// fresh browser.
+func newChromeContext(ctx context.Context, flags ...chromedp.ExecAllocatorOption) (context.Context, context.CancelFunc) {
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(ctx, chromeOptions(flags...)...)
+	chromeCtx, cancelChrome := chromedp.NewContext(allocCtx)
+
+	return chromeCtx, func() {
+		cancelChrome()
+		cancelAlloc()
+	}
+}
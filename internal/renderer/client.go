@@ -0,0 +1,123 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxResponseSize bounds the documents accepted from a remote renderer.
+const maxResponseSize = 256 << 20
+
+// Client renders through a remote renderer process over HTTP.
+type Client struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func NewClient(baseURL, token string) *Client {
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{},
+	}
+}
+
+// renderRequest is the body of the renderer's endpoints.
+type renderRequest struct {
+	HTML string `json:"html"`
+	PDFOptions
+	// Width is the image width of a page render.
+	Width int `json:"width,omitempty"`
+}
+
+func (c *Client) PrintPDF(ctx context.Context, html string, opts PDFOptions) ([]byte, error) {
+	return c.do(ctx, "/render/pdf", renderRequest{HTML: html, PDFOptions: opts})
+}
+
+func (c *Client) Screenshot(ctx context.Context, html string) ([]byte, error) {
+	return c.do(ctx, "/render/screenshot", renderRequest{HTML: html})
+}
+
+func (c *Client) PagePNG(ctx context.Context, html string, width int) ([]byte, error) {
+	return c.do(ctx, "/render/page", renderRequest{HTML: html, Width: width})
+}
+
+// Probe returns the capabilities the remote renderer found at its startup.
+func (c *Client) Probe(ctx context.Context) (*Capabilities, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/health", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create health request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	var health struct {
+		Capabilities *Capabilities `json:"capabilities"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to read renderer health: %w", err)
+	}
+	if health.Capabilities == nil || health.Capabilities.Error != "" || health.Capabilities.Product == "" {
+		return nil, fmt.Errorf("renderer has not completed its probe")
+	}
+	return health.Capabilities, nil
+}
+
+func (c *Client) do(ctx context.Context, path string, body renderRequest) ([]byte, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode render request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create render request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read render response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+		msg := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &failure) == nil && failure.Error != "" {
+			msg = failure.Error
+		}
+		switch resp.StatusCode {
+		case http.StatusServiceUnavailable, http.StatusBadGateway:
+			return nil, fmt.Errorf("%w: %s", ErrUnavailable, msg)
+		case http.StatusGatewayTimeout:
+			return nil, fmt.Errorf("%w: %s", context.DeadlineExceeded, msg)
+		}
+		return nil, fmt.Errorf("renderer returned status %d: %s", resp.StatusCode, msg)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,171 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/chromedp"
+)
+
+// browserMaxRenders is how many documents a browser renders before it is
+// replaced, so memory Chrome does not give back is released.
+const browserMaxRenders = 200
+
+// healthCheckTimeout bounds the ping of an idle browser.
+const healthCheckTimeout = 5 * time.Second
+
+// pooledBrowser is a running headless Chrome.
+type pooledBrowser struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	renders int
+}
+
+// alive reports whether the browser process is still connected.
+func (b *pooledBrowser) alive() bool {
+	if b.ctx.Err() != nil {
+		return false
+	}
+	c := chromedp.FromContext(b.ctx)
+	if c == nil || c.Browser == nil {
+		return false
+	}
+	select {
+	case <-c.Browser.LostConnection:
+		return false
+	default:
+		return true
+	}
+}
+
+// browserPool keeps up to size long-lived browsers started with the same
+// flags. Each renders one document at a time, in a new tab; callers wait for
+// a free browser. Browsers are started on first use and replaced when they
+// crash, fail a health check or reach browserMaxRenders.
+type browserPool struct {
+	flags []chromedp.ExecAllocatorOption
+	// slots holds one entry per browser: nil for one not started yet.
+	slots chan *pooledBrowser
+}
+
+func newBrowserPool(size int, flags ...chromedp.ExecAllocatorOption) *browserPool {
+	if size <= 0 {
+		size = 1
+	}
+	p := &browserPool{flags: flags, slots: make(chan *pooledBrowser, size)}
+	for i := 0; i < size; i++ {
+		p.slots <- nil
+	}
+	return p
+}
+
+// run runs actions in a new tab of a pooled browser. The tab is closed when
+// ctx is done, leaving the browser running for the next document.
+func (p *browserPool) run(ctx context.Context, actions ...chromedp.Action) error {
+	var b *pooledBrowser
+	select {
+	case b = <-p.slots:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if b != nil && !b.alive() {
+		log.Printf("Warning: pooled Chrome exited, restarting it")
+		b.cancel()
+		b = nil
+	}
+	if b == nil {
+		var err error
+		if b, err = p.start(); err != nil {
+			p.slots <- nil
+			return err
+		}
+	}
+
+	tabCtx, cancelTab := chromedp.NewContext(b.ctx)
+	stop := context.AfterFunc(ctx, cancelTab)
+	err := chromedp.Run(tabCtx, actions...)
+	stop()
+	cancelTab()
+
+	b.renders++
+	p.release(b)
+
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
+// start launches a browser, outliving the request that needed it.
+func (p *browserPool) start() (*pooledBrowser, error) {
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), chromeOptions(p.flags...)...)
+	browserCtx, cancelBrowser := chromedp.NewContext(allocCtx)
+	cancel := func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+
+	// Running no actions starts the browser.
+	if err := chromedp.Run(browserCtx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start Chrome: %w", err)
+	}
+	return &pooledBrowser{ctx: browserCtx, cancel: cancel}, nil
+}
+
+// release returns a browser to the pool, replacing it if it died or is due
+// to be recycled.
+func (p *browserPool) release(b *pooledBrowser) {
+	if !b.alive() || b.renders >= browserMaxRenders {
+		b.cancel()
+		b = nil
+	}
+	p.slots <- b
+}
+
+// check pings the idle browsers and shuts down the ones that do not answer,
+// so the next document starts a fresh one. Busy browsers are left alone.
+func (p *browserPool) check() {
+	for i := 0; i < cap(p.slots); i++ {
+		var b *pooledBrowser
+		select {
+		case b = <-p.slots:
+		default:
+			return
+		}
+
+		if b != nil {
+			ctx, cancel := context.WithTimeout(b.ctx, healthCheckTimeout)
+			err := chromedp.Run(ctx, chromedp.ActionFunc(func(ctx context.Context) error {
+				_, _, _, _, _, err := browser.GetVersion().Do(ctx)
+				return err
+			}))
+			cancel()
+			if err != nil || !b.alive() {
+				log.Printf("Warning: pooled Chrome failed its health check, restarting it: %v", err)
+				b.cancel()
+				b = nil
+			}
+		}
+		p.slots <- b
+	}
+}
+
+// close shuts down the idle browsers.
+func (p *browserPool) close() {
+	for i := 0; i < cap(p.slots); i++ {
+		select {
+		case b := <-p.slots:
+			if b != nil {
+				b.cancel()
+			}
+			p.slots <- nil
+		default:
+			return
+		}
+	}
+}
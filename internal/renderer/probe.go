@@ -0,0 +1,172 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"github.com/chromedp/cdproto/browser"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+)
+
+// probeRetryInterval is how long the monitor waits before probing again
+// after a failed probe.
+const probeRetryInterval = time.Minute
+
+// Capabilities describes the Chrome a renderer prints with, so rendering
+// differences between environments can be traced to their cause.
+type Capabilities struct {
+	Product         string          `json:"product"`
+	Version         string          `json:"version"`
+	ProtocolVersion string          `json:"protocolVersion"`
+	UserAgent       string          `json:"userAgent"`
+	PrintToPDF      bool            `json:"printToPdf"`
+	Fonts           map[string]bool `json:"fonts"`
+	MissingFonts    []string        `json:"missingFonts,omitempty"`
+	PinnedVersion   string          `json:"pinnedVersion,omitempty"`
+	VersionMatches  bool            `json:"versionMatches"`
+	Healthy         bool            `json:"healthy"`
+	Error           string          `json:"error,omitempty"`
+	CheckedAt       time.Time       `json:"checkedAt"`
+}
+
+// Probe starts Chrome, reads its version, prints a one-page PDF and checks
+// which of the fallback fonts it can use.
+func (r *Chrome) Probe(ctx context.Context) (*Capabilities, error) {
+	chromeCtx, cancel := newChromeContext(ctx)
+	defer cancel()
+
+	caps := &Capabilities{}
+	var pdfBytes []byte
+	families := utils.FallbackFamilies()
+	err := chromedp.Run(chromeCtx,
+		chromedp.Navigate("about:blank"),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			protocol, product, _, userAgent, _, err := browser.GetVersion().Do(ctx)
+			if err != nil {
+				return err
+			}
+			caps.Product = product
+			caps.ProtocolVersion = protocol
+			caps.UserAgent = userAgent
+			if _, version, ok := strings.Cut(product, "/"); ok {
+				caps.Version = version
+			}
+			return nil
+		}),
+		chromedp.Evaluate(fontCheckScript(families), &caps.Fonts),
+		chromedp.ActionFunc(func(ctx context.Context) error {
+			var err error
+			pdfBytes, _, err = page.PrintToPDF().WithPaperWidth(8.27).WithPaperHeight(11.69).Do(ctx)
+			return err
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	caps.PrintToPDF = bytes.HasPrefix(pdfBytes, []byte("%PDF"))
+	return caps, nil
+}
+
+// fontCheckScript returns JavaScript that reports, per family, whether text
+// set in it measures differently from the generic families. A family that
+// is not installed falls back and measures the same.
+func fontCheckScript(families []string) string {
+	list, _ := json.Marshal(families)
+	return fmt.Sprintf(`(() => {
+	const ctx = document.createElement("canvas").getContext("2d");
+	const sample = "mmmmmmmmmmlliWWกขค中文한글";
+	const width = (font) => { ctx.font = "72px " + font; return ctx.measureText(sample).width; };
+	const generic = ["monospace", "serif", "sans-serif"];
+	const result = {};
+	for (const family of %s) {
+		result[family] = generic.some((g) => width("'" + family + "', " + g) !== width(g));
+	}
+	return result;
+})()`, list)
+}
+
+// Monitor probes a renderer at startup and keeps the result for the health
+// endpoint. A failed probe is retried until one succeeds.
+type Monitor struct {
+	renderer Renderer
+	pin      string
+	current  atomic.Pointer[Capabilities]
+}
+
+// NewMonitor creates a monitor for r. A non-empty pin is the Chrome version
+// or version prefix (e.g. "120" or "120.0.6099") the renderer must run.
+func NewMonitor(r Renderer, pin string) *Monitor {
+	return &Monitor{renderer: r, pin: pin}
+}
+
+// Capabilities returns the latest probe result, or nil before the first probe.
+func (m *Monitor) Capabilities() *Capabilities {
+	return m.current.Load()
+}
+
+// Start probes in the background until a probe succeeds or ctx ends.
+func (m *Monitor) Start(ctx context.Context) {
+	go func() {
+		for {
+			if m.probe(ctx) {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(probeRetryInterval):
+			}
+		}
+	}()
+}
+
+func (m *Monitor) probe(ctx context.Context) bool {
+	probeCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	caps, err := m.renderer.Probe(probeCtx)
+	if err != nil {
+		log.Printf("Warning: renderer probe failed: %v", err)
+		m.current.Store(&Capabilities{Error: err.Error(), PinnedVersion: m.pin, CheckedAt: time.Now()})
+		return false
+	}
+
+	caps.PinnedVersion = m.pin
+	caps.VersionMatches = versionMatches(caps.Version, m.pin)
+	caps.MissingFonts = nil
+	for family, installed := range caps.Fonts {
+		if !installed {
+			caps.MissingFonts = append(caps.MissingFonts, family)
+		}
+	}
+	sort.Strings(caps.MissingFonts)
+	caps.Healthy = caps.PrintToPDF && caps.VersionMatches && len(caps.MissingFonts) == 0
+	caps.CheckedAt = time.Now()
+	m.current.Store(caps)
+
+	log.Printf("Renderer: %s (protocol %s), PrintToPDF %t, fonts missing: %v", caps.Product, caps.ProtocolVersion, caps.PrintToPDF, caps.MissingFonts)
+	if !caps.VersionMatches {
+		log.Printf("Warning: renderer runs Chrome %s but %s is pinned; PDFs may render differently", caps.Version, m.pin)
+	}
+	if !caps.PrintToPDF {
+		log.Println("Warning: renderer Chrome did not produce a PDF")
+	}
+	return true
+}
+
+// versionMatches reports whether version equals pin or starts with pin
+// followed by a dot. An empty pin matches every version.
+func versionMatches(version, pin string) bool {
+	return pin == "" || version == pin || strings.HasPrefix(version, pin+".")
+}
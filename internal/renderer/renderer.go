@@ -0,0 +1,45 @@
+// Package renderer turns generated HTML into PDFs and screenshots with
+// headless Chrome, either in-process or in a separate renderer process
+// reached over HTTP (see cmd/renderer).
+package renderer
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrUnavailable is returned when a remote renderer cannot be reached or is
+// at capacity.
+var ErrUnavailable = errors.New("renderer unavailable")
+
+// PDFOptions is the sheet size in inches. Margins are always zero; page
+// layout is handled by the generated HTML.
+type PDFOptions struct {
+	PaperWidth  float64 `json:"paperWidth"`
+	PaperHeight float64 `json:"paperHeight"`
+	// Deterministic pins the Chrome settings that vary between runs (random
+	// seed, font rasterization, motion) so the same HTML prints the same
+	// PDF. Volatile PDF metadata is stripped separately; see
+	// utils.NormalizePDF.
+	Deterministic bool `json:"deterministic,omitempty"`
+}
+
+// PageWidth and PageHeight are the CSS pixel size of an A4 page in the
+// generated HTML.
+const (
+	PageWidth  = 794
+	PageHeight = 1123
+)
+
+// Renderer prints HTML documents.
+type Renderer interface {
+	PrintPDF(ctx context.Context, html string, opts PDFOptions) ([]byte, error)
+	// Screenshot returns a full-page JPEG of the document, for diagnostics.
+	Screenshot(ctx context.Context, html string) ([]byte, error)
+	// PagePNG returns a PNG of the top PageWidth x PageHeight CSS pixels of
+	// the document, scaled to width pixels wide, for live previews.
+	PagePNG(ctx context.Context, html string, width int) ([]byte, error)
+	// Probe reports the Chrome version, PrintToPDF support and installed
+	// fonts of the renderer.
+	Probe(ctx context.Context) (*Capabilities, error)
+}
@@ -0,0 +1,124 @@
+package renderer
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRequestSize bounds the HTML accepted by the renderer endpoints.
+const maxRequestSize = 64 << 20
+
+// Server exposes a Renderer over HTTP for the API to call. At most
+// concurrency documents render at once; further requests are rejected with
+// 503 rather than queued, so the caller can fail fast.
+type Server struct {
+	renderer Renderer
+	monitor  *Monitor
+	token    string
+	timeout  time.Duration
+	slots    chan struct{}
+}
+
+// NewServer serves renderer. The health endpoint reports monitor's probe
+// result when monitor is not nil.
+func NewServer(renderer Renderer, monitor *Monitor, token string, concurrency int, timeout time.Duration) *Server {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	return &Server{
+		renderer: renderer,
+		monitor:  monitor,
+		token:    token,
+		timeout:  timeout,
+		slots:    make(chan struct{}, concurrency),
+	}
+}
+
+// Register adds the renderer routes to r.
+func (s *Server) Register(r gin.IRouter) {
+	r.GET("/health", func(c *gin.Context) {
+		health := gin.H{"status": "ok", "busy": len(s.slots), "capacity": cap(s.slots)}
+		if s.monitor != nil {
+			health["capabilities"] = s.monitor.Capabilities()
+		}
+		c.JSON(http.StatusOK, health)
+	})
+
+	render := r.Group("/render", s.authorize)
+	render.POST("/pdf", s.printPDF)
+	render.POST("/screenshot", s.screenshot)
+	render.POST("/page", s.pagePNG)
+}
+
+func (s *Server) authorize(c *gin.Context) {
+	if s.token == "" {
+		c.Next()
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+s.token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid renderer token"})
+		return
+	}
+	c.Next()
+}
+
+func (s *Server) printPDF(c *gin.Context) {
+	s.render(c, "application/pdf", func(ctx context.Context, req renderRequest) ([]byte, error) {
+		return s.renderer.PrintPDF(ctx, req.HTML, req.PDFOptions)
+	})
+}
+
+func (s *Server) screenshot(c *gin.Context) {
+	s.render(c, "image/jpeg", func(ctx context.Context, req renderRequest) ([]byte, error) {
+		return s.renderer.Screenshot(ctx, req.HTML)
+	})
+}
+
+func (s *Server) pagePNG(c *gin.Context) {
+	s.render(c, "image/png", func(ctx context.Context, req renderRequest) ([]byte, error) {
+		if req.Width <= 0 {
+			req.Width = PageWidth
+		}
+		return s.renderer.PagePNG(ctx, req.HTML, req.Width)
+	})
+}
+
+func (s *Server) render(c *gin.Context, contentType string, fn func(context.Context, renderRequest) ([]byte, error)) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxRequestSize)
+
+	var req renderRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.HTML == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid render request"})
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	default:
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "renderer is at capacity"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), s.timeout)
+	defer cancel()
+
+	data, err := fn(ctx, req)
+	if err != nil {
+		log.Printf("Render failed: %v", err)
+		if errors.Is(err, context.DeadlineExceeded) {
+			c.JSON(http.StatusGatewayTimeout, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, data)
+}
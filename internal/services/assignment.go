@@ -0,0 +1,258 @@
+package services
+
+import (
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+)
+
+// Work queue page sizes.
+const (
+	defaultQueueItems = 50
+	maxQueueItems     = 200
+)
+
+// dueSoonWindow is how far ahead workload stats count assignments as due soon.
+const dueSoonWindow = 48 * time.Hour
+
+// AssignRequest assigns a submission to AssigneeID, a user of WorkspaceID.
+type AssignRequest struct {
+	SubmissionID string
+	AssigneeID   string
+	WorkspaceID  string
+	DueAt        *time.Time
+	Note         string
+	AssignedBy   string
+}
+
+// QueueFilter selects the assignments of a work queue. Status defaults to
+// open; Overdue keeps only open assignments past their due date.
+type QueueFilter struct {
+	AssigneeID string
+	Status     string
+	Overdue    bool
+	Limit      int
+	Offset     int
+}
+
+// QueueItem is an assignment with what a work queue shows of its submission.
+type QueueItem struct {
+	gormmodels.Assignment
+	TemplateName     string    `json:"templateName"`
+	SubmissionStatus string    `json:"submissionStatus"`
+	SubmittedAt      time.Time `json:"submittedAt"`
+	Overdue          bool      `json:"overdue" gorm:"-"`
+}
+
+// Workload counts the assignments of one staff user.
+type Workload struct {
+	UserID            string `json:"userId"`
+	Name              string `json:"name"`
+	Email             string `json:"email"`
+	Open              int64  `json:"open"`
+	Overdue           int64  `json:"overdue"`
+	DueSoon           int64  `json:"dueSoon"`
+	CompletedLastWeek int64  `json:"completedLastWeek"`
+}
+
+type AssignmentService struct{}
+
+func NewAssignmentService() *AssignmentService {
+	return &AssignmentService{}
+}
+
+// Assign puts a submission in the assignee's queue, or moves its existing
+// assignment there. Reassigning reopens a completed assignment.
+func (s *AssignmentService) Assign(req AssignRequest) (*gormmodels.Assignment, error) {
+	if err := s.checkAssignee(req.AssigneeID, req.WorkspaceID); err != nil {
+		return nil, err
+	}
+	if req.DueAt != nil && req.DueAt.Before(time.Now()) {
+		return nil, newError(ErrValidation, "dueAt must be in the future")
+	}
+
+	var submission gormmodels.FormSubmission
+	err := internal.DB.Select("id", "template_id").Where("id = ?", req.SubmissionID).First(&submission).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, newError(ErrNotFound, "form submission not found")
+		}
+		return nil, storageError("failed to fetch form submission", err)
+	}
+
+	var assignment gormmodels.Assignment
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("submission_id = ?", req.SubmissionID).Limit(1).Find(&assignment)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		assignment.SubmissionID = submission.ID
+		assignment.TemplateID = submission.TemplateID
+		assignment.WorkspaceID = req.WorkspaceID
+		assignment.AssigneeID = req.AssigneeID
+		assignment.AssignedBy = req.AssignedBy
+		assignment.Status = gormmodels.AssignmentOpen
+		assignment.DueAt = req.DueAt
+		assignment.Note = req.Note
+		assignment.CompletedAt = nil
+		return tx.Save(&assignment).Error
+	})
+	if err != nil {
+		return nil, storageError("failed to assign form submission", err)
+	}
+	return &assignment, nil
+}
+
+func (s *AssignmentService) checkAssignee(userID, workspaceID string) error {
+	var count int64
+	err := internal.DB.Model(&gormmodels.User{}).Where("id = ? AND workspace_id = ?", userID, workspaceID).Count(&count).Error
+	if err != nil {
+		return storageError("failed to fetch user", err)
+	}
+	if count == 0 {
+		return newError(ErrValidation, "assignee is not a user of this workspace")
+	}
+	return nil
+}
+
+// Get returns the assignment of a submission within the workspace, or nil
+// if it has none.
+func (s *AssignmentService) Get(submissionID, workspaceID string) (*gormmodels.Assignment, error) {
+	var assignment gormmodels.Assignment
+
+	err := internal.DB.Where("submission_id = ? AND workspace_id = ?", submissionID, workspaceID).First(&assignment).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch assignment", err)
+	}
+
+	return &assignment, nil
+}
+
+// Complete marks the assignment of a submission done.
+func (s *AssignmentService) Complete(submissionID, workspaceID string) (*gormmodels.Assignment, error) {
+	assignment, err := s.Get(submissionID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment == nil {
+		return nil, newError(ErrNotFound, "assignment not found")
+	}
+	if assignment.Status == gormmodels.AssignmentDone {
+		return assignment, nil
+	}
+
+	now := time.Now()
+	assignment.Status = gormmodels.AssignmentDone
+	assignment.CompletedAt = &now
+	if err := internal.DB.Model(assignment).Select("status", "completed_at", "updated_at").Updates(assignment).Error; err != nil {
+		return nil, storageError("failed to complete assignment", err)
+	}
+	return assignment, nil
+}
+
+// Unassign takes a submission out of its assignee's queue.
+func (s *AssignmentService) Unassign(submissionID, workspaceID string) error {
+	result := internal.DB.Where("submission_id = ? AND workspace_id = ?", submissionID, workspaceID).Delete(&gormmodels.Assignment{})
+	if result.Error != nil {
+		return storageError("failed to delete assignment", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return newError(ErrNotFound, "assignment not found")
+	}
+	return nil
+}
+
+// Reassign moves every open assignment of one user to another, e.g. while
+// the first is away, and returns how many moved.
+func (s *AssignmentService) Reassign(fromUserID, toUserID, workspaceID, actor string) (int64, error) {
+	if fromUserID == toUserID {
+		return 0, newError(ErrValidation, "fromUserId and toUserId must differ")
+	}
+	if err := s.checkAssignee(toUserID, workspaceID); err != nil {
+		return 0, err
+	}
+
+	result := internal.DB.Model(&gormmodels.Assignment{}).
+		Where("workspace_id = ? AND assignee_id = ? AND status = ?", workspaceID, fromUserID, gormmodels.AssignmentOpen).
+		Updates(map[string]interface{}{"assignee_id": toUserID, "assigned_by": actor, "updated_at": time.Now()})
+	if result.Error != nil {
+		return 0, storageError("failed to reassign assignments", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Queue returns a page of a user's assignments, soonest due first, with the
+// total count. Assignments without a due date come last, oldest first.
+func (s *AssignmentService) Queue(filter QueueFilter) ([]QueueItem, int64, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultQueueItems
+	}
+	if filter.Limit > maxQueueItems {
+		filter.Limit = maxQueueItems
+	}
+	if filter.Status == "" || filter.Overdue {
+		filter.Status = gormmodels.AssignmentOpen
+	}
+
+	now := time.Now()
+	db := internal.ReadDB().Table("assignments").
+		Joins("JOIN form_submissions ON form_submissions.id = assignments.submission_id").
+		Joins("JOIN templates ON templates.id = assignments.template_id").
+		Where("assignments.assignee_id = ? AND assignments.status = ?", filter.AssigneeID, filter.Status)
+	if filter.Overdue {
+		db = db.Where("assignments.due_at < ?", now)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, storageError("failed to count assignments", err)
+	}
+
+	var items []QueueItem
+	err := db.Select("assignments.*, templates.display_name AS template_name, form_submissions.status AS submission_status, form_submissions.created_at AS submitted_at").
+		Order("assignments.due_at IS NULL, assignments.due_at, assignments.created_at").
+		Limit(filter.Limit).Offset(filter.Offset).
+		Scan(&items).Error
+	if err != nil {
+		return nil, 0, storageError("failed to fetch assignments", err)
+	}
+
+	for i := range items {
+		items[i].Overdue = items[i].Assignment.Overdue(now)
+	}
+	return items, total, nil
+}
+
+// Workloads counts the open, overdue and due-soon assignments of every user
+// of the workspace, and those they completed in the last week.
+func (s *AssignmentService) Workloads(workspaceID string) ([]Workload, error) {
+	now := time.Now()
+
+	var workloads []Workload
+	err := internal.ReadDB().Model(&gormmodels.User{}).
+		Select("users.id AS user_id, users.name, users.email, "+
+			"COUNT(CASE WHEN assignments.status = ? THEN 1 END) AS open, "+
+			"COUNT(CASE WHEN assignments.status = ? AND assignments.due_at < ? THEN 1 END) AS overdue, "+
+			"COUNT(CASE WHEN assignments.status = ? AND assignments.due_at >= ? AND assignments.due_at < ? THEN 1 END) AS due_soon, "+
+			"COUNT(CASE WHEN assignments.status = ? AND assignments.completed_at >= ? THEN 1 END) AS completed_last_week",
+			gormmodels.AssignmentOpen,
+			gormmodels.AssignmentOpen, now,
+			gormmodels.AssignmentOpen, now, now.Add(dueSoonWindow),
+			gormmodels.AssignmentDone, now.AddDate(0, 0, -7)).
+		Joins("LEFT JOIN assignments ON assignments.assignee_id = users.id AND assignments.workspace_id = users.workspace_id").
+		Where("users.workspace_id = ?", workspaceID).
+		Group("users.id, users.name, users.email").
+		Order("open DESC, users.name").
+		Scan(&workloads).Error
+	if err != nil {
+		return nil, storageError("failed to count workloads", err)
+	}
+	return workloads, nil
+}
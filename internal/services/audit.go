@@ -0,0 +1,33 @@
+package services
+
+import (
+	"log"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+type AuditService struct{}
+
+func NewAuditService() *AuditService {
+	return &AuditService{}
+}
+
+// Record stores an audit entry. Failures are logged rather than returned so
+// auditing never blocks the action being audited.
+func (s *AuditService) Record(entry *gormmodels.AuditLog) {
+	if err := internal.DB.Create(entry).Error; err != nil {
+		log.Printf("Warning: failed to record audit log %s: %v", entry.Action, err)
+	}
+}
+
+func (s *AuditService) GetByUserID(userID string, limit int) ([]gormmodels.AuditLog, error) {
+	var logs []gormmodels.AuditLog
+
+	err := internal.DB.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&logs).Error
+	if err != nil {
+		return nil, storageError("failed to fetch audit logs", err)
+	}
+
+	return logs, nil
+}
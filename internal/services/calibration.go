@@ -0,0 +1,89 @@
+package services
+
+import (
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CalibrationService struct{}
+
+func NewCalibrationService() *CalibrationService {
+	return &CalibrationService{}
+}
+
+// ReferencePoint pairs a point in editor SVG coordinates with the page
+// coordinate it should land on in the printed PDF.
+type ReferencePoint struct {
+	SVGX  float64
+	SVGY  float64
+	PageX float64
+	PageY float64
+}
+
+// ComputeCalibration derives a per-axis scale and offset from two reference
+// points. The points must keep their order on the page: a scale of zero or
+// below would collapse or mirror the fields.
+func ComputeCalibration(a, b ReferencePoint) (scaleX, scaleY, offsetX, offsetY float64, err error) {
+	dx := b.SVGX - a.SVGX
+	dy := b.SVGY - a.SVGY
+	if dx == 0 || dy == 0 {
+		return 0, 0, 0, 0, newError(ErrValidation, "reference points must differ on both axes")
+	}
+
+	scaleX = (b.PageX - a.PageX) / dx
+	scaleY = (b.PageY - a.PageY) / dy
+	if scaleX <= 0 || scaleY <= 0 {
+		return 0, 0, 0, 0, newError(ErrValidation, "reference points must be in the same order on the page as in the editor")
+	}
+	offsetX = a.PageX - scaleX*a.SVGX
+	offsetY = a.PageY - scaleY*a.SVGY
+	return scaleX, scaleY, offsetX, offsetY, nil
+}
+
+func (s *CalibrationService) Calibrate(templateID string, pageIndex int, a, b ReferencePoint) (*gormmodels.PageCalibration, error) {
+	scaleX, scaleY, offsetX, offsetY, err := ComputeCalibration(a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	calibration := &gormmodels.PageCalibration{
+		TemplateID: templateID,
+		PageIndex:  pageIndex,
+		ScaleX:     scaleX,
+		ScaleY:     scaleY,
+		OffsetX:    offsetX,
+		OffsetY:    offsetY,
+	}
+
+	err = internal.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "template_id"}, {Name: "page_index"}},
+		DoUpdates: clause.AssignmentColumns([]string{"scale_x", "scale_y", "offset_x", "offset_y", "updated_at"}),
+	}).Create(calibration).Error
+	if err != nil {
+		return nil, storageError("failed to save calibration", err)
+	}
+
+	return calibration, nil
+}
+
+func (s *CalibrationService) GetByTemplateID(templateID string) ([]gormmodels.PageCalibration, error) {
+	var calibrations []gormmodels.PageCalibration
+
+	err := internal.DB.Where("template_id = ?", templateID).Order("page_index ASC").Find(&calibrations).Error
+	if err != nil {
+		return nil, storageError("failed to fetch calibrations", err)
+	}
+
+	return calibrations, nil
+}
+
+func (s *CalibrationService) Delete(templateID string, pageIndex int) error {
+	err := internal.DB.Where("template_id = ? AND page_index = ?", templateID, pageIndex).Delete(&gormmodels.PageCalibration{}).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return storageError("failed to delete calibration", err)
+	}
+	return nil
+}
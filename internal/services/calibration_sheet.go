@@ -0,0 +1,73 @@
+package services
+
+import (
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// sheetPxPerMM converts millimetres measured on a printed calibration sheet
+// to page pixels at 96 DPI.
+const sheetPxPerMM = 96 / 25.4
+
+// SheetMark is a field box marked on a printed calibration sheet, in the
+// sheet's ruler coordinates. Unit is "px" (the default) or "mm".
+type SheetMark struct {
+	DataKey    string  `json:"dataKey"`
+	Occurrence int     `json:"occurrence,omitempty"`
+	Top        float64 `json:"top"`
+	Left       float64 `json:"left"`
+	Width      float64 `json:"width"`
+	Height     float64 `json:"height"`
+	Unit       string  `json:"unit,omitempty"`
+}
+
+// ApplySheetMarks moves the layout entries named by marks onto the sheet's
+// page. Sheet coordinates are where the field should print, so they are
+// mapped back through the page calibration, if any, to editor coordinates.
+// Marks without a matching entry of an exported layout are returned as
+// unmatched.
+func ApplySheetMarks(layout *TemplateLayout, pageIndex int, calibration *gormmodels.PageCalibration, marks []SheetMark) ([]string, error) {
+	if calibration != nil && (calibration.ScaleX == 0 || calibration.ScaleY == 0) {
+		return nil, newErrorf(ErrValidation, "calibration of page %d cannot be inverted", pageIndex)
+	}
+
+	type fieldKey struct {
+		dataKey    string
+		occurrence int
+	}
+	entries := make(map[fieldKey]*FieldLayout, len(layout.Fields))
+	for i := range layout.Fields {
+		entry := &layout.Fields[i]
+		entries[fieldKey{entry.DataKey, entry.Occurrence}] = entry
+	}
+
+	unmatched := []string{}
+	for _, mark := range marks {
+		entry, ok := entries[fieldKey{mark.DataKey, mark.Occurrence}]
+		if !ok {
+			unmatched = append(unmatched, mark.DataKey)
+			continue
+		}
+
+		position := LayoutPosition{Top: mark.Top, Left: mark.Left, Width: mark.Width, Height: mark.Height}
+		if mark.Unit == "mm" {
+			position = LayoutPosition{
+				Top:    position.Top * sheetPxPerMM,
+				Left:   position.Left * sheetPxPerMM,
+				Width:  position.Width * sheetPxPerMM,
+				Height: position.Height * sheetPxPerMM,
+			}
+		}
+		if calibration != nil {
+			position = LayoutPosition{
+				Top:    (position.Top - calibration.OffsetY) / calibration.ScaleY,
+				Left:   (position.Left - calibration.OffsetX) / calibration.ScaleX,
+				Width:  position.Width / calibration.ScaleX,
+				Height: position.Height / calibration.ScaleY,
+			}
+		}
+
+		entry.PageIndex = pageIndex
+		entry.Position = position
+	}
+	return unmatched, nil
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// CDNService builds CDN URLs for template assets and purges them when they
+// change. Assets are served through the /api/svg asset proxy, which the CDN
+// uses as its origin. Purge requests post {"files": [...]} to the purge URL
+// with a bearer token, the format used by Cloudflare's purge_cache endpoint.
+type CDNService struct {
+	assetHost  string
+	purgeURL   string
+	purgeToken string
+	client     *http.Client
+}
+
+func NewCDNService(assetHost, purgeURL, purgeToken string) *CDNService {
+	return &CDNService{
+		assetHost:  strings.TrimSuffix(assetHost, "/"),
+		purgeURL:   purgeURL,
+		purgeToken: purgeToken,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Enabled reports whether asset URLs should point at the CDN.
+func (s *CDNService) Enabled() bool {
+	return s.assetHost != ""
+}
+
+// PageURL returns the CDN URL of a template page background, or "" when no
+// CDN is configured.
+func (s *CDNService) PageURL(templateID string, pageIndex int) string {
+	if !s.Enabled() {
+		return ""
+	}
+	return fmt.Sprintf("%s/api/svg/%s/page_%d.svg", s.assetHost, templateID, pageIndex)
+}
+
+// PurgePage evicts a page background from the CDN cache. It does nothing
+// when no CDN or purge endpoint is configured.
+func (s *CDNService) PurgePage(ctx context.Context, templateID string, pageIndex int) error {
+	if !s.Enabled() || s.purgeURL == "" {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string][]string{"files": {s.PageURL(templateID, pageIndex)}})
+	if err != nil {
+		return fmt.Errorf("failed to encode purge request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.purgeURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create purge request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.purgeToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.purgeToken)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to purge CDN cache: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("CDN purge failed: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"encoding/json"
+	"sort"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+)
+
+// jsonSchemaDialect is the JSON Schema version of generated data schemas.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// schemaNode is one object, array or value in the formData structure
+// described by a template's DataKeys.
+type schemaNode struct {
+	value      map[string]interface{}
+	properties map[string]*schemaNode
+	required   map[string]bool
+	items      *schemaNode
+}
+
+// BuildDataSchema describes the formData a template expects as a JSON
+// Schema. Nested DataKeys such as "applicant.address.province" become nested
+// objects and "children[2].name" an array of objects; every array element
+// shares one item schema. Option lists are used for the allowed values of
+// list-backed fields.
+func BuildDataSchema(template *gormmodels.Template, optionLists map[string]gormmodels.OptionList) map[string]interface{} {
+	root := &schemaNode{}
+	for _, field := range template.Fields {
+		if field.StampID != "" {
+			// Fixed stamps take no data.
+			continue
+		}
+		segments, err := utils.ParseDataPath(field.DataKey)
+		if err != nil || !utils.IsDataPath(field.DataKey) {
+			segments = []utils.PathSegment{{Key: field.DataKey}}
+		}
+
+		node := root
+		for i, segment := range segments {
+			parent := node
+			if segment.IsIndex {
+				if node.items == nil {
+					node.items = &schemaNode{}
+				}
+				node = node.items
+			} else {
+				if node.properties == nil {
+					node.properties = make(map[string]*schemaNode)
+				}
+				if node.properties[segment.Key] == nil {
+					node.properties[segment.Key] = &schemaNode{}
+				}
+				node = node.properties[segment.Key]
+			}
+
+			// A required field makes every object on its path required too.
+			if field.Required && !segment.IsIndex {
+				if parent.required == nil {
+					parent.required = make(map[string]bool)
+				}
+				parent.required[segment.Key] = true
+			}
+			if i == len(segments)-1 && node.value == nil {
+				node.value = fieldValueSchema(field, optionLists)
+			}
+		}
+	}
+
+	schema := root.jsonSchema()
+	schema["$schema"] = jsonSchemaDialect
+	schema["$id"] = "/api/templates/" + template.ID + "/data-schema"
+	if template.DataInterface != "" {
+		schema["title"] = template.DataInterface
+	}
+	return schema
+}
+
+// jsonSchema renders the node. Nodes with nested keys or indexes are objects
+// or arrays even when a field also binds the whole value.
+func (n *schemaNode) jsonSchema() map[string]interface{} {
+	switch {
+	case n.properties != nil:
+		properties := make(map[string]interface{}, len(n.properties))
+		for key, child := range n.properties {
+			properties[key] = child.jsonSchema()
+		}
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(n.required) > 0 {
+			required := make([]string, 0, len(n.required))
+			for key := range n.required {
+				required = append(required, key)
+			}
+			sort.Strings(required)
+			schema["required"] = required
+		}
+		return schema
+	case n.items != nil:
+		return map[string]interface{}{"type": "array", "items": n.items.jsonSchema()}
+	case n.value != nil:
+		return n.value
+	}
+	return map[string]interface{}{}
+}
+
+// fieldValueSchema describes the value of one field from its type and options.
+func fieldValueSchema(field gormmodels.Field, optionLists map[string]gormmodels.OptionList) map[string]interface{} {
+	schema := map[string]interface{}{}
+	if field.Name != "" {
+		schema["title"] = field.Name
+	}
+
+	switch field.Type {
+	case FieldTypeNumber:
+		schema["type"] = "number"
+	case FieldTypeDate:
+		schema["type"] = "string"
+		schema["format"] = "date"
+	case FieldTypeSignature:
+		schema["type"] = "string"
+		schema["description"] = "ID of a stored signature of the submitting user"
+	case FieldTypeStamp:
+		schema["type"] = "string"
+		schema["description"] = "ID of a stamp of the submitting workspace"
+	case FieldTypeCheckbox:
+		schema["type"] = "boolean"
+		return schema
+	case FieldTypeImage:
+		schema["type"] = "string"
+		schema["description"] = "Base64 data URI of a PNG, JPEG, GIF or WebP image"
+		return schema
+	case FieldTypeTable:
+		// Rows of cells; a row that is a single value is one cell.
+		schema["type"] = "array"
+		schema["items"] = map[string]interface{}{
+			"anyOf": []interface{}{
+				map[string]interface{}{"type": "array"},
+				map[string]interface{}{"type": []string{"string", "number", "boolean"}},
+			},
+		}
+		return schema
+	case FieldTypeCheckboxGroup:
+		// One value, or an array of values to tick several boxes.
+		values := boxValues(field)
+		schema["anyOf"] = []interface{}{
+			map[string]interface{}{"type": "string", "enum": values},
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string", "enum": values}},
+		}
+		return schema
+	default:
+		schema["type"] = "string"
+	}
+
+	var options []string
+	if list, ok := optionLists[field.OptionListID]; ok && field.OptionListID != "" {
+		options = list.Options
+	} else if field.Options != "" {
+		_ = json.Unmarshal([]byte(field.Options), &options)
+	}
+	if len(options) > 0 {
+		schema["enum"] = options
+	}
+
+	return schema
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// debugWindowCacheTTL bounds how long a window change takes to reach every
+// request; Enable and Disable apply at once on the instance that served them.
+const debugWindowCacheTTL = 30 * time.Second
+
+// MaxRecordedBody is the largest body a debug recording keeps. JSON bodies
+// above it are summarized, since a truncated body cannot be sanitized.
+const MaxRecordedBody = 256 << 10
+
+// redactedValue replaces the values a recording must not keep.
+const redactedValue = "[redacted]"
+
+// secretKeyParts mark keys whose values are credentials wherever they appear.
+var secretKeyParts = []string{"password", "secret", "token", "apikey", "api_key", "authorization", "credential"}
+
+// personalKeys hold the personal data OCR responses carry.
+var personalKeys = map[string]bool{
+	"idNumber": true, "nameTh": true, "firstNameEn": true, "lastNameEn": true,
+	"dateOfBirth": true, "address": true, "text": true,
+}
+
+// DebugRecordingService records sanitized request/response pairs of a
+// workspace while it has an open recording window, so support can replay
+// what a customer sent. Recordings are purged after the retention period.
+type DebugRecordingService struct {
+	maxWindow time.Duration
+	retention time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedWindow
+}
+
+type cachedWindow struct {
+	expiresAt time.Time
+	checked   time.Time
+}
+
+func NewDebugRecordingService(maxWindow, retention time.Duration) *DebugRecordingService {
+	return &DebugRecordingService{
+		maxWindow: maxWindow,
+		retention: retention,
+		cache:     make(map[string]cachedWindow),
+	}
+}
+
+// Enable opens, or extends, the workspace's recording window for duration.
+// Windows are capped at the configured maximum.
+func (s *DebugRecordingService) Enable(workspaceID, userID, reason string, duration time.Duration) (*gormmodels.DebugRecordingWindow, error) {
+	if duration <= 0 {
+		return nil, newError(ErrValidation, "recording window must be longer than zero")
+	}
+	if duration > s.maxWindow {
+		return nil, newErrorf(ErrValidation, "recording window may not exceed %s", s.maxWindow)
+	}
+
+	window := &gormmodels.DebugRecordingWindow{
+		WorkspaceID: workspaceID,
+		EnabledBy:   userID,
+		Reason:      strings.TrimSpace(reason),
+		ExpiresAt:   time.Now().Add(duration),
+	}
+	err := internal.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled_by", "reason", "expires_at"}),
+	}).Create(window).Error
+	if err != nil {
+		return nil, storageError("failed to enable debug recording", err)
+	}
+
+	s.remember(workspaceID, window.ExpiresAt)
+	return window, nil
+}
+
+// Disable closes the workspace's recording window. Recordings already made
+// are kept until they expire.
+func (s *DebugRecordingService) Disable(workspaceID string) error {
+	if err := internal.DB.Delete(&gormmodels.DebugRecordingWindow{}, "workspace_id = ?", workspaceID).Error; err != nil {
+		return storageError("failed to disable debug recording", err)
+	}
+	s.remember(workspaceID, time.Time{})
+	return nil
+}
+
+// Window returns the workspace's open recording window, or nil if it has
+// none or it has expired.
+func (s *DebugRecordingService) Window(workspaceID string) (*gormmodels.DebugRecordingWindow, error) {
+	var window gormmodels.DebugRecordingWindow
+	result := internal.ReadDB().Where("workspace_id = ? AND expires_at > ?", workspaceID, time.Now()).Limit(1).Find(&window)
+	if result.Error != nil {
+		return nil, storageError("failed to fetch debug recording window", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &window, nil
+}
+
+// Active reports whether the workspace's requests are being recorded. Lookups
+// are cached briefly since every recordable request asks.
+func (s *DebugRecordingService) Active(workspaceID string) bool {
+	if workspaceID == "" {
+		return false
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	cached, ok := s.cache[workspaceID]
+	s.mu.Unlock()
+	if ok && now.Sub(cached.checked) < debugWindowCacheTTL {
+		return now.Before(cached.expiresAt)
+	}
+
+	window, err := s.Window(workspaceID)
+	if err != nil {
+		// Recording is best-effort; a failed lookup records nothing.
+		return false
+	}
+	var expiresAt time.Time
+	if window != nil {
+		expiresAt = window.ExpiresAt
+	}
+	s.remember(workspaceID, expiresAt)
+	return now.Before(expiresAt)
+}
+
+func (s *DebugRecordingService) remember(workspaceID string, expiresAt time.Time) {
+	s.mu.Lock()
+	s.cache[workspaceID] = cachedWindow{expiresAt: expiresAt, checked: time.Now()}
+	s.mu.Unlock()
+}
+
+// Record stores a recording. Failures are logged, never returned, so that
+// recording cannot fail the request it records.
+func (s *DebugRecordingService) Record(recording *gormmodels.DebugRecording) {
+	if recording.ID == "" {
+		recording.ID = uuid.New().String()
+	}
+	if err := internal.DB.Create(recording).Error; err != nil {
+		log.Printf("Warning: failed to store debug recording for workspace %s: %v", recording.WorkspaceID, err)
+	}
+}
+
+// List returns the workspace's recordings, newest first, without bodies.
+func (s *DebugRecordingService) List(workspaceID string, limit int) ([]gormmodels.DebugRecording, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	var recordings []gormmodels.DebugRecording
+	err := internal.ReadDB().Omit("request_body", "response_body").
+		Where("workspace_id = ?", workspaceID).
+		Order("created_at DESC").Limit(limit).Find(&recordings).Error
+	if err != nil {
+		return nil, storageError("failed to fetch debug recordings", err)
+	}
+	return recordings, nil
+}
+
+// Get returns a recording with its bodies, or nil if it does not exist.
+func (s *DebugRecordingService) Get(id string) (*gormmodels.DebugRecording, error) {
+	var recording gormmodels.DebugRecording
+	result := internal.ReadDB().Where("id = ?", id).Limit(1).Find(&recording)
+	if result.Error != nil {
+		return nil, storageError("failed to fetch debug recording", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &recording, nil
+}
+
+// Purge deletes recordings older than the retention period and windows that
+// have expired.
+func (s *DebugRecordingService) Purge() (int64, error) {
+	result := internal.DB.Where("created_at < ?", time.Now().Add(-s.retention)).Delete(&gormmodels.DebugRecording{})
+	if result.Error != nil {
+		return 0, storageError("failed to purge debug recordings", result.Error)
+	}
+	if err := internal.DB.Where("expires_at < ?", time.Now()).Delete(&gormmodels.DebugRecordingWindow{}).Error; err != nil {
+		return result.RowsAffected, storageError("failed to purge debug recording windows", err)
+	}
+	return result.RowsAffected, nil
+}
+
+// StartPurger runs Purge periodically until ctx is cancelled.
+func (s *DebugRecordingService) StartPurger(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := s.Purge(); err != nil {
+					log.Printf("Warning: debug recording purge failed: %v", err)
+				} else if n > 0 {
+					log.Printf("Purged %d expired debug recordings", n)
+				}
+			}
+		}
+	}()
+}
+
+// SensitiveKeys returns the DataKeys of the template's fields marked
+// sensitive, whose values recordings redact.
+func (s *DebugRecordingService) SensitiveKeys(templateID string) map[string]bool {
+	keys := make(map[string]bool)
+	if templateID == "" {
+		return keys
+	}
+	var dataKeys []string
+	err := internal.ReadDB().Model(&gormmodels.Field{}).
+		Where("template_id = ? AND sensitive = ?", templateID, true).Pluck("data_key", &dataKeys).Error
+	if err != nil {
+		log.Printf("Warning: failed to fetch sensitive fields of template %s: %v", templateID, err)
+	}
+	for _, key := range dataKeys {
+		keys[key] = true
+	}
+	return keys
+}
+
+// SanitizeRecordedBody returns what a recording keeps of a body: JSON with
+// credentials, personal data and the given sensitive DataKeys redacted, or a
+// summary of the content type, size and SHA-256 of anything else. JSON bodies
+// that were cut off at MaxRecordedBody are summarized as well.
+func SanitizeRecordedBody(contentType string, body []byte, size int64, sensitiveKeys map[string]bool) string {
+	if size == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/json" && int64(len(body)) == size {
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err == nil {
+			sanitized, err := json.Marshal(redactValue(value, "", sensitiveKeys))
+			if err == nil {
+				return string(sanitized)
+			}
+		}
+	}
+	return summarizeBody(contentType, body, size)
+}
+
+func summarizeBody(contentType string, body []byte, size int64) string {
+	if int64(len(body)) < size {
+		return fmt.Sprintf("[%s, %d bytes, not recorded]", contentType, size)
+	}
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("[%s, %d bytes, sha256 %s]", contentType, size, hex.EncodeToString(sum[:]))
+}
+
+// redactValue walks a decoded JSON value. path is the dotted key below the
+// nearest data, formattingData or htmlData object, matched against DataKeys.
+func redactValue(value interface{}, path string, sensitiveKeys map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			switch {
+			case isSecretKey(key) || personalKeys[key] || sensitiveKeys[childPath]:
+				v[key] = redactedValue
+			case path == "" && (key == "data" || key == "formattingData" || key == "htmlData"):
+				v[key] = redactValue(child, "", sensitiveKeys)
+			default:
+				v[key] = redactValue(child, childPath, sensitiveKeys)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactValue(child, path, sensitiveKeys)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
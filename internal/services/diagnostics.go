@@ -0,0 +1,138 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// RenderArtifacts points at the debugging material captured for a failed render.
+type RenderArtifacts struct {
+	ID             string    `json:"id"`
+	HTMLPath       string    `json:"htmlPath"`
+	ScreenshotPath string    `json:"screenshotPath,omitempty"`
+	HTMLURL        string    `json:"htmlUrl,omitempty"`
+	ScreenshotURL  string    `json:"screenshotUrl,omitempty"`
+	ExpiresAt      time.Time `json:"expiresAt"`
+}
+
+// DiagnosticsService stores render artifacts under a bucket prefix and purges
+// them once they are older than the retention period.
+type DiagnosticsService struct {
+	storageClient storage.StorageClient
+	prefix        string
+	retention     time.Duration
+	enabled       bool
+}
+
+func NewDiagnosticsService(storageClient storage.StorageClient, prefix string, retention time.Duration, enabled bool) *DiagnosticsService {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return &DiagnosticsService{
+		storageClient: storageClient,
+		prefix:        prefix,
+		retention:     retention,
+		enabled:       enabled,
+	}
+}
+
+// Capture uploads the intermediate HTML and, if available, a screenshot.
+// It returns nil when diagnostics are disabled or the upload fails.
+func (s *DiagnosticsService) Capture(ctx context.Context, htmlContent string, screenshot []byte) *RenderArtifacts {
+	if !s.enabled {
+		return nil
+	}
+
+	id := uuid.New().String()
+	dir := path.Join(s.prefix, time.Now().UTC().Format("2006-01-02"), id)
+	artifacts := &RenderArtifacts{
+		ID:        id,
+		HTMLPath:  dir + "/document.html",
+		ExpiresAt: time.Now().Add(s.retention),
+	}
+
+	if _, err := s.storageClient.UploadFile(ctx, strings.NewReader(htmlContent), artifacts.HTMLPath, "text/html; charset=utf-8"); err != nil {
+		log.Printf("Warning: failed to store render diagnostics %s: %v", id, err)
+		return nil
+	}
+	if url, err := s.storageClient.GetSignedURL(artifacts.HTMLPath, s.linkLifetime()); err == nil {
+		artifacts.HTMLURL = url
+	}
+
+	if len(screenshot) > 0 {
+		screenshotPath := dir + "/screenshot.png"
+		if _, err := s.storageClient.UploadFile(ctx, bytes.NewReader(screenshot), screenshotPath, "image/png"); err != nil {
+			log.Printf("Warning: failed to store render screenshot %s: %v", id, err)
+		} else {
+			artifacts.ScreenshotPath = screenshotPath
+			if url, err := s.storageClient.GetSignedURL(screenshotPath, s.linkLifetime()); err == nil {
+				artifacts.ScreenshotURL = url
+			}
+		}
+	}
+
+	log.Printf("Render diagnostics %s captured at %s", id, dir)
+	return artifacts
+}
+
+// Purge deletes artifacts older than the retention period.
+func (s *DiagnosticsService) Purge(ctx context.Context) (int, error) {
+	objects, err := s.storageClient.ListObjects(ctx, s.prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().Add(-s.retention)
+	deleted := 0
+	for _, obj := range objects {
+		if obj.Created.Before(cutoff) {
+			if err := s.storageClient.DeleteFile(ctx, obj.Name); err != nil {
+				return deleted, storageError(fmt.Sprintf("failed to purge %s", obj.Name), err)
+			}
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// StartPurger runs Purge periodically until ctx is cancelled.
+func (s *DiagnosticsService) StartPurger(ctx context.Context, interval time.Duration) {
+	if !s.enabled {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if n, err := s.Purge(ctx); err != nil {
+					log.Printf("Warning: diagnostics purge failed: %v", err)
+				} else if n > 0 {
+					log.Printf("Purged %d expired render diagnostics", n)
+				}
+			}
+		}
+	}()
+}
+
+// linkLifetime caps signed URLs at seven days, the V4 signing maximum.
+func (s *DiagnosticsService) linkLifetime() time.Duration {
+	if s.retention > 7*24*time.Hour {
+		return 7 * 24 * time.Hour
+	}
+	return s.retention
+}
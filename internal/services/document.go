@@ -0,0 +1,222 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// documentURLTTL is how long signed URLs of stored documents stay valid.
+const documentURLTTL = time.Hour
+
+type DocumentService struct {
+	storageClient storage.StorageClient
+
+	// TextExtractor, if set, indexes the text of stored documents for Search.
+	TextExtractor TextExtractor
+}
+
+func NewDocumentService(storageClient storage.StorageClient) *DocumentService {
+	return &DocumentService{
+		storageClient: storageClient,
+	}
+}
+
+// Store uploads a rendered PDF for a submission. It reports whether the
+// rendered HTML differs from the previously stored document.
+func (s *DocumentService) Store(ctx context.Context, submission *gormmodels.FormSubmission, htmlContent string, pdfBytes []byte) (*gormmodels.GeneratedDocument, bool, error) {
+	sum := sha256.Sum256([]byte(htmlContent))
+	htmlHash := hex.EncodeToString(sum[:])
+
+	previous, err := s.GetLatest(submission.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	changed := previous == nil || previous.HTMLHash != htmlHash
+
+	objectName := fmt.Sprintf("documents/%s/%s/%d.pdf", submission.TemplateID, submission.ID, time.Now().UnixNano())
+	result, err := s.storageClient.UploadFile(ctx, bytes.NewReader(pdfBytes), objectName, "application/pdf")
+	if err != nil {
+		return nil, false, storageError("failed to upload document", err)
+	}
+
+	document := &gormmodels.GeneratedDocument{
+		ID:           uuid.New().String(),
+		SubmissionID: submission.ID,
+		TemplateID:   submission.TemplateID,
+		GCSPath:      objectName,
+		KMSKeyName:   result.KMSKeyName,
+		FileSize:     result.Size,
+		HTMLHash:     htmlHash,
+	}
+
+	if err := internal.DB.Create(document).Error; err != nil {
+		s.storageClient.DeleteFile(ctx, objectName)
+		return nil, false, storageError("failed to save document metadata", err)
+	}
+
+	s.indexText(ctx, document, pdfBytes)
+
+	return document, changed, nil
+}
+
+// indexText stores the document's text for search. Failures are logged: a
+// document that cannot be indexed is still stored.
+func (s *DocumentService) indexText(ctx context.Context, document *gormmodels.GeneratedDocument, pdfBytes []byte) {
+	if s.TextExtractor == nil {
+		return
+	}
+
+	content, err := s.TextExtractor.ExtractText(ctx, pdfBytes)
+	if err != nil {
+		log.Printf("Warning: failed to extract text of document %s: %v", document.ID, err)
+		return
+	}
+
+	text := &gormmodels.DocumentText{
+		DocumentID:   document.ID,
+		SubmissionID: document.SubmissionID,
+		TemplateID:   document.TemplateID,
+		Content:      strings.Join(strings.Fields(content), " "),
+	}
+	if err := internal.DB.Create(text).Error; err != nil {
+		log.Printf("Warning: failed to index text of document %s: %v", document.ID, err)
+	}
+}
+
+const (
+	defaultSearchResults = 20
+	maxSearchResults     = 100
+	// searchSnippetRunes is the text shown either side of a search match.
+	searchSnippetRunes = 80
+)
+
+// DocumentMatch is a generated document whose text matched a search.
+type DocumentMatch struct {
+	DocumentID   string    `json:"documentId"`
+	SubmissionID string    `json:"submissionId"`
+	TemplateID   string    `json:"templateId"`
+	Snippet      string    `json:"snippet"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// DocumentSearch selects documents by their text. Documents of templates
+// owned by other workspaces than WorkspaceID are never matched.
+type DocumentSearch struct {
+	WorkspaceID string
+	Query       string
+	TemplateID  string
+	Limit       int
+}
+
+// Search finds generated documents containing the query as a phrase, newest
+// first, with a snippet of the text around the match.
+func (s *DocumentService) Search(search DocumentSearch) ([]DocumentMatch, error) {
+	query := strings.TrimSpace(search.Query)
+	if len([]rune(query)) < 2 {
+		return nil, newError(ErrValidation, "search query must be at least 2 characters")
+	}
+	if search.Limit <= 0 {
+		search.Limit = defaultSearchResults
+	}
+	if search.Limit > maxSearchResults {
+		search.Limit = maxSearchResults
+	}
+
+	// A quoted phrase in boolean mode; quotes inside the query would end it.
+	phrase := `"` + strings.ReplaceAll(query, `"`, " ") + `"`
+	db := internal.ReadDB().Model(&gormmodels.DocumentText{}).
+		Joins("JOIN templates ON templates.id = document_texts.template_id").
+		Where("templates.workspace_id = '' OR templates.workspace_id IS NULL OR templates.workspace_id = ?", search.WorkspaceID).
+		Where("MATCH(document_texts.content) AGAINST (? IN BOOLEAN MODE)", phrase)
+	if search.TemplateID != "" {
+		db = db.Where("document_texts.template_id = ?", search.TemplateID)
+	}
+
+	var texts []gormmodels.DocumentText
+	err := db.Select("document_texts.*").Order("document_texts.created_at DESC").Limit(search.Limit).Find(&texts).Error
+	if err != nil {
+		return nil, storageError("failed to search documents", err)
+	}
+
+	matches := make([]DocumentMatch, len(texts))
+	for i, text := range texts {
+		matches[i] = DocumentMatch{
+			DocumentID:   text.DocumentID,
+			SubmissionID: text.SubmissionID,
+			TemplateID:   text.TemplateID,
+			Snippet:      searchSnippet(text.Content, query),
+			CreatedAt:    text.CreatedAt,
+		}
+	}
+	return matches, nil
+}
+
+// searchSnippet returns the text around the first case-insensitive match of
+// query, or the start of the text when it only matched as ngrams.
+func searchSnippet(content, query string) string {
+	runes := []rune(content)
+	start := 0
+	if index := strings.Index(strings.ToLower(content), strings.ToLower(query)); index >= 0 {
+		start = len([]rune(content[:index]))
+	}
+
+	from := max(start-searchSnippetRunes, 0)
+	to := min(start+len([]rune(query))+searchSnippetRunes, len(runes))
+	snippet := string(runes[from:to])
+	if from > 0 {
+		snippet = "…" + snippet
+	}
+	if to < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func (s *DocumentService) GetByID(id string) (*gormmodels.GeneratedDocument, error) {
+	var document gormmodels.GeneratedDocument
+
+	err := internal.DB.Where("id = ?", id).First(&document).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch document", err)
+	}
+
+	return &document, nil
+}
+
+func (s *DocumentService) GetLatest(submissionID string) (*gormmodels.GeneratedDocument, error) {
+	var document gormmodels.GeneratedDocument
+
+	err := internal.DB.Where("submission_id = ?", submissionID).Order("created_at DESC").First(&document).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch document", err)
+	}
+
+	return &document, nil
+}
+
+func (s *DocumentService) GetSignedURL(document *gormmodels.GeneratedDocument) (string, error) {
+	signedURL, err := s.storageClient.GetSignedURL(document.GCSPath, documentURLTTL)
+	if err != nil {
+		return "", storageError("failed to generate signed URL", err)
+	}
+	return signedURL, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultNumberReservationTTL is how long a reserved document number is held
+// when the configuration leaves it unset.
+const DefaultNumberReservationTTL = 24 * time.Hour
+
+var (
+	// ErrReservationNotFound is returned for reservations that do not exist,
+	// including lapsed ones whose number was handed out again.
+	ErrReservationNotFound = newError(ErrNotFound, "number reservation not found")
+	// ErrReservationConfirmed is returned when confirming a number already
+	// confirmed for another submission.
+	ErrReservationConfirmed = newError(ErrConflict, "number is already confirmed for another submission")
+)
+
+// NumberingService hands out gapless document numbers per template. A number
+// is reserved before the document is generated, for example to print it on
+// paper first, and confirmed once the document exists. Numbers of lapsed
+// reservations are handed out again, lowest first, before new ones.
+type NumberingService struct {
+	// TTL is how long a reservation is held unconfirmed; zero means
+	// DefaultNumberReservationTTL.
+	TTL time.Duration
+}
+
+func NewNumberingService() *NumberingService {
+	return &NumberingService{}
+}
+
+func (s *NumberingService) ttl() time.Duration {
+	if s.TTL <= 0 {
+		return DefaultNumberReservationTTL
+	}
+	return s.TTL
+}
+
+// Reserve holds the next document number of a template for userID. The
+// template's counter row is locked for the duration, so concurrent
+// reservations never get the same number.
+func (s *NumberingService) Reserve(templateID, userID string) (*gormmodels.NumberReservation, error) {
+	var reservation gormmodels.NumberReservation
+
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		counter := gormmodels.DocumentNumberCounter{TemplateID: templateID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&counter).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("template_id = ?", templateID).First(&counter).Error; err != nil {
+			return err
+		}
+
+		reservation = gormmodels.NumberReservation{
+			TemplateID: templateID,
+			Status:     gormmodels.NumberReserved,
+			ReservedBy: userID,
+			ExpiresAt:  now.Add(s.ttl()),
+		}
+
+		// The lapsed reservation is replaced rather than renewed, so its
+		// former holder can no longer confirm it.
+		var lapsed gormmodels.NumberReservation
+		err := tx.Where("template_id = ? AND status = ? AND expires_at <= ?", templateID, gormmodels.NumberReserved, now).
+			Order("number").First(&lapsed).Error
+		switch {
+		case err == nil:
+			if err := tx.Delete(&lapsed).Error; err != nil {
+				return err
+			}
+			reservation.Number = lapsed.Number
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			counter.LastNumber++
+			if err := tx.Model(&counter).Update("last_number", counter.LastNumber).Error; err != nil {
+				return err
+			}
+			reservation.Number = counter.LastNumber
+		default:
+			return err
+		}
+
+		return tx.Create(&reservation).Error
+	})
+	if err != nil {
+		return nil, storageError("failed to reserve document number", err)
+	}
+	return &reservation, nil
+}
+
+// Confirm marks a reserved number as used by a generated document, optionally
+// recording its submission. Confirming again is a no-op that returns the
+// reservation, so callers may retry; a lapsed reservation can still be
+// confirmed until its number is handed out again.
+func (s *NumberingService) Confirm(templateID string, reservationID uint, submissionID string) (*gormmodels.NumberReservation, error) {
+	if submissionID != "" {
+		var count int64
+		err := internal.DB.Model(&gormmodels.FormSubmission{}).Where("id = ? AND template_id = ?", submissionID, templateID).Count(&count).Error
+		if err != nil {
+			return nil, storageError("failed to fetch submission", err)
+		}
+		if count == 0 {
+			return nil, newError(ErrValidation, "submissionId is not a submission of this template")
+		}
+	}
+
+	var reservation gormmodels.NumberReservation
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND template_id = ?", reservationID, templateID).First(&reservation).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrReservationNotFound
+			}
+			return storageError("failed to fetch number reservation", err)
+		}
+
+		if reservation.Status == gormmodels.NumberConfirmed {
+			if submissionID != "" && reservation.SubmissionID != "" && submissionID != reservation.SubmissionID {
+				return ErrReservationConfirmed
+			}
+			if submissionID == "" || reservation.SubmissionID != "" {
+				return nil
+			}
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"status": gormmodels.NumberConfirmed}
+		if reservation.ConfirmedAt == nil {
+			updates["confirmed_at"] = now
+			reservation.ConfirmedAt = &now
+		}
+		if submissionID != "" {
+			updates["submission_id"] = submissionID
+			reservation.SubmissionID = submissionID
+		}
+		if err := tx.Model(&reservation).Updates(updates).Error; err != nil {
+			return storageError("failed to confirm document number", err)
+		}
+		reservation.Status = gormmodels.NumberConfirmed
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
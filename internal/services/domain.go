@@ -0,0 +1,292 @@
+package services
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// domainRecordPrefix is prepended to a custom domain to name the TXT
+	// record that proves ownership, and domainRecordValue to the token in it.
+	domainRecordPrefix = "_fastfill-verification."
+	domainRecordValue  = "fastfill-verification="
+
+	// domainCacheTTL bounds how long another instance keeps building links on
+	// a domain after it is changed or removed.
+	domainCacheTTL = time.Minute
+)
+
+var domainLabelPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// DomainService manages the custom domains workspaces compose public links
+// on, in place of the host the API was reached through.
+type DomainService struct {
+	resolver *net.Resolver
+	// reservedHost is the API's own host, which no workspace may claim.
+	reservedHost string
+
+	mu    sync.Mutex
+	cache map[string]cachedDomain
+}
+
+type cachedDomain struct {
+	domain  string
+	expires time.Time
+}
+
+func NewDomainService(baseURL string) *DomainService {
+	reservedHost := ""
+	if parsed, err := url.Parse(baseURL); err == nil {
+		reservedHost = strings.ToLower(parsed.Hostname())
+	}
+	return &DomainService{
+		resolver:     net.DefaultResolver,
+		reservedHost: reservedHost,
+		cache:        make(map[string]cachedDomain),
+	}
+}
+
+// VerificationRecord returns the name and value of the DNS TXT record that
+// proves ownership of the domain.
+func (s *DomainService) VerificationRecord(domain *gormmodels.WorkspaceDomain) (string, string) {
+	return domainRecordPrefix + domain.Domain, domainRecordValue + domain.Token
+}
+
+// Get returns the workspace's custom domain, or nil if it has none.
+func (s *DomainService) Get(workspaceID string) (*gormmodels.WorkspaceDomain, error) {
+	var domain gormmodels.WorkspaceDomain
+
+	err := internal.DB.Where("workspace_id = ?", workspaceID).First(&domain).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch workspace domain", err)
+	}
+
+	return &domain, nil
+}
+
+// Set claims a custom domain for the workspace, replacing any previous one.
+// The domain is unverified, and unused, until Verify finds its TXT record.
+// Setting the domain the workspace already has keeps its token and state.
+func (s *DomainService) Set(workspaceID, name, userID string) (*gormmodels.WorkspaceDomain, error) {
+	name, err := s.normalizeDomain(name)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.Get(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Domain == name {
+		return existing, nil
+	}
+
+	if err := s.checkUnclaimed(workspaceID, name); err != nil {
+		return nil, err
+	}
+
+	token, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	domain := &gormmodels.WorkspaceDomain{
+		WorkspaceID: workspaceID,
+		Domain:      name,
+		Token:       token,
+		CreatedBy:   userID,
+	}
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("workspace_id = ?", workspaceID).Delete(&gormmodels.WorkspaceDomain{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(domain).Error
+	})
+	if err != nil {
+		return nil, storageError("failed to save workspace domain", err)
+	}
+
+	s.forget(workspaceID)
+	return domain, nil
+}
+
+// Verify looks up the domain's TXT record and marks the domain verified when
+// it holds the workspace's token. It returns nil if the workspace has no
+// domain, and a validation error if the record is missing or wrong.
+func (s *DomainService) Verify(ctx context.Context, workspaceID string) (*gormmodels.WorkspaceDomain, error) {
+	domain, err := s.Get(workspaceID)
+	if err != nil || domain == nil {
+		return domain, err
+	}
+
+	if err := s.checkUnclaimed(workspaceID, domain.Domain); err != nil {
+		return nil, err
+	}
+
+	recordName, recordValue := s.VerificationRecord(domain)
+	records, lookupErr := s.resolver.LookupTXT(ctx, recordName)
+
+	found := false
+	for _, record := range records {
+		if strings.TrimSpace(record) == recordValue {
+			found = true
+			break
+		}
+	}
+
+	now := time.Now()
+	domain.CheckedAt = &now
+	if found {
+		domain.VerifiedAt = &now
+	}
+	err = internal.DB.Model(domain).Select("checked_at", "verified_at").Updates(domain).Error
+	if err != nil {
+		return nil, storageError("failed to save workspace domain", err)
+	}
+	s.forget(workspaceID)
+
+	if !found {
+		if lookupErr != nil {
+			return nil, newErrorf(ErrValidation, "TXT record %s could not be read: %v", recordName, lookupErr)
+		}
+		return nil, newErrorf(ErrValidation, "TXT record %s does not contain %q", recordName, recordValue)
+	}
+	return domain, nil
+}
+
+// Remove deletes the workspace's custom domain; links go back to the API host.
+func (s *DomainService) Remove(workspaceID string) error {
+	if err := internal.DB.Where("workspace_id = ?", workspaceID).Delete(&gormmodels.WorkspaceDomain{}).Error; err != nil {
+		return storageError("failed to remove workspace domain", err)
+	}
+	s.forget(workspaceID)
+	return nil
+}
+
+// PublicBaseURL returns the base URL public links of the workspace's
+// templates are built on, or "" when it has no verified domain. Lookups are
+// cached briefly since every template response asks.
+func (s *DomainService) PublicBaseURL(workspaceID string) string {
+	if workspaceID == "" {
+		return ""
+	}
+
+	s.mu.Lock()
+	cached, ok := s.cache[workspaceID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return baseURLFor(cached.domain)
+	}
+
+	var domains []string
+	err := internal.ReadDB().Model(&gormmodels.WorkspaceDomain{}).
+		Where("workspace_id = ? AND verified_at IS NOT NULL", workspaceID).Pluck("domain", &domains).Error
+	if err != nil {
+		// Links fall back to the API host rather than failing the response.
+		return ""
+	}
+
+	domain := ""
+	if len(domains) > 0 {
+		domain = domains[0]
+	}
+
+	s.mu.Lock()
+	s.cache[workspaceID] = cachedDomain{domain: domain, expires: time.Now().Add(domainCacheTTL)}
+	s.mu.Unlock()
+
+	return baseURLFor(domain)
+}
+
+// WorkspaceForHost returns the workspace whose verified domain is host, or
+// "" when host is not a custom domain.
+func (s *DomainService) WorkspaceForHost(host string) (string, error) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "" {
+		return "", nil
+	}
+
+	var workspaceIDs []string
+	err := internal.ReadDB().Model(&gormmodels.WorkspaceDomain{}).
+		Where("domain = ? AND verified_at IS NOT NULL", host).Pluck("workspace_id", &workspaceIDs).Error
+	if err != nil {
+		return "", storageError("failed to fetch workspace domain", err)
+	}
+	if len(workspaceIDs) == 0 {
+		return "", nil
+	}
+	return workspaceIDs[0], nil
+}
+
+// normalizeDomain lower-cases a bare host name and rejects anything else:
+// schemes, ports, paths, IP addresses, single labels and the API's own host.
+func (s *DomainService) normalizeDomain(name string) (string, error) {
+	name = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+	if name == "" {
+		return "", newError(ErrValidation, "domain is required")
+	}
+	if len(name) > 253 {
+		return "", newError(ErrValidation, "domain is too long")
+	}
+	if net.ParseIP(name) != nil {
+		return "", newError(ErrValidation, "domain must be a host name, not an IP address")
+	}
+
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return "", newErrorf(ErrValidation, "%q is not a fully qualified domain", name)
+	}
+	for _, label := range labels {
+		if !domainLabelPattern.MatchString(label) {
+			return "", newErrorf(ErrValidation, "%q is not a valid domain; give the host name only, without scheme, port or path", name)
+		}
+	}
+
+	if name == s.reservedHost {
+		return "", newError(ErrValidation, "the API's own host cannot be used as a custom domain")
+	}
+	return name, nil
+}
+
+// checkUnclaimed rejects a domain another workspace has already verified.
+func (s *DomainService) checkUnclaimed(workspaceID, name string) error {
+	var count int64
+	err := internal.DB.Model(&gormmodels.WorkspaceDomain{}).
+		Where("domain = ? AND workspace_id <> ? AND verified_at IS NOT NULL", name, workspaceID).Count(&count).Error
+	if err != nil {
+		return storageError("failed to fetch workspace domains", err)
+	}
+	if count > 0 {
+		return newErrorf(ErrConflict, "domain %s is already in use by another workspace", name)
+	}
+	return nil
+}
+
+func (s *DomainService) forget(workspaceID string) {
+	s.mu.Lock()
+	delete(s.cache, workspaceID)
+	s.mu.Unlock()
+}
+
+func baseURLFor(domain string) string {
+	if domain == "" {
+		return ""
+	}
+	return "https://" + domain
+}
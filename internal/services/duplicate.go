@@ -0,0 +1,96 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// DuplicateMatch is an earlier submission matched by a template's duplicate
+// policy, together with the action the policy prescribes.
+type DuplicateMatch struct {
+	Submission *gormmodels.FormSubmission
+	Action     string
+}
+
+// FindDuplicate applies the template's duplicate policy to a new submission.
+// It sets the submission's DedupHash and returns the most recent earlier
+// submission with the same key values inside the policy window, or nil.
+// Submissions missing any key value are never considered duplicates.
+func (s *FormService) FindDuplicate(submission *gormmodels.FormSubmission) (*DuplicateMatch, error) {
+	var template gormmodels.Template
+	err := internal.DB.Select("id", "duplicate_policy").Where("id = ?", submission.TemplateID).First(&template).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch duplicate policy", err)
+	}
+
+	policy := template.DuplicatePolicy
+	if len(policy.Keys) == 0 {
+		return nil, nil
+	}
+
+	hash := dedupHash(policy.Keys, submission.FormData)
+	if hash == "" {
+		return nil, nil
+	}
+	submission.DedupHash = hash
+
+	query := internal.DB.Select("id", "template_id", "status", "created_at").
+		Where("template_id = ? AND dedup_hash = ?", template.ID, hash)
+	if policy.WindowDays > 0 {
+		query = query.Where("created_at >= ?", time.Now().AddDate(0, 0, -policy.WindowDays))
+	}
+
+	var earlier gormmodels.FormSubmission
+	if err := query.Order("created_at DESC").First(&earlier).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to check for duplicate submissions", err)
+	}
+
+	action := policy.Action
+	if action == "" {
+		action = gormmodels.DuplicateFlag
+	}
+	return &DuplicateMatch{Submission: &earlier, Action: action}, nil
+}
+
+// dedupHash fingerprints the normalized values of keys, ignoring case,
+// whitespace and dashes so "1-2345-67890-12-3" matches "1234567890123".
+func dedupHash(keys []string, data map[string]interface{}) string {
+	keys = append([]string(nil), keys...)
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, key := range keys {
+		value, ok := utils.LookupDataPath(data, key)
+		if !ok || value == nil {
+			return ""
+		}
+		normalized := strings.Map(func(r rune) rune {
+			switch r {
+			case ' ', '\t', '\n', '\r', '-':
+				return -1
+			}
+			return r
+		}, strings.ToLower(fmt.Sprint(value)))
+		if normalized == "" {
+			return ""
+		}
+		fmt.Fprintf(h, "%s=%s\x00", key, normalized)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrLockHeld is returned when another session holds a live lock.
+	ErrLockHeld = newError(ErrConflict, "template is locked by another editor")
+	// ErrLockNotHeld is returned when renewing or releasing a lock the session does not hold.
+	ErrLockNotHeld = newError(ErrConflict, "template lock is not held by this session")
+)
+
+// LockHolder identifies the editing session asking for a lock.
+type LockHolder struct {
+	SessionID string
+	UserID    string
+	Name      string
+}
+
+// EditLockService manages advisory template editing locks. Locks are not
+// enforced on writes; they let editors see who else is working on a template.
+type EditLockService struct {
+	ttl time.Duration
+}
+
+func NewEditLockService(ttl time.Duration) *EditLockService {
+	if ttl <= 0 {
+		ttl = 2 * time.Minute
+	}
+	return &EditLockService{ttl: ttl}
+}
+
+func (s *EditLockService) TTL() time.Duration {
+	return s.ttl
+}
+
+// Get returns the live lock on a template, or nil if it is unlocked or the lock expired.
+func (s *EditLockService) Get(templateID string) (*gormmodels.TemplateLock, error) {
+	var lock gormmodels.TemplateLock
+
+	err := internal.DB.Where("template_id = ?", templateID).First(&lock).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch template lock", err)
+	}
+
+	if lock.Expired(time.Now()) {
+		return nil, nil
+	}
+	return &lock, nil
+}
+
+// Acquire takes the lock for the holder, or renews it if the holder's session
+// already has it. When another session holds a live lock, Acquire returns that
+// lock with ErrLockHeld unless force is set, in which case the lock is taken over.
+func (s *EditLockService) Acquire(templateID string, holder LockHolder, force bool) (*gormmodels.TemplateLock, error) {
+	var result gormmodels.TemplateLock
+	var held bool
+
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var current gormmodels.TemplateLock
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("template_id = ?", templateID).First(&current).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return storageError("failed to fetch template lock", err)
+		}
+		exists := err == nil
+
+		if exists && !current.Expired(now) && current.SessionID != holder.SessionID && !force {
+			result = current
+			held = true
+			return nil
+		}
+
+		result = gormmodels.TemplateLock{
+			TemplateID: templateID,
+			SessionID:  holder.SessionID,
+			UserID:     holder.UserID,
+			HolderName: holder.Name,
+			AcquiredAt: now,
+			RenewedAt:  now,
+			ExpiresAt:  now.Add(s.ttl),
+		}
+		if exists && !current.Expired(now) && current.SessionID == holder.SessionID {
+			result.AcquiredAt = current.AcquiredAt
+		}
+
+		if exists {
+			err = tx.Save(&result).Error
+		} else {
+			err = tx.Create(&result).Error
+		}
+		if err != nil {
+			return storageError("failed to save template lock", err)
+		}
+		return nil
+	})
+	if err != nil {
+		// A concurrent first acquisition wins the insert; report its lock.
+		if current, getErr := s.Get(templateID); getErr == nil && current != nil && current.SessionID != holder.SessionID {
+			return current, ErrLockHeld
+		}
+		return nil, err
+	}
+
+	if held {
+		return &result, ErrLockHeld
+	}
+	return &result, nil
+}
+
+// Heartbeat extends a lock held by the session. It returns ErrLockNotHeld if
+// the lock expired or was taken over, along with the current lock if any.
+func (s *EditLockService) Heartbeat(templateID, sessionID string) (*gormmodels.TemplateLock, error) {
+	now := time.Now()
+
+	res := internal.DB.Model(&gormmodels.TemplateLock{}).
+		Where("template_id = ? AND session_id = ? AND expires_at > ?", templateID, sessionID, now).
+		Updates(map[string]interface{}{"renewed_at": now, "expires_at": now.Add(s.ttl)})
+	if res.Error != nil {
+		return nil, storageError("failed to renew template lock", res.Error)
+	}
+
+	lock, err := s.Get(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if res.RowsAffected == 0 {
+		return lock, ErrLockNotHeld
+	}
+	return lock, nil
+}
+
+// Release drops the lock if the session holds it. Releasing an unheld lock is a no-op.
+func (s *EditLockService) Release(templateID, sessionID string) error {
+	err := internal.DB.Where("template_id = ? AND session_id = ?", templateID, sessionID).Delete(&gormmodels.TemplateLock{}).Error
+	if err != nil {
+		return storageError("failed to release template lock", err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+)
+
+// MaxEFilingSchemaSize bounds the XSD stored with a template.
+const MaxEFilingSchemaSize = 256 << 10
+
+// buddhistYearToken stands for the Buddhist-era year in e-filing date formats.
+const buddhistYearToken = "BBBB"
+
+var (
+	xmlNamePattern       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+	numberFormatPattern  = regexp.MustCompile(`^0(\.0+)?$`)
+	dateFormatTokenNames = []string{"YYYY", buddhistYearToken, "MM", "DD"}
+)
+
+// EFilingMissing lists the required e-filing elements a submission has no
+// value for.
+type EFilingMissing struct {
+	Elements []string
+}
+
+func (e *EFilingMissing) Error() string {
+	return fmt.Sprintf("submission has no value for %d required e-filing element(s)", len(e.Elements))
+}
+
+// CheckEFilingExport verifies an e-filing mapping against the template's
+// DataKeys: element paths are XML names, DataKeys are fields, formats are
+// date or number patterns and the schema is well-formed XML.
+func CheckEFilingExport(export gormmodels.EFilingExport, dataKeys map[string]bool) error {
+	if export.RootElement == "" {
+		if len(export.Elements) > 0 {
+			return newError(ErrValidation, "rootElement is required")
+		}
+		return nil
+	}
+	if !xmlNamePattern.MatchString(export.RootElement) {
+		return newErrorf(ErrValidation, "rootElement %q is not a valid XML name", export.RootElement)
+	}
+
+	for i, element := range export.Elements {
+		segments := strings.Split(element.Path, "/")
+		for j, segment := range segments {
+			name := segment
+			if j == len(segments)-1 && j > 0 {
+				name = strings.TrimPrefix(segment, "@")
+			}
+			if !xmlNamePattern.MatchString(name) {
+				return newErrorf(ErrValidation, "elements[%d]: path %q is not a slash-separated list of XML names", i, element.Path)
+			}
+		}
+		if element.DataKey != "" && !dataKeys[element.DataKey] {
+			return newErrorf(ErrValidation, "elements[%d]: %s is not a field of the template", i, element.DataKey)
+		}
+		if element.Format != "" && !numberFormatPattern.MatchString(element.Format) && !isDateFormat(element.Format) {
+			return newErrorf(ErrValidation, "elements[%d]: format %q is neither a date format nor a number format", i, element.Format)
+		}
+	}
+
+	if export.Schema != "" {
+		if len(export.Schema) > MaxEFilingSchemaSize {
+			return newErrorf(ErrValidation, "schema is larger than %d KB", MaxEFilingSchemaSize>>10)
+		}
+		decoder := xml.NewDecoder(strings.NewReader(export.Schema))
+		for {
+			if _, err := decoder.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				return newErrorf(ErrValidation, "schema is not well-formed XML: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func isDateFormat(format string) bool {
+	for _, token := range dateFormatTokenNames {
+		if strings.Contains(format, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// efilingNode is an element of an e-filing payload being built.
+type efilingNode struct {
+	name     string
+	attrs    []xml.Attr
+	text     string
+	children []*efilingNode
+}
+
+// child returns the last child element called name, adding one if there is
+// none.
+func (n *efilingNode) child(name string) *efilingNode {
+	for i := len(n.children) - 1; i >= 0; i-- {
+		if n.children[i].name == name {
+			return n.children[i]
+		}
+	}
+	return n.add(name)
+}
+
+func (n *efilingNode) add(name string) *efilingNode {
+	node := &efilingNode{name: name}
+	n.children = append(n.children, node)
+	return node
+}
+
+func (n *efilingNode) encode(encoder *xml.Encoder, namespace string) error {
+	start := xml.StartElement{Name: xml.Name{Local: n.name}, Attr: n.attrs}
+	if namespace != "" {
+		start.Attr = append([]xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: namespace}}, start.Attr...)
+	}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	if n.text != "" {
+		if err := encoder.EncodeToken(xml.CharData(n.text)); err != nil {
+			return err
+		}
+	}
+	for _, child := range n.children {
+		if err := child.encode(encoder, ""); err != nil {
+			return err
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// BuildEFiling writes a submission's form data as the template's e-filing
+// payload. Elements appear in mapping order, and list values repeat their
+// element once per item. An *EFilingMissing error names the required
+// elements without a value.
+func BuildEFiling(export gormmodels.EFilingExport, data map[string]interface{}) ([]byte, error) {
+	if export.RootElement == "" {
+		return nil, newError(ErrValidation, "template has no e-filing export")
+	}
+
+	root := &efilingNode{name: export.RootElement}
+	var missing []string
+	for _, element := range export.Elements {
+		values := efilingValues(element, data)
+		if len(values) == 0 {
+			if element.Required {
+				missing = append(missing, element.Path)
+			}
+			continue
+		}
+
+		segments := strings.Split(element.Path, "/")
+		parent := root
+		last := segments[len(segments)-1]
+		if attr, ok := strings.CutPrefix(last, "@"); ok && len(segments) > 1 {
+			for _, segment := range segments[:len(segments)-1] {
+				parent = parent.child(segment)
+			}
+			parent.attrs = append(parent.attrs, xml.Attr{Name: xml.Name{Local: attr}, Value: values[0]})
+			continue
+		}
+		for _, segment := range segments[:len(segments)-1] {
+			parent = parent.child(segment)
+		}
+		for _, value := range values {
+			parent.add(last).text = value
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &EFilingMissing{Elements: missing}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := root.encode(encoder, export.Namespace); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// efilingValues returns the formatted, non-empty values of an element.
+func efilingValues(element gormmodels.EFilingElement, data map[string]interface{}) []string {
+	if element.DataKey == "" {
+		if element.Value == "" {
+			return nil
+		}
+		return []string{element.Value}
+	}
+
+	value, ok := utils.LookupDataPath(data, element.DataKey)
+	if !ok {
+		value = data[element.DataKey]
+	}
+	items, isList := value.([]interface{})
+	if !isList {
+		items = []interface{}{value}
+	}
+
+	var values []string
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		text := strings.TrimSpace(formatEFilingValue(item, element.Format))
+		if text != "" {
+			values = append(values, text)
+		}
+	}
+	return values
+}
+
+// formatEFilingValue applies a date or number format to a value. Values the
+// format does not apply to are written as they are.
+func formatEFilingValue(value interface{}, format string) string {
+	text := fmt.Sprint(value)
+	if number, ok := value.(float64); ok {
+		text = strconv.FormatFloat(number, 'f', -1, 64)
+	}
+	switch {
+	case format == "":
+		return text
+	case numberFormatPattern.MatchString(format):
+		number, ok := value.(float64)
+		if !ok {
+			var err error
+			if number, err = strconv.ParseFloat(text, 64); err != nil {
+				return text
+			}
+		}
+		decimals := 0
+		if _, fraction, ok := strings.Cut(format, "."); ok {
+			decimals = len(fraction)
+		}
+		return strconv.FormatFloat(number, 'f', decimals, 64)
+	}
+
+	date, err := time.Parse(CanonicalDateLayout, text)
+	if err != nil {
+		return text
+	}
+	parts := strings.Split(format, buddhistYearToken)
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = date.Format(dateFormatTokens.Replace(part))
+		}
+	}
+	return strings.Join(parts, strconv.Itoa(date.Year()+buddhistEraOffset))
+}
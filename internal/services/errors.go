@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/dhanavadh/fastfill-backend/internal/storage"
+
+	"github.com/go-sql-driver/mysql"
+	"gorm.io/gorm"
+)
+
+// Error categories returned by services. Specific errors such as
+// ErrVersionConflict or ErrInvalidLayout belong to one of these, so callers
+// can check either with errors.Is. Handlers map them to HTTP status codes.
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrConflict           = errors.New("conflict")
+	ErrValidation         = errors.New("validation failed")
+	ErrStorageUnavailable = errors.New("storage unavailable")
+)
+
+// mysqlDuplicateEntry is the MySQL error number for unique key violations.
+const mysqlDuplicateEntry = 1062
+
+// serviceError carries a message, an error category and an optional cause.
+type serviceError struct {
+	kind  error
+	msg   string
+	cause error
+}
+
+func (e *serviceError) Error() string {
+	if e.cause == nil {
+		return e.msg
+	}
+	return e.msg + ": " + e.cause.Error()
+}
+
+func (e *serviceError) Unwrap() []error {
+	errs := make([]error, 0, 2)
+	if e.kind != nil {
+		errs = append(errs, e.kind)
+	}
+	if e.cause != nil {
+		errs = append(errs, e.cause)
+	}
+	return errs
+}
+
+// newError returns an error with the given message in the given category.
+func newError(kind error, msg string) error {
+	return &serviceError{kind: kind, msg: msg}
+}
+
+func newErrorf(kind error, format string, args ...interface{}) error {
+	return &serviceError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// storageError wraps a database or bucket error as "msg: cause" and
+// categorizes it: missing records are ErrNotFound, unique key violations are
+// ErrConflict and connection failures are ErrStorageUnavailable. Other causes
+// are left uncategorized.
+func storageError(msg string, err error) error {
+	return &serviceError{kind: storageErrorKind(err), msg: msg, cause: err}
+}
+
+func storageErrorKind(err error) error {
+	var mysqlErr *mysql.MySQLError
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, storage.ErrObjectNotExist):
+		return ErrNotFound
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrConflict
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry:
+		return ErrConflict
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, mysql.ErrInvalidConn), errors.Is(err, sql.ErrConnDone),
+		errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr):
+		return ErrStorageUnavailable
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxEventPage is the most events List returns at once.
+	maxEventPage = 500
+
+	// eventSettleDelay holds back the newest events from List. Sequence
+	// numbers are assigned when an event is written but become visible when
+	// its transaction commits, so a slow transaction could otherwise surface
+	// an event behind a cursor that has already moved past it.
+	eventSettleDelay = 2 * time.Second
+)
+
+// recordEvent writes an event to the outbox within tx, so it is stored if
+// and only if the change it describes is. The event belongs to the workspace
+// owning templateID.
+func recordEvent(tx *gorm.DB, eventType, resourceID, templateID string, data map[string]interface{}) error {
+	var workspaceIDs []string
+	if err := tx.Model(&gormmodels.Template{}).Where("id = ?", templateID).Pluck("workspace_id", &workspaceIDs).Error; err != nil {
+		return err
+	}
+
+	event := &gormmodels.Event{
+		Type:       eventType,
+		ResourceID: resourceID,
+		TemplateID: templateID,
+		Data:       data,
+	}
+	if len(workspaceIDs) > 0 {
+		event.WorkspaceID = workspaceIDs[0]
+	}
+	return tx.Create(event).Error
+}
+
+type EventService struct{}
+
+func NewEventService() *EventService {
+	return &EventService{}
+}
+
+// Record stores an event that is not tied to a database change, such as a
+// PDF being generated. Failures are logged rather than returned so the
+// action itself never fails for it.
+func (s *EventService) Record(eventType, resourceID, templateID string, data map[string]interface{}) {
+	if err := recordEvent(internal.DB, eventType, resourceID, templateID, data); err != nil {
+		log.Printf("Warning: failed to record event %s for %s: %v", eventType, resourceID, err)
+	}
+}
+
+// EventQuery selects the events of a workspace after a cursor.
+type EventQuery struct {
+	WorkspaceID string
+	// Since is the sequence of the last event already processed; 0 starts
+	// from the oldest event.
+	Since uint64
+	Types []string
+	Limit int
+}
+
+// List returns events after query.Since in sequence order. Events younger
+// than eventSettleDelay are held back until the next call.
+func (s *EventService) List(query EventQuery) ([]gormmodels.Event, error) {
+	if query.Limit <= 0 || query.Limit > maxEventPage {
+		query.Limit = maxEventPage
+	}
+
+	db := internal.ReadDB().Where("workspace_id = ? AND sequence > ? AND created_at <= ?",
+		query.WorkspaceID, query.Since, time.Now().Add(-eventSettleDelay))
+	if len(query.Types) > 0 {
+		db = db.Where("type IN ?", query.Types)
+	}
+
+	var events []gormmodels.Event
+	if err := db.Order("sequence ASC").Limit(query.Limit).Find(&events).Error; err != nil {
+		return nil, storageError("failed to fetch events", err)
+	}
+	return events, nil
+}
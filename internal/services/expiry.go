@@ -0,0 +1,220 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+)
+
+// Submission statuses managed by the expiry job.
+const (
+	SubmissionStatusDraft   = "draft"
+	SubmissionStatusExpired = "expired"
+)
+
+// expiryBatchSize bounds how many submissions one run processes per template.
+const expiryBatchSize = 500
+
+// ExpiryService applies per-template expiry policies to stale drafts.
+type ExpiryService struct {
+	httpClient *http.Client
+}
+
+func NewExpiryService() *ExpiryService {
+	return &ExpiryService{httpClient: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// ExpiryResult summarizes one run of the expiry job.
+type ExpiryResult struct {
+	Notified int
+	Expired  int
+	Purged   int
+}
+
+// ExpiryNotice is posted to a template's NotifyURL before drafts expire.
+type ExpiryNotice struct {
+	Event       string          `json:"event"`
+	TemplateID  string          `json:"templateId"`
+	Submissions []ExpiringDraft `json:"submissions"`
+}
+
+type ExpiringDraft struct {
+	ID        string    `json:"id"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Run warns about drafts nearing expiry and expires stale drafts for every
+// template with an expiry policy. A failing template is logged and skipped.
+func (s *ExpiryService) Run(ctx context.Context) (ExpiryResult, error) {
+	var result ExpiryResult
+
+	var templates []gormmodels.Template
+	if err := internal.DB.Select("id", "expiry_policy").Find(&templates).Error; err != nil {
+		return result, storageError("failed to fetch expiry policies", err)
+	}
+
+	now := time.Now()
+	for _, template := range templates {
+		policy := template.ExpiryPolicy
+		if policy.DraftTTLDays <= 0 {
+			continue
+		}
+
+		if policy.WarnDays > 0 && policy.NotifyURL != "" {
+			notified, err := s.notify(ctx, template.ID, policy, now)
+			if err != nil {
+				log.Printf("Warning: expiry notice for template %s failed: %v", template.ID, err)
+			}
+			result.Notified += notified
+		}
+
+		expired, err := s.expire(template.ID, policy, now)
+		if err != nil {
+			log.Printf("Warning: expiring drafts of template %s failed: %v", template.ID, err)
+			continue
+		}
+		result.Expired += expired
+		if policy.PurgeData {
+			result.Purged += expired
+		}
+	}
+
+	return result, nil
+}
+
+// notify posts drafts entering the warning period to the policy's NotifyURL
+// and marks them notified. Drafts edited after a notice are notified again.
+func (s *ExpiryService) notify(ctx context.Context, templateID string, policy gormmodels.ExpiryPolicy, now time.Time) (int, error) {
+	ttl := time.Duration(policy.DraftTTLDays) * 24 * time.Hour
+	warnFrom := now.Add(-ttl + time.Duration(policy.WarnDays)*24*time.Hour)
+
+	var drafts []gormmodels.FormSubmission
+	err := internal.DB.Select("id", "updated_at").
+		Where("template_id = ? AND status = ? AND updated_at <= ? AND updated_at > ? AND legal_hold_at IS NULL", templateID, SubmissionStatusDraft, warnFrom, now.Add(-ttl)).
+		Where("expiry_notified_at IS NULL OR expiry_notified_at < updated_at").
+		Limit(expiryBatchSize).Find(&drafts).Error
+	if err != nil {
+		return 0, storageError("failed to fetch expiring drafts", err)
+	}
+	if len(drafts) == 0 {
+		return 0, nil
+	}
+
+	notice := ExpiryNotice{Event: "submissions.expiring", TemplateID: templateID}
+	ids := make([]string, len(drafts))
+	for i, draft := range drafts {
+		ids[i] = draft.ID
+		notice.Submissions = append(notice.Submissions, ExpiringDraft{
+			ID:        draft.ID,
+			UpdatedAt: draft.UpdatedAt,
+			ExpiresAt: draft.UpdatedAt.Add(ttl),
+		})
+	}
+
+	body, err := json.Marshal(notice)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, policy.NotifyURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to build expiry notice: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-FastFill-Event", notice.Event)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send expiry notice: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("expiry notice rejected with status %d", resp.StatusCode)
+	}
+
+	// UpdateColumn leaves updated_at alone so the draft's age is unchanged.
+	err = internal.DB.Model(&gormmodels.FormSubmission{}).Where("id IN ?", ids).UpdateColumn("expiry_notified_at", now).Error
+	if err != nil {
+		return 0, storageError("failed to mark drafts notified", err)
+	}
+	return len(drafts), nil
+}
+
+// expire moves drafts older than the policy's TTL to the expired status,
+// clearing their data when the policy purges. Drafts under legal hold are
+// left alone until the hold is released.
+func (s *ExpiryService) expire(templateID string, policy gormmodels.ExpiryPolicy, now time.Time) (int, error) {
+	cutoff := now.AddDate(0, 0, -policy.DraftTTLDays)
+
+	var ids []string
+	err := internal.DB.Model(&gormmodels.FormSubmission{}).
+		Where("template_id = ? AND status = ? AND updated_at <= ? AND legal_hold_at IS NULL", templateID, SubmissionStatusDraft, cutoff).
+		Where("NOT EXISTS (SELECT 1 FROM generated_documents WHERE generated_documents.submission_id = form_submissions.id AND generated_documents.legal_hold_at IS NOT NULL)").
+		Limit(expiryBatchSize).Pluck("id", &ids).Error
+	if err != nil {
+		return 0, storageError("failed to fetch stale drafts", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	updates := map[string]interface{}{
+		"status":     SubmissionStatusExpired,
+		"expired_at": now,
+	}
+	if policy.PurgeData {
+		updates["form_data"] = gorm.Expr("NULL")
+		updates["formatting_data"] = gorm.Expr("NULL")
+		updates["html_data"] = gorm.Expr("NULL")
+		updates["raw_data"] = gorm.Expr("NULL")
+	}
+
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&gormmodels.FormSubmission{}).Where("id IN ?", ids).Updates(updates).Error; err != nil {
+			return err
+		}
+		if !policy.PurgeData {
+			return nil
+		}
+		// Purged data must not survive in the revision history.
+		return tx.Where("submission_id IN ?", ids).Delete(&gormmodels.SubmissionRevision{}).Error
+	})
+	if err != nil {
+		return 0, storageError("failed to expire drafts", err)
+	}
+	return len(ids), nil
+}
+
+// StartScheduler runs Run periodically until ctx is cancelled.
+func (s *ExpiryService) StartScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				result, err := s.Run(ctx)
+				if err != nil {
+					log.Printf("Warning: submission expiry failed: %v", err)
+				} else if result.Notified+result.Expired > 0 {
+					log.Printf("Submission expiry: %d notified, %d expired, %d purged", result.Notified, result.Expired, result.Purged)
+				}
+			}
+		}
+	}()
+}
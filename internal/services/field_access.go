@@ -0,0 +1,108 @@
+package services
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+)
+
+// RestrictedValue replaces the values of fields the viewer's role may not see.
+const RestrictedValue = "[restricted]"
+
+// FieldAccessService decides which field values a role may see.
+type FieldAccessService struct{}
+
+func NewFieldAccessService() *FieldAccessService {
+	return &FieldAccessService{}
+}
+
+// RestrictedKeys returns the DataKeys of the template's fields whose view
+// roles do not include role. Requests without a role, such as those made
+// with personal access tokens, see no restricted field.
+func (s *FieldAccessService) RestrictedKeys(templateID, role string) (map[string]bool, error) {
+	var fields []gormmodels.Field
+	err := internal.ReadDB().Select("data_key", "view_roles").Where("template_id = ?", templateID).Find(&fields).Error
+	if err != nil {
+		return nil, storageError("failed to fetch template fields", err)
+	}
+
+	keys := make(map[string]bool)
+	for _, field := range fields {
+		if len(field.ViewRoles) > 0 && !slices.Contains(field.ViewRoles, role) {
+			keys[field.DataKey] = true
+		}
+	}
+	return keys, nil
+}
+
+// MaskSubmission replaces the restricted values of a submission, in its form
+// data and in the raw input and rich text kept alongside, with
+// RestrictedValue. Values the submission does not have are left absent.
+func MaskSubmission(submission *gormmodels.FormSubmission, keys map[string]bool) {
+	for key := range keys {
+		for _, data := range []map[string]interface{}{submission.FormData, submission.RawData, submission.HtmlData} {
+			maskValue(data, key)
+		}
+	}
+}
+
+func maskValue(data map[string]interface{}, key string) {
+	if data == nil {
+		return
+	}
+	if _, ok := data[key]; ok || !utils.IsDataPath(key) {
+		if ok {
+			data[key] = RestrictedValue
+		}
+		return
+	}
+	if _, ok := utils.LookupDataPath(data, key); ok {
+		utils.SetDataPath(data, key, RestrictedValue)
+	}
+}
+
+// KeepRestricted carries the stored restricted values of a submission over
+// into updated form data, so a user who cannot see a field cannot change it
+// either, nor overwrite it with the masked value they were shown.
+func KeepRestricted(formData, stored map[string]interface{}, keys map[string]bool) map[string]interface{} {
+	if len(keys) > 0 && formData == nil {
+		formData = make(map[string]interface{})
+	}
+	for key := range keys {
+		if value, ok := stored[key]; ok || !utils.IsDataPath(key) {
+			if ok {
+				formData[key] = value
+			} else {
+				delete(formData, key)
+			}
+			continue
+		}
+		if value, ok := utils.LookupDataPath(stored, key); ok {
+			utils.SetDataPath(formData, key, value)
+		} else {
+			utils.DeleteDataPath(formData, key)
+		}
+	}
+	return formData
+}
+
+// MaskChanges hides the before and after values of revision changes under a
+// restricted DataKey.
+func MaskChanges(changes []FieldChange, keys map[string]bool) {
+	for i, change := range changes {
+		for key := range keys {
+			if change.Key == key || strings.HasPrefix(change.Key, key+".") || strings.HasPrefix(change.Key, key+"[") {
+				if change.Before != nil {
+					changes[i].Before = RestrictedValue
+				}
+				if change.After != nil {
+					changes[i].After = RestrictedValue
+				}
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+)
+
+// FieldBulkUpdate sets the same formatting or size on a selection of a
+// template's fields: those bound to one of DataKeys, every field on page
+// PageIndex, or the fields of DataKeys on that page when both are given.
+// Settings left nil are kept.
+type FieldBulkUpdate struct {
+	DataKeys  []string `json:"dataKeys" binding:"max=500"`
+	PageIndex *int     `json:"pageIndex" binding:"omitempty,min=0"`
+
+	FontSize       *int     `json:"fontSize" binding:"omitempty,min=4,max=96"`
+	FontFamily     *string  `json:"fontFamily" binding:"omitempty,max=100"`
+	FontWeight     *string  `json:"fontWeight" binding:"omitempty,max=16"`
+	FontStyle      *string  `json:"fontStyle" binding:"omitempty,max=16"`
+	TextDecoration *string  `json:"textDecoration" binding:"omitempty,max=32"`
+	TextColor      *string  `json:"textColor" binding:"omitempty,max=32"`
+	Opacity        *float64 `json:"opacity" binding:"omitempty,min=0,max=1"`
+	Width          *int     `json:"width" binding:"omitempty,min=1"`
+	Height         *int     `json:"height" binding:"omitempty,min=1"`
+}
+
+// FieldBulkResult lists the fields a bulk update changed, with their new
+// layout.
+type FieldBulkResult struct {
+	Updated int           `json:"updated"`
+	Fields  []FieldLayout `json:"fields"`
+}
+
+// columns returns the field columns the update sets.
+func (u *FieldBulkUpdate) columns() []string {
+	var columns []string
+	for column, set := range map[string]bool{
+		"font_size":       u.FontSize != nil,
+		"font_family":     u.FontFamily != nil,
+		"font_weight":     u.FontWeight != nil,
+		"font_style":      u.FontStyle != nil,
+		"text_decoration": u.TextDecoration != nil,
+		"text_color":      u.TextColor != nil,
+		"opacity":         u.Opacity != nil,
+		"position_width":  u.Width != nil,
+		"position_height": u.Height != nil,
+	} {
+		if set {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+func (u *FieldBulkUpdate) apply(f *gormmodels.Field) {
+	if u.FontSize != nil {
+		f.FontSize = *u.FontSize
+	}
+	if u.FontFamily != nil {
+		f.FontFamily = strings.TrimSpace(*u.FontFamily)
+	}
+	if u.FontWeight != nil {
+		f.FontWeight = strings.TrimSpace(*u.FontWeight)
+	}
+	if u.FontStyle != nil {
+		f.FontStyle = strings.TrimSpace(*u.FontStyle)
+	}
+	if u.TextDecoration != nil {
+		f.TextDecoration = strings.TrimSpace(*u.TextDecoration)
+	}
+	if u.TextColor != nil {
+		f.TextColor = strings.TrimSpace(*u.TextColor)
+	}
+	if u.Opacity != nil {
+		f.Opacity = *u.Opacity
+	}
+	if u.Width != nil {
+		f.PositionWidth = *u.Width
+	}
+	if u.Height != nil {
+		f.PositionHeight = *u.Height
+	}
+}
+
+// BulkUpdateFields applies a bulk update to the selected fields of a
+// template in one transaction and bumps the template version. Unknown
+// DataKeys and an empty selection are rejected rather than ignored, so a
+// typo cannot silently restyle nothing.
+func (s *TemplateService) BulkUpdateFields(template *gormmodels.Template, update *FieldBulkUpdate) (*FieldBulkResult, error) {
+	if len(update.DataKeys) == 0 && update.PageIndex == nil {
+		return nil, newError(ErrValidation, "select fields with dataKeys or pageIndex")
+	}
+	columns := update.columns()
+	if len(columns) == 0 {
+		return nil, newError(ErrValidation, "nothing to update")
+	}
+
+	known := make(map[string]bool, len(template.Fields))
+	for _, f := range template.Fields {
+		known[f.DataKey] = true
+	}
+	selected := make(map[string]bool, len(update.DataKeys))
+	for _, key := range update.DataKeys {
+		if !known[key] {
+			return nil, newErrorf(ErrValidation, "%s is not a field of the template", key)
+		}
+		selected[key] = true
+	}
+
+	result := &FieldBulkResult{Fields: []FieldLayout{}}
+	var updates []gormmodels.Field
+	occurrences := make(map[string]int)
+	for _, f := range orderedFields(template.Fields) {
+		occurrence := occurrences[f.DataKey]
+		occurrences[f.DataKey]++
+		if update.PageIndex != nil && f.PageIndex != *update.PageIndex {
+			continue
+		}
+		if len(selected) > 0 && !selected[f.DataKey] {
+			continue
+		}
+
+		update.apply(&f)
+		updates = append(updates, f)
+		result.Fields = append(result.Fields, fieldLayout(f, occurrence))
+	}
+	if len(updates) == 0 {
+		return nil, newError(ErrValidation, "no fields match the selection")
+	}
+
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range updates {
+			if err := tx.Model(&updates[i]).Select(columns).Updates(&updates[i]).Error; err != nil {
+				return err
+			}
+		}
+		if _, err := bumpVersion(tx, template.ID, 0); err != nil {
+			return err
+		}
+		return tx.Model(&gormmodels.Template{}).Where("id = ?", template.ID).Update("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, storageError("failed to update fields", err)
+	}
+
+	result.Updated = len(updates)
+	return result, nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// Field types. Number and date values are normalized on submit; a checkbox
+// ticks its box when its value is true, and checkbox groups tick printed boxes
+// instead of writing their value as text; signature fields render the stored
+// signature whose ID is their value and stamp fields a workspace stamp, fixed
+// by the template or chosen by ID in formData. Image fields draw a data URI,
+// QR code fields encode their value and table fields lay out rows of cells.
+// Templates with any other type are rejected.
+const (
+	FieldTypeText          = "text"
+	FieldTypeNumber        = "number"
+	FieldTypeDate          = "date"
+	FieldTypeCheckbox      = "checkbox"
+	FieldTypeCheckboxGroup = "checkboxGroup"
+	FieldTypeSignature     = "signature"
+	FieldTypeStamp         = "stamp"
+	FieldTypeImage         = "image"
+	FieldTypeQRCode        = "qrcode"
+	FieldTypeTable         = "table"
+)
+
+// Edge kinds reported in a FieldGraph.
+const (
+	// EdgeSharedValue links fields bound to the same DataKey; they always render the same value.
+	EdgeSharedValue = "sharedValue"
+	// EdgeAddressGroup links address components on the same page.
+	EdgeAddressGroup = "addressGroup"
+)
+
+// FieldGraph is a machine-readable description of how a template's fields
+// relate to each other and which rules the server applies to their values.
+type FieldGraph struct {
+	TemplateID string      `json:"templateId"`
+	Nodes      []FieldNode `json:"nodes"`
+	Edges      []FieldEdge `json:"edges"`
+}
+
+type FieldNode struct {
+	ID               string           `json:"id"`
+	DataKey          string           `json:"dataKey"`
+	Name             string           `json:"name"`
+	Type             string           `json:"type"`
+	PageIndex        int              `json:"pageIndex"`
+	SourceTemplateID string           `json:"sourceTemplateId"`
+	Validations      []FieldValidator `json:"validations,omitempty"`
+}
+
+// FieldValidator describes a rule on a field's value. EnforcedByServer is
+// false for rules the server only reports to the form builder.
+type FieldValidator struct {
+	Rule             string   `json:"rule"`
+	OptionListID     string   `json:"optionListId,omitempty"`
+	Options          []string `json:"options,omitempty"`
+	EnforcedByServer bool     `json:"enforcedByServer"`
+}
+
+type FieldEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// BuildFieldGraph describes the fields of a resolved template. Option lists
+// are used to report the current allowed values of list-backed fields.
+func BuildFieldGraph(template *gormmodels.Template, optionLists map[string]gormmodels.OptionList) FieldGraph {
+	graph := FieldGraph{
+		TemplateID: template.ID,
+		Nodes:      make([]FieldNode, 0, len(template.Fields)),
+		Edges:      []FieldEdge{},
+	}
+
+	byDataKey := make(map[string][]string)
+	addressByPage := make(map[int][]string)
+
+	for i, field := range template.Fields {
+		node := FieldNode{
+			ID:               fieldNodeID(i, field),
+			DataKey:          field.DataKey,
+			Name:             field.Name,
+			Type:             field.Type,
+			PageIndex:        field.PageIndex,
+			SourceTemplateID: field.TemplateID,
+		}
+
+		if field.Required {
+			node.Validations = append(node.Validations, FieldValidator{Rule: "required"})
+		}
+		if field.OptionListID != "" {
+			validator := FieldValidator{Rule: "oneOf", OptionListID: field.OptionListID, EnforcedByServer: true}
+			if list, ok := optionLists[field.OptionListID]; ok {
+				validator.Options = list.Options
+			}
+			node.Validations = append(node.Validations, validator)
+		} else if field.Options != "" {
+			var options []string
+			if err := json.Unmarshal([]byte(field.Options), &options); err == nil && len(options) > 0 {
+				node.Validations = append(node.Validations, FieldValidator{Rule: "oneOf", Options: options})
+			}
+		} else if field.Type == FieldTypeCheckboxGroup && len(field.Boxes) > 0 {
+			node.Validations = append(node.Validations, FieldValidator{Rule: "oneOf", Options: boxValues(field)})
+		}
+
+		graph.Nodes = append(graph.Nodes, node)
+		byDataKey[field.DataKey] = append(byDataKey[field.DataKey], node.ID)
+		if field.IsAddressComponent {
+			addressByPage[field.PageIndex] = append(addressByPage[field.PageIndex], node.ID)
+		}
+	}
+
+	keys := make([]string, 0, len(byDataKey))
+	for key := range byDataKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		graph.Edges = append(graph.Edges, chainEdges(byDataKey[key], EdgeSharedValue)...)
+	}
+
+	pages := make([]int, 0, len(addressByPage))
+	for page := range addressByPage {
+		pages = append(pages, page)
+	}
+	sort.Ints(pages)
+	for _, page := range pages {
+		graph.Edges = append(graph.Edges, chainEdges(addressByPage[page], EdgeAddressGroup)...)
+	}
+
+	return graph
+}
+
+// chainEdges links the first node of a group to every other member.
+func chainEdges(ids []string, kind string) []FieldEdge {
+	var edges []FieldEdge
+	for _, id := range ids[1:] {
+		edges = append(edges, FieldEdge{From: ids[0], To: id, Kind: kind})
+	}
+	return edges
+}
+
+// fieldNodeID identifies a field in the graph. Stored fields use their ID;
+// fields without one fall back to their position in the template.
+func fieldNodeID(index int, field gormmodels.Field) string {
+	if field.ID != 0 {
+		return "field:" + strconv.FormatUint(uint64(field.ID), 10)
+	}
+	return "index:" + strconv.Itoa(index)
+}
+
+// boxValues lists the values of a checkbox group's boxes.
+func boxValues(field gormmodels.Field) []string {
+	values := make([]string, len(field.Boxes))
+	for i, box := range field.Boxes {
+		values[i] = box.Value
+	}
+	return values
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+type FillLinkService struct{}
+
+func NewFillLinkService() *FillLinkService {
+	return &FillLinkService{}
+}
+
+// Create stores a fill link with a new token. Locked values must be keyed by
+// DataKeys of the template's fields.
+func (s *FillLinkService) Create(link *gormmodels.FillLink) error {
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(time.Now()) {
+		return newError(ErrValidation, "expiresAt must be in the future")
+	}
+
+	if len(link.LockedValues) > 0 {
+		var dataKeys []string
+		err := internal.DB.Model(&gormmodels.Field{}).Where("template_id = ?", link.TemplateID).Distinct().Pluck("data_key", &dataKeys).Error
+		if err != nil {
+			return storageError("failed to fetch template fields", err)
+		}
+		known := make(map[string]bool, len(dataKeys))
+		for _, key := range dataKeys {
+			known[key] = true
+		}
+		for key := range link.LockedValues {
+			if !known[key] {
+				return newErrorf(ErrValidation, "lockedValues: %q is not a field of the template", key)
+			}
+		}
+	}
+
+	token, err := randomHex(16)
+	if err != nil {
+		return err
+	}
+	link.Token = token
+
+	if err := internal.DB.Create(link).Error; err != nil {
+		return storageError("failed to create fill link", err)
+	}
+	return nil
+}
+
+// GetByToken returns the link with the token, or nil if there is none or it
+// has expired.
+func (s *FillLinkService) GetByToken(token string) (*gormmodels.FillLink, error) {
+	var link gormmodels.FillLink
+
+	err := internal.DB.Where("token = ?", token).First(&link).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch fill link", err)
+	}
+
+	if link.Expired(time.Now()) {
+		return nil, nil
+	}
+	return &link, nil
+}
+
+func (s *FillLinkService) GetByTemplateID(templateID string) ([]gormmodels.FillLink, error) {
+	var links []gormmodels.FillLink
+
+	err := internal.DB.Where("template_id = ?", templateID).Order("created_at DESC").Find(&links).Error
+	if err != nil {
+		return nil, storageError("failed to fetch fill links", err)
+	}
+
+	return links, nil
+}
+
+// Delete revokes a fill link of the template.
+func (s *FillLinkService) Delete(templateID string, id uint) error {
+	result := internal.DB.Where("id = ? AND template_id = ?", id, templateID).Delete(&gormmodels.FillLink{})
+	if result.Error != nil {
+		return storageError("failed to delete fill link", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return newError(ErrNotFound, "fill link not found")
+	}
+	return nil
+}
+
+// ApplyLockedValues writes the link's locked values into formData. It
+// returns, sorted, the locked DataKeys the submitter sent a different value
+// for; formData is only changed when there are none. Omitted and null
+// values are fine.
+func (s *FillLinkService) ApplyLockedValues(link *gormmodels.FillLink, formData map[string]interface{}) ([]string, error) {
+	var changed []string
+	for key, locked := range link.LockedValues {
+		submitted, ok := utils.LookupDataPath(formData, key)
+		if !ok {
+			submitted, ok = formData[key]
+		}
+		if ok && submitted != nil && !sameValue(submitted, locked) {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return changed, nil
+	}
+
+	for key, locked := range link.LockedValues {
+		if !utils.IsDataPath(key) {
+			formData[key] = locked
+			continue
+		}
+		if err := utils.SetDataPath(formData, key, locked); err != nil {
+			return nil, newErrorf(ErrValidation, "lockedValues: %v", err)
+		}
+	}
+	return nil, nil
+}
+
+// sameValue compares submitted and locked values, treating a number and its
+// text, as form inputs often send it, as equal.
+func sameValue(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b) || fmt.Sprint(a) == fmt.Sprint(b)
+}
@@ -1,10 +1,9 @@
 package services
 
 import (
-	"fmt"
-
 	"github.com/dhanavadh/fastfill-backend/internal"
 	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
 
 	"gorm.io/gorm"
 )
@@ -15,10 +14,32 @@ func NewFormService() *FormService {
 	return &FormService{}
 }
 
+// Create stores a new submission. Archived templates take no new submissions.
 func (s *FormService) Create(submission *gormmodels.FormSubmission) error {
-	err := internal.DB.Create(submission).Error
+	var archived int64
+	err := internal.DB.Model(&gormmodels.Template{}).Where("id = ? AND archived_at IS NOT NULL", submission.TemplateID).Count(&archived).Error
+	if err != nil {
+		return storageError("failed to fetch template", err)
+	}
+	if archived > 0 {
+		return ErrTemplateArchived
+	}
+
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(submission).Error; err != nil {
+			return err
+		}
+		editedBy := ""
+		if submission.Metadata != nil {
+			editedBy = submission.Metadata.UserID
+		}
+		if err := recordRevision(tx, submission, editedBy); err != nil {
+			return err
+		}
+		return recordEvent(tx, gormmodels.EventFormSubmitted, submission.ID, submission.TemplateID, nil)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create form submission: %w", err)
+		return storageError("failed to create form submission", err)
 	}
 	return nil
 }
@@ -31,35 +52,194 @@ func (s *FormService) GetByID(id string) (*gormmodels.FormSubmission, error) {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to fetch form submission: %w", err)
+		return nil, storageError("failed to fetch form submission", err)
 	}
 
 	return &submission, nil
 }
 
-func (s *FormService) GetByTemplateID(templateID string) ([]gormmodels.FormSubmission, error) {
+// FindByIDs returns the submissions of a template among ids that the
+// workspace may see, in no particular order. IDs of other templates'
+// submissions are left out.
+func (s *FormService) FindByIDs(templateID string, ids []string, workspaceID string) ([]gormmodels.FormSubmission, error) {
 	var submissions []gormmodels.FormSubmission
 
-	err := internal.DB.Where("template_id = ?", templateID).Order("created_at DESC").Find(&submissions).Error
+	query := internal.DB.Where("template_id = ? AND id IN ?", templateID, ids)
+	err := submissionsVisibleTo(query, workspaceID).Find(&submissions).Error
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch form submissions: %w", err)
+		return nil, storageError("failed to fetch form submissions", err)
 	}
 
 	return submissions, nil
 }
 
-func (s *FormService) Update(submission *gormmodels.FormSubmission) error {
-	err := internal.DB.Model(submission).Updates(submission).Error
+// GetByTemplateID lists a template's submissions, newest first. With
+// dataKeys, formData only holds those keys; see SubmissionFilter.Fields.
+func (s *FormService) GetByTemplateID(templateID string, dataKeys []string) ([]gormmodels.FormSubmission, error) {
+	var submissions []gormmodels.FormSubmission
+
+	query := internal.ReadDB().Where("template_id = ?", templateID)
+	if len(dataKeys) > 0 {
+		var err error
+		if query, err = projectFormData(query, dataKeys); err != nil {
+			return nil, err
+		}
+	}
+
+	err := query.Order("created_at DESC").Find(&submissions).Error
 	if err != nil {
-		return fmt.Errorf("failed to update form submission: %w", err)
+		return nil, storageError("failed to fetch form submissions", err)
+	}
+
+	if len(dataKeys) > 0 {
+		dropMissingValues(submissions)
+	}
+	return submissions, nil
+}
+
+// SubmissionFilter narrows submission listings. Empty fields match everything.
+// Fields projects formData down to the given DataKeys; formattingData,
+// htmlData and rawData are then left out.
+type SubmissionFilter struct {
+	TemplateID string
+	Status     string
+	Limit      int
+	Offset     int
+	Fields     []string
+	// VisibleTo, if set, limits the submissions to those the workspace may
+	// see; see submissionsVisibleTo.
+	VisibleTo *string
+}
+
+// submissionsVisibleTo limits a submission query to the submissions of the
+// workspace's own templates and those the workspace made itself.
+func submissionsVisibleTo(query *gorm.DB, workspaceID string) *gorm.DB {
+	return query.Where("form_submissions.template_id IN (?) OR (form_submissions.workspace_id = ? AND form_submissions.workspace_id <> '')",
+		internal.ReadDB().Model(&gormmodels.Template{}).Select("id").
+			Where("workspace_id = '' OR workspace_id IS NULL OR workspace_id = ?", workspaceID),
+		workspaceID)
+}
+
+// Find returns a page of submissions matching the filter, newest first, and
+// the total number of matches.
+func (s *FormService) Find(filter SubmissionFilter) ([]gormmodels.FormSubmission, int64, error) {
+	query := internal.ReadDB().Model(&gormmodels.FormSubmission{})
+	if filter.TemplateID != "" {
+		query = query.Where("template_id = ?", filter.TemplateID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.VisibleTo != nil {
+		query = submissionsVisibleTo(query, *filter.VisibleTo)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, storageError("failed to count form submissions", err)
+	}
+
+	if len(filter.Fields) > 0 {
+		var err error
+		if query, err = projectFormData(query, filter.Fields); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	var submissions []gormmodels.FormSubmission
+	err := query.Order("created_at DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&submissions).Error
+	if err != nil {
+		return nil, 0, storageError("failed to fetch form submissions", err)
+	}
+
+	if len(filter.Fields) > 0 {
+		dropMissingValues(submissions)
+	}
+	return submissions, total, nil
+}
+
+// Update saves a submission and records its data as a new revision, made by
+// editedBy.
+func (s *FormService) Update(submission *gormmodels.FormSubmission, editedBy string) error {
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := recordRevision(tx, submission, editedBy); err != nil {
+			return err
+		}
+		return tx.Model(submission).Updates(submission).Error
+	})
+	if err != nil {
+		return storageError("failed to update form submission", err)
 	}
 	return nil
 }
 
+// Delete removes a submission unless it or one of its documents is under
+// legal hold.
 func (s *FormService) Delete(id string) error {
-	err := internal.DB.Where("id = ?", id).Delete(&gormmodels.FormSubmission{}).Error
+	held, err := submissionHeld(id)
+	if err != nil {
+		return err
+	}
+	if held {
+		return ErrLegalHold
+	}
+
+	// Load the template ID so the delete hook can update the template's counters.
+	var submission gormmodels.FormSubmission
+	if err := internal.DB.Select("id", "template_id").Where("id = ?", id).First(&submission).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return newError(ErrNotFound, "form submission not found")
+		}
+		return storageError("failed to fetch form submission", err)
+	}
+
+	var deleted int64
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&submission)
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		if err := tx.Where("submission_id = ?", id).Delete(&gormmodels.Assignment{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("submission_id = ?", id).Delete(&gormmodels.SubmissionRevision{}).Error
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete form submission: %w", err)
+		return storageError("failed to delete form submission", err)
+	}
+	if deleted == 0 {
+		return newError(ErrNotFound, "form submission not found")
 	}
 	return nil
 }
+
+// CountByDataKeys reports, for each key, how many submissions of the template
+// carry a non-empty value under it in formData.
+func (s *FormService) CountByDataKeys(templateID string, keys []string) (map[string]int, error) {
+	counts := make(map[string]int, len(keys))
+	for _, key := range keys {
+		counts[key] = 0
+	}
+	if len(keys) == 0 {
+		return counts, nil
+	}
+
+	var batch []gormmodels.FormSubmission
+	err := internal.ReadDB().Select("id", "form_data").Where("template_id = ?", templateID).
+		FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+			for _, submission := range batch {
+				for _, key := range keys {
+					if value, ok := utils.LookupDataPath(submission.FormData, key); ok && value != nil && value != "" {
+						counts[key]++
+					}
+				}
+			}
+			return nil
+		}).Error
+	if err != nil {
+		return nil, storageError("failed to count submission data", err)
+	}
+
+	return counts, nil
+}
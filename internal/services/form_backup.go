@@ -0,0 +1,71 @@
+package services
+
+import (
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+)
+
+// exportBatchSize is how many submissions Export reads per query.
+const exportBatchSize = 200
+
+// ErrSubmissionExists is returned by Restore for a submission ID that is
+// already taken.
+var ErrSubmissionExists = newError(ErrConflict, "submission already exists")
+
+// Export reads every submission of a template in batches, oldest first, and
+// passes each batch to fn. It stops at the first error fn returns.
+func (s *FormService) Export(templateID string, fn func([]gormmodels.FormSubmission) error) error {
+	var last *gormmodels.FormSubmission
+	for {
+		query := internal.ReadDB().Where("template_id = ?", templateID)
+		if last != nil {
+			query = query.Where("created_at > ? OR (created_at = ? AND id > ?)", last.CreatedAt, last.CreatedAt, last.ID)
+		}
+
+		var batch []gormmodels.FormSubmission
+		if err := query.Order("created_at ASC, id ASC").Limit(exportBatchSize).Find(&batch).Error; err != nil {
+			return storageError("failed to export form submissions", err)
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		last = &batch[len(batch)-1]
+		if err := fn(batch); err != nil {
+			return err
+		}
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// Restore stores a submission read from an export as it was: its ID, status,
+// metadata and timestamps are kept and no submission checks run, so backups
+// restore even into archived templates. The duplicate fingerprint is
+// recomputed under the template's current policy. IDs that already exist are
+// rejected with ErrSubmissionExists.
+func (s *FormService) Restore(submission *gormmodels.FormSubmission) error {
+	if _, err := s.FindDuplicate(submission); err != nil {
+		return err
+	}
+
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		var existing int64
+		if err := tx.Model(&gormmodels.FormSubmission{}).Where("id = ?", submission.ID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrSubmissionExists
+		}
+		return tx.Create(submission).Error
+	})
+	if err == ErrSubmissionExists {
+		return err
+	}
+	if err != nil {
+		return storageError("failed to restore form submission", err)
+	}
+	return nil
+}
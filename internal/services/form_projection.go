@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// MaxProjectedFields bounds the DataKeys a submission listing may project.
+const MaxProjectedFields = 50
+
+// projectedColumns are the submission columns returned alongside projected formData.
+const projectedColumns = "id, template_id, status, duplicate_of, expiry_notified_at, expired_at, created_at, updated_at"
+
+// projectFormData makes query read only the given DataKeys out of form_data.
+// MySQL builds the reduced object, so full formData never leaves the database.
+// Nested DataKeys are looked up as a flat key first, as in LookupDataPath.
+func projectFormData(query *gorm.DB, dataKeys []string) (*gorm.DB, error) {
+	if len(dataKeys) > MaxProjectedFields {
+		return nil, newErrorf(ErrValidation, "at most %d fields can be selected", MaxProjectedFields)
+	}
+
+	var pairs []string
+	var args []interface{}
+	for _, dataKey := range dataKeys {
+		if dataKey == "" {
+			return nil, newError(ErrValidation, "selected fields must not be empty")
+		}
+
+		extract, extractArgs, err := formDataExtract(dataKey)
+		if err != nil {
+			return nil, err
+		}
+		args = append(append(args, dataKey), extractArgs...)
+		pairs = append(pairs, "?, "+extract)
+	}
+
+	return query.Select(projectedColumns+", JSON_OBJECT("+strings.Join(pairs, ", ")+") AS form_data", args...), nil
+}
+
+// formDataExtract returns the SQL expression, and its arguments, reading a
+// DataKey's value out of form_data. Nested DataKeys are looked up as a flat
+// key first, as in LookupDataPath.
+func formDataExtract(dataKey string) (string, []interface{}, error) {
+	args := []interface{}{jsonKeyPath([]utils.PathSegment{{Key: dataKey}})}
+	if !utils.IsDataPath(dataKey) {
+		return "JSON_EXTRACT(form_data, ?)", args, nil
+	}
+
+	segments, err := utils.ParseDataPath(dataKey)
+	if err != nil {
+		return "", nil, newErrorf(ErrValidation, "invalid field %q: %v", dataKey, err)
+	}
+	return "COALESCE(JSON_EXTRACT(form_data, ?), JSON_EXTRACT(form_data, ?))", append(args, jsonKeyPath(segments)), nil
+}
+
+// jsonKeyPath writes path segments as a MySQL JSON path with quoted keys.
+func jsonKeyPath(segments []utils.PathSegment) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, segment := range segments {
+		if segment.IsIndex {
+			fmt.Fprintf(&b, "[%d]", segment.Index)
+			continue
+		}
+		key := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(segment.Key)
+		fmt.Fprintf(&b, `."%s"`, key)
+	}
+	return b.String()
+}
+
+// dropMissingValues removes the null entries JSON_OBJECT adds for DataKeys a
+// submission does not have.
+func dropMissingValues(submissions []gormmodels.FormSubmission) {
+	for i := range submissions {
+		for key, value := range submissions[i].FormData {
+			if value == nil {
+				delete(submissions[i].FormData, key)
+			}
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+)
+
+// Codes of FieldError.
+const (
+	FieldErrorRequired    = "REQUIRED"
+	FieldErrorInvalidType = "INVALID_TYPE"
+	FieldErrorNotAnOption = "NOT_AN_OPTION"
+)
+
+// FieldError is a submitted value that does not fit its field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// imageDataURIPattern matches the values image fields accept: base64 data
+// URIs of raster formats. Remote URLs are not fetched.
+var imageDataURIPattern = regexp.MustCompile(`^data:image/(png|jpeg|gif|webp);base64,[A-Za-z0-9+/]+={0,2}$`)
+
+// IsImageDataURI reports whether s is a value an image field can draw.
+func IsImageDataURI(s string) bool {
+	return imageDataURIPattern.MatchString(strings.TrimSpace(s))
+}
+
+// ValidateFormData checks submitted values against the template's fields.
+// With complete set, required fields must have a value; drafts may leave
+// them empty. Values must be of their field's type, read in the submitter's
+// locale, and values of fields with fixed options or checkbox boxes must be
+// one of them. Option lists are checked by OptionListService.Validate.
+func (s *FormService) ValidateFormData(templateID string, data map[string]interface{}, locale InputLocale, complete bool) ([]FieldError, error) {
+	var fields []gormmodels.Field
+	if err := internal.DB.Where("template_id = ?", templateID).Order("page_index, id").Find(&fields).Error; err != nil {
+		return nil, storageError("failed to fetch fields", err)
+	}
+	return ValidateValues(fields, data, locale, complete), nil
+}
+
+// ValidateValues applies ValidateFormData to already loaded fields. Fields
+// sharing a DataKey are checked once, and a value is required when any of
+// them is.
+func ValidateValues(fields []gormmodels.Field, data map[string]interface{}, locale InputLocale, complete bool) []FieldError {
+	required := make(map[string]bool)
+	for _, field := range fields {
+		// A stamp fixed by the template needs no value.
+		if field.Required && !(field.Type == FieldTypeStamp && field.StampID != "") {
+			required[field.DataKey] = true
+		}
+	}
+
+	var errs []FieldError
+	checked := make(map[string]bool)
+	for _, field := range fields {
+		if field.DataKey == "" || checked[field.DataKey] {
+			continue
+		}
+		checked[field.DataKey] = true
+
+		value, _ := utils.LookupDataPath(data, field.DataKey)
+		if isEmptyValue(value) {
+			if complete && required[field.DataKey] {
+				errs = append(errs, FieldError{Field: field.DataKey, Code: FieldErrorRequired, Message: fieldLabel(field) + " is required"})
+			}
+			continue
+		}
+		if fieldErr := checkFieldValue(field, value, locale); fieldErr != nil {
+			errs = append(errs, *fieldErr)
+		}
+	}
+	return errs
+}
+
+// checkFieldValue checks a non-empty value against its field's type and
+// options.
+func checkFieldValue(field gormmodels.Field, value interface{}, locale InputLocale) *FieldError {
+	invalid := func(format string, args ...interface{}) *FieldError {
+		return &FieldError{Field: field.DataKey, Code: FieldErrorInvalidType, Message: fieldLabel(field) + " " + fmt.Sprintf(format, args...)}
+	}
+	str, isString := value.(string)
+
+	switch field.Type {
+	case FieldTypeNumber:
+		if isNumber(value) {
+			return nil
+		}
+		if _, ok := locale.ParseNumber(str); !isString || !ok {
+			return invalid("must be a number")
+		}
+	case FieldTypeDate:
+		if _, ok := locale.ParseDate(str); !isString || !ok {
+			return invalid("must be a date")
+		}
+	case FieldTypeCheckbox:
+		if _, ok := value.(bool); ok || isNumber(value) {
+			return nil
+		}
+		switch strings.ToLower(strings.TrimSpace(str)) {
+		case "true", "false", "yes", "no", "on", "off", "1", "0":
+		default:
+			return invalid("must be true or false")
+		}
+	case FieldTypeCheckboxGroup:
+		values, ok := stringValues(value)
+		if !ok {
+			return invalid("must be a box value or an array of box values")
+		}
+		boxes := boxValues(field)
+		for _, v := range values {
+			if !containsString(boxes, v) {
+				return &FieldError{Field: field.DataKey, Code: FieldErrorNotAnOption,
+					Message: fmt.Sprintf("%s has no box for %q", fieldLabel(field), v)}
+			}
+		}
+		return nil
+	case FieldTypeImage:
+		if !isString || !IsImageDataURI(str) {
+			return invalid("must be a base64 data URI of a PNG, JPEG, GIF or WebP image")
+		}
+	case FieldTypeTable:
+		if _, ok := value.([]interface{}); !ok {
+			return invalid("must be an array of rows")
+		}
+	case FieldTypeSignature, FieldTypeStamp:
+		if !isString {
+			return invalid("must be an ID")
+		}
+	default:
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return invalid("must be a single value")
+		}
+	}
+
+	if field.OptionListID != "" || field.Options == "" {
+		return nil
+	}
+	var options []string
+	if err := json.Unmarshal([]byte(field.Options), &options); err != nil || len(options) == 0 {
+		return nil
+	}
+	if !containsString(options, fmt.Sprint(value)) {
+		return &FieldError{Field: field.DataKey, Code: FieldErrorNotAnOption,
+			Message: fmt.Sprintf("%s must be one of its options", fieldLabel(field))}
+	}
+	return nil
+}
+
+func fieldLabel(field gormmodels.Field) string {
+	if field.Name != "" {
+		return field.Name
+	}
+	return field.DataKey
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case float64, float32, int, int64, json.Number:
+		return true
+	}
+	return false
+}
+
+// stringValues reads a value that is a string or an array of strings.
+func stringValues(value interface{}) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, true
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, s)
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
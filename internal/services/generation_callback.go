@@ -0,0 +1,442 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Generation callback delivery defaults.
+const (
+	DefaultCallbackAttempts = 5
+	DefaultCallbackBackoff  = 30 * time.Second
+	callbackTimeout         = 30 * time.Second
+
+	// abandonedJobAge is how long a queued or running job may go without
+	// an update before it is taken to be lost to a restart.
+	abandonedJobAge   = 30 * time.Minute
+	jobSweepInterval  = 5 * time.Minute
+	abandonedJobError = "job was interrupted by a server restart"
+)
+
+// Events sent to generation callbacks.
+const (
+	EventGenerationCompleted = "generation.completed"
+	EventGenerationFailed    = "generation.failed"
+)
+
+// GenerationCallback is the JSON body posted to a callback for the "url"
+// delivery mode and for failed jobs.
+type GenerationCallback struct {
+	Event        string     `json:"event"`
+	JobID        string     `json:"jobId"`
+	SubmissionID string     `json:"submissionId"`
+	TemplateID   string     `json:"templateId"`
+	DocumentID   string     `json:"documentId,omitempty"`
+	DocumentURL  string     `json:"documentUrl,omitempty"`
+	ExpiresAt    *time.Time `json:"expiresAt,omitempty"`
+	Error        string     `json:"error,omitempty"`
+}
+
+// GenerationCallbackService renders submission PDFs in the background and
+// POSTs the result to a callback URL, for integrators that cannot poll.
+// Bodies are signed like validation webhooks, as
+// "X-FastFill-Signature: sha256=<hex>" under the shared secret. Deliveries
+// that fail with a network error, 408, 429 or 5xx are retried with
+// exponential backoff; retries are held in memory and do not survive a
+// restart, but the rendered PDF stays stored for the submission. Jobs a
+// restart interrupts are failed by StartSweeper. Callbacks are only made to
+// public addresses, so callers cannot reach the server's own network.
+type GenerationCallbackService struct {
+	documentService *DocumentService
+	formService     *FormService
+	render          DocumentRenderer
+	httpClient      *http.Client
+	secret          string
+	queue           chan string
+
+	// MaxAttempts is how often a callback is tried; zero means
+	// DefaultCallbackAttempts.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubling after each;
+	// zero means DefaultCallbackBackoff.
+	Backoff time.Duration
+}
+
+func NewGenerationCallbackService(documentService *DocumentService, formService *FormService, render DocumentRenderer, secret string) *GenerationCallbackService {
+	s := &GenerationCallbackService{
+		documentService: documentService,
+		formService:     formService,
+		render:          render,
+		httpClient:      &http.Client{Timeout: callbackTimeout, Transport: publicTransport()},
+		secret:          secret,
+		queue:           make(chan string, 100),
+	}
+	go s.worker()
+	return s
+}
+
+// Enqueue creates a job rendering a submission and delivering it to
+// callbackURL, as the PDF itself or, with delivery "url", a signed URL.
+func (s *GenerationCallbackService) Enqueue(submission *gormmodels.FormSubmission, callbackURL, delivery string) (*gormmodels.GenerationJob, error) {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, newError(ErrValidation, "callbackUrl must be an http or https URL")
+	}
+	if err := checkPublicHost(parsed.Hostname()); err != nil {
+		return nil, newErrorf(ErrValidation, "callbackUrl %v", err)
+	}
+	switch delivery {
+	case "":
+		delivery = gormmodels.CallbackDeliveryPDF
+	case gormmodels.CallbackDeliveryPDF, gormmodels.CallbackDeliveryURL:
+	default:
+		return nil, newErrorf(ErrValidation, "unknown delivery %q, expected pdf or url", delivery)
+	}
+
+	job := &gormmodels.GenerationJob{
+		ID:           uuid.New().String(),
+		SubmissionID: submission.ID,
+		TemplateID:   submission.TemplateID,
+		Status:       "queued",
+		CallbackURL:  callbackURL,
+		Delivery:     delivery,
+	}
+
+	if err := internal.DB.Create(job).Error; err != nil {
+		return nil, storageError("failed to create generation job", err)
+	}
+
+	select {
+	case s.queue <- job.ID:
+	default:
+		internal.DB.Model(job).Updates(map[string]interface{}{"status": "failed", "last_error": "queue is full"})
+		return nil, newError(ErrStorageUnavailable, "generation queue is full, try again later")
+	}
+
+	return job, nil
+}
+
+func (s *GenerationCallbackService) GetByID(id string) (*gormmodels.GenerationJob, error) {
+	var job gormmodels.GenerationJob
+
+	err := internal.DB.Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch generation job", err)
+	}
+
+	return &job, nil
+}
+
+func (s *GenerationCallbackService) worker() {
+	for jobID := range s.queue {
+		if err := s.run(jobID); err != nil {
+			log.Printf("Generation job %s failed: %v", jobID, err)
+		}
+	}
+}
+
+// run renders a job's PDF and hands it to a delivery goroutine, so slow
+// callbacks and their retries do not hold up the queue.
+func (s *GenerationCallbackService) run(jobID string) error {
+	job, err := s.GetByID(jobID)
+	if err != nil || job == nil {
+		return fmt.Errorf("failed to load job: %v", err)
+	}
+
+	// The sweeper may have failed the job while it waited in the queue.
+	now := time.Now()
+	claimed := internal.DB.Model(job).Where("status = ?", "queued").Updates(map[string]interface{}{"status": "running", "started_at": now})
+	if claimed.Error != nil {
+		return fmt.Errorf("failed to start job: %v", claimed.Error)
+	}
+	if claimed.RowsAffected == 0 {
+		return nil
+	}
+	job.Status = "running"
+	job.StartedAt = &now
+
+	callback := GenerationCallback{
+		Event:        EventGenerationCompleted,
+		JobID:        job.ID,
+		SubmissionID: job.SubmissionID,
+		TemplateID:   job.TemplateID,
+	}
+	pdfBytes, err := s.generate(job, &callback)
+	if err != nil {
+		job.LastError = err.Error()
+		callback.Event = EventGenerationFailed
+		callback.Error = err.Error()
+		pdfBytes = nil
+	}
+	internal.DB.Model(job).Select("document_id", "last_error").Updates(job)
+
+	go s.deliver(job, callback, pdfBytes)
+	return err
+}
+
+// generate renders and stores the job's PDF, filling in the document of the
+// callback.
+func (s *GenerationCallbackService) generate(job *gormmodels.GenerationJob, callback *GenerationCallback) ([]byte, error) {
+	submission, err := s.formService.GetByID(job.SubmissionID)
+	if err != nil {
+		return nil, err
+	}
+	if submission == nil {
+		return nil, newError(ErrNotFound, "submission not found")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	htmlContent, pdfBytes, err := s.render(ctx, submission)
+	if err != nil {
+		return nil, err
+	}
+
+	document, _, err := s.documentService.Store(ctx, submission, htmlContent, pdfBytes)
+	if err != nil {
+		return nil, err
+	}
+	job.DocumentID = document.ID
+	callback.DocumentID = document.ID
+
+	if job.Delivery == gormmodels.CallbackDeliveryURL {
+		signedURL, err := s.documentService.GetSignedURL(document)
+		if err != nil {
+			return nil, err
+		}
+		expiresAt := time.Now().Add(documentURLTTL)
+		callback.DocumentURL = signedURL
+		callback.ExpiresAt = &expiresAt
+	}
+	return pdfBytes, nil
+}
+
+// deliver posts the result of a job to its callback until it is accepted,
+// the callback rejects it for good or the attempts run out.
+func (s *GenerationCallbackService) deliver(job *gormmodels.GenerationJob, callback GenerationCallback, pdfBytes []byte) {
+	maxAttempts, backoff := s.retryPolicy()
+
+	failed := callback.Event == EventGenerationFailed
+	status := "failed"
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		retry, err := s.post(job, callback, pdfBytes, attempt)
+		job.Attempts = attempt
+		if err == nil {
+			delivered := time.Now()
+			job.DeliveredAt = &delivered
+			if !failed {
+				status = "delivered"
+				job.LastError = ""
+			}
+			break
+		}
+
+		log.Printf("Warning: callback of generation job %s failed (attempt %d/%d): %v", job.ID, attempt, maxAttempts, err)
+		if !failed {
+			job.LastError = err.Error()
+			status = "delivery_failed"
+		}
+		if !retry || attempt == maxAttempts {
+			break
+		}
+		internal.DB.Model(job).Select("attempts", "last_error").Updates(job)
+		time.Sleep(backoff << (attempt - 1))
+	}
+
+	finished := time.Now()
+	job.Status = status
+	job.FinishedAt = &finished
+	if err := internal.DB.Save(job).Error; err != nil {
+		log.Printf("Failed to save generation job %s: %v", job.ID, err)
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth
+// retrying.
+func (s *GenerationCallbackService) post(job *gormmodels.GenerationJob, callback GenerationCallback, pdfBytes []byte, attempt int) (bool, error) {
+	body, contentType := pdfBytes, "application/pdf"
+	if job.Delivery == gormmodels.CallbackDeliveryURL || callback.Event == EventGenerationFailed {
+		var err error
+		if body, err = json.Marshal(callback); err != nil {
+			return false, err
+		}
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequest(http.MethodPost, job.CallbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to build callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-FastFill-Event", callback.Event)
+	req.Header.Set("X-FastFill-Job", job.ID)
+	req.Header.Set("X-FastFill-Submission", job.SubmissionID)
+	req.Header.Set("X-FastFill-Attempt", fmt.Sprint(attempt))
+	if callback.DocumentID != "" {
+		req.Header.Set("X-FastFill-Document", callback.DocumentID)
+	}
+	if s.secret != "" {
+		req.Header.Set("X-FastFill-Signature", webhookSignature(s.secret, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return !errors.Is(err, errPrivateAddress), fmt.Errorf("failed to call callback: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("callback answered with status %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+func (s *GenerationCallbackService) retryPolicy() (int, time.Duration) {
+	maxAttempts := s.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultCallbackAttempts
+	}
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = DefaultCallbackBackoff
+	}
+	return maxAttempts, backoff
+}
+
+// FailAbandoned fails the queued and running jobs that have not been updated
+// for longer than a live job could go, which only happens when the server
+// holding them restarted, and reports the failure to their callbacks.
+func (s *GenerationCallbackService) FailAbandoned() (int, error) {
+	maxAttempts, backoff := s.retryPolicy()
+	staleAfter := abandonedJobAge
+	if longest := 2 * (backoff << (maxAttempts - 1)); longest > staleAfter {
+		staleAfter = longest
+	}
+
+	var jobs []gormmodels.GenerationJob
+	err := internal.DB.Where("status IN ? AND updated_at < ?", []string{"queued", "running"}, time.Now().Add(-staleAfter)).Find(&jobs).Error
+	if err != nil {
+		return 0, storageError("failed to fetch abandoned generation jobs", err)
+	}
+
+	failed := 0
+	for i := range jobs {
+		job := &jobs[i]
+		// Another instance may be sweeping too; only the one that fails the
+		// job delivers it.
+		result := internal.DB.Model(job).Where("status = ?", job.Status).Updates(map[string]interface{}{"status": "failed", "last_error": abandonedJobError})
+		if result.Error != nil {
+			return failed, storageError("failed to fail abandoned generation job", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		job.LastError = abandonedJobError
+		go s.deliver(job, GenerationCallback{
+			Event:        EventGenerationFailed,
+			JobID:        job.ID,
+			SubmissionID: job.SubmissionID,
+			TemplateID:   job.TemplateID,
+			DocumentID:   job.DocumentID,
+			Error:        abandonedJobError,
+		}, nil)
+		failed++
+	}
+	return failed, nil
+}
+
+// StartSweeper fails abandoned jobs now and periodically until ctx is
+// cancelled.
+func (s *GenerationCallbackService) StartSweeper(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(jobSweepInterval)
+		defer ticker.Stop()
+		for {
+			failed, err := s.FailAbandoned()
+			if err != nil {
+				log.Printf("Warning: generation job sweep failed: %v", err)
+			} else if failed > 0 {
+				log.Printf("Failed %d abandoned generation job(s)", failed)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// errPrivateAddress rejects callbacks to the server's own network.
+var errPrivateAddress = errors.New("must not point to a loopback, private or link-local address")
+
+// publicIP reports whether ip is routable on the internet.
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() && !ip.IsUnspecified()
+}
+
+// checkPublicHost rejects hosts that are, or resolve to, non-public
+// addresses. It catches mistakes early; publicTransport enforces the rule
+// on every connection, including redirects and hosts whose DNS changes.
+func checkPublicHost(host string) error {
+	if host == "localhost" {
+		return errPrivateAddress
+	}
+	ips := []net.IP{net.ParseIP(host)}
+	if ips[0] == nil {
+		// Hosts that do not resolve yet fail at delivery.
+		ips, _ = net.LookupIP(host)
+	}
+	for _, ip := range ips {
+		if !publicIP(ip) {
+			return errPrivateAddress
+		}
+	}
+	return nil
+}
+
+// publicTransport is an HTTP transport that connects directly, never
+// through a proxy, and only to public addresses. The address is checked
+// after resolution, right before connecting.
+func publicTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout: callbackTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("callback address %s %w", host, errPrivateAddress)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+)
+
+// MaxGridSize is the largest grid spacing, in page pixels.
+const MaxGridSize = 200
+
+// SnapResult reports which fields snapping to the grid moved or resized.
+type SnapResult struct {
+	Snapped int           `json:"snapped"`
+	Fields  []FieldLayout `json:"fields"`
+}
+
+// SetGrid saves the editor grid of a template. The grid only guides editing,
+// so saving it leaves the template version alone.
+func (s *TemplateService) SetGrid(templateID string, grid gormmodels.GridSettings) error {
+	if err := validateGrid(grid); err != nil {
+		return err
+	}
+
+	result := internal.DB.Model(&gormmodels.Template{}).Where("id = ?", templateID).
+		Select("grid").Updates(&gormmodels.Template{Grid: grid})
+	if result.Error != nil {
+		return storageError("failed to save grid", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+func validateGrid(grid gormmodels.GridSettings) error {
+	if grid.Size < 0 || grid.Size > MaxGridSize {
+		return newErrorf(ErrValidation, "grid size must be between 0 and %d", MaxGridSize)
+	}
+	if grid.Snap && grid.Size == 0 {
+		return newError(ErrValidation, "snapping needs a grid size")
+	}
+	for i, guide := range grid.Guides {
+		if guide.Orientation != gormmodels.GuideHorizontal && guide.Orientation != gormmodels.GuideVertical {
+			return newErrorf(ErrValidation, "guides[%d]: orientation must be %q or %q", i, gormmodels.GuideHorizontal, gormmodels.GuideVertical)
+		}
+		if guide.Position < 0 {
+			return newErrorf(ErrValidation, "guides[%d]: position must not be negative", i)
+		}
+		if guide.PageIndex != nil && *guide.PageIndex < 0 {
+			return newErrorf(ErrValidation, "guides[%d]: pageIndex must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// SnapToGrid moves the edges of the template's fields, and the boxes of its
+// checkbox groups, to the nearest grid line, or to the nearest guide when one
+// is within half a grid cell. Sizes never shrink below one cell. A non-nil
+// pageIndex limits snapping to that page. Unless dryRun is set the snapped
+// positions are saved and the template version is bumped.
+func (s *TemplateService) SnapToGrid(template *gormmodels.Template, pageIndex *int, dryRun bool) (*SnapResult, error) {
+	grid := template.Grid
+	if grid.Size <= 0 {
+		return nil, newError(ErrValidation, "template has no grid")
+	}
+
+	result := &SnapResult{Fields: []FieldLayout{}}
+	var updates []gormmodels.Field
+	occurrences := make(map[string]int)
+	for _, f := range orderedFields(template.Fields) {
+		occurrence := occurrences[f.DataKey]
+		occurrences[f.DataKey]++
+		if pageIndex != nil && f.PageIndex != *pageIndex {
+			continue
+		}
+
+		snapper := newGridSnapper(grid, f.PageIndex)
+		snapped := f
+		snapped.PositionTop, snapped.PositionLeft, snapped.PositionWidth, snapped.PositionHeight =
+			snapper.rect(f.PositionTop, f.PositionLeft, f.PositionWidth, f.PositionHeight)
+		snapped.Boxes = make([]gormmodels.CheckboxBox, len(f.Boxes))
+		boxesMoved := false
+		for i, box := range f.Boxes {
+			snapped.Boxes[i] = box
+			snapped.Boxes[i].Top, snapped.Boxes[i].Left, snapped.Boxes[i].Width, snapped.Boxes[i].Height =
+				snapper.rect(box.Top, box.Left, box.Width, box.Height)
+			boxesMoved = boxesMoved || snapped.Boxes[i] != box
+		}
+		if len(f.Boxes) == 0 {
+			snapped.Boxes = f.Boxes
+		}
+
+		if snapped.GetPosition() == f.GetPosition() && !boxesMoved {
+			continue
+		}
+		updates = append(updates, snapped)
+		result.Fields = append(result.Fields, FieldLayout{
+			DataKey:    f.DataKey,
+			Occurrence: occurrence,
+			PageIndex:  f.PageIndex,
+			Position: LayoutPosition{
+				Top:    float64(snapped.PositionTop),
+				Left:   float64(snapped.PositionLeft),
+				Width:  float64(snapped.PositionWidth),
+				Height: float64(snapped.PositionHeight),
+			},
+			Boxes: boxLayouts(snapped.Boxes),
+		})
+	}
+	result.Snapped = len(updates)
+	if dryRun || len(updates) == 0 {
+		return result, nil
+	}
+
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range updates {
+			err := tx.Model(&updates[i]).
+				Select("position_top", "position_left", "position_width", "position_height", "boxes").
+				Updates(&updates[i]).Error
+			if err != nil {
+				return err
+			}
+		}
+		if _, err := bumpVersion(tx, template.ID, 0); err != nil {
+			return err
+		}
+		return tx.Model(&gormmodels.Template{}).Where("id = ?", template.ID).Update("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, storageError("failed to snap fields to grid", err)
+	}
+	return result, nil
+}
+
+// gridSnapper snaps coordinates on one page to the grid and its guides.
+type gridSnapper struct {
+	size int
+	// horizontal guides snap tops and bottoms, vertical ones lefts and rights.
+	horizontal []int
+	vertical   []int
+}
+
+func newGridSnapper(grid gormmodels.GridSettings, pageIndex int) gridSnapper {
+	snapper := gridSnapper{size: grid.Size}
+	for _, guide := range grid.Guides {
+		if guide.PageIndex != nil && *guide.PageIndex != pageIndex {
+			continue
+		}
+		if guide.Orientation == gormmodels.GuideHorizontal {
+			snapper.horizontal = append(snapper.horizontal, guide.Position)
+		} else {
+			snapper.vertical = append(snapper.vertical, guide.Position)
+		}
+	}
+	return snapper
+}
+
+// rect snaps the top-left corner and the bottom-right corner of a rectangle
+// and returns its new position and size.
+func (g gridSnapper) rect(top, left, width, height int) (int, int, int, int) {
+	newTop := g.snap(top, g.horizontal)
+	newLeft := g.snap(left, g.vertical)
+	bottom := max(g.snap(top+height, g.horizontal), newTop+g.size)
+	right := max(g.snap(left+width, g.vertical), newLeft+g.size)
+	return newTop, newLeft, right - newLeft, bottom - newTop
+}
+
+func (g gridSnapper) snap(value int, guides []int) int {
+	best, bestDistance := -1, g.size
+	for _, guide := range guides {
+		if distance := abs(guide - value); distance*2 < g.size && distance < bestDistance {
+			best, bestDistance = guide, distance
+		}
+	}
+	if best < 0 {
+		best = roundTo(value, g.size)
+	}
+	return max(best, 0)
+}
+
+func roundTo(value, size int) int {
+	if value < 0 {
+		return -roundTo(-value, size)
+	}
+	return (value + size/2) / size * size
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
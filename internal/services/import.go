@@ -0,0 +1,300 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Import limits.
+const (
+	MaxImportRows    = 5000
+	MaxImportColumns = 200
+)
+
+var importTransforms = map[string]bool{
+	gormmodels.TransformNone:    true,
+	gormmodels.TransformTrim:    true,
+	gormmodels.TransformUpper:   true,
+	gormmodels.TransformLower:   true,
+	gormmodels.TransformDate:    true,
+	gormmodels.TransformNumber:  true,
+	gormmodels.TransformBoolean: true,
+}
+
+// dateFormatTokens turns the YYYY/MM/DD date formats of column mappings into
+// Go layouts. Longer tokens come first so they win at each position.
+var dateFormatTokens = strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02", "M", "1", "D", "2")
+
+var (
+	trueValues  = map[string]bool{"true": true, "yes": true, "y": true, "1": true, "x": true, "✓": true, "ใช่": true}
+	falseValues = map[string]bool{"false": true, "no": true, "n": true, "0": true, "ไม่ใช่": true}
+)
+
+// ImportRow is one CSV record mapped onto form data. Line is the record's
+// line in the file, counting the header as line 1. Errors lists the values
+// that could not be transformed; rows with errors have no FormData.
+type ImportRow struct {
+	Line     int                    `json:"line"`
+	FormData map[string]interface{} `json:"-"`
+	Errors   []string               `json:"errors,omitempty"`
+}
+
+type ImportService struct{}
+
+func NewImportService() *ImportService {
+	return &ImportService{}
+}
+
+// CreateProfile stores a column mapping profile under a name unique to its
+// template.
+func (s *ImportService) CreateProfile(profile *gormmodels.ImportProfile) error {
+	if err := s.checkProfile(profile); err != nil {
+		return err
+	}
+
+	profile.ID = uuid.New().String()
+	if err := internal.DB.Create(profile).Error; err != nil {
+		return storageError("failed to create import profile", err)
+	}
+	return nil
+}
+
+func (s *ImportService) GetProfiles(templateID string) ([]gormmodels.ImportProfile, error) {
+	var profiles []gormmodels.ImportProfile
+
+	err := internal.DB.Where("template_id = ?", templateID).Order("name").Find(&profiles).Error
+	if err != nil {
+		return nil, storageError("failed to fetch import profiles", err)
+	}
+
+	return profiles, nil
+}
+
+// GetProfile returns the template's profile with the ID, or nil if there is
+// none.
+func (s *ImportService) GetProfile(templateID, id string) (*gormmodels.ImportProfile, error) {
+	var profile gormmodels.ImportProfile
+
+	err := internal.DB.Where("id = ? AND template_id = ?", id, templateID).First(&profile).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch import profile", err)
+	}
+
+	return &profile, nil
+}
+
+func (s *ImportService) UpdateProfile(profile *gormmodels.ImportProfile) error {
+	if err := s.checkProfile(profile); err != nil {
+		return err
+	}
+
+	err := internal.DB.Model(profile).Select("name", "columns", "locale", "status", "updated_at").Updates(profile).Error
+	if err != nil {
+		return storageError("failed to update import profile", err)
+	}
+	return nil
+}
+
+func (s *ImportService) DeleteProfile(templateID, id string) error {
+	result := internal.DB.Where("id = ? AND template_id = ?", id, templateID).Delete(&gormmodels.ImportProfile{})
+	if result.Error != nil {
+		return storageError("failed to delete import profile", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return newError(ErrNotFound, "import profile not found")
+	}
+	return nil
+}
+
+func (s *ImportService) checkProfile(profile *gormmodels.ImportProfile) error {
+	profile.Name = strings.TrimSpace(profile.Name)
+	if profile.Name == "" {
+		return newError(ErrValidation, "name is required")
+	}
+	return s.CheckColumns(profile.TemplateID, profile.Columns)
+}
+
+// CheckColumns verifies a column mapping: every column is mapped once, onto
+// a DataKey of the template's fields, with a known transform.
+func (s *ImportService) CheckColumns(templateID string, columns []gormmodels.ColumnMapping) error {
+	if len(columns) == 0 {
+		return newError(ErrValidation, "columns: at least one column must be mapped")
+	}
+	if len(columns) > MaxImportColumns {
+		return newErrorf(ErrValidation, "columns: at most %d columns can be mapped", MaxImportColumns)
+	}
+
+	var dataKeys []string
+	err := internal.DB.Model(&gormmodels.Field{}).Where("template_id = ?", templateID).Distinct().Pluck("data_key", &dataKeys).Error
+	if err != nil {
+		return storageError("failed to fetch template fields", err)
+	}
+	known := make(map[string]bool, len(dataKeys))
+	for _, key := range dataKeys {
+		known[key] = true
+	}
+
+	seen := make(map[string]bool, len(columns))
+	for i, column := range columns {
+		name := headerKey(column.Column)
+		switch {
+		case name == "":
+			return newErrorf(ErrValidation, "columns[%d]: column is required", i)
+		case seen[name]:
+			return newErrorf(ErrValidation, "columns[%d]: column %q is mapped more than once", i, column.Column)
+		case !known[column.DataKey]:
+			return newErrorf(ErrValidation, "columns[%d]: %q is not a field of the template", i, column.DataKey)
+		case !importTransforms[column.Transform]:
+			return newErrorf(ErrValidation, "columns[%d]: unknown transform %q", i, column.Transform)
+		case column.Format != "" && column.Transform != gormmodels.TransformDate:
+			return newErrorf(ErrValidation, "columns[%d]: format only applies to the date transform", i)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// ReadCSV maps the records of a CSV file with a header row onto form data.
+// Columns are matched to the header by name, ignoring case and surrounding
+// space, and every mapped column must be present. Empty cells are left out
+// of the form data. Values are transformed as mapped, reading dates and
+// numbers in locale unless the mapping gives a date format.
+func (s *ImportService) ReadCSV(r io.Reader, columns []gormmodels.ColumnMapping, locale InputLocale) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, newError(ErrValidation, "file is empty")
+	}
+	if err != nil {
+		return nil, newErrorf(ErrValidation, "invalid CSV: %v", err)
+	}
+
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		positions[headerKey(name)] = i
+	}
+	indexes := make([]int, len(columns))
+	for i, column := range columns {
+		index, ok := positions[headerKey(column.Column)]
+		if !ok {
+			return nil, newErrorf(ErrValidation, "column %q is missing from the file", column.Column)
+		}
+		indexes[i] = index
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, newErrorf(ErrValidation, "invalid CSV: %v", err)
+		}
+		if len(rows) == MaxImportRows {
+			return nil, newErrorf(ErrValidation, "file has more than %d rows", MaxImportRows)
+		}
+		line, _ := reader.FieldPos(0)
+
+		row := ImportRow{Line: line, FormData: make(map[string]interface{})}
+		for i, column := range columns {
+			if indexes[i] >= len(record) || strings.TrimSpace(record[indexes[i]]) == "" {
+				continue
+			}
+			value, err := transformValue(record[indexes[i]], column, locale)
+			if err == nil {
+				err = setImportValue(row.FormData, column.DataKey, value)
+			}
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("%s: %v", column.Column, err))
+			}
+		}
+		if len(row.Errors) > 0 {
+			row.FormData = nil
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func headerKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+func setImportValue(data map[string]interface{}, key string, value interface{}) error {
+	if !utils.IsDataPath(key) {
+		data[key] = value
+		return nil
+	}
+	return utils.SetDataPath(data, key, value)
+}
+
+// transformValue applies a column's transform to one cell.
+func transformValue(cell string, column gormmodels.ColumnMapping, locale InputLocale) (interface{}, error) {
+	switch column.Transform {
+	case gormmodels.TransformTrim:
+		return strings.TrimSpace(cell), nil
+	case gormmodels.TransformUpper:
+		return strings.ToUpper(strings.TrimSpace(cell)), nil
+	case gormmodels.TransformLower:
+		return strings.ToLower(strings.TrimSpace(cell)), nil
+	case gormmodels.TransformDate:
+		date, ok := parseImportDate(strings.TrimSpace(cell), column.Format, locale)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a date", cell)
+		}
+		return date.Format(CanonicalDateLayout), nil
+	case gormmodels.TransformNumber:
+		value, ok := locale.ParseNumber(cell)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a number", cell)
+		}
+		return value, nil
+	case gormmodels.TransformBoolean:
+		value := strings.ToLower(strings.TrimSpace(cell))
+		switch {
+		case trueValues[value]:
+			return true, nil
+		case falseValues[value]:
+			return false, nil
+		}
+		return nil, fmt.Errorf("%q is not a yes or no value", cell)
+	}
+	return cell, nil
+}
+
+// parseImportDate reads a date in the given YYYY/MM/DD format, or in the
+// locale when there is none. Buddhist-era years are converted as ParseDate
+// does.
+func parseImportDate(s, format string, locale InputLocale) (time.Time, bool) {
+	if format == "" {
+		return locale.ParseDate(s)
+	}
+
+	date, err := time.Parse(dateFormatTokens.Replace(format), thaiDigits.Replace(s))
+	if err != nil {
+		return time.Time{}, false
+	}
+	if locale.BuddhistEra && date.Year() > 2400 {
+		date = date.AddDate(-buddhistEraOffset, 0, 0)
+	}
+	return date, true
+}
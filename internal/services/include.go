@@ -0,0 +1,161 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// maxIncludeDepth bounds how deeply templates may include each other.
+const maxIncludeDepth = 5
+
+var ErrInvalidInclude = newError(ErrValidation, "invalid template include")
+
+func (s *TemplateService) GetIncludes(templateID string) ([]gormmodels.TemplateInclude, error) {
+	var includes []gormmodels.TemplateInclude
+
+	err := internal.DB.Where("template_id = ?", templateID).Order("position ASC, id ASC").Find(&includes).Error
+	if err != nil {
+		return nil, storageError("failed to fetch template includes", err)
+	}
+
+	return includes, nil
+}
+
+// AddInclude validates the include against the template's current pages and
+// include chain before saving it. Validation failures wrap ErrInvalidInclude.
+func (s *TemplateService) AddInclude(template *gormmodels.Template, include *gormmodels.TemplateInclude) error {
+	include.TemplateID = template.ID
+
+	includes, err := s.GetIncludes(template.ID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.resolve(template, append(includes, *include), map[string]bool{template.ID: true}); err != nil {
+		return err
+	}
+
+	if err := internal.DB.Create(include).Error; err != nil {
+		return storageError("failed to create template include", err)
+	}
+	return nil
+}
+
+func (s *TemplateService) DeleteInclude(templateID string, includeID uint) error {
+	err := internal.DB.Where("template_id = ? AND id = ?", templateID, includeID).Delete(&gormmodels.TemplateInclude{}).Error
+	if err != nil {
+		return storageError("failed to delete template include", err)
+	}
+	return nil
+}
+
+// CountIncluders returns how many templates include the given template.
+func (s *TemplateService) CountIncluders(templateID string) (int64, error) {
+	var count int64
+
+	err := internal.DB.Model(&gormmodels.TemplateInclude{}).Where("included_template_id = ?", templateID).Count(&count).Error
+	if err != nil {
+		return 0, storageError("failed to count template includes", err)
+	}
+
+	return count, nil
+}
+
+// ResolveIncludes returns a copy of the template with the pages, fields and
+// calibrations of every included template merged in at their page offsets.
+// Templates without includes are returned unchanged.
+func (s *TemplateService) ResolveIncludes(template *gormmodels.Template) (*gormmodels.Template, error) {
+	includes, err := s.GetIncludes(template.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.resolve(template, includes, map[string]bool{template.ID: true})
+}
+
+func (s *TemplateService) resolve(template *gormmodels.Template, includes []gormmodels.TemplateInclude, chain map[string]bool) (*gormmodels.Template, error) {
+	if len(includes) == 0 {
+		return template, nil
+	}
+	if len(chain) > maxIncludeDepth {
+		return nil, fmt.Errorf("%w: includes are nested more than %d levels deep", ErrInvalidInclude, maxIncludeDepth)
+	}
+
+	sort.SliceStable(includes, func(i, j int) bool {
+		return includes[i].Position < includes[j].Position
+	})
+
+	resolved := *template
+	resolved.Fields = append([]gormmodels.Field(nil), template.Fields...)
+	resolved.SVGFiles = append([]gormmodels.SVGFile(nil), template.SVGFiles...)
+	resolved.Calibrations = append([]gormmodels.PageCalibration(nil), template.Calibrations...)
+	resolved.Includes = nil
+
+	occupied := pagesOf(template)
+
+	for _, include := range includes {
+		if chain[include.IncludedTemplateID] {
+			return nil, fmt.Errorf("%w: template %s includes itself", ErrInvalidInclude, include.IncludedTemplateID)
+		}
+
+		child, err := s.GetByID(include.IncludedTemplateID)
+		if err != nil {
+			return nil, err
+		}
+		if child == nil {
+			return nil, fmt.Errorf("%w: included template %s not found", ErrInvalidInclude, include.IncludedTemplateID)
+		}
+
+		childIncludes, err := s.GetIncludes(child.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		chain[include.IncludedTemplateID] = true
+		child, err = s.resolve(child, childIncludes, chain)
+		delete(chain, include.IncludedTemplateID)
+		if err != nil {
+			return nil, err
+		}
+
+		childPages := pagesOf(child)
+		for page := range childPages {
+			if occupied[page+include.PageOffset] {
+				return nil, fmt.Errorf("%w: page %d of template %s overlaps an existing page", ErrInvalidInclude, page+include.PageOffset+1, child.ID)
+			}
+		}
+		for page := range childPages {
+			occupied[page+include.PageOffset] = true
+		}
+
+		for _, field := range child.Fields {
+			field.PageIndex += include.PageOffset
+			resolved.Fields = append(resolved.Fields, field)
+		}
+		for _, svgFile := range child.SVGFiles {
+			svgFile.PageIndex += include.PageOffset
+			resolved.SVGFiles = append(resolved.SVGFiles, svgFile)
+		}
+		for _, calibration := range child.Calibrations {
+			calibration.PageIndex += include.PageOffset
+			resolved.Calibrations = append(resolved.Calibrations, calibration)
+		}
+	}
+
+	return &resolved, nil
+}
+
+// pagesOf returns the page indexes a template occupies through its backgrounds or fields.
+func pagesOf(template *gormmodels.Template) map[int]bool {
+	pages := make(map[int]bool)
+	for _, svgFile := range template.SVGFiles {
+		pages[svgFile.PageIndex] = true
+	}
+	for _, field := range template.Fields {
+		pages[field.PageIndex] = true
+	}
+	return pages
+}
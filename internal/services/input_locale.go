@@ -0,0 +1,250 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"golang.org/x/text/language"
+)
+
+// CanonicalDateLayout is the layout dates are stored in after normalization.
+const CanonicalDateLayout = "2006-01-02"
+
+// buddhistEraOffset is the difference between Buddhist-era and Common-era years.
+const buddhistEraOffset = 543
+
+// InputLocale describes how users of one locale write numbers and dates.
+type InputLocale struct {
+	Tag         string
+	GroupSep    string
+	DecimalSep  string
+	DayFirst    bool
+	BuddhistEra bool
+}
+
+// inputLocales lists the supported input locales; the first is the default.
+var inputLocales = []InputLocale{
+	{Tag: "en", GroupSep: ",", DecimalSep: ".", DayFirst: false},
+	{Tag: "en-GB", GroupSep: ",", DecimalSep: ".", DayFirst: true},
+	{Tag: "th", GroupSep: ",", DecimalSep: ".", DayFirst: true, BuddhistEra: true},
+	{Tag: "de", GroupSep: ".", DecimalSep: ",", DayFirst: true},
+}
+
+var inputLocaleMatcher = func() language.Matcher {
+	tags := make([]language.Tag, len(inputLocales))
+	for i, l := range inputLocales {
+		tags[i] = language.Make(l.Tag)
+	}
+	return language.NewMatcher(tags)
+}()
+
+// MatchInputLocale picks the supported input locale closest to the given
+// language tags or Accept-Language style lists. Empty and invalid values are
+// skipped; the default locale is returned when nothing matches.
+func MatchInputLocale(preferences ...string) InputLocale {
+	for _, preference := range preferences {
+		if preference == "" {
+			continue
+		}
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		_, index, confidence := inputLocaleMatcher.Match(tags...)
+		if confidence != language.No {
+			return inputLocales[index]
+		}
+	}
+	return inputLocales[0]
+}
+
+// thaiDigits maps Thai numerals onto ASCII digits.
+var thaiDigits = strings.NewReplacer("๐", "0", "๑", "1", "๒", "2", "๓", "3", "๔", "4", "๕", "5", "๖", "6", "๗", "7", "๘", "8", "๙", "9")
+
+// ParseNumber parses a number written with the locale's grouping and decimal
+// separators. Thai numerals and surrounding currency symbols are accepted.
+func (l InputLocale) ParseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(thaiDigits.Replace(s))
+	s = strings.TrimSpace(strings.Trim(s, "฿$€"))
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(s, "บาท"), "THB"))
+	if s == "" {
+		return 0, false
+	}
+
+	sign := ""
+	if s[0] == '-' || s[0] == '+' {
+		sign, s = s[:1], s[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(s, l.DecimalSep)
+	if strings.Contains(whole, l.GroupSep) {
+		groups := strings.Split(whole, l.GroupSep)
+		if len(groups[0]) == 0 || len(groups[0]) > 3 {
+			return 0, false
+		}
+		for _, group := range groups[1:] {
+			if len(group) != 3 {
+				return 0, false
+			}
+		}
+		whole = strings.Join(groups, "")
+	}
+	if !isDigits(whole) || (hasFraction && !isDigits(fraction)) {
+		return 0, false
+	}
+
+	canonical := sign + whole
+	if hasFraction {
+		canonical += "." + fraction
+	}
+	value, err := strconv.ParseFloat(canonical, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	numericDatePattern = regexp.MustCompile(`^(\d{1,4})[/.\-](\d{1,2})[/.\-](\d{1,4})$`)
+	namedDatePattern   = regexp.MustCompile(`^(\d{1,2})\s*(\S+?)\s*(\d{4})$`)
+)
+
+// thaiMonths maps full and abbreviated Thai month names to month numbers.
+var thaiMonths = map[string]time.Month{
+	"มกราคม": time.January, "ม.ค.": time.January,
+	"กุมภาพันธ์": time.February, "ก.พ.": time.February,
+	"มีนาคม": time.March, "มี.ค.": time.March,
+	"เมษายน": time.April, "เม.ย.": time.April,
+	"พฤษภาคม": time.May, "พ.ค.": time.May,
+	"มิถุนายน": time.June, "มิ.ย.": time.June,
+	"กรกฎาคม": time.July, "ก.ค.": time.July,
+	"สิงหาคม": time.August, "ส.ค.": time.August,
+	"กันยายน": time.September, "ก.ย.": time.September,
+	"ตุลาคม": time.October, "ต.ค.": time.October,
+	"พฤศจิกายน": time.November, "พ.ย.": time.November,
+	"ธันวาคม": time.December, "ธ.ค.": time.December,
+}
+
+// ParseDate parses an ISO date, a numeric date in the locale's day/month
+// order, or a date with a Thai month name. Four-digit years are read as
+// Buddhist-era years when the locale uses them and the year is later than
+// the Common-era calendar could plausibly reach.
+func (l InputLocale) ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(thaiDigits.Replace(s))
+
+	var year, month, day int
+	if m := numericDatePattern.FindStringSubmatch(s); m != nil {
+		a, _ := strconv.Atoi(m[1])
+		b, _ := strconv.Atoi(m[2])
+		c, _ := strconv.Atoi(m[3])
+		switch {
+		case len(m[1]) == 4:
+			year, month, day = a, b, c
+		case len(m[3]) != 4:
+			return time.Time{}, false
+		case l.DayFirst:
+			year, month, day = c, b, a
+		default:
+			year, month, day = c, a, b
+		}
+	} else if m := namedDatePattern.FindStringSubmatch(s); m != nil {
+		name, ok := thaiMonths[m[2]]
+		if !ok {
+			return time.Time{}, false
+		}
+		day, _ = strconv.Atoi(m[1])
+		year, _ = strconv.Atoi(m[3])
+		month = int(name)
+		// Month names only appear in Thai input, which uses Buddhist-era years.
+		l.BuddhistEra = true
+	} else {
+		return time.Time{}, false
+	}
+
+	if l.BuddhistEra && year > 2400 {
+		year -= buddhistEraOffset
+	}
+
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Year() != year || int(date.Month()) != month || date.Day() != day {
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+// NormalizeFormData rewrites the string values of a template's number and
+// date fields into canonical form: numbers become JSON numbers and dates
+// become YYYY-MM-DD in the Common era. It returns the original input of every
+// value it changed; values that cannot be parsed are left as submitted.
+func (s *FormService) NormalizeFormData(templateID string, data map[string]interface{}, locale InputLocale) (map[string]interface{}, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var fields []gormmodels.Field
+	err := internal.DB.Select("data_key", "type").
+		Where("template_id = ? AND type IN ?", templateID, []string{FieldTypeNumber, FieldTypeDate}).
+		Find(&fields).Error
+	if err != nil {
+		return nil, storageError("failed to fetch fields", err)
+	}
+
+	return NormalizeValues(fields, data, locale), nil
+}
+
+// NormalizeValues applies NormalizeFormData to already loaded fields.
+func NormalizeValues(fields []gormmodels.Field, data map[string]interface{}, locale InputLocale) map[string]interface{} {
+	var raw map[string]interface{}
+	for _, field := range fields {
+		value, _ := utils.LookupDataPath(data, field.DataKey)
+		str, ok := value.(string)
+		if !ok || strings.TrimSpace(str) == "" {
+			continue
+		}
+		if _, done := raw[field.DataKey]; done {
+			continue
+		}
+
+		var canonical interface{}
+		switch field.Type {
+		case FieldTypeNumber:
+			if value, ok := locale.ParseNumber(str); ok {
+				canonical = value
+			}
+		case FieldTypeDate:
+			if date, ok := locale.ParseDate(str); ok {
+				canonical = date.Format(CanonicalDateLayout)
+			}
+		}
+		if canonical == nil || canonical == str {
+			continue
+		}
+
+		if err := utils.SetDataPath(data, field.DataKey, canonical); err != nil {
+			continue
+		}
+		if raw == nil {
+			raw = make(map[string]interface{})
+		}
+		raw[field.DataKey] = str
+	}
+	return raw
+}
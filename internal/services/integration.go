@@ -0,0 +1,148 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+type IntegrationService struct{}
+
+func NewIntegrationService() *IntegrationService {
+	return &IntegrationService{}
+}
+
+func (s *IntegrationService) Create(integration *gormmodels.InboundIntegration) (string, error) {
+	token, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	integration.Token = token
+	integration.Secret = secret
+
+	if err := internal.DB.Create(integration).Error; err != nil {
+		return "", storageError("failed to create integration", err)
+	}
+
+	return secret, nil
+}
+
+func (s *IntegrationService) GetByID(id uint) (*gormmodels.InboundIntegration, error) {
+	var integration gormmodels.InboundIntegration
+
+	err := internal.DB.Where("id = ?", id).First(&integration).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch integration", err)
+	}
+
+	return &integration, nil
+}
+
+func (s *IntegrationService) GetByToken(token string) (*gormmodels.InboundIntegration, error) {
+	var integration gormmodels.InboundIntegration
+
+	err := internal.DB.Where("token = ?", token).First(&integration).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch integration", err)
+	}
+
+	return &integration, nil
+}
+
+func (s *IntegrationService) GetByTemplateID(templateID string) ([]gormmodels.InboundIntegration, error) {
+	var integrations []gormmodels.InboundIntegration
+
+	err := internal.DB.Where("template_id = ?", templateID).Order("created_at DESC").Find(&integrations).Error
+	if err != nil {
+		return nil, storageError("failed to fetch integrations", err)
+	}
+
+	return integrations, nil
+}
+
+func (s *IntegrationService) Delete(id uint) error {
+	result := internal.DB.Where("id = ?", id).Delete(&gormmodels.InboundIntegration{})
+	if result.Error != nil {
+		return storageError("failed to delete integration", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return newError(ErrNotFound, "integration not found")
+	}
+	return nil
+}
+
+// VerifySignature checks a "sha256=<hex>" HMAC of the raw body against the integration secret.
+func (s *IntegrationService) VerifySignature(integration *gormmodels.InboundIntegration, body []byte, signature string) bool {
+	signature = strings.TrimPrefix(signature, "sha256=")
+	provided, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(integration.Secret))
+	mac.Write(body)
+	return hmac.Equal(provided, mac.Sum(nil))
+}
+
+// MapPayload builds form data from an external payload. Mapping keys are
+// dot-separated paths into the payload and values are template DataKeys.
+// With no mapping configured, top-level payload keys are used as-is.
+func (s *IntegrationService) MapPayload(integration *gormmodels.InboundIntegration, payload map[string]interface{}) map[string]interface{} {
+	if len(integration.FieldMapping) == 0 {
+		return payload
+	}
+
+	formData := make(map[string]interface{}, len(integration.FieldMapping))
+	for path, dataKey := range integration.FieldMapping {
+		if value, ok := lookupPath(payload, path); ok {
+			if err := utils.SetDataPath(formData, dataKey, value); err != nil {
+				// Keys that clash with another mapping's structure stay flat.
+				formData[dataKey] = value
+			}
+		}
+	}
+	return formData
+}
+
+func lookupPath(payload map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = payload
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
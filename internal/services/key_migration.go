@@ -0,0 +1,194 @@
+package services
+
+import (
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// keyMigrationBatchSize is how many submissions are rewritten per batch.
+const keyMigrationBatchSize = 200
+
+// KeyMigration reports what renaming a template's DataKeys changed, or would
+// change when DryRun is set.
+type KeyMigration struct {
+	TemplateID          string            `json:"templateId"`
+	Renames             map[string]string `json:"renames"`
+	DryRun              bool              `json:"dryRun"`
+	FieldsUpdated       int               `json:"fieldsUpdated"`
+	SubmissionsUpdated  int               `json:"submissionsUpdated"`
+	IntegrationsUpdated int               `json:"integrationsUpdated"`
+	DuplicateKeys       bool              `json:"duplicateKeysUpdated"`
+	Version             int               `json:"version,omitempty"`
+}
+
+// MigrateDataKeys renames DataKeys across a template's fields, its duplicate
+// policy, the mappings of its inbound integrations and the data of all its
+// submissions, in one transaction. Fields that already use the new key are
+// left alone, so the migration can also follow a template update that renamed
+// them. With dryRun nothing is written.
+func (s *TemplateService) MigrateDataKeys(templateID string, renames map[string]string, dryRun bool) (*KeyMigration, error) {
+	if err := validateRenames(renames); err != nil {
+		return nil, err
+	}
+
+	result := &KeyMigration{TemplateID: templateID, Renames: renames, DryRun: dryRun}
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		var template gormmodels.Template
+		if err := tx.Select("id", "duplicate_policy", "version").Where("id = ?", templateID).First(&template).Error; err != nil {
+			return err
+		}
+		result.Version = template.Version
+
+		var fields []gormmodels.Field
+		if err := tx.Select("id", "data_key").Where("template_id = ?", templateID).Find(&fields).Error; err != nil {
+			return err
+		}
+		for _, field := range fields {
+			newKey, ok := renames[field.DataKey]
+			if !ok {
+				continue
+			}
+			result.FieldsUpdated++
+			if dryRun {
+				continue
+			}
+			if err := tx.Model(&field).UpdateColumn("data_key", newKey).Error; err != nil {
+				return err
+			}
+		}
+
+		policy := template.DuplicatePolicy
+		policy.Keys = append([]string(nil), policy.Keys...)
+		for i, key := range policy.Keys {
+			if newKey, ok := renames[key]; ok {
+				policy.Keys[i] = newKey
+				result.DuplicateKeys = true
+			}
+		}
+		if result.DuplicateKeys && !dryRun {
+			if err := tx.Model(&template).Select("duplicate_policy").Updates(&gormmodels.Template{DuplicatePolicy: policy}).Error; err != nil {
+				return err
+			}
+		}
+
+		var integrations []gormmodels.InboundIntegration
+		if err := tx.Where("template_id = ?", templateID).Find(&integrations).Error; err != nil {
+			return err
+		}
+		for i := range integrations {
+			integration := &integrations[i]
+			changed := false
+			for path, dataKey := range integration.FieldMapping {
+				if newKey, ok := renames[dataKey]; ok {
+					integration.FieldMapping[path] = newKey
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+			result.IntegrationsUpdated++
+			if dryRun {
+				continue
+			}
+			if err := tx.Model(integration).Select("field_mapping").Updates(integration).Error; err != nil {
+				return err
+			}
+		}
+
+		updated, err := renameSubmissionKeys(tx, templateID, renames, dryRun)
+		if err != nil {
+			return err
+		}
+		result.SubmissionsUpdated = updated
+
+		if !dryRun && (result.FieldsUpdated > 0 || result.DuplicateKeys) {
+			if result.Version, err = bumpVersion(tx, templateID, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, storageError("failed to migrate data keys", err)
+	}
+
+	return result, nil
+}
+
+// validateRenames rejects empty keys, no-op renames and chains where a new key
+// is itself renamed, whose outcome would depend on the order of application.
+func validateRenames(renames map[string]string) error {
+	if len(renames) == 0 {
+		return newError(ErrValidation, "no data keys to rename")
+	}
+	for oldKey, newKey := range renames {
+		if oldKey == "" || newKey == "" {
+			return newError(ErrValidation, "data keys must not be empty")
+		}
+		if oldKey == newKey {
+			return newErrorf(ErrValidation, "data key %q is renamed to itself", oldKey)
+		}
+		if _, chained := renames[newKey]; chained {
+			return newErrorf(ErrValidation, "data key %q is both renamed and a rename target", newKey)
+		}
+	}
+	return nil
+}
+
+// renameSubmissionKeys moves submission data from old keys to new keys across
+// formData, formattingData, htmlData and rawData in batches. Existing values
+// under a new key are never overwritten. It returns the number of submissions
+// changed, or that would change with dryRun.
+func renameSubmissionKeys(tx *gorm.DB, templateID string, renames map[string]string, dryRun bool) (int, error) {
+	updated := 0
+	var batch []gormmodels.FormSubmission
+	err := tx.Select("id", "form_data", "formatting_data", "html_data", "raw_data").
+		Where("template_id = ?", templateID).
+		FindInBatches(&batch, keyMigrationBatchSize, func(btx *gorm.DB, _ int) error {
+			for i := range batch {
+				submission := &batch[i]
+				changed := renameKeys(submission.FormData, renames)
+				changed = renameKeys(submission.FormattingData, renames) || changed
+				changed = renameKeys(submission.HtmlData, renames) || changed
+				changed = renameKeys(submission.RawData, renames) || changed
+				if !changed {
+					continue
+				}
+
+				updated++
+				if dryRun {
+					continue
+				}
+				err := tx.Model(submission).Select("form_data", "formatting_data", "html_data", "raw_data").
+					Updates(submission).Error
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+	return updated, err
+}
+
+// renameKeys moves values between DataKeys, which may be nested paths.
+func renameKeys(data map[string]interface{}, renames map[string]string) bool {
+	changed := false
+	for oldKey, newKey := range renames {
+		value, ok := utils.LookupDataPath(data, oldKey)
+		if !ok || oldKey == newKey {
+			continue
+		}
+		if _, exists := utils.LookupDataPath(data, newKey); !exists {
+			if err := utils.SetDataPath(data, newKey, value); err != nil {
+				continue
+			}
+		}
+		utils.DeleteDataPath(data, oldKey)
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+)
+
+// LayoutVersion is the version of the layout document format.
+const LayoutVersion = 1
+
+var ErrInvalidLayout = newError(ErrValidation, "invalid layout")
+
+// TemplateLayout is a standalone document holding the geometry and
+// formatting of a template's fields, without data semantics.
+type TemplateLayout struct {
+	Version    int           `json:"version"`
+	TemplateID string        `json:"templateId,omitempty"`
+	Fields     []FieldLayout `json:"fields"`
+}
+
+// FieldLayout places one field. Fields are matched by DataKey; Occurrence
+// distinguishes several fields bound to the same key, in page and position order.
+type FieldLayout struct {
+	DataKey        string         `json:"dataKey"`
+	Occurrence     int            `json:"occurrence,omitempty"`
+	PageIndex      int            `json:"pageIndex"`
+	Position       LayoutPosition `json:"position"`
+	FontSize       int            `json:"fontSize,omitempty"`
+	FontWeight     string         `json:"fontWeight,omitempty"`
+	FontStyle      string         `json:"fontStyle,omitempty"`
+	TextDecoration string         `json:"textDecoration,omitempty"`
+	TextColor      string         `json:"textColor,omitempty"`
+	FontFamily     string         `json:"fontFamily,omitempty"`
+	Rotation       int            `json:"rotation,omitempty"`
+	Vertical       bool           `json:"vertical,omitempty"`
+	ClassName      string         `json:"className,omitempty"`
+	Boxes          []BoxLayout    `json:"boxes,omitempty"`
+}
+
+// BoxLayout places one box of a checkbox group. Boxes are matched by Value.
+type BoxLayout struct {
+	Value    string         `json:"value"`
+	Position LayoutPosition `json:"position"`
+}
+
+type LayoutPosition struct {
+	Top    float64 `json:"top"`
+	Left   float64 `json:"left"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// LayoutImportResult reports how a layout was applied.
+type LayoutImportResult struct {
+	Updated   int      `json:"updated"`
+	Unmatched []string `json:"unmatched"`
+	Untouched []string `json:"untouched"`
+}
+
+// layoutColumns are the field columns a layout import may change.
+var layoutColumns = []string{
+	"page_index", "position_top", "position_left", "position_width", "position_height",
+	"font_size", "font_weight", "font_style", "text_decoration", "text_color", "font_family",
+	"rotation", "vertical", "class_name", "boxes",
+}
+
+// ExportLayout extracts the field layout of a template.
+func (s *TemplateService) ExportLayout(template *gormmodels.Template) *TemplateLayout {
+	layout := &TemplateLayout{Version: LayoutVersion, TemplateID: template.ID, Fields: []FieldLayout{}}
+
+	occurrences := make(map[string]int)
+	for _, f := range orderedFields(template.Fields) {
+		layout.Fields = append(layout.Fields, fieldLayout(f, occurrences[f.DataKey]))
+		occurrences[f.DataKey]++
+	}
+	return layout
+}
+
+func fieldLayout(f gormmodels.Field, occurrence int) FieldLayout {
+	return FieldLayout{
+		DataKey:    f.DataKey,
+		Occurrence: occurrence,
+		PageIndex:  f.PageIndex,
+		Position: LayoutPosition{
+			Top:    float64(f.PositionTop),
+			Left:   float64(f.PositionLeft),
+			Width:  float64(f.PositionWidth),
+			Height: float64(f.PositionHeight),
+		},
+		FontSize:       f.FontSize,
+		FontWeight:     f.FontWeight,
+		FontStyle:      f.FontStyle,
+		TextDecoration: f.TextDecoration,
+		TextColor:      f.TextColor,
+		FontFamily:     f.FontFamily,
+		Rotation:       f.Rotation,
+		Vertical:       f.Vertical,
+		ClassName:      f.ClassName,
+		Boxes:          boxLayouts(f.Boxes),
+	}
+}
+
+// ImportLayout applies a layout to the matching fields of a template. Fields
+// are never added or removed; layout entries without a matching field are
+// reported as unmatched, fields without an entry as untouched.
+func (s *TemplateService) ImportLayout(template *gormmodels.Template, layout *TemplateLayout) (*LayoutImportResult, error) {
+	if layout.Version != LayoutVersion {
+		return nil, fmt.Errorf("%w: unsupported layout version %d", ErrInvalidLayout, layout.Version)
+	}
+
+	type fieldKey struct {
+		dataKey    string
+		occurrence int
+	}
+	existing := make(map[fieldKey]gormmodels.Field)
+	occurrences := make(map[string]int)
+	for _, f := range orderedFields(template.Fields) {
+		existing[fieldKey{f.DataKey, occurrences[f.DataKey]}] = f
+		occurrences[f.DataKey]++
+	}
+
+	result := &LayoutImportResult{Unmatched: []string{}, Untouched: []string{}}
+	var updates []gormmodels.Field
+	for _, entry := range layout.Fields {
+		key := fieldKey{entry.DataKey, entry.Occurrence}
+		f, ok := existing[key]
+		if !ok {
+			result.Unmatched = append(result.Unmatched, entry.DataKey)
+			continue
+		}
+		delete(existing, key)
+
+		f.PageIndex = entry.PageIndex
+		f.PositionTop = int(math.Round(entry.Position.Top))
+		f.PositionLeft = int(math.Round(entry.Position.Left))
+		f.PositionWidth = int(math.Round(entry.Position.Width))
+		f.PositionHeight = int(math.Round(entry.Position.Height))
+		f.FontSize = entry.FontSize
+		f.FontWeight = entry.FontWeight
+		f.FontStyle = entry.FontStyle
+		f.TextDecoration = entry.TextDecoration
+		f.TextColor = entry.TextColor
+		f.FontFamily = entry.FontFamily
+		f.Rotation = entry.Rotation
+		f.Vertical = entry.Vertical
+		f.ClassName = entry.ClassName
+		f.Boxes = applyBoxLayouts(f.Boxes, entry.Boxes)
+		updates = append(updates, f)
+	}
+	for _, f := range existing {
+		result.Untouched = append(result.Untouched, f.DataKey)
+	}
+
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range updates {
+			if err := tx.Model(&updates[i]).Select(layoutColumns).Updates(&updates[i]).Error; err != nil {
+				return err
+			}
+		}
+		if _, err := bumpVersion(tx, template.ID, 0); err != nil {
+			return err
+		}
+		return tx.Model(&gormmodels.Template{}).Where("id = ?", template.ID).Update("updated_at", time.Now()).Error
+	})
+	if err != nil {
+		return nil, storageError("failed to import layout", err)
+	}
+
+	result.Updated = len(updates)
+	return result, nil
+}
+
+func boxLayouts(boxes []gormmodels.CheckboxBox) []BoxLayout {
+	if len(boxes) == 0 {
+		return nil
+	}
+	layouts := make([]BoxLayout, len(boxes))
+	for i, box := range boxes {
+		layouts[i] = BoxLayout{
+			Value: box.Value,
+			Position: LayoutPosition{
+				Top:    float64(box.Top),
+				Left:   float64(box.Left),
+				Width:  float64(box.Width),
+				Height: float64(box.Height),
+			},
+		}
+	}
+	return layouts
+}
+
+// applyBoxLayouts moves checkbox boxes to the positions of the layout entries
+// with the same value. Boxes are never added or removed.
+func applyBoxLayouts(boxes []gormmodels.CheckboxBox, layouts []BoxLayout) []gormmodels.CheckboxBox {
+	if len(boxes) == 0 || len(layouts) == 0 {
+		return boxes
+	}
+	byValue := make(map[string]LayoutPosition, len(layouts))
+	for _, layout := range layouts {
+		byValue[layout.Value] = layout.Position
+	}
+
+	moved := append([]gormmodels.CheckboxBox(nil), boxes...)
+	for i, box := range moved {
+		if position, ok := byValue[box.Value]; ok {
+			moved[i].Top = int(math.Round(position.Top))
+			moved[i].Left = int(math.Round(position.Left))
+			moved[i].Width = int(math.Round(position.Width))
+			moved[i].Height = int(math.Round(position.Height))
+		}
+	}
+	return moved
+}
+
+// orderedFields sorts fields by page, then top-to-bottom and left-to-right,
+// so occurrences of a repeated DataKey are numbered consistently.
+func orderedFields(fields []gormmodels.Field) []gormmodels.Field {
+	ordered := append([]gormmodels.Field(nil), fields...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if a.PageIndex != b.PageIndex {
+			return a.PageIndex < b.PageIndex
+		}
+		if a.PositionTop != b.PositionTop {
+			return a.PositionTop < b.PositionTop
+		}
+		return a.PositionLeft < b.PositionLeft
+	})
+	return ordered
+}
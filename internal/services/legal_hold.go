@@ -0,0 +1,95 @@
+package services
+
+import (
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// ErrLegalHold is returned when an action would destroy data under legal hold.
+var ErrLegalHold = newError(ErrConflict, "data is under legal hold")
+
+// LegalHoldService places and releases legal holds on submissions and their
+// generated documents.
+type LegalHoldService struct{}
+
+func NewLegalHoldService() *LegalHoldService {
+	return &LegalHoldService{}
+}
+
+// HoldSubmission places a hold on a submission, or replaces the reason and
+// actor of an existing hold.
+func (s *LegalHoldService) HoldSubmission(id, reason, actor string) error {
+	return s.hold(&gormmodels.FormSubmission{}, "form submission", id, reason, actor)
+}
+
+func (s *LegalHoldService) ReleaseSubmission(id string) error {
+	return s.release(&gormmodels.FormSubmission{}, "form submission", id)
+}
+
+// HoldDocument places a hold on a generated document, or replaces the reason
+// and actor of an existing hold.
+func (s *LegalHoldService) HoldDocument(id, reason, actor string) error {
+	return s.hold(&gormmodels.GeneratedDocument{}, "document", id, reason, actor)
+}
+
+func (s *LegalHoldService) ReleaseDocument(id string) error {
+	return s.release(&gormmodels.GeneratedDocument{}, "document", id)
+}
+
+// hold and release use UpdateColumns so a hold does not change updated_at,
+// which the expiry job measures a draft's age by.
+func (s *LegalHoldService) hold(model interface{}, kind, id, reason, actor string) error {
+	result := internal.DB.Model(model).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"legal_hold_at":     time.Now(),
+		"legal_hold_reason": reason,
+		"legal_hold_by":     actor,
+	})
+	if result.Error != nil {
+		return storageError("failed to place legal hold", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return newErrorf(ErrNotFound, "%s not found", kind)
+	}
+	return nil
+}
+
+func (s *LegalHoldService) release(model interface{}, kind, id string) error {
+	var count int64
+	if err := internal.DB.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+		return storageError("failed to release legal hold", err)
+	}
+	if count == 0 {
+		return newErrorf(ErrNotFound, "%s not found", kind)
+	}
+
+	err := internal.DB.Model(model).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"legal_hold_at":     nil,
+		"legal_hold_reason": "",
+		"legal_hold_by":     "",
+	}).Error
+	if err != nil {
+		return storageError("failed to release legal hold", err)
+	}
+	return nil
+}
+
+// submissionHeld reports whether the submission or any of its documents is
+// under legal hold.
+func submissionHeld(id string) (bool, error) {
+	var held int64
+	err := internal.DB.Model(&gormmodels.FormSubmission{}).Where("id = ? AND legal_hold_at IS NOT NULL", id).Count(&held).Error
+	if err != nil {
+		return false, storageError("failed to check legal hold", err)
+	}
+	if held > 0 {
+		return true, nil
+	}
+
+	err = internal.DB.Model(&gormmodels.GeneratedDocument{}).Where("submission_id = ? AND legal_hold_at IS NOT NULL", id).Count(&held).Error
+	if err != nil {
+		return false, storageError("failed to check legal hold", err)
+	}
+	return held > 0, nil
+}
@@ -0,0 +1,184 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ErrOCRFailed reports that the OCR provider could not read an image.
+var ErrOCRFailed = errors.New("OCR failed")
+
+// DefaultVisionEndpoint is the Google Vision images:annotate endpoint.
+const DefaultVisionEndpoint = "https://vision.googleapis.com/v1/images:annotate"
+
+// OCR image types accepted by the Vision API and the fallback.
+const (
+	OCRMimeJPEG = "image/jpeg"
+	OCRMimePNG  = "image/png"
+	OCRMimeWebP = "image/webp"
+)
+
+// OCRService reads text from images with Google Vision. Requests the usage
+// cap routes to the fallback are read by a local Tesseract binary when one is
+// configured.
+type OCRService struct {
+	mu             sync.RWMutex
+	apiKey         string
+	endpoint       string
+	fallbackBinary string
+	httpClient     *http.Client
+}
+
+func NewOCRService(apiKey, endpoint, fallbackBinary string) *OCRService {
+	if endpoint == "" {
+		endpoint = DefaultVisionEndpoint
+	}
+	return &OCRService{
+		apiKey:         apiKey,
+		endpoint:       endpoint,
+		fallbackBinary: fallbackBinary,
+		httpClient:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Enabled reports whether a Vision API key is configured.
+func (s *OCRService) Enabled() bool {
+	return s.key() != ""
+}
+
+// SetAPIKey rotates the Vision API key; requests already sent keep the old one.
+func (s *OCRService) SetAPIKey(apiKey string) {
+	s.mu.Lock()
+	s.apiKey = apiKey
+	s.mu.Unlock()
+}
+
+func (s *OCRService) key() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.apiKey
+}
+
+// OCRMimeType returns the type of an image the OCR providers accept.
+func OCRMimeType(content []byte) (string, error) {
+	if len(content) == 0 {
+		return "", newError(ErrValidation, "image is empty")
+	}
+	switch mimeType := http.DetectContentType(content); mimeType {
+	case OCRMimeJPEG, OCRMimePNG, OCRMimeWebP:
+		return mimeType, nil
+	}
+	return "", newError(ErrValidation, "image must be a JPEG, PNG or WebP file")
+}
+
+// Text returns the text in the image, read by the provider route names. The
+// fallback route fails with ErrVisionQuotaExceeded when no fallback binary is
+// configured.
+func (s *OCRService) Text(ctx context.Context, image []byte, route VisionRoute) (string, error) {
+	if _, err := OCRMimeType(image); err != nil {
+		return "", err
+	}
+	if route == VisionRouteFallback {
+		if s.fallbackBinary == "" {
+			return "", ErrVisionQuotaExceeded
+		}
+		return s.fallbackText(ctx, image)
+	}
+	return s.visionText(ctx, image)
+}
+
+type visionRequest struct {
+	Requests []visionImageRequest `json:"requests"`
+}
+
+type visionImageRequest struct {
+	Image struct {
+		Content string `json:"content"`
+	} `json:"image"`
+	Features []struct {
+		Type string `json:"type"`
+	} `json:"features"`
+	ImageContext struct {
+		LanguageHints []string `json:"languageHints"`
+	} `json:"imageContext"`
+}
+
+type visionResponse struct {
+	Responses []struct {
+		FullTextAnnotation struct {
+			Text string `json:"text"`
+		} `json:"fullTextAnnotation"`
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"responses"`
+}
+
+func (s *OCRService) visionText(ctx context.Context, image []byte) (string, error) {
+	var request visionImageRequest
+	request.Image.Content = base64.StdEncoding.EncodeToString(image)
+	request.Features = append(request.Features, struct {
+		Type string `json:"type"`
+	}{Type: "DOCUMENT_TEXT_DETECTION"})
+	request.ImageContext.LanguageHints = []string{"th", "en"}
+
+	body, err := json.Marshal(visionRequest{Requests: []visionImageRequest{request}})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"?key="+url.QueryEscape(s.key()), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: vision request failed: %v", ErrOCRFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("%w: vision returned %s: %s", ErrOCRFailed, resp.Status, bytes.TrimSpace(detail))
+	}
+
+	var result visionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("%w: invalid vision response: %v", ErrOCRFailed, err)
+	}
+	if len(result.Responses) == 0 {
+		return "", nil
+	}
+	if result.Responses[0].Error != nil {
+		return "", fmt.Errorf("%w: vision error %d: %s", ErrOCRFailed, result.Responses[0].Error.Code, result.Responses[0].Error.Message)
+	}
+	return result.Responses[0].FullTextAnnotation.Text, nil
+}
+
+// fallbackText runs Tesseract with the Thai and English models, reading the
+// image from stdin and writing the text to stdout.
+func (s *OCRService) fallbackText(ctx context.Context, image []byte) (string, error) {
+	cmd := exec.CommandContext(ctx, s.fallbackBinary, "stdin", "stdout", "-l", "tha+eng")
+	cmd.Stdin = bytes.NewReader(image)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: fallback OCR failed: %v: %s", ErrOCRFailed, err, stderr.String())
+	}
+	return stdout.String(), nil
+}
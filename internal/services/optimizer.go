@@ -0,0 +1,79 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// OptimizeOptions controls a single optimization pass.
+type OptimizeOptions struct {
+	// DPI is the target resolution for downsampled raster images.
+	DPI int
+}
+
+// OptimizeResult reports what the optimization pass achieved.
+type OptimizeResult struct {
+	OriginalSize  int  `json:"originalSize"`
+	OptimizedSize int  `json:"optimizedSize"`
+	Applied       bool `json:"applied"`
+}
+
+// PDFOptimizer rewrites generated PDFs through Ghostscript's pdfwrite device,
+// which recompresses streams, downsamples images and subsets embedded fonts.
+type PDFOptimizer struct {
+	binary     string
+	defaultDPI int
+}
+
+func NewPDFOptimizer(binary string, defaultDPI int) *PDFOptimizer {
+	return &PDFOptimizer{
+		binary:     binary,
+		defaultDPI: defaultDPI,
+	}
+}
+
+// Optimize returns the smaller of the original and optimized PDF.
+func (o *PDFOptimizer) Optimize(ctx context.Context, pdf []byte, opts OptimizeOptions) ([]byte, OptimizeResult, error) {
+	result := OptimizeResult{OriginalSize: len(pdf), OptimizedSize: len(pdf)}
+
+	dpi := opts.DPI
+	if dpi <= 0 {
+		dpi = o.defaultDPI
+	}
+	res := strconv.Itoa(dpi)
+
+	cmd := exec.CommandContext(ctx, o.binary,
+		"-sDEVICE=pdfwrite",
+		"-dCompatibilityLevel=1.5",
+		"-dNOPAUSE", "-dQUIET", "-dBATCH", "-dSAFER",
+		"-dDetectDuplicateImages=true",
+		"-dCompressFonts=true",
+		"-dSubsetFonts=true",
+		"-dEmbedAllFonts=true",
+		"-dDownsampleColorImages=true", "-dColorImageResolution="+res,
+		"-dDownsampleGrayImages=true", "-dGrayImageResolution="+res,
+		"-dDownsampleMonoImages=true", "-dMonoImageResolution="+res,
+		"-sOutputFile=-",
+		"-",
+	)
+	cmd.Stdin = bytes.NewReader(pdf)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return pdf, result, fmt.Errorf("pdf optimization failed: %w: %s", err, stderr.String())
+	}
+
+	optimized := stdout.Bytes()
+	if len(optimized) == 0 || len(optimized) >= len(pdf) {
+		return pdf, result, nil
+	}
+
+	result.OptimizedSize = len(optimized)
+	result.Applied = true
+	return optimized, result, nil
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+type OptionListService struct{}
+
+func NewOptionListService() *OptionListService {
+	return &OptionListService{}
+}
+
+// OptionViolation describes a submitted value that is not in its field's option list.
+type OptionViolation struct {
+	DataKey      string      `json:"dataKey"`
+	Value        interface{} `json:"value"`
+	OptionListID string      `json:"optionListId"`
+}
+
+// visibleOptionLists limits an option list query to the lists of the
+// workspace and those without one.
+func visibleOptionLists(query *gorm.DB, workspaceID string) *gorm.DB {
+	return query.Where("workspace_id = '' OR workspace_id IS NULL OR workspace_id = ?", workspaceID)
+}
+
+// GetAll lists the option lists the workspace may use.
+func (s *OptionListService) GetAll(workspaceID string) ([]gormmodels.OptionList, error) {
+	var lists []gormmodels.OptionList
+
+	err := visibleOptionLists(internal.DB, workspaceID).Order("name ASC").Find(&lists).Error
+	if err != nil {
+		return nil, storageError("failed to fetch option lists", err)
+	}
+
+	return lists, nil
+}
+
+// GetByID returns the option list if the workspace may use it.
+func (s *OptionListService) GetByID(id, workspaceID string) (*gormmodels.OptionList, error) {
+	var list gormmodels.OptionList
+
+	err := visibleOptionLists(internal.DB.Where("id = ?", id), workspaceID).First(&list).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch option list", err)
+	}
+
+	return &list, nil
+}
+
+// GetByIDs returns the requested option lists keyed by ID, whichever
+// workspace they belong to, for resolving the lists a template's fields
+// already reference.
+func (s *OptionListService) GetByIDs(ids []string) (map[string]gormmodels.OptionList, error) {
+	return s.getByIDs(internal.DB, ids)
+}
+
+// GetVisibleByIDs is GetByIDs limited to the lists the workspace may use.
+func (s *OptionListService) GetVisibleByIDs(ids []string, workspaceID string) (map[string]gormmodels.OptionList, error) {
+	return s.getByIDs(visibleOptionLists(internal.DB, workspaceID), ids)
+}
+
+func (s *OptionListService) getByIDs(query *gorm.DB, ids []string) (map[string]gormmodels.OptionList, error) {
+	result := make(map[string]gormmodels.OptionList)
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	var lists []gormmodels.OptionList
+	if err := query.Where("id IN ?", ids).Find(&lists).Error; err != nil {
+		return nil, storageError("failed to fetch option lists", err)
+	}
+
+	for _, list := range lists {
+		result[list.ID] = list
+	}
+	return result, nil
+}
+
+func (s *OptionListService) Create(list *gormmodels.OptionList) error {
+	if err := internal.DB.Create(list).Error; err != nil {
+		return storageError("failed to create option list", err)
+	}
+	return nil
+}
+
+// Update saves the list's name, description and options. The list keeps its
+// workspace.
+func (s *OptionListService) Update(list *gormmodels.OptionList) error {
+	err := internal.DB.Model(list).Where("workspace_id = ?", list.WorkspaceID).Select("name", "description", "options", "updated_at").Updates(list).Error
+	if err != nil {
+		return storageError("failed to update option list", err)
+	}
+	return nil
+}
+
+// Delete removes the option list if the workspace may use it.
+func (s *OptionListService) Delete(id, workspaceID string) error {
+	result := visibleOptionLists(internal.DB.Where("id = ?", id), workspaceID).Delete(&gormmodels.OptionList{})
+	if result.Error != nil {
+		return storageError("failed to delete option list", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return newError(ErrNotFound, "option list not found")
+	}
+	return nil
+}
+
+// CountFields returns how many template fields take their options from the list.
+func (s *OptionListService) CountFields(id string) (int64, error) {
+	var count int64
+
+	err := internal.DB.Model(&gormmodels.Field{}).Where("option_list_id = ?", id).Count(&count).Error
+	if err != nil {
+		return 0, storageError("failed to count fields", err)
+	}
+
+	return count, nil
+}
+
+// Validate checks submitted values of list-backed fields against the current
+// contents of their option lists. Empty values are left to required-field checks.
+func (s *OptionListService) Validate(templateID string, data map[string]interface{}) ([]OptionViolation, error) {
+	var fields []gormmodels.Field
+	err := internal.DB.Where("template_id = ? AND option_list_id <> ''", templateID).Find(&fields).Error
+	if err != nil {
+		return nil, storageError("failed to fetch fields", err)
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(fields))
+	for _, field := range fields {
+		ids = append(ids, field.OptionListID)
+	}
+	lists, err := s.GetByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []OptionViolation
+	for _, field := range fields {
+		value, ok := utils.LookupDataPath(data, field.DataKey)
+		if !ok || value == nil || value == "" {
+			continue
+		}
+
+		list, ok := lists[field.OptionListID]
+		str, isString := value.(string)
+		if !ok || !isString || !list.Contains(str) {
+			violations = append(violations, OptionViolation{
+				DataKey:      field.DataKey,
+				Value:        value,
+				OptionListID: field.OptionListID,
+			})
+		}
+	}
+
+	return violations, nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PageConverter turns the pages of a PDF into page backgrounds. Each page
+// becomes an SVG document sized to the page in CSS pixels, so it is drawn
+// like an uploaded SVG.
+type PageConverter interface {
+	// ConvertPages returns the SVG of every page, failing with a validation
+	// error when the PDF cannot be read or has more than maxPages pages.
+	ConvertPages(ctx context.Context, pdf []byte, maxPages int) ([][]byte, error)
+}
+
+// PDFToCairoConverter rasterizes pages with Poppler's pdftocairo and embeds
+// each page image in an SVG. Rasterizing keeps scanned government forms and
+// forms with unusual fonts looking exactly as printed.
+type PDFToCairoConverter struct {
+	binary string
+	dpi    int
+}
+
+// NewPDFToCairoConverter returns a converter rendering pages at dpi; values
+// below 72 use 150.
+func NewPDFToCairoConverter(binary string, dpi int) *PDFToCairoConverter {
+	if dpi < 72 {
+		dpi = 150
+	}
+	return &PDFToCairoConverter{binary: binary, dpi: dpi}
+}
+
+func (c *PDFToCairoConverter) ConvertPages(ctx context.Context, pdf []byte, maxPages int) ([][]byte, error) {
+	dir, err := os.MkdirTemp("", "pdf-pages-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	// One page past the limit is rendered to tell a PDF at the limit from a
+	// longer one.
+	cmd := exec.CommandContext(ctx, c.binary, "-png", "-r", strconv.Itoa(c.dpi), "-l", strconv.Itoa(maxPages+1), "-", filepath.Join(dir, "page"))
+	cmd.Stdin = bytes.NewReader(pdf)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, newErrorf(ErrValidation, "the file could not be read as a PDF: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	images, err := pageImages(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, newError(ErrValidation, "the PDF has no pages")
+	}
+	if len(images) > maxPages {
+		return nil, newErrorf(ErrValidation, "the PDF has more than %d pages", maxPages)
+	}
+
+	pages := make([][]byte, len(images))
+	for i, path := range images {
+		png, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if pages[i], err = c.pageSVG(png); err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+	}
+	return pages, nil
+}
+
+// pageImages lists the images pdftocairo wrote in page order. Their names are
+// "page-N.png", with N zero-padded to the width of the last page number.
+func pageImages(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "page-*.png"))
+	if err != nil {
+		return nil, err
+	}
+	pageNumber := func(path string) int {
+		n, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "page-"), ".png"))
+		return n
+	}
+	sort.Slice(paths, func(i, j int) bool { return pageNumber(paths[i]) < pageNumber(paths[j]) })
+	return paths, nil
+}
+
+// pageSVG embeds a page image in an SVG of the page's size at 96 pixels per
+// inch, the scale field positions are measured in.
+func (c *PDFToCairoConverter) pageSVG(png []byte) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(png))
+	if err != nil {
+		return nil, err
+	}
+	width := float64(config.Width) * 96 / float64(c.dpi)
+	height := float64(config.Height) * 96 / float64(c.dpi)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%.2f" height="%.2f" viewBox="0 0 %.2f %.2f">`, width, height, width, height)
+	fmt.Fprintf(&b, `<image x="0" y="0" width="%.2f" height="%.2f" preserveAspectRatio="none" xlink:href="data:image/png;base64,`, width, height)
+	b.WriteString(base64.StdEncoding.EncodeToString(png))
+	b.WriteString(`"/></svg>`)
+	return b.Bytes(), nil
+}
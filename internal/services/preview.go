@@ -0,0 +1,137 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log"
+
+	"github.com/dhanavadh/fastfill-backend/internal/storage"
+
+	"github.com/chromedp/chromedp"
+)
+
+// Limits for rasterized page previews. Widths are rounded up to a multiple of
+// previewWidthStep so the cache holds a bounded number of sizes per page.
+const (
+	PreviewMinWidth     = 100
+	PreviewMaxWidth     = 2400
+	PreviewDefaultWidth = 800
+	previewWidthStep    = 100
+	previewCachePrefix  = "previews/"
+)
+
+// PreviewService rasterizes SVG page backgrounds to PNG for clients that
+// cannot render large SVGs, caching the results in the bucket.
+type PreviewService struct {
+	storageClient storage.StorageClient
+	uploadService *UploadService
+}
+
+func NewPreviewService(storageClient storage.StorageClient, uploadService *UploadService) *PreviewService {
+	return &PreviewService{
+		storageClient: storageClient,
+		uploadService: uploadService,
+	}
+}
+
+// PagePreview is a rasterized page. ETag changes whenever the page's SVG is replaced.
+type PagePreview struct {
+	Data  []byte
+	Width int
+	ETag  string
+}
+
+// NormalizePreviewWidth clamps a requested width and rounds it up to the cache step.
+func NormalizePreviewWidth(width int) int {
+	if width <= 0 {
+		width = PreviewDefaultWidth
+	}
+	if width < PreviewMinWidth {
+		width = PreviewMinWidth
+	}
+	if width > PreviewMaxWidth {
+		width = PreviewMaxWidth
+	}
+	return (width + previewWidthStep - 1) / previewWidthStep * previewWidthStep
+}
+
+// PagePNG returns the PNG preview of a template page, rendering and caching it
+// on first use. It returns nil if the page has no SVG background. When
+// ifNoneMatch equals the current ETag the preview is returned without data.
+func (s *PreviewService) PagePNG(ctx context.Context, templateID string, pageIndex, width int, ifNoneMatch string) (*PagePreview, error) {
+	svgFile, err := s.uploadService.GetSVGFileByPage(templateID, pageIndex)
+	if err != nil {
+		return nil, err
+	}
+	if svgFile == nil {
+		return nil, nil
+	}
+
+	width = NormalizePreviewWidth(width)
+	preview := &PagePreview{
+		Width: width,
+		ETag:  fmt.Sprintf(`"%d-%d"`, svgFile.ID, width),
+	}
+	if ifNoneMatch == preview.ETag {
+		return preview, nil
+	}
+
+	// The SVG file ID is part of the key, so re-uploading a page bypasses stale entries.
+	objectName := fmt.Sprintf("%s%s/%d_w%d.png", previewCachePrefix, templateID, svgFile.ID, width)
+	if cached, err := s.storageClient.ReadFile(ctx, objectName); err == nil && len(cached) > 0 {
+		preview.Data = cached
+		return preview, nil
+	}
+
+	content, err := s.uploadService.GetSVGFileContent(svgFile)
+	if err != nil {
+		return nil, storageError("failed to read SVG content", err)
+	}
+
+	png, err := rasterizeSVG(ctx, content, width)
+	if err != nil {
+		return nil, err
+	}
+	preview.Data = png
+
+	if _, err := s.storageClient.UploadFile(ctx, bytes.NewReader(png), objectName, "image/png"); err != nil {
+		log.Printf("Warning: failed to cache page preview %s: %v", objectName, err)
+	}
+
+	return preview, nil
+}
+
+// rasterizeSVG renders an SVG at the given pixel width with headless Chrome.
+func rasterizeSVG(ctx context.Context, svg []byte, width int) ([]byte, error) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("headless", true),
+		chromedp.Flag("disable-gpu", true),
+		chromedp.Flag("no-sandbox", true),
+		chromedp.Flag("disable-dev-shm-usage", true),
+	)
+
+	allocCtx, cancel := chromedp.NewExecAllocator(ctx, opts...)
+	defer cancel()
+
+	chromeCtx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+
+	html := fmt.Sprintf(`<!DOCTYPE html><html><head><style>html,body{margin:0;padding:0;background:#fff}img{display:block;width:%dpx}</style></head><body><img src="data:image/svg+xml;base64,%s"></body></html>`,
+		width, base64.StdEncoding.EncodeToString(svg))
+
+	var png []byte
+	err := chromedp.Run(chromeCtx,
+		chromedp.EmulateViewport(int64(width), 1),
+		chromedp.Navigate("data:text/html;base64,"+base64.StdEncoding.EncodeToString([]byte(html))),
+		chromedp.WaitReady("img"),
+		// Quality 100 produces PNG rather than JPEG.
+		chromedp.FullScreenshot(&png, 100),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rasterize SVG: %w", err)
+	}
+
+	return png, nil
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// maxPreviewSessions bounds the live preview sessions one instance keeps.
+const maxPreviewSessions = 1000
+
+// Page image widths accepted for live previews.
+const (
+	DefaultPreviewSessionWidth = 800
+	MaxPreviewSessionWidth     = 1600
+)
+
+// PreviewValues are the values a live preview renders, keyed by DataKey as in
+// a PDF generation request.
+type PreviewValues struct {
+	Data           map[string]interface{} `json:"data"`
+	FormattingData map[string]interface{} `json:"formattingData"`
+	HTMLData       map[string]interface{} `json:"htmlData"`
+}
+
+// PreviewPageRenderer renders one page of a resolved template as a PNG image
+// width pixels wide. backgrounds caches page backgrounds between calls of
+// one session; the renderer fills it the first time a page is drawn.
+type PreviewPageRenderer func(ctx context.Context, template *gormmodels.Template, pageIndex int, values PreviewValues, backgrounds map[int]string, width int) ([]byte, error)
+
+// PreviewTemplateResolver returns a template as it renders, with the pages
+// and fields of its includes.
+type PreviewTemplateResolver func(template *gormmodels.Template) (*gormmodels.Template, error)
+
+// PreviewPage is one rendered page of a live preview.
+type PreviewPage struct {
+	PageIndex int    `json:"pageIndex"`
+	Image     []byte `json:"image"`
+}
+
+// PreviewUpdate is the outcome of a change to a live preview. A superseded
+// update was overtaken by a later one within the debounce window; the later
+// update renders the pages of both.
+type PreviewUpdate struct {
+	Revision   int           `json:"revision"`
+	Superseded bool          `json:"superseded"`
+	Pages      []PreviewPage `json:"pages"`
+	ExpiresAt  time.Time     `json:"expiresAt"`
+}
+
+// PreviewSession is a template resolved once, with its page backgrounds and
+// current values, so that each keystroke re-renders only the pages it touches.
+type PreviewSession struct {
+	ID          string
+	WorkspaceID string
+	TemplateID  string
+
+	template *gormmodels.Template
+	width    int
+	// pagesByKey lists the pages each DataKey is printed on.
+	pagesByKey map[string][]int
+	pages      []int
+
+	mu        sync.Mutex
+	values    PreviewValues
+	dirty     map[int]bool
+	revision  int
+	expiresAt time.Time
+
+	// renderMu serializes renders and guards backgrounds.
+	renderMu    sync.Mutex
+	backgrounds map[int]string
+}
+
+// ExpiresAt returns when the session is dropped unless it is used again.
+func (s *PreviewSession) ExpiresAt() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expiresAt
+}
+
+// PreviewSessionService keeps live preview sessions in memory, so a session
+// only works against the instance that created it. Sessions expire ttl after
+// their last use. Updates wait debounce for a later update before rendering.
+type PreviewSessionService struct {
+	templateService *TemplateService
+	resolve         PreviewTemplateResolver
+	render          PreviewPageRenderer
+	ttl             time.Duration
+	debounce        time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*PreviewSession
+}
+
+func NewPreviewSessionService(templateService *TemplateService, resolve PreviewTemplateResolver, render PreviewPageRenderer, ttl, debounce time.Duration) *PreviewSessionService {
+	return &PreviewSessionService{
+		templateService: templateService,
+		resolve:         resolve,
+		render:          render,
+		ttl:             ttl,
+		debounce:        debounce,
+		sessions:        make(map[string]*PreviewSession),
+	}
+}
+
+// NormalizePreviewSessionWidth clamps a requested page image width.
+func NormalizePreviewSessionWidth(width int) int {
+	if width <= 0 {
+		return DefaultPreviewSessionWidth
+	}
+	return min(max(width, 100), MaxPreviewSessionWidth)
+}
+
+// Create starts a session for the template and renders every page with the
+// initial values. The caller checks access to the template.
+func (s *PreviewSessionService) Create(ctx context.Context, workspaceID, templateID string, values PreviewValues, width int) (*PreviewSession, []PreviewPage, error) {
+	template, err := s.templateService.GetByID(templateID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if template == nil {
+		return nil, nil, ErrTemplateNotFound
+	}
+	template, err = s.resolve(template)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session := &PreviewSession{
+		ID:          id,
+		WorkspaceID: workspaceID,
+		TemplateID:  templateID,
+		template:    template,
+		width:       NormalizePreviewSessionWidth(width),
+		pagesByKey:  make(map[string][]int),
+		values:      PreviewValues{Data: map[string]interface{}{}, FormattingData: map[string]interface{}{}, HTMLData: map[string]interface{}{}},
+		dirty:       make(map[int]bool),
+		backgrounds: make(map[int]string),
+	}
+	session.indexPages()
+	session.values.merge(values)
+
+	pages, err := s.renderPages(ctx, session, session.pages, session.values.clone())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.add(session); err != nil {
+		return nil, nil, err
+	}
+	return session, pages, nil
+}
+
+// Get returns the workspace's session, or nil if it does not exist, has
+// expired or belongs to another workspace.
+func (s *PreviewSessionService) Get(id, workspaceID string) *PreviewSession {
+	s.mu.Lock()
+	session, ok := s.sessions[id]
+	s.mu.Unlock()
+	if !ok || session.WorkspaceID != workspaceID {
+		return nil
+	}
+	if time.Now().After(session.ExpiresAt()) {
+		s.Close(id)
+		return nil
+	}
+	return session
+}
+
+// Update applies changed values to the session and renders the pages they
+// appear on. A nil value removes the key. If another update arrives within
+// the debounce window this one is superseded and renders nothing; pages
+// changed by superseded updates are rendered by the next update that is not.
+func (s *PreviewSessionService) Update(ctx context.Context, session *PreviewSession, changes PreviewValues) (*PreviewUpdate, error) {
+	session.mu.Lock()
+	for _, key := range changes.keys() {
+		for _, page := range session.pagesOf(key) {
+			session.dirty[page] = true
+		}
+	}
+	session.values.merge(changes)
+	session.revision++
+	revision := session.revision
+	session.expiresAt = time.Now().Add(s.ttl)
+	session.mu.Unlock()
+
+	if s.debounce > 0 {
+		timer := time.NewTimer(s.debounce)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	session.mu.Lock()
+	if session.revision != revision {
+		expiresAt := session.expiresAt
+		session.mu.Unlock()
+		return &PreviewUpdate{Revision: revision, Superseded: true, Pages: []PreviewPage{}, ExpiresAt: expiresAt}, nil
+	}
+	pages := make([]int, 0, len(session.dirty))
+	for page := range session.dirty {
+		pages = append(pages, page)
+	}
+	sort.Ints(pages)
+	session.dirty = make(map[int]bool)
+	values := session.values.clone()
+	session.mu.Unlock()
+
+	rendered, err := s.renderPages(ctx, session, pages, values)
+	if err != nil {
+		// Leave the pages dirty so the next update renders them again.
+		session.mu.Lock()
+		for _, page := range pages {
+			session.dirty[page] = true
+		}
+		session.mu.Unlock()
+		return nil, err
+	}
+
+	return &PreviewUpdate{Revision: revision, Pages: rendered, ExpiresAt: session.ExpiresAt()}, nil
+}
+
+// Close drops a session.
+func (s *PreviewSessionService) Close(id string) {
+	s.mu.Lock()
+	delete(s.sessions, id)
+	s.mu.Unlock()
+}
+
+func (s *PreviewSessionService) add(session *PreviewSession) error {
+	now := time.Now()
+	session.expiresAt = now.Add(s.ttl)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.sessions) >= maxPreviewSessions {
+		for id, existing := range s.sessions {
+			if now.After(existing.ExpiresAt()) {
+				delete(s.sessions, id)
+			}
+		}
+	}
+	if len(s.sessions) >= maxPreviewSessions {
+		return newError(ErrConflict, "too many live preview sessions are open; retry shortly")
+	}
+	s.sessions[session.ID] = session
+	return nil
+}
+
+func (s *PreviewSessionService) renderPages(ctx context.Context, session *PreviewSession, pages []int, values PreviewValues) ([]PreviewPage, error) {
+	session.renderMu.Lock()
+	defer session.renderMu.Unlock()
+
+	rendered := make([]PreviewPage, 0, len(pages))
+	for _, page := range pages {
+		image, err := s.render(ctx, session.template, page, values, session.backgrounds, session.width)
+		if err != nil {
+			return nil, err
+		}
+		rendered = append(rendered, PreviewPage{PageIndex: page, Image: image})
+	}
+	return rendered, nil
+}
+
+// indexPages records the pages of the template and the pages each DataKey
+// is printed on. Templates without page files render as a single page.
+func (s *PreviewSession) indexPages() {
+	if len(s.template.SVGFiles) == 0 {
+		s.pages = []int{0}
+		for _, field := range s.template.Fields {
+			s.pagesByKey[field.DataKey] = []int{0}
+		}
+		return
+	}
+
+	seen := make(map[int]bool)
+	for _, svgFile := range s.template.SVGFiles {
+		seen[svgFile.PageIndex] = true
+	}
+	for _, field := range s.template.Fields {
+		seen[field.PageIndex] = true
+		pages := s.pagesByKey[field.DataKey]
+		if len(pages) == 0 || pages[len(pages)-1] != field.PageIndex {
+			s.pagesByKey[field.DataKey] = append(pages, field.PageIndex)
+		}
+	}
+	for page := range seen {
+		s.pages = append(s.pages, page)
+	}
+	sort.Ints(s.pages)
+}
+
+// pagesOf returns the pages of the fields a top-level key feeds: the fields
+// bound to it and, for nested values, those bound to a path below it.
+func (s *PreviewSession) pagesOf(key string) []int {
+	var pages []int
+	for dataKey, keyPages := range s.pagesByKey {
+		if dataKey == key || strings.HasPrefix(dataKey, key+".") || strings.HasPrefix(dataKey, key+"[") {
+			pages = append(pages, keyPages...)
+		}
+	}
+	return pages
+}
+
+// merge sets the keys of changes in v; nil values delete the key.
+func (v *PreviewValues) merge(changes PreviewValues) {
+	mergeValues(v.Data, changes.Data)
+	mergeValues(v.FormattingData, changes.FormattingData)
+	mergeValues(v.HTMLData, changes.HTMLData)
+}
+
+func mergeValues(values, changes map[string]interface{}) {
+	for key, value := range changes {
+		if value == nil {
+			delete(values, key)
+			continue
+		}
+		values[key] = value
+	}
+}
+
+// keys returns the DataKeys changes touch.
+func (v PreviewValues) keys() []string {
+	var keys []string
+	for _, values := range []map[string]interface{}{v.Data, v.FormattingData, v.HTMLData} {
+		for key := range values {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (v PreviewValues) clone() PreviewValues {
+	return PreviewValues{
+		Data:           cloneValues(v.Data),
+		FormattingData: cloneValues(v.FormattingData),
+		HTMLData:       cloneValues(v.HTMLData),
+	}
+}
+
+func cloneValues(values map[string]interface{}) map[string]interface{} {
+	cloned := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		cloned[key] = value
+	}
+	return cloned
+}
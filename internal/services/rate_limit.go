@@ -0,0 +1,75 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter allows up to limit requests per key in each fixed window.
+// It is kept in memory, so limits apply per server instance.
+type RateLimiter struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*rateWindow
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:   limit,
+		window:  window,
+		entries: make(map[string]*rateWindow),
+	}
+}
+
+// SetLimit changes the number of requests allowed per window. Windows
+// already open keep their counts.
+func (l *RateLimiter) SetLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+}
+
+// Allow counts a request for key. When the key is over its limit it returns
+// false and how long until the window resets. A non-positive limit allows everything.
+func (l *RateLimiter) Allow(key string) (time.Duration, bool) {
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit <= 0 {
+		return 0, true
+	}
+
+	entry, ok := l.entries[key]
+	if !ok || now.Sub(entry.start) >= l.window {
+		l.pruneLocked(now)
+		entry = &rateWindow{start: now}
+		l.entries[key] = entry
+	}
+
+	if entry.count >= l.limit {
+		return entry.start.Add(l.window).Sub(now), false
+	}
+	entry.count++
+	return 0, true
+}
+
+// pruneLocked drops windows that have ended. The caller must hold l.mu.
+func (l *RateLimiter) pruneLocked(now time.Time) {
+	if len(l.entries) < 1024 {
+		return
+	}
+	for key, entry := range l.entries {
+		if now.Sub(entry.start) >= l.window {
+			delete(l.entries, key)
+		}
+	}
+}
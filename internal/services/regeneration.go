@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DocumentRenderer renders a submission against its template's current
+// definition, returning the intermediate HTML and the resulting PDF.
+type DocumentRenderer func(ctx context.Context, submission *gormmodels.FormSubmission) (string, []byte, error)
+
+const maxRecordedJobErrors = 50
+
+type RegenerationService struct {
+	documentService *DocumentService
+	formService     *FormService
+	render          DocumentRenderer
+	queue           chan string
+}
+
+func NewRegenerationService(documentService *DocumentService, formService *FormService, render DocumentRenderer) *RegenerationService {
+	s := &RegenerationService{
+		documentService: documentService,
+		formService:     formService,
+		render:          render,
+		queue:           make(chan string, 100),
+	}
+	go s.worker()
+	return s
+}
+
+// Enqueue creates a job for the given submissions of a template. With no
+// submission IDs, every submission of the template is regenerated.
+func (s *RegenerationService) Enqueue(templateID string, submissionIDs []string) (*gormmodels.RegenerationJob, error) {
+	if len(submissionIDs) == 0 {
+		err := internal.DB.Model(&gormmodels.FormSubmission{}).Where("template_id = ?", templateID).
+			Order("created_at ASC").Pluck("id", &submissionIDs).Error
+		if err != nil {
+			return nil, storageError("failed to list submissions", err)
+		}
+	} else {
+		var owned []string
+		err := internal.DB.Model(&gormmodels.FormSubmission{}).
+			Where("template_id = ? AND id IN ?", templateID, submissionIDs).Pluck("id", &owned).Error
+		if err != nil {
+			return nil, storageError("failed to list submissions", err)
+		}
+		if len(owned) != len(submissionIDs) {
+			return nil, newErrorf(ErrValidation, "%d of the selected submissions do not belong to template %s", len(submissionIDs)-len(owned), templateID)
+		}
+	}
+
+	job := &gormmodels.RegenerationJob{
+		ID:            uuid.New().String(),
+		TemplateID:    templateID,
+		Status:        "queued",
+		SubmissionIDs: submissionIDs,
+		Total:         len(submissionIDs),
+	}
+
+	if err := internal.DB.Create(job).Error; err != nil {
+		return nil, storageError("failed to create regeneration job", err)
+	}
+
+	select {
+	case s.queue <- job.ID:
+	default:
+		internal.DB.Model(job).Updates(map[string]interface{}{"status": "failed", "errors": []string{"queue is full"}})
+		return nil, fmt.Errorf("regeneration queue is full, try again later")
+	}
+
+	return job, nil
+}
+
+func (s *RegenerationService) GetByID(id string) (*gormmodels.RegenerationJob, error) {
+	var job gormmodels.RegenerationJob
+
+	err := internal.DB.Where("id = ?", id).First(&job).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch regeneration job", err)
+	}
+
+	return &job, nil
+}
+
+func (s *RegenerationService) worker() {
+	for jobID := range s.queue {
+		if err := s.run(jobID); err != nil {
+			log.Printf("Regeneration job %s failed: %v", jobID, err)
+		}
+	}
+}
+
+func (s *RegenerationService) run(jobID string) error {
+	job, err := s.GetByID(jobID)
+	if err != nil || job == nil {
+		return fmt.Errorf("failed to load job: %v", err)
+	}
+
+	now := time.Now()
+	job.Status = "running"
+	job.StartedAt = &now
+	internal.DB.Save(job)
+
+	for _, submissionID := range job.SubmissionIDs {
+		changed, err := s.regenerate(submissionID)
+		job.Processed++
+		switch {
+		case err != nil:
+			job.Failed++
+			if len(job.Errors) < maxRecordedJobErrors {
+				job.Errors = append(job.Errors, fmt.Sprintf("%s: %v", submissionID, err))
+			}
+		case changed:
+			job.Changed++
+		default:
+			job.Unchanged++
+		}
+		internal.DB.Model(job).Select("processed", "changed", "unchanged", "failed", "errors").Updates(job)
+	}
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.Status = "completed"
+	if job.Failed > 0 {
+		job.Status = "completed_with_errors"
+	}
+	return internal.DB.Save(job).Error
+}
+
+func (s *RegenerationService) regenerate(submissionID string) (bool, error) {
+	submission, err := s.formService.GetByID(submissionID)
+	if err != nil {
+		return false, err
+	}
+	if submission == nil {
+		return false, newError(ErrNotFound, "submission not found")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	htmlContent, pdfBytes, err := s.render(ctx, submission)
+	if err != nil {
+		return false, err
+	}
+
+	_, changed, err := s.documentService.Store(ctx, submission, htmlContent, pdfBytes)
+	return changed, err
+}
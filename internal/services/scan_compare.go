@@ -0,0 +1,295 @@
+package services
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"math"
+)
+
+const (
+	// MaxScanPixels bounds the images a scan comparison decodes.
+	MaxScanPixels = 40_000_000
+	// inkThreshold is the gray level below which a scan pixel counts as ink.
+	inkThreshold = 150
+	// formBleed widens the printed form around its lines so that small
+	// scanning offsets are not mistaken for filled-in ink.
+	formBleed = 3
+	// minInkPixels is the least ink that marks a field as filled.
+	minInkPixels = 12
+	// alignedInside is the share of a field's ink that must fall inside its
+	// box for the field to count as aligned.
+	alignedInside = 0.85
+)
+
+// Scan field statuses.
+const (
+	ScanFieldAligned    = "aligned"
+	ScanFieldMisaligned = "misaligned"
+	ScanFieldEmpty      = "empty"
+)
+
+// ScanBox is a field box to check on a scan, in page pixels.
+type ScanBox struct {
+	Label  string
+	Top    int
+	Left   int
+	Width  int
+	Height int
+}
+
+// ScanRect is a rectangle in page pixels.
+type ScanRect struct {
+	Top    int `json:"top"`
+	Left   int `json:"left"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// ScanFieldResult measures how the ink written for a field sits relative to
+// its box. Offsets are from the centre of the box to the centre of the ink;
+// overflow is how far the ink reaches past each edge.
+type ScanFieldResult struct {
+	Field     string    `json:"field"`
+	Box       ScanRect  `json:"box"`
+	Ink       *ScanRect `json:"ink,omitempty"`
+	InkPixels int       `json:"inkPixels"`
+	// Inside is the share of the ink found around the box that lies in it.
+	Inside   float64      `json:"inside"`
+	OffsetX  float64      `json:"offsetX"`
+	OffsetY  float64      `json:"offsetY"`
+	Overflow ScanOverflow `json:"overflow"`
+	Status   string       `json:"status"`
+}
+
+type ScanOverflow struct {
+	Top    int `json:"top"`
+	Right  int `json:"right"`
+	Bottom int `json:"bottom"`
+	Left   int `json:"left"`
+}
+
+// ScanComparison is the outcome of comparing a scanned filled form with the
+// field boxes of one template page.
+type ScanComparison struct {
+	Fields     []ScanFieldResult `json:"fields"`
+	Aligned    int               `json:"aligned"`
+	Misaligned int               `json:"misaligned"`
+	Empty      int               `json:"empty"`
+	// MeanInside averages Inside over the filled fields.
+	MeanInside float64 `json:"meanInside"`
+	// MeanOffsetX and MeanOffsetY average the offsets of the filled fields;
+	// a large shared offset usually means the page needs calibrating.
+	MeanOffsetX float64 `json:"meanOffsetX"`
+	MeanOffsetY float64 `json:"meanOffsetY"`
+	// BackgroundSubtracted is false when no blank rendering of the page was
+	// available, so printed form content may be counted as ink.
+	BackgroundSubtracted bool   `json:"backgroundSubtracted"`
+	Overlay              []byte `json:"overlay"`
+}
+
+// DecodeScan decodes a PNG or JPEG scan, rejecting images too large to
+// process.
+func DecodeScan(content []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, newError(ErrValidation, "scan must be a PNG or JPEG image")
+	}
+	if config.Width*config.Height > MaxScanPixels {
+		return nil, newErrorf(ErrValidation, "scan is %dx%d pixels; at most %d megapixels are supported", config.Width, config.Height, MaxScanPixels/1_000_000)
+	}
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, newErrorf(ErrValidation, "failed to decode scan: %v", err)
+	}
+	return img, nil
+}
+
+// CompareScan measures how the ink on a scanned filled form lines up with
+// the field boxes of the page. The scan is stretched to width x height page
+// pixels, so it should be cropped to the edges of the paper. blank, a
+// rendering of the page without values, is subtracted so that only the
+// filled-in ink is measured; it may be nil. The overlay is the scan with the
+// boxes drawn over it: green when aligned, red when not, gray when empty,
+// and the ink found for each field in blue.
+func CompareScan(scan, blank image.Image, boxes []ScanBox, width, height int) (*ScanComparison, error) {
+	page := grayscale(scan, width, height)
+	ink := make([]bool, width*height)
+	for i, level := range page.Pix {
+		ink[i] = level < inkThreshold
+	}
+
+	comparison := &ScanComparison{Fields: []ScanFieldResult{}}
+	if blank != nil {
+		form := grayscale(blank, width, height)
+		for i, level := range form.Pix {
+			if level >= inkThreshold {
+				continue
+			}
+			x, y := i%width, i/width
+			for dy := -formBleed; dy <= formBleed; dy++ {
+				for dx := -formBleed; dx <= formBleed; dx++ {
+					if px, py := x+dx, y+dy; px >= 0 && py >= 0 && px < width && py < height {
+						ink[py*width+px] = false
+					}
+				}
+			}
+		}
+		comparison.BackgroundSubtracted = true
+	}
+
+	overlay := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(overlay, overlay.Bounds(), page, image.Point{}, draw.Src)
+
+	filled := 0
+	for _, box := range boxes {
+		result := measureField(ink, width, height, box)
+		switch result.Status {
+		case ScanFieldAligned:
+			comparison.Aligned++
+		case ScanFieldMisaligned:
+			comparison.Misaligned++
+		default:
+			comparison.Empty++
+		}
+		if result.Status != ScanFieldEmpty {
+			filled++
+			comparison.MeanInside += result.Inside
+			comparison.MeanOffsetX += result.OffsetX
+			comparison.MeanOffsetY += result.OffsetY
+		}
+		drawResult(overlay, result)
+		comparison.Fields = append(comparison.Fields, result)
+	}
+	if filled > 0 {
+		comparison.MeanInside = round2(comparison.MeanInside / float64(filled))
+		comparison.MeanOffsetX = round2(comparison.MeanOffsetX / float64(filled))
+		comparison.MeanOffsetY = round2(comparison.MeanOffsetY / float64(filled))
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, overlay); err != nil {
+		return nil, err
+	}
+	comparison.Overlay = buf.Bytes()
+	return comparison, nil
+}
+
+// measureField looks for ink in the box and a margin around it as large as
+// the box is high, the distance handwriting or a misplaced field typically
+// strays.
+func measureField(ink []bool, width, height int, box ScanBox) ScanFieldResult {
+	result := ScanFieldResult{
+		Field:  box.Label,
+		Box:    ScanRect{Top: box.Top, Left: box.Left, Width: box.Width, Height: box.Height},
+		Status: ScanFieldEmpty,
+	}
+
+	margin := max(box.Height, 10)
+	x0, y0 := max(box.Left-margin, 0), max(box.Top-margin, 0)
+	x1, y1 := min(box.Left+box.Width+margin, width), min(box.Top+box.Height+margin, height)
+
+	inside := 0
+	minX, minY, maxX, maxY := width, height, -1, -1
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			if !ink[y*width+x] {
+				continue
+			}
+			result.InkPixels++
+			if x >= box.Left && x < box.Left+box.Width && y >= box.Top && y < box.Top+box.Height {
+				inside++
+			}
+			minX, minY, maxX, maxY = min(minX, x), min(minY, y), max(maxX, x), max(maxY, y)
+		}
+	}
+	if result.InkPixels < minInkPixels {
+		return result
+	}
+
+	result.Ink = &ScanRect{Top: minY, Left: minX, Width: maxX - minX + 1, Height: maxY - minY + 1}
+	result.Inside = round2(float64(inside) / float64(result.InkPixels))
+	result.OffsetX = float64(minX+maxX+1)/2 - (float64(box.Left) + float64(box.Width)/2)
+	result.OffsetY = float64(minY+maxY+1)/2 - (float64(box.Top) + float64(box.Height)/2)
+	result.Overflow = ScanOverflow{
+		Top:    max(box.Top-minY, 0),
+		Right:  max(maxX+1-(box.Left+box.Width), 0),
+		Bottom: max(maxY+1-(box.Top+box.Height), 0),
+		Left:   max(box.Left-minX, 0),
+	}
+
+	result.Status = ScanFieldAligned
+	if result.Inside < alignedInside || math.Abs(result.OffsetY) > float64(box.Height)/2 {
+		result.Status = ScanFieldMisaligned
+	}
+	return result
+}
+
+// grayscale scales img to width x height, averaging the source pixels that
+// fall into each target pixel so thin strokes survive downscaling.
+func grayscale(img image.Image, width, height int) *image.Gray {
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	sums := make([]uint64, width*height)
+	counts := make([]uint32, width*height)
+	for y := 0; y < sh; y++ {
+		ty := y * height / sh
+		for x := 0; x < sw; x++ {
+			i := ty*width + x*width/sw
+			sums[i] += uint64(color.GrayModel.Convert(img.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.Gray).Y)
+			counts[i]++
+		}
+	}
+
+	gray := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range gray.Pix {
+		if counts[i] == 0 {
+			// Upscaling: take the nearest source pixel.
+			x, y := i%width, i/width
+			gray.Pix[i] = color.GrayModel.Convert(img.At(bounds.Min.X+x*sw/width, bounds.Min.Y+y*sh/height)).(color.Gray).Y
+			continue
+		}
+		gray.Pix[i] = uint8(sums[i] / uint64(counts[i]))
+	}
+	return gray
+}
+
+var (
+	alignedColor    = color.RGBA{0, 160, 60, 255}
+	misalignedColor = color.RGBA{220, 30, 30, 255}
+	emptyColor      = color.RGBA{150, 150, 150, 255}
+	inkColor        = color.RGBA{30, 90, 230, 255}
+)
+
+func drawResult(img *image.RGBA, result ScanFieldResult) {
+	boxColor := emptyColor
+	switch result.Status {
+	case ScanFieldAligned:
+		boxColor = alignedColor
+	case ScanFieldMisaligned:
+		boxColor = misalignedColor
+	}
+	if result.Ink != nil {
+		strokeRect(img, *result.Ink, inkColor)
+	}
+	strokeRect(img, result.Box, boxColor)
+}
+
+func strokeRect(img *image.RGBA, r ScanRect, c color.RGBA) {
+	right, bottom := r.Left+r.Width-1, r.Top+r.Height-1
+	for x := r.Left; x <= right; x++ {
+		img.SetRGBA(x, r.Top, c)
+		img.SetRGBA(x, bottom, c)
+	}
+	for y := r.Top; y <= bottom; y++ {
+		img.SetRGBA(r.Left, y, c)
+		img.SetRGBA(right, y, c)
+	}
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
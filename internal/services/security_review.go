@@ -0,0 +1,288 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+)
+
+// Security finding severities. Errors fail the review; warnings are reported
+// for follow-up but do not.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// securityReviewSubmissions bounds the recent submissions whose htmlData is scanned.
+const securityReviewSubmissions = 200
+
+var (
+	// Remote resources in CSS: url() and @import pointing off the document.
+	cssRemoteURLPattern   = regexp.MustCompile(`(?i)url\(\s*['"]?\s*(https?:|//|ftp:)`)
+	cssImportPattern      = regexp.MustCompile(`(?i)@import\b`)
+	cssFontFacePattern    = regexp.MustCompile(`(?is)@font-face\s*\{[^}]*\}`)
+	cssScriptPattern      = regexp.MustCompile(`(?i)(expression\s*\(|javascript:|behavior\s*:|-moz-binding)`)
+	htmlScriptPattern     = regexp.MustCompile(`(?i)<\s*(script|iframe|object|embed)\b`)
+	htmlEventPattern      = regexp.MustCompile(`(?i)\son[a-z]+\s*=`)
+	htmlJavaScriptPattern = regexp.MustCompile(`(?i)javascript:`)
+	htmlRemoteURLPattern  = regexp.MustCompile(`(?i)(src|href)\s*=\s*['"]?\s*(https?:|//)`)
+)
+
+// SecurityFinding is one risky construct found in a template's content.
+type SecurityFinding struct {
+	Severity string `json:"severity"`
+	Rule     string `json:"rule"`
+	Location string `json:"location"`
+	Detail   string `json:"detail"`
+}
+
+// SecurityReport is the result of reviewing a template's content. Passed is
+// false when any finding is an error.
+type SecurityReport struct {
+	TemplateID         string            `json:"templateId"`
+	Passed             bool              `json:"passed"`
+	Findings           []SecurityFinding `json:"findings"`
+	PagesScanned       int               `json:"pagesScanned"`
+	SubmissionsScanned int               `json:"submissionsScanned"`
+	ReviewedAt         time.Time         `json:"reviewedAt"`
+}
+
+// SecurityReviewService scans template content for constructs that run
+// script or load resources from outside the document when it is rendered.
+type SecurityReviewService struct {
+	uploadService *UploadService
+}
+
+func NewSecurityReviewService(uploadService *UploadService) *SecurityReviewService {
+	return &SecurityReviewService{uploadService: uploadService}
+}
+
+// Review scans the template's page SVGs, its custom CSS and the htmlData of
+// its most recent submissions. Page SVGs and custom CSS are rendered as
+// stored, so problems there are errors; htmlData is sanitized before it is
+// rendered, so problems there are warnings about what clients send.
+func (s *SecurityReviewService) Review(template *gormmodels.Template) (*SecurityReport, error) {
+	report := &SecurityReport{
+		TemplateID: template.ID,
+		Findings:   []SecurityFinding{},
+		ReviewedAt: time.Now(),
+	}
+
+	svgFiles := append([]gormmodels.SVGFile(nil), template.SVGFiles...)
+	sort.Slice(svgFiles, func(i, j int) bool { return svgFiles[i].PageIndex < svgFiles[j].PageIndex })
+	for i := range svgFiles {
+		content, err := s.uploadService.GetSVGFileContent(&svgFiles[i])
+		if err != nil {
+			return nil, err
+		}
+		location := fmt.Sprintf("page %d (%s)", svgFiles[i].PageIndex, svgFiles[i].Filename)
+		report.Findings = append(report.Findings, reviewSVG(content, location)...)
+		report.PagesScanned++
+	}
+
+	if template.CustomCSS != "" {
+		if _, err := utils.SanitizeTemplateCSS(template.CustomCSS); err != nil {
+			report.Findings = append(report.Findings, SecurityFinding{
+				Severity: SeverityError,
+				Rule:     "invalid-css",
+				Location: "customCss",
+				Detail:   strings.TrimPrefix(err.Error(), utils.ErrInvalidCSS.Error()+": "),
+			})
+		}
+	}
+
+	var submissions []gormmodels.FormSubmission
+	err := internal.ReadDB().Select("id", "html_data").
+		Where("template_id = ?", template.ID).
+		Order("created_at DESC").Limit(securityReviewSubmissions).
+		Find(&submissions).Error
+	if err != nil {
+		return nil, storageError("failed to fetch submissions", err)
+	}
+	report.SubmissionsScanned = len(submissions)
+	report.Findings = append(report.Findings, reviewHTMLData(submissions)...)
+
+	report.Passed = true
+	for _, finding := range report.Findings {
+		if finding.Severity == SeverityError {
+			report.Passed = false
+			break
+		}
+	}
+	return report, nil
+}
+
+// reviewSVG walks an SVG document and reports scripts, event handlers,
+// embedded HTML and references to resources outside the document.
+func reviewSVG(content []byte, location string) []SecurityFinding {
+	var findings []SecurityFinding
+	add := func(severity, rule, detail string) {
+		findings = append(findings, SecurityFinding{Severity: severity, Rule: rule, Location: location, Detail: detail})
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var inStyle bool
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				add(SeverityError, "unparseable-svg", err.Error())
+			}
+			return findings
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			switch name {
+			case "script":
+				add(SeverityError, "script", "<script> element")
+			case "foreignobject":
+				add(SeverityError, "embedded-content", "<foreignObject> embeds HTML in the SVG")
+			case "iframe", "object", "embed":
+				add(SeverityError, "embedded-content", fmt.Sprintf("<%s> element", name))
+			case "style":
+				inStyle = true
+			}
+
+			for _, attr := range t.Attr {
+				attrName := strings.ToLower(attr.Name.Local)
+				value := strings.TrimSpace(attr.Value)
+				switch {
+				case strings.HasPrefix(attrName, "on"):
+					add(SeverityError, "event-handler", fmt.Sprintf("%s attribute on <%s>", attr.Name.Local, t.Name.Local))
+				case attrName == "href" || attrName == "src":
+					if finding, ok := reviewReference(value); ok {
+						add(SeverityError, finding, fmt.Sprintf("<%s %s=%q>", t.Name.Local, attr.Name.Local, truncateDetail(value)))
+					}
+				case attrName == "style":
+					for _, finding := range reviewCSS(value) {
+						add(SeverityError, finding.Rule, fmt.Sprintf("style attribute on <%s>: %s", t.Name.Local, finding.Detail))
+					}
+				}
+			}
+		case xml.EndElement:
+			if strings.EqualFold(t.Name.Local, "style") {
+				inStyle = false
+			}
+		case xml.CharData:
+			if inStyle {
+				for _, finding := range reviewCSS(string(t)) {
+					add(SeverityError, finding.Rule, "<style>: "+finding.Detail)
+				}
+			}
+		}
+	}
+}
+
+// reviewReference classifies an href or src value; data and fragment
+// references to the document itself are fine.
+func reviewReference(value string) (string, bool) {
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(lower, "javascript:"):
+		return "javascript-url", true
+	case strings.HasPrefix(lower, "data:text/html"), strings.HasPrefix(lower, "data:image/svg"):
+		return "embedded-content", true
+	case strings.HasPrefix(lower, "http:"), strings.HasPrefix(lower, "https:"), strings.HasPrefix(lower, "//"):
+		return "external-url", true
+	}
+	return "", false
+}
+
+// reviewCSS reports remote fonts, imports, remote url() references and
+// script in a stylesheet or style attribute. Findings carry no location.
+func reviewCSS(css string) []SecurityFinding {
+	var findings []SecurityFinding
+	css = strings.ReplaceAll(css, `\`, "")
+
+	for _, block := range cssFontFacePattern.FindAllString(css, -1) {
+		if cssRemoteURLPattern.MatchString(block) {
+			findings = append(findings, SecurityFinding{Rule: "remote-font", Detail: "@font-face loads a font from the internet"})
+		}
+	}
+	rest := cssFontFacePattern.ReplaceAllString(css, "")
+
+	if cssImportPattern.MatchString(rest) {
+		findings = append(findings, SecurityFinding{Rule: "css-import", Detail: "@import loads another stylesheet"})
+	}
+	if match := cssRemoteURLPattern.FindString(rest); match != "" {
+		findings = append(findings, SecurityFinding{Rule: "external-url", Detail: fmt.Sprintf("%q references an external resource", match)})
+	}
+	if match := cssScriptPattern.FindString(rest); match != "" {
+		findings = append(findings, SecurityFinding{Rule: "script", Detail: fmt.Sprintf("%q runs script", match)})
+	}
+	return findings
+}
+
+// reviewHTMLData reports htmlData keys whose stored values carry markup the
+// rich text sanitizer strips at render time, with how many submissions do.
+func reviewHTMLData(submissions []gormmodels.FormSubmission) []SecurityFinding {
+	type key struct{ dataKey, rule string }
+	counts := make(map[key]int)
+
+	for _, submission := range submissions {
+		for dataKey, value := range submission.HtmlData {
+			str, ok := value.(string)
+			if !ok {
+				continue
+			}
+			rules := make(map[string]bool)
+			if htmlScriptPattern.MatchString(str) || htmlJavaScriptPattern.MatchString(str) {
+				rules["script"] = true
+			}
+			if htmlEventPattern.MatchString(str) {
+				rules["event-handler"] = true
+			}
+			if htmlRemoteURLPattern.MatchString(str) || cssRemoteURLPattern.MatchString(str) {
+				rules["external-url"] = true
+			}
+			for rule := range rules {
+				counts[key{dataKey, rule}]++
+			}
+		}
+	}
+
+	keys := make([]key, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].dataKey != keys[j].dataKey {
+			return keys[i].dataKey < keys[j].dataKey
+		}
+		return keys[i].rule < keys[j].rule
+	})
+
+	findings := make([]SecurityFinding, 0, len(keys))
+	for _, k := range keys {
+		findings = append(findings, SecurityFinding{
+			Severity: SeverityWarning,
+			Rule:     k.rule,
+			Location: fmt.Sprintf("htmlData[%s]", k.dataKey),
+			Detail:   fmt.Sprintf("found in %d of the %d most recent submissions; stripped when rendered", counts[k], len(submissions)),
+		})
+	}
+	return findings
+}
+
+func truncateDetail(value string) string {
+	if len(value) > 80 {
+		return value[:80] + "..."
+	}
+	return value
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TemplateAccess is what a workspace may do with a template, from least to
+// most.
+type TemplateAccess int
+
+const (
+	AccessNone TemplateAccess = iota
+	// AccessView allows viewing and copying, granted by a copy share.
+	AccessView
+	// AccessUse also allows filling in and generating, granted by a read share.
+	AccessUse
+	// AccessOwner allows everything, including editing and sharing.
+	AccessOwner
+)
+
+// Access reports what workspaceID (empty for anonymous callers) may do with a
+// template. Templates without an owning workspace are open to everyone.
+func (s *TemplateService) Access(templateID, workspaceID string) (TemplateAccess, error) {
+	var owners []string
+	err := internal.DB.Model(&gormmodels.Template{}).Where("id = ?", templateID).Pluck("workspace_id", &owners).Error
+	if err != nil {
+		return AccessNone, storageError("failed to fetch template", err)
+	}
+	if len(owners) == 0 {
+		return AccessNone, ErrTemplateNotFound
+	}
+	if owners[0] == "" || owners[0] == workspaceID {
+		return AccessOwner, nil
+	}
+	if workspaceID == "" {
+		return AccessNone, nil
+	}
+
+	var share gormmodels.TemplateShare
+	err = internal.DB.Where("template_id = ? AND workspace_id = ? AND accepted_at IS NOT NULL", templateID, workspaceID).First(&share).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return AccessNone, nil
+		}
+		return AccessNone, storageError("failed to fetch template share", err)
+	}
+	if share.Access == gormmodels.ShareRead {
+		return AccessUse, nil
+	}
+	return AccessView, nil
+}
+
+// visibleTo limits a template query to templates the workspace may view.
+func visibleTo(query *gorm.DB, workspaceID string) *gorm.DB {
+	return query.Where("templates.workspace_id = '' OR templates.workspace_id IS NULL OR templates.workspace_id = ? OR templates.id IN (?)",
+		workspaceID,
+		internal.ReadDB().Model(&gormmodels.TemplateShare{}).Select("template_id").
+			Where("workspace_id = ? AND workspace_id <> '' AND accepted_at IS NOT NULL", workspaceID))
+}
+
+type ShareService struct {
+	syncService *SyncService
+}
+
+func NewShareService(syncService *SyncService) *ShareService {
+	return &ShareService{syncService: syncService}
+}
+
+// Invite offers a workspace template to another workspace, named either by
+// WorkspaceID or by the InviteEmail of a user who will accept it.
+func (s *ShareService) Invite(share *gormmodels.TemplateShare) error {
+	if share.Access != gormmodels.ShareRead && share.Access != gormmodels.ShareCopy {
+		return newErrorf(ErrValidation, "access must be %q or %q", gormmodels.ShareRead, gormmodels.ShareCopy)
+	}
+	share.InviteEmail = strings.ToLower(strings.TrimSpace(share.InviteEmail))
+	if (share.WorkspaceID == "") == (share.InviteEmail == "") {
+		return newError(ErrValidation, "invite either a workspaceId or an email")
+	}
+	if share.WorkspaceID == share.OwnerWorkspaceID {
+		return newError(ErrValidation, "a template cannot be shared with its own workspace")
+	}
+
+	var template gormmodels.Template
+	err := internal.DB.Select("id", "workspace_id").Where("id = ?", share.TemplateID).First(&template).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return ErrTemplateNotFound
+		}
+		return storageError("failed to fetch template", err)
+	}
+	if template.WorkspaceID == "" || template.WorkspaceID != share.OwnerWorkspaceID {
+		return newError(ErrValidation, "only templates owned by the workspace can be shared")
+	}
+
+	if share.WorkspaceID != "" {
+		var count int64
+		if err := internal.DB.Model(&gormmodels.Workspace{}).Where("id = ?", share.WorkspaceID).Count(&count).Error; err != nil {
+			return storageError("failed to fetch workspace", err)
+		}
+		if count == 0 {
+			return newErrorf(ErrValidation, "workspace %s does not exist", share.WorkspaceID)
+		}
+	}
+
+	var existing int64
+	query := internal.DB.Model(&gormmodels.TemplateShare{}).Where("template_id = ?", share.TemplateID)
+	if share.WorkspaceID != "" {
+		query = query.Where("workspace_id = ?", share.WorkspaceID)
+	} else {
+		query = query.Where("invite_email = ? AND accepted_at IS NULL", share.InviteEmail)
+	}
+	if err := query.Count(&existing).Error; err != nil {
+		return storageError("failed to fetch template shares", err)
+	}
+	if existing > 0 {
+		return newError(ErrConflict, "template is already shared with this workspace")
+	}
+
+	share.ID = uuid.New().String()
+	share.AcceptedBy = ""
+	share.AcceptedAt = nil
+	if err := internal.DB.Create(share).Error; err != nil {
+		return storageError("failed to create template share", err)
+	}
+	return nil
+}
+
+func (s *ShareService) GetByTemplateID(templateID string) ([]gormmodels.TemplateShare, error) {
+	var shares []gormmodels.TemplateShare
+
+	err := internal.DB.Where("template_id = ?", templateID).Order("created_at DESC").Find(&shares).Error
+	if err != nil {
+		return nil, storageError("failed to fetch template shares", err)
+	}
+
+	return shares, nil
+}
+
+// GetForOwner returns one of the owner workspace's shares of a template, or
+// nil if there is none with that ID.
+func (s *ShareService) GetForOwner(ownerWorkspaceID, templateID, id string) (*gormmodels.TemplateShare, error) {
+	var share gormmodels.TemplateShare
+
+	err := internal.DB.Where("id = ? AND template_id = ? AND owner_workspace_id = ?", id, templateID, ownerWorkspaceID).First(&share).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch template share", err)
+	}
+
+	return &share, nil
+}
+
+// Revoke withdraws a share, accepted or not.
+func (s *ShareService) Revoke(share *gormmodels.TemplateShare) error {
+	if err := internal.DB.Delete(share).Error; err != nil {
+		return storageError("failed to revoke template share", err)
+	}
+	return nil
+}
+
+// Incoming lists the shares offered to a workspace: those naming it and the
+// pending email invites addressed to the user.
+func (s *ShareService) Incoming(workspaceID, userID string) ([]gormmodels.TemplateShare, error) {
+	email, err := s.userEmail(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := internal.DB.Where("workspace_id = ?", workspaceID)
+	if email != "" {
+		query = query.Or("invite_email = ? AND accepted_at IS NULL", email)
+	}
+
+	var shares []gormmodels.TemplateShare
+	if err := query.Order("created_at DESC").Find(&shares).Error; err != nil {
+		return nil, storageError("failed to fetch template shares", err)
+	}
+	return shares, nil
+}
+
+// Accept accepts an invite for the user's workspace. It returns nil if the
+// invite does not exist or is not addressed to the workspace or user.
+func (s *ShareService) Accept(id, workspaceID, userID string) (*gormmodels.TemplateShare, error) {
+	email, err := s.userEmail(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var share gormmodels.TemplateShare
+	err = internal.DB.Where("id = ?", id).First(&share).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch template share", err)
+	}
+
+	switch {
+	case share.WorkspaceID != "" && share.WorkspaceID == workspaceID:
+	case share.WorkspaceID == "" && email != "" && share.InviteEmail == email:
+		if workspaceID == share.OwnerWorkspaceID {
+			return nil, newError(ErrValidation, "a template cannot be shared with its own workspace")
+		}
+		var count int64
+		err := internal.DB.Model(&gormmodels.TemplateShare{}).
+			Where("template_id = ? AND workspace_id = ?", share.TemplateID, workspaceID).Count(&count).Error
+		if err != nil {
+			return nil, storageError("failed to fetch template shares", err)
+		}
+		if count > 0 {
+			return nil, newError(ErrConflict, "template is already shared with this workspace")
+		}
+		share.WorkspaceID = workspaceID
+	default:
+		return nil, nil
+	}
+
+	if share.Accepted() {
+		return &share, nil
+	}
+
+	now := time.Now()
+	share.AcceptedBy = userID
+	share.AcceptedAt = &now
+	err = internal.DB.Model(&share).Select("workspace_id", "accepted_by", "accepted_at").Updates(&share).Error
+	if err != nil {
+		return nil, storageError("failed to accept template share", err)
+	}
+	return &share, nil
+}
+
+// Copy creates a copy of a template, with its fields and pages, owned by
+// workspaceID. The copy starts at version 1 and is not linked to the original.
+func (s *ShareService) Copy(ctx context.Context, templateID, workspaceID string) (*gormmodels.Template, error) {
+	bundle, err := s.syncService.Export(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return nil, ErrTemplateNotFound
+	}
+
+	rebaseBundle(bundle, workspaceID)
+	bundle.Hash = bundleHash(bundle)
+
+	if err := s.syncService.Import(ctx, bundle, true); err != nil {
+		return nil, err
+	}
+	if err := s.syncService.MarkSynced(bundle.Template.ID, ""); err != nil {
+		return nil, err
+	}
+
+	return s.syncService.templateService.GetByID(bundle.Template.ID)
+}
+
+// rebaseBundle turns an exported bundle into a new template owned by
+// workspaceID.
+func rebaseBundle(bundle *TemplateBundle, workspaceID string) {
+	bundle.Template.ID = uuid.New().String()
+	bundle.Template.WorkspaceID = workspaceID
+	bundle.Template.CreatedAt = time.Time{}
+	bundle.Template.UpdatedAt = time.Time{}
+	for i := range bundle.Template.Fields {
+		bundle.Template.Fields[i].ID = 0
+		bundle.Template.Fields[i].TemplateID = bundle.Template.ID
+	}
+}
+
+func (s *ShareService) userEmail(userID string) (string, error) {
+	var emails []string
+	if err := internal.DB.Model(&gormmodels.User{}).Where("id = ?", userID).Pluck("email", &emails).Error; err != nil {
+		return "", storageError("failed to fetch user", err)
+	}
+	if len(emails) == 0 {
+		return "", nil
+	}
+	return strings.ToLower(strings.TrimSpace(emails[0])), nil
+}
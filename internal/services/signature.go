@@ -0,0 +1,291 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/storage"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxSignatureSize bounds the size of a stored signature image.
+const MaxSignatureSize = 512 << 10
+
+// Signature image types.
+const (
+	SignatureMimePNG = "image/png"
+	SignatureMimeSVG = "image/svg+xml"
+)
+
+// unsafeSVGPattern matches SVG content that could run script or load
+// external resources when the image is opened directly.
+var unsafeSVGPattern = regexp.MustCompile(`(?i)<\s*(script|foreignObject|iframe|object|embed)\b|\son[a-z]+\s*=|javascript:|(?:href|src)\s*=\s*["']\s*(?:https?:|//)`)
+
+// SignatureViolation describes a signature field whose value does not refer
+// to a usable signature of the submitting user.
+type SignatureViolation struct {
+	DataKey     string `json:"dataKey"`
+	SignatureID string `json:"signatureId"`
+	Reason      string `json:"reason"`
+}
+
+type SignatureService struct {
+	storageClient storage.StorageClient
+}
+
+func NewSignatureService(storageClient storage.StorageClient) *SignatureService {
+	return &SignatureService{storageClient: storageClient}
+}
+
+// SignatureMimeType detects whether content is a PNG or an SVG signature and
+// rejects anything else, including SVGs with script or external references.
+func SignatureMimeType(content []byte) (string, error) {
+	return imageMimeType(content, MaxSignatureSize, "signature")
+}
+
+// imageMimeType detects PNG and SVG images of at most maxSize bytes for the
+// kind of asset named in errors.
+func imageMimeType(content []byte, maxSize int, kind string) (string, error) {
+	if len(content) == 0 {
+		return "", newErrorf(ErrValidation, "%s image is empty", kind)
+	}
+	if len(content) > maxSize {
+		return "", newErrorf(ErrValidation, "%s image exceeds %d KB", kind, maxSize>>10)
+	}
+
+	if http.DetectContentType(content) == SignatureMimePNG {
+		return SignatureMimePNG, nil
+	}
+
+	head := bytes.ToLower(content[:min(len(content), 1024)])
+	if bytes.Contains(head, []byte("<svg")) {
+		if unsafeSVGPattern.Match(content) {
+			return "", newErrorf(ErrValidation, "%s SVG must not contain scripts or external references", kind)
+		}
+		return SignatureMimeSVG, nil
+	}
+
+	return "", newErrorf(ErrValidation, "%s must be a PNG or SVG image", kind)
+}
+
+// Create stores the image and records the signature with the user's consent.
+func (s *SignatureService) Create(ctx context.Context, signature *gormmodels.Signature, content []byte) error {
+	mimeType, err := SignatureMimeType(content)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(content)
+	signature.ID = uuid.New().String()
+	signature.MimeType = mimeType
+	signature.FileSize = int64(len(content))
+	signature.SHA256 = hex.EncodeToString(sum[:])
+	signature.GCSPath = signatureObjectName(signature)
+	if signature.ConsentedAt.IsZero() {
+		signature.ConsentedAt = time.Now()
+	}
+
+	result, err := s.storageClient.UploadFile(ctx, bytes.NewReader(content), signature.GCSPath, mimeType)
+	if err != nil {
+		return storageError("failed to store signature image", err)
+	}
+	signature.KMSKeyName = result.KMSKeyName
+
+	if err := internal.DB.Create(signature).Error; err != nil {
+		s.storageClient.DeleteFile(ctx, signature.GCSPath)
+		return storageError("failed to create signature", err)
+	}
+	return nil
+}
+
+func signatureObjectName(signature *gormmodels.Signature) string {
+	ext := ".png"
+	if signature.MimeType == SignatureMimeSVG {
+		ext = ".svg"
+	}
+	return fmt.Sprintf("signatures/%s/%s%s", signature.UserID, signature.ID, ext)
+}
+
+// GetByUserID lists a user's signatures, newest first, including invalidated ones.
+func (s *SignatureService) GetByUserID(userID string) ([]gormmodels.Signature, error) {
+	var signatures []gormmodels.Signature
+
+	err := internal.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&signatures).Error
+	if err != nil {
+		return nil, storageError("failed to fetch signatures", err)
+	}
+
+	return signatures, nil
+}
+
+// GetForUser returns one of the user's signatures, or nil if the user has no
+// signature with that ID.
+func (s *SignatureService) GetForUser(userID, id string) (*gormmodels.Signature, error) {
+	var signature gormmodels.Signature
+
+	err := internal.DB.Where("id = ? AND user_id = ?", id, userID).First(&signature).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch signature", err)
+	}
+
+	return &signature, nil
+}
+
+// Invalidate stops the signature from being used or rendered and deletes its
+// image. The record and its consent details are kept.
+func (s *SignatureService) Invalidate(ctx context.Context, signature *gormmodels.Signature) error {
+	now := time.Now()
+	err := internal.DB.Model(signature).Where("invalidated_at IS NULL").Update("invalidated_at", now).Error
+	if err != nil {
+		return storageError("failed to invalidate signature", err)
+	}
+	signature.InvalidatedAt = &now
+
+	if err := s.storageClient.DeleteFile(ctx, signature.GCSPath); err != nil {
+		return storageError("failed to delete signature image", err)
+	}
+	return nil
+}
+
+// Content returns the image of a valid signature.
+func (s *SignatureService) Content(ctx context.Context, signature *gormmodels.Signature) ([]byte, error) {
+	if !signature.Valid() {
+		return nil, newError(ErrNotFound, "signature has been invalidated")
+	}
+
+	content, err := s.storageClient.ReadFile(ctx, signature.GCSPath)
+	if err != nil {
+		return nil, storageError("failed to read signature image", err)
+	}
+	return content, nil
+}
+
+// CheckReferences verifies that every non-empty signature field of a
+// template refers to a valid signature owned by userID. Anonymous
+// submissions cannot reference stored signatures.
+func (s *SignatureService) CheckReferences(templateID string, data map[string]interface{}, userID string) ([]SignatureViolation, error) {
+	refs, err := s.references(templateID, data)
+	if err != nil || len(refs) == 0 {
+		return nil, err
+	}
+
+	signatures, err := s.byIDs(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []SignatureViolation
+	for dataKey, id := range refs {
+		signature, ok := signatures[id]
+		reason := ""
+		switch {
+		case !ok || userID == "" || signature.UserID != userID:
+			reason = "not found"
+		case !signature.Valid():
+			reason = "invalidated"
+		}
+		if reason != "" {
+			violations = append(violations, SignatureViolation{DataKey: dataKey, SignatureID: id, Reason: reason})
+		}
+	}
+	return violations, nil
+}
+
+// Images returns the images of the valid signatures referenced by a
+// template's signature fields, keyed by DataKey. Missing and invalidated
+// signatures are left out.
+func (s *SignatureService) Images(ctx context.Context, fields []gormmodels.Field, data map[string]interface{}) (map[string]SignatureImage, error) {
+	refs := signatureRefs(fields, data)
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	signatures, err := s.byIDs(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make(map[string]SignatureImage, len(refs))
+	for dataKey, id := range refs {
+		signature, ok := signatures[id]
+		if !ok || !signature.Valid() {
+			continue
+		}
+		content, err := s.Content(ctx, &signature)
+		if err != nil {
+			return nil, err
+		}
+		images[dataKey] = SignatureImage{MimeType: signature.MimeType, Content: content}
+	}
+	return images, nil
+}
+
+// SignatureImage is the image of a stored signature.
+type SignatureImage struct {
+	MimeType string
+	Content  []byte
+}
+
+func (s *SignatureService) references(templateID string, data map[string]interface{}) (map[string]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var fields []gormmodels.Field
+	err := internal.DB.Select("data_key", "type").
+		Where("template_id = ? AND type = ?", templateID, FieldTypeSignature).
+		Find(&fields).Error
+	if err != nil {
+		return nil, storageError("failed to fetch fields", err)
+	}
+
+	return signatureRefs(fields, data), nil
+}
+
+func (s *SignatureService) byIDs(refs map[string]string) (map[string]gormmodels.Signature, error) {
+	ids := make([]string, 0, len(refs))
+	for _, id := range refs {
+		ids = append(ids, id)
+	}
+
+	var signatures []gormmodels.Signature
+	if err := internal.DB.Where("id IN ?", ids).Find(&signatures).Error; err != nil {
+		return nil, storageError("failed to fetch signatures", err)
+	}
+
+	byID := make(map[string]gormmodels.Signature, len(signatures))
+	for _, signature := range signatures {
+		byID[signature.ID] = signature
+	}
+	return byID, nil
+}
+
+// signatureRefs maps the DataKeys of signature fields to the signature IDs in data.
+func signatureRefs(fields []gormmodels.Field, data map[string]interface{}) map[string]string {
+	refs := make(map[string]string)
+	for _, field := range fields {
+		if field.Type != FieldTypeSignature {
+			continue
+		}
+		value, _ := utils.LookupDataPath(data, field.DataKey)
+		if id, ok := value.(string); ok && strings.TrimSpace(id) != "" {
+			refs[field.DataKey] = strings.TrimSpace(id)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SnapshotRenderer renders sample data against a template, returning the
+// document HTML, a full-page screenshot and the number of pages stacked in it.
+type SnapshotRenderer func(ctx context.Context, template *gormmodels.Template, data, formattingData, htmlData map[string]interface{}) (string, []byte, int, error)
+
+// SnapshotResult is the outcome of verifying one template against its snapshot.
+type SnapshotResult struct {
+	TemplateID      string `json:"templateId"`
+	TemplateVersion int    `json:"templateVersion"`
+	SnapshotID      string `json:"snapshotId"`
+	Result          string `json:"result"`
+	ChangedPages    []int  `json:"changedPages,omitempty"`
+	HTMLChanged     bool   `json:"htmlChanged"`
+	Error           string `json:"error,omitempty"`
+}
+
+type SnapshotService struct {
+	templateService *TemplateService
+	render          SnapshotRenderer
+}
+
+func NewSnapshotService(templateService *TemplateService, render SnapshotRenderer) *SnapshotService {
+	return &SnapshotService{
+		templateService: templateService,
+		render:          render,
+	}
+}
+
+// Record renders the sample data against the template's current version and
+// stores the result as that version's snapshot, replacing an earlier one.
+func (s *SnapshotService) Record(ctx context.Context, templateID string, snapshot *gormmodels.TemplateSnapshot) error {
+	template, err := s.templateService.GetByID(templateID)
+	if err != nil {
+		return err
+	}
+	if template == nil {
+		return newError(ErrNotFound, "template not found")
+	}
+
+	htmlHash, pageHashes, err := s.renderHashes(ctx, template, snapshot)
+	if err != nil {
+		return err
+	}
+
+	snapshot.ID = uuid.New().String()
+	snapshot.TemplateID = template.ID
+	snapshot.TemplateVersion = template.Version
+	snapshot.HTMLHash = htmlHash
+	snapshot.PageHashes = pageHashes
+
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ? AND template_version = ?", template.ID, template.Version).
+			Delete(&gormmodels.TemplateSnapshot{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(snapshot).Error
+	})
+	if err != nil {
+		return storageError("failed to save snapshot", err)
+	}
+	return nil
+}
+
+// GetByTemplateID lists a template's snapshots, newest version first.
+func (s *SnapshotService) GetByTemplateID(templateID string) ([]gormmodels.TemplateSnapshot, error) {
+	var snapshots []gormmodels.TemplateSnapshot
+
+	err := internal.DB.Where("template_id = ?", templateID).Order("template_version DESC").Find(&snapshots).Error
+	if err != nil {
+		return nil, storageError("failed to fetch snapshots", err)
+	}
+
+	return snapshots, nil
+}
+
+// Verify re-renders the snapshot of the template's current version and
+// compares the output page by page. Rendering failures are reported in the
+// result rather than returned.
+func (s *SnapshotService) Verify(ctx context.Context, templateID string) (*SnapshotResult, error) {
+	template, err := s.templateService.GetByID(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, newError(ErrNotFound, "template not found")
+	}
+
+	var snapshot gormmodels.TemplateSnapshot
+	err = internal.DB.Where("template_id = ? AND template_version = ?", template.ID, template.Version).First(&snapshot).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, newErrorf(ErrNotFound, "template has no snapshot for version %d", template.Version)
+		}
+		return nil, storageError("failed to fetch snapshot", err)
+	}
+
+	return s.verify(ctx, template, &snapshot)
+}
+
+// VerifyAll verifies every template that has a snapshot for its current
+// version. Templates edited since their last snapshot are skipped, since
+// their output is expected to change.
+func (s *SnapshotService) VerifyAll(ctx context.Context) ([]SnapshotResult, error) {
+	var snapshots []gormmodels.TemplateSnapshot
+	err := internal.DB.Joins("JOIN templates ON templates.id = template_snapshots.template_id AND templates.version = template_snapshots.template_version").
+		Order("template_snapshots.template_id").Find(&snapshots).Error
+	if err != nil {
+		return nil, storageError("failed to fetch snapshots", err)
+	}
+
+	results := make([]SnapshotResult, 0, len(snapshots))
+	for i := range snapshots {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		template, err := s.templateService.GetByID(snapshots[i].TemplateID)
+		if err != nil {
+			return results, err
+		}
+		if template == nil {
+			continue
+		}
+
+		result, err := s.verify(ctx, template, &snapshots[i])
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// StartVerification verifies all snapshots once in the background and logs
+// templates whose output changed, e.g. after a renderer or font upgrade.
+func (s *SnapshotService) StartVerification(ctx context.Context) {
+	go func() {
+		results, err := s.VerifyAll(ctx)
+		if err != nil {
+			log.Printf("Warning: snapshot verification stopped: %v", err)
+		}
+		for _, result := range results {
+			if result.Result != gormmodels.SnapshotMatch {
+				log.Printf("Warning: template %s version %d snapshot %s: pages %v %s", result.TemplateID, result.TemplateVersion, result.Result, result.ChangedPages, result.Error)
+			}
+		}
+		log.Printf("Verified %d template snapshots", len(results))
+	}()
+}
+
+func (s *SnapshotService) verify(ctx context.Context, template *gormmodels.Template, snapshot *gormmodels.TemplateSnapshot) (*SnapshotResult, error) {
+	result := &SnapshotResult{
+		TemplateID:      template.ID,
+		TemplateVersion: snapshot.TemplateVersion,
+		SnapshotID:      snapshot.ID,
+		Result:          gormmodels.SnapshotMatch,
+	}
+
+	htmlHash, pageHashes, err := s.renderHashes(ctx, template, snapshot)
+	if err != nil {
+		result.Result = gormmodels.SnapshotFailed
+		result.Error = err.Error()
+	} else {
+		result.HTMLChanged = htmlHash != snapshot.HTMLHash
+		result.ChangedPages = changedPages(snapshot.PageHashes, pageHashes)
+		if len(result.ChangedPages) > 0 {
+			result.Result = gormmodels.SnapshotChanged
+		}
+	}
+
+	now := time.Now()
+	snapshot.LastResult = result.Result
+	snapshot.ChangedPages = result.ChangedPages
+	snapshot.LastError = result.Error
+	snapshot.VerifiedAt = &now
+	err = internal.DB.Model(snapshot).Select("last_result", "changed_pages", "last_error", "verified_at").Updates(snapshot).Error
+	if err != nil {
+		return nil, storageError("failed to save snapshot result", err)
+	}
+	return result, nil
+}
+
+func (s *SnapshotService) renderHashes(ctx context.Context, template *gormmodels.Template, snapshot *gormmodels.TemplateSnapshot) (string, []string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+	defer cancel()
+
+	htmlContent, screenshot, pages, err := s.render(ctx, template, snapshot.SampleData, snapshot.FormattingData, snapshot.HtmlData)
+	if err != nil {
+		return "", nil, err
+	}
+
+	pageHashes, err := hashPages(screenshot, pages)
+	if err != nil {
+		return "", nil, err
+	}
+
+	sum := sha256.Sum256([]byte(htmlContent))
+	return hex.EncodeToString(sum[:]), pageHashes, nil
+}
+
+// hashPages splits a screenshot of stacked pages into equal strips and hashes
+// the pixels of each.
+func hashPages(screenshot []byte, pages int) ([]string, error) {
+	img, _, err := image.Decode(bytes.NewReader(screenshot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode screenshot: %w", err)
+	}
+	if pages < 1 {
+		pages = 1
+	}
+
+	bounds := img.Bounds()
+	height := bounds.Dy() / pages
+	if height == 0 {
+		return nil, fmt.Errorf("screenshot is too small for %d pages", pages)
+	}
+
+	hashes := make([]string, pages)
+	for i := range hashes {
+		strip := image.Rect(bounds.Min.X, bounds.Min.Y+i*height, bounds.Max.X, bounds.Min.Y+(i+1)*height)
+		rgba := image.NewRGBA(image.Rect(0, 0, strip.Dx(), strip.Dy()))
+		draw.Draw(rgba, rgba.Bounds(), img, strip.Min, draw.Src)
+
+		sum := sha256.Sum256(rgba.Pix)
+		hashes[i] = hex.EncodeToString(sum[:])
+	}
+	return hashes, nil
+}
+
+// changedPages lists the indexes of pages whose hashes differ, including
+// pages added or removed.
+func changedPages(expected, actual []string) []int {
+	var changed []int
+	for i := 0; i < len(expected) || i < len(actual); i++ {
+		if i >= len(expected) || i >= len(actual) || expected[i] != actual[i] {
+			changed = append(changed, i)
+		}
+	}
+	return changed
+}
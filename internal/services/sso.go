@@ -0,0 +1,348 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+type SSOService struct {
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	discovery map[string]*oidcDiscovery
+}
+
+func NewSSOService() *SSOService {
+	return &SSOService{
+		httpClient: &http.Client{Timeout: 15 * time.Second},
+		discovery:  make(map[string]*oidcDiscovery),
+	}
+}
+
+type oidcDiscovery struct {
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint"`
+}
+
+// SSOLogin describes a workspace whose SSO configuration has been resolved.
+type SSOLogin struct {
+	Workspace *gormmodels.Workspace
+	Config    *gormmodels.SSOConfig
+}
+
+// CreateWorkspace creates a workspace together with its SSO configuration,
+// if any, so its first admins can sign in.
+func (s *SSOService) CreateWorkspace(workspace *gormmodels.Workspace, config *gormmodels.SSOConfig) error {
+	if config != nil {
+		if err := checkSSOConfig(config); err != nil {
+			return err
+		}
+	}
+	if workspace.ID == "" {
+		workspace.ID = uuid.New().String()
+	}
+
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+		if config == nil {
+			return nil
+		}
+		config.WorkspaceID = workspace.ID
+		return tx.Create(config).Error
+	})
+	if err != nil {
+		return storageError("failed to create workspace", err)
+	}
+	return nil
+}
+
+func (s *SSOService) GetWorkspace(idOrSlug string) (*gormmodels.Workspace, error) {
+	var workspace gormmodels.Workspace
+
+	err := internal.DB.Where("id = ? OR slug = ?", idOrSlug, idOrSlug).First(&workspace).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch workspace", err)
+	}
+
+	return &workspace, nil
+}
+
+func (s *SSOService) GetConfig(workspaceID string) (*gormmodels.SSOConfig, error) {
+	var config gormmodels.SSOConfig
+
+	err := internal.DB.Where("workspace_id = ?", workspaceID).First(&config).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch SSO config", err)
+	}
+
+	return &config, nil
+}
+
+// SaveConfig creates or replaces the SSO configuration of a workspace.
+func (s *SSOService) SaveConfig(config *gormmodels.SSOConfig) error {
+	if err := checkSSOConfig(config); err != nil {
+		return err
+	}
+
+	existing, err := s.GetConfig(config.WorkspaceID)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		config.ID = existing.ID
+		config.CreatedAt = existing.CreatedAt
+		if config.ClientSecret == "" {
+			config.ClientSecret = existing.ClientSecret
+		}
+	}
+
+	if err := internal.DB.Save(config).Error; err != nil {
+		return storageError("failed to save SSO config", err)
+	}
+
+	s.mu.Lock()
+	delete(s.discovery, config.Issuer)
+	s.mu.Unlock()
+	return nil
+}
+
+// ResolveLogin loads the workspace and its enabled SSO configuration.
+func (s *SSOService) ResolveLogin(workspaceSlug string) (*SSOLogin, error) {
+	workspace, err := s.GetWorkspace(workspaceSlug)
+	if err != nil || workspace == nil {
+		return nil, err
+	}
+
+	config, err := s.GetConfig(workspace.ID)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil || !config.Enabled {
+		return nil, nil
+	}
+	if config.Protocol != "oidc" {
+		return nil, newErrorf(ErrValidation, "SSO protocol %q is not supported", config.Protocol)
+	}
+
+	return &SSOLogin{Workspace: workspace, Config: config}, nil
+}
+
+// AuthCodeURL returns the IdP authorization URL to redirect the browser to.
+func (s *SSOService) AuthCodeURL(ctx context.Context, login *SSOLogin, redirectURL, state string) (string, error) {
+	oauthConfig, _, err := s.oauthConfig(ctx, login.Config, redirectURL)
+	if err != nil {
+		return "", err
+	}
+	return oauthConfig.AuthCodeURL(state), nil
+}
+
+// CompleteLogin exchanges the authorization code, reads the IdP identity and
+// provisions or updates the matching local user.
+func (s *SSOService) CompleteLogin(ctx context.Context, login *SSOLogin, redirectURL, code string) (*gormmodels.User, error) {
+	oauthConfig, discovery, err := s.oauthConfig(ctx, login.Config, redirectURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, s.httpClient)
+	token, err := oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+
+	claims, err := s.fetchUserinfo(ctx, oauthConfig.TokenSource(ctx, token), discovery.UserinfoEndpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, fmt.Errorf("identity provider did not return a subject")
+	}
+
+	email, _ := claims["email"].(string)
+	name, _ := claims["name"].(string)
+	role := mapRole(login.Config, claims)
+
+	now := time.Now()
+	var user gormmodels.User
+	err = internal.DB.Where("workspace_id = ? AND provider = ? AND subject = ?", login.Workspace.ID, "oidc", subject).First(&user).Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		user = gormmodels.User{
+			ID:          uuid.New().String(),
+			WorkspaceID: login.Workspace.ID,
+			Email:       email,
+			Name:        name,
+			Role:        role,
+			Provider:    "oidc",
+			Subject:     subject,
+			LastLoginAt: &now,
+		}
+		if err := internal.DB.Create(&user).Error; err != nil {
+			return nil, storageError("failed to provision user", err)
+		}
+	case err != nil:
+		return nil, storageError("failed to fetch user", err)
+	default:
+		user.Email = email
+		user.Name = name
+		user.Role = role
+		user.LastLoginAt = &now
+		if err := internal.DB.Save(&user).Error; err != nil {
+			return nil, storageError("failed to update user", err)
+		}
+	}
+
+	return &user, nil
+}
+
+func (s *SSOService) oauthConfig(ctx context.Context, config *gormmodels.SSOConfig, redirectURL string) (*oauth2.Config, *oidcDiscovery, error) {
+	discovery, err := s.discover(ctx, config.Issuer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &oauth2.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       strings.Fields(config.Scopes),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}, discovery, nil
+}
+
+func (s *SSOService) discover(ctx context.Context, issuer string) (*oidcDiscovery, error) {
+	s.mu.Lock()
+	cached, ok := s.discovery[issuer]
+	s.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	wellKnown := strings.TrimRight(issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, wellKnown, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery request: %w", err)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch OIDC discovery document: status %d", resp.StatusCode)
+	}
+
+	var discovery oidcDiscovery
+	if err := json.NewDecoder(resp.Body).Decode(&discovery); err != nil {
+		return nil, fmt.Errorf("failed to decode OIDC discovery document: %w", err)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.UserinfoEndpoint == "" {
+		return nil, fmt.Errorf("OIDC discovery document is missing required endpoints")
+	}
+
+	s.mu.Lock()
+	s.discovery[issuer] = &discovery
+	s.mu.Unlock()
+	return &discovery, nil
+}
+
+func (s *SSOService) fetchUserinfo(ctx context.Context, tokenSource oauth2.TokenSource, endpoint string) (map[string]interface{}, error) {
+	client := oauth2.NewClient(ctx, tokenSource)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create userinfo request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch userinfo: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch userinfo: status %d", resp.StatusCode)
+	}
+
+	var claims map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, fmt.Errorf("failed to decode userinfo: %w", err)
+	}
+
+	return claims, nil
+}
+
+// checkSSOConfig rejects configurations that would give signed-in users a
+// role other than member or admin.
+func checkSSOConfig(config *gormmodels.SSOConfig) error {
+	if !validRole(config.DefaultRole) {
+		return newErrorf(ErrValidation, "defaultRole must be %q or %q", gormmodels.RoleMember, gormmodels.RoleAdmin)
+	}
+	for claim, role := range config.RoleMapping {
+		if !validRole(role) {
+			return newErrorf(ErrValidation, "roleMapping of %q must be %q or %q", claim, gormmodels.RoleMember, gormmodels.RoleAdmin)
+		}
+	}
+	return nil
+}
+
+func validRole(role string) bool {
+	return role == gormmodels.RoleMember || role == gormmodels.RoleAdmin
+}
+
+// mapRole picks the local role from the configured claim. The claim may be a
+// string or a list of strings; the first value present in the mapping wins.
+func mapRole(config *gormmodels.SSOConfig, claims map[string]interface{}) string {
+	if config.RoleClaim == "" {
+		return config.DefaultRole
+	}
+
+	var values []string
+	switch v := claims[config.RoleClaim].(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+	}
+
+	for _, value := range values {
+		if role, ok := config.RoleMapping[value]; ok {
+			return role
+		}
+	}
+
+	return config.DefaultRole
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/storage"
+	"github.com/dhanavadh/fastfill-backend/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxStampSize bounds the size of a stamp image.
+const MaxStampSize = 2 << 20
+
+// StampViolation describes a stamp field whose value does not refer to a
+// stamp of the submitting workspace.
+type StampViolation struct {
+	DataKey string `json:"dataKey"`
+	StampID string `json:"stampId"`
+}
+
+type StampService struct {
+	storageClient storage.StorageClient
+}
+
+func NewStampService(storageClient storage.StorageClient) *StampService {
+	return &StampService{storageClient: storageClient}
+}
+
+// Create stores the image and records the stamp.
+func (s *StampService) Create(ctx context.Context, stamp *gormmodels.Stamp, content []byte) error {
+	mimeType, err := imageMimeType(content, MaxStampSize, "stamp")
+	if err != nil {
+		return err
+	}
+
+	ext := ".png"
+	if mimeType == SignatureMimeSVG {
+		ext = ".svg"
+	}
+	stamp.ID = uuid.New().String()
+	stamp.MimeType = mimeType
+	stamp.FileSize = int64(len(content))
+	stamp.GCSPath = fmt.Sprintf("stamps/%s/%s%s", stamp.WorkspaceID, stamp.ID, ext)
+
+	result, err := s.storageClient.UploadFile(ctx, bytes.NewReader(content), stamp.GCSPath, mimeType)
+	if err != nil {
+		return storageError("failed to store stamp image", err)
+	}
+	stamp.KMSKeyName = result.KMSKeyName
+
+	if err := internal.DB.Create(stamp).Error; err != nil {
+		s.storageClient.DeleteFile(ctx, stamp.GCSPath)
+		return storageError("failed to create stamp", err)
+	}
+	return nil
+}
+
+func (s *StampService) GetByWorkspaceID(workspaceID string) ([]gormmodels.Stamp, error) {
+	var stamps []gormmodels.Stamp
+
+	err := internal.DB.Where("workspace_id = ?", workspaceID).Order("name ASC").Find(&stamps).Error
+	if err != nil {
+		return nil, storageError("failed to fetch stamps", err)
+	}
+
+	return stamps, nil
+}
+
+// GetForWorkspace returns one of the workspace's stamps, or nil if the
+// workspace has no stamp with that ID.
+func (s *StampService) GetForWorkspace(workspaceID, id string) (*gormmodels.Stamp, error) {
+	var stamp gormmodels.Stamp
+
+	err := internal.DB.Where("id = ? AND workspace_id = ?", id, workspaceID).First(&stamp).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch stamp", err)
+	}
+
+	return &stamp, nil
+}
+
+// CountFields reports how many template fields place the stamp at a fixed position.
+func (s *StampService) CountFields(stampID string) (int64, error) {
+	var count int64
+	if err := internal.DB.Model(&gormmodels.Field{}).Where("stamp_id = ?", stampID).Count(&count).Error; err != nil {
+		return 0, storageError("failed to count stamp fields", err)
+	}
+	return count, nil
+}
+
+// Delete removes the stamp and its image. Submissions that chose it render
+// the field empty from then on.
+func (s *StampService) Delete(ctx context.Context, stamp *gormmodels.Stamp) error {
+	if err := internal.DB.Delete(stamp).Error; err != nil {
+		return storageError("failed to delete stamp", err)
+	}
+
+	if err := s.storageClient.DeleteFile(ctx, stamp.GCSPath); err != nil {
+		return storageError("failed to delete stamp image", err)
+	}
+	return nil
+}
+
+func (s *StampService) Content(ctx context.Context, stamp *gormmodels.Stamp) ([]byte, error) {
+	content, err := s.storageClient.ReadFile(ctx, stamp.GCSPath)
+	if err != nil {
+		return nil, storageError("failed to read stamp image", err)
+	}
+	return content, nil
+}
+
+// CheckFixed verifies that the stamps placed by fields exist in the workspace.
+func (s *StampService) CheckFixed(fields []gormmodels.Field, workspaceID string) error {
+	for _, field := range fields {
+		if field.StampID == "" {
+			continue
+		}
+		if field.Type != FieldTypeStamp {
+			return newErrorf(ErrValidation, "field %s sets stampId but is not a stamp field", field.DataKey)
+		}
+		if field.Opacity < 0 || field.Opacity > 1 {
+			return newErrorf(ErrValidation, "field %s has an opacity outside 0-1", field.DataKey)
+		}
+
+		stamp, err := s.GetForWorkspace(workspaceID, field.StampID)
+		if err != nil {
+			return err
+		}
+		if stamp == nil {
+			return newErrorf(ErrValidation, "field %s uses unknown stamp %s", field.DataKey, field.StampID)
+		}
+	}
+	return nil
+}
+
+// CheckReferences verifies that every non-empty stamp field of a template
+// without a fixed stamp refers to a stamp of workspaceID.
+func (s *StampService) CheckReferences(templateID string, data map[string]interface{}, workspaceID string) ([]StampViolation, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var fields []gormmodels.Field
+	err := internal.DB.Select("data_key", "type", "stamp_id").
+		Where("template_id = ? AND type = ?", templateID, FieldTypeStamp).
+		Find(&fields).Error
+	if err != nil {
+		return nil, storageError("failed to fetch fields", err)
+	}
+
+	refs := stampRefs(fields, data)
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	stamps, err := s.byIDs(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []StampViolation
+	for dataKey, id := range refs {
+		if stamp, ok := stamps[id]; !ok || workspaceID == "" || stamp.WorkspaceID != workspaceID {
+			violations = append(violations, StampViolation{DataKey: dataKey, StampID: id})
+		}
+	}
+	return violations, nil
+}
+
+// StampImage is the image of a stamp.
+type StampImage struct {
+	MimeType string
+	Content  []byte
+}
+
+// Images returns the stamp images of a template's stamp fields keyed by
+// DataKey: the fixed stamp when the field has one, otherwise the stamp chosen
+// in data. Deleted stamps are left out.
+func (s *StampService) Images(ctx context.Context, fields []gormmodels.Field, data map[string]interface{}) (map[string]StampImage, error) {
+	refs := stampRefs(fields, data)
+	if len(refs) == 0 {
+		return nil, nil
+	}
+
+	stamps, err := s.byIDs(refs)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make(map[string]StampImage, len(refs))
+	for dataKey, id := range refs {
+		stamp, ok := stamps[id]
+		if !ok {
+			continue
+		}
+		content, err := s.Content(ctx, &stamp)
+		if err != nil {
+			return nil, err
+		}
+		images[dataKey] = StampImage{MimeType: stamp.MimeType, Content: content}
+	}
+	return images, nil
+}
+
+func (s *StampService) byIDs(refs map[string]string) (map[string]gormmodels.Stamp, error) {
+	ids := make([]string, 0, len(refs))
+	for _, id := range refs {
+		ids = append(ids, id)
+	}
+
+	var stamps []gormmodels.Stamp
+	if err := internal.DB.Where("id IN ?", ids).Find(&stamps).Error; err != nil {
+		return nil, storageError("failed to fetch stamps", err)
+	}
+
+	byID := make(map[string]gormmodels.Stamp, len(stamps))
+	for _, stamp := range stamps {
+		byID[stamp.ID] = stamp
+	}
+	return byID, nil
+}
+
+// stampRefs maps the DataKeys of stamp fields to their fixed stamp or, for
+// fields without one, the stamp ID in data.
+func stampRefs(fields []gormmodels.Field, data map[string]interface{}) map[string]string {
+	refs := make(map[string]string)
+	for _, field := range fields {
+		if field.Type != FieldTypeStamp {
+			continue
+		}
+		if field.StampID != "" {
+			refs[field.DataKey] = field.StampID
+			continue
+		}
+		value, _ := utils.LookupDataPath(data, field.DataKey)
+		if id, ok := value.(string); ok && strings.TrimSpace(id) != "" {
+			refs[field.DataKey] = strings.TrimSpace(id)
+		}
+	}
+	return refs
+}
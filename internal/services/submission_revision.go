@@ -0,0 +1,267 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+)
+
+// Field change kinds in a revision diff.
+const (
+	FieldAdded   = "added"
+	FieldRemoved = "removed"
+	FieldChanged = "changed"
+)
+
+// RevisionSummary describes a revision without its data.
+type RevisionSummary struct {
+	Revision  int       `json:"revision"`
+	Status    string    `json:"status"`
+	EditedBy  string    `json:"editedBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	// ChangedKeys lists the data paths that differ from the previous revision.
+	ChangedKeys []string `json:"changedKeys"`
+}
+
+// FieldChange is one value that differs between two revisions. Revision,
+// ChangedAt and ChangedBy tell which revision in between last changed it.
+type FieldChange struct {
+	Key       string      `json:"key"`
+	Change    string      `json:"change"`
+	Before    interface{} `json:"before,omitempty"`
+	After     interface{} `json:"after,omitempty"`
+	Revision  int         `json:"revision"`
+	ChangedAt time.Time   `json:"changedAt"`
+	ChangedBy string      `json:"changedBy,omitempty"`
+}
+
+// RevisionDiff lists the form data values that differ between two revisions
+// of a submission.
+type RevisionDiff struct {
+	SubmissionID  string        `json:"submissionId"`
+	From          int           `json:"from"`
+	To            int           `json:"to"`
+	StatusChanged bool          `json:"statusChanged"`
+	Changes       []FieldChange `json:"changes"`
+}
+
+// recordRevision stores the submission's current data as its next revision.
+// A submission created before revisions were kept first gets its stored
+// data recorded as revision 1, so the first edit still shows up as a change.
+func recordRevision(tx *gorm.DB, submission *gormmodels.FormSubmission, editedBy string) error {
+	var latest int
+	err := tx.Model(&gormmodels.SubmissionRevision{}).Where("submission_id = ?", submission.ID).
+		Select("COALESCE(MAX(revision), 0)").Scan(&latest).Error
+	if err != nil {
+		return err
+	}
+
+	if latest == 0 {
+		var stored gormmodels.FormSubmission
+		result := tx.Select("id", "form_data", "status", "metadata", "updated_at").Where("id = ?", submission.ID).Limit(1).Find(&stored)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 && !reflect.DeepEqual(stored.FormData, submission.FormData) {
+			baseline := gormmodels.SubmissionRevision{
+				SubmissionID: submission.ID,
+				Revision:     1,
+				FormData:     stored.FormData,
+				Status:       stored.Status,
+				CreatedAt:    stored.UpdatedAt,
+			}
+			if stored.Metadata != nil {
+				baseline.EditedBy = stored.Metadata.UserID
+			}
+			if err := tx.Create(&baseline).Error; err != nil {
+				return err
+			}
+			latest = 1
+		}
+	}
+
+	return tx.Create(&gormmodels.SubmissionRevision{
+		SubmissionID: submission.ID,
+		Revision:     latest + 1,
+		FormData:     submission.FormData,
+		Status:       submission.Status,
+		EditedBy:     editedBy,
+	}).Error
+}
+
+// Revisions lists the revisions of a submission, oldest first.
+func (s *FormService) Revisions(submissionID string) ([]RevisionSummary, error) {
+	revisions, err := s.loadRevisions(submissionID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]RevisionSummary, len(revisions))
+	for i, revision := range revisions {
+		summaries[i] = RevisionSummary{
+			Revision:    revision.Revision,
+			Status:      revision.Status,
+			EditedBy:    revision.EditedBy,
+			CreatedAt:   revision.CreatedAt,
+			ChangedKeys: []string{},
+		}
+		previous := map[string]interface{}{}
+		if i > 0 {
+			previous = flattenData(revisions[i-1].FormData)
+		}
+		for key := range diffData(previous, flattenData(revision.FormData)) {
+			summaries[i].ChangedKeys = append(summaries[i].ChangedKeys, key)
+		}
+		sort.Strings(summaries[i].ChangedKeys)
+	}
+	return summaries, nil
+}
+
+// DiffRevisions compares revision from with revision to of a submission.
+// Nested values are compared by data path, e.g. "applicant.address.province".
+// A zero to means the latest revision and a zero from the one before to.
+func (s *FormService) DiffRevisions(submissionID string, from, to int) (*RevisionDiff, error) {
+	if from < 0 || to < 0 {
+		return nil, newError(ErrValidation, "revisions must be positive")
+	}
+
+	if to == 0 {
+		err := internal.ReadDB().Model(&gormmodels.SubmissionRevision{}).Where("submission_id = ?", submissionID).
+			Select("COALESCE(MAX(revision), 0)").Scan(&to).Error
+		if err != nil {
+			return nil, storageError("failed to fetch submission revisions", err)
+		}
+		if to == 0 {
+			return nil, newError(ErrNotFound, "submission has no revisions")
+		}
+	}
+	if from == 0 {
+		from = max(to-1, 1)
+	}
+	if from > to {
+		return nil, newError(ErrValidation, "from must not be after to")
+	}
+
+	revisions, err := s.loadRevisions(submissionID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if len(revisions) == 0 || revisions[0].Revision != from || revisions[len(revisions)-1].Revision != to {
+		return nil, newErrorf(ErrNotFound, "revision %d or %d not found", from, to)
+	}
+
+	first, last := revisions[0], revisions[len(revisions)-1]
+	diff := &RevisionDiff{
+		SubmissionID:  submissionID,
+		From:          from,
+		To:            to,
+		StatusChanged: first.Status != last.Status,
+		Changes:       []FieldChange{},
+	}
+
+	before, after := flattenData(first.FormData), flattenData(last.FormData)
+	changed := diffData(before, after)
+	keys := make([]string, 0, len(changed))
+	for key := range changed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Walk the revisions in between to find which one last touched each key.
+	lastChange := make(map[string]gormmodels.SubmissionRevision, len(keys))
+	previous := before
+	for _, revision := range revisions[1:] {
+		current := flattenData(revision.FormData)
+		for key := range diffData(previous, current) {
+			if changed[key] != "" {
+				lastChange[key] = revision
+			}
+		}
+		previous = current
+	}
+
+	for _, key := range keys {
+		revision := lastChange[key]
+		diff.Changes = append(diff.Changes, FieldChange{
+			Key:       key,
+			Change:    changed[key],
+			Before:    before[key],
+			After:     after[key],
+			Revision:  revision.Revision,
+			ChangedAt: revision.CreatedAt,
+			ChangedBy: revision.EditedBy,
+		})
+	}
+	return diff, nil
+}
+
+// loadRevisions loads the revisions of a submission, oldest first, limited to
+// from..to when to is positive.
+func (s *FormService) loadRevisions(submissionID string, from, to int) ([]gormmodels.SubmissionRevision, error) {
+	query := internal.ReadDB().Where("submission_id = ?", submissionID)
+	if to > 0 {
+		query = query.Where("revision BETWEEN ? AND ?", from, to)
+	}
+
+	var revisions []gormmodels.SubmissionRevision
+	if err := query.Order("revision").Find(&revisions).Error; err != nil {
+		return nil, storageError("failed to fetch submission revisions", err)
+	}
+	return revisions, nil
+}
+
+// diffData returns the kind of change of every key that differs.
+func diffData(before, after map[string]interface{}) map[string]string {
+	changes := make(map[string]string)
+	for key, value := range after {
+		previous, ok := before[key]
+		switch {
+		case !ok:
+			changes[key] = FieldAdded
+		case !reflect.DeepEqual(previous, value):
+			changes[key] = FieldChanged
+		}
+	}
+	for key := range before {
+		if _, ok := after[key]; !ok {
+			changes[key] = FieldRemoved
+		}
+	}
+	return changes
+}
+
+// flattenData maps every leaf value of data to its data path.
+func flattenData(data map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	for key, value := range data {
+		flattenValue(flat, key, value)
+	}
+	return flat
+}
+
+func flattenValue(flat map[string]interface{}, path string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			flat[path] = v
+		}
+		for key, child := range v {
+			flattenValue(flat, path+"."+key, child)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			flat[path] = v
+		}
+		for i, child := range v {
+			flattenValue(flat, fmt.Sprintf("%s[%d]", path, i), child)
+		}
+	default:
+		flat[path] = value
+	}
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+const (
+	defaultSuggestions = 10
+	// MaxSuggestions bounds the values one suggestions request returns.
+	MaxSuggestions = 50
+)
+
+// Suggestion is a value submitted before for a field, with how many
+// submissions used it.
+type Suggestion struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// SuggestionService offers the values most often submitted for a field, so
+// forms can autocomplete things like company names and branch addresses.
+type SuggestionService struct {
+	excludedKeys []string
+}
+
+func NewSuggestionService(excludedKeys []string) *SuggestionService {
+	lowered := make([]string, len(excludedKeys))
+	for i, key := range excludedKeys {
+		lowered[i] = strings.ToLower(key)
+	}
+	return &SuggestionService{excludedKeys: lowered}
+}
+
+// Allowed reports whether values of the field may be suggested. Fields marked
+// sensitive, signature and stamp fields, and DataKeys containing an excluded
+// fragment are never suggested.
+func (s *SuggestionService) Allowed(field *gormmodels.Field) bool {
+	if field.Sensitive || field.Type == FieldTypeSignature || field.Type == FieldTypeStamp {
+		return false
+	}
+	dataKey := strings.ToLower(field.DataKey)
+	for _, excluded := range s.excludedKeys {
+		if strings.Contains(dataKey, excluded) {
+			return false
+		}
+	}
+	return true
+}
+
+// Suggest returns the most frequent non-empty text values submitted for
+// dataKey on the template, most used first, optionally only those containing
+// query (case-insensitive). Only submissions the workspace may see are
+// counted, so workspaces the template is shared with are not offered each
+// other's values; expired submissions are not counted either.
+func (s *SuggestionService) Suggest(templateID, workspaceID, dataKey, query string, limit int) ([]Suggestion, error) {
+	if limit <= 0 {
+		limit = defaultSuggestions
+	}
+	if limit > MaxSuggestions {
+		limit = MaxSuggestions
+	}
+
+	extract, args, err := formDataExtract(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	value := "JSON_UNQUOTE(" + extract + ")"
+
+	db := internal.ReadDB().Model(&gormmodels.FormSubmission{}).
+		Select(value+" AS value, COUNT(*) AS count", args...).
+		Where("template_id = ? AND expired_at IS NULL", templateID).
+		Where("JSON_TYPE("+extract+") = 'STRING'", args...).
+		Where(value+" <> ''", args...)
+	db = submissionsVisibleTo(db, workspaceID)
+	if query = strings.TrimSpace(query); query != "" {
+		pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(strings.ToLower(query)) + "%"
+		db = db.Where("LOWER(CONVERT("+value+" USING utf8mb4)) LIKE ?", append(args, pattern)...)
+	}
+
+	var suggestions []Suggestion
+	err = db.Group("value").Order("count DESC, value ASC").Limit(limit).Scan(&suggestions).Error
+	if err != nil {
+		return nil, storageError("failed to fetch suggestions", err)
+	}
+	return suggestions, nil
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+// staticRoot is the directory legacy "/static/..." backgrounds are read from.
+const staticRoot = "./static"
+
+// BackgroundMigration reports what migrating one template's legacy
+// svgBackground did, or would do in a dry run.
+type BackgroundMigration struct {
+	TemplateID string `json:"templateId"`
+	Background string `json:"background"`
+	// Source describes where the page content was read from.
+	Source   string `json:"source,omitempty"`
+	Size     int    `json:"size,omitempty"`
+	Migrated bool   `json:"migrated"`
+	Error    string `json:"error,omitempty"`
+}
+
+// MigrateLegacyBackgrounds backfills page 0 of every template that has a
+// legacy svgBackground but no page files, so that rendering can read pages
+// from SVGFiles alone. The background is copied into a new object owned by
+// the template; the svgBackground value is then set to the template ID, as
+// uploads do. A non-empty templateID limits the migration to one template.
+// Templates that fail are reported and skipped.
+func (s *UploadService) MigrateLegacyBackgrounds(ctx context.Context, templateID string, dryRun bool) ([]BackgroundMigration, error) {
+	query := internal.DB.Model(&gormmodels.Template{}).
+		Where("svg_background <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM svg_files WHERE svg_files.template_id = templates.id)")
+	if templateID != "" {
+		query = query.Where("id = ?", templateID)
+	}
+
+	var templates []gormmodels.Template
+	if err := query.Order("created_at").Find(&templates).Error; err != nil {
+		return nil, storageError("failed to fetch templates", err)
+	}
+
+	migrations := make([]BackgroundMigration, 0, len(templates))
+	for i := range templates {
+		migration := BackgroundMigration{TemplateID: templates[i].ID, Background: templates[i].SVGBackground}
+		if err := s.migrateBackground(ctx, &templates[i], &migration, dryRun); err != nil {
+			migration.Error = err.Error()
+		}
+		migrations = append(migrations, migration)
+	}
+	return migrations, nil
+}
+
+// MigrateLegacyBackground backfills page 0 of one template from its legacy
+// svgBackground. Templates that already have page files are left alone.
+func (s *UploadService) MigrateLegacyBackground(ctx context.Context, templateID string) (*BackgroundMigration, error) {
+	migrations, err := s.MigrateLegacyBackgrounds(ctx, templateID, false)
+	if err != nil || len(migrations) == 0 {
+		return nil, err
+	}
+	if migrations[0].Error != "" {
+		return &migrations[0], newErrorf(ErrValidation, "failed to migrate background of template %s: %s", templateID, migrations[0].Error)
+	}
+	return &migrations[0], nil
+}
+
+func (s *UploadService) migrateBackground(ctx context.Context, template *gormmodels.Template, migration *BackgroundMigration, dryRun bool) error {
+	content, source, err := s.legacyBackground(template.SVGBackground)
+	if err != nil {
+		return err
+	}
+	migration.Source = source
+	migration.Size = len(content)
+	if dryRun {
+		return nil
+	}
+
+	filename := path.Base(source)
+	if !strings.HasSuffix(filename, ".svg") {
+		filename = "background.svg"
+	}
+	objectName := storage.GeneratePageObjectName(template.ID, 0, filename)
+	result, err := s.storageClient.UploadFile(ctx, bytes.NewReader(content), objectName, "image/svg+xml")
+	if err != nil {
+		return fmt.Errorf("failed to upload to storage: %w", err)
+	}
+
+	svgFile := &gormmodels.SVGFile{
+		TemplateID:   template.ID,
+		Filename:     filename,
+		OriginalName: filename,
+		FilePath:     objectName,
+		GCSPath:      objectName,
+		KMSKeyName:   result.KMSKeyName,
+		FileSize:     result.Size,
+		MimeType:     "image/svg+xml",
+		PageIndex:    0,
+	}
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(svgFile).Error; err != nil {
+			return err
+		}
+		return tx.Model(template).UpdateColumn("svg_background", template.ID).Error
+	})
+	if err != nil {
+		s.storageClient.DeleteFile(ctx, objectName)
+		return fmt.Errorf("failed to save file metadata: %w", err)
+	}
+
+	migration.Migrated = true
+	return nil
+}
+
+// LegacyBackground reads the SVG a legacy svgBackground value refers to. The
+// value may be a data URI, a "/static/..." URL or path, an /api/files/svg/ or
+// /api/svg/ URL, or a "templates/{id}/{file}.svg" storage path. Values that
+// cannot be parsed fail with ErrValidation.
+func (s *UploadService) LegacyBackground(background string) ([]byte, error) {
+	content, _, err := s.legacyBackground(background)
+	return content, err
+}
+
+// legacyBackground returns the content of a legacy background and a short
+// description of where it was read from.
+func (s *UploadService) legacyBackground(background string) ([]byte, string, error) {
+	if strings.HasPrefix(background, "data:") {
+		content, err := decodeDataURI(background)
+		return content, "data URI", err
+	}
+
+	if i := strings.Index(background, "/static/"); i >= 0 || strings.HasPrefix(background, "static/") {
+		rel := strings.TrimPrefix(background[max(i, 0):], "/")
+		rel = strings.TrimPrefix(rel, "static/")
+		if u, err := url.Parse(rel); err == nil {
+			rel = u.Path
+		}
+		clean := filepath.Clean("/" + rel)
+		content, err := os.ReadFile(filepath.Join(staticRoot, clean))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read static background: %w", err)
+		}
+		return content, "static" + filepath.ToSlash(clean), nil
+	}
+
+	templateID, svgID, err := parseLegacyBackground(background)
+	if err != nil {
+		return nil, "", err
+	}
+	content, err := s.GetSVGContent(templateID, svgID)
+	if err != nil {
+		return nil, "", err
+	}
+	source := "templates/" + templateID
+	if svgID != "" {
+		source += "/" + svgID + ".svg"
+	}
+	return content, source, nil
+}
+
+// parseLegacyBackground extracts the template and SVG identifier from a
+// legacy background URL; an empty svgID means the template's latest file.
+func parseLegacyBackground(background string) (string, string, error) {
+	if strings.Contains(background, "/api/files/svg/") {
+		parts := strings.Split(background[strings.Index(background, "/api/files/svg/")+1:], "/")
+		if len(parts) >= 6 && parts[4] == "page" && parts[3] != "" {
+			// "/api/files/svg/{templateId}/page/{pageIndex}"
+			return parts[3], "page_" + parts[5], nil
+		}
+		if len(parts) >= 4 && parts[3] != "" {
+			// "/api/files/svg/{templateId}": the template's latest file
+			return parts[3], "", nil
+		}
+	} else if i := strings.Index(background, "/api/svg/"); i >= 0 {
+		// Asset proxy and CDN URLs: ".../api/svg/{templateId}/page_{pageIndex}.svg"
+		parts := strings.Split(background[i+len("/api/svg/"):], "/")
+		if len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+			return parts[0], strings.TrimSuffix(parts[1], ".svg"), nil
+		}
+	} else if strings.Contains(background, "templates/") {
+		// Storage path: "templates/{templateId}/{timestamp}.svg"
+		parts := strings.Split(strings.TrimPrefix(background, "/"), "/")
+		if len(parts) >= 3 && parts[0] == "templates" {
+			return parts[1], strings.TrimSuffix(parts[2], ".svg"), nil
+		}
+	} else {
+		return "", "", newErrorf(ErrValidation, "unsupported SVG background %q: expected a data URI, a /static/, /api/files/svg/ or /api/svg/ URL or a templates/ storage path", background)
+	}
+	return "", "", newErrorf(ErrValidation, "invalid SVG background URL %q", background)
+}
+
+// decodeDataURI returns the content of a base64 or percent-encoded data URI.
+func decodeDataURI(uri string) ([]byte, error) {
+	header, data, ok := strings.Cut(strings.TrimPrefix(uri, "data:"), ",")
+	if !ok {
+		return nil, newError(ErrValidation, "invalid data URI")
+	}
+	if strings.HasSuffix(header, ";base64") {
+		content, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, newErrorf(ErrValidation, "invalid base64 data URI: %v", err)
+		}
+		return content, nil
+	}
+	content, err := url.PathUnescape(data)
+	if err != nil {
+		return nil, newErrorf(ErrValidation, "invalid data URI: %v", err)
+	}
+	return []byte(content), nil
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+// ErrSyncConflict is returned when both sides changed a template since the last sync.
+var ErrSyncConflict = newError(ErrConflict, "template was modified on both sides since last sync")
+
+type SyncService struct {
+	storageClient   storage.StorageClient
+	templateService *TemplateService
+	httpClient      *http.Client
+}
+
+func NewSyncService(storageClient storage.StorageClient, templateService *TemplateService) *SyncService {
+	return &SyncService{
+		storageClient:   storageClient,
+		templateService: templateService,
+		httpClient:      &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// TemplateBundle is the wire format exchanged between FastFill instances.
+type TemplateBundle struct {
+	Template gormmodels.Template `json:"template"`
+	Assets   []SyncAsset         `json:"assets"`
+	Hash     string              `json:"hash"`
+}
+
+type SyncAsset struct {
+	PageIndex    int    `json:"pageIndex"`
+	Filename     string `json:"filename"`
+	OriginalName string `json:"originalName"`
+	MimeType     string `json:"mimeType"`
+	Content      []byte `json:"content"`
+}
+
+// Export builds a bundle for the given template, or nil if it does not exist.
+func (s *SyncService) Export(ctx context.Context, templateID string) (*TemplateBundle, error) {
+	template, err := s.templateService.GetByID(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, nil
+	}
+
+	assets := make([]SyncAsset, 0, len(template.SVGFiles))
+	for _, svgFile := range template.SVGFiles {
+		content, err := s.storageClient.ReadFile(ctx, svgFile.GCSPath)
+		if err != nil {
+			return nil, storageError(fmt.Sprintf("failed to read asset for page %d", svgFile.PageIndex), err)
+		}
+		assets = append(assets, SyncAsset{
+			PageIndex:    svgFile.PageIndex,
+			Filename:     svgFile.Filename,
+			OriginalName: svgFile.OriginalName,
+			MimeType:     svgFile.MimeType,
+			Content:      content,
+		})
+	}
+
+	bundle := &TemplateBundle{Template: *template, Assets: assets}
+	bundle.Template.SVGFiles = nil
+	bundle.Template.Submissions = nil
+	bundle.Template.Calibrations = nil
+	bundle.Hash = bundleHash(bundle)
+	return bundle, nil
+}
+
+// Import applies a bundle locally. Unless force is set, it refuses to
+// overwrite a template that changed locally since it was last synced.
+func (s *SyncService) Import(ctx context.Context, bundle *TemplateBundle, force bool) error {
+	if bundle.Hash != bundleHash(bundle) {
+		return newError(ErrValidation, "bundle hash mismatch")
+	}
+
+	existing, err := s.templateService.GetByID(bundle.Template.ID)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil && !force {
+		local, err := s.Export(ctx, existing.ID)
+		if err != nil {
+			return err
+		}
+		if local.Hash == bundle.Hash {
+			return nil
+		}
+		if existing.SyncHash != "" && local.Hash != existing.SyncHash {
+			return ErrSyncConflict
+		}
+	}
+
+	var uploaded []string
+	var replaced []gormmodels.SVGFile
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		template := bundle.Template
+		template.SyncHash = bundle.Hash
+		fields := template.Fields
+		template.Fields = nil
+		template.Calibrations = nil
+		// Versions are local to each instance
+		template.Version = 0
+
+		if existing == nil {
+			if err := tx.Create(&template).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(&template).Select("*").Omit("created_at", "version").Updates(&template).Error; err != nil {
+			return err
+		} else if _, err := bumpVersion(tx, template.ID, 0); err != nil {
+			return err
+		}
+
+		if err := tx.Where("template_id = ?", template.ID).Delete(&gormmodels.Field{}).Error; err != nil {
+			return err
+		}
+		for i := range fields {
+			fields[i].ID = 0
+			fields[i].TemplateID = template.ID
+			if err := tx.Create(&fields[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("template_id = ?", template.ID).Find(&replaced).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&gormmodels.SVGFile{}).Error; err != nil {
+			return err
+		}
+
+		for _, asset := range bundle.Assets {
+			objectName := fmt.Sprintf("templates/%s/%d_page%d%s", template.ID, time.Now().Unix(), asset.PageIndex, assetExt(asset.Filename))
+			result, err := s.storageClient.UploadFile(ctx, bytes.NewReader(asset.Content), objectName, asset.MimeType)
+			if err != nil {
+				return err
+			}
+			uploaded = append(uploaded, objectName)
+
+			svgFile := &gormmodels.SVGFile{
+				TemplateID:   template.ID,
+				Filename:     asset.Filename,
+				OriginalName: asset.OriginalName,
+				FilePath:     objectName,
+				GCSPath:      objectName,
+				KMSKeyName:   result.KMSKeyName,
+				FileSize:     result.Size,
+				MimeType:     asset.MimeType,
+				PageIndex:    asset.PageIndex,
+			}
+			if err := tx.Create(svgFile).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		for _, objectName := range uploaded {
+			s.storageClient.DeleteFile(ctx, objectName)
+		}
+		return storageError("failed to import template", err)
+	}
+
+	for _, svgFile := range replaced {
+		if svgFile.GCSPath != "" {
+			s.storageClient.DeleteFile(ctx, svgFile.GCSPath)
+		}
+	}
+
+	return nil
+}
+
+// MarkSynced records the hash both sides agree on after a successful push.
+func (s *SyncService) MarkSynced(templateID, hash string) error {
+	err := internal.DB.Model(&gormmodels.Template{}).Where("id = ?", templateID).UpdateColumn("sync_hash", hash).Error
+	if err != nil {
+		return storageError("failed to record sync hash", err)
+	}
+	return nil
+}
+
+// Push sends a local template to a remote instance.
+func (s *SyncService) Push(ctx context.Context, remoteURL, apiKey, templateID string, force bool) (*TemplateBundle, error) {
+	bundle, err := s.Export(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return nil, newErrorf(ErrNotFound, "template %s not found", templateID)
+	}
+
+	body, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bundle: %w", err)
+	}
+
+	endpoint := strings.TrimRight(remoteURL, "/") + "/api/sync/import"
+	if force {
+		endpoint += "?force=true"
+	}
+
+	resp, err := s.doRemote(ctx, http.MethodPost, endpoint, apiKey, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return nil, ErrSyncConflict
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("remote import failed: status %d: %s", resp.StatusCode, msg)
+	}
+
+	if err := s.MarkSynced(templateID, bundle.Hash); err != nil {
+		return nil, err
+	}
+
+	return bundle, nil
+}
+
+// Pull fetches a template from a remote instance and imports it locally.
+func (s *SyncService) Pull(ctx context.Context, remoteURL, apiKey, templateID string, force bool) (*TemplateBundle, error) {
+	endpoint := strings.TrimRight(remoteURL, "/") + "/api/sync/templates/" + templateID
+
+	resp, err := s.doRemote(ctx, http.MethodGet, endpoint, apiKey, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("remote export failed: status %d: %s", resp.StatusCode, msg)
+	}
+
+	var bundle TemplateBundle
+	if err := json.NewDecoder(resp.Body).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode remote bundle: %w", err)
+	}
+
+	if err := s.Import(ctx, &bundle, force); err != nil {
+		return nil, err
+	}
+
+	return &bundle, nil
+}
+
+func (s *SyncService) doRemote(ctx context.Context, method, endpoint, apiKey string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", apiKey)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach remote instance: %w", err)
+	}
+	return resp, nil
+}
+
+// bundleLocalKeys are the template attributes that differ between instances
+// holding the same template: timestamps, versions and usage counters.
+var bundleLocalKeys = []string{"createdAt", "updatedAt", "version", "submissionCount", "lastSubmissionAt", "documentCount"}
+
+// bundleHash fingerprints the whole exported bundle, ignoring database IDs,
+// timestamps and other local attributes so identical templates hash the same
+// on every instance. Fields are compared as a set, in no particular order.
+func bundleHash(bundle *TemplateBundle) string {
+	encoded, _ := json.Marshal(struct {
+		Template gormmodels.Template `json:"template"`
+		Assets   []SyncAsset         `json:"assets"`
+	}{bundle.Template, bundle.Assets})
+
+	var content map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	decoder.Decode(&content)
+
+	template, _ := content["template"].(map[string]interface{})
+	for _, key := range bundleLocalKeys {
+		delete(template, key)
+	}
+
+	fields, _ := template["fields"].([]interface{})
+	for _, field := range fields {
+		if f, ok := field.(map[string]interface{}); ok {
+			delete(f, "id")
+			delete(f, "templateId")
+			delete(f, "createdAt")
+			delete(f, "updatedAt")
+		}
+	}
+	sortByJSON(fields)
+
+	assets, _ := content["assets"].([]interface{})
+	sortByJSON(assets)
+
+	// Maps marshal with sorted keys, so the payload is canonical.
+	payload, _ := json.Marshal(content)
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+func sortByJSON(values []interface{}) {
+	keys := make([]string, len(values))
+	for i, value := range values {
+		key, _ := json.Marshal(value)
+		keys[i] = string(key)
+	}
+	sort.Sort(byKey{values, keys})
+}
+
+// byKey sorts values by their precomputed keys.
+type byKey struct {
+	values []interface{}
+	keys   []string
+}
+
+func (b byKey) Len() int           { return len(b.values) }
+func (b byKey) Less(i, j int) bool { return b.keys[i] < b.keys[j] }
+func (b byKey) Swap(i, j int) {
+	b.values[i], b.values[j] = b.values[j], b.values[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+func assetExt(filename string) string {
+	if i := strings.LastIndex(filename, "."); i >= 0 {
+		return filename[i:]
+	}
+	return ".svg"
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+	"github.com/dhanavadh/fastfill-backend/internal/storage"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Temporary upload defaults, used when the configuration leaves them unset.
+const (
+	DefaultTempUploadTTL     = 24 * time.Hour
+	DefaultTempUploadMaxSize = 20 << 20
+)
+
+// tempUploadSweepInterval is how often expired temporary uploads are purged.
+const tempUploadSweepInterval = 15 * time.Minute
+
+// ErrTempUploadTooLarge rejects temporary uploads over the size limit.
+var ErrTempUploadTooLarge = newError(ErrValidation, "file is too large")
+
+// TempUploadService keeps files uploaded before the template they belong to
+// exists. Each upload belongs to the user who made it and is deleted, with
+// its object, once it expires without being attached.
+type TempUploadService struct {
+	storageClient storage.StorageClient
+
+	// TTL is how long an unattached upload is kept; zero means
+	// DefaultTempUploadTTL.
+	TTL time.Duration
+	// MaxSize bounds each upload, in bytes; zero means
+	// DefaultTempUploadMaxSize.
+	MaxSize int64
+}
+
+func NewTempUploadService(storageClient storage.StorageClient) *TempUploadService {
+	return &TempUploadService{storageClient: storageClient}
+}
+
+func (s *TempUploadService) ttl() time.Duration {
+	if s.TTL <= 0 {
+		return DefaultTempUploadTTL
+	}
+	return s.TTL
+}
+
+// Limit returns the largest upload accepted, in bytes.
+func (s *TempUploadService) Limit() int64 {
+	if s.MaxSize <= 0 {
+		return DefaultTempUploadMaxSize
+	}
+	return s.MaxSize
+}
+
+// Upload stores a file in the hold area for userID. Files over the size limit
+// fail with ErrTempUploadTooLarge and leave nothing behind.
+func (s *TempUploadService) Upload(ctx context.Context, userID, workspaceID, filename, mimeType string, file io.Reader) (*gormmodels.TempUpload, error) {
+	upload := &gormmodels.TempUpload{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		Filename:    filepath.Base(filename),
+		MimeType:    mimeType,
+	}
+	upload.GCSPath = fmt.Sprintf("uploads/tmp/%s%s", upload.ID, filepath.Ext(upload.Filename))
+
+	limit := s.Limit()
+	result, err := s.storageClient.UploadFile(ctx, io.LimitReader(file, limit+1), upload.GCSPath, mimeType)
+	if err != nil {
+		return nil, storageError("failed to upload to storage", err)
+	}
+	if result.Size > limit {
+		s.removeObject(ctx, upload.GCSPath)
+		return nil, ErrTempUploadTooLarge
+	}
+
+	upload.Size = result.Size
+	upload.KMSKeyName = result.KMSKeyName
+	upload.ExpiresAt = time.Now().Add(s.ttl())
+	if err := internal.DB.Create(upload).Error; err != nil {
+		s.removeObject(ctx, upload.GCSPath)
+		return nil, storageError("failed to save upload", err)
+	}
+	return upload, nil
+}
+
+// List returns the user's unexpired uploads, newest first.
+func (s *TempUploadService) List(userID string) ([]gormmodels.TempUpload, error) {
+	var uploads []gormmodels.TempUpload
+
+	err := internal.DB.Where("user_id = ? AND expires_at > ?", userID, time.Now()).Order("created_at DESC").Find(&uploads).Error
+	if err != nil {
+		return nil, storageError("failed to fetch uploads", err)
+	}
+
+	return uploads, nil
+}
+
+// Get returns one of the user's unexpired uploads, or nil if there is none.
+func (s *TempUploadService) Get(id, userID string) (*gormmodels.TempUpload, error) {
+	var upload gormmodels.TempUpload
+
+	err := internal.DB.Where("id = ? AND user_id = ? AND expires_at > ?", id, userID, time.Now()).First(&upload).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch upload", err)
+	}
+
+	return &upload, nil
+}
+
+// Delete discards one of the user's uploads and its object.
+func (s *TempUploadService) Delete(ctx context.Context, id, userID string) error {
+	upload, err := s.Get(id, userID)
+	if err != nil {
+		return err
+	}
+	if upload == nil {
+		return newError(ErrNotFound, "upload not found")
+	}
+
+	if err := internal.DB.Delete(upload).Error; err != nil {
+		return storageError("failed to delete upload", err)
+	}
+	s.removeObject(ctx, upload.GCSPath)
+	return nil
+}
+
+// Purge deletes the uploads that expired without being attached, and their
+// objects, returning how many were removed.
+func (s *TempUploadService) Purge(ctx context.Context) (int, error) {
+	var expired []gormmodels.TempUpload
+	if err := internal.DB.Where("expires_at <= ?", time.Now()).Limit(500).Find(&expired).Error; err != nil {
+		return 0, storageError("failed to fetch expired uploads", err)
+	}
+
+	purged := 0
+	for i := range expired {
+		// The record goes first: an attach racing the purge then fails
+		// instead of pointing a page at a deleted object.
+		result := internal.DB.Delete(&expired[i])
+		if result.Error != nil {
+			return purged, storageError("failed to delete expired upload", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		s.removeObject(ctx, expired[i].GCSPath)
+		purged++
+	}
+	return purged, nil
+}
+
+// StartSweeper purges expired uploads periodically until ctx is cancelled.
+func (s *TempUploadService) StartSweeper(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(tempUploadSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				purged, err := s.Purge(ctx)
+				if err != nil {
+					log.Printf("Warning: temporary upload purge failed: %v", err)
+				} else if purged > 0 {
+					log.Printf("Purged %d expired temporary upload(s)", purged)
+				}
+			}
+		}
+	}()
+}
+
+func (s *TempUploadService) removeObject(ctx context.Context, objectName string) {
+	if err := s.storageClient.DeleteFile(ctx, objectName); err != nil {
+		log.Printf("Warning: failed to delete temporary upload %s: %v", objectName, err)
+	}
+}
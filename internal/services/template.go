@@ -1,7 +1,8 @@
 package services
 
 import (
-	"fmt"
+	"slices"
+	"time"
 
 	"github.com/dhanavadh/fastfill-backend/internal"
 	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
@@ -9,51 +10,213 @@ import (
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrVersionConflict is returned when a template changed since the version
+	// the caller based its update on.
+	ErrVersionConflict  = newError(ErrConflict, "template version conflict")
+	ErrTemplateNotFound = newError(ErrNotFound, "template not found")
+	ErrTemplateArchived = newError(ErrConflict, "template is archived")
+)
+
 type TemplateService struct{}
 
 func NewTemplateService() *TemplateService {
 	return &TemplateService{}
 }
 
-func (s *TemplateService) GetAll() ([]gormmodels.Template, error) {
+// TemplateFilter narrows template listings. Empty fields match everything;
+// Search matches display name and description. Preload names the
+// associations Find loads with each template. VisibleTo, when set, limits
+// results to templates that workspace (empty for anonymous callers) may view.
+// Archived, when set, matches only archived or only active templates.
+type TemplateFilter struct {
+	Category string
+	Search   string
+	// Sort is one of TemplateSorts; empty sorts newest first.
+	Sort      string
+	Limit     int
+	Offset    int
+	Preload   []string
+	VisibleTo *string
+	Archived  *bool
+}
+
+// templateSortOrders maps the sorts of the template list to their ORDER BY.
+// The ID breaks ties, so pages do not overlap.
+var templateSortOrders = map[string]string{
+	"createdAt":        "templates.created_at ASC, templates.id ASC",
+	"-createdAt":       "templates.created_at DESC, templates.id DESC",
+	"updatedAt":        "templates.updated_at ASC, templates.id ASC",
+	"-updatedAt":       "templates.updated_at DESC, templates.id DESC",
+	"name":             "display_name ASC, templates.id ASC",
+	"-name":            "display_name DESC, templates.id DESC",
+	"submissionCount":  "submission_count ASC, templates.id ASC",
+	"-submissionCount": "submission_count DESC, templates.id DESC",
+}
+
+// TemplateSorts lists the sorts of the template list; a leading "-" sorts
+// descending.
+func TemplateSorts() []string {
+	sorts := make([]string, 0, len(templateSortOrders))
+	for sort := range templateSortOrders {
+		sorts = append(sorts, sort)
+	}
+	slices.Sort(sorts)
+	return sorts
+}
+
+func (f TemplateFilter) order() string {
+	if order, ok := templateSortOrders[f.Sort]; ok {
+		return order
+	}
+	return templateSortOrders["-createdAt"]
+}
+
+func (f TemplateFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.VisibleTo != nil {
+		query = visibleTo(query, *f.VisibleTo)
+	}
+	if f.Archived != nil {
+		if *f.Archived {
+			query = query.Where("templates.archived_at IS NOT NULL")
+		} else {
+			query = query.Where("templates.archived_at IS NULL")
+		}
+	}
+	if f.Category != "" {
+		query = query.Where("category = ?", f.Category)
+	}
+	if f.Search != "" {
+		pattern := "%" + f.Search + "%"
+		query = query.Where("display_name LIKE ? OR description LIKE ?", pattern, pattern)
+	}
+	return query
+}
+
+// Find returns a page of templates matching the filter, in the filter's sort
+// order, and the total number of matches.
+func (s *TemplateService) Find(filter TemplateFilter) ([]gormmodels.Template, int64, error) {
+	query := filter.apply(internal.ReadDB().Model(&gormmodels.Template{}))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, storageError("failed to count templates", err)
+	}
+
+	for _, association := range filter.Preload {
+		query = query.Preload(association)
+	}
+
 	var templates []gormmodels.Template
+	err := query.Order(filter.order()).Limit(filter.Limit).Offset(filter.Offset).Find(&templates).Error
+	if err != nil {
+		return nil, 0, storageError("failed to fetch templates", err)
+	}
 
-	err := internal.DB.Preload("Fields").Preload("SVGFiles").Order("created_at DESC").Find(&templates).Error
+	return templates, total, nil
+}
+
+// TemplateSummary is the list view of a template, without its fields and pages.
+type TemplateSummary struct {
+	ID           string     `json:"id"`
+	DisplayName  string     `json:"displayName"`
+	Description  string     `json:"description"`
+	Category     string     `json:"category"`
+	PreviewImage string     `json:"previewImage"`
+	Version      int        `json:"version"`
+	WorkspaceID  string     `json:"workspaceId,omitempty"`
+	ArchivedAt   *time.Time `json:"archivedAt,omitempty"`
+	FieldCount   int64      `json:"fieldCount"`
+	SVGFileCount int64      `json:"svgFileCount"`
+	// SubmissionCount, LastSubmissionAt and DocumentCount are the template's
+	// maintained counters, so listing them costs no COUNT over submissions.
+	SubmissionCount  int64      `json:"submissionCount"`
+	LastSubmissionAt *time.Time `json:"lastSubmissionAt,omitempty"`
+	DocumentCount    int64      `json:"documentCount"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
+}
+
+// FindSummaries is Find for the list view: it reads only the summary columns
+// and counts fields and SVG files in the database.
+func (s *TemplateService) FindSummaries(filter TemplateFilter) ([]TemplateSummary, int64, error) {
+	query := filter.apply(internal.ReadDB().Model(&gormmodels.Template{}))
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, storageError("failed to count templates", err)
+	}
+
+	var summaries []TemplateSummary
+	err := query.Select("templates.id, display_name, description, category, preview_image, version, templates.workspace_id, archived_at, created_at, updated_at, " +
+		"submission_count, last_submission_at, document_count, " +
+		"(SELECT COUNT(*) FROM template_fields WHERE template_fields.template_id = templates.id) AS field_count, " +
+		"(SELECT COUNT(*) FROM svg_files WHERE svg_files.template_id = templates.id) AS svg_file_count").
+		Order(filter.order()).Limit(filter.Limit).Offset(filter.Offset).Scan(&summaries).Error
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch templates: %w", err)
+		return nil, 0, storageError("failed to fetch templates", err)
 	}
 
-	return templates, nil
+	return summaries, total, nil
 }
 
 func (s *TemplateService) GetByID(id string) (*gormmodels.Template, error) {
 	var template gormmodels.Template
 
-	err := internal.DB.Preload("Fields").Preload("SVGFiles").Where("id = ?", id).First(&template).Error
+	err := internal.DB.Preload("Fields").Preload("SVGFiles").Preload("Calibrations").Where("id = ?", id).First(&template).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to fetch template: %w", err)
+		return nil, storageError("failed to fetch template", err)
 	}
 
 	return &template, nil
 }
 
 func (s *TemplateService) Create(template *gormmodels.Template) error {
-	err := internal.DB.Create(template).Error
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		return createTemplate(tx, template)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create template: %w", err)
+		return storageError("failed to create template", err)
 	}
 	return nil
 }
 
+// createTemplate inserts a new template and its fields at version 1.
+func createTemplate(tx *gorm.DB, template *gormmodels.Template) error {
+	template.Version = 1
+	if err := tx.Create(template).Error; err != nil {
+		return err
+	}
+	return recordEvent(tx, gormmodels.EventTemplateCreated, template.ID, template.ID, nil)
+}
+
+// Update replaces a template's settings and fields. A non-zero Version is
+// the version the caller last read; if the template changed since,
+// ErrVersionConflict is returned and nothing is written. On success Version
+// holds the new version.
 func (s *TemplateService) Update(template *gormmodels.Template) error {
+	expected := template.Version
+	version := 0
 	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if version, err = bumpVersion(tx, template.ID, expected); err != nil {
+			return err
+		}
+		// Version is maintained by bumpVersion; a zero value is skipped by Updates.
+		template.Version = 0
+
 		if err := tx.Model(template).Updates(template).Error; err != nil {
 			return err
 		}
 
+		// Updates skips zero values, so write the output settings explicitly.
+		if err := tx.Model(template).Select("optimize_pdf", "optimize_dpi", "pdf_metadata", "print_options", "thai_word_break", "suppress_background", "script_fonts", "custom_css", "duplicate_policy", "expiry_policy", "validation_webhook", "continuation", "efiling", "public_preview").Updates(template).Error; err != nil {
+			return err
+		}
+
 		if err := tx.Where("template_id = ?", template.ID).Delete(&gormmodels.Field{}).Error; err != nil {
 			return err
 		}
@@ -65,15 +228,68 @@ func (s *TemplateService) Update(template *gormmodels.Template) error {
 			}
 		}
 
-		return nil
+		return recordEvent(tx, gormmodels.EventTemplateUpdated, template.ID, template.ID, map[string]interface{}{"version": version})
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to update template: %w", err)
+		template.Version = expected
+		return storageError("failed to update template", err)
 	}
+	template.Version = version
 	return nil
 }
 
+// bumpVersion increments a template's version and returns the new value. When
+// expected is non-zero and no longer current it fails with ErrVersionConflict;
+// a missing template fails with ErrTemplateNotFound.
+func bumpVersion(tx *gorm.DB, templateID string, expected int) (int, error) {
+	query := tx.Model(&gormmodels.Template{}).Where("id = ?", templateID)
+	if expected > 0 {
+		query = query.Where("version = ?", expected)
+	}
+
+	result := query.UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	var versions []int
+	if err := tx.Model(&gormmodels.Template{}).Where("id = ?", templateID).Pluck("version", &versions).Error; err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, ErrTemplateNotFound
+	}
+	if result.RowsAffected == 0 && expected > 0 {
+		return 0, ErrVersionConflict
+	}
+	return versions[0], nil
+}
+
+// SetArchived archives or restores a template. Archiving an archived template
+// keeps its original archive time.
+func (s *TemplateService) SetArchived(id string, archived bool) (*gormmodels.Template, error) {
+	query := internal.DB.Model(&gormmodels.Template{}).Where("id = ?", id)
+	var err error
+	if archived {
+		err = query.Where("archived_at IS NULL").UpdateColumn("archived_at", time.Now()).Error
+	} else {
+		err = query.UpdateColumn("archived_at", nil).Error
+	}
+	if err != nil {
+		return nil, storageError("failed to archive template", err)
+	}
+
+	template, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+	return template, nil
+}
+
 func (s *TemplateService) Delete(id string) error {
 	err := internal.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("template_id = ?", id).Delete(&gormmodels.Field{}).Error; err != nil {
@@ -84,15 +300,48 @@ func (s *TemplateService) Delete(id string) error {
 			return err
 		}
 
-		if err := tx.Where("id = ?", id).Delete(&gormmodels.Template{}).Error; err != nil {
+		if err := tx.Where("template_id = ?", id).Delete(&gormmodels.PageCalibration{}).Error; err != nil {
 			return err
 		}
 
+		if err := tx.Where("template_id = ?", id).Delete(&gormmodels.InboundIntegration{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("template_id = ?", id).Delete(&gormmodels.TemplateInclude{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("template_id = ?", id).Delete(&gormmodels.FillLink{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("template_id = ?", id).Delete(&gormmodels.ImportProfile{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("template_id = ?", id).Delete(&gormmodels.TemplateShare{}).Error; err != nil {
+			return err
+		}
+
+		// Recorded before the template row goes, while its workspace is known.
+		if err := recordEvent(tx, gormmodels.EventTemplateDeleted, id, id, nil); err != nil {
+			return err
+		}
+
+		result := tx.Where("id = ?", id).Delete(&gormmodels.Template{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTemplateNotFound
+		}
+
 		return nil
 	})
 
 	if err != nil {
-		return fmt.Errorf("failed to delete template: %w", err)
+		return storageError("failed to delete template", err)
 	}
 	return nil
 }
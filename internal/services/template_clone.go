@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// svgUnitPx converts the absolute units of SVG lengths to CSS pixels.
+var svgUnitPx = map[string]float64{
+	"":   1,
+	"px": 1,
+	"pt": 96.0 / 72,
+	"pc": 16,
+	"mm": 96 / 25.4,
+	"cm": 96 / 2.54,
+	"in": 96,
+}
+
+// PageRemap reports how the fields of one page were moved onto its new
+// background. Sizes are in CSS pixels.
+type PageRemap struct {
+	PageIndex int     `json:"pageIndex"`
+	OldWidth  float64 `json:"oldWidth"`
+	OldHeight float64 `json:"oldHeight"`
+	NewWidth  float64 `json:"newWidth"`
+	NewHeight float64 `json:"newHeight"`
+	ScaleX    float64 `json:"scaleX"`
+	ScaleY    float64 `json:"scaleY"`
+	Fields    int     `json:"fields"`
+}
+
+// CloneResult is a template cloned onto new backgrounds.
+type CloneResult struct {
+	Template *gormmodels.Template `json:"template"`
+	Pages    []PageRemap          `json:"pages"`
+}
+
+// CloneWithBackgrounds copies a template into workspaceID like Copy, giving
+// the pages in pages new backgrounds, for example this year's revision of a
+// form. The fields of each such page are rescaled by the ratio of the new
+// background's size to the old one's, so they follow a layout that was only
+// stretched or shrunk; other pages keep their backgrounds and fields. An empty
+// displayName keeps the original's.
+func (s *ShareService) CloneWithBackgrounds(ctx context.Context, templateID, workspaceID, displayName string, pages []PageUpload) (*CloneResult, error) {
+	if err := checkPageUploads(pages); err != nil {
+		return nil, err
+	}
+
+	bundle, err := s.syncService.Export(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if bundle == nil {
+		return nil, ErrTemplateNotFound
+	}
+	if len(bundle.Assets) == 0 {
+		return nil, newError(ErrValidation, "template has no page files to replace; migrate its legacy background first")
+	}
+
+	remaps := make([]PageRemap, 0, len(pages))
+	for _, page := range pages {
+		asset := bundleAsset(bundle, page.PageIndex)
+		if asset == nil {
+			return nil, newErrorf(ErrValidation, "page %d is not a page of the template", page.PageIndex)
+		}
+
+		content, err := readPageUpload(page)
+		if err != nil {
+			return nil, err
+		}
+
+		remap := PageRemap{PageIndex: page.PageIndex}
+		if remap.OldWidth, remap.OldHeight, err = svgPageSize(asset.Content); err != nil {
+			return nil, newErrorf(ErrValidation, "cannot tell the size of page %d's current background: %v", page.PageIndex, err)
+		}
+		if remap.NewWidth, remap.NewHeight, err = svgPageSize(content); err != nil {
+			return nil, newErrorf(ErrValidation, "cannot tell the size of %s: %v", page.Header.Filename, err)
+		}
+		remap.ScaleX = remap.NewWidth / remap.OldWidth
+		remap.ScaleY = remap.NewHeight / remap.OldHeight
+
+		for i := range bundle.Template.Fields {
+			if field := &bundle.Template.Fields[i]; field.PageIndex == page.PageIndex {
+				scaleField(field, remap.ScaleX, remap.ScaleY)
+				remap.Fields++
+			}
+		}
+
+		asset.Filename = page.Header.Filename
+		asset.OriginalName = page.Header.Filename
+		asset.MimeType = "image/svg+xml"
+		asset.Content = content
+		remaps = append(remaps, remap)
+	}
+
+	rebaseBundle(bundle, workspaceID)
+	if displayName != "" {
+		bundle.Template.DisplayName = displayName
+	}
+	bundle.Hash = bundleHash(bundle)
+
+	if err := s.syncService.Import(ctx, bundle, true); err != nil {
+		return nil, err
+	}
+	if err := s.syncService.MarkSynced(bundle.Template.ID, ""); err != nil {
+		return nil, err
+	}
+
+	template, err := s.syncService.templateService.GetByID(bundle.Template.ID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return nil, ErrTemplateNotFound
+	}
+	return &CloneResult{Template: template, Pages: remaps}, nil
+}
+
+func bundleAsset(bundle *TemplateBundle, pageIndex int) *SyncAsset {
+	for i := range bundle.Assets {
+		if bundle.Assets[i].PageIndex == pageIndex {
+			return &bundle.Assets[i]
+		}
+	}
+	return nil
+}
+
+func readPageUpload(page PageUpload) ([]byte, error) {
+	file, err := page.Header.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", page.Header.Filename, err)
+	}
+	defer file.Close()
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", page.Header.Filename, err)
+	}
+	return content, nil
+}
+
+// scaleField stretches a field's position and checkbox boxes. Font sizes are
+// kept, as printed text does not grow with the form.
+func scaleField(field *gormmodels.Field, scaleX, scaleY float64) {
+	x := func(v int) int { return int(math.Round(float64(v) * scaleX)) }
+	y := func(v int) int { return int(math.Round(float64(v) * scaleY)) }
+
+	field.PositionLeft = x(field.PositionLeft)
+	field.PositionWidth = x(field.PositionWidth)
+	field.PositionTop = y(field.PositionTop)
+	field.PositionHeight = y(field.PositionHeight)
+	for i := range field.Boxes {
+		box := &field.Boxes[i]
+		box.Left, box.Width = x(box.Left), x(box.Width)
+		box.Top, box.Height = y(box.Top), y(box.Height)
+	}
+}
+
+// svgPageSize reads the size of an SVG document from its root element's
+// width and height, in CSS pixels, falling back to its viewBox when they are
+// missing or relative.
+func svgPageSize(content []byte) (float64, float64, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return 0, 0, errors.New("no <svg> element")
+			}
+			return 0, 0, err
+		}
+		root, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if !strings.EqualFold(root.Name.Local, "svg") {
+			return 0, 0, fmt.Errorf("root element is <%s>, not <svg>", root.Name.Local)
+		}
+
+		var width, height, viewBox string
+		for _, attr := range root.Attr {
+			switch attr.Name.Local {
+			case "width":
+				width = attr.Value
+			case "height":
+				height = attr.Value
+			case "viewBox":
+				viewBox = attr.Value
+			}
+		}
+
+		w, wOK := svgLength(width)
+		h, hOK := svgLength(height)
+		if wOK && hOK {
+			return w, h, nil
+		}
+
+		box := strings.FieldsFunc(viewBox, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r' })
+		if len(box) == 4 {
+			vw, errW := strconv.ParseFloat(box[2], 64)
+			vh, errH := strconv.ParseFloat(box[3], 64)
+			if errW == nil && errH == nil && vw > 0 && vh > 0 {
+				return vw, vh, nil
+			}
+		}
+		return 0, 0, errors.New("the <svg> element has neither an absolute width and height nor a viewBox")
+	}
+}
+
+// svgLength parses an absolute SVG length such as "210mm" or "794" into CSS
+// pixels.
+func svgLength(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	number := strings.TrimRightFunc(value, func(r rune) bool { return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' })
+	perUnit, ok := svgUnitPx[strings.ToLower(value[len(number):])]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(number, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n * perUnit, true
+}
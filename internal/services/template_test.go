@@ -0,0 +1,81 @@
+package services
+
+import (
+	"os"
+	"testing"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	"github.com/dhanavadh/fastfill-backend/internal/config"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"github.com/google/uuid"
+)
+
+// testDB connects to the MySQL database named by TEST_DB_NAME and migrates
+// it, skipping the test when none is configured. The database is shared, so
+// tests only touch rows they create.
+func testDB(t *testing.T) {
+	t.Helper()
+
+	name := os.Getenv("TEST_DB_NAME")
+	if name == "" {
+		t.Skip("TEST_DB_NAME is not set")
+	}
+
+	cfg := &config.Config{Database: config.DatabaseConfig{
+		Host:     envOr("TEST_DB_HOST", "127.0.0.1"),
+		Port:     envOr("TEST_DB_PORT", "3306"),
+		User:     envOr("TEST_DB_USER", "root"),
+		Password: os.Getenv("TEST_DB_PASSWORD"),
+		DBName:   name,
+	}}
+	if err := internal.InitDB(cfg); err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(internal.CloseDB)
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func TestDeleteTemplateWithDependents(t *testing.T) {
+	testDB(t)
+
+	template := &gormmodels.Template{ID: uuid.New().String(), DisplayName: "Delete with dependents"}
+	if err := internal.DB.Create(template).Error; err != nil {
+		t.Fatalf("failed to create template: %v", err)
+	}
+
+	dependents := []interface{}{
+		&gormmodels.TemplateShare{ID: uuid.New().String(), TemplateID: template.ID, OwnerWorkspaceID: uuid.New().String(), WorkspaceID: uuid.New().String(), Access: gormmodels.ShareRead},
+		&gormmodels.FillLink{TemplateID: template.ID, Name: "link", Token: uuid.New().String()},
+		&gormmodels.ImportProfile{ID: uuid.New().String(), TemplateID: template.ID, Name: "profile"},
+	}
+	for _, dependent := range dependents {
+		if err := internal.DB.Create(dependent).Error; err != nil {
+			t.Fatalf("failed to create %T: %v", dependent, err)
+		}
+	}
+
+	if err := NewTemplateService().Delete(template.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	for _, model := range []interface{}{&gormmodels.Template{}, &gormmodels.TemplateShare{}, &gormmodels.FillLink{}, &gormmodels.ImportProfile{}} {
+		column := "template_id"
+		if _, ok := model.(*gormmodels.Template); ok {
+			column = "id"
+		}
+		var count int64
+		if err := internal.DB.Model(model).Where(column+" = ?", template.ID).Count(&count).Error; err != nil {
+			t.Fatalf("failed to count %T: %v", model, err)
+		}
+		if count != 0 {
+			t.Errorf("%d %T rows left after Delete", count, model)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// TextExtractor reads the text layer of a PDF. Implementations back the
+// document search index; a nil extractor leaves documents unindexed.
+type TextExtractor interface {
+	ExtractText(ctx context.Context, pdf []byte) (string, error)
+}
+
+// PDFToTextExtractor extracts text with Poppler's pdftotext.
+type PDFToTextExtractor struct {
+	binary string
+}
+
+func NewPDFToTextExtractor(binary string) *PDFToTextExtractor {
+	return &PDFToTextExtractor{binary: binary}
+}
+
+func (e *PDFToTextExtractor) ExtractText(ctx context.Context, pdf []byte) (string, error) {
+	cmd := exec.CommandContext(ctx, e.binary, "-enc", "UTF-8", "-layout", "-", "-")
+	cmd.Stdin = bytes.NewReader(pdf)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("text extraction failed: %w: %s", err, stderr.String())
+	}
+	return stdout.String(), nil
+}
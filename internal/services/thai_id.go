@@ -0,0 +1,174 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ThaiIDCard holds the fields read off the front of a Thai national ID card.
+// Fields the OCR text does not yield are left empty; dates are YYYY-MM-DD in
+// the Common era.
+type ThaiIDCard struct {
+	IDNumber      string `json:"idNumber,omitempty"`
+	IDNumberValid bool   `json:"idNumberValid"`
+	NameTH        string `json:"nameTh,omitempty"`
+	TitleEN       string `json:"titleEn,omitempty"`
+	FirstNameEN   string `json:"firstNameEn,omitempty"`
+	LastNameEN    string `json:"lastNameEn,omitempty"`
+	DateOfBirth   string `json:"dateOfBirth,omitempty"`
+	Address       string `json:"address,omitempty"`
+	DateOfIssue   string `json:"dateOfIssue,omitempty"`
+	DateOfExpiry  string `json:"dateOfExpiry,omitempty"`
+}
+
+var (
+	thaiIDNumberPattern = regexp.MustCompile(`\b(\d)[\s-]?(\d{4})[\s-]?(\d{5})[\s-]?(\d{2})[\s-]?(\d)\b`)
+	thaiIDEnglishName   = regexp.MustCompile(`(?i)^name\s+(mr|mrs|miss|ms)\.?\s+(.+)$`)
+	thaiIDLastName      = regexp.MustCompile(`(?i)^last\s*name\s+(.+)$`)
+	englishDatePattern  = regexp.MustCompile(`(\d{1,2})\s+([A-Za-z]{3})[a-z]*\.?\s+(\d{4})`)
+	thaiDatePattern     = regexp.MustCompile(`(\d{1,2})\s*(\S+?\.\S+?\.|\S+)\s*(\d{4})`)
+)
+
+// thaiIDLabels start the lines that hold each card field, after the label.
+var thaiIDLabels = map[string][]string{
+	"nameTh":      {"ชื่อตัวและชื่อสกุล", "ชื่อ-นามสกุล"},
+	"birth":       {"เกิดวันที่"},
+	"birthEn":     {"Date of Birth"},
+	"address":     {"ที่อยู่"},
+	"issue":       {"วันออกบัตร"},
+	"issueEn":     {"Date of Issue"},
+	"expiry":      {"วันบัตรหมดอายุ"},
+	"expiryEn":    {"Date of Expiry"},
+	"addressStop": {"วันออกบัตร", "Date of Issue", "เจ้าพนักงานออกบัตร"},
+}
+
+var englishMonths = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// ParseThaiIDCard extracts the card fields from OCR text. English dates are
+// preferred; Thai dates, in the Buddhist era, are used when they are missing.
+func ParseThaiIDCard(text string) *ThaiIDCard {
+	card := &ThaiIDCard{}
+	lines := strings.Split(thaiDigits.Replace(text), "\n")
+	for i := range lines {
+		lines[i] = strings.Join(strings.Fields(lines[i]), " ")
+	}
+
+	for _, line := range lines {
+		if m := thaiIDNumberPattern.FindStringSubmatch(line); m != nil && card.IDNumber == "" {
+			card.IDNumber = strings.Join(m[1:], "")
+			card.IDNumberValid = ValidThaiIDNumber(card.IDNumber)
+		}
+		if m := thaiIDEnglishName.FindStringSubmatch(line); m != nil {
+			card.TitleEN = titleCase(m[1])
+			card.FirstNameEN = m[2]
+		}
+		if m := thaiIDLastName.FindStringSubmatch(line); m != nil {
+			card.LastNameEN = m[1]
+		}
+	}
+
+	card.NameTH = afterLabel(lines, thaiIDLabels["nameTh"])
+	card.DateOfBirth = cardDate(lines, "birthEn", "birth")
+	card.DateOfIssue = cardDate(lines, "issueEn", "issue")
+	card.DateOfExpiry = cardDate(lines, "expiryEn", "expiry")
+	card.Address = cardAddress(lines)
+
+	return card
+}
+
+// ValidThaiIDNumber checks the length and check digit of a 13-digit Thai
+// national ID number.
+func ValidThaiIDNumber(number string) bool {
+	if len(number) != 13 || !isDigits(number) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(number[i]-'0') * (13 - i)
+	}
+	return (11-sum%11)%10 == int(number[12]-'0')
+}
+
+// afterLabel returns the rest of the first line starting with one of labels.
+func afterLabel(lines []string, labels []string) string {
+	for _, line := range lines {
+		for _, label := range labels {
+			if rest, ok := cutLabel(line, label); ok && rest != "" {
+				return rest
+			}
+		}
+	}
+	return ""
+}
+
+func cutLabel(line, label string) (string, bool) {
+	if len(line) < len(label) || !strings.EqualFold(line[:len(label)], label) {
+		return "", false
+	}
+	return strings.TrimSpace(line[len(label):]), true
+}
+
+func cardDate(lines []string, englishLabel, thaiLabel string) string {
+	if value := afterLabel(lines, thaiIDLabels[englishLabel]); value != "" {
+		if m := englishDatePattern.FindStringSubmatch(value); m != nil {
+			day, _ := strconv.Atoi(m[1])
+			year, _ := strconv.Atoi(m[3])
+			if month, ok := englishMonths[strings.ToLower(m[2])]; ok {
+				date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+				if date.Day() == day {
+					return date.Format(CanonicalDateLayout)
+				}
+			}
+		}
+	}
+
+	if value := afterLabel(lines, thaiIDLabels[thaiLabel]); value != "" {
+		if m := thaiDatePattern.FindString(value); m != "" {
+			if date, ok := MatchInputLocale("th").ParseDate(m); ok {
+				return date.Format(CanonicalDateLayout)
+			}
+		}
+	}
+	return ""
+}
+
+// cardAddress joins the address line with the lines that continue it, up to
+// the next labelled field or the issue date printed above its label.
+func cardAddress(lines []string) string {
+	for i, line := range lines {
+		rest, ok := cutLabel(line, thaiIDLabels["address"][0])
+		if !ok {
+			continue
+		}
+		parts := []string{rest}
+		for _, next := range lines[i+1:] {
+			if _, isDate := MatchInputLocale("th").ParseDate(next); isDate || next == "" ||
+				startsWithAny(next, thaiIDLabels["addressStop"]) || thaiIDNumberPattern.MatchString(next) {
+				break
+			}
+			parts = append(parts, next)
+		}
+		return strings.TrimSpace(strings.Join(parts, " "))
+	}
+	return ""
+}
+
+func startsWithAny(line string, labels []string) bool {
+	for _, label := range labels {
+		if _, ok := cutLabel(line, label); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func titleCase(s string) string {
+	s = strings.ToLower(s)
+	return strings.ToUpper(s[:1]) + s[1:]
+}
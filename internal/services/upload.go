@@ -1,11 +1,15 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
 	"net/http"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -17,13 +21,32 @@ import (
 	"gorm.io/gorm"
 )
 
+// Signed URL lifetimes: the default, and the most GCS and S3 allow for V4 signatures.
+const (
+	DefaultSignedURLTTL = time.Hour
+	MaxSignedURLTTL     = 7 * 24 * time.Hour
+)
+
 type UploadService struct {
-	gcsClient *storage.GCSClient
+	storageClient storage.StorageClient
+
+	// SignedURLTTL is how long signed page URLs stay valid; zero means
+	// DefaultSignedURLTTL.
+	SignedURLTTL time.Duration
+
+	// OnPageChanged, if set, is called after a page background is replaced or deleted.
+	OnPageChanged func(templateID string, pageIndex int)
+
+	// PageConverter, if set, turns uploaded PDFs into page backgrounds.
+	PageConverter PageConverter
+	// MaxPDFSize bounds uploaded PDFs, in bytes; zero means
+	// DefaultMaxPDFSize.
+	MaxPDFSize int64
 }
 
-func NewUploadService(gcsClient *storage.GCSClient) *UploadService {
+func NewUploadService(storageClient storage.StorageClient) *UploadService {
 	return &UploadService{
-		gcsClient: gcsClient,
+		storageClient: storageClient,
 	}
 }
 
@@ -34,18 +57,19 @@ func (s *UploadService) UploadSVG(ctx context.Context, templateID string, file m
 func (s *UploadService) UploadSVGWithPage(ctx context.Context, templateID string, file multipart.File, header *multipart.FileHeader, pageIndex int) (*gormmodels.SVGFile, error) {
 	objectName := storage.GenerateObjectName(templateID, header.Filename)
 
-	result, err := s.gcsClient.UploadFile(ctx, file, objectName, header.Header.Get("Content-Type"))
+	result, err := s.storageClient.UploadFile(ctx, file, objectName, header.Header.Get("Content-Type"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to upload to GCS: %w", err)
+		return nil, storageError("failed to upload to storage", err)
 	}
 
 	// Check if an SVG file already exists for this page and template
 	var existingSVG gormmodels.SVGFile
 	err = internal.DB.Where("template_id = ? AND page_index = ?", templateID, pageIndex).First(&existingSVG).Error
-	if err == nil {
-		// Delete the existing file from GCS
+	replaced := err == nil
+	if replaced {
+		// Delete the existing file from storage
 		if existingSVG.GCSPath != "" {
-			s.gcsClient.DeleteFile(ctx, existingSVG.GCSPath)
+			s.storageClient.DeleteFile(ctx, existingSVG.GCSPath)
 		}
 		// Delete the existing record
 		internal.DB.Delete(&existingSVG)
@@ -57,19 +81,328 @@ func (s *UploadService) UploadSVGWithPage(ctx context.Context, templateID string
 		OriginalName: header.Filename,
 		FilePath:     objectName, // Store GCS path instead of public URL
 		GCSPath:      objectName,
+		KMSKeyName:   result.KMSKeyName,
 		FileSize:     result.Size,
 		MimeType:     header.Header.Get("Content-Type"),
 		PageIndex:    pageIndex,
 	}
 
 	if err := internal.DB.Create(svgFile).Error; err != nil {
-		s.gcsClient.DeleteFile(ctx, objectName)
-		return nil, fmt.Errorf("failed to save file metadata: %w", err)
+		s.storageClient.DeleteFile(ctx, objectName)
+		return nil, storageError("failed to save file metadata", err)
+	}
+
+	if replaced {
+		s.pageChanged(templateID, pageIndex)
 	}
 
 	return svgFile, nil
 }
 
+// AttachPage makes a temporary upload the background of a template page,
+// replacing the page's current file. The upload leaves the hold area and its
+// object now belongs to the page. An upload purged or attached in the
+// meantime fails with ErrNotFound.
+func (s *UploadService) AttachPage(ctx context.Context, templateID string, pageIndex int, upload *gormmodels.TempUpload) (*gormmodels.SVGFile, error) {
+	if pageIndex < 0 {
+		return nil, newErrorf(ErrValidation, "invalid page index %d", pageIndex)
+	}
+	if upload.MimeType != "image/svg+xml" {
+		return nil, newErrorf(ErrValidation, "%s must be an SVG", upload.Filename)
+	}
+
+	svgFile := &gormmodels.SVGFile{
+		TemplateID:   templateID,
+		Filename:     upload.Filename,
+		OriginalName: upload.Filename,
+		FilePath:     upload.GCSPath,
+		GCSPath:      upload.GCSPath,
+		KMSKeyName:   upload.KMSKeyName,
+		FileSize:     upload.Size,
+		MimeType:     upload.MimeType,
+		PageIndex:    pageIndex,
+	}
+
+	var replaced []gormmodels.SVGFile
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", upload.ID).Delete(&gormmodels.TempUpload{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("template_id = ? AND page_index = ?", templateID, pageIndex).Find(&replaced).Error; err != nil {
+			return err
+		}
+		if len(replaced) > 0 {
+			if err := tx.Delete(&replaced).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(svgFile).Error
+	})
+	if err != nil {
+		return nil, storageError("failed to attach upload", err)
+	}
+
+	for _, old := range replaced {
+		if old.GCSPath != "" {
+			if err := s.storageClient.DeleteFile(ctx, old.GCSPath); err != nil {
+				log.Printf("Warning: failed to delete replaced page %s: %v", old.GCSPath, err)
+			}
+		}
+	}
+	if len(replaced) > 0 {
+		s.pageChanged(templateID, pageIndex)
+	}
+
+	return svgFile, nil
+}
+
+// MaxBatchPages bounds the files one batch upload may carry, and the pages
+// of an uploaded PDF.
+const MaxBatchPages = 100
+
+// DefaultMaxPDFSize is the largest PDF converted into page backgrounds when
+// the configuration leaves it unset.
+const DefaultMaxPDFSize = 20 << 20
+
+// PDFLimit returns the largest PDF accepted by UploadPDF, in bytes.
+func (s *UploadService) PDFLimit() int64 {
+	if s.MaxPDFSize <= 0 {
+		return DefaultMaxPDFSize
+	}
+	return s.MaxPDFSize
+}
+
+// PageUpload is one file of a batch upload and the page it becomes.
+type PageUpload struct {
+	Header    *multipart.FileHeader
+	PageIndex int
+}
+
+// UploadPages uploads several page backgrounds at once, replacing the pages'
+// current files. It is all-or-nothing: if any upload or the database update
+// fails, the new objects are removed and the template keeps its old pages.
+// Replaced objects are only deleted once the new pages are committed.
+func (s *UploadService) UploadPages(ctx context.Context, templateID string, pages []PageUpload) ([]gormmodels.SVGFile, error) {
+	if err := checkPageUploads(pages); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkTemplateExists(templateID); err != nil {
+		return nil, err
+	}
+
+	svgFiles, err := s.uploadPageFiles(ctx, templateID, pages)
+	if err != nil {
+		return nil, err
+	}
+	return s.replacePages(ctx, templateID, svgFiles)
+}
+
+// UploadPDF converts every page of a PDF into a page background, the first
+// page becoming firstPage and the others the pages after it. Like
+// UploadPages it replaces the pages' current files all-or-nothing.
+func (s *UploadService) UploadPDF(ctx context.Context, templateID string, filename string, pdf []byte, firstPage int) ([]gormmodels.SVGFile, error) {
+	if s.PageConverter == nil {
+		return nil, newError(ErrValidation, "PDF conversion is not configured")
+	}
+	if firstPage < 0 {
+		return nil, newErrorf(ErrValidation, "invalid page index %d", firstPage)
+	}
+	if err := s.checkTemplateExists(templateID); err != nil {
+		return nil, err
+	}
+
+	pages, err := s.PageConverter.ConvertPages(ctx, pdf, MaxBatchPages)
+	if err != nil {
+		if errors.Is(err, ErrValidation) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to convert %s: %w", filename, err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	svgFiles := make([]gormmodels.SVGFile, 0, len(pages))
+	for i, page := range pages {
+		pageIndex := firstPage + i
+		pageName := fmt.Sprintf("%s-page-%d.svg", base, i+1)
+		objectName := storage.GeneratePageObjectName(templateID, pageIndex, pageName)
+		result, err := s.storageClient.UploadFile(ctx, bytes.NewReader(page), objectName, "image/svg+xml")
+		if err != nil {
+			s.removePageFiles(ctx, svgFiles)
+			return nil, storageError(fmt.Sprintf("failed to upload page %d of %s to storage", i+1, filename), err)
+		}
+
+		svgFiles = append(svgFiles, gormmodels.SVGFile{
+			TemplateID:   templateID,
+			Filename:     pageName,
+			OriginalName: filename,
+			FilePath:     objectName,
+			GCSPath:      objectName,
+			KMSKeyName:   result.KMSKeyName,
+			FileSize:     result.Size,
+			MimeType:     "image/svg+xml",
+			PageIndex:    pageIndex,
+		})
+	}
+	return s.replacePages(ctx, templateID, svgFiles)
+}
+
+func (s *UploadService) checkTemplateExists(templateID string) error {
+	var exists int64
+	if err := internal.DB.Model(&gormmodels.Template{}).Where("id = ?", templateID).Count(&exists).Error; err != nil {
+		return storageError("failed to fetch template", err)
+	}
+	if exists == 0 {
+		return ErrTemplateNotFound
+	}
+	return nil
+}
+
+// replacePages saves uploaded page records in place of the pages' current
+// files. If the database update fails the uploaded objects are removed;
+// replaced objects are only deleted once the new pages are committed.
+func (s *UploadService) replacePages(ctx context.Context, templateID string, svgFiles []gormmodels.SVGFile) ([]gormmodels.SVGFile, error) {
+	var replaced []gormmodels.SVGFile
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		indexes := make([]int, len(svgFiles))
+		for i, svgFile := range svgFiles {
+			indexes[i] = svgFile.PageIndex
+		}
+		if err := tx.Where("template_id = ? AND page_index IN ?", templateID, indexes).Find(&replaced).Error; err != nil {
+			return err
+		}
+		if len(replaced) > 0 {
+			if err := tx.Delete(&replaced).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(&svgFiles).Error
+	})
+	if err != nil {
+		s.removePageFiles(ctx, svgFiles)
+		return nil, storageError("failed to save file metadata", err)
+	}
+
+	for _, svgFile := range replaced {
+		if svgFile.GCSPath != "" {
+			if err := s.storageClient.DeleteFile(ctx, svgFile.GCSPath); err != nil {
+				log.Printf("Warning: failed to delete replaced page %s: %v", svgFile.GCSPath, err)
+			}
+		}
+		s.pageChanged(templateID, svgFile.PageIndex)
+	}
+
+	return svgFiles, nil
+}
+
+// CreateTemplate creates a template, its fields and its page backgrounds in
+// one step. Like UploadPages it is all-or-nothing: if an upload or the
+// database write fails, nothing is created and the uploaded objects are
+// removed. Page 0, when given, also becomes the legacy SVG background.
+func (s *UploadService) CreateTemplate(ctx context.Context, template *gormmodels.Template, pages []PageUpload) error {
+	if err := checkPageUploads(pages); err != nil {
+		return err
+	}
+
+	svgFiles, err := s.uploadPageFiles(ctx, template.ID, pages)
+	if err != nil {
+		return err
+	}
+	for _, svgFile := range svgFiles {
+		if svgFile.PageIndex == 0 {
+			template.SVGBackground = template.ID
+		}
+	}
+
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := createTemplate(tx, template); err != nil {
+			return err
+		}
+		return tx.Create(&svgFiles).Error
+	})
+	if err != nil {
+		s.removePageFiles(ctx, svgFiles)
+		return storageError("failed to create template", err)
+	}
+
+	template.SVGFiles = svgFiles
+	return nil
+}
+
+// checkPageUploads validates a batch of page files before anything is uploaded.
+func checkPageUploads(pages []PageUpload) error {
+	if len(pages) == 0 {
+		return newError(ErrValidation, "no files uploaded")
+	}
+	if len(pages) > MaxBatchPages {
+		return newErrorf(ErrValidation, "at most %d files can be uploaded at once", MaxBatchPages)
+	}
+	seen := make(map[int]bool, len(pages))
+	for _, page := range pages {
+		if page.PageIndex < 0 {
+			return newErrorf(ErrValidation, "invalid page index %d", page.PageIndex)
+		}
+		if seen[page.PageIndex] {
+			return newErrorf(ErrValidation, "page %d is assigned more than one file", page.PageIndex)
+		}
+		seen[page.PageIndex] = true
+		if page.Header.Header.Get("Content-Type") != "image/svg+xml" {
+			return newErrorf(ErrValidation, "%s must be an SVG", page.Header.Filename)
+		}
+	}
+	return nil
+}
+
+// uploadPageFiles uploads every page to storage and returns the unsaved records.
+// If one upload fails, those already uploaded are removed.
+func (s *UploadService) uploadPageFiles(ctx context.Context, templateID string, pages []PageUpload) ([]gormmodels.SVGFile, error) {
+	svgFiles := make([]gormmodels.SVGFile, 0, len(pages))
+	for _, page := range pages {
+		objectName := storage.GeneratePageObjectName(templateID, page.PageIndex, page.Header.Filename)
+		result, err := s.uploadPart(ctx, page.Header, objectName)
+		if err != nil {
+			s.removePageFiles(ctx, svgFiles)
+			return nil, storageError(fmt.Sprintf("failed to upload %s to storage", page.Header.Filename), err)
+		}
+
+		svgFiles = append(svgFiles, gormmodels.SVGFile{
+			TemplateID:   templateID,
+			Filename:     page.Header.Filename,
+			OriginalName: page.Header.Filename,
+			FilePath:     objectName,
+			GCSPath:      objectName,
+			KMSKeyName:   result.KMSKeyName,
+			FileSize:     result.Size,
+			MimeType:     page.Header.Header.Get("Content-Type"),
+			PageIndex:    page.PageIndex,
+		})
+	}
+	return svgFiles, nil
+}
+
+// removePageFiles deletes the objects of pages whose batch failed.
+func (s *UploadService) removePageFiles(ctx context.Context, svgFiles []gormmodels.SVGFile) {
+	for _, svgFile := range svgFiles {
+		if err := s.storageClient.DeleteFile(ctx, svgFile.GCSPath); err != nil {
+			log.Printf("Warning: failed to remove %s after a failed batch upload: %v", svgFile.GCSPath, err)
+		}
+	}
+}
+
+func (s *UploadService) uploadPart(ctx context.Context, header *multipart.FileHeader, objectName string) (*storage.UploadResult, error) {
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return s.storageClient.UploadFile(ctx, file, objectName, header.Header.Get("Content-Type"))
+}
+
 func (s *UploadService) GetSVGFile(templateID string) (*gormmodels.SVGFile, error) {
 	var svgFile gormmodels.SVGFile
 
@@ -78,7 +411,7 @@ func (s *UploadService) GetSVGFile(templateID string) (*gormmodels.SVGFile, erro
 		if err == gorm.ErrRecordNotFound {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to fetch SVG file: %w", err)
+		return nil, storageError("failed to fetch SVG file", err)
 	}
 
 	return &svgFile, nil
@@ -90,33 +423,84 @@ func (s *UploadService) GetSVGFileURL(templateID string) (string, error) {
 		return "", err
 	}
 	if svgFile == nil {
-		return "", fmt.Errorf("SVG file not found")
+		return "", newError(ErrNotFound, "SVG file not found")
 	}
 
-	// Generate signed URL valid for 1 hour
-	signedURL, err := s.gcsClient.GetSignedURL(svgFile.GCSPath, time.Hour)
+	signedURL, err := s.storageClient.GetSignedURL(svgFile.GCSPath, s.URLTTL())
 	if err != nil {
-		return "", fmt.Errorf("failed to generate signed URL: %w", err)
+		return "", storageError("failed to generate signed URL", err)
 	}
 
 	return signedURL, nil
 }
 
+// URLTTL returns how long the signed URLs handed out stay valid.
+func (s *UploadService) URLTTL() time.Duration {
+	if s.SignedURLTTL <= 0 {
+		return DefaultSignedURLTTL
+	}
+	return min(s.SignedURLTTL, MaxSignedURLTTL)
+}
+
+// SignedPageURL is a signed URL of a page background and when it expires.
+type SignedPageURL struct {
+	PageIndex int       `json:"pageIndex"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// SignedPageURLs signs fresh URLs for every page background of a template, so
+// clients that load pages straight from storage can replace URLs before
+// they expire.
+func (s *UploadService) SignedPageURLs(templateID string) ([]SignedPageURL, error) {
+	var svgFiles []gormmodels.SVGFile
+	if err := internal.DB.Where("template_id = ?", templateID).Order("page_index").Find(&svgFiles).Error; err != nil {
+		return nil, storageError("failed to fetch SVG files", err)
+	}
+
+	ttl := s.URLTTL()
+	urls := make([]SignedPageURL, 0, len(svgFiles))
+	for _, svgFile := range svgFiles {
+		// Taken before signing, so the URL is valid at least until then.
+		expiresAt := time.Now().Add(ttl)
+		signedURL, err := s.storageClient.GetSignedURL(svgFile.GCSPath, ttl)
+		if err != nil {
+			return nil, storageError("failed to generate signed URL", err)
+		}
+		urls = append(urls, SignedPageURL{PageIndex: svgFile.PageIndex, URL: signedURL, ExpiresAt: expiresAt})
+	}
+	return urls, nil
+}
+
+// GetSVGFileByPage returns the SVG file for a page, or nil if the page has none.
+func (s *UploadService) GetSVGFileByPage(templateID string, pageIndex int) (*gormmodels.SVGFile, error) {
+	var svgFile gormmodels.SVGFile
+
+	err := internal.DB.Where("template_id = ? AND page_index = ?", templateID, pageIndex).First(&svgFile).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, storageError("failed to fetch SVG file", err)
+	}
+
+	return &svgFile, nil
+}
+
 func (s *UploadService) GetSVGFileURLByPage(templateID string, pageIndex int) (string, error) {
 	var svgFile gormmodels.SVGFile
 
 	err := internal.DB.Where("template_id = ? AND page_index = ?", templateID, pageIndex).First(&svgFile).Error
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return "", fmt.Errorf("SVG file not found for page %d", pageIndex)
+			return "", newErrorf(ErrNotFound, "SVG file not found for page %d", pageIndex)
 		}
-		return "", fmt.Errorf("failed to fetch SVG file: %w", err)
+		return "", storageError("failed to fetch SVG file", err)
 	}
 
-	// Generate signed URL valid for 1 hour
-	signedURL, err := s.gcsClient.GetSignedURL(svgFile.GCSPath, time.Hour)
+	signedURL, err := s.storageClient.GetSignedURL(svgFile.GCSPath, s.URLTTL())
 	if err != nil {
-		return "", fmt.Errorf("failed to generate signed URL: %w", err)
+		return "", storageError("failed to generate signed URL", err)
 	}
 
 	return signedURL, nil
@@ -130,19 +514,20 @@ func (s *UploadService) DeleteSVGFile(ctx context.Context, templateID string) er
 		if err == gorm.ErrRecordNotFound {
 			return nil
 		}
-		return fmt.Errorf("failed to fetch SVG file: %w", err)
+		return storageError("failed to fetch SVG file", err)
 	}
 
 	if svgFile.GCSPath != "" {
-		if err := s.gcsClient.DeleteFile(ctx, svgFile.GCSPath); err != nil {
-			return fmt.Errorf("failed to delete from GCS: %w", err)
+		if err := s.storageClient.DeleteFile(ctx, svgFile.GCSPath); err != nil {
+			return storageError("failed to delete from storage", err)
 		}
 	}
 
 	if err := internal.DB.Delete(&svgFile).Error; err != nil {
-		return fmt.Errorf("failed to delete file metadata: %w", err)
+		return storageError("failed to delete file metadata", err)
 	}
 
+	s.pageChanged(svgFile.TemplateID, svgFile.PageIndex)
 	return nil
 }
 
@@ -154,22 +539,29 @@ func (s *UploadService) DeleteSVGFileByID(ctx context.Context, svgFileID uint) e
 		if err == gorm.ErrRecordNotFound {
 			return nil
 		}
-		return fmt.Errorf("failed to fetch SVG file: %w", err)
+		return storageError("failed to fetch SVG file", err)
 	}
 
 	if svgFile.GCSPath != "" {
-		if err := s.gcsClient.DeleteFile(ctx, svgFile.GCSPath); err != nil {
-			return fmt.Errorf("failed to delete from GCS: %w", err)
+		if err := s.storageClient.DeleteFile(ctx, svgFile.GCSPath); err != nil {
+			return storageError("failed to delete from storage", err)
 		}
 	}
 
 	if err := internal.DB.Delete(&svgFile).Error; err != nil {
-		return fmt.Errorf("failed to delete file metadata: %w", err)
+		return storageError("failed to delete file metadata", err)
 	}
 
+	s.pageChanged(svgFile.TemplateID, svgFile.PageIndex)
 	return nil
 }
 
+func (s *UploadService) pageChanged(templateID string, pageIndex int) {
+	if s.OnPageChanged != nil {
+		s.OnPageChanged(templateID, pageIndex)
+	}
+}
+
 func (s *UploadService) GetSVGContent(templateID, svgID string) ([]byte, error) {
 	var svgFile *gormmodels.SVGFile
 	var err error
@@ -202,18 +594,23 @@ func (s *UploadService) GetSVGContent(templateID, svgID string) ([]byte, error)
 			return nil, err
 		}
 		if svgFile == nil {
-			return nil, fmt.Errorf("SVG file not found for template %s", templateID)
+			return nil, newErrorf(ErrNotFound, "SVG file not found for template %s", templateID)
 		}
 	}
 
 	return s.fetchSVGContent(svgFile)
 }
 
+// GetSVGFileContent downloads the content of a known SVG file record.
+func (s *UploadService) GetSVGFileContent(svgFile *gormmodels.SVGFile) ([]byte, error) {
+	return s.fetchSVGContent(svgFile)
+}
+
 func (s *UploadService) fetchSVGContent(svgFile *gormmodels.SVGFile) ([]byte, error) {
 	// Generate signed URL for the specific file
-	signedURL, err := s.gcsClient.GetSignedURL(svgFile.GCSPath, time.Hour)
+	signedURL, err := s.storageClient.GetSignedURL(svgFile.GCSPath, s.URLTTL())
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate signed URL: %w", err)
+		return nil, storageError("failed to generate signed URL", err)
 	}
 
 	// Fetch content using the signed URL
@@ -227,7 +624,7 @@ func (s *UploadService) fetchSVGContent(svgFile *gormmodels.SVGFile) ([]byte, er
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch SVG: %w", err)
+		return nil, storageError("failed to fetch SVG", err)
 	}
 	defer resp.Body.Close()
 
@@ -237,7 +634,7 @@ func (s *UploadService) fetchSVGContent(svgFile *gormmodels.SVGFile) ([]byte, er
 
 	content, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read SVG content: %w", err)
+		return nil, storageError("failed to read SVG content", err)
 	}
 
 	return content, nil
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Usage metrics recorded per workspace and month.
+const (
+	MetricVisionOCR      = "vision.ocr"
+	MetricVisionFallback = "vision.ocr.fallback"
+	MetricVisionRejected = "vision.ocr.rejected"
+)
+
+// VisionCapAction values for what happens once a workspace reaches its cap.
+const (
+	VisionCapReject   = "reject"
+	VisionCapFallback = "fallback"
+)
+
+// VisionRoute tells the OCR caller which provider to use for a request.
+type VisionRoute string
+
+const (
+	VisionRoutePrimary  VisionRoute = "primary"
+	VisionRouteFallback VisionRoute = "fallback"
+)
+
+var ErrVisionQuotaExceeded = errors.New("monthly Google Vision quota exceeded")
+
+// UsageService meters Vision API calls per workspace and month and applies a
+// soft monthly cap. The cap is checked before each call, so concurrent
+// requests may overshoot it slightly.
+type UsageService struct {
+	defaultCap  int
+	capAction   string
+	costPer1000 float64
+}
+
+func NewUsageService(defaultCap int, capAction string, costPer1000 float64) *UsageService {
+	return &UsageService{
+		defaultCap:  defaultCap,
+		capAction:   capAction,
+		costPer1000: costPer1000,
+	}
+}
+
+// UsageReport summarises a workspace's usage for one month.
+type UsageReport struct {
+	WorkspaceID         string           `json:"workspaceId"`
+	Period              string           `json:"period"`
+	Metrics             map[string]int64 `json:"metrics"`
+	VisionMonthlyCap    int              `json:"visionMonthlyCap"`
+	VisionRemaining     *int64           `json:"visionRemaining,omitempty"`
+	VisionCapAction     string           `json:"visionCapAction"`
+	EstimatedVisionCost float64          `json:"estimatedVisionCost"`
+}
+
+// UsagePeriod returns the month key used for counters.
+func UsagePeriod(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// AuthorizeVision records an OCR call and decides where it should go. Once the
+// workspace reaches its cap, calls are routed to the fallback provider or
+// rejected with ErrVisionQuotaExceeded, depending on configuration.
+func (s *UsageService) AuthorizeVision(workspaceID string) (VisionRoute, error) {
+	period := UsagePeriod(time.Now())
+
+	limit, err := s.visionCap(workspaceID)
+	if err != nil {
+		return "", err
+	}
+
+	if limit > 0 {
+		used, err := s.count(workspaceID, MetricVisionOCR, period)
+		if err != nil {
+			return "", err
+		}
+
+		if used >= int64(limit) {
+			if s.capAction == VisionCapFallback {
+				return VisionRouteFallback, s.increment(workspaceID, MetricVisionFallback, period)
+			}
+			if err := s.increment(workspaceID, MetricVisionRejected, period); err != nil {
+				return "", err
+			}
+			return "", ErrVisionQuotaExceeded
+		}
+	}
+
+	return VisionRoutePrimary, s.increment(workspaceID, MetricVisionOCR, period)
+}
+
+func (s *UsageService) Report(workspaceID, period string) (*UsageReport, error) {
+	var counters []gormmodels.UsageCounter
+	err := internal.ReadDB().Where("workspace_id = ? AND period = ?", workspaceID, period).Find(&counters).Error
+	if err != nil {
+		return nil, storageError("failed to fetch usage", err)
+	}
+
+	limit, err := s.visionCap(workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UsageReport{
+		WorkspaceID:      workspaceID,
+		Period:           period,
+		Metrics:          make(map[string]int64, len(counters)),
+		VisionMonthlyCap: limit,
+		VisionCapAction:  s.capAction,
+	}
+	for _, counter := range counters {
+		report.Metrics[counter.Metric] = counter.Count
+	}
+
+	used := report.Metrics[MetricVisionOCR]
+	report.EstimatedVisionCost = float64(used) * s.costPer1000 / 1000
+	if limit > 0 {
+		remaining := int64(limit) - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		report.VisionRemaining = &remaining
+	}
+
+	return report, nil
+}
+
+// SetVisionCap overrides the monthly cap for a workspace; 0 restores the default.
+func (s *UsageService) SetVisionCap(workspaceID string, limit int) error {
+	err := internal.DB.Model(&gormmodels.Workspace{}).Where("id = ?", workspaceID).Update("vision_monthly_cap", limit).Error
+	if err != nil {
+		return storageError("failed to update vision cap", err)
+	}
+	return nil
+}
+
+func (s *UsageService) visionCap(workspaceID string) (int, error) {
+	if workspaceID == "" {
+		return s.defaultCap, nil
+	}
+
+	var workspace gormmodels.Workspace
+	err := internal.DB.Select("vision_monthly_cap").Where("id = ?", workspaceID).First(&workspace).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return s.defaultCap, nil
+		}
+		return 0, storageError("failed to fetch workspace", err)
+	}
+
+	if workspace.VisionMonthlyCap > 0 {
+		return workspace.VisionMonthlyCap, nil
+	}
+	return s.defaultCap, nil
+}
+
+func (s *UsageService) count(workspaceID, metric, period string) (int64, error) {
+	var counter gormmodels.UsageCounter
+	err := internal.DB.Where("workspace_id = ? AND metric = ? AND period = ?", workspaceID, metric, period).First(&counter).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, nil
+		}
+		return 0, storageError("failed to fetch usage", err)
+	}
+	return counter.Count, nil
+}
+
+func (s *UsageService) increment(workspaceID, metric, period string) error {
+	counter := &gormmodels.UsageCounter{
+		WorkspaceID: workspaceID,
+		Metric:      metric,
+		Period:      period,
+		Count:       1,
+	}
+
+	err := internal.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "workspace_id"}, {Name: "metric"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":      gorm.Expr("count + 1"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(counter).Error
+	if err != nil {
+		return storageError("failed to record usage", err)
+	}
+	return nil
+}
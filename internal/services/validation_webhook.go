@@ -0,0 +1,183 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dhanavadh/fastfill-backend/internal"
+	gormmodels "github.com/dhanavadh/fastfill-backend/internal/models/gorm"
+)
+
+// Validation webhook timeouts.
+const (
+	DefaultWebhookTimeout = 3 * time.Second
+	MaxWebhookTimeout     = 10 * time.Second
+)
+
+// maxWebhookResponse bounds the validation response read from a webhook.
+const maxWebhookResponse = 1 << 20
+
+// ErrValidationUnavailable reports a validation webhook that failed while its
+// template fails closed.
+var ErrValidationUnavailable = newError(ErrStorageUnavailable, "validation service unavailable")
+
+// ValidationRequest is the body posted to a template's validation webhook.
+type ValidationRequest struct {
+	Event        string                 `json:"event"`
+	TemplateID   string                 `json:"templateId"`
+	SubmissionID string                 `json:"submissionId"`
+	Status       string                 `json:"status"`
+	FormData     map[string]interface{} `json:"formData"`
+}
+
+// ValidationResponse is what a validation webhook answers: whether the
+// submission is valid, the reasons if it is not, and annotations to store
+// on it.
+type ValidationResponse struct {
+	Valid       *bool                  `json:"valid"`
+	Errors      []ValidationIssue      `json:"errors,omitempty"`
+	Annotations map[string]interface{} `json:"annotations,omitempty"`
+}
+
+// ValidationIssue is one reason a webhook rejected a submission.
+type ValidationIssue struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationRejection is returned when a validation webhook rejects a submission.
+type ValidationRejection struct {
+	Errors []ValidationIssue
+}
+
+func (e *ValidationRejection) Error() string {
+	return fmt.Sprintf("submission rejected by validation webhook with %d error(s)", len(e.Errors))
+}
+
+// ValidationWebhookService calls the validation webhooks of templates. Each
+// call is signed with an HMAC-SHA256 of the body under the shared secret, sent
+// as "X-FastFill-Signature: sha256=<hex>", so receivers can verify it came
+// from this server.
+type ValidationWebhookService struct {
+	httpClient *http.Client
+	secret     string
+}
+
+func NewValidationWebhookService(secret string) *ValidationWebhookService {
+	return &ValidationWebhookService{
+		// Per-call timeouts come from the template's webhook settings.
+		httpClient: &http.Client{},
+		secret:     secret,
+	}
+}
+
+// WebhookTimeout returns the timeout of a webhook, capped at MaxWebhookTimeout.
+func WebhookTimeout(webhook gormmodels.ValidationWebhook) time.Duration {
+	if webhook.TimeoutMS <= 0 {
+		return DefaultWebhookTimeout
+	}
+	return min(time.Duration(webhook.TimeoutMS)*time.Millisecond, MaxWebhookTimeout)
+}
+
+// Validate sends a new submission to its template's validation webhook, if it
+// has one, and records the verdict in submission.Validation. A rejection is
+// returned as *ValidationRejection. When the webhook fails, the submission is
+// accepted unverified if the template fails open, and ErrValidationUnavailable
+// is returned if it fails closed.
+func (s *ValidationWebhookService) Validate(ctx context.Context, submission *gormmodels.FormSubmission) error {
+	var template gormmodels.Template
+	result := internal.ReadDB().Select("id", "validation_webhook").Where("id = ?", submission.TemplateID).Limit(1).Find(&template)
+	if result.Error != nil {
+		return storageError("failed to fetch validation webhook", result.Error)
+	}
+	webhook := template.ValidationWebhook
+	if result.RowsAffected == 0 || webhook.URL == "" {
+		return nil
+	}
+
+	response, err := s.call(ctx, webhook, submission)
+	if err != nil {
+		log.Printf("Warning: validation webhook of template %s failed: %v", template.ID, err)
+		if webhook.FailureMode == gormmodels.WebhookFailClosed {
+			return fmt.Errorf("%w: %v", ErrValidationUnavailable, err)
+		}
+		submission.Validation = &gormmodels.SubmissionValidation{
+			Status:    gormmodels.ValidationUnverified,
+			Error:     err.Error(),
+			CheckedAt: time.Now(),
+		}
+		return nil
+	}
+
+	if !*response.Valid {
+		return &ValidationRejection{Errors: response.Errors}
+	}
+	submission.Validation = &gormmodels.SubmissionValidation{
+		Status:      gormmodels.ValidationPassed,
+		Annotations: response.Annotations,
+		CheckedAt:   time.Now(),
+	}
+	return nil
+}
+
+// webhookSignature is the X-FastFill-Signature of a body sent to a webhook:
+// an HMAC-SHA256 under secret, as "sha256=<hex>".
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *ValidationWebhookService) call(ctx context.Context, webhook gormmodels.ValidationWebhook, submission *gormmodels.FormSubmission) (*ValidationResponse, error) {
+	body, err := json.Marshal(ValidationRequest{
+		Event:        "submission.validate",
+		TemplateID:   submission.TemplateID,
+		SubmissionID: submission.ID,
+		Status:       submission.Status,
+		FormData:     submission.FormData,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, WebhookTimeout(webhook))
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build validation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-FastFill-Event", "submission.validate")
+	if s.secret != "" {
+		req.Header.Set("X-FastFill-Signature", webhookSignature(s.secret, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call validation webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("validation webhook answered with status %d", resp.StatusCode)
+	}
+
+	var response ValidationResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxWebhookResponse)).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid validation webhook response: %w", err)
+	}
+	if response.Valid == nil {
+		return nil, fmt.Errorf("validation webhook response has no \"valid\" field")
+	}
+	return &response, nil
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// StorageClient is the object store templates, page backgrounds and
+// generated documents are kept in. GCSClient and S3Client implement it.
+type StorageClient interface {
+	UploadFile(ctx context.Context, reader io.Reader, objectName string, contentType string) (*UploadResult, error)
+	DeleteFile(ctx context.Context, objectName string) error
+	// GetSignedURL returns a URL that reads the object without credentials
+	// until expiry has passed.
+	GetSignedURL(objectName string, expiry time.Duration) (string, error)
+	// ReadFile returns the object's content, or an error wrapping
+	// ErrObjectNotExist when there is no such object.
+	ReadFile(ctx context.Context, objectName string) ([]byte, error)
+	// ListObjects returns all objects whose names start with prefix.
+	ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	Close() error
+}
+
+var (
+	_ StorageClient = (*GCSClient)(nil)
+	_ StorageClient = (*S3Client)(nil)
+)
+
+type UploadResult struct {
+	ObjectName string
+	PublicURL  string
+	Size       int64
+	// KMSKeyName is the key version that encrypted the object, empty when
+	// it is Google-managed or the backend is not GCS.
+	KMSKeyName string
+}
+
+// ObjectInfo is the subset of object attributes needed for housekeeping.
+type ObjectInfo struct {
+	Name    string
+	Size    int64
+	Created time.Time
+}
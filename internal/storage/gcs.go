@@ -4,25 +4,62 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
+	"regexp"
+	"sync"
 	"time"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 )
 
+// ErrObjectNotExist is returned, wrapped, when reading an object that does
+// not exist, whatever the backend.
+var ErrObjectNotExist = storage.ErrObjectNotExist
+
+var kmsKeyNamePattern = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+
+// ValidKMSKeyName reports whether name is the resource name of a Cloud KMS
+// key, without a key version.
+func ValidKMSKeyName(name string) bool {
+	return kmsKeyNamePattern.MatchString(name)
+}
+
+// retiredClientGrace is how long a client replaced by RotateCredentials
+// stays open for the requests still using it.
+const retiredClientGrace = 5 * time.Minute
+
 type GCSClient struct {
+	mu         sync.RWMutex
 	client     *storage.Client
 	bucketName string
-}
 
-type UploadResult struct {
-	ObjectName string
-	PublicURL  string
-	Size       int64
+	credentialsPath    string
+	credentialsModTime time.Time
+
+	// KMSKeyName is the customer-managed Cloud KMS key new objects are
+	// encrypted with; empty uses the bucket's default encryption. Set it
+	// before the client is used.
+	KMSKeyName string
 }
 
 func NewGCSClient(bucketName, credentialsPath string) (*GCSClient, error) {
+	client, err := newStorageClient(credentialsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GCSClient{
+		client:             client,
+		bucketName:         bucketName,
+		credentialsPath:    credentialsPath,
+		credentialsModTime: modTime(credentialsPath),
+	}, nil
+}
+
+func newStorageClient(credentialsPath string) (*storage.Client, error) {
 	ctx := context.Background()
 	
 	var client *storage.Client
@@ -37,20 +74,62 @@ func NewGCSClient(bucketName, credentialsPath string) (*GCSClient, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create GCS client: %w", err)
 	}
+	return client, nil
+}
 
-	return &GCSClient{
-		client:     client,
-		bucketName: bucketName,
-	}, nil
+// RotateCredentials switches to a new client when the credentials path, or
+// the content of the credentials file, has changed since the client was
+// created. Requests already running finish on the old client. It reports
+// whether the client was replaced.
+func (g *GCSClient) RotateCredentials(credentialsPath string) (bool, error) {
+	g.mu.RLock()
+	unchanged := credentialsPath == g.credentialsPath && modTime(credentialsPath).Equal(g.credentialsModTime)
+	g.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	client, err := newStorageClient(credentialsPath)
+	if err != nil {
+		return false, err
+	}
+
+	g.mu.Lock()
+	retired := g.client
+	g.client = client
+	g.credentialsPath = credentialsPath
+	g.credentialsModTime = modTime(credentialsPath)
+	g.mu.Unlock()
+
+	time.AfterFunc(retiredClientGrace, func() { retired.Close() })
+	return true, nil
+}
+
+func modTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func (g *GCSClient) bucket() *storage.BucketHandle {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.client.Bucket(g.bucketName)
 }
 
 func (g *GCSClient) UploadFile(ctx context.Context, reader io.Reader, objectName string, contentType string) (*UploadResult, error) {
-	bucket := g.client.Bucket(g.bucketName)
+	bucket := g.bucket()
 	obj := bucket.Object(objectName)
 
 	writer := obj.NewWriter(ctx)
 	writer.ContentType = contentType
 	writer.CacheControl = "public, max-age=86400"
+	writer.KMSKeyName = g.KMSKeyName
 
 	size, err := io.Copy(writer, reader)
 	if err != nil {
@@ -62,15 +141,19 @@ func (g *GCSClient) UploadFile(ctx context.Context, reader io.Reader, objectName
 		return nil, fmt.Errorf("failed to close writer: %w", err)
 	}
 
-	return &UploadResult{
+	result := &UploadResult{
 		ObjectName: objectName,
 		PublicURL:  "", // Don't store public URL for private bucket
 		Size:       size,
-	}, nil
+	}
+	if attrs := writer.Attrs(); attrs != nil {
+		result.KMSKeyName = attrs.KMSKeyName
+	}
+	return result, nil
 }
 
 func (g *GCSClient) DeleteFile(ctx context.Context, objectName string) error {
-	bucket := g.client.Bucket(g.bucketName)
+	bucket := g.bucket()
 	obj := bucket.Object(objectName)
 
 	if err := obj.Delete(ctx); err != nil {
@@ -81,7 +164,7 @@ func (g *GCSClient) DeleteFile(ctx context.Context, objectName string) error {
 }
 
 func (g *GCSClient) GetSignedURL(objectName string, expiry time.Duration) (string, error) {
-	bucket := g.client.Bucket(g.bucketName)
+	bucket := g.bucket()
 	
 	opts := &storage.SignedURLOptions{
 		Scheme:  storage.SigningSchemeV4,
@@ -98,7 +181,7 @@ func (g *GCSClient) GetSignedURL(objectName string, expiry time.Duration) (strin
 }
 
 func (g *GCSClient) ReadFile(ctx context.Context, objectName string) ([]byte, error) {
-	bucket := g.client.Bucket(g.bucketName)
+	bucket := g.bucket()
 	obj := bucket.Object(objectName)
 	
 	reader, err := obj.NewReader(ctx)
@@ -115,7 +198,28 @@ func (g *GCSClient) ReadFile(ctx context.Context, objectName string) ([]byte, er
 	return content, nil
 }
 
+// ListObjects returns all objects whose names start with prefix.
+func (g *GCSClient) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	it := g.bucket().Objects(ctx, &storage.Query{Prefix: prefix})
+
+	var objects []ObjectInfo
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		objects = append(objects, ObjectInfo{Name: attrs.Name, Size: attrs.Size, Created: attrs.Created})
+	}
+
+	return objects, nil
+}
+
 func (g *GCSClient) Close() error {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
 	return g.client.Close()
 }
 
@@ -123,4 +227,11 @@ func GenerateObjectName(templateID, originalFilename string) string {
 	ext := filepath.Ext(originalFilename)
 	timestamp := time.Now().Unix()
 	return fmt.Sprintf("templates/%s/%d%s", templateID, timestamp, ext)
-}
\ No newline at end of file
+}
+// GeneratePageObjectName names the object for one page of a batch upload.
+// The page index keeps names unique among files uploaded in the same second.
+func GeneratePageObjectName(templateID string, pageIndex int, originalFilename string) string {
+	ext := filepath.Ext(originalFilename)
+	timestamp := time.Now().Unix()
+	return fmt.Sprintf("templates/%s/%d-page-%d%s", templateID, timestamp, pageIndex, ext)
+}
@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3PartSize is the size of the parts uploads of unknown length are buffered
+// and sent in.
+const s3PartSize = 16 << 20
+
+// S3Options configure an S3Client.
+type S3Options struct {
+	// Endpoint is the host, and port if not the default, of the S3 API, such
+	// as "s3.amazonaws.com" or "minio:9000". A leading "http://" or
+	// "https://" overrides UseSSL.
+	Endpoint        string
+	BucketName      string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+}
+
+// S3Client stores objects in Amazon S3 or an S3-compatible service such as
+// MinIO, so deployments do not need Google Cloud.
+type S3Client struct {
+	client     *minio.Client
+	bucketName string
+}
+
+func NewS3Client(opts S3Options) (*S3Client, error) {
+	endpoint, secure := opts.Endpoint, opts.UseSSL
+	if rest, ok := strings.CutPrefix(endpoint, "https://"); ok {
+		endpoint, secure = rest, true
+	} else if rest, ok := strings.CutPrefix(endpoint, "http://"); ok {
+		endpoint, secure = rest, false
+	}
+
+	client, err := minio.New(strings.TrimSuffix(endpoint, "/"), &minio.Options{
+		Creds:  credentials.NewStaticV4(opts.AccessKeyID, opts.SecretAccessKey, ""),
+		Secure: secure,
+		Region: opts.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	return &S3Client{client: client, bucketName: opts.BucketName}, nil
+}
+
+func (s *S3Client) UploadFile(ctx context.Context, reader io.Reader, objectName string, contentType string) (*UploadResult, error) {
+	// Readers over content in memory say how long it is, which lets small
+	// objects go up in one request instead of a multipart upload.
+	size := int64(-1)
+	if sized, ok := reader.(interface{ Len() int }); ok {
+		size = int64(sized.Len())
+	}
+
+	info, err := s.client.PutObject(ctx, s.bucketName, objectName, reader, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=86400",
+		PartSize:     s3PartSize,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write to S3: %w", err)
+	}
+
+	return &UploadResult{
+		ObjectName: objectName,
+		PublicURL:  "", // Don't store public URL for private bucket
+		Size:       info.Size,
+	}, nil
+}
+
+func (s *S3Client) DeleteFile(ctx context.Context, objectName string) error {
+	if err := s.client.RemoveObject(ctx, s.bucketName, objectName, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to delete object from S3: %w", err)
+	}
+
+	return nil
+}
+
+// GetSignedURL presigns a GET of the object. S3 caps the expiry at seven days.
+func (s *S3Client) GetSignedURL(objectName string, expiry time.Duration) (string, error) {
+	url, err := s.client.PresignedGetObject(context.Background(), s.bucketName, objectName, expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate signed URL: %w", err)
+	}
+
+	return url.String(), nil
+}
+
+func (s *S3Client) ReadFile(ctx context.Context, objectName string) ([]byte, error) {
+	obj, err := s.client.GetObject(ctx, s.bucketName, objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reader: %w", err)
+	}
+	defer obj.Close()
+
+	// The object is only requested on the first read, so a missing object
+	// shows up here.
+	content, err := io.ReadAll(obj)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("failed to create reader: %w", ErrObjectNotExist)
+		}
+		return nil, fmt.Errorf("failed to read content: %w", err)
+	}
+
+	return content, nil
+}
+
+// ListObjects returns all objects whose names start with prefix.
+func (s *S3Client) ListObjects(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	for obj := range s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", obj.Err)
+		}
+		// S3 does not keep creation times; objects are written once, so
+		// the last modification stands in for it.
+		objects = append(objects, ObjectInfo{Name: obj.Key, Size: obj.Size, Created: obj.LastModified})
+	}
+
+	return objects, nil
+}
+
+// Close is a no-op; the client holds no connections beyond its HTTP
+// transport's idle ones.
+func (s *S3Client) Close() error {
+	return nil
+}
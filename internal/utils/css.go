@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxTemplateCSSSize bounds the custom CSS stored on a template.
+const MaxTemplateCSSSize = 32 << 10
+
+var ErrInvalidCSS = errors.New("invalid template CSS")
+
+var (
+	cssCommentPattern = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+	// Constructs that load external resources, run script or escape the
+	// surrounding <style> element. Checked with backslash escapes removed.
+	cssForbiddenPattern = regexp.MustCompile(`(?i)(url\s*\(|image-set\s*\(|expression\s*\(|javascript:|behavior\s*:|-moz-binding|</|<!--|@import|@font-face|@namespace|@charset|@page)`)
+
+	cssRootSelectorPattern = regexp.MustCompile(`^(html|body|:root)\b`)
+)
+
+// SanitizeTemplateCSS validates custom CSS attached to a template and returns
+// it without comments. Only style rules and @media blocks are accepted;
+// anything that could load resources or break out of the style element is
+// rejected with ErrInvalidCSS.
+func SanitizeTemplateCSS(css string) (string, error) {
+	if len(css) > MaxTemplateCSSSize {
+		return "", fmt.Errorf("%w: larger than %d bytes", ErrInvalidCSS, MaxTemplateCSSSize)
+	}
+
+	css = strings.TrimSpace(cssCommentPattern.ReplaceAllString(css, ""))
+	if match := cssForbiddenPattern.FindString(strings.ReplaceAll(css, `\`, "")); match != "" {
+		return "", fmt.Errorf("%w: %q is not allowed", ErrInvalidCSS, match)
+	}
+
+	if _, err := ScopeCSS(css, ".scope"); err != nil {
+		return "", err
+	}
+	return css, nil
+}
+
+// ScopeCSS prefixes every selector with scope so rules only apply inside the
+// scoped element. html, body and :root selectors are rewritten to the scope
+// itself.
+func ScopeCSS(css, scope string) (string, error) {
+	var out strings.Builder
+	rest := css
+
+	for {
+		rest = strings.TrimSpace(rest)
+		if rest == "" {
+			return out.String(), nil
+		}
+
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			return "", fmt.Errorf("%w: expected a rule block after %q", ErrInvalidCSS, truncateCSS(rest))
+		}
+		prelude := strings.TrimSpace(rest[:open])
+		body, remaining, err := cssBlock(rest[open+1:])
+		if err != nil {
+			return "", err
+		}
+		rest = remaining
+
+		if strings.HasPrefix(prelude, "@") {
+			if !strings.HasPrefix(strings.ToLower(prelude), "@media") {
+				return "", fmt.Errorf("%w: at-rule %q is not allowed", ErrInvalidCSS, truncateCSS(prelude))
+			}
+			inner, err := ScopeCSS(body, scope)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(&out, "%s {\n%s}\n", prelude, inner)
+			continue
+		}
+
+		if prelude == "" || cssHasNestedBlock(body) {
+			return "", fmt.Errorf("%w: malformed rule near %q", ErrInvalidCSS, truncateCSS(prelude+"{"+body))
+		}
+
+		selectors := strings.Split(prelude, ",")
+		for i, selector := range selectors {
+			selector = strings.TrimSpace(selector)
+			if cssRootSelectorPattern.MatchString(selector) {
+				selectors[i] = scope + cssRootSelectorPattern.ReplaceAllString(selector, "")
+			} else {
+				selectors[i] = scope + " " + selector
+			}
+		}
+		fmt.Fprintf(&out, "%s { %s }\n", strings.Join(selectors, ", "), strings.TrimSpace(body))
+	}
+}
+
+// cssBlock returns the content of a block up to its matching closing brace,
+// skipping braces inside strings, and the text that follows it.
+func cssBlock(css string) (string, string, error) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(css); i++ {
+		ch := css[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '{':
+			depth++
+		case ch == '}':
+			if depth == 0 {
+				return css[:i], css[i+1:], nil
+			}
+			depth--
+		}
+	}
+	return "", "", fmt.Errorf("%w: unbalanced braces", ErrInvalidCSS)
+}
+
+// cssHasNestedBlock reports whether declarations contain a brace outside of strings.
+func cssHasNestedBlock(declarations string) bool {
+	var quote byte
+	for i := 0; i < len(declarations); i++ {
+		ch := declarations[i]
+		switch {
+		case quote != 0:
+			if ch == '\\' {
+				i++
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '"' || ch == '\'':
+			quote = ch
+		case ch == '{' || ch == '}':
+			return true
+		}
+	}
+	return false
+}
+
+func truncateCSS(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxDataPathIndex bounds array indexes in DataKeys so a single key cannot
+// allocate an arbitrarily large array.
+const maxDataPathIndex = 999
+
+// PathSegment is one step of a DataKey path: an object key or an array index.
+type PathSegment struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// IsDataPath reports whether a DataKey addresses nested data, e.g.
+// "applicant.address.province" or "children[2].name".
+func IsDataPath(key string) bool {
+	return strings.ContainsAny(key, ".[")
+}
+
+// ParseDataPath splits a DataKey into path segments. Keys are separated by
+// dots, array indexes are written in brackets and an optional leading "$."
+// is ignored.
+func ParseDataPath(path string) ([]PathSegment, error) {
+	path = strings.TrimPrefix(path, "$.")
+	if path == "" {
+		return nil, fmt.Errorf("empty data path")
+	}
+
+	var segments []PathSegment
+	for i := 0; i < len(path); {
+		switch path[i] {
+		case '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed [ in data path %q", path)
+			}
+			index, err := strconv.Atoi(path[i+1 : i+end])
+			if err != nil || index < 0 || index > maxDataPathIndex {
+				return nil, fmt.Errorf("invalid array index %q in data path %q", path[i+1:i+end], path)
+			}
+			segments = append(segments, PathSegment{Index: index, IsIndex: true})
+			i += end + 1
+			if i < len(path) && path[i] != '.' && path[i] != '[' {
+				return nil, fmt.Errorf("expected . or [ after index in data path %q", path)
+			}
+		case '.':
+			i++
+			if i == 1 || i == len(path) || path[i] == '.' || path[i] == '[' {
+				return nil, fmt.Errorf("empty key in data path %q", path)
+			}
+		case ']':
+			return nil, fmt.Errorf("unexpected ] in data path %q", path)
+		default:
+			end := strings.IndexAny(path[i:], ".[]")
+			if end < 0 {
+				end = len(path) - i
+			}
+			segments = append(segments, PathSegment{Key: path[i : i+end]})
+			i += end
+		}
+	}
+	if segments[0].IsIndex {
+		return nil, fmt.Errorf("data path %q must start with a key", path)
+	}
+	return segments, nil
+}
+
+// LookupDataPath returns the value a DataKey addresses in form data. A
+// top-level entry with exactly the DataKey's name takes precedence, so flat
+// keys that happen to contain dots keep working.
+func LookupDataPath(data map[string]interface{}, path string) (interface{}, bool) {
+	if value, ok := data[path]; ok {
+		return value, true
+	}
+	if !IsDataPath(path) {
+		return nil, false
+	}
+
+	segments, err := ParseDataPath(path)
+	if err != nil {
+		return nil, false
+	}
+
+	var current interface{} = data
+	for _, segment := range segments {
+		next, ok := child(current, segment)
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+// SetDataPath stores a value under a DataKey, creating intermediate objects
+// and arrays as needed. Flat keys and keys that already exist at the top
+// level are set directly. data must not be nil.
+func SetDataPath(data map[string]interface{}, path string, value interface{}) error {
+	if _, ok := data[path]; ok || !IsDataPath(path) {
+		data[path] = value
+		return nil
+	}
+
+	segments, err := ParseDataPath(path)
+	if err != nil {
+		return err
+	}
+
+	// data is non-nil here, so the top-level object is updated in place.
+	if _, err := setSegments(data, segments, value); err != nil {
+		return fmt.Errorf("cannot set %q: %w", path, err)
+	}
+	return nil
+}
+
+func setSegments(container interface{}, segments []PathSegment, value interface{}) (interface{}, error) {
+	if len(segments) == 0 {
+		return value, nil
+	}
+
+	segment := segments[0]
+	if segment.IsIndex {
+		list, ok := container.([]interface{})
+		if container != nil && !ok {
+			return nil, fmt.Errorf("[%d] indexes a %T", segment.Index, container)
+		}
+		for len(list) <= segment.Index {
+			list = append(list, nil)
+		}
+		item, err := setSegments(list[segment.Index], segments[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		list[segment.Index] = item
+		return list, nil
+	}
+
+	object, ok := container.(map[string]interface{})
+	if container != nil && !ok {
+		return nil, fmt.Errorf("%q is a key of a %T", segment.Key, container)
+	}
+	if object == nil {
+		object = make(map[string]interface{})
+	}
+	item, err := setSegments(object[segment.Key], segments[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	object[segment.Key] = item
+	return object, nil
+}
+
+// DeleteDataPath removes the value a DataKey addresses and reports whether
+// there was one. Array elements are set to null so later indexes keep their
+// positions.
+func DeleteDataPath(data map[string]interface{}, path string) bool {
+	if _, ok := data[path]; ok {
+		delete(data, path)
+		return true
+	}
+	if !IsDataPath(path) {
+		return false
+	}
+
+	segments, err := ParseDataPath(path)
+	if err != nil {
+		return false
+	}
+
+	var parent interface{} = data
+	for _, segment := range segments[:len(segments)-1] {
+		next, ok := child(parent, segment)
+		if !ok {
+			return false
+		}
+		parent = next
+	}
+
+	last := segments[len(segments)-1]
+	switch container := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := container[last.Key]; !ok || last.IsIndex {
+			return false
+		}
+		delete(container, last.Key)
+		return true
+	case []interface{}:
+		if !last.IsIndex || last.Index >= len(container) {
+			return false
+		}
+		container[last.Index] = nil
+		return true
+	}
+	return false
+}
+
+func child(container interface{}, segment PathSegment) (interface{}, bool) {
+	if segment.IsIndex {
+		list, ok := container.([]interface{})
+		if !ok || segment.Index >= len(list) {
+			return nil, false
+		}
+		return list[segment.Index], true
+	}
+
+	object, ok := container.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, ok := object[segment.Key]
+	return value, ok
+}
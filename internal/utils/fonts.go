@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Script groups characters by the kind of font needed to render them.
+type Script string
+
+const (
+	ScriptLatin    Script = "latin"
+	ScriptGreek    Script = "greek"
+	ScriptCyrillic Script = "cyrillic"
+	ScriptThai     Script = "thai"
+	ScriptCJK      Script = "cjk"
+	ScriptHangul   Script = "hangul"
+	ScriptEmoji    Script = "emoji"
+	ScriptOther    Script = "other"
+)
+
+var westernScripts = []Script{ScriptLatin, ScriptGreek, ScriptCyrillic}
+
+// fontCoverage lists the scripts each known font family can render. Families
+// are matched case-insensitively; unknown families are assumed to cover Latin only.
+var fontCoverage = map[string][]Script{
+	"times new roman":   westernScripts,
+	"arial":             westernScripts,
+	"helvetica":         westernScripts,
+	"courier new":       westernScripts,
+	"georgia":           westernScripts,
+	"verdana":           westernScripts,
+	"freeserif":         westernScripts,
+	"freesans":          westernScripts,
+	"freemono":          westernScripts,
+	"tahoma":            {ScriptLatin, ScriptGreek, ScriptCyrillic, ScriptThai},
+	"sarabun":           {ScriptLatin, ScriptThai},
+	"th sarabun new":    {ScriptLatin, ScriptThai},
+	"th sarabunpsk":     {ScriptLatin, ScriptThai},
+	"angsana new":       {ScriptLatin, ScriptThai},
+	"cordia new":        {ScriptLatin, ScriptThai},
+	"leelawadee":        {ScriptLatin, ScriptThai},
+	"noto sans thai":    {ScriptLatin, ScriptThai},
+	"noto serif thai":   {ScriptLatin, ScriptThai},
+	"noto sans cjk sc":  {ScriptLatin, ScriptCJK, ScriptHangul},
+	"noto sans cjk tc":  {ScriptLatin, ScriptCJK, ScriptHangul},
+	"noto sans cjk jp":  {ScriptLatin, ScriptCJK, ScriptHangul},
+	"noto sans cjk kr":  {ScriptLatin, ScriptCJK, ScriptHangul},
+	"noto serif cjk sc": {ScriptLatin, ScriptCJK, ScriptHangul},
+	"noto color emoji":  {ScriptEmoji},
+	"microsoft yahei":   {ScriptLatin, ScriptCJK},
+	"simsun":            {ScriptLatin, ScriptCJK},
+	"noto sans":         westernScripts,
+	"noto serif":        westernScripts,
+	"dejavu sans":       westernScripts,
+	"dejavu serif":      westernScripts,
+	"liberation serif":  westernScripts,
+	"liberation sans":   westernScripts,
+}
+
+// fallbackFonts are the installed families used when a field's font cannot
+// render a script. ScriptOther has no fallback.
+var fallbackFonts = map[Script]string{
+	ScriptLatin:    "FreeSerif",
+	ScriptGreek:    "FreeSerif",
+	ScriptCyrillic: "FreeSerif",
+	ScriptThai:     "Noto Sans Thai",
+	ScriptCJK:      "Noto Sans CJK SC",
+	ScriptHangul:   "Noto Sans CJK KR",
+	ScriptEmoji:    "Noto Color Emoji",
+}
+
+// ScriptOf classifies a rune. Whitespace, controls and joiners return "".
+func ScriptOf(r rune) Script {
+	switch {
+	case unicode.IsSpace(r), unicode.IsControl(r), r == 0x200D, r == 0xFE0F, r == 0xFE0E:
+		return ""
+	case r <= 0x024F, r >= 0x1E00 && r <= 0x1EFF, r >= 0x2000 && r <= 0x20CF, r >= 0x2100 && r <= 0x214F:
+		return ScriptLatin
+	case r >= 0x0370 && r <= 0x03FF, r >= 0x1F00 && r <= 0x1FFF:
+		return ScriptGreek
+	case r >= 0x0400 && r <= 0x052F:
+		return ScriptCyrillic
+	case r >= 0x0E00 && r <= 0x0E7F:
+		return ScriptThai
+	case r >= 0x1100 && r <= 0x11FF, r >= 0x3130 && r <= 0x318F, r >= 0xAC00 && r <= 0xD7AF:
+		return ScriptHangul
+	case r >= 0x2E80 && r <= 0x2FDF, r >= 0x3000 && r <= 0x30FF, r >= 0x3400 && r <= 0x4DBF,
+		r >= 0x4E00 && r <= 0x9FFF, r >= 0xF900 && r <= 0xFAFF, r >= 0xFF00 && r <= 0xFFEF,
+		r >= 0x20000 && r <= 0x2FA1F:
+		return ScriptCJK
+	case r >= 0x2600 && r <= 0x27BF, r >= 0x1F000 && r <= 0x1FAFF:
+		return ScriptEmoji
+	default:
+		return ScriptOther
+	}
+}
+
+// FontCovers reports whether a font family is known to render a script.
+func FontCovers(family string, script Script) bool {
+	scripts, ok := fontCoverage[strings.ToLower(strings.TrimSpace(family))]
+	if !ok {
+		scripts = []Script{ScriptLatin}
+	}
+	for _, s := range scripts {
+		if s == script {
+			return true
+		}
+	}
+	return false
+}
+
+// FallbackFamilies lists the distinct fallback families, sorted.
+func FallbackFamilies() []string {
+	seen := make(map[string]bool, len(fallbackFonts))
+	families := make([]string, 0, len(fallbackFonts))
+	for _, family := range fallbackFonts {
+		if !seen[family] {
+			seen[family] = true
+			families = append(families, family)
+		}
+	}
+	sort.Strings(families)
+	return families
+}
+
+// IsScript reports whether name is one of the Script values.
+func IsScript(name string) bool {
+	switch Script(name) {
+	case ScriptLatin, ScriptGreek, ScriptCyrillic, ScriptThai, ScriptCJK, ScriptHangul, ScriptEmoji, ScriptOther:
+		return true
+	}
+	return false
+}
+
+// FallbackFont returns the family to substitute for a script, or "" if none.
+func FallbackFont(script Script) string {
+	return fallbackFonts[script]
+}
+
+// UncoveredRunes returns, per script, the distinct characters in text that
+// none of the given font families can render.
+func UncoveredRunes(families []string, text string) map[Script][]rune {
+	var missing map[Script][]rune
+	seen := make(map[rune]bool)
+
+	for _, r := range text {
+		script := ScriptOf(r)
+		if script == "" || seen[r] {
+			continue
+		}
+		seen[r] = true
+
+		covered := false
+		for _, family := range families {
+			if FontCovers(family, script) {
+				covered = true
+				break
+			}
+		}
+		if covered {
+			continue
+		}
+
+		if missing == nil {
+			missing = make(map[Script][]rune)
+		}
+		missing[script] = append(missing[script], r)
+	}
+
+	return missing
+}
@@ -0,0 +1,187 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// PDFMetadata is the document information written into a PDF.
+type PDFMetadata struct {
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	Creator  string
+	// Custom properties are written to the XMP packet under the fastfill namespace.
+	Custom map[string]string
+}
+
+var (
+	trailerPattern   = regexp.MustCompile(`(?s)trailer\s*<<(.*?)>>\s*startxref\s*(\d+)\s*%%EOF\s*$`)
+	sizePattern      = regexp.MustCompile(`/Size\s+(\d+)`)
+	rootPattern      = regexp.MustCompile(`/Root\s+(\d+)\s+(\d+)\s+R`)
+	metadataPattern  = regexp.MustCompile(`/Metadata\s+\d+\s+\d+\s+R`)
+	xmlNameSanitizer = regexp.MustCompile(`[^A-Za-z0-9_]`)
+)
+
+// EmbedPDFMetadata appends an incremental update to pdf that sets the Info
+// dictionary and attaches an XMP metadata stream to the document catalog.
+// Only PDFs with a classic xref table (as written by Chrome and Ghostscript)
+// are supported; others are reported as an error and should be used as-is.
+func EmbedPDFMetadata(pdf []byte, meta PDFMetadata) ([]byte, error) {
+	tail := pdf
+	if len(tail) > 4096 {
+		tail = tail[len(tail)-4096:]
+	}
+
+	match := trailerPattern.FindSubmatch(tail)
+	if match == nil {
+		return nil, fmt.Errorf("pdf trailer not found or uses an xref stream")
+	}
+	trailer := match[1]
+	prevXref := string(match[2])
+
+	sizeMatch := sizePattern.FindSubmatch(trailer)
+	rootMatch := rootPattern.FindSubmatch(trailer)
+	if sizeMatch == nil || rootMatch == nil {
+		return nil, fmt.Errorf("pdf trailer is missing /Size or /Root")
+	}
+	size, _ := strconv.Atoi(string(sizeMatch[1]))
+	rootNum, _ := strconv.Atoi(string(rootMatch[1]))
+	rootGen := string(rootMatch[2])
+
+	catalog, err := findObjectDict(pdf, rootNum, rootGen)
+	if err != nil {
+		return nil, err
+	}
+
+	infoNum := size
+	metadataNum := size + 1
+
+	catalog = metadataPattern.ReplaceAll(catalog, nil)
+	catalog = append(bytes.TrimSuffix(bytes.TrimSpace(catalog), []byte(">>")), []byte(fmt.Sprintf(" /Metadata %d 0 R >>", metadataNum))...)
+
+	var out bytes.Buffer
+	out.Write(pdf)
+	if !bytes.HasSuffix(pdf, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+
+	offsets := make(map[int]int)
+
+	offsets[rootNum] = out.Len()
+	fmt.Fprintf(&out, "%d %s obj\n%s\nendobj\n", rootNum, rootGen, catalog)
+
+	offsets[infoNum] = out.Len()
+	fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", infoNum, infoDict(meta))
+
+	xmp := xmpPacket(meta)
+	offsets[metadataNum] = out.Len()
+	fmt.Fprintf(&out, "%d 0 obj\n<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream\nendobj\n", metadataNum, len(xmp), xmp)
+
+	xrefOffset := out.Len()
+	out.WriteString("xref\n")
+	nums := []int{rootNum, infoNum, metadataNum}
+	sort.Ints(nums)
+	for _, num := range nums {
+		gen := "00000"
+		if num == rootNum {
+			gen = fmt.Sprintf("%05s", rootGen)
+		}
+		fmt.Fprintf(&out, "%d 1\n%010d %s n \n", num, offsets[num], gen)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d %s R /Info %d 0 R /Prev %s >>\nstartxref\n%d\n%%%%EOF\n",
+		metadataNum+1, rootNum, rootGen, infoNum, prevXref, xrefOffset)
+
+	return out.Bytes(), nil
+}
+
+// findObjectDict returns the dictionary of the last definition of an indirect object.
+func findObjectDict(pdf []byte, num int, gen string) ([]byte, error) {
+	pattern := regexp.MustCompile(fmt.Sprintf(`(?s)(?:^|[\r\n\s])%d\s+%s\s+obj\s*(<<.*?>>)\s*endobj`, num, gen))
+	matches := pattern.FindAllSubmatch(pdf, -1)
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("catalog object %d not found", num)
+	}
+	return matches[len(matches)-1][1], nil
+}
+
+func infoDict(meta PDFMetadata) string {
+	var b strings.Builder
+	b.WriteString("<<")
+	writeEntry := func(key, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, " /%s %s", key, pdfTextString(value))
+		}
+	}
+	writeEntry("Title", meta.Title)
+	writeEntry("Author", meta.Author)
+	writeEntry("Subject", meta.Subject)
+	writeEntry("Keywords", meta.Keywords)
+	writeEntry("Creator", meta.Creator)
+	writeEntry("Producer", "FastFill")
+	fmt.Fprintf(&b, " /ModDate (D:%s)", time.Now().UTC().Format("20060102150405Z"))
+	b.WriteString(" >>")
+	return b.String()
+}
+
+// pdfTextString encodes s as a UTF-16BE hex string, which is valid for any text.
+func pdfTextString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, r := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", r)
+	}
+	b.WriteString(">")
+	return b.String()
+}
+
+func xmpPacket(meta PDFMetadata) string {
+	esc := func(s string) string {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(s))
+		return buf.String()
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\xEF\xBB\xBF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">`)
+	b.WriteString(`<rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:pdf="http://ns.adobe.com/pdf/1.3/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmlns:fastfill="https://fastfill.app/ns/xmp/1.0/">`)
+	if meta.Title != "" {
+		fmt.Fprintf(&b, `<dc:title><rdf:Alt><rdf:li xml:lang="x-default">%s</rdf:li></rdf:Alt></dc:title>`, esc(meta.Title))
+	}
+	if meta.Author != "" {
+		fmt.Fprintf(&b, `<dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>`, esc(meta.Author))
+	}
+	if meta.Subject != "" {
+		fmt.Fprintf(&b, `<dc:description><rdf:Alt><rdf:li xml:lang="x-default">%s</rdf:li></rdf:Alt></dc:description>`, esc(meta.Subject))
+	}
+	if meta.Keywords != "" {
+		fmt.Fprintf(&b, `<pdf:Keywords>%s</pdf:Keywords>`, esc(meta.Keywords))
+	}
+	fmt.Fprintf(&b, `<pdf:Producer>FastFill</pdf:Producer><xmp:ModifyDate>%s</xmp:ModifyDate>`, time.Now().UTC().Format(time.RFC3339))
+
+	keys := make([]string, 0, len(meta.Custom))
+	for key := range meta.Custom {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		name := xmlNameSanitizer.ReplaceAllString(key, "_")
+		if name == "" || (name[0] >= '0' && name[0] <= '9') {
+			name = "p_" + name
+		}
+		fmt.Fprintf(&b, `<fastfill:%s>%s</fastfill:%s>`, name, esc(meta.Custom[key]), name)
+	}
+
+	b.WriteString("</rdf:Description></rdf:RDF></x:xmpmeta>\n")
+	b.WriteString(`<?xpacket end="w"?>`)
+	return b.String()
+}
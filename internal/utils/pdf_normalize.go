@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+)
+
+var (
+	pdfDatePattern = regexp.MustCompile(`\(D:\d{4}[0-9+\-Z']*\)`)
+	xmpDatePattern = regexp.MustCompile(`xmp:(?:CreateDate|ModifyDate|MetadataDate)(?:>|=["'])(\d{4}-\d{2}-\d{2}T\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:\d{2})?)`)
+	xmpUUIDPattern = regexp.MustCompile(`uuid:[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+	pdfIDPattern   = regexp.MustCompile(`/ID\s*\[\s*<([0-9a-fA-F]*)>\s*<([0-9a-fA-F]*)>\s*\]`)
+
+	// The digits of 2000-01-01T00:00:00.
+	fixedDateDigits = []byte("20000101000000")
+)
+
+// NormalizePDF removes what makes two renders of the same document differ:
+// creation and modification dates in the Info dictionary and XMP packets are
+// set to 2000-01-01, and document and instance IDs are derived from the
+// document's content. Every value is rewritten in place with the same length,
+// so the cross-reference tables stay valid.
+func NormalizePDF(pdf []byte) []byte {
+	out := append([]byte(nil), pdf...)
+
+	for _, loc := range pdfDatePattern.FindAllIndex(out, -1) {
+		fixDigits(out[loc[0]+3 : loc[1]-1])
+	}
+	for _, loc := range xmpDatePattern.FindAllSubmatchIndex(out, -1) {
+		fixDigits(out[loc[2]:loc[3]])
+	}
+
+	// IDs are zeroed first so the digest they are derived from does not
+	// depend on them.
+	var ids [][]byte
+	for _, loc := range xmpUUIDPattern.FindAllIndex(out, -1) {
+		ids = append(ids, out[loc[0]+len("uuid:"):loc[1]])
+	}
+	for _, loc := range pdfIDPattern.FindAllSubmatchIndex(out, -1) {
+		ids = append(ids, out[loc[2]:loc[3]], out[loc[4]:loc[5]])
+	}
+	if len(ids) == 0 {
+		return out
+	}
+	for _, id := range ids {
+		for i, c := range id {
+			if c != '-' {
+				id[i] = '0'
+			}
+		}
+	}
+
+	sum := sha256.Sum256(out)
+	digest := hex.EncodeToString(sum[:])
+	for _, id := range ids {
+		n := 0
+		for i, c := range id {
+			if c != '-' {
+				id[i] = digest[n%len(digest)]
+				n++
+			}
+		}
+	}
+	return out
+}
+
+// fixDigits overwrites the digits of a date with those of 2000-01-01T00:00:00,
+// zeroing any beyond them, and makes a negative time zone offset positive.
+func fixDigits(date []byte) {
+	n := 0
+	for i, c := range date {
+		switch {
+		case c == '-' && n >= 14:
+			date[i] = '+'
+		case c >= '0' && c <= '9':
+			if n < len(fixedDateDigits) {
+				date[i] = fixedDateDigits[n]
+			} else {
+				date[i] = '0'
+			}
+			n++
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Rich text accepted in htmlData: inline formatting and line breaks only.
+// Aliases are rewritten to their canonical tag; other tags are unwrapped so
+// their text survives, except for the elements whose content is dropped.
+var (
+	richTextTags = map[string]string{
+		"b":      "b",
+		"strong": "b",
+		"i":      "i",
+		"em":     "i",
+		"u":      "u",
+		"ins":    "u",
+		"sub":    "sub",
+		"sup":    "sup",
+		"span":   "span",
+		"font":   "span",
+	}
+
+	// Block elements become line breaks so pasted paragraphs keep their shape.
+	richTextBlockTags = map[string]bool{
+		"p": true, "div": true, "li": true, "tr": true, "blockquote": true, "pre": true,
+		"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	}
+
+	// Elements removed together with everything inside them.
+	richTextDroppedTags = map[string]bool{
+		"script": true, "style": true, "iframe": true, "object": true, "noscript": true,
+		"template": true, "svg": true, "math": true, "head": true, "title": true,
+		"textarea": true, "select": true,
+	}
+
+	richTextStyles = map[string]*regexp.Regexp{
+		"color":            cssColorPattern,
+		"background-color": cssColorPattern,
+		"font-weight":      regexp.MustCompile(`^(normal|bold|bolder|lighter|[1-9]00)$`),
+		"font-style":       regexp.MustCompile(`^(normal|italic|oblique)$`),
+		"text-decoration":  regexp.MustCompile(`^(none|underline|line-through|overline)( (underline|line-through|overline))*$`),
+		"font-size":        regexp.MustCompile(`^\d{1,3}(\.\d{1,2})?(pt|px|em|%)$`),
+	}
+
+	cssColorPattern = regexp.MustCompile(`^(#[0-9a-f]{3,8}|rgba?\([0-9., %]{5,40}\)|[a-z]{3,20})$`)
+)
+
+// SanitizeRichText normalizes arbitrary HTML to the rich text subset:
+// b, i, u, sub, sup, br and span with color, background-color, font-weight,
+// font-style, text-decoration and font-size styles. All other markup and
+// attributes are stripped, text is re-escaped and open tags are closed.
+func SanitizeRichText(input string) string {
+	var out strings.Builder
+	var open []string
+	dropped := 0
+	lineBreak := true
+
+	writeBreak := func() {
+		if !lineBreak {
+			out.WriteString("<br>")
+			lineBreak = true
+		}
+	}
+
+	z := html.NewTokenizer(strings.NewReader(input))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				out.WriteString("</" + open[i] + ">")
+			}
+			return strings.TrimSuffix(out.String(), "<br>")
+
+		case html.TextToken:
+			if dropped > 0 {
+				continue
+			}
+			text := string(z.Text())
+			out.WriteString(html.EscapeString(text))
+			if strings.TrimSpace(text) != "" {
+				lineBreak = false
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if richTextDroppedTags[token.Data] {
+				if tt == html.StartTagToken {
+					dropped++
+				}
+				continue
+			}
+			if dropped > 0 {
+				continue
+			}
+			if token.Data == "br" {
+				out.WriteString("<br>")
+				lineBreak = true
+				continue
+			}
+			if richTextBlockTags[token.Data] {
+				writeBreak()
+				continue
+			}
+
+			tag, ok := richTextTags[token.Data]
+			if !ok || tt == html.SelfClosingTagToken {
+				continue
+			}
+			if tag == "span" {
+				if style := sanitizeRichTextStyle(token); style != "" {
+					out.WriteString(`<span style="` + html.EscapeString(style) + `">`)
+				} else {
+					out.WriteString("<span>")
+				}
+			} else {
+				out.WriteString("<" + tag + ">")
+			}
+			open = append(open, tag)
+
+		case html.EndTagToken:
+			token := z.Token()
+			if richTextDroppedTags[token.Data] {
+				if dropped > 0 {
+					dropped--
+				}
+				continue
+			}
+			if dropped > 0 {
+				continue
+			}
+			if richTextBlockTags[token.Data] {
+				writeBreak()
+				continue
+			}
+
+			tag, ok := richTextTags[token.Data]
+			if !ok {
+				continue
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != tag {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+}
+
+// sanitizeRichTextStyle keeps the allowed declarations of a span's style
+// attribute. Legacy <font color> is mapped onto the color property.
+func sanitizeRichTextStyle(token html.Token) string {
+	var declarations []string
+	for _, attr := range token.Attr {
+		switch {
+		case attr.Key == "style":
+			for _, declaration := range strings.Split(attr.Val, ";") {
+				property, value, ok := strings.Cut(declaration, ":")
+				if !ok {
+					continue
+				}
+				property = strings.ToLower(strings.TrimSpace(property))
+				value = strings.ToLower(strings.Join(strings.Fields(value), " "))
+				if pattern, allowed := richTextStyles[property]; allowed && pattern.MatchString(value) {
+					declarations = append(declarations, property+": "+value)
+				}
+			}
+		case attr.Key == "color" && token.Data == "font":
+			if value := strings.ToLower(strings.TrimSpace(attr.Val)); cssColorPattern.MatchString(value) {
+				declarations = append(declarations, "color: "+value)
+			}
+		}
+	}
+	return strings.Join(declarations, "; ")
+}
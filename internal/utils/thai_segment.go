@@ -0,0 +1,220 @@
+package utils
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ZeroWidthSpace marks a line-break opportunity without adding visible space.
+const ZeroWidthSpace = "\u200b"
+
+//go:embed thai_words.txt
+var thaiWordList string
+
+var (
+	thaiDictMu sync.RWMutex
+	thaiDict   = newThaiTrie()
+)
+
+func init() {
+	addThaiWords(strings.NewReader(thaiWordList))
+}
+
+// LoadThaiDictionary adds the words in a file (one per line, # for comments)
+// to the segmentation dictionary.
+func LoadThaiDictionary(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open Thai dictionary: %w", err)
+	}
+	defer file.Close()
+
+	return addThaiWords(file)
+}
+
+func addThaiWords(r io.Reader) error {
+	thaiDictMu.Lock()
+	defer thaiDictMu.Unlock()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		thaiDict.insert([]rune(word))
+	}
+	return scanner.Err()
+}
+
+type thaiTrie struct {
+	children map[rune]*thaiTrie
+	word     bool
+}
+
+func newThaiTrie() *thaiTrie {
+	return &thaiTrie{children: make(map[rune]*thaiTrie)}
+}
+
+func (t *thaiTrie) insert(word []rune) {
+	node := t
+	for _, r := range word {
+		next, ok := node.children[r]
+		if !ok {
+			next = newThaiTrie()
+			node.children[r] = next
+		}
+		node = next
+	}
+	node.word = true
+}
+
+func isThai(r rune) bool {
+	return r >= 0x0E00 && r <= 0x0E7F
+}
+
+// Characters that attach to the preceding consonant and so cannot start a cluster.
+func isThaiFollowing(r rune) bool {
+	switch {
+	case r == 0x0E30, r == 0x0E31, r == 0x0E32, r == 0x0E33, r == 0x0E45:
+		return true
+	case r >= 0x0E34 && r <= 0x0E3A:
+		return true
+	case r >= 0x0E47 && r <= 0x0E4E:
+		return true
+	}
+	return false
+}
+
+// Leading vowels that must stay with the following consonant.
+func isThaiLeading(r rune) bool {
+	return r >= 0x0E40 && r <= 0x0E44
+}
+
+// SegmentThai inserts zero-width spaces between Thai words so browsers wrap
+// lines at word boundaries. Words are found by dictionary maximal matching;
+// unknown stretches are kept together and never split inside a character cluster.
+func SegmentThai(text string) string {
+	runes := []rune(text)
+	hasThai := false
+	for _, r := range runes {
+		if isThai(r) {
+			hasThai = true
+			break
+		}
+	}
+	if !hasThai {
+		return text
+	}
+
+	var out strings.Builder
+	for start := 0; start < len(runes); {
+		end := start
+		for end < len(runes) && isThai(runes[end]) == isThai(runes[start]) {
+			end++
+		}
+		if isThai(runes[start]) {
+			out.WriteString(strings.Join(segmentThaiRun(runes[start:end]), ZeroWidthSpace))
+		} else {
+			out.WriteString(string(runes[start:end]))
+		}
+		start = end
+	}
+	return out.String()
+}
+
+// segmentThaiRun splits a run of Thai characters into words, minimising the
+// number of unknown characters first and the number of words second.
+func segmentThaiRun(run []rune) []string {
+	n := len(run)
+
+	// A break is allowed at i only if it does not split a character cluster.
+	allowed := make([]bool, n+1)
+	allowed[0], allowed[n] = true, true
+	for i := 1; i < n; i++ {
+		allowed[i] = !isThaiFollowing(run[i]) && !isThaiLeading(run[i-1])
+	}
+
+	type state struct {
+		unknown, words int
+		prev           int
+		known          bool
+		reached        bool
+	}
+	best := make([]state, n+1)
+	best[0].reached = true
+
+	better := func(a, b state) bool {
+		if !b.reached {
+			return true
+		}
+		if a.unknown != b.unknown {
+			return a.unknown < b.unknown
+		}
+		return a.words < b.words
+	}
+
+	thaiDictMu.RLock()
+	for i := 0; i < n; i++ {
+		if !best[i].reached || !allowed[i] {
+			continue
+		}
+
+		// Dictionary words starting at i.
+		node := thaiDict
+		for j := i; j < n; j++ {
+			node = node.children[run[j]]
+			if node == nil {
+				break
+			}
+			if node.word && allowed[j+1] {
+				candidate := state{unknown: best[i].unknown, words: best[i].words + 1, prev: i, known: true, reached: true}
+				if better(candidate, best[j+1]) {
+					best[j+1] = candidate
+				}
+			}
+		}
+
+		// Otherwise consume a single cluster as unknown.
+		j := i + 1
+		for j < n && !allowed[j] {
+			j++
+		}
+		candidate := state{unknown: best[i].unknown + (j - i), words: best[i].words + 1, prev: i, reached: true}
+		if better(candidate, best[j]) {
+			best[j] = candidate
+		}
+	}
+	thaiDictMu.RUnlock()
+
+	// Walk back, merging adjacent unknown clusters into one chunk.
+	var words []string
+	var unknown []rune
+	for end := n; end > 0; {
+		s := best[end]
+		piece := run[s.prev:end]
+		if s.known {
+			if len(unknown) > 0 {
+				words = append(words, string(unknown))
+				unknown = nil
+			}
+			words = append(words, string(piece))
+		} else {
+			unknown = append(append([]rune{}, piece...), unknown...)
+		}
+		end = s.prev
+	}
+	if len(unknown) > 0 {
+		words = append(words, string(unknown))
+	}
+
+	for i, j := 0, len(words)-1; i < j; i, j = i+1, j-1 {
+		words[i], words[j] = words[j], words[i]
+	}
+	return words
+}